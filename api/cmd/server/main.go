@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
 	"strconv"
@@ -21,6 +22,7 @@ import (
 	"nginx-proxy-guard/internal/database"
 	"nginx-proxy-guard/internal/handler"
 	authMiddleware "nginx-proxy-guard/internal/middleware"
+	"nginx-proxy-guard/internal/model"
 	"nginx-proxy-guard/internal/nginx"
 	"nginx-proxy-guard/internal/repository"
 	"nginx-proxy-guard/internal/scheduler"
@@ -87,6 +89,25 @@ func main() {
 	uriBlockRepo := repository.NewURIBlockRepository(db)
 	geoIPHistoryRepo := repository.NewGeoIPHistoryRepository(db.DB)
 	exploitBlockRuleRepo := repository.NewExploitBlockRuleRepository(db.DB)
+	threatFeedRepo := repository.NewThreatFeedRepository(db.DB)
+	crowdSecRepo := repository.NewCrowdSecRepository(db.DB)
+	trustedIPRepo := repository.NewTrustedIPRepository(db.DB)
+	forwardAuthRepo := repository.NewForwardAuthRepository(db)
+	oidcGateRepo := repository.NewOIDCGateRepository(db.DB)
+	clientCARepo := repository.NewClientCARepository(db.DB)
+	upstreamTLSRepo := repository.NewUpstreamTLSRepository(db.DB)
+	tlsPolicyRepo := repository.NewTLSPolicyRepository(db.DB)
+	corsPolicyRepo := repository.NewCORSPolicyRepository(db.DB)
+	signedURLRepo := repository.NewSignedURLRepository(db.DB)
+	userRepo := repository.NewUserRepository(db.DB)
+	adminSSORepo := repository.NewAdminSSORepository(db.DB)
+	ldapRepo := repository.NewLDAPRepository(db.DB)
+	webauthnRepo := repository.NewWebAuthnRepository(db.DB)
+	sessionRepo := repository.NewSessionRepository(db.DB)
+	passwordPolicyRepo := repository.NewPasswordPolicyRepository(db.DB)
+	changeRequestRepo := repository.NewChangeRequestRepository(db.DB)
+	managementRepo := repository.NewManagementRepository(db.DB)
+	realIPRepo := repository.NewRealIPRepository(db.DB)
 
 	// Wire up Valkey cache to repositories (if available)
 	if redisCache != nil {
@@ -116,10 +137,42 @@ func main() {
 
 	// Inject certificate service into proxy host service for clone operations
 	proxyHostService.SetCertificateService(certificateService)
+	proxyHostService.SetThreatFeedRepository(threatFeedRepo)
+	proxyHostService.SetForwardAuthRepository(forwardAuthRepo)
+	proxyHostService.SetOIDCGateRepository(oidcGateRepo)
+	proxyHostService.SetClientCARepository(clientCARepo)
+	proxyHostService.SetUpstreamTLSRepository(upstreamTLSRepo)
+	proxyHostService.SetTLSPolicyRepository(tlsPolicyRepo)
+	proxyHostService.SetCORSPolicyRepository(corsPolicyRepo)
+	proxyHostService.SetSignedURLRepository(signedURLRepo)
+
+	// Initialize management plane access (IP allowlist and the optional dedicated TLS listener)
+	managementService := service.NewManagementService(managementRepo, certificateRepo)
+	managementService.SetAllowlistOverride(os.Getenv("MANAGEMENT_ALLOWLIST_DISABLED") == "true")
+	managementUIUpstream := os.Getenv("MANAGEMENT_UI_UPSTREAM")
+	if managementUIUpstream == "" {
+		managementUIUpstream = "https://ui:443"
+	}
+	if err := managementService.SetUIUpstream(managementUIUpstream); err != nil {
+		log.Printf("[Startup] Warning: %v", err)
+	}
+	// X-Real-IP is only believed from the UI container, which fronts the API on the main port
+	managementTrustedProxies := os.Getenv("MANAGEMENT_TRUSTED_PROXIES")
+	if managementTrustedProxies == "" {
+		if upstream, err := url.Parse(managementUIUpstream); err == nil {
+			managementTrustedProxies = upstream.Hostname()
+		}
+	}
+	if err := service.SetManagementTrustedProxies(strings.Split(managementTrustedProxies, ",")); err != nil {
+		log.Printf("[Startup] Warning: %v", err)
+	}
 
 	// Set up certificate ready callback to regenerate nginx configs
 	// when a certificate is issued or renewed
 	certificateService.SetCertificateReadyCallback(func(ctx context.Context, certificateID string) error {
+		if err := managementService.OnCertificateReady(ctx, certificateID); err != nil {
+			log.Printf("Failed to reload management listener certificate %s: %v", certificateID, err)
+		}
 		log.Printf("Certificate %s is ready, regenerating nginx configs for affected proxy hosts", certificateID)
 		return proxyHostService.RegenerateConfigsForCertificate(ctx, certificateID)
 	})
@@ -128,6 +181,31 @@ func main() {
 	startupCtx, startupCancel := context.WithTimeout(context.Background(), config.ContextTimeout)
 	defer startupCancel()
 
+	// Initialize trusted proxy real IP configuration (set_real_ip_from from cloud provider ranges and custom CIDRs)
+	// PROXY protocol listeners must be known before any server config is generated
+	realIPService := service.NewRealIPService(realIPRepo, cloudProviderRepo, nginxManager)
+	if err := realIPService.LoadListeners(startupCtx); err != nil {
+		log.Printf("[Startup] Warning: failed to load PROXY protocol listeners: %v", err)
+	}
+	realIPService.SetListenersChangedCallback(func(ctx context.Context) error {
+		log.Println("[RealIP] PROXY protocol listeners changed, regenerating all server configs")
+		hosts, _, err := redirectHostRepo.List(ctx, 1, config.MaxWAFRulesLimit)
+		if err != nil {
+			return err
+		}
+		if err := nginxManager.GenerateAllRedirectConfigs(ctx, hosts); err != nil {
+			return err
+		}
+		action := "allow"
+		if settings, err := globalSettingsRepo.Get(ctx); err == nil && settings != nil {
+			action = settings.DirectIPAccessAction
+		}
+		if err := nginxManager.GenerateDefaultServerConfig(ctx, action); err != nil {
+			return err
+		}
+		return proxyHostService.SyncAllConfigs(ctx)
+	})
+
 	// Sync all proxy host configs on startup to apply any template changes
 	log.Println("[Startup] Syncing all proxy host configs...")
 	if err := proxyHostService.SyncAllConfigs(startupCtx); err != nil {
@@ -169,9 +247,32 @@ func main() {
 	// Initialize challenge service
 	challengeService := service.NewChallengeService(challengeRepo)
 
+	// Initialize OIDC login gate service (session cookies are signed with the JWT secret)
+	oidcGateService := service.NewOIDCGateService(oidcGateRepo, cfg.JWTSecret)
+
 	// Initialize auth service
 	authService := service.NewAuthServiceWithCache(authRepo, cfg.JWTSecret, redisCache)
 
+	// Initialize user administration service
+	userService := service.NewUserService(userRepo, authRepo, webauthnRepo)
+
+	// Initialize admin single sign-on (shares the OIDC code flow with the login gate)
+	adminSSOService := service.NewAdminSSOService(adminSSORepo, userRepo, authRepo, oidcGateService, authService)
+	authService.SetLocalLoginPolicy(adminSSOService)
+
+	// Initialize LDAP / Active Directory authentication (next to local accounts)
+	ldapService := service.NewLDAPService(ldapRepo, userRepo, authRepo)
+	authService.SetDirectoryAuthenticator(ldapService)
+
+	// Security keys and passkeys
+	webauthnService := service.NewWebAuthnService(webauthnRepo, authRepo, authService)
+	authService.SetSecondFactorPolicy(webauthnService)
+
+	// Password policy, login lockout and breached password check
+	passwordPolicyService := service.NewPasswordPolicyService(passwordPolicyRepo, authRepo)
+	authService.SetPasswordRules(passwordPolicyService)
+	userService.SetPasswordRules(passwordPolicyService)
+
 	// Initialize Docker stats service
 	dockerStatsService := service.NewDockerStatsService()
 
@@ -186,10 +287,13 @@ func main() {
 	wafTestHandler := handler.NewWAFTestHandler()
 	wafHandler := handler.NewWAFHandler(wafRepo, proxyHostRepo, geoRepo, nginxManager)
 	exploitBlockRuleHandler := handler.NewExploitBlockRuleHandler(exploitBlockRuleRepo, proxyHostRepo, proxyHostService)
-	accessListHandler := handler.NewAccessListHandler(accessListRepo)
+	accessListHandler := handler.NewAccessListHandler(accessListRepo, proxyHostService, nginxManager)
 	redirectHostHandler := handler.NewRedirectHostHandler(redirectHostRepo, nginxManager, auditService)
 	geoHandler := handler.NewGeoHandler(geoRepo, proxyHostRepo, nginxManager, accessListRepo, rateLimitRepo, securityHeadersRepo, botFilterRepo, upstreamRepo)
 	securityHandler := handler.NewSecurityHandler(rateLimitRepo, botFilterRepo, securityHeadersRepo, upstreamRepo, proxyHostRepo, proxyHostService, auditService, redisCache, ipBanHistoryRepo, uriBlockRepo, nginxReloader)
+	banEscalationService := service.NewBanEscalationService(systemSettingsRepo, ipBanHistoryRepo)
+	securityHandler.SetBanEscalation(banEscalationService)
+	banAggregationService := service.NewBanAggregationService(rateLimitRepo, systemSettingsRepo, ipBanHistoryRepo, redisCache)
 	settingsHandler := handler.NewSettingsHandler(globalSettingsRepo, dashboardRepo, backupRepo, proxyHostRepo, redirectHostRepo, certificateRepo, wafRepo, nginxManager, cfg.BackupPath, auditService, dockerStatsService, proxyHostService, redisCache)
 	systemLogHandler := handler.NewSystemLogHandler(systemLogRepo)
 	authHandler := handler.NewAuthHandler(authService, auditService)
@@ -197,16 +301,61 @@ func main() {
 	geoIPService := service.NewGeoIPServiceWithCache(redisCache)
 	defer geoIPService.Close()
 
+	// Session list and idle / lifetime policy
+	sessionService := service.NewSessionService(sessionRepo, authRepo, authService, geoIPService)
+	authService.SetSessionPolicy(sessionService)
+
+	if err := realIPService.Apply(startupCtx); err != nil {
+		log.Printf("Warning: Failed to apply real IP settings: %v", err)
+	}
+
 	// Initialize Cloud Provider service for auto-seeding and IP range updates
 	cloudProviderService := service.NewCloudProviderService(cloudProviderRepo)
 	// Set up callback to regenerate nginx configs when cloud provider IP ranges are updated
 	cloudProviderService.SetIPRangesUpdatedCallback(func(ctx context.Context, updatedProviders []string) error {
 		log.Printf("[CloudProvider] IP ranges updated for %v, regenerating affected nginx configs", updatedProviders)
+		if err := realIPService.OnCloudProvidersUpdated(ctx, updatedProviders); err != nil {
+			log.Printf("[RealIP] Failed to refresh trusted proxy ranges: %v", err)
+		}
 		return proxyHostService.RegenerateConfigsForCloudProviders(ctx, updatedProviders)
 	})
 	cloudProviderService.Start()
 	defer cloudProviderService.Stop()
 
+	// Initialize threat feed service for scheduled blocklist downloads
+	threatFeedService := service.NewThreatFeedService(threatFeedRepo)
+	if err := threatFeedService.SeedDefaultFeeds(startupCtx); err != nil {
+		log.Printf("Warning: Failed to seed threat feeds: %v", err)
+	}
+	threatFeedService.SetFeedsUpdatedCallback(func(ctx context.Context, updatedFeeds []string) error {
+		log.Printf("[ThreatFeed] Feeds updated %v, regenerating affected nginx configs", updatedFeeds)
+		return proxyHostService.RegenerateConfigsForThreatFeeds(ctx, updatedFeeds)
+	})
+	threatFeedService.Start()
+	defer threatFeedService.Stop()
+
+	// Initialize CrowdSec bouncer (pulls LAPI decisions into the shared banned IPs include)
+	crowdSecService := service.NewCrowdSecService(crowdSecRepo, nginxManager)
+	crowdSecService.Start()
+	defer crowdSecService.Stop()
+
+	// Initialize trusted IP allowlist (renders the global never-ban list and drops expired entries)
+	trustedIPService := service.NewTrustedIPService(trustedIPRepo, nginxManager)
+	securityHandler.SetTrustedIPs(trustedIPService)
+	trustedIPService.Start()
+	defer trustedIPService.Stop()
+
+	// Initialize two-person approval (holds configured high-risk changes until reviewed, expires stale requests)
+	changeRequestService := service.NewChangeRequestService(changeRequestRepo)
+	settingsHandler.SetApprovals(changeRequestService)
+	certificateHandler.SetApprovals(changeRequestService)
+	proxyHostHandler.SetApprovals(changeRequestService, wafHandler)
+	changeRequestService.SetExpiredCallback(func(ctx context.Context, cr *model.ChangeRequest) {
+		auditService.LogChangeExpired(ctx, cr)
+	})
+	changeRequestService.Start()
+	defer changeRequestService.Stop()
+
 	// Initialize GeoIP scheduler for automatic updates
 	geoIPScheduler := service.NewGeoIPScheduler(systemSettingsRepo, geoIPHistoryRepo, geoIPService)
 	geoIPScheduler.SetCloudProviderService(cloudProviderService) // Wire for seeding on GeoIP update
@@ -218,6 +367,25 @@ func main() {
 	auditLogHandler := handler.NewAuditLogHandler(auditLogRepo, apiTokenRepo)
 	challengeHandler := handler.NewChallengeHandler(challengeService, auditService)
 	cloudProviderHandler := handler.NewCloudProviderHandler(cloudProviderRepo, proxyHostService, auditService)
+	threatFeedHandler := handler.NewThreatFeedHandler(threatFeedRepo, threatFeedService, proxyHostService, auditService)
+	crowdSecHandler := handler.NewCrowdSecHandler(crowdSecRepo, crowdSecService, auditService)
+	trustedIPHandler := handler.NewTrustedIPHandler(trustedIPRepo, trustedIPService, auditService)
+	forwardAuthHandler := handler.NewForwardAuthHandler(forwardAuthRepo, proxyHostRepo, geoRepo, oidcGateRepo, proxyHostService, auditService)
+	oidcGateHandler := handler.NewOIDCGateHandler(oidcGateRepo, oidcGateService, proxyHostRepo, forwardAuthRepo, geoRepo, proxyHostService, auditService)
+	clientCAHandler := handler.NewClientCAHandler(clientCARepo, proxyHostRepo, proxyHostService, nginxManager, auditService)
+	tlsPolicyHandler := handler.NewTLSPolicyHandler(tlsPolicyRepo, proxyHostRepo, proxyHostService, auditService)
+	corsPolicyHandler := handler.NewCORSPolicyHandler(corsPolicyRepo, proxyHostRepo, proxyHostService, auditService)
+	signedURLHandler := handler.NewSignedURLHandler(signedURLRepo, proxyHostRepo, proxyHostService, auditService)
+	userHandler := handler.NewUserHandler(userService, auditService)
+	adminSSOHandler := handler.NewAdminSSOHandler(adminSSOService, auditService)
+	ldapHandler := handler.NewLDAPHandler(ldapService, auditService)
+	webauthnHandler := handler.NewWebAuthnHandler(webauthnService, auditService)
+	sessionHandler := handler.NewSessionHandler(sessionService, auditService)
+	passwordPolicyHandler := handler.NewPasswordPolicyHandler(passwordPolicyService, auditService)
+	changeRequestHandler := handler.NewChangeRequestHandler(changeRequestService, auditService)
+	managementHandler := handler.NewManagementHandler(managementService, auditService)
+	realIPHandler := handler.NewRealIPHandler(realIPService, auditService)
+	upstreamTLSHandler := handler.NewUpstreamTLSHandler(upstreamTLSRepo, proxyHostRepo, upstreamRepo, certificateRepo, proxyHostService, auditService)
 
 	// Initialize log collector (with Redis buffer if available)
 	var logCollector *service.LogCollector
@@ -227,12 +395,20 @@ func main() {
 
 	// Initialize WAF Auto-Ban service
 	wafAutoBanService := service.NewWAFAutoBanService(db.DB, systemSettingsRepo, rateLimitRepo, proxyHostRepo, proxyHostService, ipBanHistoryRepo)
+	wafAutoBanService.SetBanNotifier(crowdSecService)
+	wafAutoBanService.SetBanEscalation(banEscalationService)
+	wafAutoBanService.SetBanAggregation(banAggregationService)
+	wafAutoBanService.SetTrustedIPs(trustedIPService)
 	if logCollector != nil {
 		logCollector.SetWAFAutoBanService(wafAutoBanService)
 	}
 
 	// Initialize Fail2ban service
 	fail2banService := service.NewFail2banService(db.DB, rateLimitRepo, proxyHostRepo, proxyHostService, redisCache, ipBanHistoryRepo)
+	fail2banService.SetBanNotifier(crowdSecService)
+	fail2banService.SetBanEscalation(banEscalationService)
+	fail2banService.SetBanAggregation(banAggregationService)
+	fail2banService.SetTrustedIPs(trustedIPService)
 	if logCollector != nil {
 		logCollector.SetFail2banService(fail2banService)
 		logCollector.SetProxyHostRepo(proxyHostRepo)
@@ -245,6 +421,7 @@ func main() {
 		6*time.Hour,
 		30,
 	)
+	renewalScheduler.SetRequiredCertificates(managementService.RenewalCertificates)
 	renewalScheduler.Start()
 
 	// Initialize partition scheduler (creates monthly partitions for logs/stats)
@@ -346,6 +523,9 @@ func main() {
 		e.Use(middleware.RateLimiter(middleware.NewRateLimiterMemoryStore(rate.Limit(rateLimit))))
 	}
 
+	// Management plane allowlist (health, challenge, OIDC gate and public routes stay open)
+	e.Use(authMiddleware.ManagementAccess(managementService))
+
 	// Health check (uses db and cache for actual health check)
 	startTime := time.Now()
 	e.GET("/health", func(c echo.Context) error {
@@ -398,6 +578,17 @@ func main() {
 		auth.POST("/logout", authHandler.Logout)
 		auth.GET("/status", authHandler.GetStatus)
 		auth.POST("/verify-2fa", authHandler.Verify2FA)
+		auth.POST("/accept-invite", userHandler.AcceptInvite)
+		auth.GET("/sso/status", adminSSOHandler.Status)      // Whether to offer SSO and password login
+		auth.GET("/sso/login", adminSSOHandler.Login)        // Redirect to the identity provider
+		auth.GET("/sso/callback", adminSSOHandler.Callback)  // Complete login and redirect with a one-time code
+		auth.POST("/sso/exchange", adminSSOHandler.Exchange) // Redeem the one-time code for a session
+
+		auth.GET("/webauthn/status", webauthnHandler.Status)                      // Whether to offer passkey login
+		auth.POST("/webauthn/login/begin", webauthnHandler.BeginLogin)            // Security key step of a password login
+		auth.POST("/webauthn/login/finish", webauthnHandler.FinishLogin)          // Verify the key and create the session
+		auth.POST("/webauthn/passkey/begin", webauthnHandler.BeginPasskeyLogin)   // Passwordless login
+		auth.POST("/webauthn/passkey/finish", webauthnHandler.FinishPasskeyLogin) // Verify the passkey and create the session
 	}
 
 	// Challenge routes (public - for GeoIP blocked users)
@@ -409,6 +600,15 @@ func main() {
 		challenge.GET("/favicon.ico", handler.ServeFavicon)             // Serve favicon for challenge pages
 	}
 
+	// OIDC login gate routes (public - proxied by nginx on protected hosts)
+	oidc := v1.Group("/oidc")
+	{
+		oidc.GET("/login", oidcGateHandler.Login)       // Redirect to the identity provider
+		oidc.GET("/callback", oidcGateHandler.Callback) // Complete login and set session cookie
+		oidc.GET("/validate", oidcGateHandler.Validate) // For nginx auth_request
+		oidc.GET("/logout", oidcGateHandler.Logout)     // Clear session cookie
+	}
+
 	// Public UI settings (no auth required) - for welcome page, 403 page, etc.
 	v1.GET("/public/ui-settings", systemSettingsHandler.GetPublicUISettings)
 
@@ -425,6 +625,14 @@ func main() {
 		protected.POST("/auth/2fa/setup", authHandler.Setup2FA)
 		protected.POST("/auth/2fa/enable", authHandler.Enable2FA)
 		protected.POST("/auth/2fa/disable", authHandler.Disable2FA)
+		protected.GET("/auth/webauthn/credentials", webauthnHandler.ListCredentials)
+		protected.POST("/auth/webauthn/register/begin", webauthnHandler.BeginRegistration)
+		protected.POST("/auth/webauthn/register/finish", webauthnHandler.FinishRegistration)
+		protected.PUT("/auth/webauthn/credentials/:id", webauthnHandler.RenameCredential)
+		protected.DELETE("/auth/webauthn/credentials/:id", webauthnHandler.DeleteCredential)
+		protected.GET("/auth/sessions", sessionHandler.ListOwn)
+		protected.DELETE("/auth/sessions", sessionHandler.RevokeOtherOwn)
+		protected.DELETE("/auth/sessions/:id", sessionHandler.RevokeOwn)
 		protected.GET("/auth/language", authHandler.GetLanguage)
 		protected.PUT("/auth/language", authHandler.SetLanguage)
 		protected.GET("/auth/font", authHandler.GetFontFamily)
@@ -453,7 +661,69 @@ func main() {
 	// All other routes require authentication (JWT or API Token)
 	v1.Use(authMiddleware.APITokenAuth(apiTokenRepo, auditLogRepo)) // Check API token first
 	v1.Use(authMiddleware.AuthMiddleware(authService))              // Then JWT
+	v1.Use(authMiddleware.Authorize())                              // Then role / token permissions per route
+	v1.Use(authMiddleware.TokenScope(proxyHostRepo))                // Then API token resource scope
 	{
+		// User administration routes
+		users := v1.Group("/users")
+		{
+			users.GET("", userHandler.List)
+			users.POST("", userHandler.Create)
+			users.GET("/roles", userHandler.ListRoles)
+			users.POST("/invite", userHandler.Invite)
+			users.GET("/:id", userHandler.Get)
+			users.PUT("/:id/role", userHandler.UpdateRole)
+			users.POST("/:id/disable", userHandler.Disable)
+			users.POST("/:id/enable", userHandler.Enable)
+			users.POST("/:id/reset-2fa", userHandler.Reset2FA)
+			users.GET("/:id/sessions", sessionHandler.ListUser)
+			users.DELETE("/:id/sessions", sessionHandler.RevokeAllUser)
+			users.DELETE("/:id/sessions/:sessionId", sessionHandler.RevokeUser)
+			users.POST("/:id/unlock", passwordPolicyHandler.Unlock)
+			users.DELETE("/:id", userHandler.Delete)
+		}
+
+		// Admin single sign-on settings
+		v1.GET("/admin-sso", adminSSOHandler.GetSettings)
+		v1.PUT("/admin-sso", adminSSOHandler.UpdateSettings)
+
+		// LDAP / Active Directory authentication settings
+		v1.GET("/ldap", ldapHandler.GetSettings)
+		v1.PUT("/ldap", ldapHandler.UpdateSettings)
+		v1.POST("/ldap/test", ldapHandler.TestConnection)
+
+		// Security key and passkey settings
+		v1.GET("/webauthn", webauthnHandler.GetSettings)
+		v1.PUT("/webauthn", webauthnHandler.UpdateSettings)
+
+		// Session idle timeout and lifetime
+		v1.GET("/session-settings", sessionHandler.GetSettings)
+		v1.PUT("/session-settings", sessionHandler.UpdateSettings)
+
+		// Password policy, lockout and breached password list
+		v1.GET("/password-policy", passwordPolicyHandler.GetSettings)
+		v1.PUT("/password-policy", passwordPolicyHandler.UpdateSettings)
+		v1.GET("/password-policy/breach-list", passwordPolicyHandler.GetBreachList)
+		v1.POST("/password-policy/breach-list", passwordPolicyHandler.UploadBreachList)
+		v1.DELETE("/password-policy/breach-list", passwordPolicyHandler.DeleteBreachList)
+
+		// Two-person approval: settings and review of change requests
+		v1.GET("/approval-policy", changeRequestHandler.GetSettings)
+		v1.PUT("/approval-policy", changeRequestHandler.UpdateSettings)
+		changeRequests := v1.Group("/change-requests")
+		{
+			changeRequests.GET("", changeRequestHandler.List)
+			changeRequests.GET("/:id", changeRequestHandler.Get)
+			changeRequests.POST("/:id/approve", changeRequestHandler.Approve)
+			changeRequests.POST("/:id/reject", changeRequestHandler.Reject)
+			changeRequests.POST("/:id/cancel", changeRequestHandler.Cancel)
+		}
+
+		// Management plane access: IP allowlist and dedicated listener
+		v1.GET("/management", managementHandler.GetSettings)
+		v1.PUT("/management", managementHandler.UpdateSettings)
+		v1.GET("/management/status", managementHandler.GetStatus)
+
 		// API Token management routes
 		apiTokens := v1.Group("/api-tokens")
 		{
@@ -478,6 +748,7 @@ func main() {
 			proxyHosts.PUT("/:id", proxyHostHandler.Update)
 			proxyHosts.DELETE("/:id", proxyHostHandler.Delete)
 			proxyHosts.POST("/:id/test", proxyHostHandler.TestHost)
+			proxyHosts.POST("/:id/test-upstream", proxyHostHandler.TestUpstream)
 			proxyHosts.POST("/:id/clone", proxyHostHandler.Clone)
 		}
 
@@ -552,10 +823,10 @@ func main() {
 			waf.GET("/hosts/:id/history", echo.WrapHandler(http.HandlerFunc(wafHandler.GetPolicyHistory)))
 
 			// Disable a rule for a proxy host
-			waf.POST("/hosts/:id/rules/:ruleId/disable", echo.WrapHandler(http.HandlerFunc(wafHandler.DisableRule)))
+			waf.POST("/hosts/:id/rules/:ruleId/disable", wafHandler.DisableRuleWithApproval)
 
 			// Disable a rule by host domain name (used from log viewer)
-			waf.POST("/rules/disable-by-host", echo.WrapHandler(http.HandlerFunc(wafHandler.DisableRuleByHost)))
+			waf.POST("/rules/disable-by-host", wafHandler.DisableRuleByHostWithApproval)
 
 			// Enable a rule for a proxy host (remove exclusion)
 			waf.DELETE("/hosts/:id/rules/:ruleId/disable", echo.WrapHandler(http.HandlerFunc(wafHandler.EnableRule)))
@@ -654,6 +925,7 @@ func main() {
 			bannedIPs.GET("/history", securityHandler.GetIPBanHistory)
 			bannedIPs.GET("/history/stats", securityHandler.GetIPBanHistoryStats)
 			bannedIPs.GET("/history/ip/:ip", securityHandler.GetIPBanHistoryByIP)
+			bannedIPs.GET("/history/ip/:ip/escalation", securityHandler.GetIPBanEscalation)
 		}
 
 		// Bot Filter routes (per proxy host)
@@ -791,6 +1063,100 @@ func main() {
 		v1.GET("/proxy-hosts/:proxyHostId/blocked-cloud-providers", cloudProviderHandler.GetBlockedProviders)
 		v1.PUT("/proxy-hosts/:proxyHostId/blocked-cloud-providers", cloudProviderHandler.SetBlockedProviders)
 
+		// Threat intelligence feed routes
+		threatFeeds := v1.Group("/threat-feeds")
+		{
+			threatFeeds.GET("", threatFeedHandler.ListFeeds)
+			threatFeeds.GET("/stats", threatFeedHandler.GetMatchStats)
+			threatFeeds.GET("/:slug", threatFeedHandler.GetFeed)
+			threatFeeds.POST("", threatFeedHandler.CreateFeed)
+			threatFeeds.PUT("/:slug", threatFeedHandler.UpdateFeed)
+			threatFeeds.DELETE("/:slug", threatFeedHandler.DeleteFeed)
+			threatFeeds.POST("/:slug/refresh", threatFeedHandler.RefreshFeed)
+		}
+		// Per proxy host threat feed subscriptions
+		v1.GET("/proxy-hosts/:proxyHostId/threat-feeds", threatFeedHandler.GetHostFeeds)
+		v1.PUT("/proxy-hosts/:proxyHostId/threat-feeds", threatFeedHandler.SetHostFeeds)
+
+		// Per proxy host forward auth (Authelia, Authentik, oauth2-proxy, ...)
+		v1.GET("/proxy-hosts/:proxyHostId/forward-auth", forwardAuthHandler.Get)
+		v1.PUT("/proxy-hosts/:proxyHostId/forward-auth", forwardAuthHandler.Upsert)
+		v1.DELETE("/proxy-hosts/:proxyHostId/forward-auth", forwardAuthHandler.Delete)
+
+		// OIDC identity providers and per proxy host login gate
+		oidcProviders := v1.Group("/oidc-providers")
+		{
+			oidcProviders.GET("", oidcGateHandler.ListProviders)
+			oidcProviders.POST("", oidcGateHandler.CreateProvider)
+			oidcProviders.PUT("/:id", oidcGateHandler.UpdateProvider)
+			oidcProviders.DELETE("/:id", oidcGateHandler.DeleteProvider)
+		}
+		v1.GET("/proxy-hosts/:proxyHostId/oidc-gate", oidcGateHandler.GetGate)
+		v1.PUT("/proxy-hosts/:proxyHostId/oidc-gate", oidcGateHandler.UpsertGate)
+		v1.DELETE("/proxy-hosts/:proxyHostId/oidc-gate", oidcGateHandler.DeleteGate)
+
+		// Client CAs and per proxy host client certificate authentication (mTLS)
+		clientCAs := v1.Group("/client-cas")
+		{
+			clientCAs.GET("", clientCAHandler.List)
+			clientCAs.POST("", clientCAHandler.Create)
+			clientCAs.GET("/:id", clientCAHandler.Get)
+			clientCAs.PUT("/:id", clientCAHandler.Update)
+			clientCAs.DELETE("/:id", clientCAHandler.Delete)
+		}
+		v1.GET("/proxy-hosts/:proxyHostId/client-cert-auth", clientCAHandler.GetAuth)
+		v1.PUT("/proxy-hosts/:proxyHostId/client-cert-auth", clientCAHandler.UpsertAuth)
+		v1.DELETE("/proxy-hosts/:proxyHostId/client-cert-auth", clientCAHandler.DeleteAuth)
+
+		// Backend TLS verification and client certificate per proxy host
+		v1.GET("/proxy-hosts/:proxyHostId/upstream-tls", upstreamTLSHandler.Get)
+		v1.PUT("/proxy-hosts/:proxyHostId/upstream-tls", upstreamTLSHandler.Upsert)
+		v1.DELETE("/proxy-hosts/:proxyHostId/upstream-tls", upstreamTLSHandler.Delete)
+
+		// TLS policy profiles per proxy host
+		v1.GET("/tls-policies/profiles", tlsPolicyHandler.ListProfiles)
+		v1.GET("/proxy-hosts/:proxyHostId/tls-policy", tlsPolicyHandler.Get)
+		v1.PUT("/proxy-hosts/:proxyHostId/tls-policy", tlsPolicyHandler.Upsert)
+		v1.DELETE("/proxy-hosts/:proxyHostId/tls-policy", tlsPolicyHandler.Delete)
+
+		// CORS policies per proxy host and path prefix
+		v1.GET("/cors-policies/presets", corsPolicyHandler.ListPresets)
+		v1.GET("/proxy-hosts/:proxyHostId/cors-policies", corsPolicyHandler.List)
+		v1.PUT("/proxy-hosts/:proxyHostId/cors-policies", corsPolicyHandler.Upsert)
+		v1.DELETE("/proxy-hosts/:proxyHostId/cors-policies/:policyId", corsPolicyHandler.Delete)
+
+		// Signed URL (secure_link) protected paths and link minting per proxy host
+		v1.GET("/proxy-hosts/:proxyHostId/signed-url-locations", signedURLHandler.ListLocations)
+		v1.PUT("/proxy-hosts/:proxyHostId/signed-url-locations", signedURLHandler.UpsertLocation)
+		v1.DELETE("/proxy-hosts/:proxyHostId/signed-url-locations/:locationId", signedURLHandler.DeleteLocation)
+		v1.POST("/proxy-hosts/:proxyHostId/signed-urls", signedURLHandler.Mint)
+
+		// CrowdSec bouncer and signal sharing routes
+		crowdSec := v1.Group("/crowdsec")
+		{
+			crowdSec.GET("/settings", crowdSecHandler.GetSettings)
+			crowdSec.PUT("/settings", crowdSecHandler.UpdateSettings)
+			crowdSec.POST("/test", crowdSecHandler.TestConnection)
+			crowdSec.POST("/sync", crowdSecHandler.Sync)
+		}
+
+		// Global trusted IP allowlist routes
+		trustedIPs := v1.Group("/trusted-ips")
+		{
+			trustedIPs.GET("", trustedIPHandler.List)
+			trustedIPs.GET("/report", trustedIPHandler.GetReport)
+			trustedIPs.POST("", trustedIPHandler.Create)
+			trustedIPs.PUT("/:id", trustedIPHandler.Update)
+			trustedIPs.DELETE("/:id", trustedIPHandler.Delete)
+		}
+
+		// Trusted proxy / CDN real IP routes
+		realIP := v1.Group("/real-ip")
+		{
+			realIP.GET("/settings", realIPHandler.GetSettings)
+			realIP.PUT("/settings", realIPHandler.UpdateSettings)
+		}
+
 		// Test endpoints (Phase 1 + Phase 7)
 		test := v1.Group("/test")
 		{
@@ -891,9 +1257,13 @@ func main() {
 		dockerLogCollector.Stop()
 		renewalScheduler.Stop()
 		partitionScheduler.Stop()
+		managementService.Stop()
 		e.Close()
 	}()
 
+	// Start the dedicated management listener, if enabled, serving the same routes
+	managementService.Start(e)
+
 	log.Printf("Starting server on port %s", port)
 	if err := e.Start(":" + port); err != nil && err != http.ErrServerClosed {
 		log.Fatalf("Server error: %v", err)
@@ -68,6 +68,10 @@ func (db *DB) RunMigrations() error {
 		ALTER TYPE public.block_reason ADD VALUE IF NOT EXISTS 'cloud_provider_block';
 		ALTER TYPE public.block_reason ADD VALUE IF NOT EXISTS 'uri_block';
 		ALTER TYPE public.block_reason ADD VALUE IF NOT EXISTS 'access_denied';
+		ALTER TYPE public.block_reason ADD VALUE IF NOT EXISTS 'threat_feed_block';
+		ALTER TYPE public.block_reason ADD VALUE IF NOT EXISTS 'threat_feed_challenge';
+		ALTER TYPE public.block_reason ADD VALUE IF NOT EXISTS 'client_cert';
+		ALTER TYPE public.block_reason ADD VALUE IF NOT EXISTS 'signed_url';
 
 		-- Column upgrades
 		ALTER TABLE public.proxy_hosts ADD COLUMN IF NOT EXISTS cache_static_only boolean DEFAULT true NOT NULL;
@@ -82,6 +86,25 @@ func (db *DB) RunMigrations() error {
 		ALTER TABLE public.proxy_hosts ADD COLUMN IF NOT EXISTS proxy_request_buffering character varying(10) DEFAULT '';
 		ALTER TABLE public.proxy_hosts ADD COLUMN IF NOT EXISTS client_max_body_size character varying(20) DEFAULT '';
 		ALTER TABLE public.proxy_hosts ADD COLUMN IF NOT EXISTS proxy_max_temp_file_size character varying(20) DEFAULT '';
+		ALTER TABLE public.proxy_hosts ADD COLUMN IF NOT EXISTS proxy_protocol boolean DEFAULT false NOT NULL;
+
+		-- Ban decision origin (local or crowdsec)
+		ALTER TABLE public.banned_ips ADD COLUMN IF NOT EXISTS origin character varying(20) DEFAULT 'local' NOT NULL;
+		CREATE INDEX IF NOT EXISTS idx_banned_ips_origin ON public.banned_ips USING btree (origin);
+
+		-- Ban escalation for repeat offenders
+		ALTER TABLE public.system_settings ADD COLUMN IF NOT EXISTS ban_escalation_enabled boolean DEFAULT false;
+		ALTER TABLE public.system_settings ADD COLUMN IF NOT EXISTS ban_escalation_steps integer[] DEFAULT ARRAY[3600, 86400, 604800, 0];
+		ALTER TABLE public.system_settings ADD COLUMN IF NOT EXISTS ban_escalation_window integer DEFAULT 2592000;
+		ALTER TABLE public.ip_ban_history ADD COLUMN IF NOT EXISTS escalation_level integer;
+		ALTER TABLE public.ip_ban_history ADD COLUMN IF NOT EXISTS escalation_reason text;
+
+		-- Subnet aggregation of auto-bans
+		ALTER TABLE public.system_settings ADD COLUMN IF NOT EXISTS ban_subnet_aggregation_enabled boolean DEFAULT false;
+		ALTER TABLE public.system_settings ADD COLUMN IF NOT EXISTS ban_subnet_threshold integer DEFAULT 5;
+		ALTER TABLE public.system_settings ADD COLUMN IF NOT EXISTS ban_subnet_ipv4_prefix integer DEFAULT 24;
+		ALTER TABLE public.system_settings ADD COLUMN IF NOT EXISTS ban_subnet_ipv6_prefix integer DEFAULT 64;
+		ALTER TABLE public.system_settings ADD COLUMN IF NOT EXISTS ban_subnet_window integer DEFAULT 3600;
 
 		-- Default exploit block rules (seed if not exists)
 		INSERT INTO public.exploit_block_rules (id, category, name, pattern, pattern_type, description, severity, enabled, is_system, sort_order) VALUES
@@ -128,6 +151,413 @@ func (db *DB) RunMigrations() error {
 				FOREIGN KEY (access_list_id) REFERENCES public.access_lists(id) ON DELETE SET NULL;
 			END IF;
 		END $$;
+
+		-- Threat intelligence feeds
+		CREATE TABLE IF NOT EXISTS public.threat_feeds (
+			id uuid DEFAULT gen_random_uuid() NOT NULL PRIMARY KEY,
+			name character varying(100) NOT NULL,
+			slug character varying(50) NOT NULL UNIQUE,
+			description text,
+			url character varying(1000) NOT NULL,
+			format character varying(10) DEFAULT 'plain' NOT NULL,
+			update_interval integer DEFAULT 86400 NOT NULL,
+			ip_ranges text[] DEFAULT '{}' NOT NULL,
+			is_builtin boolean DEFAULT false NOT NULL,
+			enabled boolean DEFAULT true NOT NULL,
+			last_updated timestamp with time zone,
+			last_error text,
+			created_at timestamp with time zone DEFAULT now() NOT NULL,
+			updated_at timestamp with time zone DEFAULT now() NOT NULL,
+			CONSTRAINT threat_feeds_format_check CHECK (format IN ('plain', 'csv', 'json'))
+		);
+		CREATE TABLE IF NOT EXISTS public.proxy_host_threat_feeds (
+			proxy_host_id uuid NOT NULL REFERENCES public.proxy_hosts(id) ON DELETE CASCADE,
+			feed_id uuid NOT NULL REFERENCES public.threat_feeds(id) ON DELETE CASCADE,
+			action character varying(20) DEFAULT 'block' NOT NULL,
+			created_at timestamp with time zone DEFAULT now() NOT NULL,
+			PRIMARY KEY (proxy_host_id, feed_id),
+			CONSTRAINT proxy_host_threat_feeds_action_check CHECK (action IN ('block', 'challenge'))
+		);
+		CREATE INDEX IF NOT EXISTS idx_proxy_host_threat_feeds_feed ON public.proxy_host_threat_feeds USING btree (feed_id);
+
+		-- CrowdSec integration
+		CREATE TABLE IF NOT EXISTS public.crowdsec_settings (
+			id uuid DEFAULT gen_random_uuid() NOT NULL PRIMARY KEY,
+			enabled boolean DEFAULT false NOT NULL,
+			lapi_url character varying(500) DEFAULT '' NOT NULL,
+			api_key text DEFAULT '' NOT NULL,
+			poll_interval integer DEFAULT 60 NOT NULL,
+			push_signals boolean DEFAULT false NOT NULL,
+			machine_id character varying(255) DEFAULT '' NOT NULL,
+			machine_password text DEFAULT '' NOT NULL,
+			last_sync_at timestamp with time zone,
+			last_sync_error text,
+			created_at timestamp with time zone DEFAULT now() NOT NULL,
+			updated_at timestamp with time zone DEFAULT now() NOT NULL
+		);
+
+		-- Trusted IP allowlist
+		CREATE TABLE IF NOT EXISTS public.trusted_ips (
+			id uuid DEFAULT gen_random_uuid() NOT NULL PRIMARY KEY,
+			cidr character varying(50) NOT NULL UNIQUE,
+			label character varying(255) DEFAULT '' NOT NULL,
+			expires_at timestamp with time zone,
+			created_at timestamp with time zone DEFAULT now() NOT NULL,
+			updated_at timestamp with time zone DEFAULT now() NOT NULL
+		);
+		CREATE TABLE IF NOT EXISTS public.trusted_ip_hits (
+			id uuid DEFAULT gen_random_uuid() NOT NULL PRIMARY KEY,
+			trusted_ip_id uuid NOT NULL REFERENCES public.trusted_ips(id) ON DELETE CASCADE,
+			ip_address character varying(50) NOT NULL,
+			source character varying(50) NOT NULL,
+			reason text,
+			proxy_host_id uuid REFERENCES public.proxy_hosts(id) ON DELETE SET NULL,
+			created_at timestamp with time zone DEFAULT now() NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_trusted_ip_hits_trusted_ip ON public.trusted_ip_hits USING btree (trusted_ip_id, created_at DESC);
+
+		-- Access list Basic Auth users
+		CREATE TABLE IF NOT EXISTS public.access_list_auth_users (
+			id uuid DEFAULT gen_random_uuid() NOT NULL PRIMARY KEY,
+			access_list_id uuid NOT NULL REFERENCES public.access_lists(id) ON DELETE CASCADE,
+			username character varying(255) NOT NULL,
+			password_hash text NOT NULL,
+			created_at timestamp with time zone DEFAULT now() NOT NULL,
+			UNIQUE (access_list_id, username)
+		);
+
+		-- Forward auth
+		CREATE TABLE IF NOT EXISTS public.forward_auth_configs (
+			id uuid DEFAULT gen_random_uuid() NOT NULL PRIMARY KEY,
+			proxy_host_id uuid NOT NULL UNIQUE REFERENCES public.proxy_hosts(id) ON DELETE CASCADE,
+			enabled boolean DEFAULT true NOT NULL,
+			auth_url text NOT NULL,
+			signin_url text DEFAULT ''::text NOT NULL,
+			response_headers text[] DEFAULT '{}'::text[] NOT NULL,
+			bypass_paths text[] DEFAULT '{}'::text[] NOT NULL,
+			protect_host boolean DEFAULT true NOT NULL,
+			created_at timestamp with time zone DEFAULT now() NOT NULL,
+			updated_at timestamp with time zone DEFAULT now() NOT NULL
+		);
+
+		-- OIDC login gate
+		CREATE TABLE IF NOT EXISTS public.oidc_providers (
+			id uuid DEFAULT gen_random_uuid() NOT NULL PRIMARY KEY,
+			name character varying(255) NOT NULL UNIQUE,
+			issuer_url text NOT NULL,
+			client_id text NOT NULL,
+			client_secret text DEFAULT ''::text NOT NULL,
+			scopes text[] DEFAULT '{openid,email,profile}'::text[] NOT NULL,
+			groups_claim character varying(255) DEFAULT 'groups'::character varying NOT NULL,
+			enabled boolean DEFAULT true NOT NULL,
+			created_at timestamp with time zone DEFAULT now() NOT NULL,
+			updated_at timestamp with time zone DEFAULT now() NOT NULL
+		);
+		CREATE TABLE IF NOT EXISTS public.oidc_host_gates (
+			id uuid DEFAULT gen_random_uuid() NOT NULL PRIMARY KEY,
+			proxy_host_id uuid NOT NULL UNIQUE REFERENCES public.proxy_hosts(id) ON DELETE CASCADE,
+			provider_id uuid NOT NULL REFERENCES public.oidc_providers(id) ON DELETE RESTRICT,
+			enabled boolean DEFAULT true NOT NULL,
+			allowed_email_domains text[] DEFAULT '{}'::text[] NOT NULL,
+			allowed_groups text[] DEFAULT '{}'::text[] NOT NULL,
+			required_claims jsonb DEFAULT '{}'::jsonb NOT NULL,
+			session_lifetime integer DEFAULT 28800 NOT NULL,
+			created_at timestamp with time zone DEFAULT now() NOT NULL,
+			updated_at timestamp with time zone DEFAULT now() NOT NULL
+		);
+
+		-- Client certificate authentication (mTLS)
+		CREATE TABLE IF NOT EXISTS public.client_cas (
+			id uuid DEFAULT gen_random_uuid() NOT NULL PRIMARY KEY,
+			name character varying(255) NOT NULL UNIQUE,
+			description text DEFAULT ''::text NOT NULL,
+			ca_pem text NOT NULL,
+			crl_pem text DEFAULT ''::text NOT NULL,
+			created_at timestamp with time zone DEFAULT now() NOT NULL,
+			updated_at timestamp with time zone DEFAULT now() NOT NULL
+		);
+		CREATE TABLE IF NOT EXISTS public.client_cert_auth_configs (
+			id uuid DEFAULT gen_random_uuid() NOT NULL PRIMARY KEY,
+			proxy_host_id uuid NOT NULL UNIQUE REFERENCES public.proxy_hosts(id) ON DELETE CASCADE,
+			client_ca_id uuid NOT NULL REFERENCES public.client_cas(id) ON DELETE RESTRICT,
+			enabled boolean DEFAULT true NOT NULL,
+			verify_mode character varying(20) DEFAULT 'on'::character varying NOT NULL,
+			verify_depth integer DEFAULT 1 NOT NULL,
+			forward_headers boolean DEFAULT false NOT NULL,
+			enforce_paths text[] DEFAULT '{}'::text[] NOT NULL,
+			created_at timestamp with time zone DEFAULT now() NOT NULL,
+			updated_at timestamp with time zone DEFAULT now() NOT NULL
+		);
+
+		-- Upstream TLS
+		CREATE TABLE IF NOT EXISTS public.upstream_tls_configs (
+			id uuid DEFAULT gen_random_uuid() NOT NULL PRIMARY KEY,
+			proxy_host_id uuid NOT NULL UNIQUE REFERENCES public.proxy_hosts(id) ON DELETE CASCADE,
+			enabled boolean DEFAULT true NOT NULL,
+			verify boolean DEFAULT true NOT NULL,
+			trusted_ca_pem text DEFAULT ''::text NOT NULL,
+			verify_depth integer DEFAULT 2 NOT NULL,
+			server_name character varying(255) DEFAULT ''::character varying NOT NULL,
+			sni boolean DEFAULT true NOT NULL,
+			client_certificate_id uuid REFERENCES public.certificates(id) ON DELETE SET NULL,
+			created_at timestamp with time zone DEFAULT now() NOT NULL,
+			updated_at timestamp with time zone DEFAULT now() NOT NULL
+		);
+
+		-- TLS Policies
+		CREATE TABLE IF NOT EXISTS public.tls_policies (
+			id uuid DEFAULT gen_random_uuid() NOT NULL PRIMARY KEY,
+			proxy_host_id uuid NOT NULL UNIQUE REFERENCES public.proxy_hosts(id) ON DELETE CASCADE,
+			profile character varying(20) DEFAULT 'intermediate'::character varying NOT NULL,
+			protocols text[] DEFAULT '{}'::text[] NOT NULL,
+			ciphers text DEFAULT ''::text NOT NULL,
+			curves character varying(255) DEFAULT ''::character varying NOT NULL,
+			prefer_server_ciphers boolean DEFAULT false NOT NULL,
+			session_tickets boolean DEFAULT false NOT NULL,
+			ocsp_stapling boolean DEFAULT true NOT NULL,
+			hsts_enabled boolean DEFAULT false NOT NULL,
+			hsts_max_age integer DEFAULT 63072000 NOT NULL,
+			hsts_include_subdomains boolean DEFAULT false NOT NULL,
+			hsts_preload boolean DEFAULT false NOT NULL,
+			created_at timestamp with time zone DEFAULT now() NOT NULL,
+			updated_at timestamp with time zone DEFAULT now() NOT NULL
+		);
+
+		-- Real IP (trusted proxies)
+		CREATE TABLE IF NOT EXISTS public.real_ip_settings (
+			id uuid DEFAULT gen_random_uuid() NOT NULL PRIMARY KEY,
+			enabled boolean DEFAULT false NOT NULL,
+			header character varying(50) DEFAULT 'X-Forwarded-For'::character varying NOT NULL,
+			recursive boolean DEFAULT true NOT NULL,
+			include_private_ranges boolean DEFAULT true NOT NULL,
+			cloud_providers text[] DEFAULT '{}'::text[] NOT NULL,
+			custom_cidrs text[] DEFAULT '{}'::text[] NOT NULL,
+			created_at timestamp with time zone DEFAULT now() NOT NULL,
+			updated_at timestamp with time zone DEFAULT now() NOT NULL
+		);
+		ALTER TABLE public.real_ip_settings ADD COLUMN IF NOT EXISTS proxy_protocol boolean DEFAULT false NOT NULL;
+		ALTER TABLE public.real_ip_settings ADD COLUMN IF NOT EXISTS proxy_protocol_http_port integer DEFAULT 0 NOT NULL;
+		ALTER TABLE public.real_ip_settings ADD COLUMN IF NOT EXISTS proxy_protocol_https_port integer DEFAULT 0 NOT NULL;
+
+		-- CORS policies
+		CREATE TABLE IF NOT EXISTS public.cors_policies (
+			id uuid DEFAULT gen_random_uuid() NOT NULL PRIMARY KEY,
+			proxy_host_id uuid NOT NULL REFERENCES public.proxy_hosts(id) ON DELETE CASCADE,
+			path_prefix character varying(255) DEFAULT ''::character varying NOT NULL,
+			enabled boolean DEFAULT true NOT NULL,
+			allowed_origins text[] DEFAULT '{}'::text[] NOT NULL,
+			allowed_origin_patterns text[] DEFAULT '{}'::text[] NOT NULL,
+			allowed_methods text[] DEFAULT '{}'::text[] NOT NULL,
+			allowed_headers text[] DEFAULT '{}'::text[] NOT NULL,
+			exposed_headers text[] DEFAULT '{}'::text[] NOT NULL,
+			allow_credentials boolean DEFAULT false NOT NULL,
+			max_age integer DEFAULT 0 NOT NULL,
+			created_at timestamp with time zone DEFAULT now() NOT NULL,
+			updated_at timestamp with time zone DEFAULT now() NOT NULL,
+			UNIQUE (proxy_host_id, path_prefix)
+		);
+
+		-- Signed URL locations
+		CREATE TABLE IF NOT EXISTS public.signed_url_locations (
+			id uuid DEFAULT gen_random_uuid() NOT NULL PRIMARY KEY,
+			proxy_host_id uuid NOT NULL REFERENCES public.proxy_hosts(id) ON DELETE CASCADE,
+			path_prefix character varying(255) NOT NULL,
+			enabled boolean DEFAULT true NOT NULL,
+			secret character varying(128) NOT NULL,
+			hash_expression character varying(255) NOT NULL,
+			token_param character varying(32) DEFAULT 'md5'::character varying NOT NULL,
+			expires_param character varying(32) DEFAULT 'expires'::character varying NOT NULL,
+			default_ttl integer DEFAULT 3600 NOT NULL,
+			max_ttl integer DEFAULT 604800 NOT NULL,
+			created_at timestamp with time zone DEFAULT now() NOT NULL,
+			updated_at timestamp with time zone DEFAULT now() NOT NULL,
+			UNIQUE (proxy_host_id, path_prefix)
+		);
+
+		-- Multi-user administration
+		ALTER TABLE public.users ALTER COLUMN email DROP NOT NULL;
+		ALTER TABLE public.users ADD COLUMN IF NOT EXISTS disabled boolean DEFAULT false NOT NULL;
+		CREATE TABLE IF NOT EXISTS public.user_invitations (
+			id uuid DEFAULT gen_random_uuid() NOT NULL PRIMARY KEY,
+			user_id uuid NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+			token_hash character varying(64) NOT NULL UNIQUE,
+			expires_at timestamp with time zone NOT NULL,
+			created_by uuid REFERENCES public.users(id) ON DELETE SET NULL,
+			created_at timestamp with time zone DEFAULT now() NOT NULL
+		);
+
+		-- API token resource scopes
+		ALTER TABLE public.proxy_hosts ADD COLUMN IF NOT EXISTS tags text[] DEFAULT '{}'::text[] NOT NULL;
+		ALTER TABLE public.api_tokens ADD COLUMN IF NOT EXISTS resource_scope jsonb DEFAULT '{}'::jsonb NOT NULL;
+		-- Admin single sign-on
+		CREATE TABLE IF NOT EXISTS public.admin_sso_settings (
+			id uuid DEFAULT gen_random_uuid() NOT NULL PRIMARY KEY,
+			enabled boolean DEFAULT false NOT NULL,
+			provider_id uuid REFERENCES public.oidc_providers(id) ON DELETE RESTRICT,
+			role_claim character varying(255) DEFAULT ''::character varying NOT NULL,
+			role_mappings jsonb DEFAULT '[]'::jsonb NOT NULL,
+			default_role character varying(50) DEFAULT ''::character varying NOT NULL,
+			auto_provision boolean DEFAULT false NOT NULL,
+			allowed_email_domains text[] DEFAULT '{}'::text[] NOT NULL,
+			disable_local_login boolean DEFAULT false NOT NULL,
+			break_glass_username character varying(255) DEFAULT ''::character varying NOT NULL,
+			created_at timestamp with time zone DEFAULT now() NOT NULL,
+			updated_at timestamp with time zone DEFAULT now() NOT NULL
+		);
+		ALTER TABLE public.users ADD COLUMN IF NOT EXISTS oidc_provider_id uuid REFERENCES public.oidc_providers(id) ON DELETE SET NULL;
+		ALTER TABLE public.users ADD COLUMN IF NOT EXISTS oidc_subject character varying(255);
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_users_oidc_identity ON public.users USING btree (oidc_provider_id, oidc_subject) WHERE (oidc_provider_id IS NOT NULL);
+
+		-- LDAP / Active Directory authentication
+		CREATE TABLE IF NOT EXISTS public.ldap_settings (
+			id uuid DEFAULT gen_random_uuid() NOT NULL PRIMARY KEY,
+			enabled boolean DEFAULT false NOT NULL,
+			url text DEFAULT ''::text NOT NULL,
+			start_tls boolean DEFAULT false NOT NULL,
+			skip_tls_verify boolean DEFAULT false NOT NULL,
+			bind_dn text DEFAULT ''::text NOT NULL,
+			bind_password text DEFAULT ''::text NOT NULL,
+			search_base text DEFAULT ''::text NOT NULL,
+			user_filter text DEFAULT '(uid={username})'::text NOT NULL,
+			email_attribute character varying(255) DEFAULT 'mail'::character varying NOT NULL,
+			group_attribute character varying(255) DEFAULT 'memberOf'::character varying NOT NULL,
+			group_search_base text DEFAULT ''::text NOT NULL,
+			group_filter text DEFAULT '(|(member={dn})(uniqueMember={dn}))'::text NOT NULL,
+			group_mappings jsonb DEFAULT '[]'::jsonb NOT NULL,
+			default_role character varying(50) DEFAULT ''::character varying NOT NULL,
+			auto_provision boolean DEFAULT true NOT NULL,
+			timeout integer DEFAULT 10 NOT NULL,
+			created_at timestamp with time zone DEFAULT now() NOT NULL,
+			updated_at timestamp with time zone DEFAULT now() NOT NULL
+		);
+		ALTER TABLE public.users ADD COLUMN IF NOT EXISTS ldap_dn text;
+
+		-- WebAuthn security keys and passkeys
+		CREATE TABLE IF NOT EXISTS public.webauthn_settings (
+			id uuid DEFAULT gen_random_uuid() NOT NULL PRIMARY KEY,
+			enabled boolean DEFAULT false NOT NULL,
+			rp_id character varying(255) DEFAULT ''::character varying NOT NULL,
+			rp_name character varying(255) DEFAULT 'Nginx Proxy Guard'::character varying NOT NULL,
+			rp_origins text[] DEFAULT '{}'::text[] NOT NULL,
+			allow_passwordless boolean DEFAULT false NOT NULL,
+			require_hardware_key_for_admins boolean DEFAULT false NOT NULL,
+			created_at timestamp with time zone DEFAULT now() NOT NULL,
+			updated_at timestamp with time zone DEFAULT now() NOT NULL
+		);
+		CREATE TABLE IF NOT EXISTS public.webauthn_credentials (
+			id uuid DEFAULT gen_random_uuid() NOT NULL PRIMARY KEY,
+			user_id uuid NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+			name character varying(100) NOT NULL,
+			credential_id bytea NOT NULL UNIQUE,
+			public_key bytea NOT NULL,
+			attestation_type character varying(32) DEFAULT ''::character varying NOT NULL,
+			transports text[] DEFAULT '{}'::text[] NOT NULL,
+			aaguid bytea,
+			sign_count bigint DEFAULT 0 NOT NULL,
+			clone_warning boolean DEFAULT false NOT NULL,
+			backup_eligible boolean DEFAULT false NOT NULL,
+			backup_state boolean DEFAULT false NOT NULL,
+			attachment character varying(32) DEFAULT ''::character varying NOT NULL,
+			last_used_at timestamp with time zone,
+			created_at timestamp with time zone DEFAULT now() NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_webauthn_credentials_user ON public.webauthn_credentials USING btree (user_id);
+
+		-- Session management
+		ALTER TABLE public.auth_sessions ADD COLUMN IF NOT EXISTS last_seen_at timestamp with time zone DEFAULT now() NOT NULL;
+		ALTER TABLE public.auth_sessions ADD COLUMN IF NOT EXISTS password_expired boolean DEFAULT false NOT NULL;
+		CREATE TABLE IF NOT EXISTS public.session_settings (
+			id uuid DEFAULT gen_random_uuid() NOT NULL PRIMARY KEY,
+			idle_timeout_minutes integer DEFAULT 0 NOT NULL,
+			max_lifetime_hours integer DEFAULT 24 NOT NULL,
+			created_at timestamp with time zone DEFAULT now() NOT NULL,
+			updated_at timestamp with time zone DEFAULT now() NOT NULL
+		);
+
+		-- Password policy, history and breached password list
+		CREATE TABLE IF NOT EXISTS public.password_policy (
+			id uuid DEFAULT gen_random_uuid() NOT NULL PRIMARY KEY,
+			min_length integer DEFAULT 8 NOT NULL,
+			require_uppercase boolean DEFAULT false NOT NULL,
+			require_lowercase boolean DEFAULT false NOT NULL,
+			require_digit boolean DEFAULT false NOT NULL,
+			require_symbol boolean DEFAULT false NOT NULL,
+			history_count integer DEFAULT 0 NOT NULL,
+			max_age_days integer DEFAULT 0 NOT NULL,
+			breach_check_enabled boolean DEFAULT false NOT NULL,
+			lockout_threshold integer DEFAULT 5 NOT NULL,
+			ip_lockout_threshold integer DEFAULT 10 NOT NULL,
+			lockout_minutes integer DEFAULT 15 NOT NULL,
+			max_lockout_minutes integer DEFAULT 1440 NOT NULL,
+			created_at timestamp with time zone DEFAULT now() NOT NULL,
+			updated_at timestamp with time zone DEFAULT now() NOT NULL
+		);
+		CREATE TABLE IF NOT EXISTS public.password_history (
+			id uuid DEFAULT gen_random_uuid() NOT NULL PRIMARY KEY,
+			user_id uuid NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+			password_hash character varying(255) NOT NULL,
+			created_at timestamp with time zone DEFAULT now() NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_password_history_user ON public.password_history USING btree (user_id, created_at DESC);
+		CREATE TABLE IF NOT EXISTS public.breached_password_lists (
+			id uuid DEFAULT gen_random_uuid() NOT NULL PRIMARY KEY,
+			name character varying(255) NOT NULL,
+			hash_count integer NOT NULL,
+			hashes bytea NOT NULL,
+			uploaded_by character varying(255) DEFAULT ''::character varying NOT NULL,
+			created_at timestamp with time zone DEFAULT now() NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_login_attempts_username ON public.login_attempts USING btree (username, attempted_at);
+		-- Existing passwords start the history, dated by the last update of the user
+		INSERT INTO public.password_history (user_id, password_hash, created_at)
+		SELECT u.id, u.password_hash, u.updated_at FROM public.users u
+		WHERE u.password_hash NOT IN ('', '!')
+		  AND NOT EXISTS (SELECT 1 FROM public.password_history h WHERE h.user_id = u.id);
+
+		-- Two-person approval of high-risk changes
+		CREATE TABLE IF NOT EXISTS public.approval_settings (
+			id uuid DEFAULT gen_random_uuid() NOT NULL PRIMARY KEY,
+			enabled boolean DEFAULT false NOT NULL,
+			actions text[] DEFAULT '{}'::text[] NOT NULL,
+			approver_role character varying(32) DEFAULT 'admin' NOT NULL,
+			expiry_hours integer DEFAULT 24 NOT NULL,
+			created_at timestamp with time zone DEFAULT now() NOT NULL,
+			updated_at timestamp with time zone DEFAULT now() NOT NULL
+		);
+		CREATE TABLE IF NOT EXISTS public.change_requests (
+			id uuid DEFAULT gen_random_uuid() NOT NULL PRIMARY KEY,
+			action character varying(64) NOT NULL,
+			target_id character varying(255) DEFAULT '' NOT NULL,
+			target_name character varying(1024) DEFAULT '' NOT NULL,
+			payload jsonb DEFAULT '{}'::jsonb NOT NULL,
+			diff text DEFAULT '' NOT NULL,
+			status character varying(20) DEFAULT 'pending' NOT NULL,
+			requested_by uuid REFERENCES public.users(id) ON DELETE SET NULL,
+			requested_by_name character varying(255) DEFAULT '' NOT NULL,
+			reviewed_by uuid REFERENCES public.users(id) ON DELETE SET NULL,
+			reviewed_by_name character varying(255) DEFAULT '' NOT NULL,
+			review_comment text DEFAULT '' NOT NULL,
+			error_message text DEFAULT '' NOT NULL,
+			expires_at timestamp with time zone NOT NULL,
+			reviewed_at timestamp with time zone,
+			applied_at timestamp with time zone,
+			created_at timestamp with time zone DEFAULT now() NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_change_requests_status ON public.change_requests USING btree (status, expires_at);
+		CREATE INDEX IF NOT EXISTS idx_change_requests_created ON public.change_requests USING btree (created_at DESC);
+
+		-- Management plane access (IP allowlist and dedicated TLS listener)
+		CREATE TABLE IF NOT EXISTS public.management_settings (
+			id uuid DEFAULT gen_random_uuid() NOT NULL PRIMARY KEY,
+			allowlist_enabled boolean DEFAULT false NOT NULL,
+			allowed_networks text[] DEFAULT '{}'::text[] NOT NULL,
+			listener_enabled boolean DEFAULT false NOT NULL,
+			listener_address character varying(255) DEFAULT ':8443' NOT NULL,
+			certificate_id uuid REFERENCES public.certificates(id) ON DELETE SET NULL,
+			dedicated_only boolean DEFAULT false NOT NULL,
+			created_at timestamp with time zone DEFAULT now() NOT NULL,
+			updated_at timestamp with time zone DEFAULT now() NOT NULL
+		);
 	`
 	_, err = db.Exec(upgradeSQL)
 	if err != nil {
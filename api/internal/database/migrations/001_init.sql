@@ -9,7 +9,7 @@ CREATE EXTENSION IF NOT EXISTS pg_trgm;
 -- ENUM Types (wrapped in DO blocks to handle existing types)
 DO $$ BEGIN
     CREATE TYPE public.block_reason AS ENUM (
-        'none', 'waf', 'bot_filter', 'rate_limit', 'geo_block', 'exploit_block', 'banned_ip', 'uri_block', 'cloud_provider_challenge', 'cloud_provider_block', 'access_denied'
+        'none', 'waf', 'bot_filter', 'rate_limit', 'geo_block', 'exploit_block', 'banned_ip', 'uri_block', 'cloud_provider_challenge', 'cloud_provider_block', 'access_denied', 'threat_feed_block', 'threat_feed_challenge', 'client_cert', 'signed_url'
     );
 EXCEPTION WHEN duplicate_object THEN NULL;
 END $$;
@@ -244,6 +244,7 @@ CREATE TABLE IF NOT EXISTS public.api_tokens (
     is_active boolean DEFAULT true NOT NULL,
     revoked_at timestamp with time zone,
     revoked_reason character varying(255),
+    resource_scope jsonb DEFAULT '{}'::jsonb NOT NULL,
     created_at timestamp with time zone DEFAULT now() NOT NULL,
     updated_at timestamp with time zone DEFAULT now() NOT NULL
 );
@@ -267,7 +268,9 @@ CREATE TABLE IF NOT EXISTS public.auth_sessions (
     ip_address character varying(45),
     user_agent text,
     expires_at timestamp with time zone NOT NULL,
-    created_at timestamp with time zone DEFAULT now() NOT NULL
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    last_seen_at timestamp with time zone DEFAULT now() NOT NULL,
+    password_expired boolean DEFAULT false NOT NULL
 );
 CREATE TABLE IF NOT EXISTS public.backups (
     id uuid DEFAULT gen_random_uuid() NOT NULL,
@@ -295,7 +298,8 @@ CREATE TABLE IF NOT EXISTS public.banned_ips (
     expires_at timestamp with time zone,
     is_permanent boolean DEFAULT false,
     created_at timestamp with time zone DEFAULT now(),
-    is_auto_banned boolean DEFAULT false
+    is_auto_banned boolean DEFAULT false,
+    origin character varying(20) DEFAULT 'local'::character varying NOT NULL
 );
 CREATE TABLE IF NOT EXISTS public.bot_filters (
     id uuid DEFAULT gen_random_uuid() NOT NULL,
@@ -695,6 +699,8 @@ CREATE TABLE IF NOT EXISTS public.ip_ban_history (
     user_id uuid,
     user_email character varying(255),
     metadata jsonb,
+    escalation_level integer,
+    escalation_reason text,
     created_at timestamp with time zone DEFAULT now()
 );
 CREATE TABLE IF NOT EXISTS public.log_settings (
@@ -1083,6 +1089,8 @@ CREATE TABLE IF NOT EXISTS public.proxy_hosts (
     proxy_buffering character varying(10) DEFAULT ''::character varying,
     client_max_body_size character varying(20) DEFAULT ''::character varying,
     proxy_max_temp_file_size character varying(20) DEFAULT ''::character varying,
+    proxy_protocol boolean DEFAULT false NOT NULL,
+    tags text[] DEFAULT '{}'::text[] NOT NULL,
     CONSTRAINT chk_waf_anomaly_threshold CHECK (((waf_anomaly_threshold >= 1) AND (waf_anomaly_threshold <= 100))),
     CONSTRAINT chk_waf_paranoia_level CHECK (((waf_paranoia_level >= 1) AND (waf_paranoia_level <= 4)))
 );
@@ -1094,6 +1102,8 @@ COMMENT ON COLUMN public.proxy_hosts.forward_port IS 'Target port to forward req
 COMMENT ON COLUMN public.proxy_hosts.custom_locations IS 'JSON array of custom location blocks';
 COMMENT ON COLUMN public.proxy_hosts.advanced_config IS 'Raw nginx config to append to server block';
 COMMENT ON COLUMN public.proxy_hosts.ssl_http3 IS 'Enable HTTP/3 (QUIC) support for this proxy host';
+COMMENT ON COLUMN public.proxy_hosts.proxy_protocol IS 'Also listen on the dedicated PROXY protocol ports';
+COMMENT ON COLUMN public.proxy_hosts.tags IS 'Free-form lowercase labels (used to scope API tokens)';
 COMMENT ON COLUMN public.proxy_hosts.waf_paranoia_level IS 'OWASP CRS paranoia level (1-4). Higher = more rules, more false positives';
 COMMENT ON COLUMN public.proxy_hosts.waf_anomaly_threshold IS 'Anomaly score threshold for blocking. Lower = stricter';
 COMMENT ON COLUMN public.proxy_hosts.block_exploits_exceptions IS 'Newline-separated regex patterns for URI paths that bypass RFI/exploit blocking. Example: ^/wp-json/';
@@ -1449,6 +1459,14 @@ geckodriver'::text,
     waf_auto_ban_threshold integer DEFAULT 10,
     waf_auto_ban_window integer DEFAULT 300,
     waf_auto_ban_duration integer DEFAULT 3600,
+    ban_escalation_enabled boolean DEFAULT false,
+    ban_escalation_steps integer[] DEFAULT ARRAY[3600, 86400, 604800, 0],
+    ban_escalation_window integer DEFAULT 2592000,
+    ban_subnet_aggregation_enabled boolean DEFAULT false,
+    ban_subnet_threshold integer DEFAULT 5,
+    ban_subnet_ipv4_prefix integer DEFAULT 24,
+    ban_subnet_ipv6_prefix integer DEFAULT 64,
+    ban_subnet_window integer DEFAULT 3600,
     direct_ip_access_action character varying(20) DEFAULT 'allow'::character varying,
     system_logs_enabled boolean DEFAULT true NOT NULL,
     system_logs_levels jsonb DEFAULT '{"npm-guard-db": "warn", "npm-guard-ui": "warn", "npm-guard-api": "info", "npm-guard-proxy": "info"}'::jsonb,
@@ -1516,7 +1534,7 @@ CREATE TABLE IF NOT EXISTS public.uri_blocks (
 );
 CREATE TABLE IF NOT EXISTS public.users (
     id uuid DEFAULT public.uuid_generate_v4() NOT NULL,
-    email character varying(255) NOT NULL,
+    email character varying(255),
     password_hash character varying(255) NOT NULL,
     name character varying(255),
     role character varying(50) DEFAULT 'user'::character varying,
@@ -1532,7 +1550,11 @@ CREATE TABLE IF NOT EXISTS public.users (
     totp_verified_at timestamp with time zone,
     backup_codes text[],
     language character varying(10) DEFAULT 'ko'::character varying,
-    font_family character varying(100) DEFAULT 'system'::character varying
+    font_family character varying(100) DEFAULT 'system'::character varying,
+    disabled boolean DEFAULT false NOT NULL,
+    oidc_provider_id uuid,
+    oidc_subject character varying(255),
+    ldap_dn text
 );
 CREATE TABLE IF NOT EXISTS public.waf_policy_history (
     id uuid DEFAULT gen_random_uuid() NOT NULL,
@@ -1916,6 +1938,7 @@ CREATE INDEX IF NOT EXISTS stats_hourly_p2026_03_proxy_host_id_hour_bucket_idx O
 CREATE INDEX IF NOT EXISTS stats_hourly_p_default_hour_bucket_idx ON public.stats_hourly_p_default USING btree (hour_bucket);
 CREATE INDEX IF NOT EXISTS stats_hourly_p_default_proxy_host_id_hour_bucket_idx ON public.stats_hourly_p_default USING btree (proxy_host_id, hour_bucket);
 CREATE UNIQUE INDEX IF NOT EXISTS users_username_key ON public.users USING btree (username);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_oidc_identity ON public.users USING btree (oidc_provider_id, oidc_subject) WHERE (oidc_provider_id IS NOT NULL);
 DO $$ BEGIN ALTER INDEX public.idx_logs_partitioned_exploit_rule ATTACH PARTITION public.logs_p2025_12_exploit_rule_idx; EXCEPTION WHEN OTHERS THEN NULL; END $$;
 DO $$ BEGIN ALTER INDEX public.idx_logs_part_host ATTACH PARTITION public.logs_p2025_12_host_idx; EXCEPTION WHEN OTHERS THEN NULL; END $$;
 DO $$ BEGIN ALTER INDEX public.idx_logs_part_log_type ATTACH PARTITION public.logs_p2025_12_log_type_idx; EXCEPTION WHEN OTHERS THEN NULL; END $$;
@@ -2308,3 +2331,461 @@ DO $$ BEGIN
         FOREIGN KEY (proxy_host_id) REFERENCES public.proxy_hosts(id) ON DELETE SET NULL;
 EXCEPTION WHEN OTHERS THEN NULL;
 END $$;
+
+-- ============================================================================
+-- THREAT INTELLIGENCE FEEDS
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS public.threat_feeds (
+    id uuid DEFAULT gen_random_uuid() NOT NULL PRIMARY KEY,
+    name character varying(100) NOT NULL,
+    slug character varying(50) NOT NULL UNIQUE,
+    description text,
+    url character varying(1000) NOT NULL,
+    format character varying(10) DEFAULT 'plain'::character varying NOT NULL,
+    update_interval integer DEFAULT 86400 NOT NULL,
+    ip_ranges text[] DEFAULT '{}'::text[] NOT NULL,
+    is_builtin boolean DEFAULT false NOT NULL,
+    enabled boolean DEFAULT true NOT NULL,
+    last_updated timestamp with time zone,
+    last_error text,
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    updated_at timestamp with time zone DEFAULT now() NOT NULL,
+    CONSTRAINT threat_feeds_format_check CHECK (((format)::text = ANY ((ARRAY['plain'::character varying, 'csv'::character varying, 'json'::character varying])::text[])))
+);
+CREATE TABLE IF NOT EXISTS public.proxy_host_threat_feeds (
+    proxy_host_id uuid NOT NULL REFERENCES public.proxy_hosts(id) ON DELETE CASCADE,
+    feed_id uuid NOT NULL REFERENCES public.threat_feeds(id) ON DELETE CASCADE,
+    action character varying(20) DEFAULT 'block'::character varying NOT NULL,
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    PRIMARY KEY (proxy_host_id, feed_id),
+    CONSTRAINT proxy_host_threat_feeds_action_check CHECK (((action)::text = ANY ((ARRAY['block'::character varying, 'challenge'::character varying])::text[])))
+);
+CREATE INDEX IF NOT EXISTS idx_proxy_host_threat_feeds_feed ON public.proxy_host_threat_feeds USING btree (feed_id);
+COMMENT ON TABLE public.threat_feeds IS 'Threat intelligence IP blocklist feeds refreshed on their own interval';
+COMMENT ON TABLE public.proxy_host_threat_feeds IS 'Per-host threat feed subscriptions with block or challenge action';
+
+-- ============================================================================
+-- CROWDSEC INTEGRATION
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS public.crowdsec_settings (
+    id uuid DEFAULT gen_random_uuid() NOT NULL PRIMARY KEY,
+    enabled boolean DEFAULT false NOT NULL,
+    lapi_url character varying(500) DEFAULT ''::character varying NOT NULL,
+    api_key text DEFAULT ''::text NOT NULL,
+    poll_interval integer DEFAULT 60 NOT NULL,
+    push_signals boolean DEFAULT false NOT NULL,
+    machine_id character varying(255) DEFAULT ''::character varying NOT NULL,
+    machine_password text DEFAULT ''::text NOT NULL,
+    last_sync_at timestamp with time zone,
+    last_sync_error text,
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    updated_at timestamp with time zone DEFAULT now() NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_banned_ips_origin ON public.banned_ips USING btree (origin);
+COMMENT ON TABLE public.crowdsec_settings IS 'CrowdSec LAPI bouncer and signal sharing settings';
+COMMENT ON COLUMN public.banned_ips.origin IS 'Where the ban decision came from: local or crowdsec';
+
+-- ============================================================================
+-- TRUSTED IP ALLOWLIST
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS public.trusted_ips (
+    id uuid DEFAULT gen_random_uuid() NOT NULL PRIMARY KEY,
+    cidr character varying(50) NOT NULL UNIQUE,
+    label character varying(255) DEFAULT ''::character varying NOT NULL,
+    expires_at timestamp with time zone,
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    updated_at timestamp with time zone DEFAULT now() NOT NULL
+);
+CREATE TABLE IF NOT EXISTS public.trusted_ip_hits (
+    id uuid DEFAULT gen_random_uuid() NOT NULL PRIMARY KEY,
+    trusted_ip_id uuid NOT NULL REFERENCES public.trusted_ips(id) ON DELETE CASCADE,
+    ip_address character varying(50) NOT NULL,
+    source character varying(50) NOT NULL,
+    reason text,
+    proxy_host_id uuid REFERENCES public.proxy_hosts(id) ON DELETE SET NULL,
+    created_at timestamp with time zone DEFAULT now() NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_trusted_ip_hits_trusted_ip ON public.trusted_ip_hits USING btree (trusted_ip_id, created_at DESC);
+COMMENT ON TABLE public.trusted_ips IS 'Global never-ban networks honored by every blocking subsystem';
+COMMENT ON TABLE public.trusted_ip_hits IS 'Blocks that were prevented because the client matched a trusted network';
+
+-- ============================================================================
+-- ACCESS LIST BASIC AUTH
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS public.access_list_auth_users (
+    id uuid DEFAULT gen_random_uuid() NOT NULL PRIMARY KEY,
+    access_list_id uuid NOT NULL REFERENCES public.access_lists(id) ON DELETE CASCADE,
+    username character varying(255) NOT NULL,
+    password_hash text NOT NULL,
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    UNIQUE (access_list_id, username)
+);
+COMMENT ON TABLE public.access_list_auth_users IS 'HTTP Basic Auth users of an access list (bcrypt password hashes rendered into htpasswd)';
+
+-- ============================================================================
+-- FORWARD AUTH
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS public.forward_auth_configs (
+    id uuid DEFAULT gen_random_uuid() NOT NULL PRIMARY KEY,
+    proxy_host_id uuid NOT NULL UNIQUE REFERENCES public.proxy_hosts(id) ON DELETE CASCADE,
+    enabled boolean DEFAULT true NOT NULL,
+    auth_url text NOT NULL,
+    signin_url text DEFAULT ''::text NOT NULL,
+    response_headers text[] DEFAULT '{}'::text[] NOT NULL,
+    bypass_paths text[] DEFAULT '{}'::text[] NOT NULL,
+    protect_host boolean DEFAULT true NOT NULL,
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    updated_at timestamp with time zone DEFAULT now() NOT NULL
+);
+COMMENT ON TABLE public.forward_auth_configs IS 'Per-host external authentication (auth_request to Authelia, Authentik, oauth2-proxy, ...)';
+
+-- ============================================================================
+-- OIDC LOGIN GATE
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS public.oidc_providers (
+    id uuid DEFAULT gen_random_uuid() NOT NULL PRIMARY KEY,
+    name character varying(255) NOT NULL UNIQUE,
+    issuer_url text NOT NULL,
+    client_id text NOT NULL,
+    client_secret text DEFAULT ''::text NOT NULL,
+    scopes text[] DEFAULT '{openid,email,profile}'::text[] NOT NULL,
+    groups_claim character varying(255) DEFAULT 'groups'::character varying NOT NULL,
+    enabled boolean DEFAULT true NOT NULL,
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    updated_at timestamp with time zone DEFAULT now() NOT NULL
+);
+CREATE TABLE IF NOT EXISTS public.oidc_host_gates (
+    id uuid DEFAULT gen_random_uuid() NOT NULL PRIMARY KEY,
+    proxy_host_id uuid NOT NULL UNIQUE REFERENCES public.proxy_hosts(id) ON DELETE CASCADE,
+    provider_id uuid NOT NULL REFERENCES public.oidc_providers(id) ON DELETE RESTRICT,
+    enabled boolean DEFAULT true NOT NULL,
+    allowed_email_domains text[] DEFAULT '{}'::text[] NOT NULL,
+    allowed_groups text[] DEFAULT '{}'::text[] NOT NULL,
+    required_claims jsonb DEFAULT '{}'::jsonb NOT NULL,
+    session_lifetime integer DEFAULT 28800 NOT NULL,
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    updated_at timestamp with time zone DEFAULT now() NOT NULL
+);
+COMMENT ON TABLE public.oidc_providers IS 'OpenID Connect identity providers used by the built-in login gate';
+CREATE TABLE IF NOT EXISTS public.admin_sso_settings (
+    id uuid DEFAULT gen_random_uuid() NOT NULL PRIMARY KEY,
+    enabled boolean DEFAULT false NOT NULL,
+    provider_id uuid REFERENCES public.oidc_providers(id) ON DELETE RESTRICT,
+    role_claim character varying(255) DEFAULT ''::character varying NOT NULL,
+    role_mappings jsonb DEFAULT '[]'::jsonb NOT NULL,
+    default_role character varying(50) DEFAULT ''::character varying NOT NULL,
+    auto_provision boolean DEFAULT false NOT NULL,
+    allowed_email_domains text[] DEFAULT '{}'::text[] NOT NULL,
+    disable_local_login boolean DEFAULT false NOT NULL,
+    break_glass_username character varying(255) DEFAULT ''::character varying NOT NULL,
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    updated_at timestamp with time zone DEFAULT now() NOT NULL
+);
+ALTER TABLE public.users ADD CONSTRAINT users_oidc_provider_id_fkey FOREIGN KEY (oidc_provider_id) REFERENCES public.oidc_providers(id) ON DELETE SET NULL;
+COMMENT ON TABLE public.oidc_host_gates IS 'Proxy hosts that require an OIDC login, with the users allowed in';
+COMMENT ON TABLE public.admin_sso_settings IS 'Singleton: OIDC single sign-on for the admin UI and API, with claim to role mapping';
+
+-- ============================================================================
+-- LDAP / ACTIVE DIRECTORY AUTHENTICATION
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS public.ldap_settings (
+    id uuid DEFAULT gen_random_uuid() NOT NULL PRIMARY KEY,
+    enabled boolean DEFAULT false NOT NULL,
+    url text DEFAULT ''::text NOT NULL,
+    start_tls boolean DEFAULT false NOT NULL,
+    skip_tls_verify boolean DEFAULT false NOT NULL,
+    bind_dn text DEFAULT ''::text NOT NULL,
+    bind_password text DEFAULT ''::text NOT NULL,
+    search_base text DEFAULT ''::text NOT NULL,
+    user_filter text DEFAULT '(uid={username})'::text NOT NULL,
+    email_attribute character varying(255) DEFAULT 'mail'::character varying NOT NULL,
+    group_attribute character varying(255) DEFAULT 'memberOf'::character varying NOT NULL,
+    group_search_base text DEFAULT ''::text NOT NULL,
+    group_filter text DEFAULT '(|(member={dn})(uniqueMember={dn}))'::text NOT NULL,
+    group_mappings jsonb DEFAULT '[]'::jsonb NOT NULL,
+    default_role character varying(50) DEFAULT ''::character varying NOT NULL,
+    auto_provision boolean DEFAULT true NOT NULL,
+    timeout integer DEFAULT 10 NOT NULL,
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    updated_at timestamp with time zone DEFAULT now() NOT NULL
+);
+COMMENT ON TABLE public.ldap_settings IS 'Singleton: LDAP / Active Directory login next to local accounts, with group to role mapping';
+
+-- ============================================================================
+-- WEBAUTHN SECURITY KEYS AND PASSKEYS
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS public.webauthn_settings (
+    id uuid DEFAULT gen_random_uuid() NOT NULL PRIMARY KEY,
+    enabled boolean DEFAULT false NOT NULL,
+    rp_id character varying(255) DEFAULT ''::character varying NOT NULL,
+    rp_name character varying(255) DEFAULT 'Nginx Proxy Guard'::character varying NOT NULL,
+    rp_origins text[] DEFAULT '{}'::text[] NOT NULL,
+    allow_passwordless boolean DEFAULT false NOT NULL,
+    require_hardware_key_for_admins boolean DEFAULT false NOT NULL,
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    updated_at timestamp with time zone DEFAULT now() NOT NULL
+);
+CREATE TABLE IF NOT EXISTS public.webauthn_credentials (
+    id uuid DEFAULT gen_random_uuid() NOT NULL PRIMARY KEY,
+    user_id uuid NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    name character varying(100) NOT NULL,
+    credential_id bytea NOT NULL UNIQUE,
+    public_key bytea NOT NULL,
+    attestation_type character varying(32) DEFAULT ''::character varying NOT NULL,
+    transports text[] DEFAULT '{}'::text[] NOT NULL,
+    aaguid bytea,
+    sign_count bigint DEFAULT 0 NOT NULL,
+    clone_warning boolean DEFAULT false NOT NULL,
+    backup_eligible boolean DEFAULT false NOT NULL,
+    backup_state boolean DEFAULT false NOT NULL,
+    attachment character varying(32) DEFAULT ''::character varying NOT NULL,
+    last_used_at timestamp with time zone,
+    created_at timestamp with time zone DEFAULT now() NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_webauthn_credentials_user ON public.webauthn_credentials USING btree (user_id);
+COMMENT ON TABLE public.webauthn_settings IS 'Singleton: relying party and policy for security keys, passkey login and hardware keys for admins';
+COMMENT ON TABLE public.webauthn_credentials IS 'Security keys and passkeys registered by users as second factor or passwordless login';
+
+-- ============================================================================
+-- SESSION POLICY
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS public.session_settings (
+    id uuid DEFAULT gen_random_uuid() NOT NULL PRIMARY KEY,
+    idle_timeout_minutes integer DEFAULT 0 NOT NULL,
+    max_lifetime_hours integer DEFAULT 24 NOT NULL,
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    updated_at timestamp with time zone DEFAULT now() NOT NULL
+);
+COMMENT ON TABLE public.session_settings IS 'Singleton: idle timeout and absolute lifetime of admin UI sessions';
+
+-- ============================================================================
+-- PASSWORD POLICY AND ACCOUNT LOCKOUT
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS public.password_policy (
+    id uuid DEFAULT gen_random_uuid() NOT NULL PRIMARY KEY,
+    min_length integer DEFAULT 8 NOT NULL,
+    require_uppercase boolean DEFAULT false NOT NULL,
+    require_lowercase boolean DEFAULT false NOT NULL,
+    require_digit boolean DEFAULT false NOT NULL,
+    require_symbol boolean DEFAULT false NOT NULL,
+    history_count integer DEFAULT 0 NOT NULL,
+    max_age_days integer DEFAULT 0 NOT NULL,
+    breach_check_enabled boolean DEFAULT false NOT NULL,
+    lockout_threshold integer DEFAULT 5 NOT NULL,
+    ip_lockout_threshold integer DEFAULT 10 NOT NULL,
+    lockout_minutes integer DEFAULT 15 NOT NULL,
+    max_lockout_minutes integer DEFAULT 1440 NOT NULL,
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    updated_at timestamp with time zone DEFAULT now() NOT NULL
+);
+CREATE TABLE IF NOT EXISTS public.password_history (
+    id uuid DEFAULT gen_random_uuid() NOT NULL PRIMARY KEY,
+    user_id uuid NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    password_hash character varying(255) NOT NULL,
+    created_at timestamp with time zone DEFAULT now() NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_password_history_user ON public.password_history USING btree (user_id, created_at DESC);
+CREATE TABLE IF NOT EXISTS public.breached_password_lists (
+    id uuid DEFAULT gen_random_uuid() NOT NULL PRIMARY KEY,
+    name character varying(255) NOT NULL,
+    hash_count integer NOT NULL,
+    hashes bytea NOT NULL,
+    uploaded_by character varying(255) DEFAULT ''::character varying NOT NULL,
+    created_at timestamp with time zone DEFAULT now() NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_login_attempts_username ON public.login_attempts USING btree (username, attempted_at);
+COMMENT ON TABLE public.password_policy IS 'Singleton: password rules for local accounts and progressive lockout after failed logins';
+COMMENT ON TABLE public.password_history IS 'Hashes of the current and previous passwords of local accounts, for reuse checks and maximum age';
+COMMENT ON TABLE public.breached_password_lists IS 'Uploaded list of breached password SHA-1 hashes, replacing the bundled list';
+
+-- ============================================================================
+-- TWO-PERSON APPROVAL
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS public.approval_settings (
+    id uuid DEFAULT gen_random_uuid() NOT NULL PRIMARY KEY,
+    enabled boolean DEFAULT false NOT NULL,
+    actions text[] DEFAULT '{}'::text[] NOT NULL,
+    approver_role character varying(32) DEFAULT 'admin' NOT NULL,
+    expiry_hours integer DEFAULT 24 NOT NULL,
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    updated_at timestamp with time zone DEFAULT now() NOT NULL
+);
+CREATE TABLE IF NOT EXISTS public.change_requests (
+    id uuid DEFAULT gen_random_uuid() NOT NULL PRIMARY KEY,
+    action character varying(64) NOT NULL,
+    target_id character varying(255) DEFAULT '' NOT NULL,
+    target_name character varying(1024) DEFAULT '' NOT NULL,
+    payload jsonb DEFAULT '{}'::jsonb NOT NULL,
+    diff text DEFAULT '' NOT NULL,
+    status character varying(20) DEFAULT 'pending' NOT NULL,
+    requested_by uuid REFERENCES public.users(id) ON DELETE SET NULL,
+    requested_by_name character varying(255) DEFAULT '' NOT NULL,
+    reviewed_by uuid REFERENCES public.users(id) ON DELETE SET NULL,
+    reviewed_by_name character varying(255) DEFAULT '' NOT NULL,
+    review_comment text DEFAULT '' NOT NULL,
+    error_message text DEFAULT '' NOT NULL,
+    expires_at timestamp with time zone NOT NULL,
+    reviewed_at timestamp with time zone,
+    applied_at timestamp with time zone,
+    created_at timestamp with time zone DEFAULT now() NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_change_requests_status ON public.change_requests USING btree (status, expires_at);
+CREATE INDEX IF NOT EXISTS idx_change_requests_created ON public.change_requests USING btree (created_at DESC);
+COMMENT ON TABLE public.approval_settings IS 'Singleton: which high-risk actions need approval by a second user, and by which role';
+COMMENT ON TABLE public.change_requests IS 'Pending and reviewed high-risk changes with their payload and rendered config diff';
+
+-- ============================================================================
+-- MANAGEMENT PLANE ACCESS
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS public.management_settings (
+    id uuid DEFAULT gen_random_uuid() NOT NULL PRIMARY KEY,
+    allowlist_enabled boolean DEFAULT false NOT NULL,
+    allowed_networks text[] DEFAULT '{}'::text[] NOT NULL,
+    listener_enabled boolean DEFAULT false NOT NULL,
+    listener_address character varying(255) DEFAULT ':8443' NOT NULL,
+    certificate_id uuid REFERENCES public.certificates(id) ON DELETE SET NULL,
+    dedicated_only boolean DEFAULT false NOT NULL,
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    updated_at timestamp with time zone DEFAULT now() NOT NULL
+);
+COMMENT ON TABLE public.management_settings IS 'Singleton: networks allowed to reach the management API and the optional dedicated management listener';
+
+-- ============================================================================
+-- CLIENT CERTIFICATE AUTHENTICATION (mTLS)
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS public.client_cas (
+    id uuid DEFAULT gen_random_uuid() NOT NULL PRIMARY KEY,
+    name character varying(255) NOT NULL UNIQUE,
+    description text DEFAULT ''::text NOT NULL,
+    ca_pem text NOT NULL,
+    crl_pem text DEFAULT ''::text NOT NULL,
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    updated_at timestamp with time zone DEFAULT now() NOT NULL
+);
+CREATE TABLE IF NOT EXISTS public.client_cert_auth_configs (
+    id uuid DEFAULT gen_random_uuid() NOT NULL PRIMARY KEY,
+    proxy_host_id uuid NOT NULL UNIQUE REFERENCES public.proxy_hosts(id) ON DELETE CASCADE,
+    client_ca_id uuid NOT NULL REFERENCES public.client_cas(id) ON DELETE RESTRICT,
+    enabled boolean DEFAULT true NOT NULL,
+    verify_mode character varying(20) DEFAULT 'on'::character varying NOT NULL,
+    verify_depth integer DEFAULT 1 NOT NULL,
+    forward_headers boolean DEFAULT false NOT NULL,
+    enforce_paths text[] DEFAULT '{}'::text[] NOT NULL,
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    updated_at timestamp with time zone DEFAULT now() NOT NULL
+);
+COMMENT ON TABLE public.client_cas IS 'Uploaded CA bundles (and optional CRLs) that client certificates are verified against';
+COMMENT ON TABLE public.client_cert_auth_configs IS 'Per-host mutual TLS settings (ssl_verify_client) referencing a client CA';
+
+-- ============================================================================
+-- UPSTREAM TLS
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS public.upstream_tls_configs (
+    id uuid DEFAULT gen_random_uuid() NOT NULL PRIMARY KEY,
+    proxy_host_id uuid NOT NULL UNIQUE REFERENCES public.proxy_hosts(id) ON DELETE CASCADE,
+    enabled boolean DEFAULT true NOT NULL,
+    verify boolean DEFAULT true NOT NULL,
+    trusted_ca_pem text DEFAULT ''::text NOT NULL,
+    verify_depth integer DEFAULT 2 NOT NULL,
+    server_name character varying(255) DEFAULT ''::character varying NOT NULL,
+    sni boolean DEFAULT true NOT NULL,
+    client_certificate_id uuid REFERENCES public.certificates(id) ON DELETE SET NULL,
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    updated_at timestamp with time zone DEFAULT now() NOT NULL
+);
+COMMENT ON TABLE public.upstream_tls_configs IS 'Per-host backend TLS: certificate verification, SNI and the client certificate presented to the backend';
+
+-- ============================================================================
+-- TLS POLICIES
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS public.tls_policies (
+    id uuid DEFAULT gen_random_uuid() NOT NULL PRIMARY KEY,
+    proxy_host_id uuid NOT NULL UNIQUE REFERENCES public.proxy_hosts(id) ON DELETE CASCADE,
+    profile character varying(20) DEFAULT 'intermediate'::character varying NOT NULL,
+    protocols text[] DEFAULT '{}'::text[] NOT NULL,
+    ciphers text DEFAULT ''::text NOT NULL,
+    curves character varying(255) DEFAULT ''::character varying NOT NULL,
+    prefer_server_ciphers boolean DEFAULT false NOT NULL,
+    session_tickets boolean DEFAULT false NOT NULL,
+    ocsp_stapling boolean DEFAULT true NOT NULL,
+    hsts_enabled boolean DEFAULT false NOT NULL,
+    hsts_max_age integer DEFAULT 63072000 NOT NULL,
+    hsts_include_subdomains boolean DEFAULT false NOT NULL,
+    hsts_preload boolean DEFAULT false NOT NULL,
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    updated_at timestamp with time zone DEFAULT now() NOT NULL
+);
+COMMENT ON TABLE public.tls_policies IS 'Per-host TLS server policy: protocols, ciphers, curves, session tickets, OCSP stapling and HSTS';
+
+-- ============================================================================
+-- REAL IP (TRUSTED PROXIES)
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS public.real_ip_settings (
+    id uuid DEFAULT gen_random_uuid() NOT NULL PRIMARY KEY,
+    enabled boolean DEFAULT false NOT NULL,
+    header character varying(50) DEFAULT 'X-Forwarded-For'::character varying NOT NULL,
+    recursive boolean DEFAULT true NOT NULL,
+    include_private_ranges boolean DEFAULT true NOT NULL,
+    cloud_providers text[] DEFAULT '{}'::text[] NOT NULL,
+    custom_cidrs text[] DEFAULT '{}'::text[] NOT NULL,
+    proxy_protocol boolean DEFAULT false NOT NULL,
+    proxy_protocol_http_port integer DEFAULT 0 NOT NULL,
+    proxy_protocol_https_port integer DEFAULT 0 NOT NULL,
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    updated_at timestamp with time zone DEFAULT now() NOT NULL
+);
+COMMENT ON TABLE public.real_ip_settings IS 'Trusted proxies/CDNs allowed to report the client IP (set_real_ip_from, real_ip_header)';
+
+-- ============================================================================
+-- CORS POLICIES
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS public.cors_policies (
+    id uuid DEFAULT gen_random_uuid() NOT NULL PRIMARY KEY,
+    proxy_host_id uuid NOT NULL REFERENCES public.proxy_hosts(id) ON DELETE CASCADE,
+    path_prefix character varying(255) DEFAULT ''::character varying NOT NULL,
+    enabled boolean DEFAULT true NOT NULL,
+    allowed_origins text[] DEFAULT '{}'::text[] NOT NULL,
+    allowed_origin_patterns text[] DEFAULT '{}'::text[] NOT NULL,
+    allowed_methods text[] DEFAULT '{}'::text[] NOT NULL,
+    allowed_headers text[] DEFAULT '{}'::text[] NOT NULL,
+    exposed_headers text[] DEFAULT '{}'::text[] NOT NULL,
+    allow_credentials boolean DEFAULT false NOT NULL,
+    max_age integer DEFAULT 0 NOT NULL,
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    updated_at timestamp with time zone DEFAULT now() NOT NULL,
+    UNIQUE (proxy_host_id, path_prefix)
+);
+COMMENT ON TABLE public.cors_policies IS 'Per-host and per-path CORS policies: allowed origins, methods, headers, credentials and preflight max-age';
+
+-- ============================================================================
+-- SIGNED URL LOCATIONS
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS public.signed_url_locations (
+    id uuid DEFAULT gen_random_uuid() NOT NULL PRIMARY KEY,
+    proxy_host_id uuid NOT NULL REFERENCES public.proxy_hosts(id) ON DELETE CASCADE,
+    path_prefix character varying(255) NOT NULL,
+    enabled boolean DEFAULT true NOT NULL,
+    secret character varying(128) NOT NULL,
+    hash_expression character varying(255) NOT NULL,
+    token_param character varying(32) DEFAULT 'md5'::character varying NOT NULL,
+    expires_param character varying(32) DEFAULT 'expires'::character varying NOT NULL,
+    default_ttl integer DEFAULT 3600 NOT NULL,
+    max_ttl integer DEFAULT 604800 NOT NULL,
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    updated_at timestamp with time zone DEFAULT now() NOT NULL,
+    UNIQUE (proxy_host_id, path_prefix)
+);
+COMMENT ON TABLE public.signed_url_locations IS 'Path prefixes of proxy hosts served only through expiring signed links (secure_link)';
+
+-- ============================================================================
+-- USER INVITATIONS
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS public.user_invitations (
+    id uuid DEFAULT gen_random_uuid() NOT NULL PRIMARY KEY,
+    user_id uuid NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    token_hash character varying(64) NOT NULL UNIQUE,
+    expires_at timestamp with time zone NOT NULL,
+    created_by uuid REFERENCES public.users(id) ON DELETE SET NULL,
+    created_at timestamp with time zone DEFAULT now() NOT NULL
+);
+COMMENT ON TABLE public.user_invitations IS 'One-time invitation tokens letting invited users set their own password';
@@ -2,20 +2,73 @@ package handler
 
 import (
 	"encoding/json"
+	"log"
 	"net/http"
+	"strings"
 
 	"github.com/labstack/echo/v4"
+	"golang.org/x/crypto/bcrypt"
 
 	"nginx-proxy-guard/internal/model"
+	"nginx-proxy-guard/internal/nginx"
 	"nginx-proxy-guard/internal/repository"
+	"nginx-proxy-guard/internal/service"
 )
 
+// bcrypt ignores everything after 72 bytes, so longer passwords are rejected
+const maxAccessListPasswordLength = 72
+
 type AccessListHandler struct {
-	repo *repository.AccessListRepository
+	repo             *repository.AccessListRepository
+	proxyHostService *service.ProxyHostService
+	nginxManager     *nginx.Manager
 }
 
-func NewAccessListHandler(repo *repository.AccessListRepository) *AccessListHandler {
-	return &AccessListHandler{repo: repo}
+func NewAccessListHandler(repo *repository.AccessListRepository, proxyHostService *service.ProxyHostService, nginxManager *nginx.Manager) *AccessListHandler {
+	return &AccessListHandler{repo: repo, proxyHostService: proxyHostService, nginxManager: nginxManager}
+}
+
+// prepareAuthUsers validates Basic Auth users and replaces their passwords with bcrypt hashes
+// existing holds the usernames that may be sent without a password (keep current password)
+// Invalid input is reported as a *ValidationError
+func prepareAuthUsers(users []model.AccessListAuthUserRequest, existing map[string]bool) error {
+	seen := make(map[string]bool, len(users))
+	for i := range users {
+		user := &users[i]
+		if user.Username == "" || len(user.Username) > MaxNameLength || strings.ContainsAny(user.Username, ": \t\r\n") {
+			return &ValidationError{Field: "auth_users", Message: "username must be 1-255 characters without spaces or ':'"}
+		}
+		if seen[user.Username] {
+			return &ValidationError{Field: "auth_users", Message: "contains duplicate username " + user.Username}
+		}
+		seen[user.Username] = true
+
+		if user.Password == "" {
+			if !existing[user.Username] {
+				return &ValidationError{Field: "auth_users", Message: "password is required for new user " + user.Username}
+			}
+			continue
+		}
+		if len(user.Password) > maxAccessListPasswordLength {
+			return &ValidationError{Field: "auth_users", Message: "password must be at most 72 bytes"}
+		}
+
+		hash, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return err
+		}
+		user.PasswordHash = string(hash)
+		user.Password = ""
+	}
+	return nil
+}
+
+// regenerateHosts applies access list changes to the proxy hosts using it
+func (h *AccessListHandler) regenerateHosts(c echo.Context, hostIDs []string) error {
+	if h.proxyHostService == nil {
+		return nil
+	}
+	return h.proxyHostService.RegenerateConfigsForAccessList(c.Request().Context(), hostIDs)
 }
 
 // List returns a paginated list of access lists.
@@ -54,6 +107,13 @@ func (h *AccessListHandler) Create(c echo.Context) error {
 		return validationError(c, "name", err.(*ValidationError).Message)
 	}
 
+	if err := prepareAuthUsers(req.AuthUsers, nil); err != nil {
+		if verr, ok := err.(*ValidationError); ok {
+			return validationError(c, verr.Field, verr.Message)
+		}
+		return internalError(c, "hash access list password", err)
+	}
+
 	list, err := h.repo.Create(c.Request().Context(), &req)
 	if err != nil {
 		return databaseError(c, "create access list", err)
@@ -90,7 +150,29 @@ func (h *AccessListHandler) Update(c echo.Context) error {
 		}
 	}
 
-	list, err := h.repo.Update(c.Request().Context(), id, &req)
+	ctx := c.Request().Context()
+	if req.AuthUsers != nil {
+		existing, err := h.repo.GetByID(ctx, id)
+		if err != nil {
+			return databaseError(c, "get access list", err)
+		}
+		if existing == nil {
+			return notFoundError(c, "Access list")
+		}
+		usernames := make(map[string]bool, len(existing.AuthUsers))
+		for _, user := range existing.AuthUsers {
+			usernames[user.Username] = true
+		}
+
+		if err := prepareAuthUsers(req.AuthUsers, usernames); err != nil {
+			if verr, ok := err.(*ValidationError); ok {
+				return validationError(c, verr.Field, verr.Message)
+			}
+			return internalError(c, "hash access list password", err)
+		}
+	}
+
+	list, err := h.repo.Update(ctx, id, &req)
 	if err != nil {
 		return databaseError(c, "update access list", err)
 	}
@@ -98,14 +180,43 @@ func (h *AccessListHandler) Update(c echo.Context) error {
 		return notFoundError(c, "Access list")
 	}
 
+	// Apply the new rules and users to every host using this list
+	hostIDs, err := h.repo.GetProxyHostIDs(ctx, id)
+	if err != nil {
+		return databaseError(c, "get access list hosts", err)
+	}
+	if err := h.regenerateHosts(c, hostIDs); err != nil {
+		return internalError(c, "regenerate nginx configs for access list", err)
+	}
+
 	return c.JSON(http.StatusOK, list)
 }
 
 // Delete removes an access list by ID.
 func (h *AccessListHandler) Delete(c echo.Context) error {
 	id := c.Param("id")
-	if err := h.repo.Delete(c.Request().Context(), id); err != nil {
+	ctx := c.Request().Context()
+
+	// Collect hosts before the delete clears their access_list_id
+	hostIDs, err := h.repo.GetProxyHostIDs(ctx, id)
+	if err != nil {
+		return databaseError(c, "get access list hosts", err)
+	}
+
+	if err := h.repo.Delete(ctx, id); err != nil {
 		return databaseError(c, "delete access list", err)
 	}
+
+	if err := h.regenerateHosts(c, hostIDs); err != nil {
+		return internalError(c, "regenerate nginx configs for access list", err)
+	}
+
+	// Hosts no longer reference the htpasswd file once regenerated
+	if h.nginxManager != nil {
+		if err := h.nginxManager.RemoveAccessListHtpasswd(id); err != nil {
+			log.Printf("[AccessList] Warning: failed to remove htpasswd for %s: %v", id, err)
+		}
+	}
+
 	return noContentResponse(c)
 }
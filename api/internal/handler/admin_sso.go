@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+
+	"github.com/labstack/echo/v4"
+
+	"nginx-proxy-guard/internal/model"
+	"nginx-proxy-guard/internal/service"
+)
+
+// adminSSOCookiePath scopes the state cookie to the SSO login endpoints
+const adminSSOCookiePath = "/api/v1/auth/sso/"
+
+type AdminSSOHandler struct {
+	service *service.AdminSSOService
+	audit   *service.AuditService
+}
+
+func NewAdminSSOHandler(adminSSOService *service.AdminSSOService, audit *service.AuditService) *AdminSSOHandler {
+	return &AdminSSOHandler{
+		service: adminSSOService,
+		audit:   audit,
+	}
+}
+
+// GetSettings returns the admin single sign-on settings
+func (h *AdminSSOHandler) GetSettings(c echo.Context) error {
+	settings, err := h.service.GetSettings(c.Request().Context())
+	if err != nil {
+		return databaseError(c, "get admin SSO settings", err)
+	}
+	return c.JSON(http.StatusOK, settings)
+}
+
+// UpdateSettings updates the admin single sign-on settings
+func (h *AdminSSOHandler) UpdateSettings(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	var req model.UpdateAdminSSOSettingsRequest
+	if err := c.Bind(&req); err != nil {
+		return badRequestError(c, "Invalid request body")
+	}
+
+	settings, err := h.service.UpdateSettings(ctx, &req)
+	if err != nil {
+		var uerr *service.UserError
+		if errors.As(err, &uerr) {
+			return validationError(c, uerr.Field, uerr.Message)
+		}
+		return databaseError(c, "update admin SSO settings", err)
+	}
+
+	// Audit log
+	auditCtx := service.ContextWithAudit(ctx, c)
+	h.audit.LogSettingsUpdate(auditCtx, "Admin SSO", map[string]interface{}{
+		"enabled":               settings.Enabled,
+		"provider_id":           settings.ProviderID,
+		"role_claim":            settings.RoleClaim,
+		"role_mappings":         settings.RoleMappings,
+		"default_role":          settings.DefaultRole,
+		"auto_provision":        settings.AutoProvision,
+		"allowed_email_domains": settings.AllowedEmailDomains,
+		"disable_local_login":   settings.DisableLocalLogin,
+		"break_glass_username":  settings.BreakGlassUsername,
+	})
+
+	return c.JSON(http.StatusOK, settings)
+}
+
+// === Public login flow ===
+
+// Status tells the login page whether to offer single sign-on and password login
+func (h *AdminSSOHandler) Status(c echo.Context) error {
+	status, err := h.service.Status(c.Request().Context())
+	if err != nil {
+		return databaseError(c, "get admin SSO status", err)
+	}
+	return c.JSON(http.StatusOK, status)
+}
+
+// Login redirects to the identity provider
+func (h *AdminSSOHandler) Login(c echo.Context) error {
+	redirectURI := c.Scheme() + "://" + c.Request().Host + adminSSOCookiePath + "callback"
+
+	start, err := h.service.BeginLogin(c.Request().Context(), redirectURI)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrAdminSSODisabled):
+			return c.HTML(http.StatusNotFound, oidcErrorPage("Not Found", "Single sign-on is not enabled."))
+		case errors.Is(err, service.ErrOIDCProviderDisabled):
+			return c.HTML(http.StatusServiceUnavailable, oidcErrorPage("Login Unavailable", "The identity provider is disabled."))
+		}
+		log.Printf("[AdminSSO] Failed to start login: %v", err)
+		return c.HTML(http.StatusBadGateway, oidcErrorPage("Login Unavailable", "The identity provider could not be reached."))
+	}
+
+	c.SetCookie(&http.Cookie{
+		Name:     service.AdminSSOStateCookie,
+		Value:    start.StateCookie,
+		Path:     adminSSOCookiePath,
+		MaxAge:   600,
+		HttpOnly: true,
+		Secure:   c.Scheme() == "https",
+		SameSite: http.SameSiteLaxMode,
+	})
+	return c.Redirect(http.StatusFound, start.AuthorizationURL)
+}
+
+// Callback completes the login and hands a one-time code to the UI, which
+// redeems it through Exchange so the session token never appears in a URL
+func (h *AdminSSOHandler) Callback(c echo.Context) error {
+	if c.QueryParam("error") != "" {
+		return c.HTML(http.StatusForbidden, oidcErrorPage("Login Failed", "The identity provider did not complete the login."))
+	}
+
+	stateCookie, err := c.Cookie(service.AdminSSOStateCookie)
+	if err != nil {
+		return c.HTML(http.StatusBadRequest, oidcErrorPage("Login Expired", "Your login took too long. Please try again."))
+	}
+
+	c.SetCookie(&http.Cookie{
+		Name:     service.AdminSSOStateCookie,
+		Path:     adminSSOCookiePath,
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   c.Scheme() == "https",
+	})
+
+	code, err := h.service.CompleteLogin(c.Request().Context(), stateCookie.Value, c.QueryParam("state"), c.QueryParam("code"), c.RealIP())
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrOIDCInvalidState):
+			return c.HTML(http.StatusBadRequest, oidcErrorPage("Login Expired", "Your login took too long. Please try again."))
+		case errors.Is(err, service.ErrOIDCAccessDenied):
+			return c.HTML(http.StatusForbidden, oidcErrorPage("Access Denied", "Your account is not allowed to manage this server."))
+		case errors.Is(err, service.ErrAdminSSONoAccount):
+			return c.HTML(http.StatusForbidden, oidcErrorPage("Access Denied", "No account is linked to your identity. Ask an administrator to invite you."))
+		case errors.Is(err, service.ErrAccountDisabled):
+			return c.HTML(http.StatusForbidden, oidcErrorPage("Access Denied", "Your account is disabled."))
+		case errors.Is(err, service.ErrAdminSSODisabled):
+			return c.HTML(http.StatusNotFound, oidcErrorPage("Not Found", "Single sign-on is not enabled."))
+		case errors.Is(err, service.ErrOIDCProviderDisabled):
+			return c.HTML(http.StatusServiceUnavailable, oidcErrorPage("Login Unavailable", "The identity provider is disabled."))
+		}
+		log.Printf("[AdminSSO] Login failed: %v", err)
+		return c.HTML(http.StatusBadGateway, oidcErrorPage("Login Failed", "The login could not be verified."))
+	}
+
+	return c.Redirect(http.StatusFound, "/?sso_code="+url.QueryEscape(code))
+}
+
+// Exchange redeems the one-time code of a completed SSO login for a session
+func (h *AdminSSOHandler) Exchange(c echo.Context) error {
+	var req model.SSOExchangeRequest
+	if err := c.Bind(&req); err != nil {
+		return badRequestError(c, "Invalid request body")
+	}
+	if req.Code == "" {
+		return badRequestError(c, "Code is required")
+	}
+
+	ip := c.RealIP()
+	userAgent := c.Request().UserAgent()
+
+	login, err := h.service.Exchange(c.Request().Context(), req.Code, ip, userAgent)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidSSOCode):
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Invalid or expired sign-on code"})
+		case errors.Is(err, service.ErrAccountDisabled):
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "Account is disabled"})
+		}
+		return internalError(c, "exchange SSO code", err)
+	}
+
+	// For login, set user info directly since middleware hasn't set it yet
+	user := login.Response.User
+	ctx := c.Request().Context()
+	ctx = context.WithValue(ctx, "user_id", user.ID)
+	ctx = context.WithValue(ctx, "username", user.Username)
+	ctx = context.WithValue(ctx, "client_ip", ip)
+	ctx = context.WithValue(ctx, "user_agent", userAgent)
+	h.audit.LogUserSSOLogin(ctx, user.Username, login.ProviderName, ip, userAgent, login.Provisioned)
+
+	return c.JSON(http.StatusOK, login.Response)
+}
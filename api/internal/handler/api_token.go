@@ -68,6 +68,26 @@ func (h *APITokenHandler) CreateToken(c echo.Context) error {
 		if !isValidPermission(perm) {
 			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid permission: " + perm})
 		}
+		if !isGrantablePermission(c, perm) {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "permission exceeds your role: " + perm})
+		}
+	}
+
+	// Validate resource scope; tokens minted by a scoped token inherit its scope
+	var scope model.APITokenScope
+	if req.Scope != nil {
+		normalized, err := req.Scope.Normalize()
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		scope = normalized
+	}
+	if callerScope, scoped := callerTokenScope(c); scoped {
+		if req.Scope == nil {
+			scope = callerScope
+		} else if !scope.Equal(callerScope) {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "scope exceeds your token scope"})
+		}
 	}
 
 	// Generate token
@@ -97,6 +117,7 @@ func (h *APITokenHandler) CreateToken(c echo.Context) error {
 		RateLimit:   req.RateLimit,
 		ExpiresAt:   expiresAt,
 		IsActive:    true,
+		Scope:       scope,
 	}
 
 	if err := h.tokenRepo.Create(c.Request().Context(), apiToken); err != nil {
@@ -104,19 +125,23 @@ func (h *APITokenHandler) CreateToken(c echo.Context) error {
 	}
 
 	// Audit log
+	details := map[string]interface{}{
+		"token_name":   req.Name,
+		"token_prefix": prefix,
+		"permissions":  req.Permissions,
+	}
+	if !scope.IsEmpty() {
+		details["scope"] = scope
+	}
 	h.auditRepo.Log(c.Request().Context(), &model.AuditLogEntry{
 		UserID:     userID,
 		Username:   username,
 		Action:     "api_token_created",
 		Resource:   "api_token",
 		ResourceID: apiToken.ID,
-		Details: map[string]interface{}{
-			"token_name":   req.Name,
-			"token_prefix": prefix,
-			"permissions":  req.Permissions,
-		},
-		IPAddress: c.RealIP(),
-		UserAgent: c.Request().UserAgent(),
+		Details:    details,
+		IPAddress:  c.RealIP(),
+		UserAgent:  c.Request().UserAgent(),
 	})
 
 	// Return token with secret (only time it's shown)
@@ -226,26 +251,48 @@ func (h *APITokenHandler) UpdateToken(c echo.Context) error {
 			if !isValidPermission(perm) {
 				return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid permission: " + perm})
 			}
+			if !isGrantablePermission(c, perm) {
+				return c.JSON(http.StatusForbidden, map[string]string{"error": "permission exceeds your role: " + perm})
+			}
+		}
+	}
+
+	// Validate resource scope if provided; a scoped token can't widen it
+	if req.Scope != nil {
+		scope, err := req.Scope.Normalize()
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		if callerScope, scoped := callerTokenScope(c); scoped && !scope.Equal(callerScope) {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "scope exceeds your token scope"})
 		}
+		req.Scope = &scope
 	}
 
 	if err := h.tokenRepo.Update(c.Request().Context(), tokenID, &req); err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to update token"})
 	}
 
-	// Audit log
+	// Audit log; scope changes get their own action so they can be listed per token
+	action := "api_token_updated"
+	details := map[string]interface{}{
+		"token_name":   token.Name,
+		"token_prefix": token.TokenPrefix,
+	}
+	if req.Scope != nil && !req.Scope.Equal(token.Scope) {
+		action = "api_token_scope_changed"
+		details["old_scope"] = token.Scope
+		details["new_scope"] = *req.Scope
+	}
 	h.auditRepo.Log(c.Request().Context(), &model.AuditLogEntry{
 		UserID:     userID,
 		Username:   username,
-		Action:     "api_token_updated",
+		Action:     action,
 		Resource:   "api_token",
 		ResourceID: tokenID,
-		Details: map[string]interface{}{
-			"token_name":   token.Name,
-			"token_prefix": token.TokenPrefix,
-		},
-		IPAddress: c.RealIP(),
-		UserAgent: c.Request().UserAgent(),
+		Details:    details,
+		IPAddress:  c.RealIP(),
+		UserAgent:  c.Request().UserAgent(),
 	})
 
 	// Return updated token
@@ -396,9 +443,21 @@ func (h *APITokenHandler) GetTokenUsage(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to get usage stats"})
 	}
 
+	// Scope change history from the audit log
+	scopeChanges, _, err := h.auditRepo.List(c.Request().Context(), repository.AuditLogFilter{
+		Action:       "api_token_scope_changed",
+		ResourceType: "api_token",
+		ResourceID:   tokenID,
+		Limit:        20,
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to get scope changes"})
+	}
+
 	return c.JSON(http.StatusOK, map[string]interface{}{
-		"token":  token.ToResponse(),
-		"usages": usages,
+		"token":         token.ToResponse(),
+		"usages":        usages,
+		"scope_changes": scopeChanges,
 	})
 }
 
@@ -424,6 +483,35 @@ func isValidPermission(perm string) bool {
 	return false
 }
 
+// isGrantablePermission checks that the caller holds the permission it puts on a
+// token: a token never gets more than its creator's role (or, when created with
+// another API token, more than that token) allows
+func isGrantablePermission(c echo.Context, perm string) bool {
+	return callerPermissionCheck(c)(perm)
+}
+
+// callerPermissionCheck reports the permissions of the caller: those of the
+// API token used, or else those of the signed-in user's role
+func callerPermissionCheck(c echo.Context) func(permission string) bool {
+	if token, ok := c.Get("api_token").(*model.APIToken); ok && token != nil {
+		return token.HasPermission
+	}
+	role, _ := getContextString(c, "role")
+	return func(permission string) bool {
+		return model.RoleHasPermission(role, permission)
+	}
+}
+
+// callerTokenScope returns the resource scope of the API token making the
+// request, if the request uses a scoped token
+func callerTokenScope(c echo.Context) (model.APITokenScope, bool) {
+	token, ok := c.Get("api_token").(*model.APIToken)
+	if !ok || token == nil || token.Scope.IsEmpty() {
+		return model.APITokenScope{}, false
+	}
+	return token.Scope, true
+}
+
 func parseExpiry(s string) (*time.Time, error) {
 	var duration time.Duration
 	var multiplier int
@@ -218,6 +218,7 @@ func formatActionLabel(action string) string {
 		"waf_rules_updated":        "WAF 규칙 수정",
 		"settings_updated":         "설정 변경",
 		"user_login":               "로그인",
+		"user_sso_login":           "SSO 로그인",
 		"user_logout":              "로그아웃",
 		"user_created":             "사용자 생성",
 		"user_updated":             "사용자 수정",
@@ -226,6 +227,22 @@ func formatActionLabel(action string) string {
 		"username_changed":         "사용자명 변경",
 		"totp_enabled":             "2FA 활성화",
 		"totp_disabled":            "2FA 비활성화",
+		"webauthn_registered":      "보안 키 등록",
+		"webauthn_removed":         "보안 키 삭제",
+		"session_revoked":          "세션 종료",
+		"sessions_revoked":         "전체 세션 종료",
+		"password_breach_rejected": "유출 비밀번호 거부",
+		"password_breach_override": "유출 비밀번호 허용",
+		"login_locked_out":         "로그인 잠금",
+		"account_unlocked":         "계정 잠금 해제",
+		"breach_list_updated":      "유출 비밀번호 목록 변경",
+		"change_requested":         "변경 승인 요청",
+		"change_approved":          "변경 승인",
+		"change_rejected":          "변경 거부",
+		"change_cancelled":         "변경 요청 취소",
+		"change_expired":           "변경 요청 만료",
+		"change_applied":           "승인된 변경 적용",
+		"change_failed":            "승인된 변경 적용 실패",
 		"backup_created":           "백업 생성",
 		"backup_restored":          "백업 복원",
 		"backup_deleted":           "백업 삭제",
@@ -268,15 +285,16 @@ func formatActionLabel(action string) string {
 
 func formatResourceTypeLabel(resourceType string) string {
 	labels := map[string]string{
-		"proxy_host":    "프록시 호스트",
-		"certificate":   "인증서",
-		"waf":           "WAF",
-		"settings":      "설정",
-		"user":          "사용자",
-		"backup":        "백업",
-		"api_token":     "API 토큰",
-		"access_list":   "접근 목록",
-		"redirect_host": "리다이렉트 호스트",
+		"proxy_host":     "프록시 호스트",
+		"certificate":    "인증서",
+		"waf":            "WAF",
+		"settings":       "설정",
+		"user":           "사용자",
+		"backup":         "백업",
+		"api_token":      "API 토큰",
+		"access_list":    "접근 목록",
+		"redirect_host":  "리다이렉트 호스트",
+		"change_request": "변경 요청",
 	}
 
 	if label, ok := labels[resourceType]; ok {
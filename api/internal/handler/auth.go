@@ -2,8 +2,11 @@ package handler
 
 import (
 	"context"
+	"errors"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/labstack/echo/v4"
 
@@ -23,6 +26,38 @@ func NewAuthHandler(authService *service.AuthService, auditService *service.Audi
 	}
 }
 
+// tooManyAttempts answers a login refused by a lockout and records the first
+// refusal of every lockout in the audit log
+func tooManyAttempts(c echo.Context, audit *service.AuditService, err error) error {
+	var lockout *service.LockoutError
+	if errors.As(err, &lockout) {
+		if lockout.First {
+			auditCtx := service.ContextWithAudit(c.Request().Context(), c)
+			audit.LogLoginLockout(auditCtx, lockout.Scope, lockout.Value, lockout.Until)
+		}
+		retryAfter := int(time.Until(lockout.Until).Seconds()) + 1
+		c.Response().Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	}
+	return c.JSON(http.StatusTooManyRequests, map[string]string{
+		"error": "Too many failed login attempts. Please try again later.",
+	})
+}
+
+// passwordPolicyError answers a new password refused by the password policy,
+// or returns nil for any other error
+func (h *AuthHandler) passwordPolicyError(c echo.Context, username string, err error) error {
+	var uerr *service.UserError
+	switch {
+	case errors.As(err, &uerr):
+		return validationError(c, "new_password", uerr.Message)
+	case errors.Is(err, service.ErrBreachedPassword):
+		auditCtx := service.ContextWithAudit(c.Request().Context(), c)
+		h.auditService.LogPasswordBreachRejected(auditCtx, username)
+		return badRequestError(c, "This password appears in a list of breached passwords. Choose a different one.")
+	}
+	return nil
+}
+
 // getUserFromContext safely extracts user from echo context
 func getUserFromContext(c echo.Context) (*model.User, bool) {
 	val := c.Get("user")
@@ -53,14 +88,34 @@ func (h *AuthHandler) Login(c echo.Context) error {
 
 	resp, err := h.authService.Login(c.Request().Context(), &req, ip, userAgent)
 	if err != nil {
+		if errors.Is(err, service.ErrLDAPUnavailable) {
+			return c.JSON(http.StatusServiceUnavailable, map[string]string{
+				"error": "Directory server is unavailable",
+			})
+		}
+		if errors.Is(err, service.ErrTooManyAttempts) {
+			return tooManyAttempts(c, h.auditService, err)
+		}
 		switch err {
 		case service.ErrInvalidCredentials:
 			return c.JSON(http.StatusUnauthorized, map[string]string{
 				"error": "Invalid username or password",
 			})
-		case service.ErrTooManyAttempts:
-			return c.JSON(http.StatusTooManyRequests, map[string]string{
-				"error": "Too many failed login attempts. Please try again later.",
+		case service.ErrAccountDisabled:
+			return c.JSON(http.StatusForbidden, map[string]string{
+				"error": "Account is disabled",
+			})
+		case service.ErrLDAPAccessDenied:
+			return c.JSON(http.StatusForbidden, map[string]string{
+				"error": "Your directory account is not allowed to log in",
+			})
+		case service.ErrLocalLoginDisabled:
+			return c.JSON(http.StatusForbidden, map[string]string{
+				"error": "Password login is disabled. Sign in with single sign-on.",
+			})
+		case service.ErrSecurityKeyRequired:
+			return c.JSON(http.StatusForbidden, map[string]string{
+				"error": "A hardware security key is required for this account. Ask another administrator for help.",
 			})
 		default:
 			return c.JSON(http.StatusInternalServerError, map[string]string{
@@ -184,6 +239,9 @@ func (h *AuthHandler) ChangeCredentials(c echo.Context) error {
 
 	err := h.authService.ChangeCredentials(c.Request().Context(), user.ID, &req)
 	if err != nil {
+		if perr := h.passwordPolicyError(c, user.Username, err); perr != nil {
+			return perr
+		}
 		switch err {
 		case service.ErrInvalidCredentials:
 			return c.JSON(http.StatusUnauthorized, map[string]string{
@@ -241,6 +299,9 @@ func (h *AuthHandler) ChangePassword(c echo.Context) error {
 
 	err := h.authService.ChangePassword(c.Request().Context(), user.ID, &req)
 	if err != nil {
+		if perr := h.passwordPolicyError(c, user.Username, err); perr != nil {
+			return perr
+		}
 		switch err {
 		case service.ErrInvalidCredentials:
 			return c.JSON(http.StatusUnauthorized, map[string]string{
@@ -290,6 +351,9 @@ func (h *AuthHandler) Verify2FA(c echo.Context) error {
 
 	resp, err := h.authService.Verify2FA(c.Request().Context(), &req, ip)
 	if err != nil {
+		if errors.Is(err, service.ErrTooManyAttempts) {
+			return tooManyAttempts(c, h.auditService, err)
+		}
 		switch err {
 		case service.ErrInvalidTempToken:
 			return c.JSON(http.StatusUnauthorized, map[string]string{
@@ -299,6 +363,14 @@ func (h *AuthHandler) Verify2FA(c echo.Context) error {
 			return c.JSON(http.StatusUnauthorized, map[string]string{
 				"error": "Invalid 2FA code",
 			})
+		case service.ErrAccountDisabled:
+			return c.JSON(http.StatusForbidden, map[string]string{
+				"error": "Account is disabled",
+			})
+		case service.ErrSecurityKeyRequired:
+			return c.JSON(http.StatusForbidden, map[string]string{
+				"error": "A hardware security key is required for this account",
+			})
 		default:
 			return c.JSON(http.StatusInternalServerError, map[string]string{
 				"error": "2FA verification failed",
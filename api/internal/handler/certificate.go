@@ -3,6 +3,8 @@ package handler
 import (
 	"archive/zip"
 	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
@@ -17,19 +19,32 @@ import (
 )
 
 type CertificateHandler struct {
-	service *service.CertificateService
-	audit   *service.AuditService
+	service   *service.CertificateService
+	audit     *service.AuditService
+	approvals *service.ChangeRequestService
 }
 
 func NewCertificateHandler(svc *service.CertificateService, audit *service.AuditService) *CertificateHandler {
 	return &CertificateHandler{service: svc, audit: audit}
 }
 
+// SetApprovals lets certificate deletion require approval by a second user
+func (h *CertificateHandler) SetApprovals(approvals *service.ChangeRequestService) {
+	h.approvals = approvals
+	approvals.Register(model.ChangeActionCertificateDelete, model.PermissionCertDelete, h.applyDelete)
+}
+
+// certificateDeleteChange is the payload of a certificate delete change request
+type certificateDeleteChange struct {
+	CertificateID string `json:"certificate_id"`
+}
+
 // List handles GET /api/v1/certificates
 func (h *CertificateHandler) List(c echo.Context) error {
 	page, perPage := ParsePaginationParams(c)
 
-	response, err := h.service.List(c.Request().Context(), page, perPage)
+	scope, _ := callerTokenScope(c)
+	response, err := h.service.ListInScope(c.Request().Context(), scope, page, perPage)
 	if err != nil {
 		return databaseError(c, "list certificates", err)
 	}
@@ -49,6 +64,9 @@ func (h *CertificateHandler) Get(c echo.Context) error {
 	if cert == nil {
 		return notFoundError(c, "Certificate")
 	}
+	if err := h.checkScope(c, id); err != nil {
+		return err
+	}
 
 	return c.JSON(http.StatusOK, cert.ToWithDetails())
 }
@@ -67,6 +85,9 @@ func (h *CertificateHandler) Create(c echo.Context) error {
 			"error": "domain_names is required",
 		})
 	}
+	if err := h.checkDomainsScope(c, req.DomainNames); err != nil {
+		return err
+	}
 
 	cert, err := h.service.Create(c.Request().Context(), &req)
 	if err != nil {
@@ -95,6 +116,10 @@ func (h *CertificateHandler) Upload(c echo.Context) error {
 		})
 	}
 
+	if err := h.checkUploadScope(c, &req); err != nil {
+		return err
+	}
+
 	cert, err := h.service.UploadCustom(c.Request().Context(), &req)
 	if err != nil {
 		return badRequestError(c, "Invalid certificate or key format")
@@ -124,6 +149,13 @@ func (h *CertificateHandler) UpdateUpload(c echo.Context) error {
 		})
 	}
 
+	if err := h.checkScope(c, id); err != nil {
+		return err
+	}
+	if err := h.checkUploadScope(c, &req); err != nil {
+		return err
+	}
+
 	cert, err := h.service.UpdateCustom(c.Request().Context(), id, &req)
 	if err != nil {
 		if errors.Is(err, model.ErrCustomCertOnly) {
@@ -148,9 +180,31 @@ func (h *CertificateHandler) UpdateUpload(c echo.Context) error {
 func (h *CertificateHandler) Delete(c echo.Context) error {
 	id := c.Param("id")
 
+	if err := h.checkScope(c, id); err != nil {
+		return err
+	}
+
 	// Get certificate info before deletion for audit
 	cert, _ := h.service.GetByID(c.Request().Context(), id)
 
+	if required, err := approvalRequired(c, h.approvals, model.ChangeActionCertificateDelete); err != nil {
+		return databaseError(c, "check change approval", err)
+	} else if required {
+		if cert == nil {
+			return notFoundError(c, "Certificate")
+		}
+		diff, err := service.FieldDiff(cert, nil)
+		if err != nil {
+			return internalError(c, "render certificate diff", err)
+		}
+		return submitChange(c, h.approvals, h.audit, &model.ChangeRequest{
+			Action:     model.ChangeActionCertificateDelete,
+			TargetID:   cert.ID,
+			TargetName: strings.Join(cert.DomainNames, ", "),
+			Diff:       diff,
+		}, certificateDeleteChange{CertificateID: cert.ID})
+	}
+
 	err := h.service.Delete(c.Request().Context(), id)
 	if err != nil {
 		if err == model.ErrNotFound {
@@ -168,10 +222,38 @@ func (h *CertificateHandler) Delete(c echo.Context) error {
 	return c.NoContent(http.StatusNoContent)
 }
 
+// applyDelete applies an approved certificate delete change request
+func (h *CertificateHandler) applyDelete(ctx context.Context, payload json.RawMessage) error {
+	var change certificateDeleteChange
+	if err := json.Unmarshal(payload, &change); err != nil {
+		return fmt.Errorf("invalid change request payload: %w", err)
+	}
+
+	cert, err := h.service.GetByID(ctx, change.CertificateID)
+	if err != nil {
+		return err
+	}
+	if err := h.service.Delete(ctx, change.CertificateID); err != nil {
+		if err == model.ErrNotFound {
+			return fmt.Errorf("certificate no longer exists")
+		}
+		return err
+	}
+
+	if cert != nil {
+		h.audit.LogCertificateDelete(ctx, cert.DomainNames)
+	}
+	return nil
+}
+
 // Renew handles POST /api/v1/certificates/:id/renew
 func (h *CertificateHandler) Renew(c echo.Context) error {
 	id := c.Param("id")
 
+	if err := h.checkScope(c, id); err != nil {
+		return err
+	}
+
 	// Get certificate info for audit
 	cert, _ := h.service.GetByID(c.Request().Context(), id)
 
@@ -203,10 +285,20 @@ func (h *CertificateHandler) GetExpiring(c echo.Context) error {
 		return databaseError(c, "get expiring certificates", err)
 	}
 
-	// Convert to details
-	result := make([]model.CertificateWithDetails, len(certs))
-	for i, cert := range certs {
-		result[i] = cert.ToWithDetails()
+	// Convert to details, keeping only certificates in the token scope
+	scope, scoped := callerTokenScope(c)
+	result := make([]model.CertificateWithDetails, 0, len(certs))
+	for _, cert := range certs {
+		if scoped {
+			inScope, err := h.service.InScope(c.Request().Context(), scope, cert.ID)
+			if err != nil {
+				return databaseError(c, "check certificate scope", err)
+			}
+			if !inScope {
+				continue
+			}
+		}
+		result = append(result, cert.ToWithDetails())
 	}
 
 	return c.JSON(http.StatusOK, map[string]interface{}{
@@ -219,6 +311,9 @@ func (h *CertificateHandler) GetExpiring(c echo.Context) error {
 // Returns the real-time logs for a certificate issuance process
 func (h *CertificateHandler) GetLogs(c echo.Context) error {
 	id := c.Param("id")
+	if err := h.checkScope(c, id); err != nil {
+		return err
+	}
 
 	logs, err := h.service.GetCertLogs(c.Request().Context(), id)
 	if err != nil {
@@ -237,6 +332,16 @@ func (h *CertificateHandler) ListHistory(c echo.Context) error {
 	page, perPage := ParsePaginationParams(c)
 	certificateID := c.QueryParam("certificate_id")
 
+	// History isn't filtered by scope, so scoped tokens must ask for one certificate
+	if _, scoped := callerTokenScope(c); scoped {
+		if certificateID == "" {
+			return badRequestError(c, "certificate_id is required for scoped tokens")
+		}
+		if err := h.checkScope(c, certificateID); err != nil {
+			return err
+		}
+	}
+
 	response, err := h.service.ListHistory(c.Request().Context(), page, perPage, certificateID)
 	if err != nil {
 		return databaseError(c, "list certificate history", err)
@@ -263,6 +368,9 @@ func (h *CertificateHandler) Download(c echo.Context) error {
 	if cert == nil {
 		return notFoundError(c, "Certificate")
 	}
+	if err := h.checkScope(c, id); err != nil {
+		return err
+	}
 
 	// Check if certificate is issued
 	if cert.Status != model.CertStatusIssued {
@@ -359,3 +467,53 @@ func (h *CertificateHandler) Download(c echo.Context) error {
 		})
 	}
 }
+
+// checkScope returns the error response when a scoped API token targets a
+// certificate outside its scope, and nil when the request may proceed
+func (h *CertificateHandler) checkScope(c echo.Context, id string) error {
+	scope, scoped := callerTokenScope(c)
+	if !scoped {
+		return nil
+	}
+	inScope, err := h.service.InScope(c.Request().Context(), scope, id)
+	if err != nil {
+		return databaseError(c, "check certificate scope", err)
+	}
+	if !inScope {
+		return outOfScopeError(c, "Certificate")
+	}
+	return nil
+}
+
+// checkDomainsScope is checkScope for a certificate that doesn't exist yet
+func (h *CertificateHandler) checkDomainsScope(c echo.Context, domains []string) error {
+	scope, scoped := callerTokenScope(c)
+	if !scoped {
+		return nil
+	}
+	inScope, err := h.service.DomainsInScope(c.Request().Context(), scope, domains)
+	if err != nil {
+		return databaseError(c, "check certificate scope", err)
+	}
+	if !inScope {
+		return outOfScopeError(c, "Certificate")
+	}
+	return nil
+}
+
+// checkUploadScope checks the domains a custom certificate will be stored
+// under: the requested ones, or else those in the certificate itself
+func (h *CertificateHandler) checkUploadScope(c echo.Context, req *model.UploadCertificateRequest) error {
+	if _, scoped := callerTokenScope(c); !scoped {
+		return nil
+	}
+	domains := req.DomainNames
+	if len(domains) == 0 {
+		parsed, _, err := acme.ValidateCertificate(req.CertificatePEM)
+		if err != nil {
+			return badRequestError(c, "Invalid certificate or key format")
+		}
+		domains = parsed
+	}
+	return h.checkDomainsScope(c, domains)
+}
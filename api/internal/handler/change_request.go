@@ -0,0 +1,221 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"nginx-proxy-guard/internal/model"
+	"nginx-proxy-guard/internal/service"
+)
+
+type ChangeRequestHandler struct {
+	service *service.ChangeRequestService
+	audit   *service.AuditService
+}
+
+func NewChangeRequestHandler(changeRequests *service.ChangeRequestService, audit *service.AuditService) *ChangeRequestHandler {
+	return &ChangeRequestHandler{
+		service: changeRequests,
+		audit:   audit,
+	}
+}
+
+// changeRequestError maps change request service errors to responses
+func changeRequestError(c echo.Context, operation string, err error) error {
+	switch {
+	case errors.Is(err, service.ErrChangeRequestNotFound):
+		return notFoundError(c, "Change request")
+	case errors.Is(err, service.ErrChangeRequestClosed):
+		return conflictError(c, err.Error())
+	case errors.Is(err, service.ErrSelfApproval),
+		errors.Is(err, service.ErrReviewerRole),
+		errors.Is(err, service.ErrNotRequester):
+		return c.JSON(http.StatusForbidden, map[string]string{"error": err.Error()})
+	}
+	return databaseError(c, operation, err)
+}
+
+// approvalSettingsError maps approval settings validation errors to responses
+func approvalSettingsError(c echo.Context, err error) error {
+	var uerr *service.UserError
+	if errors.As(err, &uerr) {
+		return validationError(c, uerr.Field, uerr.Message)
+	}
+	return databaseError(c, "update approval settings", err)
+}
+
+// approvalRequired reports whether action has to go through a change
+// request; without an approval service nothing does
+func approvalRequired(c echo.Context, approvals *service.ChangeRequestService, action string) (bool, error) {
+	if approvals == nil {
+		return false, nil
+	}
+	return approvals.Required(c.Request().Context(), action)
+}
+
+// submitChange stores cr for approval and answers 202 Accepted with it
+func submitChange(c echo.Context, approvals *service.ChangeRequestService, audit *service.AuditService, cr *model.ChangeRequest, payload interface{}) error {
+	cr.RequestedBy, _ = getContextString(c, "user_id")
+	cr.RequestedByName, _ = getContextString(c, "username")
+
+	created, err := approvals.Submit(c.Request().Context(), cr, payload)
+	if err != nil {
+		return databaseError(c, "submit change request", err)
+	}
+
+	auditCtx := service.ContextWithAudit(c.Request().Context(), c)
+	audit.LogChangeRequested(auditCtx, created)
+
+	return c.JSON(http.StatusAccepted, map[string]interface{}{
+		"message":        "This change needs approval by another user before it is applied",
+		"change_request": created,
+	})
+}
+
+// GetSettings returns the approval settings
+func (h *ChangeRequestHandler) GetSettings(c echo.Context) error {
+	settings, err := h.service.GetSettings(c.Request().Context())
+	if err != nil {
+		return databaseError(c, "get approval settings", err)
+	}
+	return c.JSON(http.StatusOK, settings)
+}
+
+// UpdateSettings chooses which actions need approval and who may approve them
+func (h *ChangeRequestHandler) UpdateSettings(c echo.Context) error {
+	var req model.UpdateApprovalSettingsRequest
+	if err := c.Bind(&req); err != nil {
+		return badRequestError(c, "Invalid request body")
+	}
+
+	// Weakening the policy needs a second user, or one admin could turn it
+	// off and then act alone
+	current, required, err := h.service.SettingsChangeRequired(c.Request().Context(), &req)
+	if err != nil {
+		return approvalSettingsError(c, err)
+	}
+	if required {
+		diff, err := service.FieldDiff(current, &req)
+		if err != nil {
+			return internalError(c, "render approval settings diff", err)
+		}
+		return submitChange(c, h.service, h.audit, &model.ChangeRequest{
+			Action:     model.ChangeActionApprovalPolicy,
+			TargetName: "Approval policy",
+			Diff:       diff,
+		}, req)
+	}
+
+	settings, err := h.service.UpdateSettings(c.Request().Context(), &req)
+	if err != nil {
+		return approvalSettingsError(c, err)
+	}
+
+	auditCtx := service.ContextWithAudit(c.Request().Context(), c)
+	h.audit.LogSettingsUpdate(auditCtx, "Change approval", map[string]interface{}{
+		"enabled":       settings.Enabled,
+		"actions":       settings.Actions,
+		"approver_role": settings.ApproverRole,
+		"expiry_hours":  settings.ExpiryHours,
+	})
+
+	return c.JSON(http.StatusOK, settings)
+}
+
+// List returns change requests, optionally filtered by ?status=. Callers see
+// their own requests and those of actions they hold the permission for.
+func (h *ChangeRequestHandler) List(c echo.Context) error {
+	page, perPage := ParsePaginationParams(c)
+
+	userID, _ := getContextString(c, "user_id")
+	response, err := h.service.List(c.Request().Context(), c.QueryParam("status"), userID, callerPermissionCheck(c), page, perPage)
+	if err != nil {
+		return databaseError(c, "list change requests", err)
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+// Get returns a change request with its payload and diff
+func (h *ChangeRequestHandler) Get(c echo.Context) error {
+	userID, _ := getContextString(c, "user_id")
+	cr, err := h.service.Get(c.Request().Context(), c.Param("id"), userID, callerPermissionCheck(c))
+	if err != nil {
+		return changeRequestError(c, "get change request", err)
+	}
+	return c.JSON(http.StatusOK, cr)
+}
+
+// reviewer returns the signed-in user reviewing a change request. API
+// tokens can submit changes but not review them.
+func reviewer(c echo.Context) (*model.User, bool) {
+	user, ok := getUserFromContext(c)
+	return user, ok && user != nil
+}
+
+// Approve approves a pending change request and applies it
+func (h *ChangeRequestHandler) Approve(c echo.Context) error {
+	user, ok := reviewer(c)
+	if !ok {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "change requests must be reviewed by a signed-in user"})
+	}
+	var req model.ReviewChangeRequest
+	if err := c.Bind(&req); err != nil {
+		return badRequestError(c, "Invalid request body")
+	}
+
+	auditCtx := service.ContextWithAudit(c.Request().Context(), c)
+	cr, err := h.service.Approve(auditCtx, c.Param("id"), user, req.Comment)
+	if err != nil {
+		return changeRequestError(c, "approve change request", err)
+	}
+
+	h.audit.LogChangeApproved(auditCtx, cr)
+
+	return c.JSON(http.StatusOK, cr)
+}
+
+// Reject rejects a pending change request
+func (h *ChangeRequestHandler) Reject(c echo.Context) error {
+	user, ok := reviewer(c)
+	if !ok {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "change requests must be reviewed by a signed-in user"})
+	}
+	var req model.ReviewChangeRequest
+	if err := c.Bind(&req); err != nil {
+		return badRequestError(c, "Invalid request body")
+	}
+
+	cr, err := h.service.Reject(c.Request().Context(), c.Param("id"), user, req.Comment)
+	if err != nil {
+		return changeRequestError(c, "reject change request", err)
+	}
+
+	auditCtx := service.ContextWithAudit(c.Request().Context(), c)
+	h.audit.LogChangeRejected(auditCtx, cr)
+
+	return c.JSON(http.StatusOK, cr)
+}
+
+// Cancel withdraws a pending change request of the current user
+func (h *ChangeRequestHandler) Cancel(c echo.Context) error {
+	user, ok := reviewer(c)
+	if !ok {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "change requests must be cancelled by a signed-in user"})
+	}
+	var req model.ReviewChangeRequest
+	if err := c.Bind(&req); err != nil {
+		return badRequestError(c, "Invalid request body")
+	}
+
+	cr, err := h.service.Cancel(c.Request().Context(), c.Param("id"), user, req.Comment)
+	if err != nil {
+		return changeRequestError(c, "cancel change request", err)
+	}
+
+	auditCtx := service.ContextWithAudit(c.Request().Context(), c)
+	h.audit.LogChangeCancelled(auditCtx, cr)
+
+	return c.JSON(http.StatusOK, cr)
+}
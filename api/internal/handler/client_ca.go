@@ -0,0 +1,380 @@
+package handler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"nginx-proxy-guard/internal/model"
+	"nginx-proxy-guard/internal/nginx"
+	"nginx-proxy-guard/internal/repository"
+	"nginx-proxy-guard/internal/service"
+)
+
+const (
+	defaultClientCertVerifyDepth = 1
+	maxClientCertVerifyDepth     = 10
+)
+
+type ClientCAHandler struct {
+	repo             *repository.ClientCARepository
+	proxyHostRepo    *repository.ProxyHostRepository
+	proxyHostService *service.ProxyHostService
+	nginxManager     *nginx.Manager
+	audit            *service.AuditService
+}
+
+func NewClientCAHandler(
+	repo *repository.ClientCARepository,
+	proxyHostRepo *repository.ProxyHostRepository,
+	proxyHostService *service.ProxyHostService,
+	nginxManager *nginx.Manager,
+	audit *service.AuditService,
+) *ClientCAHandler {
+	return &ClientCAHandler{
+		repo:             repo,
+		proxyHostRepo:    proxyHostRepo,
+		proxyHostService: proxyHostService,
+		nginxManager:     nginxManager,
+		audit:            audit,
+	}
+}
+
+// === Client CAs ===
+
+// List returns all client CAs
+func (h *ClientCAHandler) List(c echo.Context) error {
+	cas, err := h.repo.List(c.Request().Context())
+	if err != nil {
+		return databaseError(c, "list client CAs", err)
+	}
+	if cas == nil {
+		cas = []model.ClientCA{}
+	}
+	for i := range cas {
+		nginx.DescribeClientCA(&cas[i])
+	}
+	return c.JSON(http.StatusOK, cas)
+}
+
+// Get returns a client CA
+func (h *ClientCAHandler) Get(c echo.Context) error {
+	ca, err := h.repo.GetByID(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return databaseError(c, "get client CA", err)
+	}
+	if ca == nil {
+		return notFoundError(c, "Client CA")
+	}
+	nginx.DescribeClientCA(ca)
+	return c.JSON(http.StatusOK, ca)
+}
+
+// Create uploads a client CA bundle
+func (h *ClientCAHandler) Create(c echo.Context) error {
+	var req model.ClientCARequest
+	if err := c.Bind(&req); err != nil {
+		return badRequestError(c, "Invalid request body")
+	}
+	if verr := normalizeClientCARequest(&req); verr != nil {
+		return validationError(c, verr.Field, verr.Message)
+	}
+
+	ctx := c.Request().Context()
+	existing, err := h.repo.GetByName(ctx, req.Name)
+	if err != nil {
+		return databaseError(c, "get client CA", err)
+	}
+	if existing != nil {
+		return conflictError(c, "A client CA with this name already exists")
+	}
+
+	ca, err := h.repo.Create(ctx, &req)
+	if err != nil {
+		return databaseError(c, "create client CA", err)
+	}
+	nginx.DescribeClientCA(ca)
+
+	// Audit log
+	auditCtx := service.ContextWithAudit(ctx, c)
+	h.audit.LogSettingsUpdate(auditCtx, "Client CA", map[string]interface{}{
+		"action":   "create",
+		"name":     ca.Name,
+		"subjects": ca.Subjects,
+		"has_crl":  ca.HasCRL,
+	})
+
+	return createdResponse(c, ca)
+}
+
+// Update replaces a client CA bundle and reloads the proxy hosts using it
+func (h *ClientCAHandler) Update(c echo.Context) error {
+	var req model.ClientCARequest
+	if err := c.Bind(&req); err != nil {
+		return badRequestError(c, "Invalid request body")
+	}
+	if verr := normalizeClientCARequest(&req); verr != nil {
+		return validationError(c, verr.Field, verr.Message)
+	}
+
+	ctx := c.Request().Context()
+	id := c.Param("id")
+	existing, err := h.repo.GetByName(ctx, req.Name)
+	if err != nil {
+		return databaseError(c, "get client CA", err)
+	}
+	if existing != nil && existing.ID != id {
+		return conflictError(c, "A client CA with this name already exists")
+	}
+
+	ca, err := h.repo.Update(ctx, id, &req)
+	if err != nil {
+		return databaseError(c, "update client CA", err)
+	}
+	if ca == nil {
+		return notFoundError(c, "Client CA")
+	}
+
+	hostIDs, err := h.repo.ListProxyHostIDsByCA(ctx, id)
+	if err != nil {
+		return databaseError(c, "list client CA proxy hosts", err)
+	}
+	if err := h.proxyHostService.RegenerateConfigsForClientCA(ctx, hostIDs); err != nil {
+		return internalError(c, "regenerate nginx configs for client CA", err)
+	}
+	nginx.DescribeClientCA(ca)
+
+	// Audit log
+	auditCtx := service.ContextWithAudit(ctx, c)
+	h.audit.LogSettingsUpdate(auditCtx, "Client CA", map[string]interface{}{
+		"action":   "update",
+		"name":     ca.Name,
+		"subjects": ca.Subjects,
+		"has_crl":  ca.HasCRL,
+	})
+
+	return c.JSON(http.StatusOK, ca)
+}
+
+// Delete removes a client CA that no proxy host uses
+func (h *ClientCAHandler) Delete(c echo.Context) error {
+	ctx := c.Request().Context()
+	id := c.Param("id")
+
+	ca, err := h.repo.GetByID(ctx, id)
+	if err != nil {
+		return databaseError(c, "get client CA", err)
+	}
+	if ca == nil {
+		return notFoundError(c, "Client CA")
+	}
+
+	hostIDs, err := h.repo.ListProxyHostIDsByCA(ctx, id)
+	if err != nil {
+		return databaseError(c, "list client CA proxy hosts", err)
+	}
+	if len(hostIDs) > 0 {
+		return conflictError(c, "Client CA is used by proxy hosts")
+	}
+
+	if err := h.repo.Delete(ctx, id); err != nil {
+		return databaseError(c, "delete client CA", err)
+	}
+	if err := h.nginxManager.RemoveClientCAFiles(id); err != nil {
+		log.Printf("[ClientCA] Failed to remove files of client CA %s: %v", id, err)
+	}
+
+	// Audit log
+	auditCtx := service.ContextWithAudit(ctx, c)
+	h.audit.LogSettingsUpdate(auditCtx, "Client CA", map[string]interface{}{
+		"action": "delete",
+		"name":   ca.Name,
+	})
+
+	return noContentResponse(c)
+}
+
+// === Per proxy host mTLS ===
+
+// GetAuth returns the client certificate authentication of a proxy host
+func (h *ClientCAHandler) GetAuth(c echo.Context) error {
+	proxyHostID := c.Param("proxyHostId")
+
+	cfg, err := h.repo.GetAuthByProxyHostID(c.Request().Context(), proxyHostID)
+	if err != nil {
+		return databaseError(c, "get client cert auth", err)
+	}
+	if cfg == nil {
+		cfg = &model.ClientCertAuthConfig{
+			ProxyHostID:  proxyHostID,
+			VerifyMode:   model.ClientCertVerifyOn,
+			VerifyDepth:  defaultClientCertVerifyDepth,
+			EnforcePaths: []string{},
+		}
+	}
+	return c.JSON(http.StatusOK, cfg)
+}
+
+// UpsertAuth requires client certificates on a proxy host
+func (h *ClientCAHandler) UpsertAuth(c echo.Context) error {
+	proxyHostID := c.Param("proxyHostId")
+	ctx := c.Request().Context()
+
+	var req model.UpsertClientCertAuthRequest
+	if err := c.Bind(&req); err != nil {
+		return badRequestError(c, "Invalid request body")
+	}
+	if verr := normalizeClientCertAuthRequest(&req); verr != nil {
+		return validationError(c, verr.Field, verr.Message)
+	}
+
+	host, err := h.proxyHostRepo.GetByID(ctx, proxyHostID)
+	if err != nil {
+		return databaseError(c, "get proxy host", err)
+	}
+	if host == nil {
+		return notFoundError(c, "Proxy host")
+	}
+
+	ca, err := h.repo.GetByID(ctx, req.ClientCAID)
+	if err != nil {
+		return databaseError(c, "get client CA", err)
+	}
+	if ca == nil {
+		return validationError(c, "client_ca_id", "does not reference an existing client CA")
+	}
+
+	if (req.Enabled == nil || *req.Enabled) && (!host.SSLEnabled || !host.SSLForceHTTPS) {
+		return badRequestError(c, "Client certificate authentication requires SSL with Force HTTPS, otherwise plain HTTP would bypass it")
+	}
+
+	cfg, err := h.repo.UpsertAuth(ctx, proxyHostID, &req)
+	if err != nil {
+		return databaseError(c, "upsert client cert auth", err)
+	}
+
+	if host.Enabled {
+		if err := h.proxyHostService.RegenerateConfigForHost(ctx, proxyHostID); err != nil {
+			return internalError(c, "regenerate nginx config for client cert auth", err)
+		}
+	}
+
+	// Audit log
+	auditCtx := service.ContextWithAudit(ctx, c)
+	h.audit.LogSecurityFeatureUpdate(auditCtx, "client_cert_auth", hostDisplayName(host), cfg.Enabled, map[string]interface{}{
+		"client_ca":   ca.Name,
+		"verify_mode": cfg.VerifyMode,
+	})
+
+	return c.JSON(http.StatusOK, cfg)
+}
+
+// DeleteAuth removes the client certificate authentication of a proxy host
+func (h *ClientCAHandler) DeleteAuth(c echo.Context) error {
+	proxyHostID := c.Param("proxyHostId")
+	ctx := c.Request().Context()
+
+	host, err := h.proxyHostRepo.GetByID(ctx, proxyHostID)
+	if err != nil {
+		return databaseError(c, "get proxy host", err)
+	}
+	if host == nil {
+		return notFoundError(c, "Proxy host")
+	}
+
+	if err := h.repo.DeleteAuth(ctx, proxyHostID); err != nil {
+		return databaseError(c, "delete client cert auth", err)
+	}
+
+	if host.Enabled {
+		if err := h.proxyHostService.RegenerateConfigForHost(ctx, proxyHostID); err != nil {
+			return internalError(c, "regenerate nginx config for client cert auth removal", err)
+		}
+	}
+
+	// Audit log
+	auditCtx := service.ContextWithAudit(ctx, c)
+	h.audit.LogSecurityFeatureUpdate(auditCtx, "client_cert_auth", hostDisplayName(host), false, nil)
+
+	return noContentResponse(c)
+}
+
+// normalizeClientCARequest trims and validates an uploaded CA bundle and CRL
+func normalizeClientCARequest(req *model.ClientCARequest) *ValidationError {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return &ValidationError{Field: "name", Message: "is required"}
+	}
+	if len(req.Name) > MaxNameLength {
+		return &ValidationError{Field: "name", Message: "is too long"}
+	}
+	req.Description = strings.TrimSpace(req.Description)
+
+	req.CAPEM = strings.TrimSpace(req.CAPEM)
+	if req.CAPEM == "" {
+		return &ValidationError{Field: "ca_pem", Message: "is required"}
+	}
+	certs, err := nginx.ParseClientCABundle(req.CAPEM)
+	if err != nil {
+		return &ValidationError{Field: "ca_pem", Message: err.Error()}
+	}
+	for _, cert := range certs {
+		if !cert.IsCA {
+			return &ValidationError{Field: "ca_pem", Message: fmt.Sprintf("contains a certificate that is not a CA: %s", cert.Subject.String())}
+		}
+	}
+	req.CAPEM += "\n"
+
+	req.CRLPEM = strings.TrimSpace(req.CRLPEM)
+	if req.CRLPEM != "" {
+		if err := nginx.ValidateClientCRL(req.CRLPEM, certs); err != nil {
+			return &ValidationError{Field: "crl_pem", Message: err.Error()}
+		}
+		req.CRLPEM += "\n"
+	}
+	return nil
+}
+
+// normalizeClientCertAuthRequest applies defaults and validates the values rendered into the nginx config
+func normalizeClientCertAuthRequest(req *model.UpsertClientCertAuthRequest) *ValidationError {
+	req.ClientCAID = strings.TrimSpace(req.ClientCAID)
+	if req.ClientCAID == "" {
+		return &ValidationError{Field: "client_ca_id", Message: "is required"}
+	}
+
+	switch req.VerifyMode {
+	case "":
+		req.VerifyMode = model.ClientCertVerifyOn
+	case model.ClientCertVerifyOn, model.ClientCertVerifyOptional, model.ClientCertVerifyOptionalNoCA:
+	default:
+		return &ValidationError{Field: "verify_mode", Message: "must be one of on, optional, optional_no_ca"}
+	}
+
+	if req.VerifyDepth == 0 {
+		req.VerifyDepth = defaultClientCertVerifyDepth
+	}
+	if req.VerifyDepth < 1 || req.VerifyDepth > maxClientCertVerifyDepth {
+		return &ValidationError{Field: "verify_depth", Message: fmt.Sprintf("must be between 1 and %d", maxClientCertVerifyDepth)}
+	}
+
+	paths := make([]string, 0, len(req.EnforcePaths))
+	for _, path := range req.EnforcePaths {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		if !strings.HasPrefix(path, "/") || strings.ContainsAny(path, " \t\r\n\"';{}") {
+			return &ValidationError{Field: "enforce_paths", Message: fmt.Sprintf("contains an invalid path (must start with /): %s", path)}
+		}
+		paths = append(paths, path)
+	}
+	if len(paths) > 0 && req.VerifyMode == model.ClientCertVerifyOn {
+		return &ValidationError{Field: "enforce_paths", Message: "only apply to the optional verify modes, mode on requires a certificate for every request"}
+	}
+	req.EnforcePaths = paths
+
+	return nil
+}
@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"nginx-proxy-guard/internal/model"
+	"nginx-proxy-guard/internal/repository"
+	"nginx-proxy-guard/internal/service"
+)
+
+type CORSPolicyHandler struct {
+	repo             *repository.CORSPolicyRepository
+	proxyHostRepo    *repository.ProxyHostRepository
+	proxyHostService *service.ProxyHostService
+	audit            *service.AuditService
+}
+
+func NewCORSPolicyHandler(
+	repo *repository.CORSPolicyRepository,
+	proxyHostRepo *repository.ProxyHostRepository,
+	proxyHostService *service.ProxyHostService,
+	audit *service.AuditService,
+) *CORSPolicyHandler {
+	return &CORSPolicyHandler{
+		repo:             repo,
+		proxyHostRepo:    proxyHostRepo,
+		proxyHostService: proxyHostService,
+		audit:            audit,
+	}
+}
+
+// ListPresets returns the preset CORS policies
+func (h *CORSPolicyHandler) ListPresets(c echo.Context) error {
+	return c.JSON(http.StatusOK, model.CORSPolicyPresets)
+}
+
+// List returns the CORS policies of a proxy host
+func (h *CORSPolicyHandler) List(c echo.Context) error {
+	policies, err := h.repo.ListByProxyHostID(c.Request().Context(), c.Param("proxyHostId"))
+	if err != nil {
+		return databaseError(c, "list CORS policies", err)
+	}
+	return c.JSON(http.StatusOK, policies)
+}
+
+// Upsert creates or replaces the CORS policy of a proxy host path
+func (h *CORSPolicyHandler) Upsert(c echo.Context) error {
+	proxyHostID := c.Param("proxyHostId")
+	ctx := c.Request().Context()
+
+	var req model.UpsertCORSPolicyRequest
+	if err := c.Bind(&req); err != nil {
+		return badRequestError(c, "Invalid request body")
+	}
+
+	resolved, err := service.ResolveCORSPolicy(&req)
+	if err != nil {
+		var perr *service.CORSPolicyError
+		if errors.As(err, &perr) {
+			return validationError(c, perr.Field, perr.Message)
+		}
+		return badRequestError(c, err.Error())
+	}
+
+	host, err := h.proxyHostRepo.GetByID(ctx, proxyHostID)
+	if err != nil {
+		return databaseError(c, "get proxy host", err)
+	}
+	if host == nil {
+		return notFoundError(c, "Proxy host")
+	}
+
+	policy, err := h.repo.Upsert(ctx, proxyHostID, resolved)
+	if err != nil {
+		return databaseError(c, "upsert CORS policy", err)
+	}
+
+	if host.Enabled {
+		if err := h.proxyHostService.RegenerateConfigForHost(ctx, proxyHostID); err != nil {
+			return internalError(c, "regenerate nginx config for CORS policy", err)
+		}
+	}
+
+	// Audit log
+	auditCtx := service.ContextWithAudit(ctx, c)
+	h.audit.LogSecurityFeatureUpdate(auditCtx, "cors_policy", hostDisplayName(host), policy.Enabled, map[string]interface{}{
+		"path_prefix":       policy.PathPrefix,
+		"allowed_origins":   policy.AllowedOrigins,
+		"origin_patterns":   policy.AllowedOriginPatterns,
+		"allow_credentials": policy.AllowCredentials,
+	})
+
+	return c.JSON(http.StatusOK, policy)
+}
+
+// Delete removes a CORS policy of a proxy host
+func (h *CORSPolicyHandler) Delete(c echo.Context) error {
+	proxyHostID := c.Param("proxyHostId")
+	ctx := c.Request().Context()
+
+	host, err := h.proxyHostRepo.GetByID(ctx, proxyHostID)
+	if err != nil {
+		return databaseError(c, "get proxy host", err)
+	}
+	if host == nil {
+		return notFoundError(c, "Proxy host")
+	}
+
+	policy, err := h.repo.GetByID(ctx, proxyHostID, c.Param("policyId"))
+	if err != nil {
+		return databaseError(c, "get CORS policy", err)
+	}
+	if policy == nil {
+		return notFoundError(c, "CORS policy")
+	}
+
+	if err := h.repo.Delete(ctx, proxyHostID, policy.ID); err != nil {
+		return databaseError(c, "delete CORS policy", err)
+	}
+
+	if host.Enabled {
+		if err := h.proxyHostService.RegenerateConfigForHost(ctx, proxyHostID); err != nil {
+			return internalError(c, "regenerate nginx config for CORS policy removal", err)
+		}
+	}
+
+	// Audit log
+	auditCtx := service.ContextWithAudit(ctx, c)
+	h.audit.LogSecurityFeatureUpdate(auditCtx, "cors_policy", hostDisplayName(host), false, map[string]interface{}{
+		"path_prefix": policy.PathPrefix,
+	})
+
+	return noContentResponse(c)
+}
@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"nginx-proxy-guard/internal/model"
+	"nginx-proxy-guard/internal/repository"
+	"nginx-proxy-guard/internal/service"
+)
+
+type CrowdSecHandler struct {
+	repo    *repository.CrowdSecRepository
+	service *service.CrowdSecService
+	audit   *service.AuditService
+}
+
+func NewCrowdSecHandler(repo *repository.CrowdSecRepository, crowdSecService *service.CrowdSecService, audit *service.AuditService) *CrowdSecHandler {
+	return &CrowdSecHandler{
+		repo:    repo,
+		service: crowdSecService,
+		audit:   audit,
+	}
+}
+
+// GetSettings returns the CrowdSec settings (secrets are masked)
+func (h *CrowdSecHandler) GetSettings(c echo.Context) error {
+	settings, err := h.repo.GetSettings(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, settings)
+}
+
+// UpdateSettings updates the CrowdSec settings and resyncs decisions in the background
+func (h *CrowdSecHandler) UpdateSettings(c echo.Context) error {
+	var req model.UpdateCrowdSecSettingsRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	}
+
+	if req.LAPIURL != nil && *req.LAPIURL != "" {
+		u, err := url.Parse(*req.LAPIURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "LAPI URL must be an http or https URL"})
+		}
+	}
+	if req.PollInterval != nil && (*req.PollInterval < 10 || *req.PollInterval > 3600) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Poll interval must be between 10 and 3600 seconds"})
+	}
+
+	ctx := c.Request().Context()
+	current, err := h.repo.GetSettings(ctx)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	enabled, lapiURL, hasAPIKey := current.Enabled, current.LAPIURL, current.HasAPIKey
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+	if req.LAPIURL != nil {
+		lapiURL = *req.LAPIURL
+	}
+	if req.APIKey != nil && *req.APIKey != "" {
+		hasAPIKey = true
+	}
+	if enabled && (lapiURL == "" || !hasAPIKey) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "LAPI URL and bouncer API key are required to enable CrowdSec"})
+	}
+
+	settings, err := h.repo.UpdateSettings(ctx, &req)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	// Pull the full decision set with the new settings (or drop decisions when disabled)
+	h.service.Reset()
+	go func() {
+		bgCtx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+		if _, err := h.service.Sync(bgCtx); err != nil && !errors.Is(err, service.ErrCrowdSecDisabled) {
+			log.Printf("[CrowdSec] Sync after settings update failed: %v", err)
+		}
+	}()
+
+	// Audit log
+	auditCtx := service.ContextWithAudit(ctx, c)
+	h.audit.LogSettingsUpdate(auditCtx, "CrowdSec", map[string]interface{}{
+		"enabled":      settings.Enabled,
+		"lapi_url":     settings.LAPIURL,
+		"push_signals": settings.PushSignals,
+	})
+
+	return c.JSON(http.StatusOK, settings)
+}
+
+// TestConnection checks the stored bouncer key and watcher credentials against LAPI
+func (h *CrowdSecHandler) TestConnection(c echo.Context) error {
+	ctx := c.Request().Context()
+	settings, err := h.repo.GetSettings(ctx)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, h.service.TestConnection(ctx, settings))
+}
+
+// Sync polls the LAPI decision stream immediately
+func (h *CrowdSecHandler) Sync(c echo.Context) error {
+	ctx := c.Request().Context()
+	result, err := h.service.Sync(ctx)
+	if errors.Is(err, service.ErrCrowdSecDisabled) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	if err != nil {
+		return c.JSON(http.StatusBadGateway, map[string]string{"error": err.Error()})
+	}
+
+	// Audit log
+	auditCtx := service.ContextWithAudit(ctx, c)
+	h.audit.LogSettingsUpdate(auditCtx, "CrowdSec", map[string]interface{}{
+		"action":      "sync",
+		"added":       result.Added,
+		"deleted":     result.Deleted,
+		"active_bans": result.ActiveBans,
+	})
+
+	return c.JSON(http.StatusOK, result)
+}
@@ -71,6 +71,13 @@ func forbiddenError(c echo.Context) error {
 	})
 }
 
+// outOfScopeError returns a forbidden error for a resource outside the API token scope
+func outOfScopeError(c echo.Context, resource string) error {
+	return c.JSON(http.StatusForbidden, map[string]string{
+		"error": resource + " is outside the token scope",
+	})
+}
+
 // httpInternalError is for standard http.ResponseWriter handlers
 func httpInternalError(w http.ResponseWriter, operation string, err error) {
 	log.Printf("[ERROR] %s: %v", operation, err)
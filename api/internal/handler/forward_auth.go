@@ -0,0 +1,224 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"nginx-proxy-guard/internal/model"
+	"nginx-proxy-guard/internal/nginx"
+	"nginx-proxy-guard/internal/repository"
+	"nginx-proxy-guard/internal/service"
+)
+
+// Header names copied from the auth response are used in nginx variable names
+var forwardAuthHeaderPattern = regexp.MustCompile(`^[A-Za-z0-9-]+$`)
+
+type ForwardAuthHandler struct {
+	repo             *repository.ForwardAuthRepository
+	proxyHostRepo    *repository.ProxyHostRepository
+	geoRepo          *repository.GeoRepository
+	oidcGateRepo     *repository.OIDCGateRepository
+	proxyHostService *service.ProxyHostService
+	audit            *service.AuditService
+}
+
+func NewForwardAuthHandler(
+	repo *repository.ForwardAuthRepository,
+	proxyHostRepo *repository.ProxyHostRepository,
+	geoRepo *repository.GeoRepository,
+	oidcGateRepo *repository.OIDCGateRepository,
+	proxyHostService *service.ProxyHostService,
+	audit *service.AuditService,
+) *ForwardAuthHandler {
+	return &ForwardAuthHandler{
+		repo:             repo,
+		proxyHostRepo:    proxyHostRepo,
+		geoRepo:          geoRepo,
+		oidcGateRepo:     oidcGateRepo,
+		proxyHostService: proxyHostService,
+		audit:            audit,
+	}
+}
+
+// Get returns the forward auth settings of a proxy host
+func (h *ForwardAuthHandler) Get(c echo.Context) error {
+	proxyHostID := c.Param("proxyHostId")
+
+	fa, err := h.repo.GetByProxyHostID(c.Request().Context(), proxyHostID)
+	if err != nil {
+		return databaseError(c, "get forward auth", err)
+	}
+	if fa == nil {
+		fa = &model.ForwardAuthConfig{
+			ProxyHostID:     proxyHostID,
+			ResponseHeaders: []string{},
+			BypassPaths:     []string{},
+			ProtectHost:     true,
+		}
+	}
+	fa.IncludePath = nginx.ForwardAuthIncludePath(proxyHostID)
+
+	return c.JSON(http.StatusOK, fa)
+}
+
+// Upsert creates or replaces the forward auth settings of a proxy host
+func (h *ForwardAuthHandler) Upsert(c echo.Context) error {
+	proxyHostID := c.Param("proxyHostId")
+	ctx := c.Request().Context()
+
+	var req model.UpsertForwardAuthRequest
+	if err := c.Bind(&req); err != nil {
+		return badRequestError(c, "Invalid request body")
+	}
+	if verr := normalizeForwardAuthRequest(&req); verr != nil {
+		return validationError(c, verr.Field, verr.Message)
+	}
+
+	host, err := h.proxyHostRepo.GetByID(ctx, proxyHostID)
+	if err != nil {
+		return databaseError(c, "get proxy host", err)
+	}
+	if host == nil {
+		return notFoundError(c, "Proxy host")
+	}
+
+	if req.Enabled == nil || *req.Enabled {
+		geo, err := h.geoRepo.GetByProxyHostID(ctx, proxyHostID)
+		if err != nil {
+			return databaseError(c, "get geo restriction", err)
+		}
+		if geo != nil && geo.ChallengeMode {
+			return badRequestError(c, "Forward auth cannot be combined with geo restriction challenge mode")
+		}
+		gate, err := h.oidcGateRepo.GetGateByProxyHostID(ctx, proxyHostID)
+		if err != nil {
+			return databaseError(c, "get OIDC host gate", err)
+		}
+		if gate != nil && gate.Enabled {
+			return badRequestError(c, "Forward auth cannot be combined with OIDC login")
+		}
+	}
+
+	fa, err := h.repo.Upsert(ctx, proxyHostID, &req)
+	if err != nil {
+		return databaseError(c, "upsert forward auth", err)
+	}
+	fa.IncludePath = nginx.ForwardAuthIncludePath(proxyHostID)
+
+	if host.Enabled {
+		if err := h.proxyHostService.RegenerateConfigForHost(ctx, proxyHostID); err != nil {
+			return internalError(c, "regenerate nginx config for forward auth", err)
+		}
+	}
+
+	// Audit log
+	auditCtx := service.ContextWithAudit(ctx, c)
+	h.audit.LogSecurityFeatureUpdate(auditCtx, "forward_auth", hostDisplayName(host), fa.Enabled, nil)
+
+	return c.JSON(http.StatusOK, fa)
+}
+
+// Delete removes the forward auth settings of a proxy host
+func (h *ForwardAuthHandler) Delete(c echo.Context) error {
+	proxyHostID := c.Param("proxyHostId")
+	ctx := c.Request().Context()
+
+	host, err := h.proxyHostRepo.GetByID(ctx, proxyHostID)
+	if err != nil {
+		return databaseError(c, "get proxy host", err)
+	}
+	if host == nil {
+		return notFoundError(c, "Proxy host")
+	}
+
+	if err := h.repo.Delete(ctx, proxyHostID); err != nil {
+		return databaseError(c, "delete forward auth", err)
+	}
+
+	if host.Enabled {
+		if err := h.proxyHostService.RegenerateConfigForHost(ctx, proxyHostID); err != nil {
+			return internalError(c, "regenerate nginx config for forward auth removal", err)
+		}
+	}
+
+	// Audit log
+	auditCtx := service.ContextWithAudit(ctx, c)
+	h.audit.LogSecurityFeatureUpdate(auditCtx, "forward_auth", hostDisplayName(host), false, nil)
+
+	return noContentResponse(c)
+}
+
+// normalizeForwardAuthRequest trims and validates the values rendered into the nginx config
+func normalizeForwardAuthRequest(req *model.UpsertForwardAuthRequest) *ValidationError {
+	req.AuthURL = strings.TrimSpace(req.AuthURL)
+	if err := validateForwardAuthURL(req.AuthURL); err != nil {
+		return &ValidationError{Field: "auth_url", Message: err.Error()}
+	}
+
+	req.SigninURL = strings.TrimSpace(req.SigninURL)
+	if req.SigninURL != "" {
+		if err := validateForwardAuthURL(req.SigninURL); err != nil {
+			return &ValidationError{Field: "signin_url", Message: err.Error()}
+		}
+	}
+
+	headers := make([]string, 0, len(req.ResponseHeaders))
+	seen := make(map[string]bool, len(req.ResponseHeaders))
+	for _, header := range req.ResponseHeaders {
+		header = strings.TrimSpace(header)
+		if header == "" {
+			continue
+		}
+		if !forwardAuthHeaderPattern.MatchString(header) {
+			return &ValidationError{Field: "response_headers", Message: fmt.Sprintf("contains an invalid header name: %s", header)}
+		}
+		if seen[strings.ToLower(header)] {
+			continue
+		}
+		seen[strings.ToLower(header)] = true
+		headers = append(headers, header)
+	}
+	req.ResponseHeaders = headers
+
+	paths := make([]string, 0, len(req.BypassPaths))
+	for _, path := range req.BypassPaths {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		if !strings.HasPrefix(path, "/") || strings.ContainsAny(path, " \t\r\n\"';{}") {
+			return &ValidationError{Field: "bypass_paths", Message: fmt.Sprintf("contains an invalid path (must start with /): %s", path)}
+		}
+		paths = append(paths, path)
+	}
+	req.BypassPaths = paths
+
+	return nil
+}
+
+// validateForwardAuthURL checks that a URL is an absolute http(s) URL safe to embed in nginx
+func validateForwardAuthURL(raw string) error {
+	if raw == "" {
+		return fmt.Errorf("is required")
+	}
+	if strings.ContainsAny(raw, " \t\r\n\"';{}$") {
+		return fmt.Errorf("contains invalid characters")
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("must be an absolute http or https URL")
+	}
+	return nil
+}
+
+func hostDisplayName(host *model.ProxyHost) string {
+	if len(host.DomainNames) > 0 {
+		return host.DomainNames[0]
+	}
+	return host.ID
+}
@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"nginx-proxy-guard/internal/model"
+	"nginx-proxy-guard/internal/service"
+)
+
+type LDAPHandler struct {
+	service *service.LDAPService
+	audit   *service.AuditService
+}
+
+func NewLDAPHandler(ldapService *service.LDAPService, audit *service.AuditService) *LDAPHandler {
+	return &LDAPHandler{
+		service: ldapService,
+		audit:   audit,
+	}
+}
+
+// GetSettings returns the LDAP directory settings
+func (h *LDAPHandler) GetSettings(c echo.Context) error {
+	settings, err := h.service.GetSettings(c.Request().Context())
+	if err != nil {
+		return databaseError(c, "get LDAP settings", err)
+	}
+	return c.JSON(http.StatusOK, settings)
+}
+
+// UpdateSettings updates the LDAP directory settings
+func (h *LDAPHandler) UpdateSettings(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	var req model.UpdateLDAPSettingsRequest
+	if err := c.Bind(&req); err != nil {
+		return badRequestError(c, "Invalid request body")
+	}
+
+	settings, err := h.service.UpdateSettings(ctx, &req)
+	if err != nil {
+		var uerr *service.UserError
+		if errors.As(err, &uerr) {
+			return validationError(c, uerr.Field, uerr.Message)
+		}
+		return databaseError(c, "update LDAP settings", err)
+	}
+
+	// Audit log
+	auditCtx := service.ContextWithAudit(ctx, c)
+	h.audit.LogSettingsUpdate(auditCtx, "LDAP", map[string]interface{}{
+		"enabled":           settings.Enabled,
+		"url":               settings.URL,
+		"start_tls":         settings.StartTLS,
+		"skip_tls_verify":   settings.SkipTLSVerify,
+		"bind_dn":           settings.BindDN,
+		"password_changed":  req.BindPassword != nil && *req.BindPassword != "",
+		"search_base":       settings.SearchBase,
+		"user_filter":       settings.UserFilter,
+		"group_search_base": settings.GroupSearchBase,
+		"group_mappings":    settings.GroupMappings,
+		"default_role":      settings.DefaultRole,
+		"auto_provision":    settings.AutoProvision,
+	})
+
+	return c.JSON(http.StatusOK, settings)
+}
+
+// TestConnection binds and searches with the settings in the request applied,
+// optionally looking up (and with a password, binding as) a user
+func (h *LDAPHandler) TestConnection(c echo.Context) error {
+	var req model.LDAPTestRequest
+	if err := c.Bind(&req); err != nil {
+		return badRequestError(c, "Invalid request body")
+	}
+
+	result, err := h.service.TestConnection(c.Request().Context(), &req)
+	if err != nil {
+		var uerr *service.UserError
+		if errors.As(err, &uerr) {
+			return validationError(c, uerr.Field, uerr.Message)
+		}
+		return databaseError(c, "test LDAP connection", err)
+	}
+	return c.JSON(http.StatusOK, result)
+}
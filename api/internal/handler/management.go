@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"nginx-proxy-guard/internal/model"
+	"nginx-proxy-guard/internal/service"
+)
+
+type ManagementHandler struct {
+	service *service.ManagementService
+	audit   *service.AuditService
+}
+
+func NewManagementHandler(management *service.ManagementService, audit *service.AuditService) *ManagementHandler {
+	return &ManagementHandler{
+		service: management,
+		audit:   audit,
+	}
+}
+
+// GetSettings returns the management allowlist and listener settings
+func (h *ManagementHandler) GetSettings(c echo.Context) error {
+	settings, err := h.service.GetSettings(c.Request().Context())
+	if err != nil {
+		return databaseError(c, "get management settings", err)
+	}
+	return c.JSON(http.StatusOK, settings)
+}
+
+// UpdateSettings updates the management allowlist and listener. The caller
+// can't lock themselves out.
+func (h *ManagementHandler) UpdateSettings(c echo.Context) error {
+	var req model.UpdateManagementSettingsRequest
+	if err := c.Bind(&req); err != nil {
+		return badRequestError(c, "Invalid request body")
+	}
+
+	r := c.Request()
+	settings, err := h.service.UpdateSettings(r.Context(), &req, service.ManagementClientIP(r), service.FromManagementListener(r.Context()))
+	if err != nil {
+		var uerr *service.UserError
+		if errors.As(err, &uerr) {
+			return validationError(c, uerr.Field, uerr.Message)
+		}
+		return databaseError(c, "update management settings", err)
+	}
+
+	auditCtx := service.ContextWithAudit(r.Context(), c)
+	h.audit.LogSettingsUpdate(auditCtx, "Management access", map[string]interface{}{
+		"allowlist_enabled": settings.AllowlistEnabled,
+		"allowed_networks":  settings.AllowedNetworks,
+		"listener_enabled":  settings.ListenerEnabled,
+		"listener_address":  settings.ListenerAddress,
+		"certificate_id":    settings.CertificateID,
+		"dedicated_only":    settings.DedicatedOnly,
+	})
+
+	return c.JSON(http.StatusOK, settings)
+}
+
+// GetStatus reports the state of the dedicated listener and how the
+// allowlist sees the caller
+func (h *ManagementHandler) GetStatus(c echo.Context) error {
+	return c.JSON(http.StatusOK, h.service.Status(c.Request()))
+}
@@ -0,0 +1,529 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"nginx-proxy-guard/internal/model"
+	"nginx-proxy-guard/internal/repository"
+	"nginx-proxy-guard/internal/service"
+)
+
+type OIDCGateHandler struct {
+	repo             *repository.OIDCGateRepository
+	svc              *service.OIDCGateService
+	proxyHostRepo    *repository.ProxyHostRepository
+	forwardAuthRepo  *repository.ForwardAuthRepository
+	geoRepo          *repository.GeoRepository
+	proxyHostService *service.ProxyHostService
+	audit            *service.AuditService
+}
+
+func NewOIDCGateHandler(
+	repo *repository.OIDCGateRepository,
+	svc *service.OIDCGateService,
+	proxyHostRepo *repository.ProxyHostRepository,
+	forwardAuthRepo *repository.ForwardAuthRepository,
+	geoRepo *repository.GeoRepository,
+	proxyHostService *service.ProxyHostService,
+	audit *service.AuditService,
+) *OIDCGateHandler {
+	return &OIDCGateHandler{
+		repo:             repo,
+		svc:              svc,
+		proxyHostRepo:    proxyHostRepo,
+		forwardAuthRepo:  forwardAuthRepo,
+		geoRepo:          geoRepo,
+		proxyHostService: proxyHostService,
+		audit:            audit,
+	}
+}
+
+// === Identity providers ===
+
+// ListProviders returns all identity providers
+func (h *OIDCGateHandler) ListProviders(c echo.Context) error {
+	providers, err := h.repo.ListProviders(c.Request().Context())
+	if err != nil {
+		return databaseError(c, "list OIDC providers", err)
+	}
+	if providers == nil {
+		providers = []model.OIDCProvider{}
+	}
+	return c.JSON(http.StatusOK, providers)
+}
+
+// CreateProvider adds an identity provider
+func (h *OIDCGateHandler) CreateProvider(c echo.Context) error {
+	var req model.OIDCProviderRequest
+	if err := c.Bind(&req); err != nil {
+		return badRequestError(c, "Invalid request body")
+	}
+	if verr := normalizeOIDCProviderRequest(&req); verr != nil {
+		return validationError(c, verr.Field, verr.Message)
+	}
+	if req.ClientSecret == "" {
+		return validationError(c, "client_secret", "is required")
+	}
+
+	ctx := c.Request().Context()
+	existing, err := h.repo.GetProviderByName(ctx, req.Name)
+	if err != nil {
+		return databaseError(c, "get OIDC provider", err)
+	}
+	if existing != nil {
+		return conflictError(c, "An identity provider with this name already exists")
+	}
+
+	provider, err := h.repo.CreateProvider(ctx, &req)
+	if err != nil {
+		return databaseError(c, "create OIDC provider", err)
+	}
+
+	// Audit log
+	auditCtx := service.ContextWithAudit(ctx, c)
+	h.audit.LogSettingsUpdate(auditCtx, "OIDC Provider", map[string]interface{}{
+		"action": "create",
+		"name":   provider.Name,
+		"issuer": provider.IssuerURL,
+	})
+
+	return createdResponse(c, provider)
+}
+
+// UpdateProvider updates an identity provider
+func (h *OIDCGateHandler) UpdateProvider(c echo.Context) error {
+	var req model.OIDCProviderRequest
+	if err := c.Bind(&req); err != nil {
+		return badRequestError(c, "Invalid request body")
+	}
+	if verr := normalizeOIDCProviderRequest(&req); verr != nil {
+		return validationError(c, verr.Field, verr.Message)
+	}
+
+	ctx := c.Request().Context()
+	id := c.Param("id")
+	existing, err := h.repo.GetProviderByName(ctx, req.Name)
+	if err != nil {
+		return databaseError(c, "get OIDC provider", err)
+	}
+	if existing != nil && existing.ID != id {
+		return conflictError(c, "An identity provider with this name already exists")
+	}
+
+	provider, err := h.repo.UpdateProvider(ctx, id, &req)
+	if err != nil {
+		return databaseError(c, "update OIDC provider", err)
+	}
+	if provider == nil {
+		return notFoundError(c, "OIDC provider")
+	}
+
+	// Audit log
+	auditCtx := service.ContextWithAudit(ctx, c)
+	h.audit.LogSettingsUpdate(auditCtx, "OIDC Provider", map[string]interface{}{
+		"action": "update",
+		"name":   provider.Name,
+		"issuer": provider.IssuerURL,
+	})
+
+	return c.JSON(http.StatusOK, provider)
+}
+
+// DeleteProvider removes an identity provider that no proxy host or admin login uses
+func (h *OIDCGateHandler) DeleteProvider(c echo.Context) error {
+	ctx := c.Request().Context()
+	id := c.Param("id")
+
+	provider, err := h.repo.GetProvider(ctx, id)
+	if err != nil {
+		return databaseError(c, "get OIDC provider", err)
+	}
+	if provider == nil {
+		return notFoundError(c, "OIDC provider")
+	}
+
+	inUse, err := h.repo.CountGatesByProvider(ctx, id)
+	if err != nil {
+		return databaseError(c, "count OIDC host gates", err)
+	}
+	if inUse > 0 {
+		return conflictError(c, "Identity provider is used by proxy hosts")
+	}
+	adminSSO, err := h.repo.IsAdminSSOProvider(ctx, id)
+	if err != nil {
+		return databaseError(c, "check admin SSO provider", err)
+	}
+	if adminSSO {
+		return conflictError(c, "Identity provider is used for admin single sign-on")
+	}
+
+	if err := h.repo.DeleteProvider(ctx, id); err != nil {
+		return databaseError(c, "delete OIDC provider", err)
+	}
+
+	// Audit log
+	auditCtx := service.ContextWithAudit(ctx, c)
+	h.audit.LogSettingsUpdate(auditCtx, "OIDC Provider", map[string]interface{}{
+		"action": "delete",
+		"name":   provider.Name,
+	})
+
+	return noContentResponse(c)
+}
+
+// === Per proxy host login gate ===
+
+// GetGate returns the login gate of a proxy host
+func (h *OIDCGateHandler) GetGate(c echo.Context) error {
+	proxyHostID := c.Param("proxyHostId")
+
+	gate, err := h.repo.GetGateByProxyHostID(c.Request().Context(), proxyHostID)
+	if err != nil {
+		return databaseError(c, "get OIDC host gate", err)
+	}
+	if gate == nil {
+		gate = &model.OIDCHostGate{
+			ProxyHostID:         proxyHostID,
+			AllowedEmailDomains: []string{},
+			AllowedGroups:       []string{},
+			RequiredClaims:      map[string]string{},
+			SessionLifetime:     service.DefaultOIDCSessionLifetime,
+		}
+	}
+	return c.JSON(http.StatusOK, gate)
+}
+
+// UpsertGate requires a login for a proxy host
+func (h *OIDCGateHandler) UpsertGate(c echo.Context) error {
+	proxyHostID := c.Param("proxyHostId")
+	ctx := c.Request().Context()
+
+	var req model.UpsertOIDCHostGateRequest
+	if err := c.Bind(&req); err != nil {
+		return badRequestError(c, "Invalid request body")
+	}
+	if verr := normalizeOIDCHostGateRequest(&req); verr != nil {
+		return validationError(c, verr.Field, verr.Message)
+	}
+
+	host, err := h.proxyHostRepo.GetByID(ctx, proxyHostID)
+	if err != nil {
+		return databaseError(c, "get proxy host", err)
+	}
+	if host == nil {
+		return notFoundError(c, "Proxy host")
+	}
+
+	provider, err := h.repo.GetProvider(ctx, req.ProviderID)
+	if err != nil {
+		return databaseError(c, "get OIDC provider", err)
+	}
+	if provider == nil {
+		return validationError(c, "provider_id", "does not exist")
+	}
+
+	if req.Enabled == nil || *req.Enabled {
+		fa, err := h.forwardAuthRepo.GetByProxyHostID(ctx, proxyHostID)
+		if err != nil {
+			return databaseError(c, "get forward auth", err)
+		}
+		if fa != nil && fa.Enabled {
+			return badRequestError(c, "OIDC login cannot be combined with forward auth")
+		}
+		geo, err := h.geoRepo.GetByProxyHostID(ctx, proxyHostID)
+		if err != nil {
+			return databaseError(c, "get geo restriction", err)
+		}
+		if geo != nil && geo.ChallengeMode {
+			return badRequestError(c, "OIDC login cannot be combined with geo restriction challenge mode")
+		}
+	}
+
+	gate, err := h.repo.UpsertGate(ctx, proxyHostID, &req)
+	if err != nil {
+		return databaseError(c, "upsert OIDC host gate", err)
+	}
+
+	if host.Enabled {
+		if err := h.proxyHostService.RegenerateConfigForHost(ctx, proxyHostID); err != nil {
+			return internalError(c, "regenerate nginx config for OIDC login", err)
+		}
+	}
+
+	// Audit log
+	auditCtx := service.ContextWithAudit(ctx, c)
+	h.audit.LogSecurityFeatureUpdate(auditCtx, "oidc_login", hostDisplayName(host), gate.Enabled, nil)
+
+	return c.JSON(http.StatusOK, gate)
+}
+
+// DeleteGate stops requiring a login for a proxy host
+func (h *OIDCGateHandler) DeleteGate(c echo.Context) error {
+	proxyHostID := c.Param("proxyHostId")
+	ctx := c.Request().Context()
+
+	host, err := h.proxyHostRepo.GetByID(ctx, proxyHostID)
+	if err != nil {
+		return databaseError(c, "get proxy host", err)
+	}
+	if host == nil {
+		return notFoundError(c, "Proxy host")
+	}
+
+	if err := h.repo.DeleteGate(ctx, proxyHostID); err != nil {
+		return databaseError(c, "delete OIDC host gate", err)
+	}
+
+	if host.Enabled {
+		if err := h.proxyHostService.RegenerateConfigForHost(ctx, proxyHostID); err != nil {
+			return internalError(c, "regenerate nginx config for OIDC login removal", err)
+		}
+	}
+
+	// Audit log
+	auditCtx := service.ContextWithAudit(ctx, c)
+	h.audit.LogSecurityFeatureUpdate(auditCtx, "oidc_login", hostDisplayName(host), false, nil)
+
+	return noContentResponse(c)
+}
+
+// === Public login flow (proxied by nginx on the protected host) ===
+
+// Login redirects to the identity provider of a proxy host
+// The return parameter is always last and taken verbatim, since nginx can't escape $request_uri
+func (h *OIDCGateHandler) Login(c echo.Context) error {
+	hostID := c.QueryParam("host")
+	returnURL := ""
+	if _, raw, ok := strings.Cut(c.Request().URL.RawQuery, "return="); ok {
+		returnURL = raw
+	}
+	returnURL = sameHostReturnURL(c, returnURL)
+	redirectURI := c.Scheme() + "://" + c.Request().Host + "/api/v1/oidc/callback"
+
+	start, err := h.svc.BeginLogin(c.Request().Context(), hostID, redirectURI, returnURL)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrOIDCGateNotFound):
+			return c.HTML(http.StatusNotFound, oidcErrorPage("Not Found", "Login is not enabled for this site."))
+		case errors.Is(err, service.ErrOIDCProviderDisabled):
+			return c.HTML(http.StatusServiceUnavailable, oidcErrorPage("Login Unavailable", "The identity provider for this site is disabled."))
+		}
+		log.Printf("[OIDC] Failed to start login for host %s: %v", hostID, err)
+		return c.HTML(http.StatusBadGateway, oidcErrorPage("Login Unavailable", "The identity provider could not be reached."))
+	}
+
+	c.SetCookie(&http.Cookie{
+		Name:     service.OIDCStateCookie,
+		Value:    start.StateCookie,
+		Path:     "/api/v1/oidc/",
+		MaxAge:   600,
+		HttpOnly: true,
+		Secure:   c.Scheme() == "https",
+		SameSite: http.SameSiteLaxMode,
+	})
+	return c.Redirect(http.StatusFound, start.AuthorizationURL)
+}
+
+// Callback completes the login and sets the session cookie for the protected host
+func (h *OIDCGateHandler) Callback(c echo.Context) error {
+	if c.QueryParam("error") != "" {
+		return c.HTML(http.StatusForbidden, oidcErrorPage("Login Failed", "The identity provider did not complete the login."))
+	}
+
+	stateCookie, err := c.Cookie(service.OIDCStateCookie)
+	if err != nil {
+		return c.HTML(http.StatusBadRequest, oidcErrorPage("Login Expired", "Your login took too long. Please try again."))
+	}
+
+	result, err := h.svc.CompleteLogin(c.Request().Context(), stateCookie.Value, c.QueryParam("state"), c.QueryParam("code"))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrOIDCInvalidState):
+			return c.HTML(http.StatusBadRequest, oidcErrorPage("Login Expired", "Your login took too long. Please try again."))
+		case errors.Is(err, service.ErrOIDCAccessDenied):
+			return c.HTML(http.StatusForbidden, oidcErrorPage("Access Denied", "Your account is not allowed to access this site."))
+		case errors.Is(err, service.ErrOIDCGateNotFound):
+			return c.HTML(http.StatusNotFound, oidcErrorPage("Not Found", "Login is not enabled for this site."))
+		}
+		log.Printf("[OIDC] Login failed: %v", err)
+		return c.HTML(http.StatusBadGateway, oidcErrorPage("Login Failed", "The login could not be verified."))
+	}
+
+	secure := c.Scheme() == "https"
+	c.SetCookie(&http.Cookie{
+		Name:     service.OIDCStateCookie,
+		Path:     "/api/v1/oidc/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   secure,
+	})
+	c.SetCookie(&http.Cookie{
+		Name:     service.OIDCSessionCookie,
+		Value:    result.SessionCookie,
+		Path:     "/",
+		MaxAge:   result.MaxAge,
+		HttpOnly: true,
+		Secure:   secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return c.Redirect(http.StatusFound, sameHostReturnURL(c, result.ReturnURL))
+}
+
+// Validate checks the session cookie (internal endpoint for nginx auth_request)
+// The user is returned in X-Auth-User / X-Auth-Email for nginx to pass upstream
+func (h *OIDCGateHandler) Validate(c echo.Context) error {
+	session := c.Request().Header.Get("X-OIDC-Session")
+	if session == "" {
+		if cookie, err := c.Cookie(service.OIDCSessionCookie); err == nil {
+			session = cookie.Value
+		}
+	}
+	if session == "" {
+		return c.NoContent(http.StatusUnauthorized)
+	}
+
+	identity, err := h.svc.ValidateSession(c.Request().Context(), c.Request().Header.Get("X-Proxy-Host-ID"), session)
+	if err != nil {
+		return c.NoContent(http.StatusUnauthorized)
+	}
+
+	user := identity.Name
+	if user == "" {
+		user = identity.Subject
+	}
+	c.Response().Header().Set("X-Auth-User", user)
+	c.Response().Header().Set("X-Auth-Email", identity.Email)
+	return c.NoContent(http.StatusOK)
+}
+
+// Logout clears the session cookie of the protected host
+func (h *OIDCGateHandler) Logout(c echo.Context) error {
+	c.SetCookie(&http.Cookie{
+		Name:     service.OIDCSessionCookie,
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   c.Scheme() == "https",
+	})
+	return c.HTML(http.StatusOK, oidcErrorPage("Signed Out", "You have been signed out."))
+}
+
+// sameHostReturnURL only allows returning to the host the login runs on, to avoid open redirects
+func sameHostReturnURL(c echo.Context, returnURL string) string {
+	fallback := c.Scheme() + "://" + c.Request().Host + "/"
+	u, err := url.Parse(returnURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return fallback
+	}
+	requestHost := c.Request().Host
+	if host, _, err := net.SplitHostPort(requestHost); err == nil {
+		requestHost = host
+	}
+	if !strings.EqualFold(u.Hostname(), requestHost) {
+		return fallback
+	}
+	return returnURL
+}
+
+func oidcErrorPage(title, message string) string {
+	return `<!DOCTYPE html><html><head><title>` + escapeHTML(title) + `</title></head><body><h1>` +
+		escapeHTML(title) + `</h1><p>` + escapeHTML(message) + `</p></body></html>`
+}
+
+// normalizeOIDCProviderRequest trims and validates an identity provider
+func normalizeOIDCProviderRequest(req *model.OIDCProviderRequest) *ValidationError {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return &ValidationError{Field: "name", Message: "is required"}
+	}
+	if len(req.Name) > MaxNameLength {
+		return &ValidationError{Field: "name", Message: "is too long"}
+	}
+
+	req.IssuerURL = strings.TrimSuffix(strings.TrimSpace(req.IssuerURL), "/")
+	u, err := url.Parse(req.IssuerURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return &ValidationError{Field: "issuer_url", Message: "must be an absolute http or https URL"}
+	}
+
+	req.ClientID = strings.TrimSpace(req.ClientID)
+	if req.ClientID == "" {
+		return &ValidationError{Field: "client_id", Message: "is required"}
+	}
+
+	scopes := []string{"openid"}
+	for _, scope := range req.Scopes {
+		scope = strings.TrimSpace(scope)
+		if scope == "" || scope == "openid" {
+			continue
+		}
+		if strings.ContainsAny(scope, " \t\r\n") {
+			return &ValidationError{Field: "scopes", Message: "must not contain whitespace"}
+		}
+		scopes = append(scopes, scope)
+	}
+	if len(scopes) == 1 {
+		scopes = append(scopes, "email", "profile")
+	}
+	req.Scopes = scopes
+
+	req.GroupsClaim = strings.TrimSpace(req.GroupsClaim)
+	if req.GroupsClaim == "" {
+		req.GroupsClaim = "groups"
+	}
+	return nil
+}
+
+// normalizeOIDCHostGateRequest trims and validates a login gate
+func normalizeOIDCHostGateRequest(req *model.UpsertOIDCHostGateRequest) *ValidationError {
+	req.ProviderID = strings.TrimSpace(req.ProviderID)
+	if req.ProviderID == "" {
+		return &ValidationError{Field: "provider_id", Message: "is required"}
+	}
+
+	if req.SessionLifetime == 0 {
+		req.SessionLifetime = service.DefaultOIDCSessionLifetime
+	}
+	if req.SessionLifetime < service.MinOIDCSessionLifetime || req.SessionLifetime > service.MaxOIDCSessionLifetime {
+		return &ValidationError{Field: "session_lifetime", Message: "must be between 5 minutes and 30 days"}
+	}
+
+	domains := make([]string, 0, len(req.AllowedEmailDomains))
+	for _, d := range req.AllowedEmailDomains {
+		d = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(d), "@"))
+		if d == "" {
+			continue
+		}
+		if strings.ContainsAny(d, " @/") {
+			return &ValidationError{Field: "allowed_email_domains", Message: "contains an invalid domain: " + d}
+		}
+		domains = append(domains, d)
+	}
+	req.AllowedEmailDomains = domains
+
+	groups := make([]string, 0, len(req.AllowedGroups))
+	for _, g := range req.AllowedGroups {
+		if g = strings.TrimSpace(g); g != "" {
+			groups = append(groups, g)
+		}
+	}
+	req.AllowedGroups = groups
+
+	claims := make(map[string]string, len(req.RequiredClaims))
+	for name, value := range req.RequiredClaims {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return &ValidationError{Field: "required_claims", Message: "contains an empty claim name"}
+		}
+		claims[name] = value
+	}
+	req.RequiredClaims = claims
+	return nil
+}
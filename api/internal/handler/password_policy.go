@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"nginx-proxy-guard/internal/model"
+	"nginx-proxy-guard/internal/service"
+)
+
+type PasswordPolicyHandler struct {
+	service *service.PasswordPolicyService
+	audit   *service.AuditService
+}
+
+func NewPasswordPolicyHandler(policyService *service.PasswordPolicyService, audit *service.AuditService) *PasswordPolicyHandler {
+	return &PasswordPolicyHandler{
+		service: policyService,
+		audit:   audit,
+	}
+}
+
+// GetSettings returns the password policy
+func (h *PasswordPolicyHandler) GetSettings(c echo.Context) error {
+	policy, err := h.service.GetSettings(c.Request().Context())
+	if err != nil {
+		return databaseError(c, "get password policy", err)
+	}
+	return c.JSON(http.StatusOK, policy)
+}
+
+// UpdateSettings updates the password policy and login lockout
+func (h *PasswordPolicyHandler) UpdateSettings(c echo.Context) error {
+	var req model.UpdatePasswordPolicyRequest
+	if err := c.Bind(&req); err != nil {
+		return badRequestError(c, "Invalid request body")
+	}
+
+	policy, err := h.service.UpdateSettings(c.Request().Context(), &req)
+	if err != nil {
+		var uerr *service.UserError
+		if errors.As(err, &uerr) {
+			return validationError(c, uerr.Field, uerr.Message)
+		}
+		return databaseError(c, "update password policy", err)
+	}
+
+	auditCtx := service.ContextWithAudit(c.Request().Context(), c)
+	h.audit.LogSettingsUpdate(auditCtx, "Password policy", map[string]interface{}{
+		"min_length":           policy.MinLength,
+		"require_uppercase":    policy.RequireUppercase,
+		"require_lowercase":    policy.RequireLowercase,
+		"require_digit":        policy.RequireDigit,
+		"require_symbol":       policy.RequireSymbol,
+		"history_count":        policy.HistoryCount,
+		"max_age_days":         policy.MaxAgeDays,
+		"breach_check_enabled": policy.BreachCheckEnabled,
+		"lockout_threshold":    policy.LockoutThreshold,
+		"ip_lockout_threshold": policy.IPLockoutThreshold,
+		"lockout_minutes":      policy.LockoutMinutes,
+		"max_lockout_minutes":  policy.MaxLockoutMinutes,
+	})
+
+	return c.JSON(http.StatusOK, policy)
+}
+
+// GetBreachList describes the breached password list in use
+func (h *PasswordPolicyHandler) GetBreachList(c echo.Context) error {
+	status, err := h.service.BreachListStatus(c.Request().Context())
+	if err != nil {
+		return databaseError(c, "get breached password list", err)
+	}
+	return c.JSON(http.StatusOK, status)
+}
+
+// UploadBreachList replaces the bundled list with an uploaded file of SHA-1
+// hashes, one per line, such as a Pwned Passwords download
+func (h *PasswordPolicyHandler) UploadBreachList(c echo.Context) error {
+	file, err := c.FormFile("file")
+	if err != nil {
+		return badRequestError(c, "file is required")
+	}
+	src, err := file.Open()
+	if err != nil {
+		return internalError(c, "read breached password list", err)
+	}
+	defer src.Close()
+
+	username, _ := getContextString(c, "username")
+	status, err := h.service.UploadBreachList(c.Request().Context(), file.Filename, src, username)
+	if err != nil {
+		var uerr *service.UserError
+		if errors.As(err, &uerr) {
+			return validationError(c, uerr.Field, uerr.Message)
+		}
+		return databaseError(c, "save breached password list", err)
+	}
+
+	auditCtx := service.ContextWithAudit(c.Request().Context(), c)
+	h.audit.LogBreachListUpdated(auditCtx, status)
+
+	return c.JSON(http.StatusOK, status)
+}
+
+// DeleteBreachList removes the uploaded list and goes back to the bundled one
+func (h *PasswordPolicyHandler) DeleteBreachList(c echo.Context) error {
+	status, err := h.service.DeleteBreachList(c.Request().Context())
+	if err != nil {
+		return databaseError(c, "delete breached password list", err)
+	}
+
+	auditCtx := service.ContextWithAudit(c.Request().Context(), c)
+	h.audit.LogBreachListUpdated(auditCtx, status)
+
+	return c.JSON(http.StatusOK, status)
+}
+
+// Unlock ends the lockout of a user after too many failed logins
+func (h *PasswordPolicyHandler) Unlock(c echo.Context) error {
+	user, cleared, err := h.service.Unlock(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			return notFoundError(c, "User")
+		}
+		return databaseError(c, "unlock user", err)
+	}
+
+	auditCtx := service.ContextWithAudit(c.Request().Context(), c)
+	h.audit.LogAccountUnlocked(auditCtx, user.ID, user.Username, cleared)
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"cleared_attempts": cleared,
+	})
+}
@@ -1,6 +1,8 @@
 package handler
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"strings"
@@ -12,9 +14,11 @@ import (
 )
 
 type ProxyHostHandler struct {
-	service *service.ProxyHostService
-	audit   *service.AuditService
-	tester  *service.ProxyHostTester
+	service   *service.ProxyHostService
+	audit     *service.AuditService
+	tester    *service.ProxyHostTester
+	approvals *service.ChangeRequestService
+	waf       *WAFHandler
 }
 
 func NewProxyHostHandler(svc *service.ProxyHostService, audit *service.AuditService) *ProxyHostHandler {
@@ -25,6 +29,62 @@ func NewProxyHostHandler(svc *service.ProxyHostService, audit *service.AuditServ
 	}
 }
 
+// SetApprovals lets weakening the WAF of a host require approval by a second
+// user: turning it off, switching it to detection only, or disabling one of
+// its rules through waf
+func (h *ProxyHostHandler) SetApprovals(approvals *service.ChangeRequestService, waf *WAFHandler) {
+	h.approvals = approvals
+	h.waf = waf
+	waf.SetApprovals(approvals, h.audit)
+	approvals.Register(model.ChangeActionWAFDisable, model.PermissionWAFWrite, h.applyWAFDisable)
+}
+
+// scopeAllowsHostWrite reports whether a scoped token may write a proxy host:
+// existing (nil for a new host) with domains and tags set by the request (nil
+// when unchanged). Domains must pass the scope's domain globs even when the
+// host is in scope by ID or tag, so a token can't claim other domains, and
+// only the scope's tags may be added.
+func scopeAllowsHostWrite(scope model.APITokenScope, existing *model.ProxyHost, domains []string, tags *[]string) bool {
+	var current []string
+	if existing != nil {
+		current = existing.Tags
+		updated := *existing
+		if domains != nil {
+			updated.DomainNames = domains
+		}
+		if tags != nil {
+			updated.Tags = *tags
+		}
+		if !scope.AllowsHost(&updated) {
+			return false
+		}
+	}
+	if (existing == nil || domains != nil) && !scope.AllowsDomains(domains) {
+		return false
+	}
+	return tags == nil || scope.AllowsTags(*tags, current)
+}
+
+// wafDisableChange is the payload of a change request that weakens the WAF of
+// a host: either a host update or a rule exclusion
+type wafDisableChange struct {
+	ProxyHostID   string                               `json:"proxy_host_id"`
+	Request       *model.UpdateProxyHostRequest        `json:"request,omitempty"`
+	RuleExclusion *model.CreateWAFRuleExclusionRequest `json:"rule_exclusion,omitempty"`
+}
+
+// weakensWAF reports whether a host update turns off the WAF of host or
+// switches it from blocking to detection only
+func weakensWAF(host *model.ProxyHost, req *model.UpdateProxyHostRequest) bool {
+	if !host.WAFEnabled {
+		return false
+	}
+	if req.WAFEnabled != nil && !*req.WAFEnabled {
+		return true
+	}
+	return req.WAFMode != nil && *req.WAFMode == "detection" && host.WAFMode != "detection"
+}
+
 func (h *ProxyHostHandler) Create(c echo.Context) error {
 	var req model.CreateProxyHostRequest
 	if err := c.Bind(&req); err != nil {
@@ -59,6 +119,10 @@ func (h *ProxyHostHandler) Create(c echo.Context) error {
 		})
 	}
 
+	if scope, scoped := callerTokenScope(c); scoped && !scopeAllowsHostWrite(scope, nil, req.DomainNames, &req.Tags) {
+		return outOfScopeError(c, "Proxy host")
+	}
+
 	host, err := h.service.Create(c.Request().Context(), &req)
 	if err != nil {
 		errMsg := err.Error()
@@ -116,6 +180,9 @@ func (h *ProxyHostHandler) GetByDomain(c echo.Context) error {
 	if host == nil {
 		return notFoundError(c, "Proxy host")
 	}
+	if scope, scoped := callerTokenScope(c); scoped && !scope.AllowsHost(host) {
+		return outOfScopeError(c, "Proxy host")
+	}
 
 	return c.JSON(http.StatusOK, host)
 }
@@ -126,7 +193,8 @@ func (h *ProxyHostHandler) List(c echo.Context) error {
 	sortBy := c.QueryParam("sort_by")
 	sortOrder := c.QueryParam("sort_order")
 
-	response, err := h.service.List(c.Request().Context(), page, perPage, search, sortBy, sortOrder)
+	scope, _ := callerTokenScope(c)
+	response, err := h.service.ListInScope(c.Request().Context(), scope, page, perPage, search, sortBy, sortOrder)
 	if err != nil {
 		return databaseError(c, "list proxy hosts", err)
 	}
@@ -152,6 +220,29 @@ func (h *ProxyHostHandler) Update(c echo.Context) error {
 		})
 	}
 
+	// A scoped token can't move a host out of its scope or onto other domains
+	if scope, scoped := callerTokenScope(c); scoped && existingHost != nil && !scopeAllowsHostWrite(scope, existingHost, req.DomainNames, req.Tags) {
+		return outOfScopeError(c, "Proxy host")
+	}
+
+	// The whole update waits for approval when it turns off the WAF or stops it from blocking
+	if existingHost != nil && weakensWAF(existingHost, &req) {
+		if required, err := approvalRequired(c, h.approvals, model.ChangeActionWAFDisable); err != nil {
+			return databaseError(c, "check change approval", err)
+		} else if required {
+			diff, err := service.FieldDiff(existingHost, &req)
+			if err != nil {
+				return internalError(c, "render proxy host diff", err)
+			}
+			return submitChange(c, h.approvals, h.audit, &model.ChangeRequest{
+				Action:     model.ChangeActionWAFDisable,
+				TargetID:   id,
+				TargetName: strings.Join(existingHost.DomainNames, ", "),
+				Diff:       diff,
+			}, wafDisableChange{ProxyHostID: id, Request: &req})
+		}
+	}
+
 	host, err := h.service.Update(c.Request().Context(), id, &req)
 	if err != nil {
 		errMsg := err.Error()
@@ -186,6 +277,40 @@ func (h *ProxyHostHandler) Update(c echo.Context) error {
 	return c.JSON(http.StatusOK, host)
 }
 
+// applyWAFDisable applies an approved change that weakens the WAF of a host
+func (h *ProxyHostHandler) applyWAFDisable(ctx context.Context, payload json.RawMessage) error {
+	var change wafDisableChange
+	if err := json.Unmarshal(payload, &change); err != nil {
+		return fmt.Errorf("invalid change request payload: %w", err)
+	}
+	if change.RuleExclusion != nil {
+		return h.waf.applyRuleDisable(ctx, change.ProxyHostID, change.RuleExclusion)
+	}
+	if change.Request == nil {
+		return fmt.Errorf("invalid change request payload: no host update")
+	}
+
+	host, err := h.service.Update(ctx, change.ProxyHostID, change.Request)
+	if err != nil {
+		return err
+	}
+	if host == nil {
+		return fmt.Errorf("proxy host no longer exists")
+	}
+
+	h.audit.LogProxyHostUpdate(ctx, host.DomainNames, map[string]interface{}{
+		"id": host.ID,
+	})
+	if change.Request.WAFEnabled != nil && !*change.Request.WAFEnabled {
+		h.audit.LogWAFDisabled(ctx, strings.Join(host.DomainNames, ", "))
+	} else if change.Request.WAFMode != nil {
+		h.audit.LogWAFRulesUpdated(ctx, strings.Join(host.DomainNames, ", "), map[string]interface{}{
+			"waf_mode": *change.Request.WAFMode,
+		})
+	}
+	return nil
+}
+
 func (h *ProxyHostHandler) Delete(c echo.Context) error {
 	id := c.Param("id")
 	if id == "" {
@@ -248,6 +373,37 @@ func (h *ProxyHostHandler) TestHost(c echo.Context) error {
 	return c.JSON(http.StatusOK, result)
 }
 
+// TestUpstream tests the connection to a proxy host's backend, including its backend TLS settings
+func (h *ProxyHostHandler) TestUpstream(c echo.Context) error {
+	id := c.Param("id")
+	if id == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "id is required",
+		})
+	}
+
+	host, err := h.service.GetByID(c.Request().Context(), id)
+	if err != nil {
+		return databaseError(c, "get proxy host for upstream test", err)
+	}
+
+	if host == nil {
+		return notFoundError(c, "Proxy host")
+	}
+
+	tlsCfg, clientCert, err := h.service.GetUpstreamTLS(c.Request().Context(), id)
+	if err != nil {
+		return databaseError(c, "get upstream TLS for test", err)
+	}
+
+	result, err := h.tester.TestUpstream(c.Request().Context(), host, tlsCfg, clientCert)
+	if err != nil {
+		return internalError(c, "test upstream", err)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
 // Clone creates a copy of an existing proxy host with new domain names
 func (h *ProxyHostHandler) Clone(c echo.Context) error {
 	id := c.Param("id")
@@ -283,6 +439,14 @@ func (h *ProxyHostHandler) Clone(c echo.Context) error {
 	// Get source host info for audit
 	sourceHost, _ := h.service.GetByID(c.Request().Context(), id)
 
+	// The clone is a new host carrying the source's tags
+	if scope, scoped := callerTokenScope(c); scoped && sourceHost != nil {
+		tags := []string(sourceHost.Tags)
+		if !scopeAllowsHostWrite(scope, nil, req.DomainNames, &tags) {
+			return outOfScopeError(c, "Proxy host")
+		}
+	}
+
 	host, err := h.service.Clone(c.Request().Context(), id, &req)
 	if err != nil {
 		errMsg := err.Error()
@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+
+	"nginx-proxy-guard/internal/model"
+)
+
+func TestWeakensWAF(t *testing.T) {
+	off, on := false, true
+	detection, blocking := "detection", "blocking"
+
+	tests := []struct {
+		name string
+		host model.ProxyHost
+		req  model.UpdateProxyHostRequest
+		want bool
+	}{
+		{"turn off", model.ProxyHost{WAFEnabled: true, WAFMode: blocking}, model.UpdateProxyHostRequest{WAFEnabled: &off}, true},
+		{"switch to detection", model.ProxyHost{WAFEnabled: true, WAFMode: blocking}, model.UpdateProxyHostRequest{WAFMode: &detection}, true},
+		{"keep detection", model.ProxyHost{WAFEnabled: true, WAFMode: detection}, model.UpdateProxyHostRequest{WAFMode: &detection}, false},
+		{"switch to blocking", model.ProxyHost{WAFEnabled: true, WAFMode: detection}, model.UpdateProxyHostRequest{WAFMode: &blocking}, false},
+		{"keep on", model.ProxyHost{WAFEnabled: true, WAFMode: blocking}, model.UpdateProxyHostRequest{WAFEnabled: &on}, false},
+		{"already off", model.ProxyHost{WAFMode: blocking}, model.UpdateProxyHostRequest{WAFEnabled: &off, WAFMode: &detection}, false},
+		{"unrelated update", model.ProxyHost{WAFEnabled: true, WAFMode: blocking}, model.UpdateProxyHostRequest{}, false},
+	}
+	for _, tt := range tests {
+		if got := weakensWAF(&tt.host, &tt.req); got != tt.want {
+			t.Errorf("%s: weakensWAF() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestWAFDisableChangePayload(t *testing.T) {
+	// Requests submitted before rule exclusions could be captured only hold a host update
+	var change wafDisableChange
+	if err := json.Unmarshal([]byte(`{"proxy_host_id":"host-1","request":{"waf_enabled":false}}`), &change); err != nil {
+		t.Fatal(err)
+	}
+	if change.Request == nil || change.Request.WAFEnabled == nil || *change.Request.WAFEnabled || change.RuleExclusion != nil {
+		t.Errorf("host update payload decoded as %+v", change)
+	}
+
+	data, err := json.Marshal(wafDisableChange{ProxyHostID: "host-1", RuleExclusion: &model.CreateWAFRuleExclusionRequest{RuleID: 942100}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	change = wafDisableChange{}
+	if err := json.Unmarshal(data, &change); err != nil {
+		t.Fatal(err)
+	}
+	if change.Request != nil || change.RuleExclusion == nil || change.RuleExclusion.RuleID != 942100 {
+		t.Errorf("rule exclusion payload decoded as %+v", change)
+	}
+}
+
+func TestScopeAllowsHostWrite(t *testing.T) {
+	const hostID = "3f0c8a52-6d3e-4c1b-9a57-0d4f2b1e8c90"
+	tagScope := model.APITokenScope{Tags: []string{"ci"}}
+	idScope := model.APITokenScope{ProxyHostIDs: []string{hostID}}
+	domainScope := model.APITokenScope{Domains: []string{"*.ci.example.com"}, Tags: []string{"ci"}}
+	ciHost := &model.ProxyHost{ID: hostID, DomainNames: []string{"app.ci.example.com"}, Tags: []string{"ci", "legacy"}}
+
+	tags := func(t ...string) *[]string { return &t }
+
+	tests := []struct {
+		name     string
+		scope    model.APITokenScope
+		existing *model.ProxyHost
+		domains  []string
+		tags     *[]string
+		want     bool
+	}{
+		// Create
+		{"create: tag scope can't claim a domain", tagScope, nil, []string{"admin.example.com"}, tags("ci"), false},
+		{"create: ID scope can't create", idScope, nil, []string{"admin.example.com"}, tags(), false},
+		{"create: domain in the globs", domainScope, nil, []string{"new.ci.example.com"}, tags("ci"), true},
+		{"create: domain outside the globs", domainScope, nil, []string{"admin.example.com"}, tags("ci"), false},
+		{"create: tag outside the scope", domainScope, nil, []string{"new.ci.example.com"}, tags("prod"), false},
+
+		// Update
+		{"update: ID scope can't retarget domains", idScope, ciHost, []string{"admin.example.com"}, nil, false},
+		{"update: ID scope keeps its domains", idScope, ciHost, nil, nil, true},
+		{"update: tag scope can't retarget domains", tagScope, ciHost, []string{"admin.example.com"}, nil, false},
+		{"update: domain in the globs", domainScope, ciHost, []string{"www.ci.example.com"}, nil, true},
+		{"update: keeps the host's tags", domainScope, ciHost, nil, tags("ci", "legacy"), true},
+		{"update: adds a tag outside the scope", domainScope, ciHost, nil, tags("ci", "prod"), false},
+		{"update: drops the tag the host is in scope by", tagScope, ciHost, nil, tags("legacy"), false},
+
+		// Clone: a new host with the source's tags
+		{"clone: tag scope can't claim a domain", tagScope, nil, []string{"admin.example.com"}, tags("ci"), false},
+		{"clone: ID scope can't claim a domain", idScope, nil, []string{"admin.example.com"}, tags(), false},
+		{"clone: domain in the globs", domainScope, nil, []string{"copy.ci.example.com"}, tags("ci"), true},
+		{"clone: source tags outside the scope", domainScope, nil, []string{"copy.ci.example.com"}, tags("ci", "legacy"), false},
+	}
+	for _, tt := range tests {
+		if got := scopeAllowsHostWrite(tt.scope, tt.existing, tt.domains, tt.tags); got != tt.want {
+			t.Errorf("%s: scopeAllowsHostWrite() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCreateProxyHostOutOfScope(t *testing.T) {
+	tests := []struct {
+		name  string
+		scope model.APITokenScope
+		body  string
+	}{
+		{"tag-scoped token", model.APITokenScope{Tags: []string{"ci"}},
+			`{"domain_names":["admin.example.com"],"tags":["ci"],"forward_host":"backend","forward_port":8080}`},
+		{"ID-scoped token", model.APITokenScope{ProxyHostIDs: []string{"3f0c8a52-6d3e-4c1b-9a57-0d4f2b1e8c90"}},
+			`{"domain_names":["admin.example.com"],"forward_host":"backend","forward_port":8080}`},
+	}
+	for _, tt := range tests {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/proxy-hosts", strings.NewReader(tt.body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.Set("api_token", &model.APIToken{Scope: tt.scope})
+
+		// Refused before the service is reached
+		h := &ProxyHostHandler{}
+		if err := h.Create(c); err != nil {
+			t.Fatalf("%s: Create() error = %v", tt.name, err)
+		}
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("%s: status = %d, want %d", tt.name, rec.Code, http.StatusForbidden)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"nginx-proxy-guard/internal/model"
+	"nginx-proxy-guard/internal/service"
+)
+
+type RealIPHandler struct {
+	service *service.RealIPService
+	audit   *service.AuditService
+}
+
+func NewRealIPHandler(realIPService *service.RealIPService, audit *service.AuditService) *RealIPHandler {
+	return &RealIPHandler{
+		service: realIPService,
+		audit:   audit,
+	}
+}
+
+// GetSettings returns the trusted proxy / CDN real IP settings
+func (h *RealIPHandler) GetSettings(c echo.Context) error {
+	settings, err := h.service.GetSettings(c.Request().Context())
+	if err != nil {
+		return databaseError(c, "get real IP settings", err)
+	}
+	return c.JSON(http.StatusOK, settings)
+}
+
+// UpdateSettings updates the trusted proxy and PROXY protocol settings and reloads nginx
+func (h *RealIPHandler) UpdateSettings(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	var req model.UpdateRealIPSettingsRequest
+	if err := c.Bind(&req); err != nil {
+		return badRequestError(c, "Invalid request body")
+	}
+
+	settings, err := h.service.Update(ctx, &req)
+	if err != nil {
+		var serr *service.RealIPSettingsError
+		if errors.As(err, &serr) {
+			return validationError(c, serr.Field, serr.Message)
+		}
+		return internalError(c, "update real IP settings", err)
+	}
+
+	// Audit log
+	auditCtx := service.ContextWithAudit(ctx, c)
+	h.audit.LogSettingsUpdate(auditCtx, "Real IP", map[string]interface{}{
+		"enabled":         settings.Enabled,
+		"header":          settings.Header,
+		"recursive":       settings.Recursive,
+		"private_ranges":  settings.IncludePrivateRanges,
+		"cloud_providers": settings.CloudProviders,
+		"custom_cidrs":    settings.CustomCIDRs,
+		"proxy_protocol":  settings.ProxyProtocol,
+		"pp_http_port":    settings.ProxyProtocolHTTPPort,
+		"pp_https_port":   settings.ProxyProtocolHTTPSPort,
+	})
+
+	return c.JSON(http.StatusOK, settings)
+}
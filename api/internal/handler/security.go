@@ -28,6 +28,8 @@ type SecurityHandler struct {
 	historyRepo      *repository.IPBanHistoryRepository
 	uriBlockRepo     *repository.URIBlockRepository
 	nginxReloader    *service.NginxReloader
+	banEscalation    *service.BanEscalationService
+	trustedIPs       *service.TrustedIPService
 }
 
 func NewSecurityHandler(
@@ -58,6 +60,16 @@ func NewSecurityHandler(
 	}
 }
 
+// SetBanEscalation sets the service used to report repeat-offender escalation
+func (h *SecurityHandler) SetBanEscalation(escalation *service.BanEscalationService) {
+	h.banEscalation = escalation
+}
+
+// SetTrustedIPs makes manual bans refuse addresses in the global trusted IP allowlist
+func (h *SecurityHandler) SetTrustedIPs(trustedIPs *service.TrustedIPService) {
+	h.trustedIPs = trustedIPs
+}
+
 // Rate Limit handlers
 
 func (h *SecurityHandler) GetRateLimit(c echo.Context) error {
@@ -275,6 +287,10 @@ func (h *SecurityHandler) BanIP(c echo.Context) error {
 		return badRequestError(c, "ip_address is required")
 	}
 
+	if h.trustedIPs != nil && h.trustedIPs.PreventBan(c.Request().Context(), req.IPAddress, model.BanSourceManual, req.Reason, req.ProxyHostID) {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "IP address is in the trusted IP allowlist and cannot be banned"})
+	}
+
 	bannedIP, err := h.rateLimitRepo.BanIP(c.Request().Context(), req.ProxyHostID, req.IPAddress, req.Reason, req.BanTime)
 	if err != nil {
 		return databaseError(c, "ban IP", err)
@@ -944,6 +960,25 @@ func (h *SecurityHandler) GetIPBanHistoryByIP(c echo.Context) error {
 	return c.JSON(http.StatusOK, result)
 }
 
+// GetIPBanEscalation returns the current escalation level of an IP and the length of its next ban
+func (h *SecurityHandler) GetIPBanEscalation(c echo.Context) error {
+	if h.banEscalation == nil {
+		return internalError(c, "ban escalation service not initialized", nil)
+	}
+
+	ip := c.Param("ip")
+	if ip == "" {
+		return badRequestError(c, "IP address is required")
+	}
+
+	status, err := h.banEscalation.GetStatus(c.Request().Context(), ip)
+	if err != nil {
+		return databaseError(c, "get IP ban escalation", err)
+	}
+
+	return c.JSON(http.StatusOK, status)
+}
+
 func (h *SecurityHandler) GetIPBanHistoryStats(c echo.Context) error {
 	if h.historyRepo == nil {
 		return internalError(c, "history repository not initialized", nil)
@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"nginx-proxy-guard/internal/model"
+	"nginx-proxy-guard/internal/service"
+)
+
+type SessionHandler struct {
+	service *service.SessionService
+	audit   *service.AuditService
+}
+
+func NewSessionHandler(sessionService *service.SessionService, audit *service.AuditService) *SessionHandler {
+	return &SessionHandler{
+		service: sessionService,
+		audit:   audit,
+	}
+}
+
+// sessionError maps session service errors to responses
+func sessionError(c echo.Context, operation string, err error) error {
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		return notFoundError(c, "User")
+	case errors.Is(err, service.ErrSessionNotFound):
+		return notFoundError(c, "Session")
+	}
+	return databaseError(c, operation, err)
+}
+
+// GetSettings returns the session policy
+func (h *SessionHandler) GetSettings(c echo.Context) error {
+	settings, err := h.service.GetSettings(c.Request().Context())
+	if err != nil {
+		return databaseError(c, "get session settings", err)
+	}
+	return c.JSON(http.StatusOK, settings)
+}
+
+// UpdateSettings updates the idle timeout and absolute lifetime of sessions
+func (h *SessionHandler) UpdateSettings(c echo.Context) error {
+	var req model.UpdateSessionSettingsRequest
+	if err := c.Bind(&req); err != nil {
+		return badRequestError(c, "Invalid request body")
+	}
+
+	settings, err := h.service.UpdateSettings(c.Request().Context(), &req)
+	if err != nil {
+		var uerr *service.UserError
+		if errors.As(err, &uerr) {
+			return validationError(c, uerr.Field, uerr.Message)
+		}
+		return databaseError(c, "update session settings", err)
+	}
+
+	auditCtx := service.ContextWithAudit(c.Request().Context(), c)
+	h.audit.LogSettingsUpdate(auditCtx, "Session", map[string]interface{}{
+		"idle_timeout_minutes": settings.IdleTimeoutMinutes,
+		"max_lifetime_hours":   settings.MaxLifetimeHours,
+	})
+
+	return c.JSON(http.StatusOK, settings)
+}
+
+// === Own sessions ===
+
+// ListOwn returns the active sessions of the current user
+func (h *SessionHandler) ListOwn(c echo.Context) error {
+	user, ok := getUserFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "authentication required"})
+	}
+	token, _ := getContextString(c, "token")
+
+	sessions, err := h.service.List(c.Request().Context(), user.ID, token)
+	if err != nil {
+		return sessionError(c, "list sessions", err)
+	}
+	return c.JSON(http.StatusOK, sessions)
+}
+
+// RevokeOwn signs out one session of the current user, which may be the current one
+func (h *SessionHandler) RevokeOwn(c echo.Context) error {
+	user, ok := getUserFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "authentication required"})
+	}
+
+	_, session, err := h.service.Revoke(c.Request().Context(), user.ID, c.Param("id"))
+	if err != nil {
+		return sessionError(c, "revoke session", err)
+	}
+
+	auditCtx := service.ContextWithAudit(c.Request().Context(), c)
+	h.audit.LogSessionRevoked(auditCtx, user.ID, user.Username, session)
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// RevokeOtherOwn signs out every session of the current user except the current one
+func (h *SessionHandler) RevokeOtherOwn(c echo.Context) error {
+	user, ok := getUserFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "authentication required"})
+	}
+	token, _ := getContextString(c, "token")
+
+	_, revoked, err := h.service.RevokeAll(c.Request().Context(), user.ID, token)
+	if err != nil {
+		return sessionError(c, "revoke sessions", err)
+	}
+
+	auditCtx := service.ContextWithAudit(c.Request().Context(), c)
+	h.audit.LogSessionsRevoked(auditCtx, user.ID, user.Username, revoked)
+
+	return c.JSON(http.StatusOK, model.RevokeSessionsResponse{Revoked: revoked})
+}
+
+// === Sessions of any user (admin) ===
+
+// ListUser returns the active sessions of a user
+func (h *SessionHandler) ListUser(c echo.Context) error {
+	token, _ := getContextString(c, "token")
+
+	sessions, err := h.service.List(c.Request().Context(), c.Param("id"), token)
+	if err != nil {
+		return sessionError(c, "list sessions", err)
+	}
+	return c.JSON(http.StatusOK, sessions)
+}
+
+// RevokeUser signs out one session of a user
+func (h *SessionHandler) RevokeUser(c echo.Context) error {
+	user, session, err := h.service.Revoke(c.Request().Context(), c.Param("id"), c.Param("sessionId"))
+	if err != nil {
+		return sessionError(c, "revoke session", err)
+	}
+
+	auditCtx := service.ContextWithAudit(c.Request().Context(), c)
+	h.audit.LogSessionRevoked(auditCtx, user.ID, user.Username, session)
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// RevokeAllUser signs out every session of a user, for example after a
+// compromise, without having to change the password
+func (h *SessionHandler) RevokeAllUser(c echo.Context) error {
+	user, revoked, err := h.service.RevokeAll(c.Request().Context(), c.Param("id"), "")
+	if err != nil {
+		return sessionError(c, "revoke sessions", err)
+	}
+
+	auditCtx := service.ContextWithAudit(c.Request().Context(), c)
+	h.audit.LogSessionsRevoked(auditCtx, user.ID, user.Username, revoked)
+
+	return c.JSON(http.StatusOK, model.RevokeSessionsResponse{Revoked: revoked})
+}
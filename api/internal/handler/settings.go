@@ -47,6 +47,7 @@ type SettingsHandler struct {
 	dockerStats      *service.DockerStatsService
 	proxyHostService *service.ProxyHostService
 	redisCache       *cache.RedisClient
+	approvals        *service.ChangeRequestService
 }
 
 func NewSettingsHandler(
@@ -81,6 +82,26 @@ func NewSettingsHandler(
 	}
 }
 
+// SetApprovals lets backup restores and global settings changes require
+// approval by a second user, and registers how to apply them once approved
+func (h *SettingsHandler) SetApprovals(approvals *service.ChangeRequestService) {
+	h.approvals = approvals
+	approvals.Register(model.ChangeActionBackupRestore, model.PermissionBackupRestore, h.applyBackupRestore)
+	approvals.Register(model.ChangeActionGlobalSettings, model.PermissionSettingsWrite, h.applyGlobalSettingsChange)
+}
+
+// globalSettingsChange is the payload of a global settings change request
+type globalSettingsChange struct {
+	Operation string                             `json:"operation"` // update, reset or preset
+	Preset    string                             `json:"preset,omitempty"`
+	Request   *model.UpdateGlobalSettingsRequest `json:"request,omitempty"`
+}
+
+// backupRestoreChange is the payload of a backup restore change request
+type backupRestoreChange struct {
+	BackupID string `json:"backup_id"`
+}
+
 // Global Settings Handlers
 
 func (h *SettingsHandler) GetGlobalSettings(c echo.Context) error {
@@ -97,64 +118,96 @@ func (h *SettingsHandler) UpdateGlobalSettings(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
 	}
 
-	settings, err := h.settingsRepo.Update(c.Request().Context(), &req)
+	if required, err := approvalRequired(c, h.approvals, model.ChangeActionGlobalSettings); err != nil {
+		return databaseError(c, "check change approval", err)
+	} else if required {
+		return h.submitGlobalSettingsChange(c, globalSettingsChange{Operation: "update", Request: &req})
+	}
+
+	settings, err := h.updateGlobalSettings(c.Request().Context(), &req)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 	}
 
+	// Audit log
+	auditCtx := service.ContextWithAudit(c.Request().Context(), c)
+	h.audit.LogSettingsUpdate(auditCtx, "전역 설정", map[string]interface{}{
+		"action": "update",
+	})
+
+	return c.JSON(http.StatusOK, settings)
+}
+
+// updateGlobalSettings saves the settings, then regenerates the nginx configs and reloads
+func (h *SettingsHandler) updateGlobalSettings(ctx context.Context, req *model.UpdateGlobalSettingsRequest) (*model.GlobalSettings, error) {
+	settings, err := h.settingsRepo.Update(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
 	// Regenerate default server config if direct IP access action changed
 	if req.DirectIPAccessAction != nil {
-		if err := h.nginxManager.GenerateDefaultServerConfig(c.Request().Context(), settings.DirectIPAccessAction); err != nil {
+		if err := h.nginxManager.GenerateDefaultServerConfig(ctx, settings.DirectIPAccessAction); err != nil {
 			log.Printf("[Settings] Warning: failed to generate default server config: %v", err)
 		}
 	}
 
 	// Regenerate all proxy host configs to apply global settings (timeouts, body size, etc.)
 	if h.proxyHostService != nil {
-		if err := h.proxyHostService.SyncAllConfigs(c.Request().Context()); err != nil {
+		if err := h.proxyHostService.SyncAllConfigs(ctx); err != nil {
 			log.Printf("[Settings] Warning: failed to regenerate proxy host configs after global settings change: %v", err)
 		}
 	}
 
 	// Reload nginx to apply all changes
-	if err := h.nginxManager.ReloadNginx(c.Request().Context()); err != nil {
+	if err := h.nginxManager.ReloadNginx(ctx); err != nil {
 		log.Printf("[Settings] Warning: failed to reload nginx after global settings change: %v", err)
 	}
 
+	return settings, nil
+}
+
+func (h *SettingsHandler) ResetGlobalSettings(c echo.Context) error {
+	if required, err := approvalRequired(c, h.approvals, model.ChangeActionGlobalSettings); err != nil {
+		return databaseError(c, "check change approval", err)
+	} else if required {
+		return h.submitGlobalSettingsChange(c, globalSettingsChange{Operation: "reset"})
+	}
+
+	settings, err := h.resetGlobalSettings(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
 	// Audit log
 	auditCtx := service.ContextWithAudit(c.Request().Context(), c)
 	h.audit.LogSettingsUpdate(auditCtx, "전역 설정", map[string]interface{}{
-		"action": "update",
+		"action": "reset",
 	})
 
 	return c.JSON(http.StatusOK, settings)
 }
 
-func (h *SettingsHandler) ResetGlobalSettings(c echo.Context) error {
-	settings, err := h.settingsRepo.Reset(c.Request().Context())
+// resetGlobalSettings restores the default settings, then regenerates the nginx configs and reloads
+func (h *SettingsHandler) resetGlobalSettings(ctx context.Context) (*model.GlobalSettings, error) {
+	settings, err := h.settingsRepo.Reset(ctx)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return nil, err
 	}
 
 	// Regenerate all proxy host configs to apply default global settings
 	if h.proxyHostService != nil {
-		if err := h.proxyHostService.SyncAllConfigs(c.Request().Context()); err != nil {
+		if err := h.proxyHostService.SyncAllConfigs(ctx); err != nil {
 			log.Printf("[Settings] Warning: failed to regenerate proxy host configs after global settings reset: %v", err)
 		}
 	}
 
 	// Reload nginx to apply all changes
-	if err := h.nginxManager.ReloadNginx(c.Request().Context()); err != nil {
+	if err := h.nginxManager.ReloadNginx(ctx); err != nil {
 		log.Printf("[Settings] Warning: failed to reload nginx after global settings reset: %v", err)
 	}
 
-	// Audit log
-	auditCtx := service.ContextWithAudit(c.Request().Context(), c)
-	h.audit.LogSettingsUpdate(auditCtx, "전역 설정", map[string]interface{}{
-		"action": "reset",
-	})
-
-	return c.JSON(http.StatusOK, settings)
+	return settings, nil
 }
 
 func (h *SettingsHandler) GetSettingsPresets(c echo.Context) error {
@@ -187,6 +240,12 @@ func (h *SettingsHandler) ApplySettingsPreset(c echo.Context) error {
 		SSLPreferServerCiphers: &presetConfig.SSLPreferServerCiphers,
 	}
 
+	if required, err := approvalRequired(c, h.approvals, model.ChangeActionGlobalSettings); err != nil {
+		return databaseError(c, "check change approval", err)
+	} else if required {
+		return h.submitGlobalSettingsChange(c, globalSettingsChange{Operation: "preset", Preset: preset, Request: req})
+	}
+
 	settings, err := h.settingsRepo.Update(c.Request().Context(), req)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
@@ -202,6 +261,65 @@ func (h *SettingsHandler) ApplySettingsPreset(c echo.Context) error {
 	return c.JSON(http.StatusOK, settings)
 }
 
+// submitGlobalSettingsChange captures a global settings change for approval,
+// with the settings it changes as the diff
+func (h *SettingsHandler) submitGlobalSettingsChange(c echo.Context, change globalSettingsChange) error {
+	diff := "# All global settings are reset to their defaults\n"
+	if change.Request != nil {
+		current, err := h.settingsRepo.Get(c.Request().Context())
+		if err != nil {
+			return databaseError(c, "get global settings", err)
+		}
+		if diff, err = service.FieldDiff(current, change.Request); err != nil {
+			return internalError(c, "render global settings diff", err)
+		}
+	}
+
+	name := "Global settings"
+	if change.Preset != "" {
+		name += " (preset " + change.Preset + ")"
+	}
+	return submitChange(c, h.approvals, h.audit, &model.ChangeRequest{
+		Action:     model.ChangeActionGlobalSettings,
+		TargetName: name,
+		Diff:       diff,
+	}, change)
+}
+
+// applyGlobalSettingsChange applies an approved global settings change request
+func (h *SettingsHandler) applyGlobalSettingsChange(ctx context.Context, payload json.RawMessage) error {
+	var change globalSettingsChange
+	if err := json.Unmarshal(payload, &change); err != nil {
+		return fmt.Errorf("invalid change request payload: %w", err)
+	}
+
+	if change.Operation != "reset" && change.Request == nil {
+		return fmt.Errorf("change request payload has no settings")
+	}
+
+	var err error
+	switch change.Operation {
+	case "update":
+		_, err = h.updateGlobalSettings(ctx, change.Request)
+	case "preset":
+		_, err = h.settingsRepo.Update(ctx, change.Request)
+	case "reset":
+		_, err = h.resetGlobalSettings(ctx)
+	default:
+		return fmt.Errorf("unknown global settings operation %q", change.Operation)
+	}
+	if err != nil {
+		return err
+	}
+
+	details := map[string]interface{}{"action": change.Operation}
+	if change.Preset != "" {
+		details["preset"] = change.Preset
+	}
+	h.audit.LogSettingsUpdate(ctx, "전역 설정", details)
+	return nil
+}
+
 // Dashboard Handlers
 
 func (h *SettingsHandler) GetDashboard(c echo.Context) error {
@@ -739,6 +857,12 @@ func (h *SettingsHandler) RestoreBackup(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "backup not completed"})
 	}
 
+	if required, err := approvalRequired(c, h.approvals, model.ChangeActionBackupRestore); err != nil {
+		return databaseError(c, "check change approval", err)
+	} else if required {
+		return h.submitBackupRestore(c, backup)
+	}
+
 	// Perform restore and get detailed result
 	result, err := h.performRestore(c.Request().Context(), backup)
 	if err != nil {
@@ -765,6 +889,94 @@ func (h *SettingsHandler) RestoreBackup(c echo.Context) error {
 	return c.JSON(httpStatus, result)
 }
 
+// submitBackupRestore captures a restore for approval. The diff shows the
+// proxy hosts and global settings that the restore would change.
+func (h *SettingsHandler) submitBackupRestore(c echo.Context, backup *model.Backup) error {
+	return submitChange(c, h.approvals, h.audit, &model.ChangeRequest{
+		Action:     model.ChangeActionBackupRestore,
+		TargetID:   backup.ID,
+		TargetName: backup.Filename,
+		Diff:       h.restoreDiff(c.Request().Context(), backup),
+	}, backupRestoreChange{BackupID: backup.ID})
+}
+
+// restoreDiff compares the proxy hosts and global settings in a backup with
+// the current ones. Parts that can't be read are noted in the diff instead.
+func (h *SettingsHandler) restoreDiff(ctx context.Context, backup *model.Backup) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Restore %s (created %s)\n", backup.Filename, backup.CreatedAt.Format(time.RFC3339))
+	if !backup.IncludesDatabase {
+		b.WriteString("# Configuration files and certificates only\n")
+		return b.String()
+	}
+
+	data, err := h.extractExportJSON(backup.FilePath)
+	if err != nil || data == nil {
+		b.WriteString("# The backup contents could not be read\n")
+		return b.String()
+	}
+	var export model.ExportData
+	if err := json.Unmarshal(data, &export); err != nil {
+		b.WriteString("# The backup contents could not be read\n")
+		return b.String()
+	}
+
+	var backupHosts []string
+	for _, ph := range export.ProxyHosts {
+		backupHosts = append(backupHosts, strings.Join(ph.ProxyHost.DomainNames, ", "))
+	}
+	if hosts, _, err := h.proxyHostRepo.List(ctx, 1, config.MaxWAFRulesLimit, "", "", ""); err == nil {
+		var currentHosts []string
+		for _, host := range hosts {
+			currentHosts = append(currentHosts, strings.Join(host.DomainNames, ", "))
+		}
+		if diff := service.ListDiff(currentHosts, backupHosts); diff != "" {
+			b.WriteString("\n# Proxy hosts\n")
+			b.WriteString(diff)
+		}
+	}
+
+	if export.GlobalSettings != nil {
+		if current, err := h.settingsRepo.Get(ctx); err == nil {
+			if diff, err := service.FieldDiff(current, export.GlobalSettings); err == nil && diff != "" {
+				b.WriteString("\n# Global settings\n")
+				b.WriteString(diff)
+			}
+		}
+	}
+	return b.String()
+}
+
+// applyBackupRestore applies an approved backup restore change request
+func (h *SettingsHandler) applyBackupRestore(ctx context.Context, payload json.RawMessage) error {
+	var change backupRestoreChange
+	if err := json.Unmarshal(payload, &change); err != nil {
+		return fmt.Errorf("invalid change request payload: %w", err)
+	}
+
+	backup, err := h.backupRepo.GetByID(ctx, change.BackupID)
+	if err != nil {
+		return err
+	}
+	if backup == nil {
+		return fmt.Errorf("backup no longer exists")
+	}
+	if backup.Status != "completed" {
+		return fmt.Errorf("backup not completed")
+	}
+
+	result, err := h.performRestore(ctx, backup)
+	if err != nil {
+		return err
+	}
+	if result.Status == "partial" {
+		log.Printf("[Settings] Approved restore of %s was partial: %s", backup.Filename, result.Message)
+	}
+
+	h.audit.LogBackupRestore(ctx, backup.Filename)
+	return nil
+}
+
 func (h *SettingsHandler) performRestore(ctx context.Context, backup *model.Backup) (*model.RestoreResult, error) {
 	result := model.NewRestoreResult()
 
@@ -1216,6 +1428,13 @@ func (h *SettingsHandler) UploadAndRestoreBackup(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to create backup record"})
 	}
 
+	// The uploaded backup stays in the backup list until the restore is reviewed
+	if required, err := approvalRequired(c, h.approvals, model.ChangeActionBackupRestore); err != nil {
+		return databaseError(c, "check change approval", err)
+	} else if required {
+		return h.submitBackupRestore(c, backup)
+	}
+
 	// Perform restore and get detailed result
 	result, err := h.performRestore(c.Request().Context(), backup)
 	if err != nil {
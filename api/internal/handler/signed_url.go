@@ -0,0 +1,184 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"nginx-proxy-guard/internal/model"
+	"nginx-proxy-guard/internal/repository"
+	"nginx-proxy-guard/internal/service"
+)
+
+type SignedURLHandler struct {
+	repo             *repository.SignedURLRepository
+	proxyHostRepo    *repository.ProxyHostRepository
+	proxyHostService *service.ProxyHostService
+	audit            *service.AuditService
+}
+
+func NewSignedURLHandler(
+	repo *repository.SignedURLRepository,
+	proxyHostRepo *repository.ProxyHostRepository,
+	proxyHostService *service.ProxyHostService,
+	audit *service.AuditService,
+) *SignedURLHandler {
+	return &SignedURLHandler{
+		repo:             repo,
+		proxyHostRepo:    proxyHostRepo,
+		proxyHostService: proxyHostService,
+		audit:            audit,
+	}
+}
+
+// signedURLValidationError maps service errors to validation responses
+func signedURLValidationError(c echo.Context, err error) error {
+	var serr *service.SignedURLError
+	if errors.As(err, &serr) {
+		return validationError(c, serr.Field, serr.Message)
+	}
+	return badRequestError(c, err.Error())
+}
+
+// ListLocations returns the signed URL locations of a proxy host
+func (h *SignedURLHandler) ListLocations(c echo.Context) error {
+	locations, err := h.repo.ListByProxyHostID(c.Request().Context(), c.Param("proxyHostId"))
+	if err != nil {
+		return databaseError(c, "list signed URL locations", err)
+	}
+	return c.JSON(http.StatusOK, locations)
+}
+
+// UpsertLocation creates or replaces the signed URL protection of a proxy host path prefix
+func (h *SignedURLHandler) UpsertLocation(c echo.Context) error {
+	proxyHostID := c.Param("proxyHostId")
+	ctx := c.Request().Context()
+
+	var req model.UpsertSignedURLLocationRequest
+	if err := c.Bind(&req); err != nil {
+		return badRequestError(c, "Invalid request body")
+	}
+
+	host, err := h.proxyHostRepo.GetByID(ctx, proxyHostID)
+	if err != nil {
+		return databaseError(c, "get proxy host", err)
+	}
+	if host == nil {
+		return notFoundError(c, "Proxy host")
+	}
+
+	existing, err := h.repo.GetByPath(ctx, proxyHostID, req.PathPrefix)
+	if err != nil {
+		return databaseError(c, "get signed URL location", err)
+	}
+	resolved, err := service.ResolveSignedURLLocation(existing, &req)
+	if err != nil {
+		return signedURLValidationError(c, err)
+	}
+
+	location, err := h.repo.Upsert(ctx, proxyHostID, resolved)
+	if err != nil {
+		return databaseError(c, "upsert signed URL location", err)
+	}
+
+	if host.Enabled {
+		if err := h.proxyHostService.RegenerateConfigForHost(ctx, proxyHostID); err != nil {
+			return internalError(c, "regenerate nginx config for signed URL location", err)
+		}
+	}
+
+	// Audit log
+	auditCtx := service.ContextWithAudit(ctx, c)
+	h.audit.LogSecurityFeatureUpdate(auditCtx, "signed_url", hostDisplayName(host), location.Enabled, map[string]interface{}{
+		"path_prefix":     location.PathPrefix,
+		"hash_expression": location.HashExpression,
+		"max_ttl":         location.MaxTTL,
+		"secret_rotated":  req.Secret != "" || req.RotateSecret,
+	})
+
+	return c.JSON(http.StatusOK, location)
+}
+
+// DeleteLocation removes the signed URL protection of a path prefix
+func (h *SignedURLHandler) DeleteLocation(c echo.Context) error {
+	proxyHostID := c.Param("proxyHostId")
+	ctx := c.Request().Context()
+
+	host, err := h.proxyHostRepo.GetByID(ctx, proxyHostID)
+	if err != nil {
+		return databaseError(c, "get proxy host", err)
+	}
+	if host == nil {
+		return notFoundError(c, "Proxy host")
+	}
+
+	location, err := h.repo.GetByID(ctx, proxyHostID, c.Param("locationId"))
+	if err != nil {
+		return databaseError(c, "get signed URL location", err)
+	}
+	if location == nil {
+		return notFoundError(c, "Signed URL location")
+	}
+
+	if err := h.repo.Delete(ctx, proxyHostID, location.ID); err != nil {
+		return databaseError(c, "delete signed URL location", err)
+	}
+
+	if host.Enabled {
+		if err := h.proxyHostService.RegenerateConfigForHost(ctx, proxyHostID); err != nil {
+			return internalError(c, "regenerate nginx config for signed URL location removal", err)
+		}
+	}
+
+	// Audit log
+	auditCtx := service.ContextWithAudit(ctx, c)
+	h.audit.LogSecurityFeatureUpdate(auditCtx, "signed_url", hostDisplayName(host), false, map[string]interface{}{
+		"path_prefix": location.PathPrefix,
+	})
+
+	return noContentResponse(c)
+}
+
+// Mint signs a link to a path of a proxy host
+func (h *SignedURLHandler) Mint(c echo.Context) error {
+	proxyHostID := c.Param("proxyHostId")
+	ctx := c.Request().Context()
+
+	var req model.MintSignedURLRequest
+	if err := c.Bind(&req); err != nil {
+		return badRequestError(c, "Invalid request body")
+	}
+
+	host, err := h.proxyHostRepo.GetByID(ctx, proxyHostID)
+	if err != nil {
+		return databaseError(c, "get proxy host", err)
+	}
+	if host == nil {
+		return notFoundError(c, "Proxy host")
+	}
+
+	locations, err := h.repo.ListByProxyHostID(ctx, proxyHostID)
+	if err != nil {
+		return databaseError(c, "list signed URL locations", err)
+	}
+	location := service.MatchSignedURLLocation(locations, req.Path)
+	if location == nil {
+		return validationError(c, "path", "is not below an enabled signed URL location")
+	}
+
+	signed, err := service.MintSignedURL(location, host, &req, time.Now())
+	if err != nil {
+		return signedURLValidationError(c, err)
+	}
+
+	// Audit log
+	auditCtx := service.ContextWithAudit(ctx, c)
+	h.audit.LogSecurityFeatureUpdate(auditCtx, "signed_url_minted", hostDisplayName(host), true, map[string]interface{}{
+		"path":       signed.Path,
+		"expires_at": signed.ExpiresAt,
+	})
+
+	return c.JSON(http.StatusCreated, signed)
+}
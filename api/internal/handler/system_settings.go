@@ -118,6 +118,36 @@ func (h *SystemSettingsHandler) UpdateSystemSettings(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
 	}
 
+	// Validate ban escalation policy
+	if req.BanEscalationSteps != nil {
+		steps := *req.BanEscalationSteps
+		if len(steps) == 0 || len(steps) > 10 {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Ban escalation must have between 1 and 10 steps"})
+		}
+		for i, step := range steps {
+			if step < 0 || (step == 0 && i != len(steps)-1) {
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": "Ban escalation steps must be positive durations in seconds; only the last step may be 0 (permanent)"})
+			}
+		}
+	}
+	if req.BanEscalationWindow != nil && *req.BanEscalationWindow < 60 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Ban escalation window must be at least 60 seconds"})
+	}
+
+	// Validate subnet aggregation
+	if req.BanSubnetThreshold != nil && *req.BanSubnetThreshold < 2 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Subnet aggregation threshold must be at least 2"})
+	}
+	if req.BanSubnetIPv4Prefix != nil && (*req.BanSubnetIPv4Prefix < 8 || *req.BanSubnetIPv4Prefix > 31) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "IPv4 aggregation prefix must be between /8 and /31"})
+	}
+	if req.BanSubnetIPv6Prefix != nil && (*req.BanSubnetIPv6Prefix < 16 || *req.BanSubnetIPv6Prefix > 127) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "IPv6 aggregation prefix must be between /16 and /127"})
+	}
+	if req.BanSubnetWindow != nil && *req.BanSubnetWindow < 60 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Subnet aggregation window must be at least 60 seconds"})
+	}
+
 	settings, err := h.repo.Update(c.Request().Context(), &req)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
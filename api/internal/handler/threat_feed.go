@@ -0,0 +1,342 @@
+package handler
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"nginx-proxy-guard/internal/model"
+	"nginx-proxy-guard/internal/repository"
+	"nginx-proxy-guard/internal/service"
+)
+
+// Minimum refresh interval to avoid hammering feed providers (seconds)
+const minThreatFeedInterval = 300
+
+var threatFeedSlugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,49}$`)
+
+type ThreatFeedHandler struct {
+	repo             *repository.ThreatFeedRepository
+	service          *service.ThreatFeedService
+	proxyHostService *service.ProxyHostService
+	audit            *service.AuditService
+}
+
+func NewThreatFeedHandler(
+	repo *repository.ThreatFeedRepository,
+	threatFeedService *service.ThreatFeedService,
+	proxyHostService *service.ProxyHostService,
+	audit *service.AuditService,
+) *ThreatFeedHandler {
+	return &ThreatFeedHandler{
+		repo:             repo,
+		service:          threatFeedService,
+		proxyHostService: proxyHostService,
+		audit:            audit,
+	}
+}
+
+func validThreatFeedFormat(format string) bool {
+	return format == model.ThreatFeedFormatPlain || format == model.ThreatFeedFormatCSV || format == model.ThreatFeedFormatJSON
+}
+
+func validThreatFeedURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// ListFeeds returns all threat feeds
+func (h *ThreatFeedHandler) ListFeeds(c echo.Context) error {
+	feeds, err := h.repo.List(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if feeds == nil {
+		feeds = []model.ThreatFeed{}
+	}
+	return c.JSON(http.StatusOK, feeds)
+}
+
+// GetFeed returns a single threat feed with its IP ranges
+func (h *ThreatFeedHandler) GetFeed(c echo.Context) error {
+	feed, err := h.repo.GetBySlug(c.Request().Context(), c.Param("slug"))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if feed == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Threat feed not found"})
+	}
+	return c.JSON(http.StatusOK, feed)
+}
+
+// CreateFeed creates a custom threat feed
+func (h *ThreatFeedHandler) CreateFeed(c echo.Context) error {
+	var req model.CreateThreatFeedRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	}
+
+	if req.Name == "" || !threatFeedSlugPattern.MatchString(req.Slug) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Name and a lowercase slug (a-z, 0-9, -) are required"})
+	}
+	if !validThreatFeedURL(req.URL) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "URL must be an http or https URL"})
+	}
+	if req.Format == "" {
+		req.Format = model.ThreatFeedFormatPlain
+	}
+	if !validThreatFeedFormat(req.Format) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Format must be plain, csv or json"})
+	}
+	if req.UpdateInterval == 0 {
+		req.UpdateInterval = 24 * 60 * 60
+	}
+	if req.UpdateInterval < minThreatFeedInterval {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Update interval must be at least 300 seconds"})
+	}
+
+	ctx := c.Request().Context()
+	exists, err := h.repo.ExistsBySlug(ctx, req.Slug)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if exists {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "A threat feed with this slug already exists"})
+	}
+
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+
+	feed, err := h.repo.Create(ctx, &model.ThreatFeed{
+		Name:           req.Name,
+		Slug:           req.Slug,
+		Description:    req.Description,
+		URL:            req.URL,
+		Format:         req.Format,
+		UpdateInterval: req.UpdateInterval,
+		Enabled:        enabled,
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	// Audit log
+	auditCtx := service.ContextWithAudit(ctx, c)
+	h.audit.LogSettingsUpdate(auditCtx, "Threat Feed", map[string]interface{}{
+		"action": "create",
+		"slug":   req.Slug,
+		"url":    req.URL,
+	})
+
+	return c.JSON(http.StatusCreated, feed)
+}
+
+// UpdateFeed updates a threat feed
+// Built-in feeds can only be enabled/disabled or have their interval changed
+func (h *ThreatFeedHandler) UpdateFeed(c echo.Context) error {
+	slug := c.Param("slug")
+	ctx := c.Request().Context()
+
+	var req model.UpdateThreatFeedRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	}
+
+	existing, err := h.repo.GetBySlug(ctx, slug)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if existing == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Threat feed not found"})
+	}
+
+	if existing.IsBuiltin && (req.Name != nil || req.URL != nil || req.Format != nil) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Name, URL and format of built-in feeds cannot be changed"})
+	}
+	if req.URL != nil && !validThreatFeedURL(*req.URL) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "URL must be an http or https URL"})
+	}
+	if req.Format != nil && !validThreatFeedFormat(*req.Format) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Format must be plain, csv or json"})
+	}
+	if req.UpdateInterval != nil && *req.UpdateInterval < minThreatFeedInterval {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Update interval must be at least 300 seconds"})
+	}
+
+	feed, err := h.repo.Update(ctx, slug, &req)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	// Enabling/disabling a feed changes what subscribed hosts enforce
+	if req.Enabled != nil && *req.Enabled != existing.Enabled && h.proxyHostService != nil {
+		if err := h.proxyHostService.RegenerateConfigsForThreatFeeds(ctx, []string{slug}); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Feed saved but failed to regenerate nginx config: " + err.Error(),
+			})
+		}
+	}
+
+	// Audit log
+	auditCtx := service.ContextWithAudit(ctx, c)
+	h.audit.LogSettingsUpdate(auditCtx, "Threat Feed", map[string]interface{}{
+		"action": "update",
+		"slug":   slug,
+	})
+
+	return c.JSON(http.StatusOK, feed)
+}
+
+// DeleteFeed deletes a custom threat feed
+func (h *ThreatFeedHandler) DeleteFeed(c echo.Context) error {
+	slug := c.Param("slug")
+	ctx := c.Request().Context()
+
+	existing, err := h.repo.GetBySlug(ctx, slug)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if existing == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Threat feed not found"})
+	}
+	if existing.IsBuiltin {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Built-in feeds cannot be deleted, disable them instead"})
+	}
+
+	// Collect subscribed hosts before the cascade removes the subscriptions
+	hostIDs, err := h.repo.GetProxyHostIDsForFeeds(ctx, []string{slug})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	if err := h.repo.Delete(ctx, slug); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	if h.proxyHostService != nil {
+		for _, hostID := range hostIDs {
+			if _, err := h.proxyHostService.Update(ctx, hostID, nil); err != nil {
+				return c.JSON(http.StatusInternalServerError, map[string]string{
+					"error": "Feed deleted but failed to regenerate nginx config: " + err.Error(),
+				})
+			}
+		}
+	}
+
+	// Audit log
+	auditCtx := service.ContextWithAudit(ctx, c)
+	h.audit.LogSettingsUpdate(auditCtx, "Threat Feed", map[string]interface{}{
+		"action": "delete",
+		"slug":   slug,
+	})
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// RefreshFeed downloads a threat feed immediately
+func (h *ThreatFeedHandler) RefreshFeed(c echo.Context) error {
+	slug := c.Param("slug")
+	ctx := c.Request().Context()
+
+	if err := h.service.RefreshFeed(ctx, slug); err != nil {
+		return c.JSON(http.StatusBadGateway, map[string]string{"error": err.Error()})
+	}
+
+	if h.proxyHostService != nil {
+		if err := h.proxyHostService.RegenerateConfigsForThreatFeeds(ctx, []string{slug}); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Feed refreshed but failed to regenerate nginx config: " + err.Error(),
+			})
+		}
+	}
+
+	feed, err := h.repo.GetBySlug(ctx, slug)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, feed)
+}
+
+// GetMatchStats returns per-host counts of requests matched by threat feeds
+// Query param hours (default 24, max 720)
+func (h *ThreatFeedHandler) GetMatchStats(c echo.Context) error {
+	hours := 24
+	if v, err := strconv.Atoi(c.QueryParam("hours")); err == nil && v > 0 {
+		hours = v
+	}
+	if hours > 720 {
+		hours = 720
+	}
+
+	stats, err := h.repo.GetMatchStats(c.Request().Context(), time.Now().Add(-time.Duration(hours)*time.Hour))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, stats)
+}
+
+// GetHostFeeds returns threat feed subscriptions for a proxy host
+func (h *ThreatFeedHandler) GetHostFeeds(c echo.Context) error {
+	feeds, err := h.repo.GetHostFeeds(c.Request().Context(), c.Param("proxyHostId"))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"feeds": feeds})
+}
+
+// SetHostFeeds replaces threat feed subscriptions for a proxy host
+func (h *ThreatFeedHandler) SetHostFeeds(c echo.Context) error {
+	proxyHostID := c.Param("proxyHostId")
+	skipReload := c.QueryParam("skip_reload") == "true"
+
+	var req model.ProxyHostThreatFeedsRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	}
+
+	for i := range req.Feeds {
+		if req.Feeds[i].Action == "" {
+			req.Feeds[i].Action = model.ThreatFeedActionBlock
+		}
+		if req.Feeds[i].Action != model.ThreatFeedActionBlock && req.Feeds[i].Action != model.ThreatFeedActionChallenge {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Action must be block or challenge"})
+		}
+	}
+
+	ctx := c.Request().Context()
+
+	if err := h.repo.SetHostFeeds(ctx, proxyHostID, req.Feeds); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	// Regenerate nginx config (skip if requested)
+	if !skipReload && h.proxyHostService != nil {
+		if _, err := h.proxyHostService.Update(ctx, proxyHostID, nil); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Settings saved but failed to regenerate nginx config: " + err.Error(),
+			})
+		}
+	}
+
+	// Audit log
+	auditCtx := service.ContextWithAudit(ctx, c)
+	h.audit.LogSettingsUpdate(auditCtx, "Proxy Host Threat Feeds", map[string]interface{}{
+		"proxy_host_id": proxyHostID,
+		"feeds":         req.Feeds,
+	})
+
+	feeds, err := h.repo.GetHostFeeds(ctx, proxyHostID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"feeds":   feeds,
+		"message": "Threat feed subscriptions updated successfully",
+	})
+}
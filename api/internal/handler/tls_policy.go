@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"nginx-proxy-guard/internal/model"
+	"nginx-proxy-guard/internal/repository"
+	"nginx-proxy-guard/internal/service"
+)
+
+type TLSPolicyHandler struct {
+	repo             *repository.TLSPolicyRepository
+	proxyHostRepo    *repository.ProxyHostRepository
+	proxyHostService *service.ProxyHostService
+	audit            *service.AuditService
+}
+
+func NewTLSPolicyHandler(
+	repo *repository.TLSPolicyRepository,
+	proxyHostRepo *repository.ProxyHostRepository,
+	proxyHostService *service.ProxyHostService,
+	audit *service.AuditService,
+) *TLSPolicyHandler {
+	return &TLSPolicyHandler{
+		repo:             repo,
+		proxyHostRepo:    proxyHostRepo,
+		proxyHostService: proxyHostService,
+		audit:            audit,
+	}
+}
+
+// ListProfiles returns the preset TLS profiles with their grades
+func (h *TLSPolicyHandler) ListProfiles(c echo.Context) error {
+	return c.JSON(http.StatusOK, service.TLSProfilePresets())
+}
+
+// Get returns the TLS policy of a proxy host, or the defaults it is served with
+func (h *TLSPolicyHandler) Get(c echo.Context) error {
+	proxyHostID := c.Param("proxyHostId")
+
+	policy, err := h.repo.GetByProxyHostID(c.Request().Context(), proxyHostID)
+	if err != nil {
+		return databaseError(c, "get TLS policy", err)
+	}
+	if policy == nil {
+		return c.JSON(http.StatusOK, service.DefaultTLSPolicy(proxyHostID))
+	}
+	service.GradeTLSPolicy(policy)
+	return c.JSON(http.StatusOK, policy)
+}
+
+// Upsert selects the TLS policy of a proxy host
+func (h *TLSPolicyHandler) Upsert(c echo.Context) error {
+	proxyHostID := c.Param("proxyHostId")
+	ctx := c.Request().Context()
+
+	var req model.UpsertTLSPolicyRequest
+	if err := c.Bind(&req); err != nil {
+		return badRequestError(c, "Invalid request body")
+	}
+
+	resolved, err := service.ResolveTLSPolicy(&req)
+	if err != nil {
+		var perr *service.TLSPolicyError
+		if errors.As(err, &perr) {
+			return validationError(c, perr.Field, perr.Message)
+		}
+		return badRequestError(c, err.Error())
+	}
+
+	host, err := h.proxyHostRepo.GetByID(ctx, proxyHostID)
+	if err != nil {
+		return databaseError(c, "get proxy host", err)
+	}
+	if host == nil {
+		return notFoundError(c, "Proxy host")
+	}
+
+	if host.SSLHTTP3 {
+		hasTLS13 := false
+		for _, protocol := range resolved.Protocols {
+			if protocol == "TLSv1.3" {
+				hasTLS13 = true
+			}
+		}
+		if !hasTLS13 {
+			return validationError(c, "protocols", "must include TLSv1.3 while HTTP/3 is enabled")
+		}
+	}
+	if resolved.HSTSEnabled && !host.SSLForceHTTPS {
+		return validationError(c, "hsts_enabled", "requires Force HTTPS, browsers ignore HSTS until the site redirects to HTTPS")
+	}
+
+	policy, err := h.repo.Upsert(ctx, proxyHostID, resolved)
+	if err != nil {
+		return databaseError(c, "upsert TLS policy", err)
+	}
+
+	if host.Enabled && host.SSLEnabled {
+		if err := h.proxyHostService.RegenerateConfigForHost(ctx, proxyHostID); err != nil {
+			return internalError(c, "regenerate nginx config for TLS policy", err)
+		}
+	}
+	service.GradeTLSPolicy(policy)
+
+	// Audit log
+	auditCtx := service.ContextWithAudit(ctx, c)
+	h.audit.LogSecurityFeatureUpdate(auditCtx, "tls_policy", hostDisplayName(host), true, map[string]interface{}{
+		"profile":   policy.Profile,
+		"protocols": policy.Protocols,
+		"hsts":      policy.HSTSEnabled,
+		"grade":     policy.Grade,
+	})
+
+	return c.JSON(http.StatusOK, policy)
+}
+
+// Delete reverts a proxy host to the default TLS settings
+func (h *TLSPolicyHandler) Delete(c echo.Context) error {
+	proxyHostID := c.Param("proxyHostId")
+	ctx := c.Request().Context()
+
+	host, err := h.proxyHostRepo.GetByID(ctx, proxyHostID)
+	if err != nil {
+		return databaseError(c, "get proxy host", err)
+	}
+	if host == nil {
+		return notFoundError(c, "Proxy host")
+	}
+
+	if err := h.repo.Delete(ctx, proxyHostID); err != nil {
+		return databaseError(c, "delete TLS policy", err)
+	}
+
+	if host.Enabled && host.SSLEnabled {
+		if err := h.proxyHostService.RegenerateConfigForHost(ctx, proxyHostID); err != nil {
+			return internalError(c, "regenerate nginx config for TLS policy removal", err)
+		}
+	}
+
+	// Audit log
+	auditCtx := service.ContextWithAudit(ctx, c)
+	h.audit.LogSecurityFeatureUpdate(auditCtx, "tls_policy", hostDisplayName(host), false, nil)
+
+	return noContentResponse(c)
+}
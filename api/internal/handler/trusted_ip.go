@@ -0,0 +1,186 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"nginx-proxy-guard/internal/model"
+	"nginx-proxy-guard/internal/repository"
+	"nginx-proxy-guard/internal/service"
+)
+
+// Maximum number of recent prevented blocks listed per trusted network in the report
+const trustedIPReportRecentHits = 20
+
+type TrustedIPHandler struct {
+	repo    *repository.TrustedIPRepository
+	service *service.TrustedIPService
+	audit   *service.AuditService
+}
+
+func NewTrustedIPHandler(
+	repo *repository.TrustedIPRepository,
+	trustedIPService *service.TrustedIPService,
+	audit *service.AuditService,
+) *TrustedIPHandler {
+	return &TrustedIPHandler{
+		repo:    repo,
+		service: trustedIPService,
+		audit:   audit,
+	}
+}
+
+// List returns all trusted networks, including expired ones
+func (h *TrustedIPHandler) List(c echo.Context) error {
+	entries, err := h.repo.List(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if entries == nil {
+		entries = []model.TrustedIP{}
+	}
+	return c.JSON(http.StatusOK, entries)
+}
+
+// Create adds a trusted network
+func (h *TrustedIPHandler) Create(c echo.Context) error {
+	var req model.CreateTrustedIPRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	}
+
+	ipNet, err := service.ParseTrustedCIDR(req.CIDR)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "cidr must be a valid IP address or CIDR"})
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "expires_at must be in the future"})
+	}
+
+	ctx := c.Request().Context()
+	cidr := ipNet.String()
+	exists, err := h.repo.ExistsByCIDR(ctx, cidr)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if exists {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "This network is already trusted"})
+	}
+
+	entry, err := h.repo.Create(ctx, cidr, strings.TrimSpace(req.Label), req.ExpiresAt)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	if err := h.service.Apply(ctx); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Trusted IP saved but failed to update nginx config: " + err.Error(),
+		})
+	}
+
+	// Audit log
+	auditCtx := service.ContextWithAudit(ctx, c)
+	h.audit.LogSettingsUpdate(auditCtx, "Trusted IP", map[string]interface{}{
+		"action": "create",
+		"cidr":   cidr,
+		"label":  entry.Label,
+	})
+
+	return c.JSON(http.StatusCreated, entry)
+}
+
+// Update changes the label or expiry of a trusted network
+func (h *TrustedIPHandler) Update(c echo.Context) error {
+	id := c.Param("id")
+	ctx := c.Request().Context()
+
+	var req model.UpdateTrustedIPRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+	}
+	if !req.ClearExpires && req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "expires_at must be in the future"})
+	}
+	if req.Label != nil {
+		label := strings.TrimSpace(*req.Label)
+		req.Label = &label
+	}
+
+	entry, err := h.repo.Update(ctx, id, &req)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if entry == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Trusted IP not found"})
+	}
+
+	if err := h.service.Apply(ctx); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Trusted IP saved but failed to update nginx config: " + err.Error(),
+		})
+	}
+
+	// Audit log
+	auditCtx := service.ContextWithAudit(ctx, c)
+	h.audit.LogSettingsUpdate(auditCtx, "Trusted IP", map[string]interface{}{
+		"action": "update",
+		"cidr":   entry.CIDR,
+	})
+
+	return c.JSON(http.StatusOK, entry)
+}
+
+// Delete removes a trusted network
+func (h *TrustedIPHandler) Delete(c echo.Context) error {
+	id := c.Param("id")
+	ctx := c.Request().Context()
+
+	existing, err := h.repo.GetByID(ctx, id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	if existing == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Trusted IP not found"})
+	}
+
+	if err := h.repo.Delete(ctx, id); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	if err := h.service.Apply(ctx); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Trusted IP deleted but failed to update nginx config: " + err.Error(),
+		})
+	}
+
+	// Audit log
+	auditCtx := service.ContextWithAudit(ctx, c)
+	h.audit.LogSettingsUpdate(auditCtx, "Trusted IP", map[string]interface{}{
+		"action": "delete",
+		"cidr":   existing.CIDR,
+	})
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// GetReport returns which trusted networks prevented which bans
+// Query param hours (default 168, max 720)
+func (h *TrustedIPHandler) GetReport(c echo.Context) error {
+	hours := 168
+	if v, err := strconv.Atoi(c.QueryParam("hours")); err == nil && v > 0 {
+		hours = v
+	}
+	if hours > 720 {
+		hours = 720
+	}
+
+	report, err := h.repo.GetReport(c.Request().Context(), time.Now().Add(-time.Duration(hours)*time.Hour), trustedIPReportRecentHits)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, report)
+}
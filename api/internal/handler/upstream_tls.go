@@ -0,0 +1,282 @@
+package handler
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"nginx-proxy-guard/internal/model"
+	"nginx-proxy-guard/internal/nginx"
+	"nginx-proxy-guard/internal/repository"
+	"nginx-proxy-guard/internal/service"
+)
+
+const (
+	defaultUpstreamTLSVerifyDepth = 2
+	maxUpstreamTLSVerifyDepth     = 10
+)
+
+// upstreamServerNamePattern allows host names and IP addresses rendered into proxy_ssl_name
+var upstreamServerNamePattern = regexp.MustCompile(`^[A-Za-z0-9*]([A-Za-z0-9.:\-]*[A-Za-z0-9])?$`)
+
+type UpstreamTLSHandler struct {
+	repo             *repository.UpstreamTLSRepository
+	proxyHostRepo    *repository.ProxyHostRepository
+	upstreamRepo     *repository.UpstreamRepository
+	certRepo         *repository.CertificateRepository
+	proxyHostService *service.ProxyHostService
+	audit            *service.AuditService
+}
+
+func NewUpstreamTLSHandler(
+	repo *repository.UpstreamTLSRepository,
+	proxyHostRepo *repository.ProxyHostRepository,
+	upstreamRepo *repository.UpstreamRepository,
+	certRepo *repository.CertificateRepository,
+	proxyHostService *service.ProxyHostService,
+	audit *service.AuditService,
+) *UpstreamTLSHandler {
+	return &UpstreamTLSHandler{
+		repo:             repo,
+		proxyHostRepo:    proxyHostRepo,
+		upstreamRepo:     upstreamRepo,
+		certRepo:         certRepo,
+		proxyHostService: proxyHostService,
+		audit:            audit,
+	}
+}
+
+// Get returns the backend TLS settings of a proxy host
+func (h *UpstreamTLSHandler) Get(c echo.Context) error {
+	proxyHostID := c.Param("proxyHostId")
+
+	cfg, err := h.repo.GetByProxyHostID(c.Request().Context(), proxyHostID)
+	if err != nil {
+		return databaseError(c, "get upstream TLS", err)
+	}
+	if cfg == nil {
+		cfg = &model.UpstreamTLSConfig{
+			ProxyHostID: proxyHostID,
+			Verify:      true,
+			VerifyDepth: defaultUpstreamTLSVerifyDepth,
+			SNI:         true,
+		}
+	}
+	if cfg.Enabled {
+		cfg.IncludePath = nginx.UpstreamTLSIncludePath(proxyHostID)
+	}
+	return c.JSON(http.StatusOK, cfg)
+}
+
+// Upsert configures backend certificate verification, SNI and the client certificate of a proxy host
+func (h *UpstreamTLSHandler) Upsert(c echo.Context) error {
+	proxyHostID := c.Param("proxyHostId")
+	ctx := c.Request().Context()
+
+	var req model.UpsertUpstreamTLSRequest
+	if err := c.Bind(&req); err != nil {
+		return badRequestError(c, "Invalid request body")
+	}
+	if verr := normalizeUpstreamTLSRequest(&req); verr != nil {
+		return validationError(c, verr.Field, verr.Message)
+	}
+
+	host, err := h.proxyHostRepo.GetByID(ctx, proxyHostID)
+	if err != nil {
+		return databaseError(c, "get proxy host", err)
+	}
+	if host == nil {
+		return notFoundError(c, "Proxy host")
+	}
+
+	upstream, err := h.upstreamRepo.GetByProxyHostID(ctx, proxyHostID)
+	if err != nil {
+		return databaseError(c, "get upstream", err)
+	}
+	if req.Enabled == nil || *req.Enabled {
+		if msg := checkUpstreamTLSTarget(host, upstream); msg != "" {
+			return validationError(c, "enabled", msg)
+		}
+	}
+
+	clientCertName := ""
+	if req.ClientCertificateID != nil {
+		cert, err := h.certRepo.GetByID(ctx, *req.ClientCertificateID)
+		if err != nil {
+			return databaseError(c, "get certificate", err)
+		}
+		if cert == nil {
+			return validationError(c, "client_certificate_id", "does not reference an existing certificate")
+		}
+		if msg := checkUpstreamClientCertificate(cert); msg != "" {
+			return validationError(c, "client_certificate_id", msg)
+		}
+		if len(cert.DomainNames) > 0 {
+			clientCertName = cert.DomainNames[0]
+		}
+	}
+
+	previous, err := h.repo.GetByProxyHostID(ctx, proxyHostID)
+	if err != nil {
+		return databaseError(c, "get upstream TLS", err)
+	}
+
+	cfg, err := h.repo.Upsert(ctx, proxyHostID, &req)
+	if err != nil {
+		return databaseError(c, "upsert upstream TLS", err)
+	}
+
+	if host.Enabled {
+		if err := h.proxyHostService.RegenerateConfigForHost(ctx, proxyHostID); err != nil {
+			// The previous config is still live, so restore the settings that produced it
+			if restoreErr := h.restore(ctx, proxyHostID, previous); restoreErr != nil {
+				log.Printf("[ERROR] Rollback failed: could not restore upstream TLS of host %s: %v", proxyHostID, restoreErr)
+			}
+			return internalError(c, "regenerate nginx config for upstream TLS", err)
+		}
+	}
+	if cfg.Enabled {
+		cfg.IncludePath = nginx.UpstreamTLSIncludePath(proxyHostID)
+	}
+
+	// Audit log
+	auditCtx := service.ContextWithAudit(ctx, c)
+	h.audit.LogSecurityFeatureUpdate(auditCtx, "upstream_tls", hostDisplayName(host), cfg.Enabled, map[string]interface{}{
+		"verify":             cfg.Verify,
+		"custom_ca":          cfg.TrustedCAPEM != "",
+		"server_name":        cfg.ServerName,
+		"client_certificate": clientCertName,
+	})
+
+	return c.JSON(http.StatusOK, cfg)
+}
+
+// Delete removes the backend TLS settings of a proxy host
+func (h *UpstreamTLSHandler) Delete(c echo.Context) error {
+	proxyHostID := c.Param("proxyHostId")
+	ctx := c.Request().Context()
+
+	host, err := h.proxyHostRepo.GetByID(ctx, proxyHostID)
+	if err != nil {
+		return databaseError(c, "get proxy host", err)
+	}
+	if host == nil {
+		return notFoundError(c, "Proxy host")
+	}
+
+	if err := h.repo.Delete(ctx, proxyHostID); err != nil {
+		return databaseError(c, "delete upstream TLS", err)
+	}
+
+	if host.Enabled {
+		if err := h.proxyHostService.RegenerateConfigForHost(ctx, proxyHostID); err != nil {
+			return internalError(c, "regenerate nginx config for upstream TLS removal", err)
+		}
+	}
+
+	// Audit log
+	auditCtx := service.ContextWithAudit(ctx, c)
+	h.audit.LogSecurityFeatureUpdate(auditCtx, "upstream_tls", hostDisplayName(host), false, nil)
+
+	return noContentResponse(c)
+}
+
+// restore puts back the backend TLS settings of a proxy host as they were before an upsert
+func (h *UpstreamTLSHandler) restore(ctx context.Context, proxyHostID string, previous *model.UpstreamTLSConfig) error {
+	if previous == nil {
+		return h.repo.Delete(ctx, proxyHostID)
+	}
+	_, err := h.repo.Upsert(ctx, proxyHostID, upstreamTLSRequestFromConfig(previous))
+	return err
+}
+
+// upstreamTLSRequestFromConfig returns the request recreating cfg
+func upstreamTLSRequestFromConfig(cfg *model.UpstreamTLSConfig) *model.UpsertUpstreamTLSRequest {
+	enabled, verify, sni := cfg.Enabled, cfg.Verify, cfg.SNI
+	return &model.UpsertUpstreamTLSRequest{
+		Enabled:             &enabled,
+		Verify:              &verify,
+		TrustedCAPEM:        cfg.TrustedCAPEM,
+		VerifyDepth:         cfg.VerifyDepth,
+		ServerName:          cfg.ServerName,
+		SNI:                 &sni,
+		ClientCertificateID: cfg.ClientCertificateID,
+	}
+}
+
+// checkUpstreamTLSTarget returns why backend TLS can't apply to a proxy host
+// Without an upstream group nginx connects to the forward target with the host's own scheme
+func checkUpstreamTLSTarget(host *model.ProxyHost, upstream *model.Upstream) string {
+	if upstream != nil && len(upstream.Servers) > 0 {
+		return ""
+	}
+	if host.ForwardScheme != "https" {
+		return "requires the https forward scheme or an upstream group"
+	}
+	return ""
+}
+
+// normalizeUpstreamTLSRequest applies defaults and validates the values rendered into the nginx config
+func normalizeUpstreamTLSRequest(req *model.UpsertUpstreamTLSRequest) *ValidationError {
+	req.TrustedCAPEM = strings.TrimSpace(req.TrustedCAPEM)
+	if req.TrustedCAPEM != "" {
+		if _, err := nginx.ParseClientCABundle(req.TrustedCAPEM); err != nil {
+			return &ValidationError{Field: "trusted_ca_pem", Message: err.Error()}
+		}
+		req.TrustedCAPEM += "\n"
+	}
+
+	if req.VerifyDepth == 0 {
+		req.VerifyDepth = defaultUpstreamTLSVerifyDepth
+	}
+	if req.VerifyDepth < 1 || req.VerifyDepth > maxUpstreamTLSVerifyDepth {
+		return &ValidationError{Field: "verify_depth", Message: fmt.Sprintf("must be between 1 and %d", maxUpstreamTLSVerifyDepth)}
+	}
+
+	req.ServerName = strings.TrimSpace(req.ServerName)
+	if req.ServerName != "" && (len(req.ServerName) > 253 || !upstreamServerNamePattern.MatchString(req.ServerName)) {
+		return &ValidationError{Field: "server_name", Message: "must be a host name or IP address"}
+	}
+
+	if req.ClientCertificateID != nil {
+		id := strings.TrimSpace(*req.ClientCertificateID)
+		if id == "" {
+			req.ClientCertificateID = nil
+		} else {
+			req.ClientCertificateID = &id
+		}
+	}
+	return nil
+}
+
+// checkUpstreamClientCertificate returns why a stored certificate can't be presented to a backend
+func checkUpstreamClientCertificate(cert *model.Certificate) string {
+	if cert.Status != model.CertStatusIssued || cert.CertificatePEM == "" {
+		return "references a certificate that has not been issued"
+	}
+	block, _ := pem.Decode([]byte(cert.CertificatePEM))
+	if block == nil {
+		return "references a certificate that can't be parsed"
+	}
+	parsed, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return "references a certificate that can't be parsed"
+	}
+	// No extended key usage means any usage is allowed
+	if len(parsed.ExtKeyUsage) == 0 {
+		return ""
+	}
+	for _, usage := range parsed.ExtKeyUsage {
+		if usage == x509.ExtKeyUsageClientAuth || usage == x509.ExtKeyUsageAny {
+			return ""
+		}
+	}
+	return "references a certificate whose extended key usage doesn't allow client authentication"
+}
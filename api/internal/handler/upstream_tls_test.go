@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"reflect"
+	"testing"
+
+	"nginx-proxy-guard/internal/model"
+)
+
+func TestCheckUpstreamTLSTarget(t *testing.T) {
+	group := &model.Upstream{Servers: []model.UpstreamServer{{Address: "backend-1", Port: 8443}}}
+
+	tests := []struct {
+		name     string
+		scheme   string
+		upstream *model.Upstream
+		wantOK   bool
+	}{
+		{"https forward target", "https", nil, true},
+		{"http forward target", "http", nil, false},
+		{"http forward target with an empty group", "http", &model.Upstream{}, false},
+		{"http forward target with a group", "http", group, true},
+	}
+	for _, tt := range tests {
+		host := &model.ProxyHost{ForwardScheme: tt.scheme}
+		if got := checkUpstreamTLSTarget(host, tt.upstream); (got == "") != tt.wantOK {
+			t.Errorf("%s: checkUpstreamTLSTarget() = %q", tt.name, got)
+		}
+	}
+}
+
+func TestUpstreamTLSRequestFromConfig(t *testing.T) {
+	certID := "cert-1"
+	cfg := &model.UpstreamTLSConfig{
+		Enabled:             true,
+		Verify:              false,
+		TrustedCAPEM:        "-----BEGIN CERTIFICATE-----\n",
+		VerifyDepth:         3,
+		ServerName:          "backend.internal",
+		SNI:                 true,
+		ClientCertificateID: &certID,
+	}
+	req := upstreamTLSRequestFromConfig(cfg)
+
+	restored := model.UpstreamTLSConfig{
+		Enabled:             *req.Enabled,
+		Verify:              *req.Verify,
+		TrustedCAPEM:        req.TrustedCAPEM,
+		VerifyDepth:         req.VerifyDepth,
+		ServerName:          req.ServerName,
+		SNI:                 *req.SNI,
+		ClientCertificateID: req.ClientCertificateID,
+	}
+	if !reflect.DeepEqual(restored, *cfg) {
+		t.Errorf("restored settings = %+v, want %+v", restored, *cfg)
+	}
+}
@@ -0,0 +1,200 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"nginx-proxy-guard/internal/model"
+	"nginx-proxy-guard/internal/service"
+)
+
+type UserHandler struct {
+	service *service.UserService
+	audit   *service.AuditService
+}
+
+func NewUserHandler(userService *service.UserService, audit *service.AuditService) *UserHandler {
+	return &UserHandler{
+		service: userService,
+		audit:   audit,
+	}
+}
+
+// userServiceError maps user service errors to responses
+func userServiceError(c echo.Context, operation string, err error) error {
+	var uerr *service.UserError
+	switch {
+	case errors.As(err, &uerr):
+		return validationError(c, uerr.Field, uerr.Message)
+	case errors.Is(err, service.ErrUserNotFound):
+		return notFoundError(c, "User")
+	case errors.Is(err, service.ErrUsernameTaken):
+		return conflictError(c, "Username already taken")
+	case errors.Is(err, service.ErrSelfModification), errors.Is(err, service.ErrLastAdmin):
+		return conflictError(c, err.Error())
+	case errors.Is(err, service.ErrWeakPassword), errors.Is(err, service.ErrPasswordMismatch),
+		errors.Is(err, service.Err2FANotEnabled), errors.Is(err, service.ErrBreachedPassword):
+		return badRequestError(c, err.Error())
+	case errors.Is(err, service.ErrInvalidInvitation):
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Invalid or expired invitation"})
+	default:
+		return databaseError(c, operation, err)
+	}
+}
+
+// List returns all users
+func (h *UserHandler) List(c echo.Context) error {
+	users, err := h.service.List(c.Request().Context())
+	if err != nil {
+		return databaseError(c, "list users", err)
+	}
+	return c.JSON(http.StatusOK, users)
+}
+
+// Get returns a single user
+func (h *UserHandler) Get(c echo.Context) error {
+	user, err := h.service.Get(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return userServiceError(c, "get user", err)
+	}
+	return c.JSON(http.StatusOK, user)
+}
+
+// ListRoles returns the built-in roles and their permissions
+func (h *UserHandler) ListRoles(c echo.Context) error {
+	return c.JSON(http.StatusOK, service.Roles())
+}
+
+// Create adds a user with an initial password
+func (h *UserHandler) Create(c echo.Context) error {
+	var req model.CreateUserRequest
+	if err := c.Bind(&req); err != nil {
+		return badRequestError(c, "Invalid request body")
+	}
+
+	auditCtx := service.ContextWithAudit(c.Request().Context(), c)
+	user, breached, err := h.service.Create(c.Request().Context(), &req)
+	if err != nil {
+		if errors.Is(err, service.ErrBreachedPassword) {
+			h.audit.LogPasswordBreachRejected(auditCtx, req.Username)
+		}
+		return userServiceError(c, "create user", err)
+	}
+
+	h.audit.LogUserCreated(auditCtx, user.ID, user.Username, user.Role, false)
+	if breached {
+		h.audit.LogPasswordBreachOverride(auditCtx, user.ID, user.Username)
+	}
+
+	return createdResponse(c, user)
+}
+
+// Invite adds a user without a password and returns a one-time invitation token
+func (h *UserHandler) Invite(c echo.Context) error {
+	var req model.InviteUserRequest
+	if err := c.Bind(&req); err != nil {
+		return badRequestError(c, "Invalid request body")
+	}
+
+	invitedBy, _ := getContextString(c, "user_id")
+	invitation, err := h.service.Invite(c.Request().Context(), &req, invitedBy)
+	if err != nil {
+		return userServiceError(c, "invite user", err)
+	}
+
+	auditCtx := service.ContextWithAudit(c.Request().Context(), c)
+	h.audit.LogUserCreated(auditCtx, invitation.User.ID, invitation.User.Username, invitation.User.Role, true)
+
+	return createdResponse(c, invitation)
+}
+
+// AcceptInvite lets an invited user set a password (public endpoint)
+func (h *UserHandler) AcceptInvite(c echo.Context) error {
+	var req model.AcceptInviteRequest
+	if err := c.Bind(&req); err != nil {
+		return badRequestError(c, "Invalid request body")
+	}
+	if req.Token == "" {
+		return validationError(c, "token", "is required")
+	}
+
+	user, err := h.service.AcceptInvite(c.Request().Context(), &req)
+	if err != nil {
+		return userServiceError(c, "accept invitation", err)
+	}
+
+	return c.JSON(http.StatusOK, user)
+}
+
+// UpdateRole changes the role of a user
+func (h *UserHandler) UpdateRole(c echo.Context) error {
+	var req model.UpdateUserRoleRequest
+	if err := c.Bind(&req); err != nil {
+		return badRequestError(c, "Invalid request body")
+	}
+
+	actorID, _ := getContextString(c, "user_id")
+	user, oldRole, err := h.service.ChangeRole(c.Request().Context(), actorID, c.Param("id"), req.Role)
+	if err != nil {
+		return userServiceError(c, "update user role", err)
+	}
+
+	if oldRole != user.Role {
+		auditCtx := service.ContextWithAudit(c.Request().Context(), c)
+		h.audit.LogUserRoleChanged(auditCtx, user.ID, user.Username, oldRole, user.Role)
+	}
+
+	return c.JSON(http.StatusOK, user)
+}
+
+// Disable disables a user and ends its sessions
+func (h *UserHandler) Disable(c echo.Context) error {
+	return h.setDisabled(c, true)
+}
+
+// Enable re-enables a disabled user
+func (h *UserHandler) Enable(c echo.Context) error {
+	return h.setDisabled(c, false)
+}
+
+func (h *UserHandler) setDisabled(c echo.Context, disabled bool) error {
+	actorID, _ := getContextString(c, "user_id")
+	user, err := h.service.SetDisabled(c.Request().Context(), actorID, c.Param("id"), disabled)
+	if err != nil {
+		return userServiceError(c, "update user status", err)
+	}
+
+	auditCtx := service.ContextWithAudit(c.Request().Context(), c)
+	h.audit.LogUserDisabled(auditCtx, user.ID, user.Username, disabled)
+
+	return c.JSON(http.StatusOK, user)
+}
+
+// Reset2FA removes the 2FA enrollment of a user
+func (h *UserHandler) Reset2FA(c echo.Context) error {
+	user, err := h.service.Reset2FA(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return userServiceError(c, "reset 2FA", err)
+	}
+
+	auditCtx := service.ContextWithAudit(c.Request().Context(), c)
+	h.audit.LogUser2FAReset(auditCtx, user.ID, user.Username)
+
+	return c.JSON(http.StatusOK, user)
+}
+
+// Delete removes a user
+func (h *UserHandler) Delete(c echo.Context) error {
+	actorID, _ := getContextString(c, "user_id")
+	user, err := h.service.Delete(c.Request().Context(), actorID, c.Param("id"))
+	if err != nil {
+		return userServiceError(c, "delete user", err)
+	}
+
+	auditCtx := service.ContextWithAudit(c.Request().Context(), c)
+	h.audit.LogUserDeleted(auditCtx, user.ID, user.Username)
+
+	return noContentResponse(c)
+}
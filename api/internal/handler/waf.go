@@ -3,6 +3,7 @@ package handler
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
@@ -12,9 +13,12 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/labstack/echo/v4"
+
 	"nginx-proxy-guard/internal/model"
 	"nginx-proxy-guard/internal/nginx"
 	"nginx-proxy-guard/internal/repository"
+	"nginx-proxy-guard/internal/service"
 )
 
 type WAFHandler struct {
@@ -23,6 +27,8 @@ type WAFHandler struct {
 	geoRepo       *repository.GeoRepository
 	nginxManager  *nginx.Manager
 	crsPath       string
+	approvals     *service.ChangeRequestService
+	audit         *service.AuditService
 }
 
 func NewWAFHandler(wafRepo *repository.WAFRepository, proxyHostRepo *repository.ProxyHostRepository, geoRepo *repository.GeoRepository, nginxManager *nginx.Manager) *WAFHandler {
@@ -39,6 +45,14 @@ func NewWAFHandler(wafRepo *repository.WAFRepository, proxyHostRepo *repository.
 	}
 }
 
+// SetApprovals lets disabling a WAF rule of a host require approval by a
+// second user. The approved exclusions are applied by the proxy host
+// handler, which owns the WAF disable action.
+func (h *WAFHandler) SetApprovals(approvals *service.ChangeRequestService, audit *service.AuditService) {
+	h.approvals = approvals
+	h.audit = audit
+}
+
 // getUsernameFromContext extracts username from request context
 // When using echo.WrapHandler, username may be stored in context by auth middleware
 func getUsernameFromContext(ctx context.Context) string {
@@ -143,8 +157,9 @@ func (h *WAFHandler) GetRules(w http.ResponseWriter, r *http.Request) {
 func (h *WAFHandler) GetHostConfigs(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	// Get all proxy hosts
-	hosts, _, err := h.proxyHostRepo.List(ctx, 1, 1000, "", "", "")
+	// Get all proxy hosts in the token scope
+	scope, _ := model.TokenScopeFromContext(ctx)
+	hosts, _, err := h.proxyHostRepo.ListInScope(ctx, scope, 1, 1000, "", "", "")
 	if err != nil {
 		httpDatabaseError(w, "list proxy hosts for WAF", err)
 		return
@@ -271,17 +286,29 @@ func (h *WAFHandler) DisableRule(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Create the exclusion
-	exclusion, err := h.wafRepo.CreateExclusion(ctx, proxyHostID, &req)
+	exclusion, err := h.disableRule(ctx, proxyHostID, &req)
 	if err != nil {
 		httpDatabaseError(w, "create WAF exclusion", err)
 		return
 	}
 
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(exclusion)
+}
+
+// disableRule creates the exclusion of a rule for a proxy host, records it in
+// the policy history and regenerates the host's WAF config
+func (h *WAFHandler) disableRule(ctx context.Context, proxyHostID string, req *model.CreateWAFRuleExclusionRequest) (*model.WAFRuleExclusion, error) {
+	exclusion, err := h.wafRepo.CreateExclusion(ctx, proxyHostID, req)
+	if err != nil {
+		return nil, err
+	}
+
 	// Record policy history
 	history := &model.WAFPolicyHistory{
 		ProxyHostID:     proxyHostID,
-		RuleID:          ruleID,
+		RuleID:          req.RuleID,
 		RuleCategory:    req.RuleCategory,
 		RuleDescription: req.RuleDescription,
 		Action:          "disabled",
@@ -294,10 +321,122 @@ func (h *WAFHandler) DisableRule(w http.ResponseWriter, r *http.Request) {
 		// Log but don't fail - the exclusion is saved
 		log.Printf("[WAF] Failed to regenerate nginx config for host %s: %v", proxyHostID, err)
 	}
+	return exclusion, nil
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(exclusion)
+// DisableRuleWithApproval disables a WAF rule of a proxy host, or submits the
+// exclusion for approval while the WAF disable action requires it
+func (h *WAFHandler) DisableRuleWithApproval(c echo.Context) error {
+	if required, err := approvalRequired(c, h.approvals, model.ChangeActionWAFDisable); err != nil {
+		return databaseError(c, "check change approval", err)
+	} else if !required {
+		return echo.WrapHandler(http.HandlerFunc(h.DisableRule))(c)
+	}
+
+	ruleID, err := strconv.Atoi(c.Param("ruleId"))
+	if err != nil {
+		return badRequestError(c, "Invalid rule ID")
+	}
+	var req model.CreateWAFRuleExclusionRequest
+	if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+		// Allow empty body, just use the rule ID from path
+		req = model.CreateWAFRuleExclusionRequest{}
+	}
+	req.RuleID = ruleID
+
+	host, err := h.proxyHostRepo.GetByID(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return databaseError(c, "get proxy host", err)
+	}
+	if host == nil {
+		return notFoundError(c, "Proxy host")
+	}
+	return h.submitRuleDisable(c, host, &req)
+}
+
+// DisableRuleByHostWithApproval is DisableRuleWithApproval for a proxy host
+// identified by domain name
+func (h *WAFHandler) DisableRuleByHostWithApproval(c echo.Context) error {
+	if required, err := approvalRequired(c, h.approvals, model.ChangeActionWAFDisable); err != nil {
+		return databaseError(c, "check change approval", err)
+	} else if !required {
+		return echo.WrapHandler(http.HandlerFunc(h.DisableRuleByHost))(c)
+	}
+
+	var req struct {
+		Host string `json:"host"`
+		model.CreateWAFRuleExclusionRequest
+	}
+	if err := c.Bind(&req); err != nil {
+		return badRequestError(c, "Invalid request body")
+	}
+	if req.Host == "" {
+		return badRequestError(c, "Host is required")
+	}
+	if req.RuleID == 0 {
+		return badRequestError(c, "Rule ID is required")
+	}
+
+	host, err := h.proxyHostRepo.GetByDomain(c.Request().Context(), req.Host)
+	if err != nil {
+		return databaseError(c, "lookup proxy host by domain", err)
+	}
+	if host == nil {
+		return notFoundError(c, "Proxy host")
+	}
+	if scope, scoped := callerTokenScope(c); scoped && !scope.AllowsHost(host) {
+		return outOfScopeError(c, "Proxy host")
+	}
+	return h.submitRuleDisable(c, host, &req.CreateWAFRuleExclusionRequest)
+}
+
+// submitRuleDisable stores the exclusion of a rule for a proxy host for approval
+func (h *WAFHandler) submitRuleDisable(c echo.Context, host *model.ProxyHost, req *model.CreateWAFRuleExclusionRequest) error {
+	existing, err := h.wafRepo.GetExclusionByRuleID(c.Request().Context(), host.ID, req.RuleID)
+	if err != nil {
+		return databaseError(c, "check WAF exclusion", err)
+	}
+	if existing != nil {
+		return conflictError(c, "Rule already disabled")
+	}
+
+	diff, err := service.FieldDiff(struct{}{}, req)
+	if err != nil {
+		return internalError(c, "render WAF exclusion diff", err)
+	}
+	return submitChange(c, h.approvals, h.audit, &model.ChangeRequest{
+		Action:     model.ChangeActionWAFDisable,
+		TargetID:   host.ID,
+		TargetName: strings.Join(host.DomainNames, ", "),
+		Diff:       diff,
+	}, wafDisableChange{ProxyHostID: host.ID, RuleExclusion: req})
+}
+
+// applyRuleDisable applies an approved exclusion of a rule for a proxy host
+func (h *WAFHandler) applyRuleDisable(ctx context.Context, proxyHostID string, req *model.CreateWAFRuleExclusionRequest) error {
+	host, err := h.proxyHostRepo.GetByID(ctx, proxyHostID)
+	if err != nil {
+		return err
+	}
+	if host == nil {
+		return fmt.Errorf("proxy host no longer exists")
+	}
+
+	// The rule may have been disabled since the change was requested
+	existing, err := h.wafRepo.GetExclusionByRuleID(ctx, proxyHostID, req.RuleID)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		if _, err := h.disableRule(ctx, proxyHostID, req); err != nil {
+			return err
+		}
+	}
+
+	h.audit.LogWAFRulesUpdated(ctx, strings.Join(host.DomainNames, ", "), map[string]interface{}{
+		"disabled_rule": req.RuleID,
+	})
+	return nil
 }
 
 // DisableRuleByHost disables a WAF rule for a proxy host identified by domain name
@@ -337,6 +476,10 @@ func (h *WAFHandler) DisableRuleByHost(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Proxy host not found for domain", http.StatusNotFound)
 		return
 	}
+	if scope, scoped := model.TokenScopeFromContext(ctx); scoped && !scope.AllowsHost(host) {
+		http.Error(w, "Proxy host is outside the token scope", http.StatusForbidden)
+		return
+	}
 
 	proxyHostID := host.ID
 
@@ -359,30 +502,12 @@ func (h *WAFHandler) DisableRuleByHost(w http.ResponseWriter, r *http.Request) {
 		Reason:          req.Reason,
 	}
 
-	// Create the exclusion
-	exclusion, err := h.wafRepo.CreateExclusion(ctx, proxyHostID, exclusionReq)
+	exclusion, err := h.disableRule(ctx, proxyHostID, exclusionReq)
 	if err != nil {
 		httpDatabaseError(w, "create WAF exclusion", err)
 		return
 	}
 
-	// Record policy history
-	history := &model.WAFPolicyHistory{
-		ProxyHostID:     proxyHostID,
-		RuleID:          req.RuleID,
-		RuleCategory:    req.RuleCategory,
-		RuleDescription: req.RuleDescription,
-		Action:          "disabled",
-		Reason:          req.Reason,
-	}
-	h.wafRepo.CreatePolicyHistory(ctx, history)
-
-	// Regenerate nginx config for this host
-	if err := h.regenerateHostConfig(ctx, proxyHostID); err != nil {
-		// Log but don't fail - the exclusion is saved
-		log.Printf("[WAF] Failed to regenerate nginx config for host %s (via DisableRuleByHost): %v", proxyHostID, err)
-	}
-
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(exclusion)
@@ -0,0 +1,306 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"nginx-proxy-guard/internal/model"
+	"nginx-proxy-guard/internal/service"
+)
+
+type WebAuthnHandler struct {
+	service *service.WebAuthnService
+	audit   *service.AuditService
+}
+
+func NewWebAuthnHandler(webauthnService *service.WebAuthnService, audit *service.AuditService) *WebAuthnHandler {
+	return &WebAuthnHandler{
+		service: webauthnService,
+		audit:   audit,
+	}
+}
+
+// requestOrigin returns the origin the UI was opened on, which ceremonies are bound to
+func requestOrigin(c echo.Context) string {
+	if origin := c.Request().Header.Get("Origin"); origin != "" {
+		return origin
+	}
+	return c.Scheme() + "://" + c.Request().Host
+}
+
+// webauthnError maps WebAuthn errors shared by the ceremonies to responses
+func webauthnError(c echo.Context, operation string, err error) error {
+	var uerr *service.UserError
+	switch {
+	case errors.As(err, &uerr):
+		return validationError(c, uerr.Field, uerr.Message)
+	case errors.Is(err, service.ErrWebAuthnDisabled):
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Security keys are not enabled"})
+	case errors.Is(err, service.ErrPasswordlessDisabled):
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Passwordless login is not enabled"})
+	case errors.Is(err, service.ErrWebAuthnOrigin):
+		return badRequestError(c, "Security keys can't be used from this address")
+	case errors.Is(err, service.ErrInvalidWebAuthnCeremony):
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Invalid or expired security key request"})
+	case errors.Is(err, service.ErrInvalidTempToken):
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Invalid or expired temporary token"})
+	case errors.Is(err, service.ErrWebAuthnVerification):
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Security key verification failed", "details": err.Error()})
+	case errors.Is(err, service.ErrSecurityKeyRequired):
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "A hardware security key is required for this account"})
+	case errors.Is(err, service.ErrAccountDisabled):
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "Account is disabled"})
+	case errors.Is(err, service.ErrLocalLoginDisabled):
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "Local login is disabled. Sign in with single sign-on."})
+	case errors.Is(err, service.ErrTooManyAttempts):
+		return c.JSON(http.StatusTooManyRequests, map[string]string{"error": "Too many failed login attempts. Please try again later."})
+	case errors.Is(err, service.ErrWebAuthnCredentialNotFound):
+		return notFoundError(c, "Security key")
+	case errors.Is(err, service.ErrLastSecurityKey):
+		return conflictError(c, "Admins must keep a hardware security key while hardware keys are required")
+	}
+	return internalError(c, operation, err)
+}
+
+// GetSettings returns the WebAuthn settings
+func (h *WebAuthnHandler) GetSettings(c echo.Context) error {
+	settings, err := h.service.GetSettings(c.Request().Context())
+	if err != nil {
+		return databaseError(c, "get WebAuthn settings", err)
+	}
+	return c.JSON(http.StatusOK, settings)
+}
+
+// UpdateSettings updates the WebAuthn settings
+func (h *WebAuthnHandler) UpdateSettings(c echo.Context) error {
+	ctx := c.Request().Context()
+	user, ok := getUserFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "authentication required"})
+	}
+
+	var req model.UpdateWebAuthnSettingsRequest
+	if err := c.Bind(&req); err != nil {
+		return badRequestError(c, "Invalid request body")
+	}
+
+	settings, err := h.service.UpdateSettings(ctx, user, &req)
+	if err != nil {
+		var uerr *service.UserError
+		if errors.As(err, &uerr) {
+			return validationError(c, uerr.Field, uerr.Message)
+		}
+		return databaseError(c, "update WebAuthn settings", err)
+	}
+
+	// Audit log
+	auditCtx := service.ContextWithAudit(ctx, c)
+	h.audit.LogSettingsUpdate(auditCtx, "WebAuthn", map[string]interface{}{
+		"enabled":                         settings.Enabled,
+		"rp_id":                           settings.RPID,
+		"rp_origins":                      settings.RPOrigins,
+		"allow_passwordless":              settings.AllowPasswordless,
+		"require_hardware_key_for_admins": settings.RequireHardwareKeyForAdmins,
+	})
+
+	return c.JSON(http.StatusOK, settings)
+}
+
+// Status tells the login page whether to offer passkey login
+func (h *WebAuthnHandler) Status(c echo.Context) error {
+	status, err := h.service.Status(c.Request().Context())
+	if err != nil {
+		return databaseError(c, "get WebAuthn status", err)
+	}
+	return c.JSON(http.StatusOK, status)
+}
+
+// === Own security keys ===
+
+// ListCredentials returns the security keys of the current user
+func (h *WebAuthnHandler) ListCredentials(c echo.Context) error {
+	user, ok := getUserFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "authentication required"})
+	}
+
+	credentials, err := h.service.ListCredentials(c.Request().Context(), user.ID)
+	if err != nil {
+		return databaseError(c, "list security keys", err)
+	}
+	return c.JSON(http.StatusOK, credentials)
+}
+
+// BeginRegistration returns the options for navigator.credentials.create()
+func (h *WebAuthnHandler) BeginRegistration(c echo.Context) error {
+	user, ok := getUserFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "authentication required"})
+	}
+
+	var req model.WebAuthnRegisterRequest
+	if err := c.Bind(&req); err != nil {
+		return badRequestError(c, "Invalid request body")
+	}
+
+	ceremony, err := h.service.BeginRegistration(c.Request().Context(), user, req.Name, requestOrigin(c))
+	if err != nil {
+		return webauthnError(c, "begin security key registration", err)
+	}
+	return c.JSON(http.StatusOK, ceremony)
+}
+
+// FinishRegistration stores the security key created by the browser
+func (h *WebAuthnHandler) FinishRegistration(c echo.Context) error {
+	user, ok := getUserFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "authentication required"})
+	}
+
+	var req model.WebAuthnFinishRequest
+	if err := c.Bind(&req); err != nil {
+		return badRequestError(c, "Invalid request body")
+	}
+	if req.CeremonyID == "" || len(req.Credential) == 0 {
+		return badRequestError(c, "Ceremony ID and credential are required")
+	}
+
+	credential, err := h.service.FinishRegistration(c.Request().Context(), user, &req)
+	if err != nil {
+		return webauthnError(c, "finish security key registration", err)
+	}
+
+	auditCtx := service.ContextWithAudit(c.Request().Context(), c)
+	h.audit.LogWebAuthnRegistered(auditCtx, user.Username, credential.Name, credential.HardwareKey)
+
+	return c.JSON(http.StatusCreated, credential)
+}
+
+// RenameCredential renames a security key of the current user
+func (h *WebAuthnHandler) RenameCredential(c echo.Context) error {
+	user, ok := getUserFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "authentication required"})
+	}
+
+	var req model.WebAuthnRenameRequest
+	if err := c.Bind(&req); err != nil {
+		return badRequestError(c, "Invalid request body")
+	}
+
+	credential, err := h.service.RenameCredential(c.Request().Context(), user.ID, c.Param("id"), req.Name)
+	if err != nil {
+		return webauthnError(c, "rename security key", err)
+	}
+	return c.JSON(http.StatusOK, credential)
+}
+
+// DeleteCredential revokes a security key of the current user
+func (h *WebAuthnHandler) DeleteCredential(c echo.Context) error {
+	user, ok := getUserFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "authentication required"})
+	}
+
+	credential, err := h.service.DeleteCredential(c.Request().Context(), user, c.Param("id"))
+	if err != nil {
+		return webauthnError(c, "delete security key", err)
+	}
+
+	auditCtx := service.ContextWithAudit(c.Request().Context(), c)
+	h.audit.LogWebAuthnRemoved(auditCtx, user.Username, credential.Name)
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// === Login ===
+
+// BeginLogin returns the options for navigator.credentials.get() to finish a
+// password login that answered requires_2fa
+func (h *WebAuthnHandler) BeginLogin(c echo.Context) error {
+	var req model.WebAuthnLoginBeginRequest
+	if err := c.Bind(&req); err != nil {
+		return badRequestError(c, "Invalid request body")
+	}
+	if req.TempToken == "" {
+		return badRequestError(c, "Temporary token is required")
+	}
+
+	ceremony, err := h.service.BeginLogin(c.Request().Context(), req.TempToken, requestOrigin(c))
+	if err != nil {
+		return webauthnError(c, "begin security key login", err)
+	}
+	return c.JSON(http.StatusOK, ceremony)
+}
+
+// FinishLogin verifies the security key and returns the session
+func (h *WebAuthnHandler) FinishLogin(c echo.Context) error {
+	var req model.WebAuthnFinishRequest
+	if err := c.Bind(&req); err != nil {
+		return badRequestError(c, "Invalid request body")
+	}
+	if req.CeremonyID == "" || len(req.Credential) == 0 {
+		return badRequestError(c, "Ceremony ID and credential are required")
+	}
+
+	resp, err := h.service.FinishLogin(c.Request().Context(), &req, c.RealIP())
+	if err != nil {
+		if errors.Is(err, service.ErrTooManyAttempts) {
+			return tooManyAttempts(c, h.audit, err)
+		}
+		return webauthnError(c, "finish security key login", err)
+	}
+
+	h.logLogin(c, resp)
+	return c.JSON(http.StatusOK, resp)
+}
+
+// BeginPasskeyLogin returns the options for a passwordless login
+func (h *WebAuthnHandler) BeginPasskeyLogin(c echo.Context) error {
+	ceremony, err := h.service.BeginPasskeyLogin(c.Request().Context(), requestOrigin(c))
+	if err != nil {
+		return webauthnError(c, "begin passkey login", err)
+	}
+	return c.JSON(http.StatusOK, ceremony)
+}
+
+// FinishPasskeyLogin verifies the passkey and returns the session of its owner
+func (h *WebAuthnHandler) FinishPasskeyLogin(c echo.Context) error {
+	var req model.WebAuthnFinishRequest
+	if err := c.Bind(&req); err != nil {
+		return badRequestError(c, "Invalid request body")
+	}
+	if req.CeremonyID == "" || len(req.Credential) == 0 {
+		return badRequestError(c, "Ceremony ID and credential are required")
+	}
+
+	resp, err := h.service.FinishPasskeyLogin(c.Request().Context(), &req, c.RealIP(), c.Request().UserAgent())
+	if err != nil {
+		if errors.Is(err, service.ErrTooManyAttempts) {
+			return tooManyAttempts(c, h.audit, err)
+		}
+		return webauthnError(c, "finish passkey login", err)
+	}
+
+	h.logLogin(c, resp)
+	return c.JSON(http.StatusOK, resp)
+}
+
+// logLogin records a completed login in the audit log
+func (h *WebAuthnHandler) logLogin(c echo.Context, resp *model.LoginResponse) {
+	if resp.User == nil {
+		return
+	}
+	// For login, set user info directly since middleware hasn't set it yet
+	ip := c.RealIP()
+	userAgent := c.Request().UserAgent()
+	ctx := c.Request().Context()
+	ctx = context.WithValue(ctx, "user_id", resp.User.ID)
+	ctx = context.WithValue(ctx, "username", resp.User.Username)
+	ctx = context.WithValue(ctx, "client_ip", ip)
+	ctx = context.WithValue(ctx, "user_agent", userAgent)
+	h.audit.LogUserLogin(ctx, resp.User.Username, ip, userAgent)
+}
@@ -50,13 +50,8 @@ func APITokenAuth(tokenRepo *repository.APITokenRepository, auditRepo *repositor
 				return c.JSON(http.StatusInternalServerError, map[string]string{"error": "authentication error"})
 			}
 
-			if token == nil {
-				return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid API token"})
-			}
-
-			// Check if token is valid
-			if !token.IsValid() {
-				return c.JSON(http.StatusUnauthorized, map[string]string{"error": "token expired or revoked"})
+			if msg := rejectAPIToken(token); msg != "" {
+				return c.JSON(http.StatusUnauthorized, map[string]string{"error": msg})
 			}
 
 			// Check IP restriction
@@ -156,30 +151,17 @@ func APITokenAuth(tokenRepo *repository.APITokenRepository, auditRepo *repositor
 	}
 }
 
-// RequireAPIPermission checks if the API token has the required permission
-func RequireAPIPermission(permission string) echo.MiddlewareFunc {
-	return func(next echo.HandlerFunc) echo.HandlerFunc {
-		return func(c echo.Context) error {
-			// If not using API token, skip this check (regular auth will handle it)
-			isAPIToken, ok := c.Get("is_api_token").(bool)
-			if !ok || !isAPIToken {
-				return next(c)
-			}
-
-			token, ok := c.Get("api_token").(*model.APIToken)
-			if !ok || token == nil {
-				return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid token"})
-			}
-
-			if !token.HasPermission(permission) {
-				return c.JSON(http.StatusForbidden, map[string]string{
-					"error":              "insufficient permissions",
-					"required":           permission,
-					"token_permissions":  strings.Join(token.Permissions, ", "),
-				})
-			}
-
-			return next(c)
-		}
+// rejectAPIToken returns why a looked up token can't authenticate, or "" if it can
+func rejectAPIToken(token *model.APIToken) string {
+	if token == nil {
+		return "invalid API token"
+	}
+	if !token.IsValid() {
+		return "token expired or revoked"
+	}
+	// Disabling a user must lock out its tokens like its sessions
+	if token.OwnerDisabled {
+		return "token owner is disabled"
 	}
+	return ""
 }
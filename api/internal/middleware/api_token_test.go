@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"nginx-proxy-guard/internal/model"
+)
+
+func TestRejectAPIToken(t *testing.T) {
+	expired := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name   string
+		token  *model.APIToken
+		reject bool
+	}{
+		{"unknown token", nil, true},
+		{"active token", &model.APIToken{IsActive: true, OwnerRole: model.RoleOperator}, false},
+		{"revoked token", &model.APIToken{IsActive: false, OwnerRole: model.RoleOperator}, true},
+		{"expired token", &model.APIToken{IsActive: true, ExpiresAt: &expired, OwnerRole: model.RoleOperator}, true},
+		{"disabled owner", &model.APIToken{IsActive: true, OwnerRole: model.RoleAdmin, OwnerDisabled: true}, true},
+	}
+	for _, tt := range tests {
+		if got := rejectAPIToken(tt.token); (got != "") != tt.reject {
+			t.Errorf("%s: rejectAPIToken() = %q, want reject %v", tt.name, got, tt.reject)
+		}
+	}
+}
+
+// authorizeToken runs Authorize for a request made with token and returns the status
+func authorizeToken(token *model.APIToken, method, path string) int {
+	e := echo.New()
+	c := e.NewContext(httptest.NewRequest(method, path, nil), httptest.NewRecorder())
+	c.SetPath(path)
+	c.Set("is_api_token", true)
+	c.Set("api_token", token)
+
+	handler := Authorize()(func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+	if err := handler(c); err != nil {
+		return http.StatusInternalServerError
+	}
+	return c.Response().Status
+}
+
+func TestAuthorizeTokenOwnerRole(t *testing.T) {
+	tests := []struct {
+		name        string
+		permissions []string
+		ownerRole   string
+		method      string
+		want        int
+	}{
+		{"admin owner", []string{model.PermissionProxyWrite}, model.RoleAdmin, http.MethodPost, http.StatusOK},
+		{"operator owner", []string{model.PermissionProxyWrite}, model.RoleOperator, http.MethodPost, http.StatusOK},
+		{"owner demoted to viewer can't write", []string{model.PermissionProxyWrite}, model.RoleViewer, http.MethodPost, http.StatusForbidden},
+		{"owner demoted to viewer can still read", []string{model.PermissionAll}, model.RoleViewer, http.MethodGet, http.StatusOK},
+		{"full token of a viewer can't write", []string{model.PermissionAll}, model.RoleViewer, http.MethodPost, http.StatusForbidden},
+		{"role doesn't widen the token", []string{model.PermissionProxyRead}, model.RoleAdmin, http.MethodPost, http.StatusForbidden},
+		{"owner without a known role", []string{model.PermissionAll}, "", http.MethodGet, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		token := &model.APIToken{IsActive: true, Permissions: tt.permissions, OwnerRole: tt.ownerRole}
+		if got := authorizeToken(token, tt.method, "/api/v1/proxy-hosts"); got != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
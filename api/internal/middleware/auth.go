@@ -1,16 +1,31 @@
 package middleware
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"strings"
 
 	"github.com/labstack/echo/v4"
 
+	"nginx-proxy-guard/internal/model"
 	"nginx-proxy-guard/internal/service"
 )
 
+// tokenValidator resolves a session token to its user
+type tokenValidator interface {
+	ValidateToken(ctx context.Context, token string) (*model.User, error)
+}
+
+// passwordChangeRoutes are all a session with an expired password may call;
+// logout is public
+var passwordChangeRoutes = map[string]bool{
+	"POST /api/v1/auth/change-password":    true,
+	"POST /api/v1/auth/change-credentials": true,
+}
+
 // AuthMiddleware creates authentication middleware
-func AuthMiddleware(authService *service.AuthService) echo.MiddlewareFunc {
+func AuthMiddleware(authService tokenValidator) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
 			// Skip if already authenticated via API token
@@ -26,7 +41,14 @@ func AuthMiddleware(authService *service.AuthService) echo.MiddlewareFunc {
 			}
 
 			user, err := authService.ValidateToken(c.Request().Context(), token)
-			if err != nil {
+			if errors.Is(err, service.ErrPasswordExpired) {
+				if !passwordChangeRoutes[c.Request().Method+" "+c.Path()] {
+					return c.JSON(http.StatusForbidden, map[string]interface{}{
+						"error":            "Password expired, change it to continue",
+						"password_expired": true,
+					})
+				}
+			} else if err != nil {
 				return c.JSON(http.StatusUnauthorized, map[string]string{
 					"error": "Invalid or expired session",
 				})
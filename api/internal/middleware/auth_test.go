@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+
+	"nginx-proxy-guard/internal/model"
+	"nginx-proxy-guard/internal/service"
+)
+
+// fakeSessions resolves session tokens to users; sessions in expired are
+// restricted to changing the password
+type fakeSessions struct {
+	users   map[string]*model.User
+	expired map[string]bool
+}
+
+func (f fakeSessions) ValidateToken(_ context.Context, token string) (*model.User, error) {
+	user, ok := f.users[token]
+	if !ok {
+		return nil, service.ErrSessionExpired
+	}
+	if f.expired[token] {
+		return user, service.ErrPasswordExpired
+	}
+	return user, nil
+}
+
+func TestAuthMiddlewarePasswordExpired(t *testing.T) {
+	sessions := fakeSessions{
+		users: map[string]*model.User{
+			"fresh": {ID: "u1", Username: "alice", Role: model.RoleAdmin},
+			"stale": {ID: "u2", Username: "bob", Role: model.RoleAdmin},
+		},
+		expired: map[string]bool{"stale": true},
+	}
+
+	tests := []struct {
+		name         string
+		token        string
+		method, path string
+		want         int
+	}{
+		{"change password", "stale", http.MethodPost, "/api/v1/auth/change-password", http.StatusOK},
+		{"change credentials", "stale", http.MethodPost, "/api/v1/auth/change-credentials", http.StatusOK},
+		{"read own account", "stale", http.MethodGet, "/api/v1/auth/account", http.StatusForbidden},
+		{"list proxy hosts", "stale", http.MethodGet, "/api/v1/proxy-hosts", http.StatusForbidden},
+		{"create user", "stale", http.MethodPost, "/api/v1/users", http.StatusForbidden},
+		{"unrestricted session", "fresh", http.MethodGet, "/api/v1/proxy-hosts", http.StatusOK},
+		{"unknown session", "gone", http.MethodPost, "/api/v1/auth/change-password", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		e := echo.New()
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		req.Header.Set("Authorization", "Bearer "+tt.token)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath(tt.path)
+
+		var user interface{}
+		err := AuthMiddleware(sessions)(func(c echo.Context) error {
+			user = c.Get("user")
+			return c.NoContent(http.StatusOK)
+		})(c)
+		if err != nil {
+			t.Fatalf("%s: AuthMiddleware() error = %v", tt.name, err)
+		}
+		if rec.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, rec.Code, tt.want)
+		}
+		if tt.want == http.StatusOK && user != sessions.users[tt.token] {
+			t.Errorf("%s: user not set in the context", tt.name)
+		}
+	}
+}
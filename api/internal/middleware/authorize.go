@@ -0,0 +1,193 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"nginx-proxy-guard/internal/model"
+)
+
+const apiPrefix = "/api/v1"
+
+// resourcePermissions are the permissions required to read (GET), write
+// (POST/PUT/PATCH) and delete (DELETE) a resource. An empty struct marks a
+// self-service resource any authenticated user may call; its handlers do
+// their own ownership checks.
+type resourcePermissions struct {
+	read, write, delete string
+}
+
+var (
+	proxyPermissions         = resourcePermissions{model.PermissionProxyRead, model.PermissionProxyWrite, model.PermissionProxyDelete}
+	certificatePermissions   = resourcePermissions{model.PermissionCertRead, model.PermissionCertWrite, model.PermissionCertDelete}
+	wafPermissions           = resourcePermissions{model.PermissionWAFRead, model.PermissionWAFWrite, model.PermissionWAFWrite}
+	logsPermissions          = resourcePermissions{model.PermissionLogsRead, model.PermissionSettingsWrite, model.PermissionSettingsWrite}
+	settingsPermissions      = resourcePermissions{model.PermissionSettingsRead, model.PermissionSettingsWrite, model.PermissionSettingsWrite}
+	backupPermissions        = resourcePermissions{model.PermissionBackupRead, model.PermissionBackupCreate, model.PermissionBackupCreate}
+	userPermissions          = resourcePermissions{model.PermissionUserRead, model.PermissionUserWrite, model.PermissionUserWrite}
+	accessListPermissions    = resourcePermissions{model.PermissionAccessListRead, model.PermissionAccessListWrite, model.PermissionAccessListDelete}
+	redirectPermissions      = resourcePermissions{model.PermissionRedirectRead, model.PermissionRedirectWrite, model.PermissionRedirectDelete}
+	dnsProviderPermissions   = resourcePermissions{model.PermissionDNSProviderRead, model.PermissionDNSProviderWrite, model.PermissionDNSProviderDelete}
+	bannedIPPermissions      = resourcePermissions{model.PermissionBannedIPRead, model.PermissionBannedIPWrite, model.PermissionBannedIPWrite}
+	exploitRulePermissions   = resourcePermissions{model.PermissionExploitRuleRead, model.PermissionExploitRuleWrite, model.PermissionExploitRuleWrite}
+	challengePermissions     = resourcePermissions{model.PermissionChallengeRead, model.PermissionChallengeWrite, model.PermissionChallengeWrite}
+	cloudProviderPermissions = resourcePermissions{model.PermissionCloudProviderRead, model.PermissionCloudProviderWrite, model.PermissionCloudProviderWrite}
+	auditPermissions         = resourcePermissions{model.PermissionAuditRead, model.PermissionAll, model.PermissionAll}
+	approvalPermissions      = resourcePermissions{model.PermissionSettingsRead, model.PermissionAll, model.PermissionAll}
+	managementPermissions    = resourcePermissions{model.PermissionSettingsRead, model.PermissionAll, model.PermissionAll}
+	selfService              = resourcePermissions{}
+)
+
+// routeResources maps the first path segment after /api/v1 to its permissions
+var routeResources = map[string]resourcePermissions{
+	"api-tokens":       selfService,
+	"users":            userPermissions,
+	"admin-sso":        userPermissions,
+	"webauthn":         userPermissions,
+	"session-settings": userPermissions,
+	"password-policy":  userPermissions,
+	"approval-policy":  approvalPermissions,
+	"change-requests":  selfService,
+	"ldap":             userPermissions,
+	"proxy-hosts":      proxyPermissions,
+	"access-lists":     accessListPermissions,
+	"redirect-hosts":   redirectPermissions,
+	"upstreams":        proxyPermissions,
+	"tls-policies":     proxyPermissions,
+	"cors-policies":    proxyPermissions,
+	"geo":              proxyPermissions,
+	"certificates":     certificatePermissions,
+	"dns-providers":    dnsProviderPermissions,
+	"client-cas":       certificatePermissions,
+	"waf":              wafPermissions,
+	"waf-test":         wafPermissions,
+	"exploit-rules":    exploitRulePermissions,
+	"banned-ips":       bannedIPPermissions,
+	"bots":             wafPermissions,
+	"uri-blocks":       wafPermissions,
+	"global-uri-block": wafPermissions,
+	"security-headers": wafPermissions,
+	"challenge-config": challengePermissions,
+	"cloud-providers":  cloudProviderPermissions,
+	"threat-feeds":     wafPermissions,
+	"crowdsec":         wafPermissions,
+	"trusted-ips":      wafPermissions,
+	"logs":             logsPermissions,
+	"system-logs":      logsPermissions,
+	"dashboard":        logsPermissions,
+	"settings":         settingsPermissions,
+	"system-settings":  settingsPermissions,
+	"audit-logs":       auditPermissions,
+	"oidc-providers":   settingsPermissions,
+	"real-ip":          settingsPermissions,
+	"management":       managementPermissions,
+	"test":             settingsPermissions,
+	"backups":          backupPermissions,
+}
+
+// proxyHostResources maps the /proxy-hosts/:id/<sub> routes to their
+// permissions; per-host security features use the scope of the feature
+var proxyHostResources = map[string]resourcePermissions{
+	"test":                    proxyPermissions,
+	"test-upstream":           proxyPermissions,
+	"clone":                   proxyPermissions,
+	"upstream":                proxyPermissions,
+	"upstream-tls":            proxyPermissions,
+	"tls-policy":              proxyPermissions,
+	"cors-policies":           proxyPermissions,
+	"signed-url-locations":    proxyPermissions,
+	"signed-urls":             proxyPermissions,
+	"geo":                     wafPermissions,
+	"rate-limit":              wafPermissions,
+	"fail2ban":                wafPermissions,
+	"bot-filter":              wafPermissions,
+	"uri-block":               wafPermissions,
+	"security-headers":        wafPermissions,
+	"threat-feeds":            wafPermissions,
+	"forward-auth":            wafPermissions,
+	"oidc-gate":               wafPermissions,
+	"client-cert-auth":        wafPermissions,
+	"challenge":               challengePermissions,
+	"blocked-cloud-providers": cloudProviderPermissions,
+}
+
+// routePermissionOverrides pins routes whose method doesn't describe the
+// action, keyed by "METHOD path" with the /api/v1 prefix removed
+var routePermissionOverrides = map[string]string{
+	"POST /backups/:id/restore":                         model.PermissionBackupRestore,
+	"POST /backups/upload-restore":                      model.PermissionBackupRestore,
+	"GET /backups/:id/download":                         model.PermissionBackupCreate, // backups contain secrets
+	"GET /certificates/:id/download":                    model.PermissionCertWrite,    // includes the private key
+	"GET /system-settings/log-files/:filename/download": model.PermissionSettingsWrite,
+}
+
+// RoutePermission returns the permission required to call a registered route
+// (echo's c.Path(), e.g. "/api/v1/proxy-hosts/:id") and whether the route is
+// mapped at all. Self-service routes require "". Unmapped routes require
+// model.PermissionAll, so they stay admin-only until they are mapped.
+func RoutePermission(method, path string) (string, bool) {
+	path = strings.TrimPrefix(path, apiPrefix)
+	if permission, ok := routePermissionOverrides[method+" "+path]; ok {
+		return permission, true
+	}
+
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	perms, ok := routeResources[segments[0]]
+	if segments[0] == "proxy-hosts" && len(segments) >= 3 && strings.HasPrefix(segments[1], ":") {
+		perms, ok = proxyHostResources[segments[2]]
+	}
+	if !ok {
+		return model.PermissionAll, false
+	}
+
+	switch method {
+	case http.MethodGet, http.MethodHead:
+		return perms.read, true
+	case http.MethodDelete:
+		return perms.delete, true
+	default:
+		return perms.write, true
+	}
+}
+
+// Authorize checks the permission required by the route against the role of
+// the logged-in user, or against the permissions of the API token used. It
+// must run after APITokenAuth and AuthMiddleware.
+func Authorize() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			permission, _ := RoutePermission(c.Request().Method, c.Path())
+			if permission == "" {
+				return next(c)
+			}
+
+			if isAPIToken, ok := c.Get("is_api_token").(bool); ok && isAPIToken {
+				token, ok := c.Get("api_token").(*model.APIToken)
+				if !ok || token == nil {
+					return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid token"})
+				}
+				if !token.HasPermission(permission) {
+					return c.JSON(http.StatusForbidden, map[string]string{
+						"error":             "insufficient permissions",
+						"required":          permission,
+						"token_permissions": strings.Join(token.Permissions, ", "),
+					})
+				}
+				return next(c)
+			}
+
+			role, _ := c.Get("role").(string)
+			if !model.RoleHasPermission(role, permission) {
+				return c.JSON(http.StatusForbidden, map[string]string{
+					"error":    "insufficient permissions",
+					"required": permission,
+					"role":     role,
+				})
+			}
+
+			return next(c)
+		}
+	}
+}
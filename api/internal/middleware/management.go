@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"nginx-proxy-guard/internal/service"
+)
+
+// managementExemptPrefixes are reached by visitors of proxied hosts and by
+// nginx rather than by administrators, so they stay open to everyone
+var managementExemptPrefixes = []string{
+	apiPrefix + "/challenge/",
+	apiPrefix + "/oidc/",
+	apiPrefix + "/public/",
+}
+
+// IsManagementPath reports whether path belongs to the management plane
+func IsManagementPath(path string) bool {
+	if path == "/health" {
+		return false
+	}
+	for _, prefix := range managementExemptPrefixes {
+		if strings.HasPrefix(path, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// ManagementAccess restricts the management plane to the allowed networks
+// and, while the dedicated listener is serving with dedicated_only set, to
+// that listener
+func ManagementAccess(management *service.ManagementService) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			if !IsManagementPath(req.URL.Path) {
+				return next(c)
+			}
+
+			if management.DedicatedOnly() && !service.FromManagementListener(req.Context()) {
+				return c.JSON(http.StatusForbidden, map[string]string{"error": "management is only available on the dedicated listener"})
+			}
+			if !management.Allowed(service.ManagementClientIP(req)) {
+				return c.JSON(http.StatusForbidden, map[string]string{"error": "management access is not allowed from this address"})
+			}
+
+			return next(c)
+		}
+	}
+}
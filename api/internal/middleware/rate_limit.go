@@ -52,9 +52,10 @@ func DefaultAPIRateLimitConfig() APIRateLimitConfig {
 				return true
 			}
 			path := c.Path()
-			// Skip health check and challenge endpoints (auth_request from nginx)
+			// Skip health check, challenge and OIDC session validation (auth_request from nginx)
 			return path == "/health" ||
-				strings.HasPrefix(path, "/api/v1/challenge/")
+				strings.HasPrefix(path, "/api/v1/challenge/") ||
+				path == "/api/v1/oidc/validate"
 		},
 	}
 }
@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+
+	"nginx-proxy-guard/internal/model"
+)
+
+type fakeProxyHosts map[string]*model.ProxyHost
+
+func (f fakeProxyHosts) GetByID(ctx context.Context, id string) (*model.ProxyHost, error) {
+	return f[id], nil
+}
+
+func TestTokenScope(t *testing.T) {
+	hosts := fakeProxyHosts{
+		"in-scope":  {ID: "in-scope", DomainNames: []string{"shop.example.com"}},
+		"out-scope": {ID: "out-scope", DomainNames: []string{"admin.example.com"}},
+	}
+	scoped := &model.APIToken{Scope: model.APITokenScope{Domains: []string{"shop.example.com"}}}
+
+	tests := []struct {
+		name   string
+		token  *model.APIToken
+		method string
+		path   string
+		params map[string]string
+		want   int
+	}{
+		{"in-scope host", scoped, http.MethodPut, "/proxy-hosts/:id/geo", map[string]string{"id": "in-scope"}, http.StatusOK},
+		{"out-of-scope host", scoped, http.MethodPut, "/proxy-hosts/:id/geo", map[string]string{"id": "out-scope"}, http.StatusForbidden},
+		{"out-of-scope host read", scoped, http.MethodGet, "/proxy-hosts/:id", map[string]string{"id": "out-scope"}, http.StatusForbidden},
+		{"out-of-scope host setting", scoped, http.MethodPut, "/proxy-hosts/:proxyHostId/rate-limit", map[string]string{"proxyHostId": "out-scope"}, http.StatusForbidden},
+		{"out-of-scope WAF host", scoped, http.MethodPost, "/waf/hosts/:id/rules/:ruleId/disable", map[string]string{"id": "out-scope", "ruleId": "942100"}, http.StatusForbidden},
+		{"out-of-scope exploit rule host", scoped, http.MethodPost, "/exploit-rules/hosts/:hostId/rules/:ruleId/exclude", map[string]string{"hostId": "out-scope", "ruleId": "r1"}, http.StatusForbidden},
+		{"in-scope exploit rule host", scoped, http.MethodPost, "/exploit-rules/hosts/:hostId/rules/:ruleId/exclude", map[string]string{"hostId": "in-scope", "ruleId": "r1"}, http.StatusOK},
+		{"unknown host reaches the handler", scoped, http.MethodPut, "/proxy-hosts/:id/geo", map[string]string{"id": "missing"}, http.StatusOK},
+		{"scope-aware write", scoped, http.MethodPost, "/proxy-hosts", nil, http.StatusOK},
+		{"write to another host resource", scoped, http.MethodPut, "/upstreams/:id", map[string]string{"id": "u1"}, http.StatusForbidden},
+		{"write to a security resource", scoped, http.MethodPost, "/waf/global/rules/:ruleId/disable", map[string]string{"ruleId": "942100"}, http.StatusForbidden},
+		{"read of another host resource", scoped, http.MethodGet, "/upstreams/:id", map[string]string{"id": "u1"}, http.StatusForbidden},
+		{"banned IP list", scoped, http.MethodGet, "/banned-ips", nil, http.StatusForbidden},
+		{"WAF global rules", scoped, http.MethodGet, "/waf/global/rules", nil, http.StatusForbidden},
+		{"exploit rule list", scoped, http.MethodGet, "/exploit-rules", nil, http.StatusForbidden},
+		{"challenge config", scoped, http.MethodHead, "/challenge-config", nil, http.StatusForbidden},
+		{"scope-aware list", scoped, http.MethodGet, "/proxy-hosts", nil, http.StatusOK},
+		{"scope-aware list via HEAD", scoped, http.MethodHead, "/certificates", nil, http.StatusOK},
+		{"read outside the scoped families", scoped, http.MethodGet, "/settings", nil, http.StatusOK},
+		{"unscoped token", &model.APIToken{}, http.MethodPut, "/proxy-hosts/:id/geo", map[string]string{"id": "out-scope"}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		e := echo.New()
+		c := e.NewContext(httptest.NewRequest(tt.method, apiPrefix+tt.path, nil), httptest.NewRecorder())
+		c.SetPath(apiPrefix + tt.path)
+		var names, values []string
+		for name, value := range tt.params {
+			names = append(names, name)
+			values = append(values, value)
+		}
+		c.SetParamNames(names...)
+		c.SetParamValues(values...)
+		c.Set("api_token", tt.token)
+
+		handler := TokenScope(hosts)(func(c echo.Context) error {
+			return c.NoContent(http.StatusOK)
+		})
+		if err := handler(c); err != nil {
+			t.Fatalf("%s: error = %v", tt.name, err)
+		}
+		if got := c.Response().Status; got != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
@@ -4,14 +4,15 @@ import "time"
 
 // AccessList represents a reusable access control list
 type AccessList struct {
-	ID          string           `json:"id"`
-	Name        string           `json:"name"`
-	Description string           `json:"description,omitempty"`
-	SatisfyAny  bool             `json:"satisfy_any"` // true = any rule match, false = all must match
-	PassAuth    bool             `json:"pass_auth"`   // Allow authenticated users to bypass
-	Items       []AccessListItem `json:"items,omitempty"`
-	CreatedAt   time.Time        `json:"created_at"`
-	UpdatedAt   time.Time        `json:"updated_at"`
+	ID          string               `json:"id"`
+	Name        string               `json:"name"`
+	Description string               `json:"description,omitempty"`
+	SatisfyAny  bool                 `json:"satisfy_any"` // true = any rule match, false = all must match
+	PassAuth    bool                 `json:"pass_auth"`   // Allow authenticated users to bypass
+	Items       []AccessListItem     `json:"items,omitempty"`
+	AuthUsers   []AccessListAuthUser `json:"auth_users,omitempty"`
+	CreatedAt   time.Time            `json:"created_at"`
+	UpdatedAt   time.Time            `json:"updated_at"`
 }
 
 // AccessListItem represents a single allow/deny rule
@@ -25,13 +26,23 @@ type AccessListItem struct {
 	CreatedAt    time.Time `json:"created_at"`
 }
 
+// AccessListAuthUser is an HTTP Basic Auth user of an access list
+type AccessListAuthUser struct {
+	ID           string    `json:"id"`
+	AccessListID string    `json:"access_list_id"`
+	Username     string    `json:"username"`
+	PasswordHash string    `json:"-"` // bcrypt, never returned by the API
+	CreatedAt    time.Time `json:"created_at"`
+}
+
 // CreateAccessListRequest is the request to create an access list
 type CreateAccessListRequest struct {
-	Name        string                       `json:"name" validate:"required,min=1,max=255"`
-	Description string                       `json:"description,omitempty"`
-	SatisfyAny  *bool                        `json:"satisfy_any,omitempty"`
-	PassAuth    *bool                        `json:"pass_auth,omitempty"`
+	Name        string                        `json:"name" validate:"required,min=1,max=255"`
+	Description string                        `json:"description,omitempty"`
+	SatisfyAny  *bool                         `json:"satisfy_any,omitempty"`
+	PassAuth    *bool                         `json:"pass_auth,omitempty"`
 	Items       []CreateAccessListItemRequest `json:"items,omitempty"`
+	AuthUsers   []AccessListAuthUserRequest   `json:"auth_users,omitempty"`
 }
 
 // CreateAccessListItemRequest is the request to create an access list item
@@ -42,6 +53,14 @@ type CreateAccessListItemRequest struct {
 	SortOrder   int    `json:"sort_order,omitempty"`
 }
 
+// AccessListAuthUserRequest is a Basic Auth user in a create/update request
+// On update an empty password keeps the user's current password
+type AccessListAuthUserRequest struct {
+	Username     string `json:"username"`
+	Password     string `json:"password,omitempty"`
+	PasswordHash string `json:"-"` // set by the handler
+}
+
 // UpdateAccessListRequest is the request to update an access list
 type UpdateAccessListRequest struct {
 	Name        *string                       `json:"name,omitempty"`
@@ -49,6 +68,7 @@ type UpdateAccessListRequest struct {
 	SatisfyAny  *bool                         `json:"satisfy_any,omitempty"`
 	PassAuth    *bool                         `json:"pass_auth,omitempty"`
 	Items       []CreateAccessListItemRequest `json:"items,omitempty"`
+	AuthUsers   []AccessListAuthUserRequest   `json:"auth_users,omitempty"` // replaces all users when set
 }
 
 // AccessListListResponse is the response for listing access lists
@@ -0,0 +1,28 @@
+package model
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestAccessListJSONOmitsPasswordHash(t *testing.T) {
+	list := AccessList{
+		ID:   "list-1",
+		Name: "Staff",
+		AuthUsers: []AccessListAuthUser{
+			{ID: "user-1", Username: "alice", PasswordHash: "$2a$10$secrethash"},
+		},
+	}
+
+	data, err := json.Marshal(list)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if strings.Contains(string(data), "secrethash") || strings.Contains(string(data), "password") {
+		t.Errorf("access list JSON exposes the password hash: %s", data)
+	}
+	if !strings.Contains(string(data), `"username":"alice"`) {
+		t.Errorf("access list JSON is missing the user: %s", data)
+	}
+}
@@ -0,0 +1,51 @@
+package model
+
+import "time"
+
+// AdminSSOSettings configures logging in to the admin UI and API with an OIDC
+// identity provider (one of the providers also used by the login gate)
+type AdminSSOSettings struct {
+	ID                  string                `json:"id"`
+	Enabled             bool                  `json:"enabled"`
+	ProviderID          string                `json:"provider_id"`
+	RoleClaim           string                `json:"role_claim"` // ID token claim mapped to roles, defaults to the provider's groups claim
+	RoleMappings        []AdminSSORoleMapping `json:"role_mappings"`
+	DefaultRole         string                `json:"default_role"` // Role when no mapping matches, empty denies the login
+	AutoProvision       bool                  `json:"auto_provision"`
+	AllowedEmailDomains []string              `json:"allowed_email_domains"`
+	DisableLocalLogin   bool                  `json:"disable_local_login"`  // Password login is refused except for the break-glass account
+	BreakGlassUsername  string                `json:"break_glass_username"` // Local account that can always log in with its password
+	CreatedAt           time.Time             `json:"created_at"`
+	UpdatedAt           time.Time             `json:"updated_at"`
+}
+
+// AdminSSORoleMapping grants a role to users whose role claim holds the value
+type AdminSSORoleMapping struct {
+	ClaimValue string `json:"claim_value"`
+	Role       string `json:"role"`
+}
+
+// UpdateAdminSSOSettingsRequest for updating the admin SSO configuration
+type UpdateAdminSSOSettingsRequest struct {
+	Enabled             *bool                  `json:"enabled,omitempty"`
+	ProviderID          *string                `json:"provider_id,omitempty"`
+	RoleClaim           *string                `json:"role_claim,omitempty"`
+	RoleMappings        *[]AdminSSORoleMapping `json:"role_mappings,omitempty"`
+	DefaultRole         *string                `json:"default_role,omitempty"`
+	AutoProvision       *bool                  `json:"auto_provision,omitempty"`
+	AllowedEmailDomains *[]string              `json:"allowed_email_domains,omitempty"`
+	DisableLocalLogin   *bool                  `json:"disable_local_login,omitempty"`
+	BreakGlassUsername  *string                `json:"break_glass_username,omitempty"`
+}
+
+// AdminSSOStatus is shown on the login page before authentication
+type AdminSSOStatus struct {
+	Enabled           bool   `json:"enabled"`
+	ProviderName      string `json:"provider_name,omitempty"`
+	LocalLoginEnabled bool   `json:"local_login_enabled"`
+}
+
+// SSOExchangeRequest redeems the one-time code the SSO callback hands to the UI
+type SSOExchangeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
@@ -47,7 +47,34 @@ const (
 	PermissionBackupCreate  = "backup:create"
 	PermissionBackupRestore = "backup:restore"
 
-	PermissionUserRead = "user:read"
+	PermissionUserRead  = "user:read"
+	PermissionUserWrite = "user:write"
+
+	PermissionAccessListRead   = "access_list:read"
+	PermissionAccessListWrite  = "access_list:write"
+	PermissionAccessListDelete = "access_list:delete"
+
+	PermissionRedirectRead   = "redirect:read"
+	PermissionRedirectWrite  = "redirect:write"
+	PermissionRedirectDelete = "redirect:delete"
+
+	PermissionDNSProviderRead   = "dns_provider:read"
+	PermissionDNSProviderWrite  = "dns_provider:write"
+	PermissionDNSProviderDelete = "dns_provider:delete"
+
+	PermissionBannedIPRead  = "banned_ip:read"
+	PermissionBannedIPWrite = "banned_ip:write"
+
+	PermissionExploitRuleRead  = "exploit_rule:read"
+	PermissionExploitRuleWrite = "exploit_rule:write"
+
+	PermissionChallengeRead  = "challenge:read"
+	PermissionChallengeWrite = "challenge:write"
+
+	PermissionCloudProviderRead  = "cloud_provider:read"
+	PermissionCloudProviderWrite = "cloud_provider:write"
+
+	PermissionAuditRead = "audit:read"
 )
 
 // AllPermissions lists all available permissions
@@ -58,7 +85,15 @@ var AllPermissions = []string{
 	PermissionLogsRead,
 	PermissionSettingsRead, PermissionSettingsWrite,
 	PermissionBackupRead, PermissionBackupCreate, PermissionBackupRestore,
-	PermissionUserRead,
+	PermissionUserRead, PermissionUserWrite,
+	PermissionAccessListRead, PermissionAccessListWrite, PermissionAccessListDelete,
+	PermissionRedirectRead, PermissionRedirectWrite, PermissionRedirectDelete,
+	PermissionDNSProviderRead, PermissionDNSProviderWrite, PermissionDNSProviderDelete,
+	PermissionBannedIPRead, PermissionBannedIPWrite,
+	PermissionExploitRuleRead, PermissionExploitRuleWrite,
+	PermissionChallengeRead, PermissionChallengeWrite,
+	PermissionCloudProviderRead, PermissionCloudProviderWrite,
+	PermissionAuditRead,
 }
 
 // PermissionGroups provides convenient permission groupings
@@ -66,6 +101,9 @@ var PermissionGroups = map[string][]string{
 	"read_only": {
 		PermissionProxyRead, PermissionCertRead, PermissionWAFRead,
 		PermissionLogsRead, PermissionSettingsRead, PermissionBackupRead,
+		PermissionAccessListRead, PermissionRedirectRead, PermissionDNSProviderRead,
+		PermissionBannedIPRead, PermissionExploitRuleRead,
+		PermissionChallengeRead, PermissionCloudProviderRead,
 	},
 	"operator": {
 		PermissionProxyRead, PermissionProxyWrite,
@@ -74,6 +112,13 @@ var PermissionGroups = map[string][]string{
 		PermissionLogsRead,
 		PermissionSettingsRead,
 		PermissionBackupRead, PermissionBackupCreate,
+		PermissionAccessListRead, PermissionAccessListWrite,
+		PermissionRedirectRead, PermissionRedirectWrite,
+		PermissionDNSProviderRead, PermissionDNSProviderWrite,
+		PermissionBannedIPRead, PermissionBannedIPWrite,
+		PermissionExploitRuleRead, PermissionExploitRuleWrite,
+		PermissionChallengeRead, PermissionChallengeWrite,
+		PermissionCloudProviderRead, PermissionCloudProviderWrite,
 	},
 	"admin": {PermissionAll},
 }
@@ -94,11 +139,16 @@ type APIToken struct {
 	IsActive    bool           `json:"is_active" db:"is_active"`
 	RevokedAt   *time.Time     `json:"revoked_at,omitempty" db:"revoked_at"`
 	RevokedReason *string      `json:"revoked_reason,omitempty" db:"revoked_reason"`
+	Scope       APITokenScope  `json:"scope" db:"resource_scope"`
 	CreatedAt   time.Time      `json:"created_at" db:"created_at"`
 	UpdatedAt   time.Time      `json:"updated_at" db:"updated_at"`
 
 	// Joined fields
 	Username string `json:"username,omitempty" db:"username"`
+
+	// Current state of the owner, loaded when authenticating with the token
+	OwnerRole     string `json:"-" db:"role"`
+	OwnerDisabled bool   `json:"-" db:"disabled"`
 }
 
 type APITokenResponse struct {
@@ -114,6 +164,7 @@ type APITokenResponse struct {
 	UseCount    int64     `json:"use_count"`
 	IsActive    bool      `json:"is_active"`
 	IsExpired   bool      `json:"is_expired"`
+	Scope       *APITokenScope `json:"scope,omitempty"`
 	CreatedAt   string    `json:"created_at"`
 	Username    string    `json:"username,omitempty"`
 }
@@ -130,6 +181,7 @@ type CreateAPITokenRequest struct {
 	AllowedIPs  []string `json:"allowed_ips,omitempty"`
 	RateLimit   *int     `json:"rate_limit,omitempty"`
 	ExpiresIn   *string  `json:"expires_in,omitempty"` // e.g., "30d", "1y", "never"
+	Scope       *APITokenScope `json:"scope,omitempty"`
 }
 
 type UpdateAPITokenRequest struct {
@@ -138,6 +190,7 @@ type UpdateAPITokenRequest struct {
 	AllowedIPs  []string `json:"allowed_ips,omitempty"`
 	RateLimit   *int     `json:"rate_limit,omitempty"`
 	IsActive    *bool    `json:"is_active,omitempty"`
+	Scope       *APITokenScope `json:"scope,omitempty"` // An empty scope removes the restriction
 }
 
 type RevokeAPITokenRequest struct {
@@ -201,6 +254,10 @@ func (t *APIToken) ToResponse() APITokenResponse {
 		Username:    t.Username,
 	}
 
+	if !t.Scope.IsEmpty() {
+		scope := t.Scope
+		resp.Scope = &scope
+	}
 	if t.ExpiresAt != nil {
 		s := t.ExpiresAt.Format(time.RFC3339)
 		resp.ExpiresAt = &s
@@ -214,9 +271,16 @@ func (t *APIToken) ToResponse() APITokenResponse {
 	return resp
 }
 
-// HasPermission checks if the token has a specific permission
+// HasPermission checks if the token has a specific permission. A token
+// never grants more than the current role of its owner, so demoting the
+// owner narrows the token too.
 func (t *APIToken) HasPermission(required string) bool {
-	for _, p := range t.Permissions {
+	return HasPermission(t.Permissions, required) && RoleHasPermission(t.OwnerRole, required)
+}
+
+// HasPermission checks if a set of granted permissions includes the required one
+func HasPermission(granted []string, required string) bool {
+	for _, p := range granted {
 		if p == PermissionAll || p == required {
 			return true
 		}
@@ -0,0 +1,180 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+var (
+	scopeHostIDPattern = regexp.MustCompile(`^[0-9a-fA-F-]{36}$`)
+	scopeDomainPattern = regexp.MustCompile(`^[a-z0-9*?.-]+$`)
+	scopeTagPattern    = regexp.MustCompile(`^[a-z0-9][a-z0-9._:-]{0,62}$`)
+)
+
+type tokenScopeContextKey struct{}
+
+// APITokenScope restricts an API token to a subset of the proxy hosts. A host
+// is in scope when its ID is listed, when it carries one of the tags, or when
+// every one of its domain names matches one of the domain globs. An empty
+// scope leaves the token unrestricted.
+type APITokenScope struct {
+	ProxyHostIDs []string `json:"proxy_host_ids,omitempty"`
+	Domains      []string `json:"domains,omitempty"` // Globs such as "*.app.example.com"
+	Tags         []string `json:"tags,omitempty"`
+}
+
+// IsEmpty reports whether the scope places no restriction
+func (s APITokenScope) IsEmpty() bool {
+	return len(s.ProxyHostIDs) == 0 && len(s.Domains) == 0 && len(s.Tags) == 0
+}
+
+// Normalize lowercases and validates the scope entries
+func (s APITokenScope) Normalize() (APITokenScope, error) {
+	var out APITokenScope
+	for _, id := range s.ProxyHostIDs {
+		id = strings.ToLower(strings.TrimSpace(id))
+		if !scopeHostIDPattern.MatchString(id) {
+			return out, fmt.Errorf("invalid proxy host ID in scope: %q", id)
+		}
+		out.ProxyHostIDs = appendScopeEntry(out.ProxyHostIDs, id)
+	}
+	for _, domain := range s.Domains {
+		domain = strings.ToLower(strings.TrimSpace(domain))
+		if _, err := path.Match(domain, ""); err != nil || !scopeDomainPattern.MatchString(domain) {
+			return out, fmt.Errorf("invalid domain glob in scope: %q", domain)
+		}
+		out.Domains = appendScopeEntry(out.Domains, domain)
+	}
+	for _, tag := range s.Tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if !scopeTagPattern.MatchString(tag) {
+			return out, fmt.Errorf("invalid tag in scope: %q", tag)
+		}
+		out.Tags = appendScopeEntry(out.Tags, tag)
+	}
+	return out, nil
+}
+
+// Equal reports whether two normalized scopes hold the same entries
+func (s APITokenScope) Equal(other APITokenScope) bool {
+	return sameScopeEntries(s.ProxyHostIDs, other.ProxyHostIDs) &&
+		sameScopeEntries(s.Domains, other.Domains) &&
+		sameScopeEntries(s.Tags, other.Tags)
+}
+
+// AllowsHost reports whether a proxy host is in scope. The host may be one
+// that is about to be created, in which case only domains and tags count.
+func (s APITokenScope) AllowsHost(host *ProxyHost) bool {
+	if s.IsEmpty() {
+		return true
+	}
+	if host == nil {
+		return false
+	}
+	for _, id := range s.ProxyHostIDs {
+		if host.ID != "" && strings.EqualFold(id, host.ID) {
+			return true
+		}
+	}
+	for _, tag := range host.Tags {
+		for _, scoped := range s.Tags {
+			if strings.EqualFold(tag, scoped) {
+				return true
+			}
+		}
+	}
+	return s.AllowsDomains(host.DomainNames)
+}
+
+// AllowsDomains reports whether every domain matches one of the domain globs
+func (s APITokenScope) AllowsDomains(domains []string) bool {
+	if s.IsEmpty() {
+		return true
+	}
+	if len(domains) == 0 || len(s.Domains) == 0 {
+		return false
+	}
+	for _, domain := range domains {
+		if !s.matchesDomain(strings.ToLower(domain)) {
+			return false
+		}
+	}
+	return true
+}
+
+// AllowsTags reports whether a scoped token may put tags on a proxy host:
+// only tags of the scope, or ones the host already carries, so a token can't
+// place a host in the scope of other tokens
+func (s APITokenScope) AllowsTags(tags, current []string) bool {
+	if s.IsEmpty() {
+		return true
+	}
+	for _, tag := range tags {
+		if !containsFold(s.Tags, tag) && !containsFold(current, tag) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsFold(entries []string, entry string) bool {
+	for _, e := range entries {
+		if strings.EqualFold(e, entry) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s APITokenScope) matchesDomain(domain string) bool {
+	for _, glob := range s.Domains {
+		if ok, _ := path.Match(glob, domain); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func appendScopeEntry(entries []string, entry string) []string {
+	for _, e := range entries {
+		if e == entry {
+			return entries
+		}
+	}
+	return append(entries, entry)
+}
+
+func sameScopeEntries(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, entry := range a {
+		found := false
+		for _, other := range b {
+			if entry == other {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// ContextWithTokenScope attaches the scope of the API token making a request
+// to its context, so handlers that only see the *http.Request can enforce it
+func ContextWithTokenScope(ctx context.Context, scope APITokenScope) context.Context {
+	return context.WithValue(ctx, tokenScopeContextKey{}, scope)
+}
+
+// TokenScopeFromContext returns the API token scope attached to a request
+// context; ok is false when the request isn't restricted by a scope
+func TokenScopeFromContext(ctx context.Context) (scope APITokenScope, ok bool) {
+	scope, ok = ctx.Value(tokenScopeContextKey{}).(APITokenScope)
+	return scope, ok && !scope.IsEmpty()
+}
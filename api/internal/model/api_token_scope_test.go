@@ -0,0 +1,82 @@
+package model
+
+import "testing"
+
+func TestAPITokenScopeAllowsHost(t *testing.T) {
+	const hostID = "3f0c8a52-6d3e-4c1b-9a57-0d4f2b1e8c90"
+	host := &ProxyHost{
+		ID:          hostID,
+		DomainNames: []string{"api.app.example.com", "www.app.example.com"},
+		Tags:        []string{"Team-Payments"},
+	}
+
+	tests := []struct {
+		name  string
+		scope APITokenScope
+		host  *ProxyHost
+		want  bool
+	}{
+		{"empty scope", APITokenScope{}, host, true},
+		{"listed ID", APITokenScope{ProxyHostIDs: []string{"3F0C8A52-6D3E-4C1B-9A57-0D4F2B1E8C90"}}, host, true},
+		{"other ID", APITokenScope{ProxyHostIDs: []string{"00000000-0000-0000-0000-000000000000"}}, host, false},
+		{"ID of a host not created yet", APITokenScope{ProxyHostIDs: []string{hostID}}, &ProxyHost{DomainNames: host.DomainNames}, false},
+		{"tag", APITokenScope{Tags: []string{"team-payments"}}, host, true},
+		{"other tag", APITokenScope{Tags: []string{"team-search"}}, host, false},
+		{"every domain matches", APITokenScope{Domains: []string{"*.app.example.com"}}, host, true},
+		{"one domain outside", APITokenScope{Domains: []string{"api.app.example.com"}}, host, false},
+		{"nil host", APITokenScope{Tags: []string{"team-payments"}}, nil, false},
+	}
+	for _, tt := range tests {
+		if got := tt.scope.AllowsHost(tt.host); got != tt.want {
+			t.Errorf("%s: AllowsHost() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAPITokenScopeAllowsDomains(t *testing.T) {
+	scope := APITokenScope{Domains: []string{"*.app.example.com", "shop.example.com"}}
+
+	tests := []struct {
+		name    string
+		scope   APITokenScope
+		domains []string
+		want    bool
+	}{
+		{"all domains match", scope, []string{"api.app.example.com", "SHOP.example.com"}, true},
+		{"one domain outside", scope, []string{"api.app.example.com", "admin.example.com"}, false},
+		{"no domains", scope, nil, false},
+		{"scope without domain globs", APITokenScope{Tags: []string{"team-payments"}}, []string{"shop.example.com"}, false},
+		{"empty scope", APITokenScope{}, nil, true},
+		// path.Match's * stops at "/" only, so it crosses dots
+		{"glob crosses dots", scope, []string{"a.b.app.example.com"}, true},
+		{"glob needs the suffix", scope, []string{"app.example.com.evil.test"}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.scope.AllowsDomains(tt.domains); got != tt.want {
+			t.Errorf("%s: AllowsDomains(%v) = %v, want %v", tt.name, tt.domains, got, tt.want)
+		}
+	}
+}
+
+func TestAPITokenScopeAllowsTags(t *testing.T) {
+	scope := APITokenScope{Tags: []string{"ci"}}
+	tests := []struct {
+		name    string
+		tags    []string
+		current []string
+		want    bool
+	}{
+		{"scope tag", []string{"CI"}, nil, true},
+		{"no tags", nil, nil, true},
+		{"tag outside the scope", []string{"ci", "prod"}, nil, false},
+		{"tag the host already carries", []string{"ci", "legacy"}, []string{"legacy"}, true},
+	}
+	for _, tt := range tests {
+		if got := scope.AllowsTags(tt.tags, tt.current); got != tt.want {
+			t.Errorf("%s: AllowsTags(%v) = %v, want %v", tt.name, tt.tags, got, tt.want)
+		}
+	}
+	if !(APITokenScope{}).AllowsTags([]string{"prod"}, nil) {
+		t.Error("empty scope should allow every tag")
+	}
+}
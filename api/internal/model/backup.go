@@ -211,6 +211,29 @@ type ExportData struct {
 	// Security: URI Blocks (per proxy host)
 	URIBlocks []URIBlockExport `json:"uri_blocks,omitempty"`
 
+	// Security: Forward auth (per proxy host)
+	ForwardAuthConfigs []ForwardAuthExport `json:"forward_auth_configs,omitempty"`
+
+	// Security: OIDC identity providers and per proxy host login gates
+	OIDCProviders []OIDCProviderExport `json:"oidc_providers,omitempty"`
+	OIDCHostGates []OIDCHostGateExport `json:"oidc_host_gates,omitempty"`
+
+	// Security: Client CAs and per proxy host client certificate authentication (mTLS)
+	ClientCAs             []ClientCAExport             `json:"client_cas,omitempty"`
+	ClientCertAuthConfigs []ClientCertAuthConfigExport `json:"client_cert_auth_configs,omitempty"`
+
+	// Security: Per proxy host backend TLS verification
+	UpstreamTLSConfigs []UpstreamTLSConfigExport `json:"upstream_tls_configs,omitempty"`
+
+	// Security: Per proxy host TLS policies
+	TLSPolicies []TLSPolicyExport `json:"tls_policies,omitempty"`
+
+	// Security: Per proxy host CORS policies
+	CORSPolicies []CORSPolicyExport `json:"cors_policies,omitempty"`
+
+	// Security: Per proxy host signed URL locations
+	SignedURLLocations []SignedURLLocationExport `json:"signed_url_locations,omitempty"`
+
 	// Security: Global URI Blocks
 	GlobalURIBlock *GlobalURIBlockExport `json:"global_uri_block,omitempty"`
 
@@ -322,6 +345,7 @@ type ProxyHostData struct {
 	WAFMode               string                 `json:"waf_mode,omitempty"`
 	AccessListID          string                 `json:"access_list_id,omitempty"`
 	Enabled               bool                   `json:"enabled"`
+	Tags                  []string               `json:"tags,omitempty"`
 	Meta                  map[string]interface{} `json:"meta,omitempty"`
 }
 
@@ -423,12 +447,13 @@ type DNSProviderExport struct {
 
 // AccessListData represents access list data for export
 type AccessListData struct {
-	ID          string               `json:"id"`
-	Name        string               `json:"name"`
-	Description string               `json:"description,omitempty"`
-	SatisfyAny  bool                 `json:"satisfy_any"`
-	PassAuth    bool                 `json:"pass_auth"`
-	Items       []AccessListItemData `json:"items,omitempty"`
+	ID          string                   `json:"id"`
+	Name        string                   `json:"name"`
+	Description string                   `json:"description,omitempty"`
+	SatisfyAny  bool                     `json:"satisfy_any"`
+	PassAuth    bool                     `json:"pass_auth"`
+	Items       []AccessListItemData     `json:"items,omitempty"`
+	AuthUsers   []AccessListAuthUserData `json:"auth_users,omitempty"`
 }
 
 // AccessListItemData represents access list item for export
@@ -439,6 +464,12 @@ type AccessListItemData struct {
 	SortOrder   int    `json:"sort_order"`
 }
 
+// AccessListAuthUserData represents an access list Basic Auth user for export
+type AccessListAuthUserData struct {
+	Username     string `json:"username"`
+	PasswordHash string `json:"password_hash"`
+}
+
 // AccessListExport represents an access list for export
 type AccessListExport struct {
 	AccessList AccessListData `json:"access_list"`
@@ -513,6 +544,115 @@ type URIBlockExport struct {
 	AllowPrivateIPs bool          `json:"allow_private_ips"`
 }
 
+// ForwardAuthExport represents forward auth settings for a proxy host
+type ForwardAuthExport struct {
+	ProxyHostID     string   `json:"proxy_host_id"`
+	Enabled         bool     `json:"enabled"`
+	AuthURL         string   `json:"auth_url"`
+	SigninURL       string   `json:"signin_url,omitempty"`
+	ResponseHeaders []string `json:"response_headers"`
+	BypassPaths     []string `json:"bypass_paths"`
+	ProtectHost     bool     `json:"protect_host"`
+}
+
+// OIDCProviderExport represents an OIDC identity provider for export
+type OIDCProviderExport struct {
+	ID           string   `json:"id"`
+	Name         string   `json:"name"`
+	IssuerURL    string   `json:"issuer_url"`
+	ClientID     string   `json:"client_id"`
+	ClientSecret string   `json:"client_secret,omitempty"`
+	Scopes       []string `json:"scopes"`
+	GroupsClaim  string   `json:"groups_claim"`
+	Enabled      bool     `json:"enabled"`
+}
+
+// OIDCHostGateExport represents the OIDC login gate of a proxy host
+type OIDCHostGateExport struct {
+	ProxyHostID         string            `json:"proxy_host_id"`
+	ProviderID          string            `json:"provider_id"`
+	Enabled             bool              `json:"enabled"`
+	AllowedEmailDomains []string          `json:"allowed_email_domains"`
+	AllowedGroups       []string          `json:"allowed_groups"`
+	RequiredClaims      map[string]string `json:"required_claims"`
+	SessionLifetime     int               `json:"session_lifetime"`
+}
+
+// ClientCAExport represents a client CA bundle for export
+type ClientCAExport struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	CAPEM       string `json:"ca_pem"`
+	CRLPEM      string `json:"crl_pem,omitempty"`
+}
+
+// ClientCertAuthConfigExport represents the client certificate authentication of a proxy host
+type ClientCertAuthConfigExport struct {
+	ProxyHostID    string   `json:"proxy_host_id"`
+	ClientCAID     string   `json:"client_ca_id"`
+	Enabled        bool     `json:"enabled"`
+	VerifyMode     string   `json:"verify_mode"`
+	VerifyDepth    int      `json:"verify_depth"`
+	ForwardHeaders bool     `json:"forward_headers"`
+	EnforcePaths   []string `json:"enforce_paths"`
+}
+
+// UpstreamTLSConfigExport represents the backend TLS settings of a proxy host
+type UpstreamTLSConfigExport struct {
+	ProxyHostID         string  `json:"proxy_host_id"`
+	Enabled             bool    `json:"enabled"`
+	Verify              bool    `json:"verify"`
+	TrustedCAPEM        string  `json:"trusted_ca_pem,omitempty"`
+	VerifyDepth         int     `json:"verify_depth"`
+	ServerName          string  `json:"server_name,omitempty"`
+	SNI                 bool    `json:"sni"`
+	ClientCertificateID *string `json:"client_certificate_id,omitempty"`
+}
+
+// TLSPolicyExport represents the TLS policy of a proxy host
+type TLSPolicyExport struct {
+	ProxyHostID           string   `json:"proxy_host_id"`
+	Profile               string   `json:"profile"`
+	Protocols             []string `json:"protocols"`
+	Ciphers               string   `json:"ciphers,omitempty"`
+	Curves                string   `json:"curves,omitempty"`
+	PreferServerCiphers   bool     `json:"prefer_server_ciphers"`
+	SessionTickets        bool     `json:"session_tickets"`
+	OCSPStapling          bool     `json:"ocsp_stapling"`
+	HSTSEnabled           bool     `json:"hsts_enabled"`
+	HSTSMaxAge            int      `json:"hsts_max_age"`
+	HSTSIncludeSubdomains bool     `json:"hsts_include_subdomains"`
+	HSTSPreload           bool     `json:"hsts_preload"`
+}
+
+// CORSPolicyExport represents a CORS policy of a proxy host path
+type CORSPolicyExport struct {
+	ProxyHostID           string   `json:"proxy_host_id"`
+	PathPrefix            string   `json:"path_prefix,omitempty"`
+	Enabled               bool     `json:"enabled"`
+	AllowedOrigins        []string `json:"allowed_origins"`
+	AllowedOriginPatterns []string `json:"allowed_origin_patterns,omitempty"`
+	AllowedMethods        []string `json:"allowed_methods"`
+	AllowedHeaders        []string `json:"allowed_headers,omitempty"`
+	ExposedHeaders        []string `json:"exposed_headers,omitempty"`
+	AllowCredentials      bool     `json:"allow_credentials"`
+	MaxAge                int      `json:"max_age"`
+}
+
+// SignedURLLocationExport represents a signed URL protected path of a proxy host
+type SignedURLLocationExport struct {
+	ProxyHostID    string `json:"proxy_host_id"`
+	PathPrefix     string `json:"path_prefix"`
+	Enabled        bool   `json:"enabled"`
+	Secret         string `json:"secret"`
+	HashExpression string `json:"hash_expression"`
+	TokenParam     string `json:"token_param"`
+	ExpiresParam   string `json:"expires_param"`
+	DefaultTTL     int    `json:"default_ttl"`
+	MaxTTL         int    `json:"max_ttl"`
+}
+
 // GlobalURIBlockExport represents global URI blocking settings
 type GlobalURIBlockExport struct {
 	Enabled         bool          `json:"enabled"`
@@ -646,6 +786,18 @@ type SystemSettingsExport struct {
 	WAFAutoBanWindow    int  `json:"waf_auto_ban_window"`
 	WAFAutoBanDuration  int  `json:"waf_auto_ban_duration"`
 
+	// Ban Escalation Settings
+	BanEscalationEnabled bool    `json:"ban_escalation_enabled"`
+	BanEscalationSteps   []int64 `json:"ban_escalation_steps,omitempty"`
+	BanEscalationWindow  int     `json:"ban_escalation_window,omitempty"`
+
+	// Subnet Aggregation Settings
+	BanSubnetAggregationEnabled bool `json:"ban_subnet_aggregation_enabled"`
+	BanSubnetThreshold          int  `json:"ban_subnet_threshold,omitempty"`
+	BanSubnetIPv4Prefix         int  `json:"ban_subnet_ipv4_prefix,omitempty"`
+	BanSubnetIPv6Prefix         int  `json:"ban_subnet_ipv6_prefix,omitempty"`
+	BanSubnetWindow             int  `json:"ban_subnet_window,omitempty"`
+
 	// Direct IP Access
 	DirectIPAccessAction string `json:"direct_ip_access_action"`
 
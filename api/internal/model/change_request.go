@@ -0,0 +1,103 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// High-risk actions that can require approval by a second user
+const (
+	ChangeActionBackupRestore     = "backup_restore"
+	ChangeActionCertificateDelete = "certificate_delete"
+	ChangeActionWAFDisable        = "waf_disable"
+	ChangeActionGlobalSettings    = "global_settings"
+
+	// Weakening the approval settings while they are enabled always needs
+	// approval, so it isn't one of the selectable ChangeActions
+	ChangeActionApprovalPolicy = "approval_policy"
+)
+
+// ChangeActions lists the actions that can require approval
+var ChangeActions = []string{
+	ChangeActionBackupRestore,
+	ChangeActionCertificateDelete,
+	ChangeActionWAFDisable,
+	ChangeActionGlobalSettings,
+}
+
+// IsChangeAction reports whether action is one of ChangeActions
+func IsChangeAction(action string) bool {
+	for _, a := range ChangeActions {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
+
+// Change request states. A pending request is approved and then applied or
+// failed, or it ends rejected, cancelled by its requester, or expired.
+const (
+	ChangeStatusPending   = "pending"
+	ChangeStatusApproved  = "approved" // Being applied
+	ChangeStatusApplied   = "applied"
+	ChangeStatusFailed    = "failed"
+	ChangeStatusRejected  = "rejected"
+	ChangeStatusCancelled = "cancelled"
+	ChangeStatusExpired   = "expired"
+)
+
+// ApprovalSettings chooses the actions that are captured as change requests
+type ApprovalSettings struct {
+	ID           string    `json:"id"`
+	Enabled      bool      `json:"enabled"`
+	Actions      []string  `json:"actions"`       // Actions that need approval while enabled
+	ApproverRole string    `json:"approver_role"` // Least privileged role allowed to approve
+	ExpiryHours  int       `json:"expiry_hours"`  // Pending requests expire after this long
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// UpdateApprovalSettingsRequest for updating the approval settings
+type UpdateApprovalSettingsRequest struct {
+	Enabled      *bool     `json:"enabled,omitempty"`
+	Actions      *[]string `json:"actions,omitempty"`
+	ApproverRole *string   `json:"approver_role,omitempty"`
+	ExpiryHours  *int      `json:"expiry_hours,omitempty"`
+}
+
+// ChangeRequest is a high-risk change waiting for, or done with, review.
+// Payload holds everything needed to apply the change once approved.
+type ChangeRequest struct {
+	ID              string          `json:"id"`
+	Action          string          `json:"action"`
+	TargetID        string          `json:"target_id,omitempty"`
+	TargetName      string          `json:"target_name,omitempty"`
+	Payload         json.RawMessage `json:"payload"`
+	Diff            string          `json:"diff"`
+	Status          string          `json:"status"`
+	RequestedBy     string          `json:"requested_by,omitempty"`
+	RequestedByName string          `json:"requested_by_name"`
+	ReviewedBy      string          `json:"reviewed_by,omitempty"`
+	ReviewedByName  string          `json:"reviewed_by_name,omitempty"`
+	ReviewComment   string          `json:"review_comment,omitempty"`
+	ErrorMessage    string          `json:"error_message,omitempty"`
+	ExpiresAt       time.Time       `json:"expires_at"`
+	ReviewedAt      *time.Time      `json:"reviewed_at,omitempty"`
+	AppliedAt       *time.Time      `json:"applied_at,omitempty"`
+	CreatedAt       time.Time       `json:"created_at"`
+}
+
+// ReviewChangeRequest approves, rejects or cancels a change request
+type ReviewChangeRequest struct {
+	Comment string `json:"comment,omitempty"`
+}
+
+// ChangeRequestListResponse for listing change requests
+type ChangeRequestListResponse struct {
+	Data       []ChangeRequest `json:"data"`
+	Total      int             `json:"total"`
+	Page       int             `json:"page"`
+	PerPage    int             `json:"per_page"`
+	TotalPages int             `json:"total_pages"`
+}
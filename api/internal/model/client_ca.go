@@ -0,0 +1,57 @@
+package model
+
+import "time"
+
+// Client certificate verify modes (nginx ssl_verify_client values)
+const (
+	ClientCertVerifyOn           = "on"
+	ClientCertVerifyOptional     = "optional"
+	ClientCertVerifyOptionalNoCA = "optional_no_ca"
+)
+
+// ClientCA is an uploaded CA bundle that client certificates are verified against
+type ClientCA struct {
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	CAPEM       string     `json:"ca_pem"`
+	CRLPEM      string     `json:"crl_pem,omitempty"` // Optional certificate revocation list
+	HasCRL      bool       `json:"has_crl"`
+	Subjects    []string   `json:"subjects"`             // Subjects of the certificates in the bundle
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"` // Earliest expiry of the certificates in the bundle
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// ClientCARequest is the request to create/update a client CA
+// An empty crl_pem removes the CRL
+type ClientCARequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	CAPEM       string `json:"ca_pem"`
+	CRLPEM      string `json:"crl_pem,omitempty"`
+}
+
+// ClientCertAuthConfig requires (or requests) client certificates on a proxy host's HTTPS server
+type ClientCertAuthConfig struct {
+	ID             string    `json:"id"`
+	ProxyHostID    string    `json:"proxy_host_id"`
+	ClientCAID     string    `json:"client_ca_id"`
+	Enabled        bool      `json:"enabled"`
+	VerifyMode     string    `json:"verify_mode"`     // on, optional, optional_no_ca
+	VerifyDepth    int       `json:"verify_depth"`    // Maximum client certificate chain depth
+	ForwardHeaders bool      `json:"forward_headers"` // Send the client DN, fingerprint and verify result upstream
+	EnforcePaths   []string  `json:"enforce_paths"`   // Path prefixes requiring a certificate in the optional modes
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// UpsertClientCertAuthRequest is the request to create/update the mTLS settings of a proxy host
+type UpsertClientCertAuthRequest struct {
+	ClientCAID     string   `json:"client_ca_id"`
+	Enabled        *bool    `json:"enabled,omitempty"`
+	VerifyMode     string   `json:"verify_mode,omitempty"`
+	VerifyDepth    int      `json:"verify_depth,omitempty"`
+	ForwardHeaders bool     `json:"forward_headers"`
+	EnforcePaths   []string `json:"enforce_paths,omitempty"`
+}
@@ -0,0 +1,62 @@
+package model
+
+import "time"
+
+// CORSPolicy is the Cross-Origin Resource Sharing policy of a proxy host, or of the
+// requests below one of its path prefixes (the longest matching prefix wins)
+type CORSPolicy struct {
+	ID                    string    `json:"id"`
+	ProxyHostID           string    `json:"proxy_host_id"`
+	PathPrefix            string    `json:"path_prefix"` // Empty applies to the whole host
+	Enabled               bool      `json:"enabled"`
+	AllowedOrigins        []string  `json:"allowed_origins"`         // Exact origins (https://app.example.com), or * for any origin
+	AllowedOriginPatterns []string  `json:"allowed_origin_patterns"` // Regular expressions matched against the whole origin
+	AllowedMethods        []string  `json:"allowed_methods"`
+	AllowedHeaders        []string  `json:"allowed_headers"`
+	ExposedHeaders        []string  `json:"exposed_headers"`
+	AllowCredentials      bool      `json:"allow_credentials"`
+	MaxAge                int       `json:"max_age"` // Preflight cache lifetime in seconds, 0 = not sent
+	CreatedAt             time.Time `json:"created_at"`
+	UpdatedAt             time.Time `json:"updated_at"`
+}
+
+// UpsertCORSPolicyRequest creates or replaces the CORS policy of a proxy host path
+// With a preset, omitted fields take the preset's values
+type UpsertCORSPolicyRequest struct {
+	Preset                string   `json:"preset,omitempty"`
+	PathPrefix            string   `json:"path_prefix"`
+	Enabled               *bool    `json:"enabled,omitempty"`
+	AllowedOrigins        []string `json:"allowed_origins,omitempty"`
+	AllowedOriginPatterns []string `json:"allowed_origin_patterns,omitempty"`
+	AllowedMethods        []string `json:"allowed_methods,omitempty"`
+	AllowedHeaders        []string `json:"allowed_headers,omitempty"`
+	ExposedHeaders        []string `json:"exposed_headers,omitempty"`
+	AllowCredentials      *bool    `json:"allow_credentials,omitempty"`
+	MaxAge                *int     `json:"max_age,omitempty"`
+}
+
+// Common CORS presets
+var CORSPolicyPresets = map[string]CORSPolicy{
+	"public_read_only": {
+		Enabled:        true,
+		AllowedOrigins: []string{"*"},
+		AllowedMethods: []string{"GET", "HEAD", "OPTIONS"},
+		AllowedHeaders: []string{"Accept", "Accept-Language", "Content-Language", "Content-Type", "Range"},
+		ExposedHeaders: []string{"Content-Length", "Content-Range", "ETag"},
+		MaxAge:         86400,
+	},
+	"public_api": {
+		Enabled:        true,
+		AllowedOrigins: []string{"*"},
+		AllowedMethods: []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders: []string{"Accept", "Authorization", "Content-Type", "X-Requested-With"},
+		MaxAge:         3600,
+	},
+	"credentialed_spa": {
+		Enabled:          true,
+		AllowedMethods:   []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "X-Requested-With"},
+		AllowCredentials: true,
+		MaxAge:           600,
+	},
+}
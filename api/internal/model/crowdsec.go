@@ -0,0 +1,77 @@
+package model
+
+import (
+	"time"
+)
+
+// CrowdSecSettings configures the CrowdSec bouncer (decision pull) and signal sharing (alert push)
+type CrowdSecSettings struct {
+	ID              string     `json:"id"`
+	Enabled         bool       `json:"enabled"`
+	LAPIURL         string     `json:"lapi_url"`
+	APIKey          string     `json:"-"`             // Bouncer API key, not exposed in API responses
+	PollInterval    int        `json:"poll_interval"` // Seconds between decision stream polls
+	PushSignals     bool       `json:"push_signals"`
+	MachineID       string     `json:"machine_id"`
+	MachinePassword string     `json:"-"` // Watcher password, not exposed in API responses
+	LastSyncAt      *time.Time `json:"last_sync_at,omitempty"`
+	LastSyncError   string     `json:"last_sync_error,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+
+	// For API responses - masked secrets
+	HasAPIKey          bool `json:"has_api_key"`
+	HasMachinePassword bool `json:"has_machine_password"`
+}
+
+// UpdateCrowdSecSettingsRequest for updating CrowdSec settings
+// An empty api_key or machine_password keeps the stored secret
+type UpdateCrowdSecSettingsRequest struct {
+	Enabled         *bool   `json:"enabled,omitempty"`
+	LAPIURL         *string `json:"lapi_url,omitempty"`
+	APIKey          *string `json:"api_key,omitempty"`
+	PollInterval    *int    `json:"poll_interval,omitempty"`
+	PushSignals     *bool   `json:"push_signals,omitempty"`
+	MachineID       *string `json:"machine_id,omitempty"`
+	MachinePassword *string `json:"machine_password,omitempty"`
+}
+
+// CrowdSecDecision is a ban decision from the CrowdSec LAPI decision stream
+type CrowdSecDecision struct {
+	ID       int64  `json:"id"`
+	Origin   string `json:"origin"`
+	Type     string `json:"type"`
+	Scope    string `json:"scope"`
+	Value    string `json:"value"`
+	Duration string `json:"duration"`
+	Scenario string `json:"scenario"`
+}
+
+// CrowdSecDecisionStream is the response of GET /v1/decisions/stream
+type CrowdSecDecisionStream struct {
+	New     []CrowdSecDecision `json:"new"`
+	Deleted []CrowdSecDecision `json:"deleted"`
+}
+
+// CrowdSecBan is a validated CrowdSec ban decision ready to be stored in banned_ips
+type CrowdSecBan struct {
+	Value    string
+	Reason   string
+	Duration time.Duration
+}
+
+// CrowdSecSyncResult summarizes a decision stream sync
+type CrowdSecSyncResult struct {
+	Added      int `json:"added"`
+	Deleted    int `json:"deleted"`
+	Skipped    int `json:"skipped"`
+	ActiveBans int `json:"active_bans"`
+}
+
+// CrowdSecTestResult is the result of testing the LAPI connection
+type CrowdSecTestResult struct {
+	BouncerOK    bool   `json:"bouncer_ok"`
+	BouncerError string `json:"bouncer_error,omitempty"`
+	WatcherOK    *bool  `json:"watcher_ok,omitempty"`
+	WatcherError string `json:"watcher_error,omitempty"`
+}
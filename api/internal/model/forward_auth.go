@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+// ForwardAuthConfig delegates authentication of a proxy host to an external service
+// (Authelia, Authentik, oauth2-proxy, Keycloak gatekeeper, ...) via nginx auth_request
+type ForwardAuthConfig struct {
+	ID              string    `json:"id"`
+	ProxyHostID     string    `json:"proxy_host_id"`
+	Enabled         bool      `json:"enabled"`
+	AuthURL         string    `json:"auth_url"`         // Verification endpoint, e.g. http://authelia:9091/api/verify
+	SigninURL       string    `json:"signin_url"`       // Where unauthenticated users are redirected (optional)
+	ResponseHeaders []string  `json:"response_headers"` // Auth response headers copied to the upstream, e.g. Remote-User
+	BypassPaths     []string  `json:"bypass_paths"`     // Path prefixes served without authentication
+	ProtectHost     bool      `json:"protect_host"`     // Protect location /; when false only custom locations that include the snippet are protected
+	IncludePath     string    `json:"include_path"`     // nginx include to add to custom locations in the advanced config
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// UpsertForwardAuthRequest is the request to create/update forward auth settings
+type UpsertForwardAuthRequest struct {
+	Enabled         *bool    `json:"enabled,omitempty"`
+	AuthURL         string   `json:"auth_url"`
+	SigninURL       string   `json:"signin_url,omitempty"`
+	ResponseHeaders []string `json:"response_headers,omitempty"`
+	BypassPaths     []string `json:"bypass_paths,omitempty"`
+	ProtectHost     *bool    `json:"protect_host,omitempty"`
+}
@@ -0,0 +1,82 @@
+package model
+
+import "time"
+
+// LDAPSettings configures authenticating admins against an LDAP directory or
+// Active Directory. Directory users log in with the regular login form next
+// to local accounts; local accounts always take precedence.
+type LDAPSettings struct {
+	ID              string             `json:"id"`
+	Enabled         bool               `json:"enabled"`
+	URL             string             `json:"url"`             // ldap://host:389 or ldaps://host:636
+	StartTLS        bool               `json:"start_tls"`       // Upgrade ldap:// connections with StartTLS
+	SkipTLSVerify   bool               `json:"skip_tls_verify"` // Accept any server certificate (testing only)
+	BindDN          string             `json:"bind_dn"`         // Service account used to search, empty binds anonymously
+	BindPassword    string             `json:"-"`
+	HasBindPassword bool               `json:"has_bind_password"`
+	SearchBase      string             `json:"search_base"`
+	UserFilter      string             `json:"user_filter"`       // {username} is replaced by the escaped login name
+	EmailAttribute  string             `json:"email_attribute"`   // e.g. mail
+	GroupAttribute  string             `json:"group_attribute"`   // User attribute listing group DNs, e.g. memberOf
+	GroupSearchBase string             `json:"group_search_base"` // Also search groups here when the directory has no memberOf
+	GroupFilter     string             `json:"group_filter"`      // {dn} is replaced by the escaped user DN
+	GroupMappings   []LDAPGroupMapping `json:"group_mappings"`
+	DefaultRole     string             `json:"default_role"` // Role when no group matches, empty denies the login
+	AutoProvision   bool               `json:"auto_provision"`
+	Timeout         int                `json:"timeout"` // Seconds
+	CreatedAt       time.Time          `json:"created_at"`
+	UpdatedAt       time.Time          `json:"updated_at"`
+}
+
+// LDAPGroupMapping grants a role to members of a group, given as DN or common name
+type LDAPGroupMapping struct {
+	Group string `json:"group"`
+	Role  string `json:"role"`
+}
+
+// UpdateLDAPSettingsRequest for updating the LDAP configuration
+// An empty bind_password keeps the stored password
+type UpdateLDAPSettingsRequest struct {
+	Enabled         *bool               `json:"enabled,omitempty"`
+	URL             *string             `json:"url,omitempty"`
+	StartTLS        *bool               `json:"start_tls,omitempty"`
+	SkipTLSVerify   *bool               `json:"skip_tls_verify,omitempty"`
+	BindDN          *string             `json:"bind_dn,omitempty"`
+	BindPassword    *string             `json:"bind_password,omitempty"`
+	SearchBase      *string             `json:"search_base,omitempty"`
+	UserFilter      *string             `json:"user_filter,omitempty"`
+	EmailAttribute  *string             `json:"email_attribute,omitempty"`
+	GroupAttribute  *string             `json:"group_attribute,omitempty"`
+	GroupSearchBase *string             `json:"group_search_base,omitempty"`
+	GroupFilter     *string             `json:"group_filter,omitempty"`
+	GroupMappings   *[]LDAPGroupMapping `json:"group_mappings,omitempty"`
+	DefaultRole     *string             `json:"default_role,omitempty"`
+	AutoProvision   *bool               `json:"auto_provision,omitempty"`
+	Timeout         *int                `json:"timeout,omitempty"`
+}
+
+// LDAPTestRequest tests the stored settings with the given changes applied.
+// With a username the user is looked up, and with a password also bound.
+type LDAPTestRequest struct {
+	UpdateLDAPSettingsRequest
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+// LDAPTestResult reports each step of a test against the directory
+type LDAPTestResult struct {
+	Success    bool           `json:"success"`
+	Steps      []LDAPTestStep `json:"steps"`
+	UserDN     string         `json:"user_dn,omitempty"`
+	Email      string         `json:"email,omitempty"`
+	Groups     []string       `json:"groups,omitempty"`
+	Role       string         `json:"role,omitempty"`
+	DurationMs int64          `json:"duration_ms"`
+}
+
+// LDAPTestStep is one step of a connection test: connect, start_tls, bind, search, user_bind or groups
+type LDAPTestStep struct {
+	Name    string `json:"name"`
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
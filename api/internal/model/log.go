@@ -27,6 +27,10 @@ const (
 	BlockReasonCloudProviderChallenge BlockReason = "cloud_provider_challenge"
 	BlockReasonCloudProviderBlock     BlockReason = "cloud_provider_block"
 	BlockReasonAccessDenied           BlockReason = "access_denied"
+	BlockReasonThreatFeedBlock        BlockReason = "threat_feed_block"
+	BlockReasonThreatFeedChallenge    BlockReason = "threat_feed_challenge"
+	BlockReasonClientCert             BlockReason = "client_cert"
+	BlockReasonSignedURL              BlockReason = "signed_url"
 )
 
 // validBlockReasons contains all valid block reason values
@@ -42,6 +46,10 @@ var validBlockReasons = map[string]BlockReason{
 	"cloud_provider_challenge": BlockReasonCloudProviderChallenge,
 	"cloud_provider_block":     BlockReasonCloudProviderBlock,
 	"access_denied":            BlockReasonAccessDenied,
+	"threat_feed_block":        BlockReasonThreatFeedBlock,
+	"threat_feed_challenge":    BlockReasonThreatFeedChallenge,
+	"client_cert":              BlockReasonClientCert,
+	"signed_url":               BlockReasonSignedURL,
 }
 
 // ParseBlockReason validates and converts a string to BlockReason.
@@ -0,0 +1,43 @@
+package model
+
+import "time"
+
+// ManagementSettings restricts who can reach the management API and UI.
+// The allowlist applies on every listener; the dedicated listener serves
+// the management plane on its own address with a certificate from the
+// certificate store, e.g. bound to a VPN interface.
+type ManagementSettings struct {
+	ID               string    `json:"id"`
+	AllowlistEnabled bool      `json:"allowlist_enabled"`
+	AllowedNetworks  []string  `json:"allowed_networks"` // IPs or CIDRs allowed to use the management API
+	ListenerEnabled  bool      `json:"listener_enabled"`
+	ListenerAddress  string    `json:"listener_address"` // host:port of the dedicated listener, e.g. 10.8.0.1:8443
+	CertificateID    *string   `json:"certificate_id,omitempty"`
+	DedicatedOnly    bool      `json:"dedicated_only"` // Refuse management requests on the main port while the listener runs
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// UpdateManagementSettingsRequest for updating the management settings.
+// An empty certificate_id clears the certificate.
+type UpdateManagementSettingsRequest struct {
+	AllowlistEnabled *bool     `json:"allowlist_enabled,omitempty"`
+	AllowedNetworks  *[]string `json:"allowed_networks,omitempty"`
+	ListenerEnabled  *bool     `json:"listener_enabled,omitempty"`
+	ListenerAddress  *string   `json:"listener_address,omitempty"`
+	CertificateID    *string   `json:"certificate_id,omitempty"`
+	DedicatedOnly    *bool     `json:"dedicated_only,omitempty"`
+}
+
+// ManagementStatus describes the dedicated listener and the caller as seen
+// by the allowlist
+type ManagementStatus struct {
+	ClientIP             string     `json:"client_ip"`
+	ClientAllowed        bool       `json:"client_allowed"`
+	ViaListener          bool       `json:"via_listener"` // The request came in on the dedicated listener
+	ListenerRunning      bool       `json:"listener_running"`
+	ListenerAddress      string     `json:"listener_address,omitempty"`
+	ListenerError        string     `json:"listener_error,omitempty"`
+	CertificateExpiresAt *time.Time `json:"certificate_expires_at,omitempty"`
+	AllowlistOverridden  bool       `json:"allowlist_overridden"` // MANAGEMENT_ALLOWLIST_DISABLED is set
+}
@@ -0,0 +1,63 @@
+package model
+
+import "time"
+
+// OIDCProvider is an OpenID Connect identity provider the login gate authenticates against
+type OIDCProvider struct {
+	ID              string    `json:"id"`
+	Name            string    `json:"name"`
+	IssuerURL       string    `json:"issuer_url"`
+	ClientID        string    `json:"client_id"`
+	ClientSecret    string    `json:"-"`
+	HasClientSecret bool      `json:"has_client_secret"`
+	Scopes          []string  `json:"scopes"`
+	GroupsClaim     string    `json:"groups_claim"` // ID token claim holding the user's groups
+	Enabled         bool      `json:"enabled"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// OIDCProviderRequest is the request to create/update an identity provider
+// An empty client_secret on update keeps the stored secret
+type OIDCProviderRequest struct {
+	Name         string   `json:"name"`
+	IssuerURL    string   `json:"issuer_url"`
+	ClientID     string   `json:"client_id"`
+	ClientSecret string   `json:"client_secret,omitempty"`
+	Scopes       []string `json:"scopes,omitempty"`
+	GroupsClaim  string   `json:"groups_claim,omitempty"`
+	Enabled      *bool    `json:"enabled,omitempty"`
+}
+
+// OIDCHostGate requires users to log in with an identity provider before reaching a proxy host
+// Empty allow lists don't restrict; all configured restrictions must be satisfied
+type OIDCHostGate struct {
+	ID                  string            `json:"id"`
+	ProxyHostID         string            `json:"proxy_host_id"`
+	ProviderID          string            `json:"provider_id"`
+	Enabled             bool              `json:"enabled"`
+	AllowedEmailDomains []string          `json:"allowed_email_domains"`
+	AllowedGroups       []string          `json:"allowed_groups"`
+	RequiredClaims      map[string]string `json:"required_claims"`  // Claim name -> required value
+	SessionLifetime     int               `json:"session_lifetime"` // Seconds
+	CreatedAt           time.Time         `json:"created_at"`
+	UpdatedAt           time.Time         `json:"updated_at"`
+}
+
+// UpsertOIDCHostGateRequest is the request to create/update the login gate of a proxy host
+type UpsertOIDCHostGateRequest struct {
+	ProviderID          string            `json:"provider_id"`
+	Enabled             *bool             `json:"enabled,omitempty"`
+	AllowedEmailDomains []string          `json:"allowed_email_domains,omitempty"`
+	AllowedGroups       []string          `json:"allowed_groups,omitempty"`
+	RequiredClaims      map[string]string `json:"required_claims,omitempty"`
+	SessionLifetime     int               `json:"session_lifetime,omitempty"`
+}
+
+// OIDCIdentity is the authenticated user carried by a login gate session
+type OIDCIdentity struct {
+	Subject string   `json:"sub"`
+	Email   string   `json:"email,omitempty"`
+	Name    string   `json:"name,omitempty"`
+	Groups  []string `json:"groups,omitempty"`
+}
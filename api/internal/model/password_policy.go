@@ -0,0 +1,64 @@
+package model
+
+import "time"
+
+// PasswordPolicy configures the passwords of local accounts and the lockout
+// after failed logins
+type PasswordPolicy struct {
+	ID                 string    `json:"id"`
+	MinLength          int       `json:"min_length"`
+	RequireUppercase   bool      `json:"require_uppercase"`
+	RequireLowercase   bool      `json:"require_lowercase"`
+	RequireDigit       bool      `json:"require_digit"`
+	RequireSymbol      bool      `json:"require_symbol"`
+	HistoryCount       int       `json:"history_count"`        // Previous passwords that can't be reused, 0 disables
+	MaxAgeDays         int       `json:"max_age_days"`         // Passwords must be changed after this many days, 0 disables
+	BreachCheckEnabled bool      `json:"breach_check_enabled"` // Reject passwords found in the breached password list
+	LockoutThreshold   int       `json:"lockout_threshold"`    // Failed logins per username before a lockout, 0 disables
+	IPLockoutThreshold int       `json:"ip_lockout_threshold"` // Failed logins per source IP before a lockout, 0 disables
+	LockoutMinutes     int       `json:"lockout_minutes"`      // First lockout, doubled for every further threshold of failures
+	MaxLockoutMinutes  int       `json:"max_lockout_minutes"`
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+// UpdatePasswordPolicyRequest for updating the password policy
+type UpdatePasswordPolicyRequest struct {
+	MinLength          *int  `json:"min_length,omitempty"`
+	RequireUppercase   *bool `json:"require_uppercase,omitempty"`
+	RequireLowercase   *bool `json:"require_lowercase,omitempty"`
+	RequireDigit       *bool `json:"require_digit,omitempty"`
+	RequireSymbol      *bool `json:"require_symbol,omitempty"`
+	HistoryCount       *int  `json:"history_count,omitempty"`
+	MaxAgeDays         *int  `json:"max_age_days,omitempty"`
+	BreachCheckEnabled *bool `json:"breach_check_enabled,omitempty"`
+	LockoutThreshold   *int  `json:"lockout_threshold,omitempty"`
+	IPLockoutThreshold *int  `json:"ip_lockout_threshold,omitempty"`
+	LockoutMinutes     *int  `json:"lockout_minutes,omitempty"`
+	MaxLockoutMinutes  *int  `json:"max_lockout_minutes,omitempty"`
+}
+
+// BreachedPasswordList is an uploaded list of SHA-1 hashes of breached
+// passwords, stored sorted as 20 byte hashes
+type BreachedPasswordList struct {
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
+	HashCount  int       `json:"hash_count"`
+	Hashes     []byte    `json:"-"`
+	UploadedBy string    `json:"uploaded_by,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// BreachListStatus describes the breached password list in use
+type BreachListStatus struct {
+	Source     string     `json:"source"` // bundled or uploaded
+	Name       string     `json:"name"`
+	HashCount  int        `json:"hash_count"`
+	UploadedAt *time.Time `json:"uploaded_at,omitempty"`
+}
+
+// Breached password list sources
+const (
+	BreachListBundled  = "bundled"
+	BreachListUploaded = "uploaded"
+)
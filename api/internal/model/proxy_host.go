@@ -73,6 +73,9 @@ var dangerousDirectives = []string{
 	"access_log",         // Could redirect logs (in root context)
 	"ssl_certificate",    // Certificate paths (should use UI)
 	"ssl_certificate_key", // Certificate paths (should use UI)
+	"ssl_client_certificate", // Client certificate authentication (should use Client CAs)
+	"ssl_verify_client",  // Client certificate authentication (should use Client CAs)
+	"ssl_crl",            // Client certificate revocation (should use Client CAs)
 	"modsecurity",        // Could disable WAF protection
 	"modsecurity_rules",  // Could modify WAF rules
 	"SecRuleEngine",      // Could disable WAF (ModSecurity directive)
@@ -160,6 +163,9 @@ type ProxyHost struct {
 	SSLHTTP3      bool    `json:"ssl_http3"`
 	CertificateID *string `json:"certificate_id,omitempty"`
 
+	// Listener configuration
+	ProxyProtocol bool `json:"proxy_protocol"` // Also listen on the dedicated PROXY protocol ports (for L4 load balancers)
+
 	// Access configuration
 	AllowWebsocketUpgrade bool `json:"allow_websocket_upgrade"`
 
@@ -197,6 +203,9 @@ type ProxyHost struct {
 	// Status
 	Enabled bool `json:"enabled"`
 
+	// Free-form labels, used e.g. to scope API tokens
+	Tags pq.StringArray `json:"tags"`
+
 	// Metadata
 	Meta      json.RawMessage `json:"meta,omitempty"`
 	CreatedAt time.Time       `json:"created_at"`
@@ -232,7 +241,9 @@ type CreateProxyHostRequest struct {
 	ProxyRequestBuffering   string   `json:"proxy_request_buffering,omitempty"`
 	ClientMaxBodySize       string   `json:"client_max_body_size,omitempty"`
 	ProxyMaxTempFileSize    string   `json:"proxy_max_temp_file_size,omitempty"`
+	ProxyProtocol           bool     `json:"proxy_protocol"`
 	Enabled                 bool     `json:"enabled"`
+	Tags                    []string `json:"tags,omitempty"`
 }
 
 type UpdateProxyHostRequest struct {
@@ -264,7 +275,9 @@ type UpdateProxyHostRequest struct {
 	ProxyRequestBuffering   *string `json:"proxy_request_buffering,omitempty"`
 	ClientMaxBodySize       *string `json:"client_max_body_size,omitempty"`
 	ProxyMaxTempFileSize    *string `json:"proxy_max_temp_file_size,omitempty"`
+	ProxyProtocol           *bool   `json:"proxy_protocol,omitempty"`
 	Enabled                 *bool   `json:"enabled,omitempty"`
+	Tags                    *[]string `json:"tags,omitempty"`
 }
 
 // CloneProxyHostRequest is the request to clone a proxy host
@@ -298,6 +311,7 @@ type ProxyHostTestResult struct {
 	HTTP          *HTTPTestResult         `json:"http,omitempty"`
 	Cache         *CacheTestResult        `json:"cache,omitempty"`
 	Security      *SecurityTestResult     `json:"security,omitempty"`
+	UpstreamTLS   *UpstreamTLSTestResult  `json:"upstream_tls,omitempty"`
 	Headers       map[string]string       `json:"headers,omitempty"`
 }
 
@@ -314,6 +328,27 @@ type SSLTestResult struct {
 	Error         string   `json:"error,omitempty"`
 }
 
+// UpstreamTLSTestResult describes the TLS handshake with a backend as nginx would verify it
+type UpstreamTLSTestResult struct {
+	Protocol          string                    `json:"protocol,omitempty"`
+	Cipher            string                    `json:"cipher,omitempty"`
+	ServerName        string                    `json:"server_name,omitempty"`
+	Verified          bool                      `json:"verified"`
+	VerifyError       string                    `json:"verify_error,omitempty"`
+	ClientCertificate bool                      `json:"client_certificate"` // A client certificate was presented
+	Chain             []UpstreamCertificateInfo `json:"chain"`
+}
+
+// UpstreamCertificateInfo is one certificate of the chain sent by a backend
+type UpstreamCertificateInfo struct {
+	Subject           string   `json:"subject"`
+	Issuer            string   `json:"issuer"`
+	DNSNames          []string `json:"dns_names,omitempty"`
+	NotBefore         string   `json:"not_before"`
+	NotAfter          string   `json:"not_after"`
+	FingerprintSHA256 string   `json:"fingerprint_sha256"`
+}
+
 type HTTPTestResult struct {
 	HTTP2Enabled    bool   `json:"http2_enabled"`
 	HTTP3Enabled    bool   `json:"http3_enabled"`
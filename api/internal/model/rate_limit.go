@@ -63,9 +63,16 @@ type BannedIP struct {
 	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
 	IsPermanent  bool       `json:"is_permanent"`
 	IsAutoBanned bool       `json:"is_auto_banned"`
+	Origin       string     `json:"origin"`
 	CreatedAt    time.Time  `json:"created_at"`
 }
 
+// BannedIP origins
+const (
+	BanOriginLocal    = "local"
+	BanOriginCrowdSec = "crowdsec"
+)
+
 // BannedIPListResponse is the response for listing banned IPs
 type BannedIPListResponse struct {
 	Data       []BannedIP `json:"data"`
@@ -83,11 +90,12 @@ const (
 
 // IPBanHistory sources
 const (
-	BanSourceFail2ban    = "fail2ban"
-	BanSourceWAFAutoBan  = "waf_auto_ban"
-	BanSourceManual      = "manual"
-	BanSourceAPI         = "api"
-	BanSourceExpired     = "expired"
+	BanSourceFail2ban          = "fail2ban"
+	BanSourceWAFAutoBan        = "waf_auto_ban"
+	BanSourceManual            = "manual"
+	BanSourceAPI               = "api"
+	BanSourceExpired           = "expired"
+	BanSourceSubnetAggregation = "subnet_aggregation"
 )
 
 // IPBanHistory represents a single ban/unban event in the history
@@ -98,7 +106,7 @@ type IPBanHistory struct {
 	ProxyHostID  *string                `json:"proxy_host_id,omitempty"`
 	DomainName   string                 `json:"domain_name,omitempty"`
 	Reason       string                 `json:"reason,omitempty"`
-	Source       string                 `json:"source"` // fail2ban, waf_auto_ban, manual, api, expired
+	Source       string                 `json:"source"` // fail2ban, waf_auto_ban, manual, api, expired, subnet_aggregation
 	BanDuration  *int                   `json:"ban_duration,omitempty"` // seconds, 0 = permanent
 	ExpiresAt    *time.Time             `json:"expires_at,omitempty"`
 	IsPermanent  bool                   `json:"is_permanent"`
@@ -108,6 +116,10 @@ type IPBanHistory struct {
 	UserEmail    string                 `json:"user_email,omitempty"`
 	Metadata     map[string]interface{} `json:"metadata,omitempty"`
 	CreatedAt    time.Time              `json:"created_at"`
+
+	// Ban escalation (repeat offenders); set when the duration was chosen by the escalation policy
+	EscalationLevel  *int   `json:"escalation_level,omitempty"`  // 1 = first ban within the lookback window
+	EscalationReason string `json:"escalation_reason,omitempty"` // Human-readable explanation of the ban length
 }
 
 // IPBanHistoryListResponse is the response for listing ban history
@@ -140,6 +152,25 @@ type IPBanHistoryStats struct {
 	TopBannedIPs    []IPBanCount   `json:"top_banned_ips"`
 }
 
+// BanEscalation describes the ban duration chosen by the escalation policy for an IP
+type BanEscalation struct {
+	Level       int    `json:"level"`      // 1 = first ban within the lookback window
+	PriorBans   int    `json:"prior_bans"` // Bans recorded for the IP within the lookback window
+	Duration    int    `json:"duration"`   // Seconds, 0 = permanent
+	Explanation string `json:"explanation"`
+}
+
+// IPBanEscalationStatus is the current escalation state of an IP
+type IPBanEscalationStatus struct {
+	IPAddress     string        `json:"ip_address"`
+	Enabled       bool          `json:"enabled"`
+	Steps         []int64       `json:"steps"`
+	WindowSeconds int           `json:"window_seconds"`
+	CurrentLevel  int           `json:"current_level"` // Bans within the lookback window, 0 = none
+	LastBanAt     *time.Time    `json:"last_ban_at,omitempty"`
+	Next          BanEscalation `json:"next"` // What the next ban of this IP would be
+}
+
 // IPBanCount represents ban count for a specific IP
 type IPBanCount struct {
 	IPAddress string `json:"ip_address"`
@@ -0,0 +1,48 @@
+package model
+
+import "time"
+
+// Real IP sources (real_ip_header values)
+const (
+	RealIPHeaderXForwardedFor  = "X-Forwarded-For"
+	RealIPHeaderXRealIP        = "X-Real-IP"
+	RealIPHeaderCFConnectingIP = "CF-Connecting-IP"
+	RealIPHeaderTrueClientIP   = "True-Client-IP"
+	RealIPHeaderProxyProtocol  = "proxy_protocol"
+)
+
+// RealIPSettings configures which proxies/CDNs in front of nginx are trusted to report the client IP
+// When disabled, the built-in defaults of nginx.conf apply (private ranges, X-Forwarded-For)
+type RealIPSettings struct {
+	ID                   string   `json:"id"`
+	Enabled              bool     `json:"enabled"`
+	Header               string   `json:"header"`                 // Header carrying the client IP, or proxy_protocol
+	Recursive            bool     `json:"recursive"`              // Skip trusted addresses from the right of X-Forwarded-For
+	IncludePrivateRanges bool     `json:"include_private_ranges"` // Trust loopback and private networks (Docker, LAN load balancers)
+	CloudProviders       []string `json:"cloud_providers"`        // Cloud provider slugs whose IP ranges are trusted
+	CustomCIDRs          []string `json:"custom_cidrs"`
+
+	// PROXY protocol listeners (L4 load balancers such as HAProxy or cloud NLBs)
+	ProxyProtocol          bool      `json:"proxy_protocol"`            // Standard HTTP/HTTPS listeners require the PROXY protocol
+	ProxyProtocolHTTPPort  int       `json:"proxy_protocol_http_port"`  // Dedicated PROXY protocol HTTP port for opted-in hosts, 0 = none
+	ProxyProtocolHTTPSPort int       `json:"proxy_protocol_https_port"` // Dedicated PROXY protocol HTTPS port for opted-in hosts, 0 = none
+	CreatedAt              time.Time `json:"created_at"`
+	UpdatedAt              time.Time `json:"updated_at"`
+
+	// Computed
+	SourceCount int `json:"source_count"` // Trusted networks currently rendered
+}
+
+// UpdateRealIPSettingsRequest for updating the trusted proxy configuration
+type UpdateRealIPSettingsRequest struct {
+	Enabled              *bool     `json:"enabled,omitempty"`
+	Header               *string   `json:"header,omitempty"`
+	Recursive            *bool     `json:"recursive,omitempty"`
+	IncludePrivateRanges *bool     `json:"include_private_ranges,omitempty"`
+	CloudProviders       *[]string `json:"cloud_providers,omitempty"`
+	CustomCIDRs          *[]string `json:"custom_cidrs,omitempty"`
+
+	ProxyProtocol          *bool `json:"proxy_protocol,omitempty"`
+	ProxyProtocolHTTPPort  *int  `json:"proxy_protocol_http_port,omitempty"`
+	ProxyProtocolHTTPSPort *int  `json:"proxy_protocol_https_port,omitempty"`
+}
@@ -0,0 +1,99 @@
+package model
+
+import "time"
+
+// Built-in user roles
+const (
+	RoleAdmin           = "admin"
+	RoleOperator        = "operator"
+	RoleSecurityAnalyst = "security_analyst"
+	RoleViewer          = "viewer"
+)
+
+// AllRoles lists the built-in roles from most to least privileged
+var AllRoles = []string{RoleAdmin, RoleOperator, RoleSecurityAnalyst, RoleViewer}
+
+// RolePermissions is the permission matrix for the built-in roles.
+// JWT sessions and API tokens are checked against the same permission strings.
+var RolePermissions = map[string][]string{
+	RoleAdmin:    {PermissionAll},
+	RoleOperator: PermissionGroups["operator"],
+	RoleSecurityAnalyst: {
+		PermissionProxyRead, PermissionCertRead,
+		PermissionWAFRead, PermissionWAFWrite,
+		PermissionLogsRead,
+		PermissionSettingsRead,
+		PermissionBackupRead,
+		PermissionAccessListRead, PermissionRedirectRead,
+		PermissionBannedIPRead, PermissionBannedIPWrite,
+		PermissionExploitRuleRead, PermissionExploitRuleWrite,
+		PermissionChallengeRead, PermissionChallengeWrite,
+		PermissionCloudProviderRead, PermissionCloudProviderWrite,
+		PermissionAuditRead,
+	},
+	RoleViewer: PermissionGroups["read_only"],
+}
+
+// RoleInfo describes a built-in role and its permissions
+type RoleInfo struct {
+	Name        string   `json:"name"`
+	Permissions []string `json:"permissions"`
+}
+
+// IsValidRole reports whether role is one of the built-in roles
+func IsValidRole(role string) bool {
+	_, ok := RolePermissions[role]
+	return ok
+}
+
+// RoleHasPermission checks if a role grants the required permission.
+// Unknown roles (including the legacy "user" default) grant nothing.
+func RoleHasPermission(role, required string) bool {
+	return HasPermission(RolePermissions[role], required)
+}
+
+// User management requests
+
+type CreateUserRequest struct {
+	Username string `json:"username" validate:"required,min=3"`
+	Email    string `json:"email,omitempty"`
+	Password string `json:"password" validate:"required,min=8"`
+	Role     string `json:"role" validate:"required"`
+
+	// Accept a password found in the breached password list
+	AllowBreachedPassword bool `json:"allow_breached_password,omitempty"`
+}
+
+type InviteUserRequest struct {
+	Username  string `json:"username" validate:"required,min=3"`
+	Email     string `json:"email,omitempty"`
+	Role      string `json:"role" validate:"required"`
+	ExpiresIn string `json:"expires_in,omitempty"` // e.g. "24h", "7d" (default 72h)
+}
+
+type UpdateUserRoleRequest struct {
+	Role string `json:"role" validate:"required"`
+}
+
+type AcceptInviteRequest struct {
+	Token           string `json:"token" validate:"required"`
+	Password        string `json:"password" validate:"required,min=8"`
+	PasswordConfirm string `json:"password_confirm" validate:"required"`
+}
+
+// UserInvitation is a pending invitation for a user created without a password
+type UserInvitation struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	TokenHash string    `json:"-"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedBy string    `json:"created_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// UserInvitationResponse is returned once when an invitation is created
+type UserInvitationResponse struct {
+	User      *User     `json:"user"`
+	Token     string    `json:"token"` // Only returned once on creation
+	ExpiresAt time.Time `json:"expires_at"`
+}
@@ -0,0 +1,32 @@
+package model
+
+import "time"
+
+// SessionSettings limits how long admin UI sessions stay valid
+type SessionSettings struct {
+	ID                 string    `json:"id"`
+	IdleTimeoutMinutes int       `json:"idle_timeout_minutes"` // Sessions unused for this long are revoked, 0 disables
+	MaxLifetimeHours   int       `json:"max_lifetime_hours"`   // Sessions end this long after login regardless of activity
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+// UpdateSessionSettingsRequest for updating the session policy
+type UpdateSessionSettingsRequest struct {
+	IdleTimeoutMinutes *int `json:"idle_timeout_minutes,omitempty"`
+	MaxLifetimeHours   *int `json:"max_lifetime_hours,omitempty"`
+}
+
+// SessionInfo is a login session as shown in the session list
+type SessionInfo struct {
+	AuthSession
+	Device      string `json:"device"`
+	Country     string `json:"country,omitempty"`
+	CountryCode string `json:"country_code,omitempty"`
+	Current     bool   `json:"current"` // The session making the request
+}
+
+// RevokeSessionsResponse reports how many sessions were revoked
+type RevokeSessionsResponse struct {
+	Revoked int `json:"revoked"`
+}
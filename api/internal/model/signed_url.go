@@ -0,0 +1,52 @@
+package model
+
+import "time"
+
+// SignedURLLocation protects the requests below a path prefix of a proxy host with
+// expiring signed links (nginx secure_link), so downloads can be shared without accounts
+type SignedURLLocation struct {
+	ID             string    `json:"id"`
+	ProxyHostID    string    `json:"proxy_host_id"`
+	PathPrefix     string    `json:"path_prefix"`
+	Enabled        bool      `json:"enabled"`
+	Secret         string    `json:"-"`
+	HasSecret      bool      `json:"has_secret"`
+	HashExpression string    `json:"hash_expression"` // secure_link_md5 expression, $secret is replaced by the secret
+	TokenParam     string    `json:"token_param"`     // Query parameter carrying the hash
+	ExpiresParam   string    `json:"expires_param"`   // Query parameter carrying the expiry (unix time)
+	DefaultTTL     int       `json:"default_ttl"`     // Seconds, used when minting without a TTL
+	MaxTTL         int       `json:"max_ttl"`         // Seconds, upper bound of minted links
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// UpsertSignedURLLocationRequest creates or replaces the signed URL protection of a path prefix
+// Without a secret, the stored secret is kept (or generated for new locations)
+type UpsertSignedURLLocationRequest struct {
+	PathPrefix     string `json:"path_prefix"`
+	Enabled        *bool  `json:"enabled,omitempty"`
+	Secret         string `json:"secret,omitempty"`
+	RotateSecret   bool   `json:"rotate_secret,omitempty"` // Generate a new secret, invalidating issued links
+	HashExpression string `json:"hash_expression,omitempty"`
+	TokenParam     string `json:"token_param,omitempty"`
+	ExpiresParam   string `json:"expires_param,omitempty"`
+	DefaultTTL     int    `json:"default_ttl,omitempty"`
+	MaxTTL         int    `json:"max_ttl,omitempty"`
+}
+
+// MintSignedURLRequest is the request to sign a link to a path of a proxy host
+type MintSignedURLRequest struct {
+	Path     string `json:"path"`
+	TTL      int    `json:"ttl,omitempty"`       // Seconds, defaults to the location's default TTL
+	Domain   string `json:"domain,omitempty"`    // Domain of the link, defaults to the host's first domain
+	ClientIP string `json:"client_ip,omitempty"` // Required when the hash expression includes $remote_addr
+}
+
+// SignedURL is a minted link
+type SignedURL struct {
+	URL        string    `json:"url"`
+	Path       string    `json:"path"`
+	LocationID string    `json:"location_id"`
+	Token      string    `json:"token"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
@@ -76,6 +76,18 @@ type SystemSettings struct {
 	WAFAutoBanWindow    int  `json:"waf_auto_ban_window" db:"waf_auto_ban_window"`       // Time window in seconds (default: 300 = 5 minutes)
 	WAFAutoBanDuration  int  `json:"waf_auto_ban_duration" db:"waf_auto_ban_duration"`   // Ban duration in seconds (default: 3600 = 1 hour, 0 = permanent)
 
+	// Ban Escalation Settings (repeat offenders, applies to WAF auto-ban and Fail2ban)
+	BanEscalationEnabled bool    `json:"ban_escalation_enabled" db:"ban_escalation_enabled"` // Escalate ban durations based on prior bans of the same IP
+	BanEscalationSteps   []int64 `json:"ban_escalation_steps" db:"ban_escalation_steps"`     // INTEGER[]: ban duration in seconds per offence (0 = permanent, last step repeats)
+	BanEscalationWindow  int     `json:"ban_escalation_window" db:"ban_escalation_window"`   // Lookback window in seconds for counting prior bans (default: 2592000 = 30 days)
+
+	// Subnet Aggregation Settings (replace sibling auto-bans with a single CIDR ban)
+	BanSubnetAggregationEnabled bool `json:"ban_subnet_aggregation_enabled" db:"ban_subnet_aggregation_enabled"`
+	BanSubnetThreshold          int  `json:"ban_subnet_threshold" db:"ban_subnet_threshold"`       // Number of banned addresses in the same prefix to trigger aggregation (default: 5)
+	BanSubnetIPv4Prefix         int  `json:"ban_subnet_ipv4_prefix" db:"ban_subnet_ipv4_prefix"`   // IPv4 prefix length (default: 24)
+	BanSubnetIPv6Prefix         int  `json:"ban_subnet_ipv6_prefix" db:"ban_subnet_ipv6_prefix"`   // IPv6 prefix length (default: 64)
+	BanSubnetWindow             int  `json:"ban_subnet_window" db:"ban_subnet_window"`             // Time window in seconds the bans must fall in (default: 3600 = 1 hour)
+
 	// Global Block Exploits Exceptions
 	GlobalBlockExploitsExceptions string `json:"global_block_exploits_exceptions" db:"global_block_exploits_exceptions"` // Line-separated regex patterns for URI paths that bypass RFI/exploit blocking globally
 
@@ -167,6 +179,18 @@ type SystemSettingsResponse struct {
 	WAFAutoBanWindow    int  `json:"waf_auto_ban_window"`
 	WAFAutoBanDuration  int  `json:"waf_auto_ban_duration"`
 
+	// Ban Escalation Settings
+	BanEscalationEnabled bool    `json:"ban_escalation_enabled"`
+	BanEscalationSteps   []int64 `json:"ban_escalation_steps"`
+	BanEscalationWindow  int     `json:"ban_escalation_window"`
+
+	// Subnet Aggregation Settings
+	BanSubnetAggregationEnabled bool `json:"ban_subnet_aggregation_enabled"`
+	BanSubnetThreshold          int  `json:"ban_subnet_threshold"`
+	BanSubnetIPv4Prefix         int  `json:"ban_subnet_ipv4_prefix"`
+	BanSubnetIPv6Prefix         int  `json:"ban_subnet_ipv6_prefix"`
+	BanSubnetWindow             int  `json:"ban_subnet_window"`
+
 	// Global Block Exploits Exceptions
 	GlobalBlockExploitsExceptions string `json:"global_block_exploits_exceptions"`
 
@@ -233,6 +257,14 @@ func (s *SystemSettings) ToResponse() *SystemSettingsResponse {
 		WAFAutoBanThreshold:                 s.WAFAutoBanThreshold,
 		WAFAutoBanWindow:                    s.WAFAutoBanWindow,
 		WAFAutoBanDuration:                  s.WAFAutoBanDuration,
+		BanEscalationEnabled:                s.BanEscalationEnabled,
+		BanEscalationSteps:                  s.BanEscalationSteps,
+		BanEscalationWindow:                 s.BanEscalationWindow,
+		BanSubnetAggregationEnabled:         s.BanSubnetAggregationEnabled,
+		BanSubnetThreshold:                  s.BanSubnetThreshold,
+		BanSubnetIPv4Prefix:                 s.BanSubnetIPv4Prefix,
+		BanSubnetIPv6Prefix:                 s.BanSubnetIPv6Prefix,
+		BanSubnetWindow:                     s.BanSubnetWindow,
 		GlobalBlockExploitsExceptions:       s.GlobalBlockExploitsExceptions,
 
 		DirectIPAccessAction:                s.DirectIPAccessAction,
@@ -340,6 +372,18 @@ type UpdateSystemSettingsRequest struct {
 	WAFAutoBanWindow    *int  `json:"waf_auto_ban_window,omitempty"`
 	WAFAutoBanDuration  *int  `json:"waf_auto_ban_duration,omitempty"`
 
+	// Ban Escalation Settings
+	BanEscalationEnabled *bool    `json:"ban_escalation_enabled,omitempty"`
+	BanEscalationSteps   *[]int64 `json:"ban_escalation_steps,omitempty"`
+	BanEscalationWindow  *int     `json:"ban_escalation_window,omitempty"`
+
+	// Subnet Aggregation Settings
+	BanSubnetAggregationEnabled *bool `json:"ban_subnet_aggregation_enabled,omitempty"`
+	BanSubnetThreshold          *int  `json:"ban_subnet_threshold,omitempty"`
+	BanSubnetIPv4Prefix         *int  `json:"ban_subnet_ipv4_prefix,omitempty"`
+	BanSubnetIPv6Prefix         *int  `json:"ban_subnet_ipv6_prefix,omitempty"`
+	BanSubnetWindow             *int  `json:"ban_subnet_window,omitempty"`
+
 	// Global Block Exploits Exceptions
 	GlobalBlockExploitsExceptions *string `json:"global_block_exploits_exceptions,omitempty"`
 
@@ -0,0 +1,78 @@
+package model
+
+import (
+	"time"
+)
+
+// Threat feed formats
+const (
+	ThreatFeedFormatPlain = "plain" // One IP/CIDR per line, '#' or ';' comments
+	ThreatFeedFormatCSV   = "csv"   // First IP/CIDR column of each row
+	ThreatFeedFormatJSON  = "json"  // Any string value that is an IP/CIDR (JSON or NDJSON)
+)
+
+// Threat feed actions per proxy host
+const (
+	ThreatFeedActionBlock     = "block"
+	ThreatFeedActionChallenge = "challenge"
+)
+
+// ThreatFeed represents an external IP blocklist (threat intelligence feed)
+type ThreatFeed struct {
+	ID             string     `json:"id"`
+	Name           string     `json:"name"`
+	Slug           string     `json:"slug"`
+	Description    string     `json:"description,omitempty"`
+	URL            string     `json:"url"`
+	Format         string     `json:"format"`
+	UpdateInterval int        `json:"update_interval"` // Seconds between refreshes
+	IPRanges       []string   `json:"ip_ranges,omitempty"`
+	EntryCount     int        `json:"entry_count"`
+	IsBuiltin      bool       `json:"is_builtin"`
+	Enabled        bool       `json:"enabled"`
+	LastUpdated    *time.Time `json:"last_updated,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// CreateThreatFeedRequest for creating a custom threat feed
+type CreateThreatFeedRequest struct {
+	Name           string `json:"name" validate:"required"`
+	Slug           string `json:"slug" validate:"required"`
+	Description    string `json:"description,omitempty"`
+	URL            string `json:"url" validate:"required,url"`
+	Format         string `json:"format" validate:"required,oneof=plain csv json"`
+	UpdateInterval int    `json:"update_interval,omitempty"`
+	Enabled        *bool  `json:"enabled,omitempty"`
+}
+
+// UpdateThreatFeedRequest for updating a threat feed
+type UpdateThreatFeedRequest struct {
+	Name           *string `json:"name,omitempty"`
+	Description    *string `json:"description,omitempty"`
+	URL            *string `json:"url,omitempty"`
+	Format         *string `json:"format,omitempty"`
+	UpdateInterval *int    `json:"update_interval,omitempty"`
+	Enabled        *bool   `json:"enabled,omitempty"`
+}
+
+// ProxyHostThreatFeed is a proxy host's subscription to a threat feed
+type ProxyHostThreatFeed struct {
+	Slug   string `json:"slug"`
+	Name   string `json:"name,omitempty"`
+	Action string `json:"action"` // block or challenge
+}
+
+// ProxyHostThreatFeedsRequest for updating threat feed subscriptions on a proxy host
+type ProxyHostThreatFeedsRequest struct {
+	Feeds []ProxyHostThreatFeed `json:"feeds"`
+}
+
+// ThreatFeedMatchStats holds the number of requests matched by threat feeds
+type ThreatFeedMatchStats struct {
+	ProxyHostID string `json:"proxy_host_id,omitempty"`
+	Host        string `json:"host"`
+	Blocked     int64  `json:"blocked"`
+	Challenged  int64  `json:"challenged"`
+}
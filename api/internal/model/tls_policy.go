@@ -0,0 +1,52 @@
+package model
+
+import "time"
+
+// TLS policy profiles (https://ssl-config.mozilla.org)
+const (
+	TLSProfileModern       = "modern"
+	TLSProfileIntermediate = "intermediate"
+	TLSProfileOld          = "old"
+	TLSProfileCustom       = "custom"
+)
+
+// TLSPolicy is the TLS server configuration of a proxy host's HTTPS server
+// Hosts without a policy use the built-in defaults, equivalent to the intermediate profile
+type TLSPolicy struct {
+	ID                    string    `json:"id"`
+	ProxyHostID           string    `json:"proxy_host_id"`
+	Profile               string    `json:"profile"`
+	Protocols             []string  `json:"protocols"`              // ssl_protocols, e.g. TLSv1.2 TLSv1.3
+	Ciphers               string    `json:"ciphers"`                // ssl_ciphers (TLS 1.2 and below), OpenSSL format
+	Curves                string    `json:"curves"`                 // ssl_ecdh_curve, empty uses the global setting
+	PreferServerCiphers   bool      `json:"prefer_server_ciphers"`
+	SessionTickets        bool      `json:"session_tickets"`
+	OCSPStapling          bool      `json:"ocsp_stapling"`
+	HSTSEnabled           bool      `json:"hsts_enabled"` // Sent instead of the security headers' HSTS header
+	HSTSMaxAge            int       `json:"hsts_max_age"`
+	HSTSIncludeSubdomains bool      `json:"hsts_include_subdomains"`
+	HSTSPreload           bool      `json:"hsts_preload"`
+	CreatedAt             time.Time `json:"created_at"`
+	UpdatedAt             time.Time `json:"updated_at"`
+
+	// Computed
+	Grade      string   `json:"grade"`
+	GradeNotes []string `json:"grade_notes"`
+}
+
+// UpsertTLSPolicyRequest is the request to select the TLS policy of a proxy host
+// Preset profiles fill protocols, ciphers, curves and cipher preference; the remaining fields
+// default to the profile's recommendation when omitted
+type UpsertTLSPolicyRequest struct {
+	Profile               string   `json:"profile"`
+	Protocols             []string `json:"protocols,omitempty"`
+	Ciphers               string   `json:"ciphers,omitempty"`
+	Curves                string   `json:"curves,omitempty"`
+	PreferServerCiphers   *bool    `json:"prefer_server_ciphers,omitempty"`
+	SessionTickets        *bool    `json:"session_tickets,omitempty"`
+	OCSPStapling          *bool    `json:"ocsp_stapling,omitempty"`
+	HSTSEnabled           *bool    `json:"hsts_enabled,omitempty"`
+	HSTSMaxAge            int      `json:"hsts_max_age,omitempty"`
+	HSTSIncludeSubdomains *bool    `json:"hsts_include_subdomains,omitempty"`
+	HSTSPreload           *bool    `json:"hsts_preload,omitempty"`
+}
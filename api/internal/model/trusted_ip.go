@@ -0,0 +1,55 @@
+package model
+
+import (
+	"time"
+)
+
+// TrustedIP is a global never-ban network. Matching clients bypass every blocking
+// subsystem (bans, geo, bot filter, rate limit, URI block, cloud provider and threat feed blocks)
+type TrustedIP struct {
+	ID        string     `json:"id"`
+	CIDR      string     `json:"cidr"`
+	Label     string     `json:"label"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"` // nil = never expires
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// IsActive reports whether the entry has not expired at t
+func (t *TrustedIP) IsActive(at time.Time) bool {
+	return t.ExpiresAt == nil || t.ExpiresAt.After(at)
+}
+
+// CreateTrustedIPRequest for adding a trusted network
+type CreateTrustedIPRequest struct {
+	CIDR      string     `json:"cidr" validate:"required"`
+	Label     string     `json:"label,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// UpdateTrustedIPRequest for updating a trusted network
+type UpdateTrustedIPRequest struct {
+	Label        *string    `json:"label,omitempty"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+	ClearExpires bool       `json:"clear_expires,omitempty"` // remove the expiry (never expires)
+}
+
+// TrustedIPHit records a block that was prevented by a trusted network
+type TrustedIPHit struct {
+	ID          string    `json:"id"`
+	TrustedIPID string    `json:"trusted_ip_id"`
+	IPAddress   string    `json:"ip_address"`
+	Source      string    `json:"source"` // IPBanHistory source of the prevented ban (fail2ban, waf_auto_ban)
+	Reason      string    `json:"reason,omitempty"`
+	ProxyHostID *string   `json:"proxy_host_id,omitempty"`
+	DomainNames []string  `json:"domain_names,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// TrustedIPReportEntry summarizes prevented blocks per trusted network
+type TrustedIPReportEntry struct {
+	TrustedIP      TrustedIP      `json:"trusted_ip"`
+	PreventedCount int64          `json:"prevented_count"`
+	LastPrevented  *time.Time     `json:"last_prevented,omitempty"`
+	RecentHits     []TrustedIPHit `json:"recent_hits"`
+}
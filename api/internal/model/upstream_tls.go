@@ -0,0 +1,32 @@
+package model
+
+import "time"
+
+// UpstreamTLSConfig authenticates the TLS connection from nginx to a proxy host's backend
+// It applies to the direct forward target (https scheme) and to the host's upstream group,
+// whose servers are then proxied over https
+type UpstreamTLSConfig struct {
+	ID                  string    `json:"id"`
+	ProxyHostID         string    `json:"proxy_host_id"`
+	Enabled             bool      `json:"enabled"`
+	Verify              bool      `json:"verify"`                          // Verify the backend certificate (proxy_ssl_verify)
+	TrustedCAPEM        string    `json:"trusted_ca_pem"`                  // CA bundle to verify against; empty uses the system store
+	VerifyDepth         int       `json:"verify_depth"`                    // Maximum backend certificate chain depth
+	ServerName          string    `json:"server_name"`                     // SNI and verified name; empty uses the proxied host name
+	SNI                 bool      `json:"sni"`                             // Send SNI (proxy_ssl_server_name)
+	ClientCertificateID *string   `json:"client_certificate_id,omitempty"` // Certificate store entry presented to the backend
+	IncludePath         string    `json:"include_path"`                    // nginx include with the proxy_ssl_* directives
+	CreatedAt           time.Time `json:"created_at"`
+	UpdatedAt           time.Time `json:"updated_at"`
+}
+
+// UpsertUpstreamTLSRequest is the request to create/update the backend TLS settings of a proxy host
+type UpsertUpstreamTLSRequest struct {
+	Enabled             *bool   `json:"enabled,omitempty"`
+	Verify              *bool   `json:"verify,omitempty"`
+	TrustedCAPEM        string  `json:"trusted_ca_pem,omitempty"`
+	VerifyDepth         int     `json:"verify_depth,omitempty"`
+	ServerName          string  `json:"server_name,omitempty"`
+	SNI                 *bool   `json:"sni,omitempty"`
+	ClientCertificateID *string `json:"client_certificate_id,omitempty"`
+}
@@ -5,8 +5,11 @@ import "time"
 type User struct {
 	ID             string     `json:"id"`
 	Username       string     `json:"username"`
+	Email          string     `json:"email,omitempty"`
 	PasswordHash   string     `json:"-"` // Never expose
 	Role           string     `json:"role"`
+	Disabled       bool       `json:"disabled"`
+	LDAPDN         string     `json:"ldap_dn,omitempty"` // Set for users authenticated by the LDAP directory
 	Language       string     `json:"language"`
 	FontFamily     string     `json:"font_family"`
 	IsInitialSetup bool       `json:"is_initial_setup"`
@@ -33,8 +36,19 @@ type LoginResponse struct {
 	IsInitialSetup bool   `json:"is_initial_setup"`
 	Requires2FA    bool   `json:"requires_2fa,omitempty"`
 	TempToken      string `json:"temp_token,omitempty"` // Temporary token for 2FA verification
+
+	// Second factors that can complete the login: totp, webauthn
+	TwoFactorMethods []string `json:"two_factor_methods,omitempty"`
+	// The password is older than the password policy allows and must be changed
+	PasswordExpired bool `json:"password_expired,omitempty"`
 }
 
+// Second factor methods offered by a login that requires 2FA
+const (
+	TwoFactorTOTP     = "totp"
+	TwoFactorWebAuthn = "webauthn"
+)
+
 type Verify2FARequest struct {
 	TempToken string `json:"temp_token" validate:"required"`
 	TOTPCode  string `json:"totp_code" validate:"required"`
@@ -54,13 +68,16 @@ type ChangePasswordRequest struct {
 }
 
 type AuthSession struct {
-	ID        string    `json:"id"`
-	UserID    string    `json:"user_id"`
-	TokenHash string    `json:"-"`
-	IPAddress string    `json:"ip_address"`
-	UserAgent string    `json:"user_agent"`
-	ExpiresAt time.Time `json:"expires_at"`
-	CreatedAt time.Time `json:"created_at"`
+	ID         string    `json:"id"`
+	UserID     string    `json:"user_id"`
+	TokenHash  string    `json:"-"`
+	IPAddress  string    `json:"ip_address"`
+	UserAgent  string    `json:"user_agent"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	// Restricted to changing the password, see service.ErrPasswordExpired
+	PasswordExpired bool `json:"password_expired,omitempty"`
 }
 
 type LoginAttempt struct {
@@ -72,9 +89,10 @@ type LoginAttempt struct {
 }
 
 type AuthStatus struct {
-	Authenticated  bool  `json:"authenticated"`
-	IsInitialSetup bool  `json:"is_initial_setup"`
-	User           *User `json:"user,omitempty"`
+	Authenticated   bool  `json:"authenticated"`
+	IsInitialSetup  bool  `json:"is_initial_setup"`
+	PasswordExpired bool  `json:"password_expired,omitempty"`
+	User            *User `json:"user,omitempty"`
 }
 
 // 2FA Setup
@@ -0,0 +1,91 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// WebAuthnSettings configures security keys and passkeys for admin logins
+type WebAuthnSettings struct {
+	ID                          string    `json:"id"`
+	Enabled                     bool      `json:"enabled"`
+	RPID                        string    `json:"rp_id"`      // Domain credentials are bound to, empty uses the host the UI is opened on
+	RPName                      string    `json:"rp_name"`    // Shown by the browser when registering a key
+	RPOrigins                   []string  `json:"rp_origins"` // Allowed origins, empty allows https://<rp_id>
+	AllowPasswordless           bool      `json:"allow_passwordless"`
+	RequireHardwareKeyForAdmins bool      `json:"require_hardware_key_for_admins"` // Admins must finish password logins with a device-bound security key
+	CreatedAt                   time.Time `json:"created_at"`
+	UpdatedAt                   time.Time `json:"updated_at"`
+}
+
+// UpdateWebAuthnSettingsRequest for updating the WebAuthn configuration
+type UpdateWebAuthnSettingsRequest struct {
+	Enabled                     *bool     `json:"enabled,omitempty"`
+	RPID                        *string   `json:"rp_id,omitempty"`
+	RPName                      *string   `json:"rp_name,omitempty"`
+	RPOrigins                   *[]string `json:"rp_origins,omitempty"`
+	AllowPasswordless           *bool     `json:"allow_passwordless,omitempty"`
+	RequireHardwareKeyForAdmins *bool     `json:"require_hardware_key_for_admins,omitempty"`
+}
+
+// WebAuthnCredential is a security key or passkey registered by a user
+type WebAuthnCredential struct {
+	ID              string     `json:"id"`
+	UserID          string     `json:"user_id"`
+	Name            string     `json:"name"`
+	CredentialID    []byte     `json:"-"`
+	PublicKey       []byte     `json:"-"`
+	AttestationType string     `json:"attestation_type"`
+	Transports      []string   `json:"transports"`
+	AAGUID          []byte     `json:"-"`
+	SignCount       uint32     `json:"sign_count"`
+	CloneWarning    bool       `json:"clone_warning"`   // The signature counter went backwards, the key may have been cloned
+	BackupEligible  bool       `json:"backup_eligible"` // Synced passkey rather than a device-bound key
+	BackupState     bool       `json:"backup_state"`
+	Attachment      string     `json:"attachment"` // platform or cross-platform, empty if the browser didn't say
+	HardwareKey     bool       `json:"hardware_key"`
+	LastUsedAt      *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// WebAuthnRegisterRequest starts registering a new credential
+type WebAuthnRegisterRequest struct {
+	Name string `json:"name" validate:"required"`
+}
+
+// WebAuthnRenameRequest renames a registered credential
+type WebAuthnRenameRequest struct {
+	Name string `json:"name" validate:"required"`
+}
+
+// WebAuthnCeremony is the public key options handed to navigator.credentials
+// and the ID the browser's response must be sent back with
+type WebAuthnCeremony struct {
+	CeremonyID string      `json:"ceremony_id"`
+	Options    interface{} `json:"options"`
+}
+
+// WebAuthnFinishRequest completes a registration or login ceremony with the
+// browser's PublicKeyCredential serialized as JSON
+type WebAuthnFinishRequest struct {
+	CeremonyID string          `json:"ceremony_id" validate:"required"`
+	Credential json.RawMessage `json:"credential" validate:"required"`
+}
+
+// WebAuthnLoginBeginRequest starts the security key step of a login that
+// answered Requires2FA
+type WebAuthnLoginBeginRequest struct {
+	TempToken string `json:"temp_token" validate:"required"`
+}
+
+// WebAuthnStatus tells the login page which WebAuthn logins to offer
+type WebAuthnStatus struct {
+	Enabled           bool `json:"enabled"`
+	AllowPasswordless bool `json:"allow_passwordless"`
+}
+
+// IsHardwareKey reports whether the credential lives on a roaming, device-bound
+// authenticator that presented an attestation, rather than a synced or built-in passkey
+func (c *WebAuthnCredential) IsHardwareKey() bool {
+	return c.AttestationType != "" && c.AttestationType != "none" && !c.BackupEligible && c.Attachment != "platform"
+}
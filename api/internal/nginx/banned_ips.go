@@ -8,33 +8,56 @@ import (
 	"strings"
 )
 
-// UpdateBannedIPs generates the banned_ips.conf file with all blocked IPs
-func (m *Manager) UpdateBannedIPs(ctx context.Context, bannedIPs []string) error {
-	// Lock globally to prevent race condition with other config operations
-	return m.executeWithLock(ctx, func() error {
-		includesPath := filepath.Join(m.configPath, "includes")
+// Shared banned IPs are decisions that apply to every host (e.g. CrowdSec bouncer decisions).
+// They are rendered once into a geo include instead of being repeated in each host config.
+const (
+	sharedBannedIPsGeoFile     = "shared_banned_ips.conf"
+	sharedBannedIPsIncludeFile = "banned_ips.conf"
+)
+
+// EnsureSharedBannedIPs makes sure the shared $shared_banned_ip geo mapping exists
+// Host configs reference the variable unconditionally, so the mapping must exist before nginx -t
+func (m *Manager) EnsureSharedBannedIPs() error {
+	includesPath := filepath.Join(m.configPath, "includes")
+	if err := os.MkdirAll(includesPath, 0755); err != nil {
+		return fmt.Errorf("failed to create includes directory: %w", err)
+	}
 
-		// Ensure includes directory exists
-		if err := os.MkdirAll(includesPath, 0755); err != nil {
-			return fmt.Errorf("failed to create includes directory: %w", err)
+	includeFile := filepath.Join(includesPath, sharedBannedIPsIncludeFile)
+	if _, err := os.Stat(includeFile); os.IsNotExist(err) {
+		if err := m.writeFileAtomic(includeFile, []byte(renderBannedIPsInclude(nil)), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", sharedBannedIPsIncludeFile, err)
 		}
+	}
 
-		// Generate banned_ips.conf content
+	geoFile := filepath.Join(m.configPath, sharedBannedIPsGeoFile)
+	if _, err := os.Stat(geoFile); os.IsNotExist(err) {
 		var content strings.Builder
-		content.WriteString("# Auto-generated banned IPs - DO NOT EDIT\n")
-		content.WriteString("# This file is managed by Nginx Proxy Guard\n\n")
-
-		if len(bannedIPs) == 0 {
-			content.WriteString("# No banned IPs\n")
-		} else {
-			for _, ip := range bannedIPs {
-				content.WriteString(fmt.Sprintf("deny %s;\n", ip))
-			}
+		content.WriteString("# Auto-generated shared banned IPs geo mapping - DO NOT EDIT\n")
+		content.WriteString("# This file is managed by Nginx Proxy Guard\n")
+		content.WriteString("geo $shared_banned_ip {\n")
+		content.WriteString("    default 0;\n")
+		content.WriteString(fmt.Sprintf("    include /etc/nginx/conf.d/includes/%s;\n", sharedBannedIPsIncludeFile))
+		content.WriteString("}\n")
+		if err := m.writeFileAtomic(geoFile, []byte(content.String()), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", sharedBannedIPsGeoFile, err)
+		}
+	}
+
+	return nil
+}
+
+// UpdateBannedIPs generates the banned_ips.conf file with all blocked IPs
+func (m *Manager) UpdateBannedIPs(ctx context.Context, bannedIPs []string) error {
+	// Lock globally to prevent race condition with other config operations
+	return m.executeWithLock(ctx, func() error {
+		if err := m.EnsureSharedBannedIPs(); err != nil {
+			return err
 		}
 
 		// Write to file atomically
-		configFile := filepath.Join(includesPath, "banned_ips.conf")
-		if err := m.writeFileAtomic(configFile, []byte(content.String()), 0644); err != nil {
+		configFile := filepath.Join(m.configPath, "includes", sharedBannedIPsIncludeFile)
+		if err := m.writeFileAtomic(configFile, []byte(renderBannedIPsInclude(bannedIPs)), 0644); err != nil {
 			return fmt.Errorf("failed to write banned_ips.conf: %w", err)
 		}
 
@@ -48,3 +71,27 @@ func (m *Manager) UpdateBannedIPs(ctx context.Context, bannedIPs []string) error
 		return nil
 	})
 }
+
+// renderBannedIPsInclude renders geo entries for the shared banned IPs include
+func renderBannedIPsInclude(bannedIPs []string) string {
+	var content strings.Builder
+	content.WriteString("# Auto-generated banned IPs - DO NOT EDIT\n")
+	content.WriteString("# This file is managed by Nginx Proxy Guard\n\n")
+
+	if len(bannedIPs) == 0 {
+		content.WriteString("# No banned IPs\n")
+		return content.String()
+	}
+
+	// nginx geo warns on duplicate networks, so skip repeats
+	seen := make(map[string]bool, len(bannedIPs))
+	for _, ip := range bannedIPs {
+		if ip == "" || seen[ip] {
+			continue
+		}
+		seen[ip] = true
+		content.WriteString(fmt.Sprintf("    %s 1;\n", ip))
+	}
+
+	return content.String()
+}
@@ -0,0 +1,123 @@
+package nginx
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"nginx-proxy-guard/internal/model"
+)
+
+// ParseClientCABundle parses the certificates of a client CA bundle
+func ParseClientCABundle(caPEM string) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	rest := []byte(caPEM)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			return nil, fmt.Errorf("unexpected PEM block %q, only certificates are allowed", block.Type)
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse certificate: %w", err)
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no PEM encoded certificate found")
+	}
+	return certs, nil
+}
+
+// ValidateClientCRL checks that every CRL in crlPEM parses and is signed by a certificate of the bundle
+// nginx checks revocation for the whole chain, so multi-level CAs need one CRL per CA
+func ValidateClientCRL(crlPEM string, certs []*x509.Certificate) error {
+	count := 0
+	rest := []byte(crlPEM)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "X509 CRL" {
+			return fmt.Errorf("unexpected PEM block %q, only X509 CRLs are allowed", block.Type)
+		}
+		crl, err := x509.ParseRevocationList(block.Bytes)
+		if err != nil {
+			return fmt.Errorf("failed to parse CRL: %w", err)
+		}
+		signed := false
+		for _, cert := range certs {
+			if crl.CheckSignatureFrom(cert) == nil {
+				signed = true
+				break
+			}
+		}
+		if !signed {
+			return fmt.Errorf("CRL issued by %s is not signed by a certificate of the bundle", crl.Issuer.String())
+		}
+		count++
+	}
+	if count == 0 {
+		return fmt.Errorf("no PEM encoded CRL found")
+	}
+	return nil
+}
+
+// DescribeClientCA fills the bundle summary (subjects, earliest expiry) shown to users
+func DescribeClientCA(ca *model.ClientCA) {
+	ca.Subjects = []string{}
+	certs, err := ParseClientCABundle(ca.CAPEM)
+	if err != nil {
+		return
+	}
+	for _, cert := range certs {
+		ca.Subjects = append(ca.Subjects, cert.Subject.String())
+		if ca.ExpiresAt == nil || cert.NotAfter.Before(*ca.ExpiresAt) {
+			notAfter := cert.NotAfter
+			ca.ExpiresAt = &notAfter
+		}
+	}
+}
+
+// GenerateClientCAFiles writes the CA bundle and CRL of a client CA referenced by ssl_client_certificate/ssl_crl
+func (m *Manager) GenerateClientCAFiles(ca *model.ClientCA) error {
+	if ca == nil {
+		return nil
+	}
+	dir := filepath.Join(m.certsPath, "client_ca", ca.ID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create client CA directory: %w", err)
+	}
+
+	if err := m.writeFileAtomic(filepath.Join(dir, "ca.pem"), []byte(ca.CAPEM), 0644); err != nil {
+		return fmt.Errorf("failed to write client CA bundle: %w", err)
+	}
+	crlPath := filepath.Join(dir, "crl.pem")
+	if ca.CRLPEM == "" {
+		if _, err := os.Stat(crlPath); err == nil {
+			return os.Remove(crlPath)
+		}
+		return nil
+	}
+	if err := m.writeFileAtomic(crlPath, []byte(ca.CRLPEM), 0644); err != nil {
+		return fmt.Errorf("failed to write client CA CRL: %w", err)
+	}
+	return nil
+}
+
+// RemoveClientCAFiles removes the files of a deleted client CA
+func (m *Manager) RemoveClientCAFiles(caID string) error {
+	if caID == "" || strings.ContainsAny(caID, "/\\.") {
+		return nil
+	}
+	return os.RemoveAll(filepath.Join(m.certsPath, "client_ca", caID))
+}
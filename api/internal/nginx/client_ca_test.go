@@ -0,0 +1,115 @@
+package nginx
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"nginx-proxy-guard/internal/model"
+)
+
+func testClientCertData(mode string, enforcePaths []string, forwardHeaders bool) ProxyHostConfigData {
+	return ProxyHostConfigData{
+		Host: withSSL(testProxyHost()),
+		ClientCertAuth: &model.ClientCertAuthConfig{
+			ClientCAID:     "ca-1",
+			Enabled:        true,
+			VerifyMode:     mode,
+			VerifyDepth:    2,
+			ForwardHeaders: forwardHeaders,
+			EnforcePaths:   enforcePaths,
+		},
+		ClientCA: &model.ClientCA{ID: "ca-1", Name: "Staff CA", CAPEM: "-----BEGIN CERTIFICATE-----\n"},
+	}
+}
+
+func TestClientCertAuthOptionalEnforcePaths(t *testing.T) {
+	tests := []struct {
+		mode  string
+		check string
+	}{
+		{model.ClientCertVerifyOptional, `if ($client_cert_check ~ "^1:(?!SUCCESS$)")`},
+		{model.ClientCertVerifyOptionalNoCA, `if ($client_cert_check = "1:NONE")`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			m := newTestManager(t)
+			config := renderProxyHost(t, m, testClientCertData(tt.mode, []string{"/admin"}, false))
+
+			https := serverBlock(t, config, "443")
+			for _, want := range []string{
+				"ssl_client_certificate /etc/nginx/certs/client_ca/ca-1/ca.pem;",
+				"ssl_verify_client " + tt.mode + ";",
+				"ssl_verify_depth 2;",
+				`if ($uri ~ "^(?:/admin(?:[/?]|$))")`,
+				tt.check,
+			} {
+				if !strings.Contains(https, want) {
+					t.Errorf("HTTPS server is missing %q", want)
+				}
+			}
+
+			if http := serverBlock(t, config, "80"); strings.Contains(http, "ssl_verify_client") {
+				t.Error("HTTP server must not verify client certificates")
+			}
+			if _, err := os.Stat(filepath.Join(m.certsPath, "client_ca", "ca-1", "ca.pem")); err != nil {
+				t.Errorf("client CA bundle not written: %v", err)
+			}
+		})
+	}
+
+	t.Run("on ignores enforce paths", func(t *testing.T) {
+		m := newTestManager(t)
+		config := renderProxyHost(t, m, testClientCertData(model.ClientCertVerifyOn, []string{"/admin"}, false))
+		if strings.Contains(config, "$client_cert_required") {
+			t.Error("verify mode on must not render per-path enforcement")
+		}
+	})
+}
+
+func TestClientCertAuthRequiresForcedHTTPS(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(data *ProxyHostConfigData)
+	}{
+		{"without SSL", func(data *ProxyHostConfigData) { data.Host.SSLEnabled = false }},
+		{"without Force HTTPS", func(data *ProxyHostConfigData) { data.Host.SSLForceHTTPS = false }},
+		{"without client CA", func(data *ProxyHostConfigData) { data.ClientCA = nil }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestManager(t)
+			data := testClientCertData(model.ClientCertVerifyOn, nil, false)
+			tt.modify(&data)
+			if err := m.GenerateConfigFull(context.Background(), data); err == nil {
+				t.Error("GenerateConfigFull() expected an error")
+			}
+		})
+	}
+}
+
+func TestClientCertAuthForwardHeaders(t *testing.T) {
+	headers := []string{
+		"proxy_set_header X-Client-Cert-Verify $ssl_client_verify;",
+		"proxy_set_header X-Client-Cert-DN $ssl_client_s_dn;",
+		"proxy_set_header X-Client-Cert-Issuer-DN $ssl_client_i_dn;",
+		"proxy_set_header X-Client-Cert-Fingerprint $ssl_client_fingerprint;",
+		"proxy_set_header X-Client-Cert-Serial $ssl_client_serial;",
+	}
+
+	m := newTestManager(t)
+	config := renderProxyHost(t, m, testClientCertData(model.ClientCertVerifyOn, nil, true))
+	root := locationBlock(t, serverBlock(t, config, "443"), "location / {")
+	for _, want := range headers {
+		if !strings.Contains(root, want) {
+			t.Errorf("location / is missing %q", want)
+		}
+	}
+
+	config = renderProxyHost(t, m, testClientCertData(model.ClientCertVerifyOn, nil, false))
+	if strings.Contains(config, "X-Client-Cert-") {
+		t.Error("client certificate headers must only be sent when forward_headers is set")
+	}
+}
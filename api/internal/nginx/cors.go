@@ -0,0 +1,96 @@
+package nginx
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"nginx-proxy-guard/internal/model"
+)
+
+// CORS policies are selected per request by http-level maps: the URI picks the policy
+// (longest path prefix, else the host-wide one), the origin is matched against that
+// policy, and the response header values are looked up from the match. add_header
+// skips empty values, so requests without an allowed origin get no CORS headers.
+
+// CORSConfig is the precomputed map data of a host's enabled CORS policies
+type CORSConfig struct {
+	DefaultIndex        int               // Host-wide policy, 0 for none
+	PathEntries         []CORSPathEntry   // Path prefix policies, longest prefix first
+	OriginEntries       []CORSOriginEntry // Allowed origin regexes per policy
+	Policies            []CORSPolicyEntry
+	HideUpstreamHeaders bool // Every request is governed by a policy, so backend CORS headers are dropped
+}
+
+// CORSPathEntry maps a path prefix regex to a policy
+type CORSPathEntry struct {
+	Regex string
+	Index int
+}
+
+// CORSOriginEntry maps an origin regex to a policy
+type CORSOriginEntry struct {
+	Regex string
+	Index int
+}
+
+// CORSPolicyEntry holds the response header values of a policy
+type CORSPolicyEntry struct {
+	Index       int
+	Methods     string
+	Headers     string
+	Expose      string
+	Credentials bool
+	MaxAge      int
+}
+
+// buildCORSConfig returns the map data of the enabled policies, or nil when there are none
+func buildCORSConfig(policies []model.CORSPolicy) *CORSConfig {
+	var enabled []model.CORSPolicy
+	for _, p := range policies {
+		if p.Enabled {
+			enabled = append(enabled, p)
+		}
+	}
+	if len(enabled) == 0 {
+		return nil
+	}
+
+	cfg := &CORSConfig{}
+	for i, p := range enabled {
+		index := i + 1
+		if p.PathPrefix == "" {
+			cfg.DefaultIndex = index
+		} else {
+			cfg.PathEntries = append(cfg.PathEntries, CORSPathEntry{Regex: "^" + regexp.QuoteMeta(p.PathPrefix), Index: index})
+		}
+
+		for _, origin := range p.AllowedOrigins {
+			if origin == "*" {
+				cfg.OriginEntries = append(cfg.OriginEntries, CORSOriginEntry{Regex: ".+", Index: index})
+			} else {
+				cfg.OriginEntries = append(cfg.OriginEntries, CORSOriginEntry{Regex: regexp.QuoteMeta(origin), Index: index})
+			}
+		}
+		for _, pattern := range p.AllowedOriginPatterns {
+			cfg.OriginEntries = append(cfg.OriginEntries, CORSOriginEntry{Regex: "(?:" + pattern + ")", Index: index})
+		}
+
+		cfg.Policies = append(cfg.Policies, CORSPolicyEntry{
+			Index:       index,
+			Methods:     strings.Join(p.AllowedMethods, ", "),
+			Headers:     strings.Join(p.AllowedHeaders, ", "),
+			Expose:      strings.Join(p.ExposedHeaders, ", "),
+			Credentials: p.AllowCredentials,
+			MaxAge:      p.MaxAge,
+		})
+	}
+
+	// nginx checks map regexes in order, so the longest prefix has to come first
+	sort.SliceStable(cfg.PathEntries, func(i, j int) bool {
+		return len(cfg.PathEntries[i].Regex) > len(cfg.PathEntries[j].Regex)
+	})
+	cfg.HideUpstreamHeaders = cfg.DefaultIndex != 0
+
+	return cfg
+}
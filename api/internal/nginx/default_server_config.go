@@ -16,9 +16,17 @@ const defaultServerTemplate = `# Default server - catch-all for unmatched reques
 
 # HTTP default server
 server {
-    listen {{.HTTPPort}} default_server;
-    listen [::]:{{.HTTPPort}} default_server;
+    listen {{.HTTPPort}} default_server{{.ListenParams}};
+    listen [::]:{{.HTTPPort}} default_server{{.ListenParams}};
+{{if .ProxyProtocolHTTPPort}}
+    listen {{.ProxyProtocolHTTPPort}} default_server proxy_protocol;
+    listen [::]:{{.ProxyProtocolHTTPPort}} default_server proxy_protocol;
+{{end}}
     server_name _;
+{{if .ProxyProtocol}}
+    # Client IP from the PROXY protocol header
+    include /etc/nginx/conf.d/includes/real_ip_proxy_protocol.conf;
+{{end}}
 
     # Health check endpoint (always allowed)
     location /health {
@@ -101,8 +109,12 @@ server {
 
 # HTTPS default server - reject SSL handshake for unknown/disabled hosts
 server {
-    listen {{.HTTPSPort}} ssl default_server;
-    listen [::]:{{.HTTPSPort}} ssl default_server;
+    listen {{.HTTPSPort}} ssl default_server{{.ListenParams}};
+    listen [::]:{{.HTTPSPort}} ssl default_server{{.ListenParams}};
+{{if .ProxyProtocolHTTPSPort}}
+    listen {{.ProxyProtocolHTTPSPort}} ssl default_server proxy_protocol;
+    listen [::]:{{.ProxyProtocolHTTPSPort}} ssl default_server proxy_protocol;
+{{end}}
     server_name _;
 
     # Reject SSL handshake immediately - no certificate warning, just connection reset
@@ -116,6 +128,12 @@ type DefaultServerConfigData struct {
 	HTTPPort  string // HTTP listen port (default: 80)
 	HTTPSPort string // HTTPS listen port (default: 443)
 	APIURL    string // API URL for nginx to reach API (default: http://api:8080)
+
+	// PROXY protocol listeners; the default server also owns the dedicated ports
+	ListenParams           string
+	ProxyProtocolHTTPPort  string
+	ProxyProtocolHTTPSPort string
+	ProxyProtocol          bool // Any listener receives PROXY protocol connections
 }
 
 // GenerateDefaultServerConfig generates the default server config based on settings
@@ -138,6 +156,16 @@ func (m *Manager) GenerateDefaultServerConfig(ctx context.Context, action string
 		HTTPSPort: m.httpsPort,
 		APIURL:    m.apiURL,
 	}
+	listeners := m.GetProxyProtocolListeners()
+	data.ListenParams = listeners.proxyProtocolParam()
+	data.ProxyProtocolHTTPPort = listeners.HTTPPort
+	data.ProxyProtocolHTTPSPort = listeners.HTTPSPort
+	data.ProxyProtocol = listeners.hasProxyProtocol(true, true)
+	if data.ProxyProtocol {
+		if err := m.EnsureRealIPInclude(); err != nil {
+			return err
+		}
+	}
 
 	var buf bytes.Buffer
 	if err := tmpl.Execute(&buf, data); err != nil {
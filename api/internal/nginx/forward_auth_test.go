@@ -0,0 +1,94 @@
+package nginx
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"nginx-proxy-guard/internal/model"
+)
+
+// normalizeURI approximates nginx's $uri: the path without the query, with
+// dot segments resolved
+func normalizeURI(requestURI string) string {
+	p := requestURI
+	if i := strings.IndexByte(p, '?'); i >= 0 {
+		p = p[:i]
+	}
+	cleaned := path.Clean(p)
+	if strings.HasSuffix(p, "/") && cleaned != "/" {
+		cleaned += "/"
+	}
+	return cleaned
+}
+
+func TestForwardAuthBypassRegex(t *testing.T) {
+	re := regexp.MustCompile(pathPrefixRegex([]string{"/public", "/static/"}))
+
+	// Mirrors the _forward_auth location: the normalized URI and the raw
+	// request URI must both match
+	bypassed := func(requestURI string) bool {
+		return re.MatchString(normalizeURI(requestURI)) && re.MatchString(requestURI)
+	}
+
+	tests := []struct {
+		requestURI string
+		want       bool
+	}{
+		{"/public", true},
+		{"/public/", true},
+		{"/public/logo.png", true},
+		{"/public?lang=en", true},
+		{"/static/app.js", true},
+		{"/publicity-secrets", false},
+		{"/public-admin/", false},
+		{"/static", false},
+		{"/public/../admin", false},
+		{"/public/./../admin/users", false},
+		{"/admin/../public/x", false},
+		{"/admin", false},
+	}
+	for _, tt := range tests {
+		if got := bypassed(tt.requestURI); got != tt.want {
+			t.Errorf("bypassed(%q) = %v, want %v", tt.requestURI, got, tt.want)
+		}
+	}
+}
+
+func TestForwardAuthBypassConfig(t *testing.T) {
+	m := newTestManager(t)
+	fa := &model.ForwardAuthConfig{
+		Enabled:     true,
+		AuthURL:     "http://authelia:9091/api/verify",
+		BypassPaths: []string{"/public"},
+		ProtectHost: true,
+	}
+	config := renderProxyHost(t, m, ProxyHostConfigData{Host: testProxyHost(), ForwardAuth: fa})
+
+	authLocation := locationBlock(t, config, "location = /_forward_auth {")
+	for _, want := range []string{
+		`if ($auth_original_uri ~ "^(?:/public(?:[/?]|$))")`,
+		`if ($request_uri ~ "^(?:/public(?:[/?]|$))")`,
+		`if ($forward_auth_bypass = "11")`,
+	} {
+		if !strings.Contains(authLocation, want) {
+			t.Errorf("_forward_auth location is missing %q:\n%s", want, authLocation)
+		}
+	}
+
+	include, err := os.ReadFile(filepath.Join(m.configPath, "includes", forwardAuthFilename("host-1")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	setURI := strings.Index(string(include), "set $auth_original_uri $uri;")
+	authRequest := strings.Index(string(include), "auth_request /_forward_auth;")
+	if setURI < 0 || authRequest < 0 || setURI > authRequest {
+		t.Errorf("include must keep the normalized URI before the auth subrequest:\n%s", include)
+	}
+	if !strings.Contains(locationBlock(t, config, "location / {"), "include /etc/nginx/conf.d/includes/forward_auth_host-1.conf;") {
+		t.Error("location / is missing the forward auth include")
+	}
+}
@@ -0,0 +1,50 @@
+package nginx
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"nginx-proxy-guard/internal/model"
+)
+
+// GenerateAccessListHtpasswd writes the htpasswd file of an access list's Basic Auth users
+// The file is removed when the list has no users
+func (m *Manager) GenerateAccessListHtpasswd(accessList *model.AccessList) error {
+	if accessList == nil {
+		return nil
+	}
+	if len(accessList.AuthUsers) == 0 {
+		return m.RemoveAccessListHtpasswd(accessList.ID)
+	}
+
+	var sb strings.Builder
+	for _, user := range accessList.AuthUsers {
+		// Entries with characters that would break the file format are never stored, skip defensively
+		if user.Username == "" || user.PasswordHash == "" || strings.ContainsAny(user.Username, ":\r\n") {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("%s:%s\n", user.Username, user.PasswordHash))
+	}
+
+	path := filepath.Join(m.configPath, "includes", htpasswdFilename(accessList.ID))
+	// nginx workers read the file, so it can't be owner-only
+	if err := m.writeFileAtomic(path, []byte(sb.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write htpasswd file: %w", err)
+	}
+	return nil
+}
+
+// RemoveAccessListHtpasswd removes the htpasswd file of an access list
+func (m *Manager) RemoveAccessListHtpasswd(accessListID string) error {
+	path := filepath.Join(m.configPath, "includes", htpasswdFilename(accessListID))
+	if _, err := os.Stat(path); err == nil {
+		return os.Remove(path)
+	}
+	return nil
+}
+
+func htpasswdFilename(accessListID string) string {
+	return fmt.Sprintf("htpasswd_%s", accessListID)
+}
@@ -0,0 +1,109 @@
+package nginx
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"nginx-proxy-guard/internal/model"
+)
+
+func testAccessList(items []model.AccessListItem, users ...string) *model.AccessList {
+	list := &model.AccessList{ID: "list-1", Name: "Staff", Items: items}
+	for _, username := range users {
+		list.AuthUsers = append(list.AuthUsers, model.AccessListAuthUser{
+			Username:     username,
+			PasswordHash: "$2a$10$" + username,
+		})
+	}
+	return list
+}
+
+func TestAccessListBasicAuth(t *testing.T) {
+	officeOnly := []model.AccessListItem{{Directive: "allow", Address: "203.0.113.0/24"}}
+
+	tests := []struct {
+		name    string
+		list    *model.AccessList
+		want    []string
+		notWant []string
+	}{
+		{
+			name:    "users only",
+			list:    testAccessList(nil, "alice"),
+			want:    []string{`auth_basic "Restricted";`, "auth_basic_user_file /etc/nginx/conf.d/includes/htpasswd_list-1;"},
+			notWant: []string{"deny all;", "satisfy any;", "satisfy all;"},
+		},
+		{
+			name: "pass_auth with users",
+			list: func() *model.AccessList {
+				l := testAccessList(officeOnly, "alice")
+				l.PassAuth = true
+				return l
+			}(),
+			want:    []string{"satisfy any;", "allow 203.0.113.0/24;", "deny all;", `auth_basic "Restricted";`},
+			notWant: []string{"satisfy all;"},
+		},
+		{
+			name:    "IP rules with users",
+			list:    testAccessList(officeOnly, "alice"),
+			want:    []string{"satisfy all;", "allow 203.0.113.0/24;", "deny all;", `auth_basic "Restricted";`},
+			notWant: []string{"satisfy any;"},
+		},
+		{
+			name:    "IP rules only",
+			list:    testAccessList(officeOnly),
+			want:    []string{"allow 203.0.113.0/24;", "deny all;"},
+			notWant: []string{`auth_basic "Restricted";`, "satisfy all;"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestManager(t)
+			config := renderProxyHost(t, m, ProxyHostConfigData{Host: testProxyHost(), AccessList: tt.list})
+
+			for _, want := range tt.want {
+				if !strings.Contains(config, want) {
+					t.Errorf("config is missing %q", want)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(config, notWant) {
+					t.Errorf("config must not contain %q", notWant)
+				}
+			}
+
+			// The ACME challenge must stay reachable without credentials
+			if acme := locationBlock(t, config, "location /.well-known/acme-challenge/ {"); !strings.Contains(acme, "auth_basic off;") {
+				t.Errorf("ACME location is missing auth_basic off:\n%s", acme)
+			}
+		})
+	}
+}
+
+func TestGenerateAccessListHtpasswd(t *testing.T) {
+	m := newTestManager(t)
+	path := filepath.Join(m.configPath, "includes", "htpasswd_list-1")
+
+	list := testAccessList(nil, "alice", "bob", "mallory:root")
+	if err := m.GenerateAccessListHtpasswd(list); err != nil {
+		t.Fatalf("GenerateAccessListHtpasswd() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "alice:$2a$10$alice\nbob:$2a$10$bob\n"; string(data) != want {
+		t.Errorf("htpasswd = %q, want %q", data, want)
+	}
+
+	// Removing the last user removes the file
+	if err := m.GenerateAccessListHtpasswd(testAccessList(nil)); err != nil {
+		t.Fatalf("GenerateAccessListHtpasswd() error = %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("htpasswd file should be removed, stat error = %v", err)
+	}
+}
@@ -30,6 +30,9 @@ type Manager struct {
 	httpPort       string // HTTP listen port (default: 80)
 	httpsPort      string // HTTPS listen port (default: 443)
 	apiURL         string // API URL for nginx to reach API (default: http://api:8080)
+
+	listenersMu   sync.RWMutex
+	proxyProtocol ProxyProtocolListeners // PROXY protocol listeners, set from the real IP settings
 }
 
 func NewManager(configPath, certsPath string) *Manager {
@@ -365,6 +368,23 @@ func (m *Manager) GenerateConfigFull(ctx context.Context, data ProxyHostConfigDa
 	data.HTTPPort = m.httpPort
 	data.HTTPSPort = m.httpsPort
 
+	// PROXY protocol on the standard listeners, and on the dedicated ports for opted-in hosts
+	listeners := m.GetProxyProtocolListeners()
+	data.ListenParams = listeners.proxyProtocolParam()
+	data.ProxyProtocolHTTPPort = ""
+	data.ProxyProtocolHTTPSPort = ""
+	if data.Host.ProxyProtocol {
+		data.ProxyProtocolHTTPPort = listeners.HTTPPort
+		if data.Host.SSLEnabled {
+			data.ProxyProtocolHTTPSPort = listeners.HTTPSPort
+		}
+	}
+	// The dedicated ports get their own server blocks, see serverListener
+	data.RealIPInclude = realIPIncludeFile
+	if listeners.Enabled {
+		data.RealIPInclude = realIPProxyProtocolIncludeFile
+	}
+
 	// Get API host from environment or default
 	apiHostValue := os.Getenv("API_HOST")
 	if apiHostValue == "" {
@@ -372,7 +392,8 @@ func (m *Manager) GenerateConfigFull(ctx context.Context, data ProxyHostConfigDa
 	}
 
 	funcMap := template.FuncMap{
-		"join": strings.Join,
+		"join":           strings.Join,
+		"serverListener": serverListener,
 		"now": func() string {
 			return "auto-generated"
 		},
@@ -603,6 +624,11 @@ func (m *Manager) GenerateConfigFull(ctx context.Context, data ProxyHostConfigDa
 	}
 	_ = sslTemporarilyDisabled // Will be used for adding comments to config in future
 
+	// Write the htpasswd file for access list Basic Auth users
+	if err := m.GenerateAccessListHtpasswd(data.AccessList); err != nil {
+		return fmt.Errorf("failed to generate access list htpasswd: %w", err)
+	}
+
 	// Generate cloud IPs include file if there are blocked cloud providers
 	// This significantly reduces the main config file size
 	if len(data.BlockedCloudIPRanges) > 0 {
@@ -614,6 +640,89 @@ func (m *Manager) GenerateConfigFull(ctx context.Context, data ProxyHostConfigDa
 		_ = m.RemoveCloudIPsInclude(data.Host.ID)
 	}
 
+	// Make sure the shared banned IPs geo mapping referenced by every host exists
+	if err := m.EnsureSharedBannedIPs(); err != nil {
+		return fmt.Errorf("failed to ensure shared banned IPs include: %w", err)
+	}
+
+	// Make sure the global trusted IPs geo mapping referenced by every host exists
+	if err := m.EnsureSharedTrustedIPs(); err != nil {
+		return fmt.Errorf("failed to ensure trusted IPs include: %w", err)
+	}
+
+	// Make sure the global real IP include referenced by every host exists
+	if err := m.EnsureRealIPInclude(); err != nil {
+		return fmt.Errorf("failed to ensure real IP include: %w", err)
+	}
+
+	// Generate threat feed include file for subscribed feeds
+	if err := m.GenerateThreatFeedsInclude(data.Host.ID, data.ThreatFeedBlockRanges, data.ThreatFeedChallengeRanges); err != nil {
+		return fmt.Errorf("failed to generate threat feeds include: %w", err)
+	}
+
+	// Forward auth, the OIDC login gate and the geo challenge each use auth_request in location /
+	geoChallenge := data.GeoRestriction != nil && data.GeoRestriction.ChallengeMode
+	if data.ForwardAuth != nil && geoChallenge {
+		return fmt.Errorf("forward auth cannot be combined with geo restriction challenge mode")
+	}
+	if data.OIDCGate != nil && (data.ForwardAuth != nil || geoChallenge) {
+		return fmt.Errorf("OIDC login cannot be combined with forward auth or geo restriction challenge mode")
+	}
+
+	// Generate forward auth snippet included by protected locations
+	if err := m.GenerateForwardAuthInclude(data.Host.ID, data.ForwardAuth); err != nil {
+		return fmt.Errorf("failed to generate forward auth include: %w", err)
+	}
+	if data.ForwardAuth != nil {
+		data.ForwardAuthBypassRegex = pathPrefixRegex(data.ForwardAuth.BypassPaths)
+		data.ForwardAuthSigninRedirect = forwardAuthSigninRedirect(data.ForwardAuth.SigninURL)
+	}
+
+	// Client certificate authentication only applies to the HTTPS server, plain HTTP must not bypass it
+	if data.ClientCertAuth != nil {
+		if data.ClientCA == nil {
+			return fmt.Errorf("client CA %s of client certificate authentication not found", data.ClientCertAuth.ClientCAID)
+		}
+		if !data.Host.SSLEnabled || !data.Host.SSLForceHTTPS {
+			return fmt.Errorf("client certificate authentication requires SSL with Force HTTPS")
+		}
+		if err := m.GenerateClientCAFiles(data.ClientCA); err != nil {
+			return fmt.Errorf("failed to generate client CA files: %w", err)
+		}
+		if data.ClientCertAuth.VerifyMode != model.ClientCertVerifyOn {
+			data.ClientCertEnforceRegex = pathPrefixRegex(data.ClientCertAuth.EnforcePaths)
+		}
+	}
+
+	// Generate backend TLS snippet included by every location proxying to the backend
+	if err := m.GenerateUpstreamTLSInclude(data.Host.ID, data.Host.ForwardHost, data.UpstreamTLS); err != nil {
+		return fmt.Errorf("failed to generate upstream TLS include: %w", err)
+	}
+	data.UpstreamScheme = "http"
+	if data.UpstreamTLS != nil {
+		data.UpstreamScheme = "https"
+	}
+
+	// Generate secure_link locations of the signed URL protected paths
+	if err := m.GenerateSignedURLInclude(&data); err != nil {
+		return fmt.Errorf("failed to generate signed URL include: %w", err)
+	}
+
+	// QUIC only runs over TLS 1.3
+	if data.TLSPolicy != nil && data.Host.SSLHTTP3 && !tlsPolicyHasProtocol(data.TLSPolicy, "TLSv1.3") {
+		return fmt.Errorf("HTTP/3 requires TLSv1.3 in the TLS policy")
+	}
+
+	// The TLS policy's HSTS replaces the security headers' one so the header is sent once
+	data.HSTSHeader = ""
+	if data.TLSPolicy != nil && data.TLSPolicy.HSTSEnabled {
+		data.HSTSHeader = hstsHeaderValue(data.TLSPolicy.HSTSMaxAge, data.TLSPolicy.HSTSIncludeSubdomains, data.TLSPolicy.HSTSPreload)
+	} else if data.SecurityHeaders != nil && data.SecurityHeaders.Enabled && data.SecurityHeaders.HSTSEnabled {
+		data.HSTSHeader = hstsHeaderValue(data.SecurityHeaders.HSTSMaxAge, data.SecurityHeaders.HSTSIncludeSubdomains, data.SecurityHeaders.HSTSPreload)
+	}
+
+	data.CORS = buildCORSConfig(data.CORSPolicies)
+
 	// Check if AdvancedConfig contains a custom location / block
 	// If so, skip generating the default location / block to avoid duplicates
 	if data.Host.AdvancedConfig != "" {
@@ -655,6 +764,10 @@ func (m *Manager) RemoveConfig(ctx context.Context, host *model.ProxyHost) error
 		// Even if main config doesn't exist, try to remove WAF config to be safe
 		_ = m.RemoveHostWAFConfig(ctx, host.ID)
 		_ = m.RemoveCloudIPsInclude(host.ID)
+		_ = m.RemoveThreatFeedsInclude(host.ID)
+		_ = m.RemoveForwardAuthInclude(host.ID)
+		_ = m.RemoveUpstreamTLSInclude(host.ID)
+		_ = m.RemoveSignedURLInclude(host.ID)
 		return nil
 	}
 
@@ -669,8 +782,12 @@ func (m *Manager) RemoveConfig(ctx context.Context, host *model.ProxyHost) error
 		// Don't return error here, as main config removal was successful
 	}
 
-	// Also remove the cloud IPs include file if it exists
+	// Also remove the cloud IPs, threat feeds, forward auth, upstream TLS and signed URL include files if they exist
 	_ = m.RemoveCloudIPsInclude(host.ID)
+	_ = m.RemoveThreatFeedsInclude(host.ID)
+	_ = m.RemoveForwardAuthInclude(host.ID)
+	_ = m.RemoveUpstreamTLSInclude(host.ID)
+	_ = m.RemoveSignedURLInclude(host.ID)
 
 	return nil
 }
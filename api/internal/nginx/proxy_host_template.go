@@ -15,8 +15,9 @@ const proxyHostTemplate = `# nginx-guard generated config
 
 {{if .RateLimit}}{{if .RateLimit.Enabled}}
 # Rate limiting zone definition
-# Map to exclude static files from rate limiting (empty key = no rate limit)
-map $request_uri $rate_limit_key_{{sanitizeID .Host.ID}} {
+# Map to exclude trusted networks and static files from rate limiting (empty key = no rate limit)
+map "$trusted_ip:$request_uri" $rate_limit_key_{{sanitizeID .Host.ID}} {
+    ~^1:  "";
     ~*\.(js|css|png|jpg|jpeg|gif|ico|svg|woff|woff2|ttf|eot|webp|avif|mp4|webm|pdf|zip|tar|gz|rar)(\?.*)?$  "";
     default  ${{if eq .RateLimit.LimitBy "uri"}}request_uri{{else if eq .RateLimit.LimitBy "ip_uri"}}binary_remote_addr$request_uri{{else}}binary_remote_addr{{end}};
 }
@@ -42,6 +43,57 @@ geo $blocked_cloud_{{sanitizeID .Host.ID}} {
 }
 {{end}}
 
+{{if or .ThreatFeedBlockRanges .ThreatFeedChallengeRanges}}
+# Threat intelligence feed IPs geo mapping for {{join .Host.DomainNames ", "}}
+# 1 = block, 2 = challenge ({{len .ThreatFeedBlockRanges}} block / {{len .ThreatFeedChallengeRanges}} challenge ranges)
+geo $threat_feed_{{sanitizeID .Host.ID}} {
+    default 0;
+    include /etc/nginx/conf.d/includes/threat_feeds_{{.Host.ID}}.conf;
+}
+{{end}}
+
+{{if .CORS}}
+# CORS policies for {{join .Host.DomainNames ", "}}
+# The URI selects the policy, the Origin header must match one of its allowed origins
+map $uri $cors_policy_{{sanitizeID .Host.ID}} {
+    default {{.CORS.DefaultIndex}};
+{{range .CORS.PathEntries}}    "~{{.Regex}}" {{.Index}};
+{{end}}}
+map "$cors_policy_{{sanitizeID .Host.ID}}:$http_origin" $cors_match_{{sanitizeID .Host.ID}} {
+    default "";
+{{range .CORS.OriginEntries}}    "~*^{{.Index}}:{{.Regex}}$" {{.Index}};
+{{end}}}
+map $cors_match_{{sanitizeID .Host.ID}} $cors_origin_{{sanitizeID .Host.ID}} {
+    default "";
+    "~." $http_origin;
+}
+map $cors_match_{{sanitizeID .Host.ID}} $cors_credentials_{{sanitizeID .Host.ID}} {
+    default "";
+{{range .CORS.Policies}}{{if .Credentials}}    {{.Index}} "true";
+{{end}}{{end}}}
+map $cors_match_{{sanitizeID .Host.ID}} $cors_methods_{{sanitizeID .Host.ID}} {
+    default "";
+{{range .CORS.Policies}}    {{.Index}} "{{.Methods}}";
+{{end}}}
+map $cors_match_{{sanitizeID .Host.ID}} $cors_headers_{{sanitizeID .Host.ID}} {
+    default "";
+{{range .CORS.Policies}}{{if .Headers}}    {{.Index}} "{{.Headers}}";
+{{end}}{{end}}}
+map $cors_match_{{sanitizeID .Host.ID}} $cors_expose_{{sanitizeID .Host.ID}} {
+    default "";
+{{range .CORS.Policies}}{{if .Expose}}    {{.Index}} "{{.Expose}}";
+{{end}}{{end}}}
+map $cors_match_{{sanitizeID .Host.ID}} $cors_max_age_{{sanitizeID .Host.ID}} {
+    default "";
+{{range .CORS.Policies}}{{if .MaxAge}}    {{.Index}} {{.MaxAge}};
+{{end}}{{end}}}
+# Preflight: an OPTIONS request with Access-Control-Request-Method from an allowed origin
+map "$request_method:$http_access_control_request_method:$cors_match_{{sanitizeID .Host.ID}}" $cors_preflight_{{sanitizeID .Host.ID}} {
+    default 0;
+    "~^OPTIONS:[^:]+:[0-9]+$" 1;
+}
+{{end}}
+
 {{if .Upstream}}
 # Upstream definition for load balancing
 upstream {{.Upstream.Name}} {
@@ -62,11 +114,36 @@ upstream {{.Upstream.Name}} {
 {{end}}
 
 {{if .Host.Enabled}}
+{{template "http_server" serverListener . ""}}
+{{if .ProxyProtocolHTTPPort}}
+{{template "http_server" serverListener . .ProxyProtocolHTTPPort}}
+{{end}}
+
+{{if .Host.SSLEnabled}}
+{{template "https_server" serverListener . ""}}
+{{if .ProxyProtocolHTTPSPort}}
+{{template "https_server" serverListener . .ProxyProtocolHTTPSPort}}
+{{end}}
+{{end}}
+{{end}}
+
+{{/* One server block per kind of listener: the real IP comes from the PROXY
+     protocol header only on the dedicated PROXY protocol port */}}
+{{define "http_server"}}
 server {
-    listen {{.HTTPPort}};
-    listen [::]:{{.HTTPPort}};
+{{if .ProxyProtocolPort}}
+    # PROXY protocol listener for L4 load balancers
+    listen {{.ProxyProtocolPort}} proxy_protocol;
+    listen [::]:{{.ProxyProtocolPort}} proxy_protocol;
+{{else}}
+    listen {{.HTTPPort}}{{.ListenParams}};
+    listen [::]:{{.HTTPPort}}{{.ListenParams}};
+{{end}}
     server_name {{join .Host.DomainNames " "}};
 
+    # Trusted proxies / CDN client IP (set_real_ip_from, real_ip_header)
+    include /etc/nginx/conf.d/includes/{{.RealIPInclude}};
+
     # Initialize tracking variables
     set $block_reason_var "-";
     set $bot_category_var "-";
@@ -110,6 +187,7 @@ server {
     location /.well-known/acme-challenge/ {
         # Allow all access for certificate validation
         allow all;
+        auth_basic off;
         root /etc/nginx/acme-challenge;
         try_files $uri =404;
     }
@@ -173,8 +251,8 @@ server {
     }
 {{end}}
     # Combine checks: block only if geo_allowed_ip=0 AND geo_block_check=Y AND not ACME challenge
-    set $geo_final_block "${geo_allowed_ip}${geo_block_check}${skip_security_for_acme}";
-    if ($geo_final_block = "0Y0") {
+    set $geo_final_block "${geo_allowed_ip}${geo_block_check}${skip_security_for_acme}${trusted_ip}";
+    if ($geo_final_block = "0Y00") {
         return 403;
     }
 {{else}}
@@ -204,8 +282,8 @@ server {
         set $block_reason_var "-";
     }
     # Skip geo block for ACME challenge
-    set $geo_block_final "${geo_block_check}${skip_security_for_acme}";
-    if ($geo_block_final = "Y0") {
+    set $geo_block_final "${geo_block_check}${skip_security_for_acme}${trusted_ip}";
+    if ($geo_block_final = "Y00") {
         return 403;
     }
 {{else}}
@@ -216,8 +294,8 @@ server {
         set $geo_direct_block "Y";
         set $block_reason_var "geo_block";
     }
-    set $geo_direct_final "${geo_direct_block}${skip_security_for_acme}";
-    if ($geo_direct_final = "Y0") {
+    set $geo_direct_final "${geo_direct_block}${skip_security_for_acme}${trusted_ip}";
+    if ($geo_direct_final = "Y00") {
         return 403;
     }
 {{else}}
@@ -234,8 +312,8 @@ server {
         set $block_reason_var "-";
     }
 {{end}}
-    set $geo_direct_final "${geo_direct_block}${skip_security_for_acme}";
-    if ($geo_direct_final = "Y0") {
+    set $geo_direct_final "${geo_direct_block}${skip_security_for_acme}${trusted_ip}";
+    if ($geo_direct_final = "Y00") {
         return 403;
     }
 {{end}}
@@ -288,6 +366,11 @@ server {
 {{end}}
 {{end}}
 {{end}}
+    # Global trusted networks bypass geo challenge
+    if ($trusted_ip = 1) {
+        set $geo_blocked 0;
+        set $block_reason_var "-";
+    }
 {{if .GeoRestriction.AllowSearchBots}}
     # Allow search engine bots - bypass geo challenge
     if ($is_search_bot = 1) {
@@ -297,15 +380,20 @@ server {
 {{end}}
 {{end}}{{end}}{{end}}
 
-{{if .AccessList}}{{if .AccessList.Items}}
+{{if .AccessList}}{{if or .AccessList.Items .AccessList.AuthUsers}}
     # Access List: {{.AccessList.Name}}
     # Note: Access list denials will have block_reason set to access_denied
     set $block_reason_var "access_denied";
-{{if .AccessList.SatisfyAny}}    satisfy any;{{end}}
+{{if or .AccessList.SatisfyAny (and .AccessList.PassAuth .AccessList.AuthUsers)}}    satisfy any;{{else if and .AccessList.Items .AccessList.AuthUsers}}    satisfy all;{{end}}
 {{range .AccessList.Items}}
     {{.Directive}} {{.Address}};{{if .Description}} # {{.Description}}{{end}}
 {{end}}
-    deny all;
+{{if .AccessList.Items}}    deny all;{{end}}
+{{if .AccessList.AuthUsers}}
+    # Basic Auth users (combined with the IP rules by satisfy any/all)
+    auth_basic "Restricted";
+    auth_basic_user_file /etc/nginx/conf.d/includes/htpasswd_{{.AccessList.ID}};
+{{end}}
 {{end}}{{end}}
 
 {{if .Host.BlockExploits}}
@@ -436,18 +524,29 @@ server {
 {{end}}
 
 {{if .BannedIPs}}
-    # Banned IPs check
-    if ($banned_ip_{{sanitizeID .Host.ID}} = 1) {
+    # Banned IPs check (global trusted networks are never blocked)
+    set $banned_ip_check "${banned_ip_{{sanitizeID .Host.ID}}}${trusted_ip}";
+    if ($banned_ip_check = "10") {
         set $block_reason_var "banned_ip";
         return 403;
     }
 {{end}}
 
+    # Shared banned IPs check (CrowdSec decisions, global trusted networks are never blocked)
+    set $shared_banned_check "${shared_banned_ip}${trusted_ip}";
+    if ($shared_banned_check = "10") {
+        set $block_reason_var "banned_ip";
+        return 403;
+    }
+
 {{if .BlockedCloudIPRanges}}
     # Blocked Cloud Provider IPs check
     # Skip for ACME challenge and challenge page (prevents redirect loops)
     # Priority Allow IPs bypass cloud provider blocking
     set $is_priority_allow_cloud $skip_security_for_acme;
+    if ($trusted_ip = 1) {
+        set $is_priority_allow_cloud 1;
+    }
 {{if and .GeoRestriction (len .GeoRestriction.AllowedIPs)}}
 {{range .GeoRestriction.AllowedIPs}}
 {{if isCIDR .}}
@@ -484,11 +583,49 @@ server {
 {{end}}
 {{end}}
 
+{{if or .ThreatFeedBlockRanges .ThreatFeedChallengeRanges}}
+    # Threat intelligence feed check
+    # Skip for ACME challenge and challenge page (prevents redirect loops)
+    # Priority Allow IPs bypass threat feed blocking
+    set $is_priority_allow_threat $skip_security_for_acme;
+    if ($trusted_ip = 1) {
+        set $is_priority_allow_threat 1;
+    }
+{{if and .GeoRestriction (len .GeoRestriction.AllowedIPs)}}
+{{range .GeoRestriction.AllowedIPs}}
+{{if isCIDR .}}
+    if ($remote_addr ~ "{{cidrToNginxPattern .}}") {
+        set $is_priority_allow_threat 1;
+    }
+{{else}}
+    if ($remote_addr = "{{.}}") {
+        set $is_priority_allow_threat 1;
+    }
+{{end}}
+{{end}}
+{{end}}
+    set $threat_feed_check_{{sanitizeID .Host.ID}} "${threat_feed_{{sanitizeID .Host.ID}}}${is_priority_allow_threat}";
+    if ($threat_feed_check_{{sanitizeID .Host.ID}} = "10") {
+        set $block_reason_var "threat_feed_block";
+        return 403;
+    }
+{{if .ThreatFeedChallengeRanges}}
+    if ($threat_feed_check_{{sanitizeID .Host.ID}} = "20") {
+        set $block_reason_var "threat_feed_challenge";
+        return 419; # Use 419 as internal marker for threat feed challenge redirect
+    }
+    error_page 419 = @threat_feed_challenge;
+{{end}}
+{{end}}
+
 {{if .BotFilter}}{{if .BotFilter.Enabled}}
     # Bot Filter - uses error_page 403 for custom error page
     # Priority Allow IPs bypass all bot filtering
     # Also bypass for ACME challenge
     set $priority_allow $skip_security_for_acme;
+    if ($trusted_ip = 1) {
+        set $priority_allow 1;
+    }
 {{if and .GeoRestriction (len .GeoRestriction.AllowedIPs)}}
 {{range .GeoRestriction.AllowedIPs}}
 {{if isCIDR .}}
@@ -575,8 +712,8 @@ server {
     # Block: {{.Description}}
     {{uriLocationDirective .MatchType .Pattern}} {
 {{if hasURIBlockExceptionIPs $.URIBlock}}
-        # Check exception IPs
-        set $uri_block_exception 0;
+        # Check exception IPs (global trusted networks are always excepted)
+        set $uri_block_exception $trusted_ip;
 {{if $.URIBlock.AllowPrivateIPs}}
         # Allow private IPs (10.x, 172.16-31.x, 192.168.x)
         if ($remote_addr ~ "^(10\.|172\.(1[6-9]|2[0-9]|3[0-1])\.|192\.168\.)") {
@@ -599,16 +736,94 @@ server {
             return 403;
         }
         # Pass through to upstream if exception matched
-        {{if $.Upstream}}proxy_pass http://{{$.Upstream.Name}};{{else}}proxy_pass {{$.Host.ForwardScheme}}://{{$.Host.ForwardHost}}:{{$.Host.ForwardPort}};{{end}}
+        {{if $.Upstream}}proxy_pass {{$.UpstreamScheme}}://{{$.Upstream.Name}};{{else}}proxy_pass {{$.Host.ForwardScheme}}://{{$.Host.ForwardHost}}:{{$.Host.ForwardPort}};{{end}}
         include /etc/nginx/includes/proxy_params.conf;
-{{else}}
-        set $block_reason_var "uri_block";
-        return 403;
-{{end}}
+{{if $.UpstreamTLS}}        include /etc/nginx/conf.d/includes/upstream_tls_{{$.Host.ID}}.conf;
+{{end}}{{else}}
+        if ($trusted_ip = 0) {
+            set $block_reason_var "uri_block";
+            return 403;
+        }
+        # Pass through to upstream for global trusted networks
+        {{if $.Upstream}}proxy_pass {{$.UpstreamScheme}}://{{$.Upstream.Name}};{{else}}proxy_pass {{$.Host.ForwardScheme}}://{{$.Host.ForwardHost}}:{{$.Host.ForwardPort}};{{end}}
+        include /etc/nginx/includes/proxy_params.conf;
+{{if $.UpstreamTLS}}        include /etc/nginx/conf.d/includes/upstream_tls_{{$.Host.ID}}.conf;
+{{end}}{{end}}
     }
 {{end}}{{end}}
 {{end}}{{end}}
 
+{{if .OIDCGate}}
+    # OIDC login gate - session validation endpoint (internal)
+    location = /_oidc/validate {
+        internal;
+        proxy_pass http://{{apiHost}}/api/v1/oidc/validate;
+        proxy_pass_request_body off;
+        proxy_set_header Content-Length "";
+        proxy_set_header X-Proxy-Host-ID "{{.Host.ID}}";
+        proxy_set_header X-OIDC-Session $cookie_ng_oidc_session;
+        # Fast timeout - fail fast if API is down
+        proxy_connect_timeout 2s;
+        proxy_read_timeout 5s;
+    }
+
+    # Send users without a valid session to the login flow (return must stay the last parameter)
+    error_page 401 = @oidc_login;
+    location @oidc_login {
+        return 302 /api/v1/oidc/login?host={{.Host.ID}}&return=$scheme://$host$request_uri;
+    }
+
+    # OIDC login gate - login, callback and logout endpoints
+    location /api/v1/oidc/ {
+        # Disable WAF for login parameters (return URL triggers SQLi/RFI rules)
+        modsecurity off;
+
+        proxy_pass http://{{apiHost}}/api/v1/oidc/;
+        proxy_set_header Host $host;
+        proxy_set_header X-Real-IP $remote_addr;
+        # Use $remote_addr directly to prevent X-Forwarded-For header spoofing
+        proxy_set_header X-Forwarded-For $remote_addr;
+        proxy_set_header X-Forwarded-Proto $scheme;
+    }
+{{end}}
+{{if .ForwardAuth}}
+    # Forward auth subrequest endpoint (internal)
+    location = /_forward_auth {
+        internal;
+{{if .ForwardAuthBypassRegex}}
+        # Bypass paths are served without authentication. Both the normalized
+        # URI of the protected location and the raw request URI must match,
+        # so dot segments and encoded slashes can't reach other paths.
+        set $forward_auth_bypass "";
+        if ($auth_original_uri ~ "{{.ForwardAuthBypassRegex}}") {
+            set $forward_auth_bypass "1";
+        }
+        if ($request_uri ~ "{{.ForwardAuthBypassRegex}}") {
+            set $forward_auth_bypass "${forward_auth_bypass}1";
+        }
+        if ($forward_auth_bypass = "11") {
+            return 200;
+        }
+{{end}}
+        proxy_pass {{.ForwardAuth.AuthURL}};
+        proxy_pass_request_body off;
+        proxy_set_header Content-Length "";
+        proxy_set_header X-Original-URL $scheme://$http_host$request_uri;
+        proxy_set_header X-Original-Method $request_method;
+        proxy_set_header X-Forwarded-Method $request_method;
+        proxy_set_header X-Forwarded-Proto $scheme;
+        proxy_set_header X-Forwarded-Host $http_host;
+        proxy_set_header X-Forwarded-Uri $request_uri;
+        proxy_set_header X-Forwarded-For $remote_addr;
+        proxy_set_header X-Real-IP $remote_addr;
+        proxy_connect_timeout 5s;
+        proxy_read_timeout 10s;
+    }
+{{if .ForwardAuthSigninRedirect}}
+    # Send unauthenticated users to the sign-in page
+    error_page 401 =302 {{.ForwardAuthSigninRedirect}}$scheme://$http_host$request_uri;
+{{end}}
+{{end}}
 {{if .GeoRestriction}}{{if .GeoRestriction.ChallengeMode}}
     # Challenge validation endpoint (internal)
     location = /_challenge/validate {
@@ -636,9 +851,10 @@ server {
         # API is down - allow request to proceed (graceful degradation)
         # Log this event for monitoring
         access_log /etc/nginx/logs/access_raw.log main buffer=64k flush=5s;
-        {{if .Upstream}}proxy_pass http://{{.Upstream.Name}};{{else}}proxy_pass {{.Host.ForwardScheme}}://{{.Host.ForwardHost}}:{{.Host.ForwardPort}};{{end}}
+        {{if .Upstream}}proxy_pass {{$.UpstreamScheme}}://{{.Upstream.Name}};{{else}}proxy_pass {{.Host.ForwardScheme}}://{{.Host.ForwardHost}}:{{.Host.ForwardPort}};{{end}}
         include /etc/nginx/includes/proxy_params.conf;
-    }
+{{if $.UpstreamTLS}}        include /etc/nginx/conf.d/includes/upstream_tls_{{$.Host.ID}}.conf;
+{{end}}    }
 {{end}}{{end}}
 
 {{if .Host.SSLEnabled}}
@@ -650,8 +866,35 @@ server {
     }
 {{end}}
     {{else}}
+{{if .SignedURLInclude}}
+    # Signed URL locations (secure_link)
+    include /etc/nginx/conf.d/includes/{{.SignedURLInclude}};
+{{end}}
 {{if not .HasCustomLocationRoot}}
     location / {
+{{if .CORS}}
+        # CORS (answered before access checks, preflights carry no credentials)
+        if ($cors_preflight_{{sanitizeID .Host.ID}}) {
+            add_header Access-Control-Allow-Origin $cors_origin_{{sanitizeID .Host.ID}} always;
+            add_header Access-Control-Allow-Credentials $cors_credentials_{{sanitizeID .Host.ID}} always;
+            add_header Access-Control-Allow-Methods $cors_methods_{{sanitizeID .Host.ID}} always;
+            add_header Access-Control-Allow-Headers $cors_headers_{{sanitizeID .Host.ID}} always;
+            add_header Access-Control-Max-Age $cors_max_age_{{sanitizeID .Host.ID}} always;
+            add_header Vary "Origin, Access-Control-Request-Method, Access-Control-Request-Headers" always;
+            return 204;
+        }
+        add_header Access-Control-Allow-Origin $cors_origin_{{sanitizeID .Host.ID}} always;
+        add_header Access-Control-Allow-Credentials $cors_credentials_{{sanitizeID .Host.ID}} always;
+        add_header Access-Control-Expose-Headers $cors_expose_{{sanitizeID .Host.ID}} always;
+        add_header Vary Origin always;
+{{if .CORS.HideUpstreamHeaders}}
+        proxy_hide_header Access-Control-Allow-Origin;
+        proxy_hide_header Access-Control-Allow-Credentials;
+        proxy_hide_header Access-Control-Allow-Methods;
+        proxy_hide_header Access-Control-Allow-Headers;
+        proxy_hide_header Access-Control-Expose-Headers;
+        proxy_hide_header Access-Control-Max-Age;
+{{end}}{{end}}
 {{if .GeoRestriction}}{{if .GeoRestriction.ChallengeMode}}
         # Check challenge token for geo-blocked users (skip for search bots)
         set $challenge_check 0;
@@ -678,9 +921,22 @@ server {
         error_page 401 = @challenge_redirect;
         error_page 500 502 503 504 = @api_fallback;
 {{end}}{{end}}
-        {{if .Upstream}}proxy_pass http://{{.Upstream.Name}};{{else}}proxy_pass {{.Host.ForwardScheme}}://{{.Host.ForwardHost}}:{{.Host.ForwardPort}};{{end}}
+{{if .ForwardAuth}}{{if .ForwardAuth.ProtectHost}}
+        # Forward auth
+        include /etc/nginx/conf.d/includes/forward_auth_{{.Host.ID}}.conf;
+{{end}}{{end}}
+{{if .OIDCGate}}
+        # OIDC login gate
+        auth_request /_oidc/validate;
+        auth_request_set $oidc_user $upstream_http_x_auth_user;
+        auth_request_set $oidc_email $upstream_http_x_auth_email;
+        proxy_set_header X-Auth-Request-User $oidc_user;
+        proxy_set_header X-Auth-Request-Email $oidc_email;
+{{end}}
+        {{if .Upstream}}proxy_pass {{$.UpstreamScheme}}://{{.Upstream.Name}};{{else}}proxy_pass {{.Host.ForwardScheme}}://{{.Host.ForwardHost}}:{{.Host.ForwardPort}};{{end}}
         include /etc/nginx/includes/proxy_params.conf;
-        {{if .GlobalSettings}}
+{{if $.UpstreamTLS}}        include /etc/nginx/conf.d/includes/upstream_tls_{{$.Host.ID}}.conf;
+{{end}}        {{if .GlobalSettings}}
         # Proxy settings (Host-level overrides Global)
         {{if gt .Host.ProxyConnectTimeout 0}}proxy_connect_timeout {{.Host.ProxyConnectTimeout}}s;{{else if gt .GlobalSettings.ProxyConnectTimeout 0}}proxy_connect_timeout {{.GlobalSettings.ProxyConnectTimeout}}s;{{end}}
         {{if .Host.AllowWebsocketUpgrade}}proxy_send_timeout 86400s;{{else if gt .Host.ProxySendTimeout 0}}proxy_send_timeout {{.Host.ProxySendTimeout}}s;{{else if gt .GlobalSettings.ProxySendTimeout 0}}proxy_send_timeout {{.GlobalSettings.ProxySendTimeout}}s;{{end}}
@@ -739,8 +995,35 @@ server {
 {{end}}
     {{end}}
 {{else}}
+{{if .SignedURLInclude}}
+    # Signed URL locations (secure_link)
+    include /etc/nginx/conf.d/includes/{{.SignedURLInclude}};
+{{end}}
 {{if not .HasCustomLocationRoot}}
     location / {
+{{if .CORS}}
+        # CORS (answered before access checks, preflights carry no credentials)
+        if ($cors_preflight_{{sanitizeID .Host.ID}}) {
+            add_header Access-Control-Allow-Origin $cors_origin_{{sanitizeID .Host.ID}} always;
+            add_header Access-Control-Allow-Credentials $cors_credentials_{{sanitizeID .Host.ID}} always;
+            add_header Access-Control-Allow-Methods $cors_methods_{{sanitizeID .Host.ID}} always;
+            add_header Access-Control-Allow-Headers $cors_headers_{{sanitizeID .Host.ID}} always;
+            add_header Access-Control-Max-Age $cors_max_age_{{sanitizeID .Host.ID}} always;
+            add_header Vary "Origin, Access-Control-Request-Method, Access-Control-Request-Headers" always;
+            return 204;
+        }
+        add_header Access-Control-Allow-Origin $cors_origin_{{sanitizeID .Host.ID}} always;
+        add_header Access-Control-Allow-Credentials $cors_credentials_{{sanitizeID .Host.ID}} always;
+        add_header Access-Control-Expose-Headers $cors_expose_{{sanitizeID .Host.ID}} always;
+        add_header Vary Origin always;
+{{if .CORS.HideUpstreamHeaders}}
+        proxy_hide_header Access-Control-Allow-Origin;
+        proxy_hide_header Access-Control-Allow-Credentials;
+        proxy_hide_header Access-Control-Allow-Methods;
+        proxy_hide_header Access-Control-Allow-Headers;
+        proxy_hide_header Access-Control-Expose-Headers;
+        proxy_hide_header Access-Control-Max-Age;
+{{end}}{{end}}
 {{if .GeoRestriction}}{{if .GeoRestriction.ChallengeMode}}
         # Check challenge token for geo-blocked users (skip for search bots)
         set $challenge_check 0;
@@ -767,9 +1050,22 @@ server {
         error_page 401 = @challenge_redirect;
         error_page 500 502 503 504 = @api_fallback;
 {{end}}{{end}}
-        {{if .Upstream}}proxy_pass http://{{.Upstream.Name}};{{else}}proxy_pass {{.Host.ForwardScheme}}://{{.Host.ForwardHost}}:{{.Host.ForwardPort}};{{end}}
+{{if .ForwardAuth}}{{if .ForwardAuth.ProtectHost}}
+        # Forward auth
+        include /etc/nginx/conf.d/includes/forward_auth_{{.Host.ID}}.conf;
+{{end}}{{end}}
+{{if .OIDCGate}}
+        # OIDC login gate
+        auth_request /_oidc/validate;
+        auth_request_set $oidc_user $upstream_http_x_auth_user;
+        auth_request_set $oidc_email $upstream_http_x_auth_email;
+        proxy_set_header X-Auth-Request-User $oidc_user;
+        proxy_set_header X-Auth-Request-Email $oidc_email;
+{{end}}
+        {{if .Upstream}}proxy_pass {{$.UpstreamScheme}}://{{.Upstream.Name}};{{else}}proxy_pass {{.Host.ForwardScheme}}://{{.Host.ForwardHost}}:{{.Host.ForwardPort}};{{end}}
         include /etc/nginx/includes/proxy_params.conf;
-        {{if .GlobalSettings}}
+{{if $.UpstreamTLS}}        include /etc/nginx/conf.d/includes/upstream_tls_{{$.Host.ID}}.conf;
+{{end}}        {{if .GlobalSettings}}
         # Proxy settings (Host-level overrides Global)
         {{if gt .Host.ProxyConnectTimeout 0}}proxy_connect_timeout {{.Host.ProxyConnectTimeout}}s;{{else if gt .GlobalSettings.ProxyConnectTimeout 0}}proxy_connect_timeout {{.GlobalSettings.ProxyConnectTimeout}}s;{{end}}
         {{if .Host.AllowWebsocketUpgrade}}proxy_send_timeout 86400s;{{else if gt .Host.ProxySendTimeout 0}}proxy_send_timeout {{.Host.ProxySendTimeout}}s;{{else if gt .GlobalSettings.ProxySendTimeout 0}}proxy_send_timeout {{.GlobalSettings.ProxySendTimeout}}s;{{end}}
@@ -843,27 +1139,44 @@ server {
     }
 {{end}}{{end}}
 
+{{if .ThreatFeedChallengeRanges}}
+    # Threat feed challenge redirect handler
+    location @threat_feed_challenge {
+        return 302 /api/v1/challenge/page?host={{.Host.ID}}&reason=threat_feed&return=$scheme://$host$request_uri;
+    }
+{{end}}
+
 {{if .Host.AdvancedConfig}}
     # Advanced configuration
     {{.Host.AdvancedConfig}}
 {{end}}
 }
+{{end}}
 
-{{if .Host.SSLEnabled}}
+{{define "https_server"}}
 server {
-    listen {{.HTTPSPort}} ssl;
-    listen [::]:{{.HTTPSPort}} ssl;
+{{if .ProxyProtocolPort}}
+    # PROXY protocol listener for L4 load balancers
+    listen {{.ProxyProtocolPort}} ssl proxy_protocol;
+    listen [::]:{{.ProxyProtocolPort}} ssl proxy_protocol;
+{{else}}
+    listen {{.HTTPSPort}} ssl{{.ListenParams}};
+    listen [::]:{{.HTTPSPort}} ssl{{.ListenParams}};
+{{end}}
 {{if .Host.SSLHTTP2}}
     # HTTP/2 over TCP (new directive style)
     http2 on;
 {{end}}
-{{if .Host.SSLHTTP3}}
+{{if and .Host.SSLHTTP3 (not .ProxyProtocolPort)}}
     # HTTP/3 over QUIC (UDP)
     listen {{.HTTPSPort}} quic;
     listen [::]:{{.HTTPSPort}} quic;
 {{end}}
     server_name {{join .Host.DomainNames " "}};
 
+    # Trusted proxies / CDN client IP (set_real_ip_from, real_ip_header)
+    include /etc/nginx/conf.d/includes/{{.RealIPInclude}};
+
     # Initialize tracking variables
     set $block_reason_var "-";
     set $bot_category_var "-";
@@ -906,12 +1219,58 @@ server {
     # SSL configuration
     ssl_certificate /etc/nginx/certs/{{certPath .Host}}/fullchain.pem;
     ssl_certificate_key /etc/nginx/certs/{{certPath .Host}}/privkey.pem;
+{{if .TLSPolicy}}
+    # TLS policy ({{.TLSPolicy.Profile}})
+    ssl_protocols {{join .TLSPolicy.Protocols " "}};
+    ssl_prefer_server_ciphers {{if .TLSPolicy.PreferServerCiphers}}on{{else}}off{{end}};
+{{if .TLSPolicy.Ciphers}}    ssl_ciphers {{.TLSPolicy.Ciphers}};
+{{end}}{{if .TLSPolicy.Curves}}    ssl_ecdh_curve {{.TLSPolicy.Curves}};
+{{end}}    ssl_session_tickets {{if .TLSPolicy.SessionTickets}}on{{else}}off{{end}};
+    ssl_stapling {{if .TLSPolicy.OCSPStapling}}on{{else}}off{{end}};
+    ssl_stapling_verify {{if .TLSPolicy.OCSPStapling}}on{{else}}off{{end}};
+{{else}}
     ssl_protocols TLSv1.2 TLSv1.3;
     ssl_prefer_server_ciphers on;
     ssl_ciphers ECDHE-ECDSA-AES128-GCM-SHA256:ECDHE-RSA-AES128-GCM-SHA256:ECDHE-ECDSA-AES256-GCM-SHA384:ECDHE-RSA-AES256-GCM-SHA384:ECDHE-ECDSA-CHACHA20-POLY1305:ECDHE-RSA-CHACHA20-POLY1305;
+{{end}}
 {{if .Host.SSLHTTP3}}
     # HTTP/3 settings
     ssl_early_data on;
+{{end}}
+{{if .ClientCertAuth}}
+    # Client certificate authentication (mTLS)
+    ssl_client_certificate /etc/nginx/certs/client_ca/{{.ClientCertAuth.ClientCAID}}/ca.pem;
+    ssl_verify_client {{.ClientCertAuth.VerifyMode}};
+    ssl_verify_depth {{.ClientCertAuth.VerifyDepth}};
+{{if .ClientCA.CRLPEM}}    ssl_crl /etc/nginx/certs/client_ca/{{.ClientCertAuth.ClientCAID}}/crl.pem;
+{{end}}
+    # Rejected handshakes (495 invalid certificate, 496 no certificate) are logged with the verify result
+    error_page 495 496 =403 @client_cert_failed;
+    location @client_cert_failed {
+        set $block_reason_var "client_cert";
+        set $bot_category_var "-";
+        set $exploit_rule_var $ssl_client_verify;
+        root /etc/nginx/html;
+        default_type text/html;
+        try_files /403.html =403;
+    }
+{{if .ClientCertEnforceRegex}}
+    # Paths that require a client certificate
+    set $client_cert_required 0;
+    if ($uri ~ "{{.ClientCertEnforceRegex}}") {
+        set $client_cert_required 1;
+    }
+    set $client_cert_check "${client_cert_required}:${ssl_client_verify}";
+{{if eq .ClientCertAuth.VerifyMode "optional_no_ca"}}
+    if ($client_cert_check = "1:NONE") {
+{{else}}
+    if ($client_cert_check ~ "^1:(?!SUCCESS$)") {
+{{end}}
+        set $block_reason_var "client_cert";
+        set $exploit_rule_var $ssl_client_verify;
+        return 403;
+    }
+{{end}}
 {{end}}
 
     # Custom error pages for upstream errors
@@ -973,8 +1332,8 @@ server {
     }
 {{end}}
     # Combine checks: block only if geo_allowed_ip=0 AND geo_block_check=Y AND not ACME challenge
-    set $geo_final_block "${geo_allowed_ip}${geo_block_check}${skip_security_for_acme}";
-    if ($geo_final_block = "0Y0") {
+    set $geo_final_block "${geo_allowed_ip}${geo_block_check}${skip_security_for_acme}${trusted_ip}";
+    if ($geo_final_block = "0Y00") {
         return 403;
     }
 {{else}}
@@ -1004,8 +1363,8 @@ server {
         set $block_reason_var "-";
     }
     # Skip geo block for ACME challenge
-    set $geo_block_final "${geo_block_check}${skip_security_for_acme}";
-    if ($geo_block_final = "Y0") {
+    set $geo_block_final "${geo_block_check}${skip_security_for_acme}${trusted_ip}";
+    if ($geo_block_final = "Y00") {
         return 403;
     }
 {{else}}
@@ -1016,8 +1375,8 @@ server {
         set $geo_direct_block "Y";
         set $block_reason_var "geo_block";
     }
-    set $geo_direct_final "${geo_direct_block}${skip_security_for_acme}";
-    if ($geo_direct_final = "Y0") {
+    set $geo_direct_final "${geo_direct_block}${skip_security_for_acme}${trusted_ip}";
+    if ($geo_direct_final = "Y00") {
         return 403;
     }
 {{else}}
@@ -1034,8 +1393,8 @@ server {
         set $block_reason_var "-";
     }
 {{end}}
-    set $geo_direct_final "${geo_direct_block}${skip_security_for_acme}";
-    if ($geo_direct_final = "Y0") {
+    set $geo_direct_final "${geo_direct_block}${skip_security_for_acme}${trusted_ip}";
+    if ($geo_direct_final = "Y00") {
         return 403;
     }
 {{end}}
@@ -1088,6 +1447,11 @@ server {
 {{end}}
 {{end}}
 {{end}}
+    # Global trusted networks bypass geo challenge
+    if ($trusted_ip = 1) {
+        set $geo_blocked 0;
+        set $block_reason_var "-";
+    }
 {{if .GeoRestriction.AllowSearchBots}}
     # Allow search engine bots - bypass geo challenge
     if ($is_search_bot = 1) {
@@ -1097,15 +1461,20 @@ server {
 {{end}}
 {{end}}{{end}}{{end}}
 
-{{if .AccessList}}{{if .AccessList.Items}}
+{{if .AccessList}}{{if or .AccessList.Items .AccessList.AuthUsers}}
     # Access List: {{.AccessList.Name}}
     # Note: Access list denials will have block_reason set to access_denied
     set $block_reason_var "access_denied";
-{{if .AccessList.SatisfyAny}}    satisfy any;{{end}}
+{{if or .AccessList.SatisfyAny (and .AccessList.PassAuth .AccessList.AuthUsers)}}    satisfy any;{{else if and .AccessList.Items .AccessList.AuthUsers}}    satisfy all;{{end}}
 {{range .AccessList.Items}}
     {{.Directive}} {{.Address}};{{if .Description}} # {{.Description}}{{end}}
 {{end}}
-    deny all;
+{{if .AccessList.Items}}    deny all;{{end}}
+{{if .AccessList.AuthUsers}}
+    # Basic Auth users (combined with the IP rules by satisfy any/all)
+    auth_basic "Restricted";
+    auth_basic_user_file /etc/nginx/conf.d/includes/htpasswd_{{.AccessList.ID}};
+{{end}}
 {{end}}{{end}}
 
 {{if .Host.BlockExploits}}
@@ -1236,18 +1605,29 @@ server {
 {{end}}
 
 {{if .BannedIPs}}
-    # Banned IPs check
-    if ($banned_ip_{{sanitizeID .Host.ID}} = 1) {
+    # Banned IPs check (global trusted networks are never blocked)
+    set $banned_ip_check "${banned_ip_{{sanitizeID .Host.ID}}}${trusted_ip}";
+    if ($banned_ip_check = "10") {
         set $block_reason_var "banned_ip";
         return 403;
     }
 {{end}}
 
+    # Shared banned IPs check (CrowdSec decisions, global trusted networks are never blocked)
+    set $shared_banned_check "${shared_banned_ip}${trusted_ip}";
+    if ($shared_banned_check = "10") {
+        set $block_reason_var "banned_ip";
+        return 403;
+    }
+
 {{if .BlockedCloudIPRanges}}
     # Blocked Cloud Provider IPs check
     # Skip for ACME challenge and challenge page (prevents redirect loops)
     # Priority Allow IPs bypass cloud provider blocking
     set $is_priority_allow_cloud $skip_security_for_acme;
+    if ($trusted_ip = 1) {
+        set $is_priority_allow_cloud 1;
+    }
 {{if and .GeoRestriction (len .GeoRestriction.AllowedIPs)}}
 {{range .GeoRestriction.AllowedIPs}}
 {{if isCIDR .}}
@@ -1284,11 +1664,49 @@ server {
 {{end}}
 {{end}}
 
+{{if or .ThreatFeedBlockRanges .ThreatFeedChallengeRanges}}
+    # Threat intelligence feed check
+    # Skip for ACME challenge and challenge page (prevents redirect loops)
+    # Priority Allow IPs bypass threat feed blocking
+    set $is_priority_allow_threat $skip_security_for_acme;
+    if ($trusted_ip = 1) {
+        set $is_priority_allow_threat 1;
+    }
+{{if and .GeoRestriction (len .GeoRestriction.AllowedIPs)}}
+{{range .GeoRestriction.AllowedIPs}}
+{{if isCIDR .}}
+    if ($remote_addr ~ "{{cidrToNginxPattern .}}") {
+        set $is_priority_allow_threat 1;
+    }
+{{else}}
+    if ($remote_addr = "{{.}}") {
+        set $is_priority_allow_threat 1;
+    }
+{{end}}
+{{end}}
+{{end}}
+    set $threat_feed_check_{{sanitizeID .Host.ID}} "${threat_feed_{{sanitizeID .Host.ID}}}${is_priority_allow_threat}";
+    if ($threat_feed_check_{{sanitizeID .Host.ID}} = "10") {
+        set $block_reason_var "threat_feed_block";
+        return 403;
+    }
+{{if .ThreatFeedChallengeRanges}}
+    if ($threat_feed_check_{{sanitizeID .Host.ID}} = "20") {
+        set $block_reason_var "threat_feed_challenge";
+        return 419; # Use 419 as internal marker for threat feed challenge redirect
+    }
+    error_page 419 = @threat_feed_challenge;
+{{end}}
+{{end}}
+
 {{if .BotFilter}}{{if .BotFilter.Enabled}}
     # Bot Filter - uses error_page 403 for custom error page
     # Priority Allow IPs bypass all bot filtering
     # Also bypass for ACME challenge
     set $priority_allow $skip_security_for_acme;
+    if ($trusted_ip = 1) {
+        set $priority_allow 1;
+    }
 {{if and .GeoRestriction (len .GeoRestriction.AllowedIPs)}}
 {{range .GeoRestriction.AllowedIPs}}
 {{if isCIDR .}}
@@ -1375,8 +1793,8 @@ server {
     # Block: {{.Description}}
     {{uriLocationDirective .MatchType .Pattern}} {
 {{if hasURIBlockExceptionIPs $.URIBlock}}
-        # Check exception IPs
-        set $uri_block_exception 0;
+        # Check exception IPs (global trusted networks are always excepted)
+        set $uri_block_exception $trusted_ip;
 {{if $.URIBlock.AllowPrivateIPs}}
         # Allow private IPs (10.x, 172.16-31.x, 192.168.x)
         if ($remote_addr ~ "^(10\.|172\.(1[6-9]|2[0-9]|3[0-1])\.|192\.168\.)") {
@@ -1399,16 +1817,94 @@ server {
             return 403;
         }
         # Pass through to upstream if exception matched
-        {{if $.Upstream}}proxy_pass http://{{$.Upstream.Name}};{{else}}proxy_pass {{$.Host.ForwardScheme}}://{{$.Host.ForwardHost}}:{{$.Host.ForwardPort}};{{end}}
+        {{if $.Upstream}}proxy_pass {{$.UpstreamScheme}}://{{$.Upstream.Name}};{{else}}proxy_pass {{$.Host.ForwardScheme}}://{{$.Host.ForwardHost}}:{{$.Host.ForwardPort}};{{end}}
         include /etc/nginx/includes/proxy_params.conf;
-{{else}}
-        set $block_reason_var "uri_block";
-        return 403;
-{{end}}
+{{if $.UpstreamTLS}}        include /etc/nginx/conf.d/includes/upstream_tls_{{$.Host.ID}}.conf;
+{{end}}{{else}}
+        if ($trusted_ip = 0) {
+            set $block_reason_var "uri_block";
+            return 403;
+        }
+        # Pass through to upstream for global trusted networks
+        {{if $.Upstream}}proxy_pass {{$.UpstreamScheme}}://{{$.Upstream.Name}};{{else}}proxy_pass {{$.Host.ForwardScheme}}://{{$.Host.ForwardHost}}:{{$.Host.ForwardPort}};{{end}}
+        include /etc/nginx/includes/proxy_params.conf;
+{{if $.UpstreamTLS}}        include /etc/nginx/conf.d/includes/upstream_tls_{{$.Host.ID}}.conf;
+{{end}}{{end}}
     }
 {{end}}{{end}}
 {{end}}{{end}}
 
+{{if .OIDCGate}}
+    # OIDC login gate - session validation endpoint (internal)
+    location = /_oidc/validate {
+        internal;
+        proxy_pass http://{{apiHost}}/api/v1/oidc/validate;
+        proxy_pass_request_body off;
+        proxy_set_header Content-Length "";
+        proxy_set_header X-Proxy-Host-ID "{{.Host.ID}}";
+        proxy_set_header X-OIDC-Session $cookie_ng_oidc_session;
+        # Fast timeout - fail fast if API is down
+        proxy_connect_timeout 2s;
+        proxy_read_timeout 5s;
+    }
+
+    # Send users without a valid session to the login flow (return must stay the last parameter)
+    error_page 401 = @oidc_login;
+    location @oidc_login {
+        return 302 /api/v1/oidc/login?host={{.Host.ID}}&return=$scheme://$host$request_uri;
+    }
+
+    # OIDC login gate - login, callback and logout endpoints
+    location /api/v1/oidc/ {
+        # Disable WAF for login parameters (return URL triggers SQLi/RFI rules)
+        modsecurity off;
+
+        proxy_pass http://{{apiHost}}/api/v1/oidc/;
+        proxy_set_header Host $host;
+        proxy_set_header X-Real-IP $remote_addr;
+        # Use $remote_addr directly to prevent X-Forwarded-For header spoofing
+        proxy_set_header X-Forwarded-For $remote_addr;
+        proxy_set_header X-Forwarded-Proto $scheme;
+    }
+{{end}}
+{{if .ForwardAuth}}
+    # Forward auth subrequest endpoint (internal)
+    location = /_forward_auth {
+        internal;
+{{if .ForwardAuthBypassRegex}}
+        # Bypass paths are served without authentication. Both the normalized
+        # URI of the protected location and the raw request URI must match,
+        # so dot segments and encoded slashes can't reach other paths.
+        set $forward_auth_bypass "";
+        if ($auth_original_uri ~ "{{.ForwardAuthBypassRegex}}") {
+            set $forward_auth_bypass "1";
+        }
+        if ($request_uri ~ "{{.ForwardAuthBypassRegex}}") {
+            set $forward_auth_bypass "${forward_auth_bypass}1";
+        }
+        if ($forward_auth_bypass = "11") {
+            return 200;
+        }
+{{end}}
+        proxy_pass {{.ForwardAuth.AuthURL}};
+        proxy_pass_request_body off;
+        proxy_set_header Content-Length "";
+        proxy_set_header X-Original-URL $scheme://$http_host$request_uri;
+        proxy_set_header X-Original-Method $request_method;
+        proxy_set_header X-Forwarded-Method $request_method;
+        proxy_set_header X-Forwarded-Proto $scheme;
+        proxy_set_header X-Forwarded-Host $http_host;
+        proxy_set_header X-Forwarded-Uri $request_uri;
+        proxy_set_header X-Forwarded-For $remote_addr;
+        proxy_set_header X-Real-IP $remote_addr;
+        proxy_connect_timeout 5s;
+        proxy_read_timeout 10s;
+    }
+{{if .ForwardAuthSigninRedirect}}
+    # Send unauthenticated users to the sign-in page
+    error_page 401 =302 {{.ForwardAuthSigninRedirect}}$scheme://$http_host$request_uri;
+{{end}}
+{{end}}
 {{if .GeoRestriction}}{{if .GeoRestriction.ChallengeMode}}
     # Challenge validation endpoint (internal)
     location = /_challenge/validate {
@@ -1429,9 +1925,10 @@ server {
     location @api_fallback {
         # API is down - allow request to proceed (graceful degradation)
         access_log /etc/nginx/logs/access_raw.log main buffer=64k flush=5s;
-        {{if .Upstream}}proxy_pass http://{{.Upstream.Name}};{{else}}proxy_pass {{.Host.ForwardScheme}}://{{.Host.ForwardHost}}:{{.Host.ForwardPort}};{{end}}
+        {{if .Upstream}}proxy_pass {{$.UpstreamScheme}}://{{.Upstream.Name}};{{else}}proxy_pass {{.Host.ForwardScheme}}://{{.Host.ForwardHost}}:{{.Host.ForwardPort}};{{end}}
         include /etc/nginx/includes/proxy_params.conf;
-    }
+{{if $.UpstreamTLS}}        include /etc/nginx/conf.d/includes/upstream_tls_{{$.Host.ID}}.conf;
+{{end}}    }
 
     # Challenge API - Bypass GeoIP and proxy to API service
     location /api/v1/challenge/ {
@@ -1448,8 +1945,35 @@ server {
     }
 {{end}}{{end}}
 
+{{if .SignedURLInclude}}
+    # Signed URL locations (secure_link)
+    include /etc/nginx/conf.d/includes/{{.SignedURLInclude}};
+{{end}}
 {{if not .HasCustomLocationRoot}}
     location / {
+{{if .CORS}}
+        # CORS (answered before access checks, preflights carry no credentials)
+        if ($cors_preflight_{{sanitizeID .Host.ID}}) {
+            add_header Access-Control-Allow-Origin $cors_origin_{{sanitizeID .Host.ID}} always;
+            add_header Access-Control-Allow-Credentials $cors_credentials_{{sanitizeID .Host.ID}} always;
+            add_header Access-Control-Allow-Methods $cors_methods_{{sanitizeID .Host.ID}} always;
+            add_header Access-Control-Allow-Headers $cors_headers_{{sanitizeID .Host.ID}} always;
+            add_header Access-Control-Max-Age $cors_max_age_{{sanitizeID .Host.ID}} always;
+            add_header Vary "Origin, Access-Control-Request-Method, Access-Control-Request-Headers" always;
+            return 204;
+        }
+        add_header Access-Control-Allow-Origin $cors_origin_{{sanitizeID .Host.ID}} always;
+        add_header Access-Control-Allow-Credentials $cors_credentials_{{sanitizeID .Host.ID}} always;
+        add_header Access-Control-Expose-Headers $cors_expose_{{sanitizeID .Host.ID}} always;
+        add_header Vary Origin always;
+{{if .CORS.HideUpstreamHeaders}}
+        proxy_hide_header Access-Control-Allow-Origin;
+        proxy_hide_header Access-Control-Allow-Credentials;
+        proxy_hide_header Access-Control-Allow-Methods;
+        proxy_hide_header Access-Control-Allow-Headers;
+        proxy_hide_header Access-Control-Expose-Headers;
+        proxy_hide_header Access-Control-Max-Age;
+{{end}}{{end}}
 {{if .GeoRestriction}}{{if .GeoRestriction.ChallengeMode}}
         # Check challenge token for geo-blocked users (skip for search bots)
         set $challenge_check 0;
@@ -1476,9 +2000,30 @@ server {
         error_page 401 = @challenge_redirect;
         error_page 500 502 503 504 = @api_fallback;
 {{end}}{{end}}
-        {{if .Upstream}}proxy_pass http://{{.Upstream.Name}};{{else}}proxy_pass {{.Host.ForwardScheme}}://{{.Host.ForwardHost}}:{{.Host.ForwardPort}};{{end}}
+{{if .ForwardAuth}}{{if .ForwardAuth.ProtectHost}}
+        # Forward auth
+        include /etc/nginx/conf.d/includes/forward_auth_{{.Host.ID}}.conf;
+{{end}}{{end}}
+{{if .OIDCGate}}
+        # OIDC login gate
+        auth_request /_oidc/validate;
+        auth_request_set $oidc_user $upstream_http_x_auth_user;
+        auth_request_set $oidc_email $upstream_http_x_auth_email;
+        proxy_set_header X-Auth-Request-User $oidc_user;
+        proxy_set_header X-Auth-Request-Email $oidc_email;
+{{end}}
+{{if .ClientCertAuth}}{{if .ClientCertAuth.ForwardHeaders}}
+        # Client certificate details for the upstream
+        proxy_set_header X-Client-Cert-Verify $ssl_client_verify;
+        proxy_set_header X-Client-Cert-DN $ssl_client_s_dn;
+        proxy_set_header X-Client-Cert-Issuer-DN $ssl_client_i_dn;
+        proxy_set_header X-Client-Cert-Fingerprint $ssl_client_fingerprint;
+        proxy_set_header X-Client-Cert-Serial $ssl_client_serial;
+{{end}}{{end}}
+        {{if .Upstream}}proxy_pass {{$.UpstreamScheme}}://{{.Upstream.Name}};{{else}}proxy_pass {{.Host.ForwardScheme}}://{{.Host.ForwardHost}}:{{.Host.ForwardPort}};{{end}}
         include /etc/nginx/includes/proxy_params.conf;
-        {{if .GlobalSettings}}
+{{if $.UpstreamTLS}}        include /etc/nginx/conf.d/includes/upstream_tls_{{$.Host.ID}}.conf;
+{{end}}        {{if .GlobalSettings}}
         # Proxy settings (Host-level overrides Global)
         {{if gt .Host.ProxyConnectTimeout 0}}proxy_connect_timeout {{.Host.ProxyConnectTimeout}}s;{{else if gt .GlobalSettings.ProxyConnectTimeout 0}}proxy_connect_timeout {{.GlobalSettings.ProxyConnectTimeout}}s;{{end}}
         {{if .Host.AllowWebsocketUpgrade}}proxy_send_timeout 86400s;{{else if gt .Host.ProxySendTimeout 0}}proxy_send_timeout {{.Host.ProxySendTimeout}}s;{{else if gt .GlobalSettings.ProxySendTimeout 0}}proxy_send_timeout {{.GlobalSettings.ProxySendTimeout}}s;{{end}}
@@ -1535,11 +2080,12 @@ server {
         proxy_cache_use_stale error timeout http_500 http_502 http_503 http_504;
         add_header X-Cache-Status $upstream_cache_status always;
         {{end}}
+        {{if .HSTSHeader}}
+        # HSTS (from the TLS policy, otherwise the security headers)
+        add_header Strict-Transport-Security "{{.HSTSHeader}}" always;
+        {{end}}
         {{if .SecurityHeaders}}{{if .SecurityHeaders.Enabled}}
         # Security Headers (in location block to ensure they are applied)
-        {{if .SecurityHeaders.HSTSEnabled}}
-        add_header Strict-Transport-Security "max-age={{.SecurityHeaders.HSTSMaxAge}}{{if .SecurityHeaders.HSTSIncludeSubdomains}}; includeSubDomains{{end}}{{if .SecurityHeaders.HSTSPreload}}; preload{{end}}" always;
-        {{end}}
         {{if .SecurityHeaders.XFrameOptions}}
         add_header X-Frame-Options "{{.SecurityHeaders.XFrameOptions}}" always;
         {{end}}
@@ -1583,13 +2129,19 @@ server {
     }
 {{end}}{{end}}
 
+{{if .ThreatFeedChallengeRanges}}
+    # Threat feed challenge redirect handler
+    location @threat_feed_challenge {
+        return 302 /api/v1/challenge/page?host={{.Host.ID}}&reason=threat_feed&return=$scheme://$host$request_uri;
+    }
+{{end}}
+
 {{if .Host.AdvancedConfig}}
     # Advanced configuration
     {{.Host.AdvancedConfig}}
 {{end}}
 }
 {{end}}
-{{end}}
 `
 
 // ProxyHostConfigData holds all data for proxy host config generation
@@ -1610,11 +2162,49 @@ type ProxyHostConfigData struct {
 	BlockedCloudIPRanges          []string              // CIDR ranges of blocked cloud providers
 	CloudProviderChallengeMode    bool                  // If true, show challenge instead of blocking cloud providers
 	CloudProviderAllowSearchBots  bool                  // If true, allow search engine bots to bypass cloud provider blocking
+	ThreatFeedBlockRanges         []string              // IP/CIDR entries from threat feeds subscribed with block action
+	ThreatFeedChallengeRanges     []string              // IP/CIDR entries from threat feeds subscribed with challenge action
 	URIBlock                      *model.URIBlock       // URI path blocking settings
+	ForwardAuth                   *model.ForwardAuthConfig // Enabled forward auth settings
+	ForwardAuthBypassRegex        string                // Regex of request URIs that skip forward auth
+	ForwardAuthSigninRedirect     string                // Sign-in URL up to the rd= parameter the original URL is appended to
+	OIDCGate                      *model.OIDCHostGate   // Enabled built-in OIDC login gate
+	ClientCertAuth                *model.ClientCertAuthConfig // Enabled mTLS settings (HTTPS only)
+	ClientCA                      *model.ClientCA       // Client CA referenced by ClientCertAuth
+	ClientCertEnforceRegex        string                // Regex of URIs requiring a client certificate in the optional modes
+	UpstreamTLS                   *model.UpstreamTLSConfig // Enabled backend TLS settings
+	UpstreamScheme                string                // Scheme of the upstream group (https with backend TLS)
+	TLSPolicy                     *model.TLSPolicy      // Per-host TLS policy (nil uses the defaults)
+	HSTSHeader                    string                // Strict-Transport-Security value from the TLS policy or security headers
+	CORSPolicies                  []model.CORSPolicy    // CORS policies of the host and its path prefixes
+	CORS                          *CORSConfig           // Map data of the enabled CORS policies, nil when none
+	SignedURLLocations            []model.SignedURLLocation // Path prefixes served only through signed links
+	SignedURLInclude              string                // secure_link locations include, empty when none are enabled
 	GlobalBlockExploitsExceptions string                // Global newline-separated list of exploit exceptions from system settings
 	ExploitBlockRules             []model.ExploitBlockRule // Dynamic exploit blocking rules from database
 	HasCustomLocationRoot         bool                  // True if AdvancedConfig contains a location / block
 	AdvancedConfigHasLocation     bool                  // True if AdvancedConfig contains any location directive
 	HTTPPort                      string                // HTTP listen port (default: 80)
 	HTTPSPort                     string                // HTTPS listen port (default: 443)
+	ListenParams                  string                // Extra parameters of the standard listeners (proxy_protocol)
+	ProxyProtocolHTTPPort         string                // Dedicated PROXY protocol HTTP port, empty when not listening on it
+	ProxyProtocolHTTPSPort        string                // Dedicated PROXY protocol HTTPS port, empty when not listening on it
+	RealIPInclude                 string                // real_ip include matching the standard listeners
+}
+
+// serverListenerData renders the server block of a host for its standard
+// listeners, or for the dedicated PROXY protocol port
+type serverListenerData struct {
+	ProxyHostConfigData
+	ProxyProtocolPort string // Dedicated PROXY protocol port, empty for the standard listeners
+	RealIPInclude     string // real_ip include matching the listeners of this block
+}
+
+// serverListener returns the data of the server block listening on the
+// dedicated PROXY protocol port, or on the standard listeners when port is empty
+func serverListener(data ProxyHostConfigData, port string) serverListenerData {
+	if port == "" {
+		return serverListenerData{ProxyHostConfigData: data, RealIPInclude: data.RealIPInclude}
+	}
+	return serverListenerData{ProxyHostConfigData: data, ProxyProtocolPort: port, RealIPInclude: realIPProxyProtocolIncludeFile}
 }
@@ -0,0 +1,104 @@
+package nginx
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"nginx-proxy-guard/internal/model"
+)
+
+const testCertificateID = "cert-1"
+
+// newTestManager returns a manager writing to temporary directories, with
+// the files of testCertificateID in place so SSL hosts keep SSL enabled
+func newTestManager(t *testing.T) *Manager {
+	t.Helper()
+	m := NewManager(t.TempDir(), t.TempDir())
+	if err := os.MkdirAll(filepath.Join(m.configPath, "includes"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	certDir := filepath.Join(m.certsPath, testCertificateID)
+	if err := os.MkdirAll(certDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(certDir, "fullchain.pem"), []byte("test"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+// testProxyHost returns a plain HTTP host proxying to http://backend:8080
+func testProxyHost() *model.ProxyHost {
+	return &model.ProxyHost{
+		ID:            "host-1",
+		DomainNames:   []string{"app.example.com"},
+		ForwardScheme: "http",
+		ForwardHost:   "backend",
+		ForwardPort:   8080,
+		Enabled:       true,
+	}
+}
+
+// withSSL enables SSL with Force HTTPS on host using testCertificateID
+func withSSL(host *model.ProxyHost) *model.ProxyHost {
+	certID := testCertificateID
+	host.SSLEnabled = true
+	host.SSLForceHTTPS = true
+	host.CertificateID = &certID
+	return host
+}
+
+// renderProxyHost generates the config of data and returns it
+func renderProxyHost(t *testing.T, m *Manager, data ProxyHostConfigData) string {
+	t.Helper()
+	if err := m.GenerateConfigFull(context.Background(), data); err != nil {
+		t.Fatalf("GenerateConfigFull() error = %v", err)
+	}
+	config, err := os.ReadFile(filepath.Join(m.configPath, GetConfigFilename(data.Host)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(config)
+}
+
+// serverBlock returns the server block of config listening on port, up to
+// the next server block
+func serverBlock(t *testing.T, config, listen string) string {
+	t.Helper()
+	start := strings.Index(config, "listen "+listen)
+	if start < 0 {
+		t.Fatalf("no server listening on %s", listen)
+	}
+	block := config[start:]
+	if end := strings.Index(block[1:], "\nserver {"); end >= 0 {
+		block = block[:end+1]
+	}
+	return block
+}
+
+// locationBlock returns the body of the first location block of config
+// starting with directive, e.g. "location / {"
+func locationBlock(t *testing.T, config, directive string) string {
+	t.Helper()
+	start := strings.Index(config, directive)
+	if start < 0 {
+		t.Fatalf("no %q in config", directive)
+	}
+	depth := 0
+	for i := start; i < len(config); i++ {
+		switch config[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return config[start : i+1]
+			}
+		}
+	}
+	t.Fatalf("unterminated %q", directive)
+	return ""
+}
@@ -0,0 +1,39 @@
+package nginx
+
+// PROXY protocol is a property of the listening socket: nginx enables it on an address
+// as soon as one server block asks for it. Hosts can therefore only opt in through
+// dedicated ports, while the global switch applies to every server on the standard ports.
+
+// ProxyProtocolListeners configures which listeners expect the PROXY protocol
+type ProxyProtocolListeners struct {
+	Enabled   bool   // The standard HTTP/HTTPS listeners of every server require the PROXY protocol
+	HTTPPort  string // Dedicated PROXY protocol HTTP port for opted-in hosts, empty for none
+	HTTPSPort string // Dedicated PROXY protocol HTTPS port for opted-in hosts, empty for none
+}
+
+// SetProxyProtocolListeners sets the PROXY protocol listeners used by subsequently generated configs
+func (m *Manager) SetProxyProtocolListeners(listeners ProxyProtocolListeners) {
+	m.listenersMu.Lock()
+	defer m.listenersMu.Unlock()
+	m.proxyProtocol = listeners
+}
+
+// GetProxyProtocolListeners returns the PROXY protocol listeners
+func (m *Manager) GetProxyProtocolListeners() ProxyProtocolListeners {
+	m.listenersMu.RLock()
+	defer m.listenersMu.RUnlock()
+	return m.proxyProtocol
+}
+
+// proxyProtocolParam returns the listen parameter for the standard ports
+func (l ProxyProtocolListeners) proxyProtocolParam() string {
+	if l.Enabled {
+		return " proxy_protocol"
+	}
+	return ""
+}
+
+// hasProxyProtocol reports whether a server listening on the dedicated ports receives PROXY protocol connections
+func (l ProxyProtocolListeners) hasProxyProtocol(dedicatedHTTP, dedicatedHTTPS bool) bool {
+	return l.Enabled || (dedicatedHTTP && l.HTTPPort != "") || (dedicatedHTTPS && l.HTTPSPort != "")
+}
@@ -0,0 +1,70 @@
+package nginx
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestProxyProtocolMixedTraffic(t *testing.T) {
+	m := newTestManager(t)
+	m.SetProxyProtocolListeners(ProxyProtocolListeners{HTTPPort: "8081", HTTPSPort: "8443"})
+
+	host := withSSL(testProxyHost())
+	host.ProxyProtocol = true
+	host.SSLHTTP3 = true
+	config := renderProxyHost(t, m, ProxyHostConfigData{Host: host})
+
+	const (
+		realIP              = "include /etc/nginx/conf.d/includes/" + realIPIncludeFile + ";"
+		realIPProxyProtocol = "include /etc/nginx/conf.d/includes/" + realIPProxyProtocolIncludeFile + ";"
+	)
+	tests := []struct {
+		listen        string
+		include, not  string
+		proxyProtocol bool
+	}{
+		{"80", realIP, realIPProxyProtocol, false},
+		{"443", realIP, realIPProxyProtocol, false},
+		{"8081 proxy_protocol", realIPProxyProtocol, realIP, true},
+		{"8443 ssl proxy_protocol", realIPProxyProtocol, realIP, true},
+	}
+	for _, tt := range tests {
+		block := serverBlock(t, config, tt.listen)
+		if !strings.Contains(block, tt.include) {
+			t.Errorf("server on %s is missing %q", tt.listen, tt.include)
+		}
+		if strings.Contains(block, tt.not) {
+			t.Errorf("server on %s must not use %q", tt.listen, tt.not)
+		}
+		if got := strings.Count(block, "proxy_protocol;"); tt.proxyProtocol != (got > 0) {
+			t.Errorf("server on %s has %d PROXY protocol listeners", tt.listen, got)
+		}
+		if !strings.Contains(block, "server_name app.example.com;") {
+			t.Errorf("server on %s is missing the host's server_name", tt.listen)
+		}
+	}
+
+	if strings.Contains(serverBlock(t, config, "8443 ssl proxy_protocol"), "quic") {
+		t.Error("the PROXY protocol server must not listen for QUIC, the standard server already does")
+	}
+	if !strings.Contains(serverBlock(t, config, "443"), "listen 443 quic;") {
+		t.Error("the standard HTTPS server lost its QUIC listener")
+	}
+}
+
+func TestProxyProtocolStandardListeners(t *testing.T) {
+	m := newTestManager(t)
+	m.SetProxyProtocolListeners(ProxyProtocolListeners{Enabled: true})
+
+	config := renderProxyHost(t, m, ProxyHostConfigData{Host: testProxyHost()})
+	block := serverBlock(t, config, "80")
+	if !strings.Contains(block, "listen 80 proxy_protocol;") {
+		t.Error("standard listener must require the PROXY protocol")
+	}
+	if !strings.Contains(block, "include /etc/nginx/conf.d/includes/"+realIPProxyProtocolIncludeFile+";") {
+		t.Error("standard listener must take the client IP from the PROXY protocol header")
+	}
+	if strings.Count(config, "\nserver {") != 1 {
+		t.Error("without dedicated ports the host needs a single server block")
+	}
+}
@@ -0,0 +1,126 @@
+package nginx
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"nginx-proxy-guard/internal/model"
+)
+
+// Real IP directives are rendered at server level into a shared include. Server-level
+// set_real_ip_from replaces the http-level defaults of nginx.conf, so a disabled
+// configuration renders no directives and leaves those defaults in effect.
+// Servers accepting the PROXY protocol include a variant reading the address from the
+// PROXY header instead.
+const (
+	realIPIncludeFile              = "real_ip.conf"
+	realIPProxyProtocolIncludeFile = "real_ip_proxy_protocol.conf"
+)
+
+// EnsureRealIPInclude makes sure the real IP include referenced by every host exists
+func (m *Manager) EnsureRealIPInclude() error {
+	includesPath := filepath.Join(m.configPath, "includes")
+	if err := os.MkdirAll(includesPath, 0755); err != nil {
+		return fmt.Errorf("failed to create includes directory: %w", err)
+	}
+
+	defaults := map[string]string{
+		realIPIncludeFile:              renderRealIPInclude(nil, nil),
+		realIPProxyProtocolIncludeFile: renderRealIPProxyProtocolInclude(nil),
+	}
+	for name, content := range defaults {
+		includeFile := filepath.Join(includesPath, name)
+		if _, err := os.Stat(includeFile); os.IsNotExist(err) {
+			if err := m.writeFileAtomic(includeFile, []byte(content), 0644); err != nil {
+				return fmt.Errorf("failed to write %s: %w", name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// UpdateRealIP rewrites the real IP includes with the trusted proxy sources and the
+// load balancers trusted to send the PROXY protocol
+func (m *Manager) UpdateRealIP(ctx context.Context, settings *model.RealIPSettings, sources, proxyProtocolSources []string) error {
+	// Lock globally to prevent race condition with other config operations
+	return m.executeWithLock(ctx, func() error {
+		if err := m.EnsureRealIPInclude(); err != nil {
+			return err
+		}
+
+		configFile := filepath.Join(m.configPath, "includes", realIPIncludeFile)
+		if err := m.writeFileAtomic(configFile, []byte(renderRealIPInclude(settings, sources)), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", realIPIncludeFile, err)
+		}
+		configFile = filepath.Join(m.configPath, "includes", realIPProxyProtocolIncludeFile)
+		if err := m.writeFileAtomic(configFile, []byte(renderRealIPProxyProtocolInclude(proxyProtocolSources)), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", realIPProxyProtocolIncludeFile, err)
+		}
+
+		// Test and reload nginx to apply changes (within the same lock)
+		if !m.skipTest {
+			if err := m.testAndReloadNginx(ctx); err != nil {
+				return fmt.Errorf("failed to reload nginx after updating real IP settings: %w", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// renderRealIPInclude renders the realip directives; nil settings or no sources render none
+func renderRealIPInclude(settings *model.RealIPSettings, sources []string) string {
+	var content strings.Builder
+	content.WriteString("# Auto-generated real IP configuration - DO NOT EDIT\n")
+	content.WriteString("# This file is managed by Nginx Proxy Guard\n\n")
+
+	if settings == nil || !settings.Enabled || len(sources) == 0 {
+		content.WriteString("# Using the nginx.conf defaults\n")
+		return content.String()
+	}
+
+	writeRealIPSources(&content, sources)
+	content.WriteString(fmt.Sprintf("real_ip_header %s;\n", settings.Header))
+	if settings.Recursive {
+		content.WriteString("real_ip_recursive on;\n")
+	} else {
+		content.WriteString("real_ip_recursive off;\n")
+	}
+
+	return content.String()
+}
+
+// renderRealIPProxyProtocolInclude renders the realip directives of servers accepting the PROXY protocol
+// Without sources, the private ranges trusted by nginx.conf are used
+func renderRealIPProxyProtocolInclude(sources []string) string {
+	var content strings.Builder
+	content.WriteString("# Auto-generated PROXY protocol real IP configuration - DO NOT EDIT\n")
+	content.WriteString("# This file is managed by Nginx Proxy Guard\n\n")
+
+	if len(sources) == 0 {
+		sources = defaultRealIPSources
+	}
+	writeRealIPSources(&content, sources)
+	content.WriteString("real_ip_header proxy_protocol;\n")
+
+	return content.String()
+}
+
+// defaultRealIPSources are the set_real_ip_from defaults of nginx.conf
+var defaultRealIPSources = []string{"10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "127.0.0.0/8", "::1", "fc00::/7"}
+
+// writeRealIPSources writes deduplicated set_real_ip_from directives
+func writeRealIPSources(content *strings.Builder, sources []string) {
+	seen := make(map[string]bool, len(sources))
+	for _, cidr := range sources {
+		if cidr == "" || seen[cidr] {
+			continue
+		}
+		seen[cidr] = true
+		content.WriteString(fmt.Sprintf("set_real_ip_from %s;\n", cidr))
+	}
+}
@@ -16,6 +16,12 @@ type RedirectHostConfigData struct {
 	Host      *model.RedirectHost
 	HTTPPort  string
 	HTTPSPort string
+
+	// PROXY protocol listeners; redirects answer on the dedicated ports like the default server
+	ListenParams           string
+	ProxyProtocolHTTPPort  string
+	ProxyProtocolHTTPSPort string
+	ProxyProtocol          bool // Any listener receives PROXY protocol connections
 }
 
 // Redirect host config template
@@ -27,9 +33,17 @@ const redirectHostTemplate = `# nginx-guard generated redirect config
 
 {{if .Host.Enabled}}
 server {
-    listen {{.HTTPPort}};
-    listen [::]:{{.HTTPPort}};
+    listen {{.HTTPPort}}{{.ListenParams}};
+    listen [::]:{{.HTTPPort}}{{.ListenParams}};
+{{if .ProxyProtocolHTTPPort}}
+    listen {{.ProxyProtocolHTTPPort}} proxy_protocol;
+    listen [::]:{{.ProxyProtocolHTTPPort}} proxy_protocol;
+{{end}}
     server_name {{join .Host.DomainNames " "}};
+{{if .ProxyProtocol}}
+    # Client IP from the PROXY protocol header
+    include /etc/nginx/conf.d/includes/real_ip_proxy_protocol.conf;
+{{end}}
 
     # ACME HTTP-01 Challenge support
     location /.well-known/acme-challenge/ {
@@ -62,12 +76,20 @@ server {
 
 {{if .Host.SSLEnabled}}
 server {
-    listen {{.HTTPSPort}} ssl;
-    listen [::]:{{.HTTPSPort}} ssl;
+    listen {{.HTTPSPort}} ssl{{.ListenParams}};
+    listen [::]:{{.HTTPSPort}} ssl{{.ListenParams}};
+{{if .ProxyProtocolHTTPSPort}}
+    listen {{.ProxyProtocolHTTPSPort}} ssl proxy_protocol;
+    listen [::]:{{.ProxyProtocolHTTPSPort}} ssl proxy_protocol;
+{{end}}
     http2 on;
     listen {{.HTTPSPort}} quic;
     listen [::]:{{.HTTPSPort}} quic;
     server_name {{join .Host.DomainNames " "}};
+{{if .ProxyProtocol}}
+    # Client IP from the PROXY protocol header
+    include /etc/nginx/conf.d/includes/real_ip_proxy_protocol.conf;
+{{end}}
 
     # SSL configuration
     ssl_certificate /etc/nginx/certs/{{certPath .Host}}/fullchain.pem;
@@ -106,6 +128,18 @@ func (m *Manager) GenerateRedirectConfig(ctx context.Context, host *model.Redire
 		HTTPPort:  m.httpPort,
 		HTTPSPort: m.httpsPort,
 	}
+	listeners := m.GetProxyProtocolListeners()
+	data.ListenParams = listeners.proxyProtocolParam()
+	data.ProxyProtocolHTTPPort = listeners.HTTPPort
+	if host.SSLEnabled {
+		data.ProxyProtocolHTTPSPort = listeners.HTTPSPort
+	}
+	data.ProxyProtocol = listeners.hasProxyProtocol(data.ProxyProtocolHTTPPort != "", data.ProxyProtocolHTTPSPort != "")
+	if data.ProxyProtocol {
+		if err := m.EnsureRealIPInclude(); err != nil {
+			return err
+		}
+	}
 
 	var buf bytes.Buffer
 	if err := tmpl.Execute(&buf, data); err != nil {
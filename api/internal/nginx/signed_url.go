@@ -0,0 +1,74 @@
+package nginx
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// GenerateSignedURLInclude writes the secure_link locations of a host's enabled signed URL
+// locations and sets data.SignedURLInclude, or removes the file when there are none
+// Requests without a valid signature are refused with 403, expired links with 410
+func (m *Manager) GenerateSignedURLInclude(data *ProxyHostConfigData) error {
+	data.SignedURLInclude = ""
+
+	var sb strings.Builder
+	count := 0
+	for _, loc := range data.SignedURLLocations {
+		if !loc.Enabled {
+			continue
+		}
+		count++
+
+		expr := strings.ReplaceAll(loc.HashExpression, "$secret", loc.Secret)
+		sb.WriteString(fmt.Sprintf("location ^~ %s {\n", loc.PathPrefix))
+		sb.WriteString(fmt.Sprintf("    secure_link $arg_%s,$arg_%s;\n", loc.TokenParam, loc.ExpiresParam))
+		sb.WriteString(fmt.Sprintf("    secure_link_md5 \"%s\";\n", expr))
+		sb.WriteString("    if ($secure_link = \"\") {\n")
+		sb.WriteString("        set $block_reason_var \"signed_url\";\n")
+		sb.WriteString("        set $exploit_rule_var \"invalid\";\n")
+		sb.WriteString("        return 403;\n")
+		sb.WriteString("    }\n")
+		sb.WriteString("    if ($secure_link = \"0\") {\n")
+		sb.WriteString("        set $block_reason_var \"signed_url\";\n")
+		sb.WriteString("        set $exploit_rule_var \"expired\";\n")
+		sb.WriteString("        return 410;\n")
+		sb.WriteString("    }\n")
+		if data.Upstream != nil {
+			sb.WriteString(fmt.Sprintf("    proxy_pass %s://%s;\n", data.UpstreamScheme, data.Upstream.Name))
+		} else {
+			sb.WriteString(fmt.Sprintf("    proxy_pass %s://%s:%d;\n", data.Host.ForwardScheme, data.Host.ForwardHost, data.Host.ForwardPort))
+		}
+		sb.WriteString("    include /etc/nginx/includes/proxy_params.conf;\n")
+		if data.UpstreamTLS != nil {
+			sb.WriteString(fmt.Sprintf("    include %s;\n", UpstreamTLSIncludePath(data.Host.ID)))
+		}
+		sb.WriteString("}\n")
+	}
+
+	if count == 0 {
+		return m.RemoveSignedURLInclude(data.Host.ID)
+	}
+
+	content := fmt.Sprintf("# Signed URL locations for host %s\n%s", data.Host.ID, sb.String())
+	path := filepath.Join(m.configPath, "includes", signedURLFilename(data.Host.ID))
+	if err := m.writeFileAtomic(path, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write signed URL include file: %w", err)
+	}
+	data.SignedURLInclude = signedURLFilename(data.Host.ID)
+	return nil
+}
+
+// RemoveSignedURLInclude removes the signed URL locations of a host
+func (m *Manager) RemoveSignedURLInclude(hostID string) error {
+	path := filepath.Join(m.configPath, "includes", signedURLFilename(hostID))
+	if _, err := os.Stat(path); err == nil {
+		return os.Remove(path)
+	}
+	return nil
+}
+
+func signedURLFilename(hostID string) string {
+	return fmt.Sprintf("signed_urls_%s.conf", hostID)
+}
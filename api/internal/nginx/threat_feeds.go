@@ -0,0 +1,67 @@
+package nginx
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Geo values used in the threat feed include file
+const (
+	threatFeedGeoBlock     = "1"
+	threatFeedGeoChallenge = "2"
+)
+
+// GenerateThreatFeedsInclude generates the include file for a host's threat feed geo mapping
+// Block entries map to 1 and challenge entries to 2; an address listed in both is blocked
+func (m *Manager) GenerateThreatFeedsInclude(hostID string, blockRanges, challengeRanges []string) error {
+	if len(blockRanges) == 0 && len(challengeRanges) == 0 {
+		return m.RemoveThreatFeedsInclude(hostID)
+	}
+
+	includesDir := filepath.Join(m.configPath, "includes")
+	if err := os.MkdirAll(includesDir, 0755); err != nil {
+		return fmt.Errorf("failed to create includes directory: %w", err)
+	}
+
+	// nginx geo rejects duplicate networks, so dedupe with block taking precedence
+	seen := make(map[string]bool, len(blockRanges)+len(challengeRanges))
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("# Threat feed IPs for host %s\n", hostID))
+	sb.WriteString(fmt.Sprintf("# Block ranges: %d, challenge ranges: %d\n", len(blockRanges), len(challengeRanges)))
+
+	for _, ip := range blockRanges {
+		if seen[ip] {
+			continue
+		}
+		seen[ip] = true
+		sb.WriteString(fmt.Sprintf("    %s %s;\n", ip, threatFeedGeoBlock))
+	}
+	for _, ip := range challengeRanges {
+		if seen[ip] {
+			continue
+		}
+		seen[ip] = true
+		sb.WriteString(fmt.Sprintf("    %s %s;\n", ip, threatFeedGeoChallenge))
+	}
+
+	includePath := filepath.Join(includesDir, fmt.Sprintf("threat_feeds_%s.conf", hostID))
+
+	// Use atomic write to prevent nginx from reading partial config
+	if err := m.writeFileAtomic(includePath, []byte(sb.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write threat feeds include file: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveThreatFeedsInclude removes the threat feeds include file for a host
+func (m *Manager) RemoveThreatFeedsInclude(hostID string) error {
+	includePath := filepath.Join(m.configPath, "includes", fmt.Sprintf("threat_feeds_%s.conf", hostID))
+	if _, err := os.Stat(includePath); err == nil {
+		return os.Remove(includePath)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package nginx
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"nginx-proxy-guard/internal/model"
+)
+
+// Trusted IPs are global never-ban networks. Host configs check $trusted_ip in every
+// blocking block, so list changes only rewrite the include and reload nginx.
+const (
+	trustedIPsGeoFile     = "shared_trusted_ips.conf"
+	trustedIPsIncludeFile = "trusted_ips.conf"
+)
+
+// EnsureSharedTrustedIPs makes sure the shared $trusted_ip geo mapping exists
+// Host configs reference the variable unconditionally, so the mapping must exist before nginx -t
+func (m *Manager) EnsureSharedTrustedIPs() error {
+	includesPath := filepath.Join(m.configPath, "includes")
+	if err := os.MkdirAll(includesPath, 0755); err != nil {
+		return fmt.Errorf("failed to create includes directory: %w", err)
+	}
+
+	includeFile := filepath.Join(includesPath, trustedIPsIncludeFile)
+	if _, err := os.Stat(includeFile); os.IsNotExist(err) {
+		if err := m.writeFileAtomic(includeFile, []byte(renderTrustedIPsInclude(nil)), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", trustedIPsIncludeFile, err)
+		}
+	}
+
+	geoFile := filepath.Join(m.configPath, trustedIPsGeoFile)
+	if _, err := os.Stat(geoFile); os.IsNotExist(err) {
+		var content strings.Builder
+		content.WriteString("# Auto-generated trusted IPs geo mapping - DO NOT EDIT\n")
+		content.WriteString("# This file is managed by Nginx Proxy Guard\n")
+		content.WriteString("geo $trusted_ip {\n")
+		content.WriteString("    default 0;\n")
+		content.WriteString(fmt.Sprintf("    include /etc/nginx/conf.d/includes/%s;\n", trustedIPsIncludeFile))
+		content.WriteString("}\n")
+		if err := m.writeFileAtomic(geoFile, []byte(content.String()), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", trustedIPsGeoFile, err)
+		}
+	}
+
+	return nil
+}
+
+// UpdateTrustedIPs rewrites the trusted_ips.conf include with the active trusted networks
+func (m *Manager) UpdateTrustedIPs(ctx context.Context, entries []model.TrustedIP) error {
+	// Lock globally to prevent race condition with other config operations
+	return m.executeWithLock(ctx, func() error {
+		if err := m.EnsureSharedTrustedIPs(); err != nil {
+			return err
+		}
+
+		configFile := filepath.Join(m.configPath, "includes", trustedIPsIncludeFile)
+		if err := m.writeFileAtomic(configFile, []byte(renderTrustedIPsInclude(entries)), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", trustedIPsIncludeFile, err)
+		}
+
+		// Test and reload nginx to apply changes (within the same lock)
+		if !m.skipTest {
+			if err := m.testAndReloadNginx(ctx); err != nil {
+				return fmt.Errorf("failed to reload nginx after updating trusted IPs: %w", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// renderTrustedIPsInclude renders geo entries for the trusted IPs include
+func renderTrustedIPsInclude(entries []model.TrustedIP) string {
+	var content strings.Builder
+	content.WriteString("# Auto-generated trusted IPs - DO NOT EDIT\n")
+	content.WriteString("# This file is managed by Nginx Proxy Guard\n\n")
+
+	if len(entries) == 0 {
+		content.WriteString("# No trusted IPs\n")
+		return content.String()
+	}
+
+	seen := make(map[string]bool, len(entries))
+	for _, e := range entries {
+		if e.CIDR == "" || seen[e.CIDR] {
+			continue
+		}
+		seen[e.CIDR] = true
+		if label := sanitizeGeoComment(e.Label); label != "" {
+			content.WriteString(fmt.Sprintf("    %s 1; # %s\n", e.CIDR, label))
+		} else {
+			content.WriteString(fmt.Sprintf("    %s 1;\n", e.CIDR))
+		}
+	}
+
+	return content.String()
+}
+
+// sanitizeGeoComment keeps a label on a single comment line
+func sanitizeGeoComment(s string) string {
+	s = strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+	return strings.TrimSpace(s)
+}
@@ -0,0 +1,93 @@
+package nginx
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"nginx-proxy-guard/internal/model"
+)
+
+// systemCABundle is the CA store of the nginx image used when no CA bundle is uploaded
+const systemCABundle = "/etc/ssl/certs/ca-certificates.crt"
+
+// UpstreamTLSIncludePath returns the path, as seen by nginx, of a host's backend TLS snippet
+func UpstreamTLSIncludePath(hostID string) string {
+	return "/etc/nginx/conf.d/includes/" + upstreamTLSFilename(hostID)
+}
+
+// GenerateUpstreamTLSInclude writes the proxy_ssl_* snippet included by every location proxying to the backend
+// serverName is the name sent as SNI and verified when the settings don't set one
+func (m *Manager) GenerateUpstreamTLSInclude(hostID, serverName string, cfg *model.UpstreamTLSConfig) error {
+	if cfg == nil || !cfg.Enabled {
+		return m.RemoveUpstreamTLSInclude(hostID)
+	}
+
+	caPath := filepath.Join(m.certsPath, "upstream_ca", hostID+".pem")
+	if cfg.TrustedCAPEM != "" {
+		if err := m.writeFileAtomic(caPath, []byte(cfg.TrustedCAPEM), 0644); err != nil {
+			return fmt.Errorf("failed to write upstream CA bundle: %w", err)
+		}
+	} else if _, err := os.Stat(caPath); err == nil {
+		os.Remove(caPath)
+	}
+
+	if cfg.ServerName != "" {
+		serverName = cfg.ServerName
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("# Backend TLS for host %s\n", hostID))
+	if cfg.Verify {
+		sb.WriteString("proxy_ssl_verify on;\n")
+		if cfg.TrustedCAPEM != "" {
+			sb.WriteString(fmt.Sprintf("proxy_ssl_trusted_certificate /etc/nginx/certs/upstream_ca/%s.pem;\n", hostID))
+		} else {
+			sb.WriteString(fmt.Sprintf("proxy_ssl_trusted_certificate %s;\n", systemCABundle))
+		}
+		sb.WriteString(fmt.Sprintf("proxy_ssl_verify_depth %d;\n", cfg.VerifyDepth))
+	} else {
+		sb.WriteString("proxy_ssl_verify off;\n")
+	}
+	if serverName != "" {
+		sb.WriteString(fmt.Sprintf("proxy_ssl_name %s;\n", serverName))
+	}
+	if cfg.SNI {
+		sb.WriteString("proxy_ssl_server_name on;\n")
+	} else {
+		sb.WriteString("proxy_ssl_server_name off;\n")
+	}
+	if cfg.ClientCertificateID != nil && *cfg.ClientCertificateID != "" {
+		certID := *cfg.ClientCertificateID
+		if _, err := os.Stat(filepath.Join(m.certsPath, certID, "fullchain.pem")); err != nil {
+			return fmt.Errorf("client certificate %s for the backend has no certificate files", certID)
+		}
+		sb.WriteString(fmt.Sprintf("proxy_ssl_certificate /etc/nginx/certs/%s/fullchain.pem;\n", certID))
+		sb.WriteString(fmt.Sprintf("proxy_ssl_certificate_key /etc/nginx/certs/%s/privkey.pem;\n", certID))
+	}
+	sb.WriteString("proxy_ssl_session_reuse on;\n")
+
+	path := filepath.Join(m.configPath, "includes", upstreamTLSFilename(hostID))
+	if err := m.writeFileAtomic(path, []byte(sb.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write upstream TLS include file: %w", err)
+	}
+	return nil
+}
+
+// RemoveUpstreamTLSInclude removes the backend TLS snippet and CA bundle of a host
+func (m *Manager) RemoveUpstreamTLSInclude(hostID string) error {
+	caPath := filepath.Join(m.certsPath, "upstream_ca", hostID+".pem")
+	if _, err := os.Stat(caPath); err == nil {
+		os.Remove(caPath)
+	}
+	path := filepath.Join(m.configPath, "includes", upstreamTLSFilename(hostID))
+	if _, err := os.Stat(path); err == nil {
+		return os.Remove(path)
+	}
+	return nil
+}
+
+func upstreamTLSFilename(hostID string) string {
+	return fmt.Sprintf("upstream_tls_%s.conf", hostID)
+}
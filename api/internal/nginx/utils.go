@@ -105,3 +105,43 @@ func GetRedirectConfigFilename(host *model.RedirectHost) string {
 	}
 	return fmt.Sprintf("redirect_host_%s.conf", safeName)
 }
+
+// pathPrefixRegex builds a regex matching request URIs under any of the given paths
+// Each path ends at a segment boundary, so /public matches /public, /public/x and
+// /public?x but not /publicity
+func pathPrefixRegex(paths []string) string {
+	if len(paths) == 0 {
+		return ""
+	}
+	quoted := make([]string, 0, len(paths))
+	for _, p := range paths {
+		if strings.HasSuffix(p, "/") {
+			quoted = append(quoted, regexp.QuoteMeta(p))
+			continue
+		}
+		quoted = append(quoted, regexp.QuoteMeta(p)+"(?:[/?]|$)")
+	}
+	return "^(?:" + strings.Join(quoted, "|") + ")"
+}
+
+// hstsHeaderValue builds a Strict-Transport-Security header value
+func hstsHeaderValue(maxAge int, includeSubdomains, preload bool) string {
+	value := fmt.Sprintf("max-age=%d", maxAge)
+	if includeSubdomains {
+		value += "; includeSubDomains"
+	}
+	if preload {
+		value += "; preload"
+	}
+	return value
+}
+
+// tlsPolicyHasProtocol reports whether a TLS policy enables a protocol
+func tlsPolicyHasProtocol(policy *model.TLSPolicy, protocol string) bool {
+	for _, p := range policy.Protocols {
+		if p == protocol {
+			return true
+		}
+	}
+	return false
+}
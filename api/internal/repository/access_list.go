@@ -50,9 +50,53 @@ func (r *AccessListRepository) Create(ctx context.Context, req *model.CreateAcce
 		}
 	}
 
+	// Create Basic Auth users if provided
+	for _, user := range req.AuthUsers {
+		if err := r.insertAuthUser(ctx, id, user.Username, user.PasswordHash); err != nil {
+			return nil, err
+		}
+	}
+
 	return r.GetByID(ctx, id)
 }
 
+func (r *AccessListRepository) insertAuthUser(ctx context.Context, accessListID, username, passwordHash string) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO access_list_auth_users (access_list_id, username, password_hash)
+		VALUES ($1, $2, $3)
+	`, accessListID, username, passwordHash)
+	if err != nil {
+		return fmt.Errorf("failed to create access list user %s: %w", username, err)
+	}
+	return nil
+}
+
+func (r *AccessListRepository) getAuthUsers(ctx context.Context, accessListID string) ([]model.AccessListAuthUser, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, access_list_id, username, password_hash, created_at
+		FROM access_list_auth_users WHERE access_list_id = $1
+		ORDER BY username
+	`, accessListID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []model.AccessListAuthUser
+	for rows.Next() {
+		var user model.AccessListAuthUser
+		if err := rows.Scan(&user.ID, &user.AccessListID, &user.Username, &user.PasswordHash, &user.CreatedAt); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate access list users: %w", err)
+	}
+
+	return users, nil
+}
+
 func (r *AccessListRepository) GetByID(ctx context.Context, id string) (*model.AccessList, error) {
 	var list model.AccessList
 	err := r.db.QueryRowContext(ctx, `
@@ -88,6 +132,11 @@ func (r *AccessListRepository) GetByID(ctx context.Context, id string) (*model.A
 		return nil, fmt.Errorf("failed to iterate access list items: %w", err)
 	}
 
+	list.AuthUsers, err = r.getAuthUsers(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
 	return &list, nil
 }
 
@@ -145,6 +194,11 @@ func (r *AccessListRepository) List(ctx context.Context, page, perPage int) ([]m
 			return nil, 0, fmt.Errorf("failed to iterate access list items: %w", err)
 		}
 		itemRows.Close()
+
+		lists[i].AuthUsers, err = r.getAuthUsers(ctx, lists[i].ID)
+		if err != nil {
+			return nil, 0, err
+		}
 	}
 
 	return lists, total, nil
@@ -214,9 +268,55 @@ func (r *AccessListRepository) Update(ctx context.Context, id string, req *model
 		}
 	}
 
+	// Replace Basic Auth users if provided; users sent without a password keep their current hash
+	if req.AuthUsers != nil {
+		currentHashes := make(map[string]string, len(existing.AuthUsers))
+		for _, user := range existing.AuthUsers {
+			currentHashes[user.Username] = user.PasswordHash
+		}
+
+		_, err = r.db.ExecContext(ctx, `DELETE FROM access_list_auth_users WHERE access_list_id = $1`, id)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, user := range req.AuthUsers {
+			hash := user.PasswordHash
+			if hash == "" {
+				hash = currentHashes[user.Username]
+			}
+			if hash == "" {
+				return nil, fmt.Errorf("password is required for new user %s", user.Username)
+			}
+			if err := r.insertAuthUser(ctx, id, user.Username, hash); err != nil {
+				return nil, err
+			}
+		}
+	}
+
 	return r.GetByID(ctx, id)
 }
 
+// GetProxyHostIDs returns IDs of proxy hosts using the access list
+func (r *AccessListRepository) GetProxyHostIDs(ctx context.Context, id string) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT id FROM proxy_hosts WHERE access_list_id = $1`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get proxy hosts for access list: %w", err)
+	}
+	defer rows.Close()
+
+	var hostIDs []string
+	for rows.Next() {
+		var hostID string
+		if err := rows.Scan(&hostID); err != nil {
+			return nil, fmt.Errorf("failed to scan proxy host ID: %w", err)
+		}
+		hostIDs = append(hostIDs, hostID)
+	}
+
+	return hostIDs, nil
+}
+
 func (r *AccessListRepository) Delete(ctx context.Context, id string) error {
 	// Items will be deleted by CASCADE
 	_, err := r.db.ExecContext(ctx, `DELETE FROM access_lists WHERE id = $1`, id)
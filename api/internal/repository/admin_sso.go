@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"nginx-proxy-guard/internal/model"
+)
+
+type AdminSSORepository struct {
+	db *sql.DB
+}
+
+func NewAdminSSORepository(db *sql.DB) *AdminSSORepository {
+	return &AdminSSORepository{db: db}
+}
+
+const adminSSOSettingsColumns = `id, enabled, COALESCE(provider_id::text, ''), role_claim, role_mappings, default_role,
+	       auto_provision, allowed_email_domains, disable_local_login, break_glass_username, created_at, updated_at`
+
+func scanAdminSSOSettings(row interface{ Scan(...interface{}) error }, s *model.AdminSSOSettings) error {
+	var mappings []byte
+	var domains pq.StringArray
+	err := row.Scan(
+		&s.ID, &s.Enabled, &s.ProviderID, &s.RoleClaim, &mappings, &s.DefaultRole,
+		&s.AutoProvision, &domains, &s.DisableLocalLogin, &s.BreakGlassUsername, &s.CreatedAt, &s.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	s.RoleMappings = []model.AdminSSORoleMapping{}
+	if len(mappings) > 0 {
+		if err := json.Unmarshal(mappings, &s.RoleMappings); err != nil {
+			return fmt.Errorf("invalid role mappings: %w", err)
+		}
+	}
+	s.AllowedEmailDomains = []string(domains)
+	if s.AllowedEmailDomains == nil {
+		s.AllowedEmailDomains = []string{}
+	}
+	return nil
+}
+
+// GetSettings returns the admin SSO settings, creating the default row if none exists
+func (r *AdminSSORepository) GetSettings(ctx context.Context) (*model.AdminSSOSettings, error) {
+	query := `SELECT ` + adminSSOSettingsColumns + ` FROM admin_sso_settings LIMIT 1`
+
+	var settings model.AdminSSOSettings
+	err := scanAdminSSOSettings(r.db.QueryRowContext(ctx, query), &settings)
+	if err == sql.ErrNoRows {
+		insert := `INSERT INTO admin_sso_settings DEFAULT VALUES RETURNING ` + adminSSOSettingsColumns
+		if err := scanAdminSSOSettings(r.db.QueryRowContext(ctx, insert), &settings); err != nil {
+			return nil, fmt.Errorf("failed to create default admin sso settings: %w", err)
+		}
+		return &settings, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get admin sso settings: %w", err)
+	}
+
+	return &settings, nil
+}
+
+// SaveSettings stores the admin SSO settings; callers merge and validate the update beforehand
+func (r *AdminSSORepository) SaveSettings(ctx context.Context, s *model.AdminSSOSettings) (*model.AdminSSOSettings, error) {
+	query := `
+		UPDATE admin_sso_settings SET
+			enabled = $1,
+			provider_id = NULLIF($2, '')::uuid,
+			role_claim = $3,
+			role_mappings = $4,
+			default_role = $5,
+			auto_provision = $6,
+			allowed_email_domains = $7,
+			disable_local_login = $8,
+			break_glass_username = $9,
+			updated_at = NOW()
+		WHERE id = $10
+		RETURNING ` + adminSSOSettingsColumns
+
+	mappings, err := json.Marshal(s.RoleMappings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode role mappings: %w", err)
+	}
+
+	var updated model.AdminSSOSettings
+	err = scanAdminSSOSettings(r.db.QueryRowContext(ctx, query,
+		s.Enabled, s.ProviderID, s.RoleClaim, mappings, s.DefaultRole,
+		s.AutoProvision, pq.Array(s.AllowedEmailDomains), s.DisableLocalLogin, s.BreakGlassUsername, s.ID,
+	), &updated)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update admin sso settings: %w", err)
+	}
+
+	return &updated, nil
+}
+
+// FindLinkedUserID returns the user linked to an identity provider subject, or "" if none
+func (r *AdminSSORepository) FindLinkedUserID(ctx context.Context, providerID, subject string) (string, error) {
+	var id string
+	err := r.db.QueryRowContext(ctx,
+		`SELECT id FROM users WHERE oidc_provider_id = $1 AND oidc_subject = $2`, providerID, subject,
+	).Scan(&id)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to find linked user: %w", err)
+	}
+	return id, nil
+}
+
+// FindUserIDByEmail returns the user with the email address, or "" if none
+func (r *AdminSSORepository) FindUserIDByEmail(ctx context.Context, email string) (string, error) {
+	var id string
+	err := r.db.QueryRowContext(ctx,
+		`SELECT id FROM users WHERE LOWER(email) = LOWER($1) AND oidc_provider_id IS NULL LIMIT 1`, email,
+	).Scan(&id)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to find user by email: %w", err)
+	}
+	return id, nil
+}
+
+// LinkUser links a user to an identity provider subject
+func (r *AdminSSORepository) LinkUser(ctx context.Context, userID, providerID, subject string) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE users SET oidc_provider_id = $2, oidc_subject = $3, updated_at = NOW() WHERE id = $1`,
+		userID, providerID, subject)
+	if err != nil {
+		return fmt.Errorf("failed to link user: %w", err)
+	}
+	return nil
+}
+
+// ProvisionUser creates a user linked to an identity provider subject. The
+// password hash is unusable, so the account can only log in through SSO.
+func (r *AdminSSORepository) ProvisionUser(ctx context.Context, u *model.User, providerID, subject string) error {
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO users (username, email, password_hash, role, is_initial_setup, oidc_provider_id, oidc_subject)
+		VALUES ($1, NULLIF($2, ''), '!', $3, FALSE, $4, $5)
+		RETURNING id
+	`, u.Username, u.Email, u.Role, providerID, subject).Scan(&u.ID)
+	if err != nil {
+		return fmt.Errorf("failed to provision user: %w", err)
+	}
+	return nil
+}
+
+// UsernameExists checks if a username is taken
+func (r *AdminSSORepository) UsernameExists(ctx context.Context, username string) (bool, error) {
+	var exists bool
+	err := r.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM users WHERE username = $1)`, username).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check username: %w", err)
+	}
+	return exists, nil
+}
@@ -25,12 +25,13 @@ func (r *APITokenRepository) Create(ctx context.Context, token *model.APIToken)
 	query := `
 		INSERT INTO api_tokens (
 			user_id, name, token_hash, token_prefix,
-			permissions, allowed_ips, rate_limit, expires_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			permissions, allowed_ips, rate_limit, expires_at, resource_scope
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 		RETURNING id, created_at, updated_at
 	`
 
 	permBytes, _ := json.Marshal(token.Permissions)
+	scopeBytes, _ := json.Marshal(token.Scope)
 
 	return r.db.QueryRowContext(ctx, query,
 		token.UserID,
@@ -41,6 +42,7 @@ func (r *APITokenRepository) Create(ctx context.Context, token *model.APIToken)
 		pq.Array(token.AllowedIPs),
 		token.RateLimit,
 		token.ExpiresAt,
+		scopeBytes,
 	).Scan(&token.ID, &token.CreatedAt, &token.UpdatedAt)
 }
 
@@ -50,7 +52,7 @@ func (r *APITokenRepository) GetByID(ctx context.Context, id string) (*model.API
 		SELECT t.id, t.user_id, t.name, t.token_hash, t.token_prefix,
 		       t.permissions, t.allowed_ips, t.rate_limit, t.expires_at,
 		       t.last_used_at, t.last_used_ip, t.use_count, t.is_active,
-		       t.revoked_at, t.revoked_reason, t.created_at, t.updated_at,
+		       t.revoked_at, t.revoked_reason, t.resource_scope, t.created_at, t.updated_at,
 		       u.username
 		FROM api_tokens t
 		JOIN users u ON t.user_id = u.id
@@ -58,14 +60,14 @@ func (r *APITokenRepository) GetByID(ctx context.Context, id string) (*model.API
 	`
 
 	token := &model.APIToken{}
-	var permBytes []byte
+	var permBytes, scopeBytes []byte
 	var revokedReason sql.NullString
 
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
 		&token.ID, &token.UserID, &token.Name, &token.TokenHash, &token.TokenPrefix,
 		&permBytes, &token.AllowedIPs, &token.RateLimit, &token.ExpiresAt,
 		&token.LastUsedAt, &token.LastUsedIP, &token.UseCount, &token.IsActive,
-		&token.RevokedAt, &revokedReason, &token.CreatedAt, &token.UpdatedAt,
+		&token.RevokedAt, &revokedReason, &scopeBytes, &token.CreatedAt, &token.UpdatedAt,
 		&token.Username,
 	)
 	if err == sql.ErrNoRows {
@@ -76,6 +78,7 @@ func (r *APITokenRepository) GetByID(ctx context.Context, id string) (*model.API
 	}
 
 	json.Unmarshal(permBytes, &token.Permissions)
+	json.Unmarshal(scopeBytes, &token.Scope)
 	if revokedReason.Valid {
 		token.RevokedReason = &revokedReason.String
 	}
@@ -89,23 +92,23 @@ func (r *APITokenRepository) GetByHash(ctx context.Context, hash string) (*model
 		SELECT t.id, t.user_id, t.name, t.token_hash, t.token_prefix,
 		       t.permissions, t.allowed_ips, t.rate_limit, t.expires_at,
 		       t.last_used_at, t.last_used_ip, t.use_count, t.is_active,
-		       t.revoked_at, t.revoked_reason, t.created_at, t.updated_at,
-		       u.username
+		       t.revoked_at, t.revoked_reason, t.resource_scope, t.created_at, t.updated_at,
+		       u.username, COALESCE(u.role, ''), u.disabled
 		FROM api_tokens t
 		JOIN users u ON t.user_id = u.id
 		WHERE t.token_hash = $1 AND t.is_active = true
 	`
 
 	token := &model.APIToken{}
-	var permBytes []byte
+	var permBytes, scopeBytes []byte
 	var revokedReason sql.NullString
 
 	err := r.db.QueryRowContext(ctx, query, hash).Scan(
 		&token.ID, &token.UserID, &token.Name, &token.TokenHash, &token.TokenPrefix,
 		&permBytes, &token.AllowedIPs, &token.RateLimit, &token.ExpiresAt,
 		&token.LastUsedAt, &token.LastUsedIP, &token.UseCount, &token.IsActive,
-		&token.RevokedAt, &revokedReason, &token.CreatedAt, &token.UpdatedAt,
-		&token.Username,
+		&token.RevokedAt, &revokedReason, &scopeBytes, &token.CreatedAt, &token.UpdatedAt,
+		&token.Username, &token.OwnerRole, &token.OwnerDisabled,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -115,6 +118,7 @@ func (r *APITokenRepository) GetByHash(ctx context.Context, hash string) (*model
 	}
 
 	json.Unmarshal(permBytes, &token.Permissions)
+	json.Unmarshal(scopeBytes, &token.Scope)
 	if revokedReason.Valid {
 		token.RevokedReason = &revokedReason.String
 	}
@@ -128,7 +132,7 @@ func (r *APITokenRepository) ListByUser(ctx context.Context, userID string) ([]*
 		SELECT t.id, t.user_id, t.name, t.token_hash, t.token_prefix,
 		       t.permissions, t.allowed_ips, t.rate_limit, t.expires_at,
 		       t.last_used_at, t.last_used_ip, t.use_count, t.is_active,
-		       t.revoked_at, t.revoked_reason, t.created_at, t.updated_at,
+		       t.revoked_at, t.revoked_reason, t.resource_scope, t.created_at, t.updated_at,
 		       u.username
 		FROM api_tokens t
 		JOIN users u ON t.user_id = u.id
@@ -145,14 +149,14 @@ func (r *APITokenRepository) ListByUser(ctx context.Context, userID string) ([]*
 	var tokens []*model.APIToken
 	for rows.Next() {
 		token := &model.APIToken{}
-		var permBytes []byte
+		var permBytes, scopeBytes []byte
 		var revokedReason sql.NullString
 
 		err := rows.Scan(
 			&token.ID, &token.UserID, &token.Name, &token.TokenHash, &token.TokenPrefix,
 			&permBytes, &token.AllowedIPs, &token.RateLimit, &token.ExpiresAt,
 			&token.LastUsedAt, &token.LastUsedIP, &token.UseCount, &token.IsActive,
-			&token.RevokedAt, &revokedReason, &token.CreatedAt, &token.UpdatedAt,
+			&token.RevokedAt, &revokedReason, &scopeBytes, &token.CreatedAt, &token.UpdatedAt,
 			&token.Username,
 		)
 		if err != nil {
@@ -160,6 +164,7 @@ func (r *APITokenRepository) ListByUser(ctx context.Context, userID string) ([]*
 		}
 
 		json.Unmarshal(permBytes, &token.Permissions)
+		json.Unmarshal(scopeBytes, &token.Scope)
 		if revokedReason.Valid {
 			token.RevokedReason = &revokedReason.String
 		}
@@ -176,7 +181,7 @@ func (r *APITokenRepository) ListAll(ctx context.Context) ([]*model.APIToken, er
 		SELECT t.id, t.user_id, t.name, t.token_hash, t.token_prefix,
 		       t.permissions, t.allowed_ips, t.rate_limit, t.expires_at,
 		       t.last_used_at, t.last_used_ip, t.use_count, t.is_active,
-		       t.revoked_at, t.revoked_reason, t.created_at, t.updated_at,
+		       t.revoked_at, t.revoked_reason, t.resource_scope, t.created_at, t.updated_at,
 		       u.username
 		FROM api_tokens t
 		JOIN users u ON t.user_id = u.id
@@ -192,14 +197,14 @@ func (r *APITokenRepository) ListAll(ctx context.Context) ([]*model.APIToken, er
 	var tokens []*model.APIToken
 	for rows.Next() {
 		token := &model.APIToken{}
-		var permBytes []byte
+		var permBytes, scopeBytes []byte
 		var revokedReason sql.NullString
 
 		err := rows.Scan(
 			&token.ID, &token.UserID, &token.Name, &token.TokenHash, &token.TokenPrefix,
 			&permBytes, &token.AllowedIPs, &token.RateLimit, &token.ExpiresAt,
 			&token.LastUsedAt, &token.LastUsedIP, &token.UseCount, &token.IsActive,
-			&token.RevokedAt, &revokedReason, &token.CreatedAt, &token.UpdatedAt,
+			&token.RevokedAt, &revokedReason, &scopeBytes, &token.CreatedAt, &token.UpdatedAt,
 			&token.Username,
 		)
 		if err != nil {
@@ -207,6 +212,7 @@ func (r *APITokenRepository) ListAll(ctx context.Context) ([]*model.APIToken, er
 		}
 
 		json.Unmarshal(permBytes, &token.Permissions)
+		json.Unmarshal(scopeBytes, &token.Scope)
 		if revokedReason.Valid {
 			token.RevokedReason = &revokedReason.String
 		}
@@ -250,6 +256,12 @@ func (r *APITokenRepository) Update(ctx context.Context, id string, req *model.U
 		args = append(args, *req.IsActive)
 		argIndex++
 	}
+	if req.Scope != nil {
+		scopeBytes, _ := json.Marshal(req.Scope)
+		query += fmt.Sprintf(", resource_scope = $%d", argIndex)
+		args = append(args, scopeBytes)
+		argIndex++
+	}
 
 	query += fmt.Sprintf(" WHERE id = $%d", argIndex)
 	args = append(args, id)
@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+
+	"nginx-proxy-guard/internal/model"
+)
+
+// hostScopeCondition returns an SQL condition matching the proxy hosts in an
+// API token scope, with its placeholders numbered from argIndex. The prefix
+// qualifies the proxy_hosts columns (e.g. "ph.") when the query joins tables.
+func hostScopeCondition(scope model.APITokenScope, prefix string, argIndex int) (string, []interface{}) {
+	domainCond, domainArgs := domainScopeCondition(scope, prefix+"domain_names", argIndex+2)
+	cond := fmt.Sprintf("(%sid::text = ANY($%d) OR %stags && $%d OR %s)",
+		prefix, argIndex, prefix, argIndex+1, domainCond)
+	args := append([]interface{}{pq.Array(scope.ProxyHostIDs), pq.Array(scope.Tags)}, domainArgs...)
+	return cond, args
+}
+
+// domainScopeCondition returns an SQL condition that holds when every name in
+// the domain array column matches one of the scope's domain globs
+func domainScopeCondition(scope model.APITokenScope, column string, argIndex int) (string, []interface{}) {
+	cond := fmt.Sprintf(
+		"(cardinality($%d::text[]) > 0 AND cardinality(%s) > 0 AND NOT EXISTS (SELECT 1 FROM unnest(%s) AS d(name) WHERE NOT lower(d.name) LIKE ANY($%d::text[])))",
+		argIndex, column, column, argIndex)
+	return cond, []interface{}{pq.Array(domainGlobPatterns(scope))}
+}
+
+// domainGlobPatterns converts the scope's domain globs to LIKE patterns. Globs
+// only hold [a-z0-9*?.-], so no LIKE escaping is needed.
+func domainGlobPatterns(scope model.APITokenScope) []string {
+	patterns := make([]string, 0, len(scope.Domains))
+	for _, glob := range scope.Domains {
+		patterns = append(patterns, strings.NewReplacer("*", "%", "?", "_").Replace(glob))
+	}
+	return patterns
+}
@@ -37,6 +37,7 @@ type AuditLogFilter struct {
 	UserID       string     `json:"user_id,omitempty"`
 	Action       string     `json:"action,omitempty"`
 	ResourceType string     `json:"resource_type,omitempty"`
+	ResourceID   string     `json:"resource_id,omitempty"`
 	Search       string     `json:"search,omitempty"`
 	StartTime    *time.Time `json:"start_time,omitempty"`
 	EndTime      *time.Time `json:"end_time,omitempty"`
@@ -111,6 +112,12 @@ func (r *AuditLogRepository) List(ctx context.Context, filter AuditLogFilter) ([
 		argIdx++
 	}
 
+	if filter.ResourceID != "" {
+		where += " AND resource_id = $" + itoa(argIdx)
+		args = append(args, filter.ResourceID)
+		argIdx++
+	}
+
 	if filter.Search != "" {
 		where += " AND (username ILIKE $" + itoa(argIdx) + " OR action ILIKE $" + itoa(argIdx) + " OR resource_name ILIKE $" + itoa(argIdx) + ")"
 		args = append(args, "%"+filter.Search+"%")
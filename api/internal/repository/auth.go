@@ -22,7 +22,8 @@ func NewAuthRepository(db *sql.DB) *AuthRepository {
 
 func (r *AuthRepository) GetUserByUsername(ctx context.Context, username string) (*model.User, error) {
 	query := `
-		SELECT id, username, password_hash, role, COALESCE(language, 'ko'), COALESCE(font_family, 'system'),
+		SELECT id, username, COALESCE(email, ''), password_hash, COALESCE(role, ''), disabled, COALESCE(ldap_dn, ''),
+		       COALESCE(language, 'ko'), COALESCE(font_family, 'system'),
 		       is_initial_setup, totp_enabled, totp_secret, totp_verified_at, backup_codes,
 		       last_login_at, last_login_ip, login_count,
 		       created_at, updated_at
@@ -36,7 +37,8 @@ func (r *AuthRepository) GetUserByUsername(ctx context.Context, username string)
 	var backupCodes []sql.NullString
 
 	err := r.db.QueryRowContext(ctx, query, username).Scan(
-		&u.ID, &u.Username, &u.PasswordHash, &u.Role, &u.Language, &u.FontFamily,
+		&u.ID, &u.Username, &u.Email, &u.PasswordHash, &u.Role, &u.Disabled, &u.LDAPDN,
+		&u.Language, &u.FontFamily,
 		&u.IsInitialSetup, &u.TOTPEnabled, &totpSecret, &totpVerifiedAt, pq.Array(&backupCodes),
 		&lastLoginAt, &lastLoginIP, &u.LoginCount,
 		&u.CreatedAt, &u.UpdatedAt,
@@ -67,7 +69,8 @@ func (r *AuthRepository) GetUserByUsername(ctx context.Context, username string)
 
 func (r *AuthRepository) GetUserByID(ctx context.Context, id string) (*model.User, error) {
 	query := `
-		SELECT id, username, password_hash, role, COALESCE(language, 'ko'), COALESCE(font_family, 'system'),
+		SELECT id, username, COALESCE(email, ''), password_hash, COALESCE(role, ''), disabled, COALESCE(ldap_dn, ''),
+		       COALESCE(language, 'ko'), COALESCE(font_family, 'system'),
 		       is_initial_setup, totp_enabled, totp_secret, totp_verified_at, backup_codes,
 		       last_login_at, last_login_ip, login_count,
 		       created_at, updated_at
@@ -81,7 +84,8 @@ func (r *AuthRepository) GetUserByID(ctx context.Context, id string) (*model.Use
 	var backupCodes []sql.NullString
 
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&u.ID, &u.Username, &u.PasswordHash, &u.Role, &u.Language, &u.FontFamily,
+		&u.ID, &u.Username, &u.Email, &u.PasswordHash, &u.Role, &u.Disabled, &u.LDAPDN,
+		&u.Language, &u.FontFamily,
 		&u.IsInitialSetup, &u.TOTPEnabled, &totpSecret, &totpVerifiedAt, pq.Array(&backupCodes),
 		&lastLoginAt, &lastLoginIP, &u.LoginCount,
 		&u.CreatedAt, &u.UpdatedAt,
@@ -242,28 +246,42 @@ func (r *AuthRepository) UseBackupCode(ctx context.Context, userID string, remai
 
 func (r *AuthRepository) CreateSession(ctx context.Context, session *model.AuthSession) error {
 	query := `
-		INSERT INTO auth_sessions (user_id, token_hash, ip_address, user_agent, expires_at)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO auth_sessions (user_id, token_hash, ip_address, user_agent, expires_at, password_expired)
+		VALUES ($1, $2, $3, $4, $5, $6)
 		RETURNING id, created_at
 	`
 	return r.db.QueryRowContext(ctx, query,
-		session.UserID, session.TokenHash, session.IPAddress, session.UserAgent, session.ExpiresAt,
+		session.UserID, session.TokenHash, session.IPAddress, session.UserAgent, session.ExpiresAt, session.PasswordExpired,
 	).Scan(&session.ID, &session.CreatedAt)
 }
 
-func (r *AuthRepository) GetSessionByTokenHash(ctx context.Context, tokenHash string) (*model.AuthSession, error) {
-	query := `
-		SELECT id, user_id, token_hash, ip_address, user_agent, expires_at, created_at
-		FROM auth_sessions
-		WHERE token_hash = $1 AND expires_at > NOW()
-	`
+// LiftPasswordExpired lifts the password change restriction from the
+// sessions of a user
+func (r *AuthRepository) LiftPasswordExpired(ctx context.Context, userID string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE auth_sessions SET password_expired = false WHERE user_id = $1 AND password_expired`, userID)
+	return err
+}
 
-	var s model.AuthSession
-	var ipAddress, userAgent sql.NullString
+const sessionColumns = `id, user_id, token_hash, ip_address, user_agent, expires_at, created_at, last_seen_at, password_expired`
 
-	err := r.db.QueryRowContext(ctx, query, tokenHash).Scan(
-		&s.ID, &s.UserID, &s.TokenHash, &ipAddress, &userAgent, &s.ExpiresAt, &s.CreatedAt,
+func scanSession(row interface{ Scan(...interface{}) error }, s *model.AuthSession) error {
+	var ipAddress, userAgent sql.NullString
+	err := row.Scan(
+		&s.ID, &s.UserID, &s.TokenHash, &ipAddress, &userAgent, &s.ExpiresAt, &s.CreatedAt, &s.LastSeenAt, &s.PasswordExpired,
 	)
+	if err != nil {
+		return err
+	}
+	s.IPAddress = ipAddress.String
+	s.UserAgent = userAgent.String
+	return nil
+}
+
+func (r *AuthRepository) GetSessionByTokenHash(ctx context.Context, tokenHash string) (*model.AuthSession, error) {
+	query := `SELECT ` + sessionColumns + ` FROM auth_sessions WHERE token_hash = $1 AND expires_at > NOW()`
+
+	var s model.AuthSession
+	err := scanSession(r.db.QueryRowContext(ctx, query, tokenHash), &s)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -271,12 +289,54 @@ func (r *AuthRepository) GetSessionByTokenHash(ctx context.Context, tokenHash st
 		return nil, err
 	}
 
-	s.IPAddress = ipAddress.String
-	s.UserAgent = userAgent.String
+	return &s, nil
+}
+
+// ListUserSessions returns the unexpired sessions of a user, most recently used first
+func (r *AuthRepository) ListUserSessions(ctx context.Context, userID string) ([]model.AuthSession, error) {
+	query := `SELECT ` + sessionColumns + ` FROM auth_sessions
+		WHERE user_id = $1 AND expires_at > NOW()
+		ORDER BY last_seen_at DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []model.AuthSession{}
+	for rows.Next() {
+		var s model.AuthSession
+		if err := scanSession(rows, &s); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, rows.Err()
+}
+
+// GetUserSession returns an unexpired session of a user by ID
+func (r *AuthRepository) GetUserSession(ctx context.Context, userID, id string) (*model.AuthSession, error) {
+	query := `SELECT ` + sessionColumns + ` FROM auth_sessions WHERE id = $1 AND user_id = $2 AND expires_at > NOW()`
+
+	var s model.AuthSession
+	err := scanSession(r.db.QueryRowContext(ctx, query, id, userID), &s)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
 
 	return &s, nil
 }
 
+// TouchSession records that a session was used
+func (r *AuthRepository) TouchSession(ctx context.Context, id string) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE auth_sessions SET last_seen_at = NOW() WHERE id = $1", id)
+	return err
+}
+
 func (r *AuthRepository) DeleteSession(ctx context.Context, tokenHash string) error {
 	_, err := r.db.ExecContext(ctx, "DELETE FROM auth_sessions WHERE token_hash = $1", tokenHash)
 	return err
@@ -310,6 +370,45 @@ func (r *AuthRepository) CountRecentFailedAttempts(ctx context.Context, ip strin
 	return count, err
 }
 
+// CountFailuresByUsername counts the failed logins of a username since the
+// later of since and its last successful login, and returns the newest failure
+func (r *AuthRepository) CountFailuresByUsername(ctx context.Context, username string, since time.Time) (int, time.Time, error) {
+	query := `
+		SELECT COUNT(*), COALESCE(MAX(attempted_at), $2)
+		FROM login_attempts
+		WHERE username = $1 AND success = FALSE AND attempted_at > GREATEST($2,
+			COALESCE((SELECT MAX(attempted_at) FROM login_attempts WHERE username = $1 AND success = TRUE), $2))
+	`
+	var count int
+	var last time.Time
+	err := r.db.QueryRowContext(ctx, query, username, since).Scan(&count, &last)
+	return count, last, err
+}
+
+// CountFailuresByIP counts the failed logins from an IP since the given time
+// and returns the newest failure. Successful logins don't reset the count, a
+// valid account would otherwise let the source keep guessing.
+func (r *AuthRepository) CountFailuresByIP(ctx context.Context, ip string, since time.Time) (int, time.Time, error) {
+	query := `
+		SELECT COUNT(*), COALESCE(MAX(attempted_at), $2)
+		FROM login_attempts
+		WHERE ip_address = $1 AND success = FALSE AND attempted_at > $2
+	`
+	var count int
+	var last time.Time
+	err := r.db.QueryRowContext(ctx, query, ip, since).Scan(&count, &last)
+	return count, last, err
+}
+
+// ClearFailedAttempts forgets the failed logins of a username, ending its lockout
+func (r *AuthRepository) ClearFailedAttempts(ctx context.Context, username string) (int64, error) {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM login_attempts WHERE username = $1 AND success = FALSE", username)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 func (r *AuthRepository) CleanOldAttempts(ctx context.Context, before time.Time) (int64, error) {
 	result, err := r.db.ExecContext(ctx, "DELETE FROM login_attempts WHERE attempted_at < $1", before)
 	if err != nil {
@@ -318,9 +417,12 @@ func (r *AuthRepository) CleanOldAttempts(ctx context.Context, before time.Time)
 	return result.RowsAffected()
 }
 
-// Check if initial setup is required
+// Check if initial setup is required (no user has completed setup yet)
 func (r *AuthRepository) IsInitialSetupRequired(ctx context.Context) (bool, error) {
-	query := `SELECT EXISTS(SELECT 1 FROM users WHERE is_initial_setup = TRUE LIMIT 1)`
+	query := `
+		SELECT EXISTS(SELECT 1 FROM users WHERE is_initial_setup = TRUE)
+		   AND NOT EXISTS(SELECT 1 FROM users WHERE is_initial_setup = FALSE)
+	`
 	var exists bool
 	err := r.db.QueryRowContext(ctx, query).Scan(&exists)
 	return exists, err
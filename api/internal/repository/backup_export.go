@@ -88,6 +88,67 @@ func (r *BackupRepository) ExportAllData(ctx context.Context) (*model.ExportData
 	}
 	export.URIBlocks = uriBlocks
 
+	// Export forward auth settings (per proxy host)
+	forwardAuthConfigs, err := r.exportForwardAuthConfigs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export forward auth configs: %w", err)
+	}
+	export.ForwardAuthConfigs = forwardAuthConfigs
+
+	// Export OIDC identity providers and login gates
+	oidcProviders, err := r.exportOIDCProviders(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export oidc providers: %w", err)
+	}
+	export.OIDCProviders = oidcProviders
+
+	oidcHostGates, err := r.exportOIDCHostGates(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export oidc host gates: %w", err)
+	}
+	export.OIDCHostGates = oidcHostGates
+
+	// Export client CAs and client certificate authentication
+	clientCAs, err := r.exportClientCAs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export client cas: %w", err)
+	}
+	export.ClientCAs = clientCAs
+
+	clientCertAuthConfigs, err := r.exportClientCertAuthConfigs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export client cert auth configs: %w", err)
+	}
+	export.ClientCertAuthConfigs = clientCertAuthConfigs
+
+	// Export backend TLS settings
+	upstreamTLSConfigs, err := r.exportUpstreamTLSConfigs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export upstream tls configs: %w", err)
+	}
+	export.UpstreamTLSConfigs = upstreamTLSConfigs
+
+	// Export TLS policies
+	tlsPolicies, err := r.exportTLSPolicies(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export tls policies: %w", err)
+	}
+	export.TLSPolicies = tlsPolicies
+
+	// Export CORS policies
+	corsPolicies, err := r.exportCORSPolicies(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export cors policies: %w", err)
+	}
+	export.CORSPolicies = corsPolicies
+
+	// Export signed URL locations
+	signedURLLocations, err := r.exportSignedURLLocations(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export signed url locations: %w", err)
+	}
+	export.SignedURLLocations = signedURLLocations
+
 	// Export Global URI Block
 	globalURIBlock, err := r.exportGlobalURIBlock(ctx)
 	if err != nil {
@@ -203,7 +264,7 @@ func (r *BackupRepository) exportProxyHosts(ctx context.Context) ([]model.ProxyH
 		       COALESCE(cache_ttl, '7d') as cache_ttl,
 		       block_exploits,
 		       custom_locations, advanced_config, waf_enabled, waf_mode,
-		       access_list_id, enabled, meta
+		       access_list_id, enabled, meta, tags
 		FROM proxy_hosts ORDER BY created_at
 	`
 
@@ -225,7 +286,7 @@ func (r *BackupRepository) exportProxyHosts(ctx context.Context) ([]model.ProxyH
 			&ph.SSLEnabled, &ph.SSLForceHTTPS, &ph.SSLHTTP2, &certID,
 			&ph.AllowWebsocketUpgrade, &ph.CacheEnabled, &ph.CacheStaticOnly, &ph.CacheTTL, &ph.BlockExploits,
 			&customLocations, &advancedConfig, &ph.WAFEnabled, &ph.WAFMode,
-			&accessListID, &ph.Enabled, &meta,
+			&accessListID, &ph.Enabled, &meta, pq.Array(&ph.Tags),
 		)
 		if err != nil {
 			return nil, err
@@ -470,6 +531,10 @@ func (r *BackupRepository) exportAccessLists(ctx context.Context) ([]model.Acces
 		items, _ := r.getAccessListItems(ctx, al.ID)
 		al.Items = items
 
+		// Get Basic Auth users (bcrypt hashes)
+		users, _ := r.getAccessListAuthUsers(ctx, al.ID)
+		al.AuthUsers = users
+
 		exports = append(exports, model.AccessListExport{AccessList: al})
 	}
 
@@ -503,6 +568,30 @@ func (r *BackupRepository) getAccessListItems(ctx context.Context, accessListID
 	return items, nil
 }
 
+func (r *BackupRepository) getAccessListAuthUsers(ctx context.Context, accessListID string) ([]model.AccessListAuthUserData, error) {
+	query := `
+		SELECT username, password_hash
+		FROM access_list_auth_users WHERE access_list_id = $1 ORDER BY username
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, accessListID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []model.AccessListAuthUserData
+	for rows.Next() {
+		var user model.AccessListAuthUserData
+		if err := rows.Scan(&user.Username, &user.PasswordHash); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	return users, nil
+}
+
 func (r *BackupRepository) exportDNSProviders(ctx context.Context) ([]model.DNSProviderExport, error) {
 	// Note: credentials are exported for full backup functionality
 	query := `SELECT id, name, provider_type, credentials, is_default FROM dns_providers ORDER BY created_at`
@@ -629,7 +718,10 @@ func (r *BackupRepository) exportSystemSettings(ctx context.Context) (*model.Sys
 		       bot_filter_default_custom_blocked_agents,
 		       bot_list_bad_bots, bot_list_ai_bots, bot_list_search_engines, bot_list_suspicious_clients,
 		       waf_auto_ban_enabled, waf_auto_ban_threshold, waf_auto_ban_window, waf_auto_ban_duration,
-		       direct_ip_access_action, system_logs_enabled
+		       direct_ip_access_action, system_logs_enabled,
+		       COALESCE(ban_escalation_enabled, false), ban_escalation_steps, COALESCE(ban_escalation_window, 2592000),
+		       COALESCE(ban_subnet_aggregation_enabled, false), COALESCE(ban_subnet_threshold, 5),
+		       COALESCE(ban_subnet_ipv4_prefix, 24), COALESCE(ban_subnet_ipv6_prefix, 64), COALESCE(ban_subnet_window, 3600)
 		FROM system_settings LIMIT 1
 	`
 
@@ -659,6 +751,9 @@ func (r *BackupRepository) exportSystemSettings(ctx context.Context) (*model.Sys
 		&botListBadBots, &botListAIBots, &botListSearchEngines, &botListSuspiciousClients,
 		&ss.WAFAutoBanEnabled, &ss.WAFAutoBanThreshold, &ss.WAFAutoBanWindow, &ss.WAFAutoBanDuration,
 		&directIPAccessAction, &ss.SystemLogsEnabled,
+		&ss.BanEscalationEnabled, pq.Array(&ss.BanEscalationSteps), &ss.BanEscalationWindow,
+		&ss.BanSubnetAggregationEnabled, &ss.BanSubnetThreshold,
+		&ss.BanSubnetIPv4Prefix, &ss.BanSubnetIPv6Prefix, &ss.BanSubnetWindow,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -686,7 +781,7 @@ func (r *BackupRepository) exportSystemSettings(ctx context.Context) (*model.Sys
 func (r *BackupRepository) exportBannedIPs(ctx context.Context) ([]model.BannedIPExport, error) {
 	query := `
 		SELECT proxy_host_id, ip_address, reason, fail_count, banned_at, expires_at, is_permanent, is_auto_banned
-		FROM banned_ips ORDER BY banned_at DESC
+		FROM banned_ips WHERE origin <> 'crowdsec' ORDER BY banned_at DESC
 	`
 
 	rows, err := r.db.QueryContext(ctx, query)
@@ -760,6 +855,285 @@ func (r *BackupRepository) exportURIBlocks(ctx context.Context) ([]model.URIBloc
 	return exports, nil
 }
 
+func (r *BackupRepository) exportForwardAuthConfigs(ctx context.Context) ([]model.ForwardAuthExport, error) {
+	query := `
+		SELECT proxy_host_id, enabled, auth_url, signin_url, response_headers, bypass_paths, protect_host
+		FROM forward_auth_configs ORDER BY proxy_host_id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var exports []model.ForwardAuthExport
+	for rows.Next() {
+		var fa model.ForwardAuthExport
+		var responseHeaders, bypassPaths pq.StringArray
+
+		err := rows.Scan(&fa.ProxyHostID, &fa.Enabled, &fa.AuthURL, &fa.SigninURL,
+			&responseHeaders, &bypassPaths, &fa.ProtectHost)
+		if err != nil {
+			return nil, err
+		}
+
+		fa.ResponseHeaders = []string(responseHeaders)
+		if fa.ResponseHeaders == nil {
+			fa.ResponseHeaders = []string{}
+		}
+		fa.BypassPaths = []string(bypassPaths)
+		if fa.BypassPaths == nil {
+			fa.BypassPaths = []string{}
+		}
+
+		exports = append(exports, fa)
+	}
+
+	return exports, rows.Err()
+}
+
+func (r *BackupRepository) exportOIDCProviders(ctx context.Context) ([]model.OIDCProviderExport, error) {
+	query := `
+		SELECT id, name, issuer_url, client_id, client_secret, scopes, groups_claim, enabled
+		FROM oidc_providers ORDER BY name
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var exports []model.OIDCProviderExport
+	for rows.Next() {
+		var p model.OIDCProviderExport
+		var scopes pq.StringArray
+
+		err := rows.Scan(&p.ID, &p.Name, &p.IssuerURL, &p.ClientID, &p.ClientSecret, &scopes, &p.GroupsClaim, &p.Enabled)
+		if err != nil {
+			return nil, err
+		}
+		p.Scopes = []string(scopes)
+
+		exports = append(exports, p)
+	}
+
+	return exports, rows.Err()
+}
+
+func (r *BackupRepository) exportOIDCHostGates(ctx context.Context) ([]model.OIDCHostGateExport, error) {
+	query := `
+		SELECT proxy_host_id, provider_id, enabled, allowed_email_domains, allowed_groups, required_claims, session_lifetime
+		FROM oidc_host_gates ORDER BY proxy_host_id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var exports []model.OIDCHostGateExport
+	for rows.Next() {
+		var g model.OIDCHostGateExport
+		var domains, groups pq.StringArray
+		var claimsJSON []byte
+
+		err := rows.Scan(&g.ProxyHostID, &g.ProviderID, &g.Enabled, &domains, &groups, &claimsJSON, &g.SessionLifetime)
+		if err != nil {
+			return nil, err
+		}
+		g.AllowedEmailDomains = []string(domains)
+		g.AllowedGroups = []string(groups)
+		if len(claimsJSON) > 0 {
+			json.Unmarshal(claimsJSON, &g.RequiredClaims)
+		}
+
+		exports = append(exports, g)
+	}
+
+	return exports, rows.Err()
+}
+
+func (r *BackupRepository) exportClientCAs(ctx context.Context) ([]model.ClientCAExport, error) {
+	query := `
+		SELECT id, name, description, ca_pem, crl_pem
+		FROM client_cas ORDER BY name
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var exports []model.ClientCAExport
+	for rows.Next() {
+		var ca model.ClientCAExport
+		if err := rows.Scan(&ca.ID, &ca.Name, &ca.Description, &ca.CAPEM, &ca.CRLPEM); err != nil {
+			return nil, err
+		}
+		exports = append(exports, ca)
+	}
+
+	return exports, rows.Err()
+}
+
+func (r *BackupRepository) exportClientCertAuthConfigs(ctx context.Context) ([]model.ClientCertAuthConfigExport, error) {
+	query := `
+		SELECT proxy_host_id, client_ca_id, enabled, verify_mode, verify_depth, forward_headers, enforce_paths
+		FROM client_cert_auth_configs ORDER BY proxy_host_id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var exports []model.ClientCertAuthConfigExport
+	for rows.Next() {
+		var cfg model.ClientCertAuthConfigExport
+		var enforcePaths pq.StringArray
+
+		err := rows.Scan(&cfg.ProxyHostID, &cfg.ClientCAID, &cfg.Enabled, &cfg.VerifyMode, &cfg.VerifyDepth,
+			&cfg.ForwardHeaders, &enforcePaths)
+		if err != nil {
+			return nil, err
+		}
+		cfg.EnforcePaths = []string(enforcePaths)
+
+		exports = append(exports, cfg)
+	}
+
+	return exports, rows.Err()
+}
+
+func (r *BackupRepository) exportUpstreamTLSConfigs(ctx context.Context) ([]model.UpstreamTLSConfigExport, error) {
+	query := `
+		SELECT proxy_host_id, enabled, verify, trusted_ca_pem, verify_depth, server_name, sni, client_certificate_id
+		FROM upstream_tls_configs ORDER BY proxy_host_id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var exports []model.UpstreamTLSConfigExport
+	for rows.Next() {
+		var cfg model.UpstreamTLSConfigExport
+		var clientCertID sql.NullString
+
+		err := rows.Scan(&cfg.ProxyHostID, &cfg.Enabled, &cfg.Verify, &cfg.TrustedCAPEM, &cfg.VerifyDepth,
+			&cfg.ServerName, &cfg.SNI, &clientCertID)
+		if err != nil {
+			return nil, err
+		}
+		if clientCertID.Valid {
+			cfg.ClientCertificateID = &clientCertID.String
+		}
+
+		exports = append(exports, cfg)
+	}
+
+	return exports, rows.Err()
+}
+
+func (r *BackupRepository) exportTLSPolicies(ctx context.Context) ([]model.TLSPolicyExport, error) {
+	query := `
+		SELECT proxy_host_id, profile, protocols, ciphers, curves, prefer_server_ciphers, session_tickets,
+		       ocsp_stapling, hsts_enabled, hsts_max_age, hsts_include_subdomains, hsts_preload
+		FROM tls_policies ORDER BY proxy_host_id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var exports []model.TLSPolicyExport
+	for rows.Next() {
+		var p model.TLSPolicyExport
+		var protocols pq.StringArray
+
+		err := rows.Scan(&p.ProxyHostID, &p.Profile, &protocols, &p.Ciphers, &p.Curves, &p.PreferServerCiphers,
+			&p.SessionTickets, &p.OCSPStapling, &p.HSTSEnabled, &p.HSTSMaxAge, &p.HSTSIncludeSubdomains, &p.HSTSPreload)
+		if err != nil {
+			return nil, err
+		}
+		p.Protocols = []string(protocols)
+
+		exports = append(exports, p)
+	}
+
+	return exports, rows.Err()
+}
+
+func (r *BackupRepository) exportCORSPolicies(ctx context.Context) ([]model.CORSPolicyExport, error) {
+	query := `
+		SELECT proxy_host_id, path_prefix, enabled, allowed_origins, allowed_origin_patterns, allowed_methods,
+		       allowed_headers, exposed_headers, allow_credentials, max_age
+		FROM cors_policies ORDER BY proxy_host_id, path_prefix
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var exports []model.CORSPolicyExport
+	for rows.Next() {
+		var p model.CORSPolicyExport
+		var origins, patterns, methods, headers, exposed pq.StringArray
+
+		err := rows.Scan(&p.ProxyHostID, &p.PathPrefix, &p.Enabled, &origins, &patterns, &methods,
+			&headers, &exposed, &p.AllowCredentials, &p.MaxAge)
+		if err != nil {
+			return nil, err
+		}
+		p.AllowedOrigins = []string(origins)
+		p.AllowedOriginPatterns = []string(patterns)
+		p.AllowedMethods = []string(methods)
+		p.AllowedHeaders = []string(headers)
+		p.ExposedHeaders = []string(exposed)
+
+		exports = append(exports, p)
+	}
+
+	return exports, rows.Err()
+}
+
+func (r *BackupRepository) exportSignedURLLocations(ctx context.Context) ([]model.SignedURLLocationExport, error) {
+	query := `
+		SELECT proxy_host_id, path_prefix, enabled, secret, hash_expression, token_param, expires_param, default_ttl, max_ttl
+		FROM signed_url_locations ORDER BY proxy_host_id, path_prefix
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var exports []model.SignedURLLocationExport
+	for rows.Next() {
+		var l model.SignedURLLocationExport
+		err := rows.Scan(&l.ProxyHostID, &l.PathPrefix, &l.Enabled, &l.Secret, &l.HashExpression, &l.TokenParam,
+			&l.ExpiresParam, &l.DefaultTTL, &l.MaxTTL)
+		if err != nil {
+			return nil, err
+		}
+		exports = append(exports, l)
+	}
+
+	return exports, rows.Err()
+}
+
 func (r *BackupRepository) exportGlobalURIBlock(ctx context.Context) (*model.GlobalURIBlockExport, error) {
 	query := `
 		SELECT enabled, rules, COALESCE(exception_ips, '{}'), COALESCE(allow_private_ips, true)
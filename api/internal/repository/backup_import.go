@@ -29,6 +29,8 @@ func (r *BackupRepository) ImportAllData(ctx context.Context, data *model.Export
 	dnsProviderIDMap := make(map[string]string)    // old ID -> new ID
 	proxyHostIDMap := make(map[string]string)      // old ID -> new ID
 	exploitRuleIDMap := make(map[string]string)    // old ID -> new ID
+	oidcProviderIDMap := make(map[string]string)   // old ID -> new ID
+	clientCAIDMap := make(map[string]string)       // old ID -> new ID
 
 	// Import Global Settings (update existing)
 	if data.GlobalSettings != nil {
@@ -147,6 +149,111 @@ func (r *BackupRepository) ImportAllData(ctx context.Context, data *model.Export
 		}
 	}
 
+	// Import forward auth settings
+	for _, fa := range data.ForwardAuthConfigs {
+		// Remap proxy host ID
+		if newID, ok := proxyHostIDMap[fa.ProxyHostID]; ok {
+			fa.ProxyHostID = newID
+		}
+		if err := r.importForwardAuthConfig(ctx, tx, &fa); err != nil {
+			return fmt.Errorf("failed to import forward auth config for proxy host %s: %w", fa.ProxyHostID, err)
+		}
+	}
+
+	// Import OIDC identity providers (login gates depend on them)
+	for _, p := range data.OIDCProviders {
+		newID, err := r.importOIDCProvider(ctx, tx, &p)
+		if err != nil {
+			return fmt.Errorf("failed to import oidc provider %s: %w", p.Name, err)
+		}
+		oidcProviderIDMap[p.ID] = newID
+	}
+
+	// Import OIDC login gates
+	for _, g := range data.OIDCHostGates {
+		// Remap proxy host and provider IDs
+		if newID, ok := proxyHostIDMap[g.ProxyHostID]; ok {
+			g.ProxyHostID = newID
+		}
+		if newID, ok := oidcProviderIDMap[g.ProviderID]; ok {
+			g.ProviderID = newID
+		}
+		if err := r.importOIDCHostGate(ctx, tx, &g); err != nil {
+			return fmt.Errorf("failed to import oidc host gate for proxy host %s: %w", g.ProxyHostID, err)
+		}
+	}
+
+	// Import client CAs (client certificate authentication depends on them)
+	for _, ca := range data.ClientCAs {
+		newID, err := r.importClientCA(ctx, tx, &ca)
+		if err != nil {
+			return fmt.Errorf("failed to import client ca %s: %w", ca.Name, err)
+		}
+		clientCAIDMap[ca.ID] = newID
+	}
+
+	// Import client certificate authentication
+	for _, cfg := range data.ClientCertAuthConfigs {
+		// Remap proxy host and client CA IDs
+		if newID, ok := proxyHostIDMap[cfg.ProxyHostID]; ok {
+			cfg.ProxyHostID = newID
+		}
+		if newID, ok := clientCAIDMap[cfg.ClientCAID]; ok {
+			cfg.ClientCAID = newID
+		}
+		if err := r.importClientCertAuthConfig(ctx, tx, &cfg); err != nil {
+			return fmt.Errorf("failed to import client cert auth config for proxy host %s: %w", cfg.ProxyHostID, err)
+		}
+	}
+
+	// Import backend TLS settings
+	for _, cfg := range data.UpstreamTLSConfigs {
+		// Remap proxy host and client certificate IDs
+		if newID, ok := proxyHostIDMap[cfg.ProxyHostID]; ok {
+			cfg.ProxyHostID = newID
+		}
+		if cfg.ClientCertificateID != nil {
+			if newID, ok := certificateIDMap[*cfg.ClientCertificateID]; ok {
+				cfg.ClientCertificateID = &newID
+			} else {
+				cfg.ClientCertificateID = nil
+			}
+		}
+		if err := r.importUpstreamTLSConfig(ctx, tx, &cfg); err != nil {
+			return fmt.Errorf("failed to import upstream tls config for proxy host %s: %w", cfg.ProxyHostID, err)
+		}
+	}
+
+	// Import TLS policies
+	for _, p := range data.TLSPolicies {
+		if newID, ok := proxyHostIDMap[p.ProxyHostID]; ok {
+			p.ProxyHostID = newID
+		}
+		if err := r.importTLSPolicy(ctx, tx, &p); err != nil {
+			return fmt.Errorf("failed to import tls policy for proxy host %s: %w", p.ProxyHostID, err)
+		}
+	}
+
+	// Import CORS policies
+	for _, p := range data.CORSPolicies {
+		if newID, ok := proxyHostIDMap[p.ProxyHostID]; ok {
+			p.ProxyHostID = newID
+		}
+		if err := r.importCORSPolicy(ctx, tx, &p); err != nil {
+			return fmt.Errorf("failed to import cors policy for proxy host %s: %w", p.ProxyHostID, err)
+		}
+	}
+
+	// Import signed URL locations
+	for _, l := range data.SignedURLLocations {
+		if newID, ok := proxyHostIDMap[l.ProxyHostID]; ok {
+			l.ProxyHostID = newID
+		}
+		if err := r.importSignedURLLocation(ctx, tx, &l); err != nil {
+			return fmt.Errorf("failed to import signed url location for proxy host %s: %w", l.ProxyHostID, err)
+		}
+	}
+
 	// Import Global URI Block
 	if data.GlobalURIBlock != nil {
 		if err := r.importGlobalURIBlock(ctx, tx, data.GlobalURIBlock); err != nil {
@@ -241,13 +348,23 @@ func (r *BackupRepository) clearExistingData(ctx context.Context, tx *sql.Tx) er
 		"fail2ban_configs",
 		"rate_limits",       // correct table name
 		"uri_blocks",        // references proxy_hosts
+		"forward_auth_configs", // references proxy_hosts
+		"oidc_host_gates",      // references proxy_hosts and oidc_providers
+		"client_cert_auth_configs", // references proxy_hosts and client_cas
+		"upstream_tls_configs",     // references proxy_hosts and certificates
+		"tls_policies",             // references proxy_hosts
+		"cors_policies",            // references proxy_hosts
+		"signed_url_locations",     // references proxy_hosts
 		"banned_ips",        // references proxy_hosts
 		"redirect_hosts",    // references certificates
 		"proxy_hosts",       // references certificates and access_lists
 		"access_list_items", // references access_lists
+		"access_list_auth_users", // references access_lists
 		"access_lists",
 		"certificates",    // references dns_providers
 		"dns_providers",
+		"oidc_providers",
+		"client_cas",
 		"global_uri_blocks",            // standalone table
 		"global_waf_rule_exclusions",   // standalone table
 		"global_exploit_rule_exclusions", // standalone table
@@ -382,6 +499,17 @@ func (r *BackupRepository) importAccessList(ctx context.Context, tx *sql.Tx, al
 		}
 	}
 
+	// Import Basic Auth users (password hashes are kept as exported)
+	for _, user := range al.AccessList.AuthUsers {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO access_list_auth_users (access_list_id, username, password_hash)
+			VALUES ($1, $2, $3)
+		`, newID, user.Username, user.PasswordHash)
+		if err != nil {
+			return "", err
+		}
+	}
+
 	return newID, nil
 }
 
@@ -401,8 +529,8 @@ func (r *BackupRepository) importProxyHost(ctx context.Context, tx *sql.Tx, ph *
 		                         ssl_enabled, ssl_force_https, ssl_http2, certificate_id,
 		                         allow_websocket_upgrade, cache_enabled, cache_static_only, cache_ttl, block_exploits,
 		                         custom_locations, advanced_config, waf_enabled, waf_mode,
-		                         access_list_id, enabled, meta)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20)
+		                         access_list_id, enabled, meta, tags)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21)
 		RETURNING id
 	`
 
@@ -420,7 +548,7 @@ func (r *BackupRepository) importProxyHost(ctx context.Context, tx *sql.Tx, ph *
 		ph.ProxyHost.SSLEnabled, ph.ProxyHost.SSLForceHTTPS, ph.ProxyHost.SSLHTTP2, certID,
 		ph.ProxyHost.AllowWebsocketUpgrade, ph.ProxyHost.CacheEnabled, cacheStaticOnly, cacheTTL, ph.ProxyHost.BlockExploits,
 		customLocations, ph.ProxyHost.AdvancedConfig, ph.ProxyHost.WAFEnabled, ph.ProxyHost.WAFMode,
-		accessListID, ph.ProxyHost.Enabled, meta,
+		accessListID, ph.ProxyHost.Enabled, meta, pq.Array(normalizeTags(ph.ProxyHost.Tags)),
 	).Scan(&newID)
 	if err != nil {
 		return "", err
@@ -613,6 +741,12 @@ func (r *BackupRepository) importWAFExclusion(ctx context.Context, tx *sql.Tx, w
 }
 
 func (r *BackupRepository) importSystemSettings(ctx context.Context, tx *sql.Tx, ss *model.SystemSettingsExport) error {
+	// Backups taken before ban escalation / subnet aggregation existed keep the current policy
+	var banEscalationSteps interface{}
+	if len(ss.BanEscalationSteps) > 0 {
+		banEscalationSteps = pq.Array(ss.BanEscalationSteps)
+	}
+
 	query := `
 		UPDATE system_settings SET
 			geoip_enabled = $1, geoip_auto_update = $2, geoip_update_interval = $3,
@@ -633,6 +767,14 @@ func (r *BackupRepository) importSystemSettings(ctx context.Context, tx *sql.Tx,
 			bot_list_bad_bots = $38, bot_list_ai_bots = $39, bot_list_search_engines = $40, bot_list_suspicious_clients = $41,
 			waf_auto_ban_enabled = $42, waf_auto_ban_threshold = $43, waf_auto_ban_window = $44, waf_auto_ban_duration = $45,
 			direct_ip_access_action = $46, system_logs_enabled = $47,
+			ban_escalation_enabled = $48,
+			ban_escalation_steps = COALESCE($49, ban_escalation_steps),
+			ban_escalation_window = COALESCE(NULLIF($50, 0), ban_escalation_window),
+			ban_subnet_aggregation_enabled = $51,
+			ban_subnet_threshold = COALESCE(NULLIF($52, 0), ban_subnet_threshold),
+			ban_subnet_ipv4_prefix = COALESCE(NULLIF($53, 0), ban_subnet_ipv4_prefix),
+			ban_subnet_ipv6_prefix = COALESCE(NULLIF($54, 0), ban_subnet_ipv6_prefix),
+			ban_subnet_window = COALESCE(NULLIF($55, 0), ban_subnet_window),
 			updated_at = NOW()
 	`
 
@@ -655,6 +797,9 @@ func (r *BackupRepository) importSystemSettings(ctx context.Context, tx *sql.Tx,
 		ss.BotListBadBots, ss.BotListAIBots, ss.BotListSearchEngines, ss.BotListSuspiciousClients,
 		ss.WAFAutoBanEnabled, ss.WAFAutoBanThreshold, ss.WAFAutoBanWindow, ss.WAFAutoBanDuration,
 		ss.DirectIPAccessAction, ss.SystemLogsEnabled,
+		ss.BanEscalationEnabled, banEscalationSteps, ss.BanEscalationWindow,
+		ss.BanSubnetAggregationEnabled, ss.BanSubnetThreshold,
+		ss.BanSubnetIPv4Prefix, ss.BanSubnetIPv6Prefix, ss.BanSubnetWindow,
 	)
 	return err
 }
@@ -712,6 +857,243 @@ func (r *BackupRepository) importURIBlock(ctx context.Context, tx *sql.Tx, ub *m
 	return err
 }
 
+func (r *BackupRepository) importForwardAuthConfig(ctx context.Context, tx *sql.Tx, fa *model.ForwardAuthExport) error {
+	query := `
+		INSERT INTO forward_auth_configs (proxy_host_id, enabled, auth_url, signin_url, response_headers, bypass_paths, protect_host)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (proxy_host_id) DO UPDATE SET
+			enabled = EXCLUDED.enabled,
+			auth_url = EXCLUDED.auth_url,
+			signin_url = EXCLUDED.signin_url,
+			response_headers = EXCLUDED.response_headers,
+			bypass_paths = EXCLUDED.bypass_paths,
+			protect_host = EXCLUDED.protect_host,
+			updated_at = NOW()
+	`
+
+	responseHeaders := fa.ResponseHeaders
+	if responseHeaders == nil {
+		responseHeaders = []string{}
+	}
+	bypassPaths := fa.BypassPaths
+	if bypassPaths == nil {
+		bypassPaths = []string{}
+	}
+
+	_, err := tx.ExecContext(ctx, query, fa.ProxyHostID, fa.Enabled, fa.AuthURL, fa.SigninURL,
+		pq.Array(responseHeaders), pq.Array(bypassPaths), fa.ProtectHost)
+	return err
+}
+
+func (r *BackupRepository) importOIDCProvider(ctx context.Context, tx *sql.Tx, p *model.OIDCProviderExport) (string, error) {
+	query := `
+		INSERT INTO oidc_providers (name, issuer_url, client_id, client_secret, scopes, groups_claim, enabled)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id
+	`
+
+	scopes := p.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{"openid", "email", "profile"}
+	}
+	groupsClaim := p.GroupsClaim
+	if groupsClaim == "" {
+		groupsClaim = "groups"
+	}
+
+	var newID string
+	err := tx.QueryRowContext(ctx, query, p.Name, p.IssuerURL, p.ClientID, p.ClientSecret,
+		pq.Array(scopes), groupsClaim, p.Enabled).Scan(&newID)
+	return newID, err
+}
+
+func (r *BackupRepository) importOIDCHostGate(ctx context.Context, tx *sql.Tx, g *model.OIDCHostGateExport) error {
+	query := `
+		INSERT INTO oidc_host_gates (proxy_host_id, provider_id, enabled, allowed_email_domains, allowed_groups, required_claims, session_lifetime)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (proxy_host_id) DO UPDATE SET
+			provider_id = EXCLUDED.provider_id,
+			enabled = EXCLUDED.enabled,
+			allowed_email_domains = EXCLUDED.allowed_email_domains,
+			allowed_groups = EXCLUDED.allowed_groups,
+			required_claims = EXCLUDED.required_claims,
+			session_lifetime = EXCLUDED.session_lifetime,
+			updated_at = NOW()
+	`
+
+	domains := g.AllowedEmailDomains
+	if domains == nil {
+		domains = []string{}
+	}
+	groups := g.AllowedGroups
+	if groups == nil {
+		groups = []string{}
+	}
+	claims := g.RequiredClaims
+	if claims == nil {
+		claims = map[string]string{}
+	}
+	claimsJSON, _ := json.Marshal(claims)
+	lifetime := g.SessionLifetime
+	if lifetime <= 0 {
+		lifetime = 28800
+	}
+
+	_, err := tx.ExecContext(ctx, query, g.ProxyHostID, g.ProviderID, g.Enabled,
+		pq.Array(domains), pq.Array(groups), claimsJSON, lifetime)
+	return err
+}
+
+func (r *BackupRepository) importClientCA(ctx context.Context, tx *sql.Tx, ca *model.ClientCAExport) (string, error) {
+	query := `
+		INSERT INTO client_cas (name, description, ca_pem, crl_pem)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`
+
+	var newID string
+	err := tx.QueryRowContext(ctx, query, ca.Name, ca.Description, ca.CAPEM, ca.CRLPEM).Scan(&newID)
+	return newID, err
+}
+
+func (r *BackupRepository) importClientCertAuthConfig(ctx context.Context, tx *sql.Tx, cfg *model.ClientCertAuthConfigExport) error {
+	query := `
+		INSERT INTO client_cert_auth_configs (proxy_host_id, client_ca_id, enabled, verify_mode, verify_depth, forward_headers, enforce_paths)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (proxy_host_id) DO UPDATE SET
+			client_ca_id = EXCLUDED.client_ca_id,
+			enabled = EXCLUDED.enabled,
+			verify_mode = EXCLUDED.verify_mode,
+			verify_depth = EXCLUDED.verify_depth,
+			forward_headers = EXCLUDED.forward_headers,
+			enforce_paths = EXCLUDED.enforce_paths,
+			updated_at = NOW()
+	`
+
+	verifyMode := cfg.VerifyMode
+	if verifyMode == "" {
+		verifyMode = model.ClientCertVerifyOn
+	}
+	verifyDepth := cfg.VerifyDepth
+	if verifyDepth <= 0 {
+		verifyDepth = 1
+	}
+	enforcePaths := cfg.EnforcePaths
+	if enforcePaths == nil {
+		enforcePaths = []string{}
+	}
+
+	_, err := tx.ExecContext(ctx, query, cfg.ProxyHostID, cfg.ClientCAID, cfg.Enabled, verifyMode, verifyDepth,
+		cfg.ForwardHeaders, pq.Array(enforcePaths))
+	return err
+}
+
+func (r *BackupRepository) importUpstreamTLSConfig(ctx context.Context, tx *sql.Tx, cfg *model.UpstreamTLSConfigExport) error {
+	query := `
+		INSERT INTO upstream_tls_configs (proxy_host_id, enabled, verify, trusted_ca_pem, verify_depth, server_name, sni, client_certificate_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (proxy_host_id) DO UPDATE SET
+			enabled = EXCLUDED.enabled,
+			verify = EXCLUDED.verify,
+			trusted_ca_pem = EXCLUDED.trusted_ca_pem,
+			verify_depth = EXCLUDED.verify_depth,
+			server_name = EXCLUDED.server_name,
+			sni = EXCLUDED.sni,
+			client_certificate_id = EXCLUDED.client_certificate_id,
+			updated_at = NOW()
+	`
+
+	verifyDepth := cfg.VerifyDepth
+	if verifyDepth <= 0 {
+		verifyDepth = 2
+	}
+
+	_, err := tx.ExecContext(ctx, query, cfg.ProxyHostID, cfg.Enabled, cfg.Verify, cfg.TrustedCAPEM, verifyDepth,
+		cfg.ServerName, cfg.SNI, cfg.ClientCertificateID)
+	return err
+}
+
+func (r *BackupRepository) importCORSPolicy(ctx context.Context, tx *sql.Tx, p *model.CORSPolicyExport) error {
+	query := `
+		INSERT INTO cors_policies (proxy_host_id, path_prefix, enabled, allowed_origins, allowed_origin_patterns,
+		                           allowed_methods, allowed_headers, exposed_headers, allow_credentials, max_age)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (proxy_host_id, path_prefix) DO UPDATE SET
+			enabled = EXCLUDED.enabled,
+			allowed_origins = EXCLUDED.allowed_origins,
+			allowed_origin_patterns = EXCLUDED.allowed_origin_patterns,
+			allowed_methods = EXCLUDED.allowed_methods,
+			allowed_headers = EXCLUDED.allowed_headers,
+			exposed_headers = EXCLUDED.exposed_headers,
+			allow_credentials = EXCLUDED.allow_credentials,
+			max_age = EXCLUDED.max_age,
+			updated_at = NOW()
+	`
+
+	// The array columns are NOT NULL
+	for _, values := range []*[]string{&p.AllowedOrigins, &p.AllowedOriginPatterns, &p.AllowedMethods, &p.AllowedHeaders, &p.ExposedHeaders} {
+		if *values == nil {
+			*values = []string{}
+		}
+	}
+
+	_, err := tx.ExecContext(ctx, query, p.ProxyHostID, p.PathPrefix, p.Enabled, pq.Array(p.AllowedOrigins),
+		pq.Array(p.AllowedOriginPatterns), pq.Array(p.AllowedMethods), pq.Array(p.AllowedHeaders), pq.Array(p.ExposedHeaders),
+		p.AllowCredentials, p.MaxAge)
+	return err
+}
+
+func (r *BackupRepository) importSignedURLLocation(ctx context.Context, tx *sql.Tx, l *model.SignedURLLocationExport) error {
+	query := `
+		INSERT INTO signed_url_locations (proxy_host_id, path_prefix, enabled, secret, hash_expression, token_param,
+		                                  expires_param, default_ttl, max_ttl)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (proxy_host_id, path_prefix) DO UPDATE SET
+			enabled = EXCLUDED.enabled,
+			secret = EXCLUDED.secret,
+			hash_expression = EXCLUDED.hash_expression,
+			token_param = EXCLUDED.token_param,
+			expires_param = EXCLUDED.expires_param,
+			default_ttl = EXCLUDED.default_ttl,
+			max_ttl = EXCLUDED.max_ttl,
+			updated_at = NOW()
+	`
+
+	_, err := tx.ExecContext(ctx, query, l.ProxyHostID, l.PathPrefix, l.Enabled, l.Secret, l.HashExpression, l.TokenParam,
+		l.ExpiresParam, l.DefaultTTL, l.MaxTTL)
+	return err
+}
+
+func (r *BackupRepository) importTLSPolicy(ctx context.Context, tx *sql.Tx, p *model.TLSPolicyExport) error {
+	query := `
+		INSERT INTO tls_policies (proxy_host_id, profile, protocols, ciphers, curves, prefer_server_ciphers, session_tickets,
+		                          ocsp_stapling, hsts_enabled, hsts_max_age, hsts_include_subdomains, hsts_preload)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT (proxy_host_id) DO UPDATE SET
+			profile = EXCLUDED.profile,
+			protocols = EXCLUDED.protocols,
+			ciphers = EXCLUDED.ciphers,
+			curves = EXCLUDED.curves,
+			prefer_server_ciphers = EXCLUDED.prefer_server_ciphers,
+			session_tickets = EXCLUDED.session_tickets,
+			ocsp_stapling = EXCLUDED.ocsp_stapling,
+			hsts_enabled = EXCLUDED.hsts_enabled,
+			hsts_max_age = EXCLUDED.hsts_max_age,
+			hsts_include_subdomains = EXCLUDED.hsts_include_subdomains,
+			hsts_preload = EXCLUDED.hsts_preload,
+			updated_at = NOW()
+	`
+
+	protocols := p.Protocols
+	if len(protocols) == 0 {
+		protocols = []string{"TLSv1.2", "TLSv1.3"}
+	}
+
+	_, err := tx.ExecContext(ctx, query, p.ProxyHostID, p.Profile, pq.Array(protocols), p.Ciphers, p.Curves,
+		p.PreferServerCiphers, p.SessionTickets, p.OCSPStapling, p.HSTSEnabled, p.HSTSMaxAge, p.HSTSIncludeSubdomains, p.HSTSPreload)
+	return err
+}
+
 func (r *BackupRepository) importGlobalURIBlock(ctx context.Context, tx *sql.Tx, ub *model.GlobalURIBlockExport) error {
 	rulesJSON, _ := json.Marshal(ub.Rules)
 
@@ -173,27 +173,55 @@ func (r *CertificateRepository) GetByID(ctx context.Context, id string) (*model.
 }
 
 func (r *CertificateRepository) List(ctx context.Context, page, perPage int) ([]model.Certificate, int, error) {
+	return r.ListInScope(ctx, model.APITokenScope{}, page, perPage)
+}
+
+// certificateScopeCondition matches certificates whose domain names are each
+// matched by a domain glob of the scope or served by a proxy host in it.
+// Attaching a certificate to an in-scope host therefore doesn't widen the scope.
+func certificateScopeCondition(scope model.APITokenScope, column string, argIndex int) (string, []interface{}) {
+	hostCond, args := hostScopeCondition(scope, "ph.", argIndex)
+	globArg := argIndex + len(args)
+	cond := fmt.Sprintf(`(cardinality(%s) > 0 AND NOT EXISTS (
+		SELECT 1 FROM unnest(%s) AS cd(name)
+		WHERE NOT (lower(cd.name) LIKE ANY($%d::text[])
+			OR EXISTS (SELECT 1 FROM proxy_hosts ph WHERE cd.name = ANY(ph.domain_names) AND %s))))`,
+		column, column, globArg, hostCond)
+	return cond, append(args, pq.Array(domainGlobPatterns(scope)))
+}
+
+// ListInScope lists the certificates inside an API token scope; an empty scope lists all certificates
+func (r *CertificateRepository) ListInScope(ctx context.Context, scope model.APITokenScope, page, perPage int) ([]model.Certificate, int, error) {
 	offset := (page - 1) * perPage
 
+	whereClause := ""
+	var args []interface{}
+	if !scope.IsEmpty() {
+		var cond string
+		cond, args = certificateScopeCondition(scope, "c.domain_names", 1)
+		whereClause = "WHERE " + cond
+	}
+
 	// Count total
 	var total int
-	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM certificates`).Scan(&total)
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM certificates c `+whereClause, args...).Scan(&total)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to count certificates: %w", err)
 	}
 
-	query := `
+	query := fmt.Sprintf(`
 		SELECT c.id, c.domain_names, c.dns_provider_id, c.status, c.provider, c.auto_renew,
 			c.expires_at, c.issued_at, c.renewal_attempted_at, c.error_message,
 			c.certificate_path, c.private_key_path, c.created_at, c.updated_at,
 			d.id, d.name, d.provider_type
 		FROM certificates c
 		LEFT JOIN dns_providers d ON c.dns_provider_id = d.id
+		%s
 		ORDER BY c.created_at DESC
-		LIMIT $1 OFFSET $2
-	`
+		LIMIT $%d OFFSET $%d
+	`, whereClause, len(args)+1, len(args)+2)
 
-	rows, err := r.db.QueryContext(ctx, query, perPage, offset)
+	rows, err := r.db.QueryContext(ctx, query, append(args, perPage, offset)...)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to list certificates: %w", err)
 	}
@@ -252,6 +280,41 @@ func (r *CertificateRepository) List(ctx context.Context, page, perPage int) ([]
 	return certs, total, nil
 }
 
+// InScope reports whether a certificate is inside an API token scope
+func (r *CertificateRepository) InScope(ctx context.Context, scope model.APITokenScope, id string) (bool, error) {
+	if scope.IsEmpty() {
+		return true, nil
+	}
+	cond, args := certificateScopeCondition(scope, "c.domain_names", 2)
+	var inScope bool
+	err := r.db.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM certificates c WHERE c.id = $1 AND `+cond+`)`,
+		append([]interface{}{id}, args...)...,
+	).Scan(&inScope)
+	if err != nil {
+		return false, fmt.Errorf("failed to check certificate scope: %w", err)
+	}
+	return inScope, nil
+}
+
+// DomainsInScope reports whether a certificate for the given domains would be
+// inside an API token scope
+func (r *CertificateRepository) DomainsInScope(ctx context.Context, scope model.APITokenScope, domains []string) (bool, error) {
+	if scope.IsEmpty() {
+		return true, nil
+	}
+	cond, args := certificateScopeCondition(scope, "$1::text[]", 2)
+	var inScope bool
+	err := r.db.QueryRowContext(ctx,
+		`SELECT `+cond,
+		append([]interface{}{pq.Array(domains)}, args...)...,
+	).Scan(&inScope)
+	if err != nil {
+		return false, fmt.Errorf("failed to check certificate domain scope: %w", err)
+	}
+	return inScope, nil
+}
+
 func (r *CertificateRepository) Update(ctx context.Context, cert *model.Certificate) error {
 	query := `
 		UPDATE certificates
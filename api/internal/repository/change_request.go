@@ -0,0 +1,261 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"nginx-proxy-guard/internal/model"
+)
+
+type ChangeRequestRepository struct {
+	db *sql.DB
+}
+
+func NewChangeRequestRepository(db *sql.DB) *ChangeRequestRepository {
+	return &ChangeRequestRepository{db: db}
+}
+
+const approvalSettingsColumns = `id, enabled, actions, approver_role, expiry_hours, created_at, updated_at`
+
+func scanApprovalSettings(row interface{ Scan(...interface{}) error }, s *model.ApprovalSettings) error {
+	return row.Scan(&s.ID, &s.Enabled, pq.Array(&s.Actions), &s.ApproverRole, &s.ExpiryHours, &s.CreatedAt, &s.UpdatedAt)
+}
+
+// GetSettings returns the approval settings, creating the default row if none exists
+func (r *ChangeRequestRepository) GetSettings(ctx context.Context) (*model.ApprovalSettings, error) {
+	query := `SELECT ` + approvalSettingsColumns + ` FROM approval_settings LIMIT 1`
+
+	var settings model.ApprovalSettings
+	err := scanApprovalSettings(r.db.QueryRowContext(ctx, query), &settings)
+	if err == sql.ErrNoRows {
+		insert := `INSERT INTO approval_settings DEFAULT VALUES RETURNING ` + approvalSettingsColumns
+		if err := scanApprovalSettings(r.db.QueryRowContext(ctx, insert), &settings); err != nil {
+			return nil, fmt.Errorf("failed to create default approval settings: %w", err)
+		}
+		return &settings, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get approval settings: %w", err)
+	}
+
+	return &settings, nil
+}
+
+// SaveSettings stores the approval settings; callers merge and validate the update beforehand
+func (r *ChangeRequestRepository) SaveSettings(ctx context.Context, s *model.ApprovalSettings) (*model.ApprovalSettings, error) {
+	query := `
+		UPDATE approval_settings SET
+			enabled = $1,
+			actions = $2,
+			approver_role = $3,
+			expiry_hours = $4,
+			updated_at = NOW()
+		WHERE id = $5
+		RETURNING ` + approvalSettingsColumns
+
+	var updated model.ApprovalSettings
+	err := scanApprovalSettings(r.db.QueryRowContext(ctx, query,
+		s.Enabled, pq.Array(s.Actions), s.ApproverRole, s.ExpiryHours, s.ID,
+	), &updated)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update approval settings: %w", err)
+	}
+
+	return &updated, nil
+}
+
+// Change requests
+
+const changeRequestColumns = `id, action, target_id, target_name, payload, diff, status,
+	       requested_by, requested_by_name, reviewed_by, reviewed_by_name, review_comment, error_message,
+	       expires_at, reviewed_at, applied_at, created_at`
+
+func scanChangeRequest(row interface{ Scan(...interface{}) error }, cr *model.ChangeRequest) error {
+	var payload []byte
+	var requestedBy, reviewedBy sql.NullString
+	var reviewedAt, appliedAt sql.NullTime
+	err := row.Scan(
+		&cr.ID, &cr.Action, &cr.TargetID, &cr.TargetName, &payload, &cr.Diff, &cr.Status,
+		&requestedBy, &cr.RequestedByName, &reviewedBy, &cr.ReviewedByName, &cr.ReviewComment, &cr.ErrorMessage,
+		&cr.ExpiresAt, &reviewedAt, &appliedAt, &cr.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	cr.Payload = payload
+	cr.RequestedBy = requestedBy.String
+	cr.ReviewedBy = reviewedBy.String
+	if reviewedAt.Valid {
+		cr.ReviewedAt = &reviewedAt.Time
+	}
+	if appliedAt.Valid {
+		cr.AppliedAt = &appliedAt.Time
+	}
+	return nil
+}
+
+// Create stores a new pending change request
+func (r *ChangeRequestRepository) Create(ctx context.Context, cr *model.ChangeRequest) (*model.ChangeRequest, error) {
+	query := `
+		INSERT INTO change_requests (action, target_id, target_name, payload, diff, requested_by, requested_by_name, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING ` + changeRequestColumns
+
+	var created model.ChangeRequest
+	err := scanChangeRequest(r.db.QueryRowContext(ctx, query,
+		cr.Action, cr.TargetID, cr.TargetName, []byte(cr.Payload), cr.Diff,
+		nullString(cr.RequestedBy), cr.RequestedByName, cr.ExpiresAt,
+	), &created)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create change request: %w", err)
+	}
+
+	return &created, nil
+}
+
+// GetByID returns a change request, nil if it doesn't exist
+func (r *ChangeRequestRepository) GetByID(ctx context.Context, id string) (*model.ChangeRequest, error) {
+	query := `SELECT ` + changeRequestColumns + ` FROM change_requests WHERE id = $1`
+
+	var cr model.ChangeRequest
+	err := scanChangeRequest(r.db.QueryRowContext(ctx, query, id), &cr)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get change request: %w", err)
+	}
+
+	return &cr, nil
+}
+
+// List returns change requests newest first, optionally only those in one
+// status. Only requests submitted by requestedBy or for one of actions are
+// returned.
+func (r *ChangeRequestRepository) List(ctx context.Context, status, requestedBy string, actions []string, page, perPage int) ([]model.ChangeRequest, int, error) {
+	whereClause := "WHERE (requested_by::text = $1 OR action = ANY($2))"
+	args := []interface{}{requestedBy, pq.Array(actions)}
+	if status != "" {
+		whereClause += " AND status = $3"
+		args = append(args, status)
+	}
+
+	var total int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM change_requests `+whereClause, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count change requests: %w", err)
+	}
+
+	query := fmt.Sprintf(`SELECT %s FROM change_requests %s ORDER BY created_at DESC LIMIT $%d OFFSET $%d`,
+		changeRequestColumns, whereClause, len(args)+1, len(args)+2)
+	rows, err := r.db.QueryContext(ctx, query, append(args, perPage, (page-1)*perPage)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list change requests: %w", err)
+	}
+	defer rows.Close()
+
+	requests := []model.ChangeRequest{}
+	for rows.Next() {
+		var cr model.ChangeRequest
+		if err := scanChangeRequest(rows, &cr); err != nil {
+			return nil, 0, err
+		}
+		requests = append(requests, cr)
+	}
+
+	return requests, total, rows.Err()
+}
+
+// Review moves a pending, unexpired change request to status and records
+// the reviewer. It returns nil when the request was no longer pending, so
+// of two concurrent reviews only one succeeds.
+func (r *ChangeRequestRepository) Review(ctx context.Context, id, status, reviewerID, reviewerName, comment string) (*model.ChangeRequest, error) {
+	query := `
+		UPDATE change_requests SET
+			status = $2,
+			reviewed_by = $3,
+			reviewed_by_name = $4,
+			review_comment = $5,
+			reviewed_at = NOW()
+		WHERE id = $1 AND status = 'pending' AND expires_at > NOW()
+		RETURNING ` + changeRequestColumns
+
+	var cr model.ChangeRequest
+	err := scanChangeRequest(r.db.QueryRowContext(ctx, query,
+		id, status, nullString(reviewerID), reviewerName, comment,
+	), &cr)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to review change request: %w", err)
+	}
+
+	return &cr, nil
+}
+
+// Finish records the outcome of applying an approved change request
+func (r *ChangeRequestRepository) Finish(ctx context.Context, id, status, errorMessage string) (*model.ChangeRequest, error) {
+	query := `
+		UPDATE change_requests SET
+			status = $2,
+			error_message = $3,
+			applied_at = $4
+		WHERE id = $1 AND status = 'approved'
+		RETURNING ` + changeRequestColumns
+
+	var appliedAt interface{}
+	if status == model.ChangeStatusApplied {
+		appliedAt = time.Now()
+	}
+
+	var cr model.ChangeRequest
+	err := scanChangeRequest(r.db.QueryRowContext(ctx, query, id, status, errorMessage, appliedAt), &cr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to finish change request: %w", err)
+	}
+
+	return &cr, nil
+}
+
+// ExpirePending marks pending change requests past their expiry as expired and returns them
+func (r *ChangeRequestRepository) ExpirePending(ctx context.Context, now time.Time) ([]model.ChangeRequest, error) {
+	query := `
+		UPDATE change_requests SET status = 'expired'
+		WHERE status = 'pending' AND expires_at <= $1
+		RETURNING ` + changeRequestColumns
+
+	rows, err := r.db.QueryContext(ctx, query, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expire change requests: %w", err)
+	}
+	defer rows.Close()
+
+	var expired []model.ChangeRequest
+	for rows.Next() {
+		var cr model.ChangeRequest
+		if err := scanChangeRequest(rows, &cr); err != nil {
+			return nil, err
+		}
+		expired = append(expired, cr)
+	}
+
+	return expired, rows.Err()
+}
+
+// FailStale marks approved change requests whose apply never finished, e.g.
+// because the server restarted mid-apply, as failed
+func (r *ChangeRequestRepository) FailStale(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE change_requests SET status = 'failed', error_message = 'apply was interrupted'
+		WHERE status = 'approved' AND reviewed_at < $1
+	`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fail stale change requests: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"nginx-proxy-guard/internal/model"
+)
+
+type ClientCARepository struct {
+	db *sql.DB
+}
+
+func NewClientCARepository(db *sql.DB) *ClientCARepository {
+	return &ClientCARepository{db: db}
+}
+
+const clientCAColumns = `id, name, description, ca_pem, crl_pem, created_at, updated_at`
+
+func scanClientCA(row interface{ Scan(...interface{}) error }) (*model.ClientCA, error) {
+	var ca model.ClientCA
+	if err := row.Scan(&ca.ID, &ca.Name, &ca.Description, &ca.CAPEM, &ca.CRLPEM, &ca.CreatedAt, &ca.UpdatedAt); err != nil {
+		return nil, err
+	}
+	ca.HasCRL = ca.CRLPEM != ""
+	return &ca, nil
+}
+
+// List returns all client CAs
+func (r *ClientCARepository) List(ctx context.Context) ([]model.ClientCA, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+clientCAColumns+` FROM client_cas ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list client CAs: %w", err)
+	}
+	defer rows.Close()
+
+	var cas []model.ClientCA
+	for rows.Next() {
+		ca, err := scanClientCA(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan client CA: %w", err)
+		}
+		cas = append(cas, *ca)
+	}
+	return cas, rows.Err()
+}
+
+// GetByID returns a client CA by ID
+func (r *ClientCARepository) GetByID(ctx context.Context, id string) (*model.ClientCA, error) {
+	ca, err := scanClientCA(r.db.QueryRowContext(ctx,
+		`SELECT `+clientCAColumns+` FROM client_cas WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client CA: %w", err)
+	}
+	return ca, nil
+}
+
+// GetByName returns a client CA by name
+func (r *ClientCARepository) GetByName(ctx context.Context, name string) (*model.ClientCA, error) {
+	ca, err := scanClientCA(r.db.QueryRowContext(ctx,
+		`SELECT `+clientCAColumns+` FROM client_cas WHERE name = $1`, name))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client CA: %w", err)
+	}
+	return ca, nil
+}
+
+// Create creates a client CA
+func (r *ClientCARepository) Create(ctx context.Context, req *model.ClientCARequest) (*model.ClientCA, error) {
+	ca, err := scanClientCA(r.db.QueryRowContext(ctx, `
+		INSERT INTO client_cas (name, description, ca_pem, crl_pem)
+		VALUES ($1, $2, $3, $4)
+		RETURNING `+clientCAColumns,
+		req.Name, req.Description, req.CAPEM, req.CRLPEM))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client CA: %w", err)
+	}
+	return ca, nil
+}
+
+// Update replaces a client CA
+func (r *ClientCARepository) Update(ctx context.Context, id string, req *model.ClientCARequest) (*model.ClientCA, error) {
+	ca, err := scanClientCA(r.db.QueryRowContext(ctx, `
+		UPDATE client_cas SET
+			name = $2,
+			description = $3,
+			ca_pem = $4,
+			crl_pem = $5,
+			updated_at = NOW()
+		WHERE id = $1
+		RETURNING `+clientCAColumns,
+		id, req.Name, req.Description, req.CAPEM, req.CRLPEM))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update client CA: %w", err)
+	}
+	return ca, nil
+}
+
+// Delete deletes a client CA
+func (r *ClientCARepository) Delete(ctx context.Context, id string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM client_cas WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete client CA: %w", err)
+	}
+	return nil
+}
+
+// ListProxyHostIDsByCA returns the proxy hosts whose mTLS settings reference a client CA
+func (r *ClientCARepository) ListProxyHostIDsByCA(ctx context.Context, caID string) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT proxy_host_id FROM client_cert_auth_configs WHERE client_ca_id = $1`, caID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list client CA proxy hosts: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan proxy host id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// GetAuthByProxyHostID returns the mTLS settings of a proxy host
+func (r *ClientCARepository) GetAuthByProxyHostID(ctx context.Context, proxyHostID string) (*model.ClientCertAuthConfig, error) {
+	var cfg model.ClientCertAuthConfig
+	var enforcePaths pq.StringArray
+
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id, proxy_host_id, client_ca_id, enabled, verify_mode, verify_depth, forward_headers,
+		       enforce_paths, created_at, updated_at
+		FROM client_cert_auth_configs WHERE proxy_host_id = $1
+	`, proxyHostID).Scan(
+		&cfg.ID, &cfg.ProxyHostID, &cfg.ClientCAID, &cfg.Enabled, &cfg.VerifyMode, &cfg.VerifyDepth, &cfg.ForwardHeaders,
+		&enforcePaths, &cfg.CreatedAt, &cfg.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client cert auth config: %w", err)
+	}
+
+	cfg.EnforcePaths = []string(enforcePaths)
+	if cfg.EnforcePaths == nil {
+		cfg.EnforcePaths = []string{}
+	}
+	return &cfg, nil
+}
+
+// UpsertAuth creates or replaces the mTLS settings of a proxy host
+func (r *ClientCARepository) UpsertAuth(ctx context.Context, proxyHostID string, req *model.UpsertClientCertAuthRequest) (*model.ClientCertAuthConfig, error) {
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+	enforcePaths := req.EnforcePaths
+	if enforcePaths == nil {
+		enforcePaths = []string{}
+	}
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO client_cert_auth_configs (proxy_host_id, client_ca_id, enabled, verify_mode, verify_depth, forward_headers, enforce_paths)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (proxy_host_id) DO UPDATE SET
+			client_ca_id = EXCLUDED.client_ca_id,
+			enabled = EXCLUDED.enabled,
+			verify_mode = EXCLUDED.verify_mode,
+			verify_depth = EXCLUDED.verify_depth,
+			forward_headers = EXCLUDED.forward_headers,
+			enforce_paths = EXCLUDED.enforce_paths,
+			updated_at = NOW()
+	`, proxyHostID, req.ClientCAID, enabled, req.VerifyMode, req.VerifyDepth, req.ForwardHeaders, pq.Array(enforcePaths))
+	if err != nil {
+		return nil, fmt.Errorf("failed to upsert client cert auth config: %w", err)
+	}
+
+	return r.GetAuthByProxyHostID(ctx, proxyHostID)
+}
+
+// DeleteAuth removes the mTLS settings of a proxy host
+func (r *ClientCARepository) DeleteAuth(ctx context.Context, proxyHostID string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM client_cert_auth_configs WHERE proxy_host_id = $1`, proxyHostID)
+	if err != nil {
+		return fmt.Errorf("failed to delete client cert auth config: %w", err)
+	}
+	return nil
+}
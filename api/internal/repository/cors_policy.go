@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"nginx-proxy-guard/internal/model"
+)
+
+type CORSPolicyRepository struct {
+	db *sql.DB
+}
+
+func NewCORSPolicyRepository(db *sql.DB) *CORSPolicyRepository {
+	return &CORSPolicyRepository{db: db}
+}
+
+const corsPolicyColumns = `id, proxy_host_id, path_prefix, enabled, allowed_origins, allowed_origin_patterns,
+	       allowed_methods, allowed_headers, exposed_headers, allow_credentials, max_age, created_at, updated_at`
+
+func scanCORSPolicy(row interface{ Scan(...interface{}) error }, p *model.CORSPolicy) error {
+	var origins, patterns, methods, headers, exposed pq.StringArray
+	err := row.Scan(
+		&p.ID, &p.ProxyHostID, &p.PathPrefix, &p.Enabled, &origins, &patterns,
+		&methods, &headers, &exposed, &p.AllowCredentials, &p.MaxAge, &p.CreatedAt, &p.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	p.AllowedOrigins = []string(origins)
+	p.AllowedOriginPatterns = []string(patterns)
+	p.AllowedMethods = []string(methods)
+	p.AllowedHeaders = []string(headers)
+	p.ExposedHeaders = []string(exposed)
+	return nil
+}
+
+// ListByProxyHostID returns the CORS policies of a proxy host, host-wide policy first
+func (r *CORSPolicyRepository) ListByProxyHostID(ctx context.Context, proxyHostID string) ([]model.CORSPolicy, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+corsPolicyColumns+`
+		FROM cors_policies WHERE proxy_host_id = $1
+		ORDER BY path_prefix
+	`, proxyHostID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cors policies: %w", err)
+	}
+	defer rows.Close()
+
+	var policies []model.CORSPolicy
+	for rows.Next() {
+		var p model.CORSPolicy
+		if err := scanCORSPolicy(rows, &p); err != nil {
+			return nil, fmt.Errorf("failed to scan cors policy: %w", err)
+		}
+		policies = append(policies, p)
+	}
+
+	return policies, rows.Err()
+}
+
+// GetByID returns a CORS policy of a proxy host
+func (r *CORSPolicyRepository) GetByID(ctx context.Context, proxyHostID, id string) (*model.CORSPolicy, error) {
+	var p model.CORSPolicy
+	err := scanCORSPolicy(r.db.QueryRowContext(ctx, `
+		SELECT `+corsPolicyColumns+`
+		FROM cors_policies WHERE id = $1 AND proxy_host_id = $2
+	`, id, proxyHostID), &p)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cors policy: %w", err)
+	}
+
+	return &p, nil
+}
+
+// GetByPath returns the CORS policy of a proxy host path prefix
+func (r *CORSPolicyRepository) GetByPath(ctx context.Context, proxyHostID, pathPrefix string) (*model.CORSPolicy, error) {
+	var p model.CORSPolicy
+	err := scanCORSPolicy(r.db.QueryRowContext(ctx, `
+		SELECT `+corsPolicyColumns+`
+		FROM cors_policies WHERE proxy_host_id = $1 AND path_prefix = $2
+	`, proxyHostID, pathPrefix), &p)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cors policy: %w", err)
+	}
+
+	return &p, nil
+}
+
+// Upsert creates or replaces the CORS policy of a proxy host path prefix
+func (r *CORSPolicyRepository) Upsert(ctx context.Context, proxyHostID string, p *model.CORSPolicy) (*model.CORSPolicy, error) {
+	var saved model.CORSPolicy
+	err := scanCORSPolicy(r.db.QueryRowContext(ctx, `
+		INSERT INTO cors_policies (proxy_host_id, path_prefix, enabled, allowed_origins, allowed_origin_patterns,
+		                           allowed_methods, allowed_headers, exposed_headers, allow_credentials, max_age)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (proxy_host_id, path_prefix) DO UPDATE SET
+			enabled = EXCLUDED.enabled,
+			allowed_origins = EXCLUDED.allowed_origins,
+			allowed_origin_patterns = EXCLUDED.allowed_origin_patterns,
+			allowed_methods = EXCLUDED.allowed_methods,
+			allowed_headers = EXCLUDED.allowed_headers,
+			exposed_headers = EXCLUDED.exposed_headers,
+			allow_credentials = EXCLUDED.allow_credentials,
+			max_age = EXCLUDED.max_age,
+			updated_at = NOW()
+		RETURNING `+corsPolicyColumns,
+		proxyHostID, p.PathPrefix, p.Enabled, pq.Array(p.AllowedOrigins), pq.Array(p.AllowedOriginPatterns),
+		pq.Array(p.AllowedMethods), pq.Array(p.AllowedHeaders), pq.Array(p.ExposedHeaders), p.AllowCredentials, p.MaxAge,
+	), &saved)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upsert cors policy: %w", err)
+	}
+
+	return &saved, nil
+}
+
+// Delete removes a CORS policy of a proxy host
+func (r *CORSPolicyRepository) Delete(ctx context.Context, proxyHostID, id string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM cors_policies WHERE id = $1 AND proxy_host_id = $2`, id, proxyHostID)
+	if err != nil {
+		return fmt.Errorf("failed to delete cors policy: %w", err)
+	}
+	return nil
+}
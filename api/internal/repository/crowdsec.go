@@ -0,0 +1,244 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"nginx-proxy-guard/internal/model"
+)
+
+type CrowdSecRepository struct {
+	db *sql.DB
+}
+
+func NewCrowdSecRepository(db *sql.DB) *CrowdSecRepository {
+	return &CrowdSecRepository{db: db}
+}
+
+const crowdSecSettingsColumns = `id, enabled, lapi_url, api_key, poll_interval, push_signals,
+	       machine_id, machine_password, last_sync_at, COALESCE(last_sync_error, ''), created_at, updated_at`
+
+func scanCrowdSecSettings(row interface{ Scan(...interface{}) error }, s *model.CrowdSecSettings) error {
+	var lastSyncAt sql.NullTime
+	err := row.Scan(
+		&s.ID, &s.Enabled, &s.LAPIURL, &s.APIKey, &s.PollInterval, &s.PushSignals,
+		&s.MachineID, &s.MachinePassword, &lastSyncAt, &s.LastSyncError, &s.CreatedAt, &s.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	if lastSyncAt.Valid {
+		s.LastSyncAt = &lastSyncAt.Time
+	}
+	s.HasAPIKey = s.APIKey != ""
+	s.HasMachinePassword = s.MachinePassword != ""
+	return nil
+}
+
+// GetSettings returns the CrowdSec settings, creating the default row if none exists
+func (r *CrowdSecRepository) GetSettings(ctx context.Context) (*model.CrowdSecSettings, error) {
+	query := `SELECT ` + crowdSecSettingsColumns + ` FROM crowdsec_settings LIMIT 1`
+
+	var settings model.CrowdSecSettings
+	err := scanCrowdSecSettings(r.db.QueryRowContext(ctx, query), &settings)
+	if err == sql.ErrNoRows {
+		insert := `INSERT INTO crowdsec_settings DEFAULT VALUES RETURNING ` + crowdSecSettingsColumns
+		if err := scanCrowdSecSettings(r.db.QueryRowContext(ctx, insert), &settings); err != nil {
+			return nil, fmt.Errorf("failed to create default crowdsec settings: %w", err)
+		}
+		return &settings, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get crowdsec settings: %w", err)
+	}
+
+	return &settings, nil
+}
+
+// UpdateSettings applies the non-nil fields of req; empty secrets keep the stored value
+func (r *CrowdSecRepository) UpdateSettings(ctx context.Context, req *model.UpdateCrowdSecSettingsRequest) (*model.CrowdSecSettings, error) {
+	settings, err := r.GetSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Enabled != nil {
+		settings.Enabled = *req.Enabled
+	}
+	if req.LAPIURL != nil {
+		settings.LAPIURL = *req.LAPIURL
+	}
+	if req.APIKey != nil && *req.APIKey != "" {
+		settings.APIKey = *req.APIKey
+	}
+	if req.PollInterval != nil {
+		settings.PollInterval = *req.PollInterval
+	}
+	if req.PushSignals != nil {
+		settings.PushSignals = *req.PushSignals
+	}
+	if req.MachineID != nil {
+		settings.MachineID = *req.MachineID
+	}
+	if req.MachinePassword != nil && *req.MachinePassword != "" {
+		settings.MachinePassword = *req.MachinePassword
+	}
+
+	query := `
+		UPDATE crowdsec_settings SET
+			enabled = $1,
+			lapi_url = $2,
+			api_key = $3,
+			poll_interval = $4,
+			push_signals = $5,
+			machine_id = $6,
+			machine_password = $7,
+			updated_at = NOW()
+		WHERE id = $8
+		RETURNING ` + crowdSecSettingsColumns
+
+	var updated model.CrowdSecSettings
+	err = scanCrowdSecSettings(r.db.QueryRowContext(ctx, query,
+		settings.Enabled, settings.LAPIURL, settings.APIKey, settings.PollInterval,
+		settings.PushSignals, settings.MachineID, settings.MachinePassword, settings.ID,
+	), &updated)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update crowdsec settings: %w", err)
+	}
+
+	return &updated, nil
+}
+
+// SetSyncStatus records the result of the last decision stream poll
+func (r *CrowdSecRepository) SetSyncStatus(ctx context.Context, syncErr string) error {
+	var errValue interface{}
+	if syncErr != "" {
+		errValue = syncErr
+	}
+	_, err := r.db.ExecContext(ctx, `UPDATE crowdsec_settings SET last_sync_at = NOW(), last_sync_error = $1`, errValue)
+	if err != nil {
+		return fmt.Errorf("failed to update crowdsec sync status: %w", err)
+	}
+	return nil
+}
+
+// ReplaceDecisions replaces all CrowdSec bans with the given set (used for a startup stream)
+func (r *CrowdSecRepository) ReplaceDecisions(ctx context.Context, bans []model.CrowdSecBan) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM banned_ips WHERE origin = $1`, model.BanOriginCrowdSec); err != nil {
+		return fmt.Errorf("failed to clear crowdsec decisions: %w", err)
+	}
+	if err := upsertCrowdSecBans(ctx, tx, bans); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ApplyDecisions adds new CrowdSec bans and removes deleted ones
+// Local bans for the same address are never overwritten or removed
+func (r *CrowdSecRepository) ApplyDecisions(ctx context.Context, bans []model.CrowdSecBan, deleted []string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if len(deleted) > 0 {
+		_, err := tx.ExecContext(ctx, `
+			DELETE FROM banned_ips
+			WHERE origin = $1 AND proxy_host_id IS NULL AND ip_address = ANY($2)
+		`, model.BanOriginCrowdSec, pq.Array(deleted))
+		if err != nil {
+			return fmt.Errorf("failed to delete crowdsec decisions: %w", err)
+		}
+	}
+	if err := upsertCrowdSecBans(ctx, tx, bans); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func upsertCrowdSecBans(ctx context.Context, tx *sql.Tx, bans []model.CrowdSecBan) error {
+	if len(bans) == 0 {
+		return nil
+	}
+
+	values := make([]string, len(bans))
+	reasons := make([]string, len(bans))
+	seconds := make([]float64, len(bans))
+	for i, b := range bans {
+		values[i] = b.Value
+		reasons[i] = b.Reason
+		seconds[i] = b.Duration.Seconds()
+	}
+
+	// CrowdSec bans are global and share the partial unique index idx_banned_ips_ip_global_unique
+	query := `
+		INSERT INTO banned_ips (ip_address, proxy_host_id, reason, fail_count, banned_at, expires_at, is_permanent, is_auto_banned, origin, created_at)
+		SELECT d.ip, NULL, d.reason, 0, NOW(), NOW() + make_interval(secs => d.secs), false, true, $4, NOW()
+		FROM unnest($1::text[], $2::text[], $3::float8[]) AS d(ip, reason, secs)
+		ON CONFLICT (ip_address) WHERE proxy_host_id IS NULL DO UPDATE SET
+			reason = EXCLUDED.reason,
+			banned_at = EXCLUDED.banned_at,
+			expires_at = EXCLUDED.expires_at
+		WHERE banned_ips.origin = $4
+	`
+	_, err := tx.ExecContext(ctx, query, pq.Array(values), pq.Array(reasons), pq.Array(seconds), model.BanOriginCrowdSec)
+	if err != nil {
+		return fmt.Errorf("failed to insert crowdsec decisions: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpired removes CrowdSec bans whose TTL has passed
+func (r *CrowdSecRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `
+		DELETE FROM banned_ips
+		WHERE origin = $1 AND expires_at IS NOT NULL AND expires_at < NOW()
+	`, model.BanOriginCrowdSec)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired crowdsec decisions: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// ListActiveValues returns the addresses of all active CrowdSec bans
+func (r *CrowdSecRepository) ListActiveValues(ctx context.Context) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT ip_address FROM banned_ips
+		WHERE origin = $1 AND (expires_at IS NULL OR expires_at > NOW())
+		ORDER BY ip_address
+	`, model.BanOriginCrowdSec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list crowdsec decisions: %w", err)
+	}
+	defer rows.Close()
+
+	var values []string
+	for rows.Next() {
+		var v string
+		if err := rows.Scan(&v); err != nil {
+			return nil, fmt.Errorf("failed to scan crowdsec decision: %w", err)
+		}
+		values = append(values, v)
+	}
+	return values, rows.Err()
+}
+
+// Clear removes all CrowdSec bans (used when the integration is disabled)
+func (r *CrowdSecRepository) Clear(ctx context.Context) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM banned_ips WHERE origin = $1`, model.BanOriginCrowdSec); err != nil {
+		return fmt.Errorf("failed to clear crowdsec decisions: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"nginx-proxy-guard/internal/database"
+	"nginx-proxy-guard/internal/model"
+)
+
+type ForwardAuthRepository struct {
+	db *database.DB
+}
+
+func NewForwardAuthRepository(db *database.DB) *ForwardAuthRepository {
+	return &ForwardAuthRepository{db: db}
+}
+
+func (r *ForwardAuthRepository) GetByProxyHostID(ctx context.Context, proxyHostID string) (*model.ForwardAuthConfig, error) {
+	var fa model.ForwardAuthConfig
+	var responseHeaders, bypassPaths pq.StringArray
+
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id, proxy_host_id, enabled, auth_url, signin_url, response_headers, bypass_paths,
+		       protect_host, created_at, updated_at
+		FROM forward_auth_configs WHERE proxy_host_id = $1
+	`, proxyHostID).Scan(
+		&fa.ID, &fa.ProxyHostID, &fa.Enabled, &fa.AuthURL, &fa.SigninURL, &responseHeaders, &bypassPaths,
+		&fa.ProtectHost, &fa.CreatedAt, &fa.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get forward auth config: %w", err)
+	}
+
+	fa.ResponseHeaders = []string(responseHeaders)
+	if fa.ResponseHeaders == nil {
+		fa.ResponseHeaders = []string{}
+	}
+	fa.BypassPaths = []string(bypassPaths)
+	if fa.BypassPaths == nil {
+		fa.BypassPaths = []string{}
+	}
+	return &fa, nil
+}
+
+func (r *ForwardAuthRepository) Upsert(ctx context.Context, proxyHostID string, req *model.UpsertForwardAuthRequest) (*model.ForwardAuthConfig, error) {
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+	protectHost := true
+	if req.ProtectHost != nil {
+		protectHost = *req.ProtectHost
+	}
+	responseHeaders := req.ResponseHeaders
+	if responseHeaders == nil {
+		responseHeaders = []string{}
+	}
+	bypassPaths := req.BypassPaths
+	if bypassPaths == nil {
+		bypassPaths = []string{}
+	}
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO forward_auth_configs (proxy_host_id, enabled, auth_url, signin_url, response_headers, bypass_paths, protect_host)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (proxy_host_id) DO UPDATE SET
+			enabled = EXCLUDED.enabled,
+			auth_url = EXCLUDED.auth_url,
+			signin_url = EXCLUDED.signin_url,
+			response_headers = EXCLUDED.response_headers,
+			bypass_paths = EXCLUDED.bypass_paths,
+			protect_host = EXCLUDED.protect_host,
+			updated_at = NOW()
+	`, proxyHostID, enabled, req.AuthURL, req.SigninURL, pq.Array(responseHeaders), pq.Array(bypassPaths), protectHost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upsert forward auth config: %w", err)
+	}
+
+	return r.GetByProxyHostID(ctx, proxyHostID)
+}
+
+func (r *ForwardAuthRepository) Delete(ctx context.Context, proxyHostID string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM forward_auth_configs WHERE proxy_host_id = $1`, proxyHostID)
+	if err != nil {
+		return fmt.Errorf("failed to delete forward auth config: %w", err)
+	}
+	return nil
+}
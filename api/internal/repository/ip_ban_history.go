@@ -36,9 +36,9 @@ func (r *IPBanHistoryRepository) RecordBanEvent(ctx context.Context, event *mode
 		INSERT INTO ip_ban_history (
 			event_type, ip_address, proxy_host_id, domain_name, reason, source,
 			ban_duration, expires_at, is_permanent, is_auto, fail_count,
-			user_id, user_email, metadata, created_at
+			user_id, user_email, metadata, escalation_level, escalation_reason, created_at
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17
 		) RETURNING id
 	`
 
@@ -58,6 +58,8 @@ func (r *IPBanHistoryRepository) RecordBanEvent(ctx context.Context, event *mode
 		event.UserID,
 		event.UserEmail,
 		metadataJSON,
+		event.EscalationLevel,
+		sql.NullString{String: event.EscalationReason, Valid: event.EscalationReason != ""},
 		now,
 	).Scan(&event.ID)
 
@@ -142,7 +144,7 @@ func (r *IPBanHistoryRepository) List(ctx context.Context, filter *model.IPBanHi
 	query := fmt.Sprintf(`
 		SELECT id, event_type, ip_address, proxy_host_id, domain_name, reason, source,
 			   ban_duration, expires_at, is_permanent, is_auto, fail_count,
-			   user_id, user_email, metadata, created_at
+			   user_id, user_email, metadata, escalation_level, escalation_reason, created_at
 		FROM ip_ban_history
 		%s
 		ORDER BY created_at DESC
@@ -161,8 +163,8 @@ func (r *IPBanHistoryRepository) List(ctx context.Context, filter *model.IPBanHi
 	for rows.Next() {
 		var h model.IPBanHistory
 		var proxyHostID, userID sql.NullString
-		var domainName, reason, userEmail sql.NullString
-		var banDuration, failCount sql.NullInt32
+		var domainName, reason, userEmail, escalationReason sql.NullString
+		var banDuration, failCount, escalationLevel sql.NullInt32
 		var expiresAt sql.NullTime
 		var metadataJSON []byte
 
@@ -182,6 +184,8 @@ func (r *IPBanHistoryRepository) List(ctx context.Context, filter *model.IPBanHi
 			&userID,
 			&userEmail,
 			&metadataJSON,
+			&escalationLevel,
+			&escalationReason,
 			&h.CreatedAt,
 		)
 		if err != nil {
@@ -197,6 +201,7 @@ func (r *IPBanHistoryRepository) List(ctx context.Context, filter *model.IPBanHi
 		h.DomainName = domainName.String
 		h.Reason = reason.String
 		h.UserEmail = userEmail.String
+		h.EscalationReason = escalationReason.String
 
 		if banDuration.Valid {
 			dur := int(banDuration.Int32)
@@ -209,6 +214,10 @@ func (r *IPBanHistoryRepository) List(ctx context.Context, filter *model.IPBanHi
 		if expiresAt.Valid {
 			h.ExpiresAt = &expiresAt.Time
 		}
+		if escalationLevel.Valid {
+			level := int(escalationLevel.Int32)
+			h.EscalationLevel = &level
+		}
 
 		if len(metadataJSON) > 0 {
 			var metadata map[string]interface{}
@@ -238,6 +247,25 @@ func (r *IPBanHistoryRepository) GetByIP(ctx context.Context, ipAddress string,
 	})
 }
 
+// CountBansSince returns the number of ban events for an IP since the given time
+// and the time of the most recent one
+func (r *IPBanHistoryRepository) CountBansSince(ctx context.Context, ipAddress string, since time.Time) (int, *time.Time, error) {
+	var count int
+	var lastBanAt sql.NullTime
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COUNT(*), MAX(created_at)
+		FROM ip_ban_history
+		WHERE ip_address = $1 AND event_type = 'ban' AND created_at >= $2
+	`, ipAddress, since).Scan(&count, &lastBanAt)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to count ban history: %w", err)
+	}
+	if lastBanAt.Valid {
+		return count, &lastBanAt.Time, nil
+	}
+	return count, nil, nil
+}
+
 // GetStats retrieves ban history statistics
 func (r *IPBanHistoryRepository) GetStats(ctx context.Context) (*model.IPBanHistoryStats, error) {
 	stats := &model.IPBanHistoryStats{
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"nginx-proxy-guard/internal/model"
+)
+
+type LDAPRepository struct {
+	db *sql.DB
+}
+
+func NewLDAPRepository(db *sql.DB) *LDAPRepository {
+	return &LDAPRepository{db: db}
+}
+
+const ldapSettingsColumns = `id, enabled, url, start_tls, skip_tls_verify, bind_dn, bind_password, search_base,
+	       user_filter, email_attribute, group_attribute, group_search_base, group_filter, group_mappings,
+	       default_role, auto_provision, timeout, created_at, updated_at`
+
+func scanLDAPSettings(row interface{ Scan(...interface{}) error }, s *model.LDAPSettings) error {
+	var mappings []byte
+	err := row.Scan(
+		&s.ID, &s.Enabled, &s.URL, &s.StartTLS, &s.SkipTLSVerify, &s.BindDN, &s.BindPassword, &s.SearchBase,
+		&s.UserFilter, &s.EmailAttribute, &s.GroupAttribute, &s.GroupSearchBase, &s.GroupFilter, &mappings,
+		&s.DefaultRole, &s.AutoProvision, &s.Timeout, &s.CreatedAt, &s.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	s.GroupMappings = []model.LDAPGroupMapping{}
+	if len(mappings) > 0 {
+		if err := json.Unmarshal(mappings, &s.GroupMappings); err != nil {
+			return fmt.Errorf("invalid group mappings: %w", err)
+		}
+	}
+	s.HasBindPassword = s.BindPassword != ""
+	return nil
+}
+
+// GetSettings returns the LDAP settings, creating the default row if none exists
+func (r *LDAPRepository) GetSettings(ctx context.Context) (*model.LDAPSettings, error) {
+	query := `SELECT ` + ldapSettingsColumns + ` FROM ldap_settings LIMIT 1`
+
+	var settings model.LDAPSettings
+	err := scanLDAPSettings(r.db.QueryRowContext(ctx, query), &settings)
+	if err == sql.ErrNoRows {
+		insert := `INSERT INTO ldap_settings DEFAULT VALUES RETURNING ` + ldapSettingsColumns
+		if err := scanLDAPSettings(r.db.QueryRowContext(ctx, insert), &settings); err != nil {
+			return nil, fmt.Errorf("failed to create default ldap settings: %w", err)
+		}
+		return &settings, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ldap settings: %w", err)
+	}
+
+	return &settings, nil
+}
+
+// SaveSettings stores the LDAP settings; callers merge and validate the update beforehand
+func (r *LDAPRepository) SaveSettings(ctx context.Context, s *model.LDAPSettings) (*model.LDAPSettings, error) {
+	query := `
+		UPDATE ldap_settings SET
+			enabled = $1,
+			url = $2,
+			start_tls = $3,
+			skip_tls_verify = $4,
+			bind_dn = $5,
+			bind_password = $6,
+			search_base = $7,
+			user_filter = $8,
+			email_attribute = $9,
+			group_attribute = $10,
+			group_search_base = $11,
+			group_filter = $12,
+			group_mappings = $13,
+			default_role = $14,
+			auto_provision = $15,
+			timeout = $16,
+			updated_at = NOW()
+		WHERE id = $17
+		RETURNING ` + ldapSettingsColumns
+
+	mappings, err := json.Marshal(s.GroupMappings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode group mappings: %w", err)
+	}
+
+	var updated model.LDAPSettings
+	err = scanLDAPSettings(r.db.QueryRowContext(ctx, query,
+		s.Enabled, s.URL, s.StartTLS, s.SkipTLSVerify, s.BindDN, s.BindPassword, s.SearchBase,
+		s.UserFilter, s.EmailAttribute, s.GroupAttribute, s.GroupSearchBase, s.GroupFilter, mappings,
+		s.DefaultRole, s.AutoProvision, s.Timeout, s.ID,
+	), &updated)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update ldap settings: %w", err)
+	}
+
+	return &updated, nil
+}
+
+// ProvisionUser creates a directory user. The password hash is unusable, so
+// the account can only log in through the directory.
+func (r *LDAPRepository) ProvisionUser(ctx context.Context, u *model.User) error {
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO users (username, email, password_hash, role, is_initial_setup, ldap_dn)
+		VALUES ($1, NULLIF($2, ''), '!', $3, FALSE, $4)
+		RETURNING id
+	`, u.Username, u.Email, u.Role, u.LDAPDN).Scan(&u.ID)
+	if err != nil {
+		return fmt.Errorf("failed to provision ldap user: %w", err)
+	}
+	return nil
+}
+
+// SetDN updates the directory entry of a user that moved in the directory
+func (r *LDAPRepository) SetDN(ctx context.Context, userID, dn string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE users SET ldap_dn = $2, updated_at = NOW() WHERE id = $1`, userID, dn)
+	if err != nil {
+		return fmt.Errorf("failed to update ldap dn: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"nginx-proxy-guard/internal/model"
+)
+
+type ManagementRepository struct {
+	db *sql.DB
+}
+
+func NewManagementRepository(db *sql.DB) *ManagementRepository {
+	return &ManagementRepository{db: db}
+}
+
+const managementSettingsColumns = `id, allowlist_enabled, allowed_networks, listener_enabled, listener_address,
+	       certificate_id, dedicated_only, created_at, updated_at`
+
+func scanManagementSettings(row interface{ Scan(...interface{}) error }, s *model.ManagementSettings) error {
+	var certificateID sql.NullString
+	if err := row.Scan(
+		&s.ID, &s.AllowlistEnabled, pq.Array(&s.AllowedNetworks), &s.ListenerEnabled, &s.ListenerAddress,
+		&certificateID, &s.DedicatedOnly, &s.CreatedAt, &s.UpdatedAt,
+	); err != nil {
+		return err
+	}
+	s.CertificateID = FromNullString(certificateID)
+	if s.AllowedNetworks == nil {
+		s.AllowedNetworks = []string{}
+	}
+	return nil
+}
+
+// GetSettings returns the management settings, creating the default row if none exists
+func (r *ManagementRepository) GetSettings(ctx context.Context) (*model.ManagementSettings, error) {
+	query := `SELECT ` + managementSettingsColumns + ` FROM management_settings LIMIT 1`
+
+	var settings model.ManagementSettings
+	err := scanManagementSettings(r.db.QueryRowContext(ctx, query), &settings)
+	if err == sql.ErrNoRows {
+		insert := `INSERT INTO management_settings DEFAULT VALUES RETURNING ` + managementSettingsColumns
+		if err := scanManagementSettings(r.db.QueryRowContext(ctx, insert), &settings); err != nil {
+			return nil, fmt.Errorf("failed to create default management settings: %w", err)
+		}
+		return &settings, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get management settings: %w", err)
+	}
+
+	return &settings, nil
+}
+
+// SaveSettings stores the management settings; callers merge and validate the update beforehand
+func (r *ManagementRepository) SaveSettings(ctx context.Context, s *model.ManagementSettings) (*model.ManagementSettings, error) {
+	query := `
+		UPDATE management_settings SET
+			allowlist_enabled = $1,
+			allowed_networks = $2,
+			listener_enabled = $3,
+			listener_address = $4,
+			certificate_id = $5,
+			dedicated_only = $6,
+			updated_at = NOW()
+		WHERE id = $7
+		RETURNING ` + managementSettingsColumns
+
+	var updated model.ManagementSettings
+	err := scanManagementSettings(r.db.QueryRowContext(ctx, query,
+		s.AllowlistEnabled, pq.Array(s.AllowedNetworks), s.ListenerEnabled, s.ListenerAddress,
+		ToNullString(s.CertificateID), s.DedicatedOnly, s.ID,
+	), &updated)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update management settings: %w", err)
+	}
+
+	return &updated, nil
+}
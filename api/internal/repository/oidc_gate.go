@@ -0,0 +1,242 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"nginx-proxy-guard/internal/model"
+)
+
+type OIDCGateRepository struct {
+	db *sql.DB
+}
+
+func NewOIDCGateRepository(db *sql.DB) *OIDCGateRepository {
+	return &OIDCGateRepository{db: db}
+}
+
+const oidcProviderColumns = `id, name, issuer_url, client_id, client_secret, scopes, groups_claim, enabled, created_at, updated_at`
+
+func scanOIDCProvider(row interface{ Scan(...interface{}) error }) (*model.OIDCProvider, error) {
+	var p model.OIDCProvider
+	var scopes pq.StringArray
+	if err := row.Scan(&p.ID, &p.Name, &p.IssuerURL, &p.ClientID, &p.ClientSecret, &scopes,
+		&p.GroupsClaim, &p.Enabled, &p.CreatedAt, &p.UpdatedAt); err != nil {
+		return nil, err
+	}
+	p.Scopes = []string(scopes)
+	if p.Scopes == nil {
+		p.Scopes = []string{}
+	}
+	p.HasClientSecret = p.ClientSecret != ""
+	return &p, nil
+}
+
+// ListProviders returns all identity providers
+func (r *OIDCGateRepository) ListProviders(ctx context.Context) ([]model.OIDCProvider, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+oidcProviderColumns+` FROM oidc_providers ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list oidc providers: %w", err)
+	}
+	defer rows.Close()
+
+	var providers []model.OIDCProvider
+	for rows.Next() {
+		p, err := scanOIDCProvider(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan oidc provider: %w", err)
+		}
+		providers = append(providers, *p)
+	}
+	return providers, rows.Err()
+}
+
+// GetProvider returns an identity provider by ID
+func (r *OIDCGateRepository) GetProvider(ctx context.Context, id string) (*model.OIDCProvider, error) {
+	p, err := scanOIDCProvider(r.db.QueryRowContext(ctx,
+		`SELECT `+oidcProviderColumns+` FROM oidc_providers WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get oidc provider: %w", err)
+	}
+	return p, nil
+}
+
+// GetProviderByName returns an identity provider by name
+func (r *OIDCGateRepository) GetProviderByName(ctx context.Context, name string) (*model.OIDCProvider, error) {
+	p, err := scanOIDCProvider(r.db.QueryRowContext(ctx,
+		`SELECT `+oidcProviderColumns+` FROM oidc_providers WHERE name = $1`, name))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get oidc provider: %w", err)
+	}
+	return p, nil
+}
+
+// CreateProvider creates an identity provider
+func (r *OIDCGateRepository) CreateProvider(ctx context.Context, req *model.OIDCProviderRequest) (*model.OIDCProvider, error) {
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+
+	p, err := scanOIDCProvider(r.db.QueryRowContext(ctx, `
+		INSERT INTO oidc_providers (name, issuer_url, client_id, client_secret, scopes, groups_claim, enabled)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING `+oidcProviderColumns,
+		req.Name, req.IssuerURL, req.ClientID, req.ClientSecret, pq.Array(req.Scopes), req.GroupsClaim, enabled))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create oidc provider: %w", err)
+	}
+	return p, nil
+}
+
+// UpdateProvider updates an identity provider, keeping the client secret when none is given
+func (r *OIDCGateRepository) UpdateProvider(ctx context.Context, id string, req *model.OIDCProviderRequest) (*model.OIDCProvider, error) {
+	p, err := scanOIDCProvider(r.db.QueryRowContext(ctx, `
+		UPDATE oidc_providers SET
+			name = $2,
+			issuer_url = $3,
+			client_id = $4,
+			client_secret = COALESCE(NULLIF($5, ''), client_secret),
+			scopes = $6,
+			groups_claim = $7,
+			enabled = COALESCE($8, enabled),
+			updated_at = NOW()
+		WHERE id = $1
+		RETURNING `+oidcProviderColumns,
+		id, req.Name, req.IssuerURL, req.ClientID, req.ClientSecret, pq.Array(req.Scopes), req.GroupsClaim, req.Enabled))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update oidc provider: %w", err)
+	}
+	return p, nil
+}
+
+// DeleteProvider deletes an identity provider
+func (r *OIDCGateRepository) DeleteProvider(ctx context.Context, id string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM oidc_providers WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete oidc provider: %w", err)
+	}
+	return nil
+}
+
+// CountGatesByProvider returns how many proxy host gates use an identity provider
+func (r *OIDCGateRepository) CountGatesByProvider(ctx context.Context, providerID string) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM oidc_host_gates WHERE provider_id = $1`, providerID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count oidc host gates: %w", err)
+	}
+	return count, nil
+}
+
+// IsAdminSSOProvider reports whether the provider is configured for admin single sign-on
+func (r *OIDCGateRepository) IsAdminSSOProvider(ctx context.Context, providerID string) (bool, error) {
+	var used bool
+	err := r.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM admin_sso_settings WHERE provider_id = $1)`, providerID).Scan(&used)
+	if err != nil {
+		return false, fmt.Errorf("failed to check admin sso provider: %w", err)
+	}
+	return used, nil
+}
+
+// GetGateByProxyHostID returns the login gate of a proxy host
+func (r *OIDCGateRepository) GetGateByProxyHostID(ctx context.Context, proxyHostID string) (*model.OIDCHostGate, error) {
+	var g model.OIDCHostGate
+	var domains, groups pq.StringArray
+	var claimsJSON []byte
+
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id, proxy_host_id, provider_id, enabled, allowed_email_domains, allowed_groups,
+		       required_claims, session_lifetime, created_at, updated_at
+		FROM oidc_host_gates WHERE proxy_host_id = $1
+	`, proxyHostID).Scan(
+		&g.ID, &g.ProxyHostID, &g.ProviderID, &g.Enabled, &domains, &groups,
+		&claimsJSON, &g.SessionLifetime, &g.CreatedAt, &g.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get oidc host gate: %w", err)
+	}
+
+	g.AllowedEmailDomains = []string(domains)
+	if g.AllowedEmailDomains == nil {
+		g.AllowedEmailDomains = []string{}
+	}
+	g.AllowedGroups = []string(groups)
+	if g.AllowedGroups == nil {
+		g.AllowedGroups = []string{}
+	}
+	g.RequiredClaims = map[string]string{}
+	if len(claimsJSON) > 0 {
+		if err := json.Unmarshal(claimsJSON, &g.RequiredClaims); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal required claims: %w", err)
+		}
+	}
+	return &g, nil
+}
+
+// UpsertGate creates or replaces the login gate of a proxy host
+func (r *OIDCGateRepository) UpsertGate(ctx context.Context, proxyHostID string, req *model.UpsertOIDCHostGateRequest) (*model.OIDCHostGate, error) {
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+	domains := req.AllowedEmailDomains
+	if domains == nil {
+		domains = []string{}
+	}
+	groups := req.AllowedGroups
+	if groups == nil {
+		groups = []string{}
+	}
+	claims := req.RequiredClaims
+	if claims == nil {
+		claims = map[string]string{}
+	}
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal required claims: %w", err)
+	}
+
+	_, err = r.db.ExecContext(ctx, `
+		INSERT INTO oidc_host_gates (proxy_host_id, provider_id, enabled, allowed_email_domains, allowed_groups, required_claims, session_lifetime)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (proxy_host_id) DO UPDATE SET
+			provider_id = EXCLUDED.provider_id,
+			enabled = EXCLUDED.enabled,
+			allowed_email_domains = EXCLUDED.allowed_email_domains,
+			allowed_groups = EXCLUDED.allowed_groups,
+			required_claims = EXCLUDED.required_claims,
+			session_lifetime = EXCLUDED.session_lifetime,
+			updated_at = NOW()
+	`, proxyHostID, req.ProviderID, enabled, pq.Array(domains), pq.Array(groups), claimsJSON, req.SessionLifetime)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upsert oidc host gate: %w", err)
+	}
+
+	return r.GetGateByProxyHostID(ctx, proxyHostID)
+}
+
+// DeleteGate removes the login gate of a proxy host
+func (r *OIDCGateRepository) DeleteGate(ctx context.Context, proxyHostID string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM oidc_host_gates WHERE proxy_host_id = $1`, proxyHostID)
+	if err != nil {
+		return fmt.Errorf("failed to delete oidc host gate: %w", err)
+	}
+	return nil
+}
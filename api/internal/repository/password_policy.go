@@ -0,0 +1,207 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"nginx-proxy-guard/internal/model"
+)
+
+type PasswordPolicyRepository struct {
+	db *sql.DB
+}
+
+func NewPasswordPolicyRepository(db *sql.DB) *PasswordPolicyRepository {
+	return &PasswordPolicyRepository{db: db}
+}
+
+const passwordPolicyColumns = `id, min_length, require_uppercase, require_lowercase, require_digit, require_symbol,
+	       history_count, max_age_days, breach_check_enabled, lockout_threshold, ip_lockout_threshold,
+	       lockout_minutes, max_lockout_minutes, created_at, updated_at`
+
+func scanPasswordPolicy(row interface{ Scan(...interface{}) error }, p *model.PasswordPolicy) error {
+	return row.Scan(
+		&p.ID, &p.MinLength, &p.RequireUppercase, &p.RequireLowercase, &p.RequireDigit, &p.RequireSymbol,
+		&p.HistoryCount, &p.MaxAgeDays, &p.BreachCheckEnabled, &p.LockoutThreshold, &p.IPLockoutThreshold,
+		&p.LockoutMinutes, &p.MaxLockoutMinutes, &p.CreatedAt, &p.UpdatedAt,
+	)
+}
+
+// GetSettings returns the password policy, creating the default row if none exists
+func (r *PasswordPolicyRepository) GetSettings(ctx context.Context) (*model.PasswordPolicy, error) {
+	query := `SELECT ` + passwordPolicyColumns + ` FROM password_policy LIMIT 1`
+
+	var policy model.PasswordPolicy
+	err := scanPasswordPolicy(r.db.QueryRowContext(ctx, query), &policy)
+	if err == sql.ErrNoRows {
+		insert := `INSERT INTO password_policy DEFAULT VALUES RETURNING ` + passwordPolicyColumns
+		if err := scanPasswordPolicy(r.db.QueryRowContext(ctx, insert), &policy); err != nil {
+			return nil, fmt.Errorf("failed to create default password policy: %w", err)
+		}
+		return &policy, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get password policy: %w", err)
+	}
+
+	return &policy, nil
+}
+
+// SaveSettings stores the password policy; callers merge and validate the update beforehand
+func (r *PasswordPolicyRepository) SaveSettings(ctx context.Context, p *model.PasswordPolicy) (*model.PasswordPolicy, error) {
+	query := `
+		UPDATE password_policy SET
+			min_length = $1,
+			require_uppercase = $2,
+			require_lowercase = $3,
+			require_digit = $4,
+			require_symbol = $5,
+			history_count = $6,
+			max_age_days = $7,
+			breach_check_enabled = $8,
+			lockout_threshold = $9,
+			ip_lockout_threshold = $10,
+			lockout_minutes = $11,
+			max_lockout_minutes = $12,
+			updated_at = NOW()
+		WHERE id = $13
+		RETURNING ` + passwordPolicyColumns
+
+	var updated model.PasswordPolicy
+	err := scanPasswordPolicy(r.db.QueryRowContext(ctx, query,
+		p.MinLength, p.RequireUppercase, p.RequireLowercase, p.RequireDigit, p.RequireSymbol,
+		p.HistoryCount, p.MaxAgeDays, p.BreachCheckEnabled, p.LockoutThreshold, p.IPLockoutThreshold,
+		p.LockoutMinutes, p.MaxLockoutMinutes, p.ID,
+	), &updated)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update password policy: %w", err)
+	}
+
+	return &updated, nil
+}
+
+// Password history
+
+// ListPasswordHistory returns the most recent password hashes of a user, newest first
+func (r *PasswordPolicyRepository) ListPasswordHistory(ctx context.Context, userID string, limit int) ([]string, error) {
+	query := `SELECT password_hash FROM password_history WHERE user_id = $1 ORDER BY created_at DESC LIMIT $2`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list password history: %w", err)
+	}
+	defer rows.Close()
+
+	var hashes []string
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, hash)
+	}
+	return hashes, rows.Err()
+}
+
+// LastPasswordChange returns when the current password of a user was set, nil if unknown
+func (r *PasswordPolicyRepository) LastPasswordChange(ctx context.Context, userID string) (*time.Time, error) {
+	var changed sql.NullTime
+	err := r.db.QueryRowContext(ctx,
+		`SELECT MAX(created_at) FROM password_history WHERE user_id = $1`, userID,
+	).Scan(&changed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last password change: %w", err)
+	}
+	if !changed.Valid {
+		return nil, nil
+	}
+	return &changed.Time, nil
+}
+
+// AddPasswordHistory records a new password hash and keeps only the newest entries
+func (r *PasswordPolicyRepository) AddPasswordHistory(ctx context.Context, userID, passwordHash string, keep int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO password_history (user_id, password_hash) VALUES ($1, $2)`, userID, passwordHash,
+	); err != nil {
+		return fmt.Errorf("failed to add password history: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM password_history
+		WHERE user_id = $1 AND id NOT IN (
+			SELECT id FROM password_history WHERE user_id = $1 ORDER BY created_at DESC LIMIT $2
+		)
+	`, userID, keep)
+	if err != nil {
+		return fmt.Errorf("failed to prune password history: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// Breached password list
+
+// GetBreachList returns the uploaded breached password list, nil if none was uploaded
+func (r *PasswordPolicyRepository) GetBreachList(ctx context.Context) (*model.BreachedPasswordList, error) {
+	query := `
+		SELECT id, name, hash_count, hashes, uploaded_by, created_at
+		FROM breached_password_lists
+		ORDER BY created_at DESC
+		LIMIT 1
+	`
+
+	var list model.BreachedPasswordList
+	err := r.db.QueryRowContext(ctx, query).Scan(
+		&list.ID, &list.Name, &list.HashCount, &list.Hashes, &list.UploadedBy, &list.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get breached password list: %w", err)
+	}
+
+	return &list, nil
+}
+
+// SaveBreachList replaces the uploaded breached password list
+func (r *PasswordPolicyRepository) SaveBreachList(ctx context.Context, list *model.BreachedPasswordList) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM breached_password_lists`); err != nil {
+		return fmt.Errorf("failed to delete breached password list: %w", err)
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO breached_password_lists (name, hash_count, hashes, uploaded_by)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`, list.Name, list.HashCount, list.Hashes, list.UploadedBy).Scan(&list.ID, &list.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save breached password list: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// DeleteBreachList removes the uploaded breached password list
+func (r *PasswordPolicyRepository) DeleteBreachList(ctx context.Context) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM breached_password_lists`)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete breached password list: %w", err)
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"strings"
 
 	"nginx-proxy-guard/internal/database"
 	"nginx-proxy-guard/internal/model"
@@ -46,6 +47,21 @@ func (r *ProxyHostRepository) invalidateAllCache(ctx context.Context) {
 	}
 }
 
+// normalizeTags lowercases and de-duplicates proxy host tags; the column is NOT NULL
+func normalizeTags(tags []string) []string {
+	normalized := []string{}
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
+
 func (r *ProxyHostRepository) Create(ctx context.Context, req *model.CreateProxyHostRequest) (*model.ProxyHost, error) {
 	query := `
 		INSERT INTO proxy_hosts (
@@ -55,8 +71,8 @@ func (r *ProxyHostRepository) Create(ctx context.Context, req *model.CreateProxy
 			block_exploits, block_exploits_exceptions,
 			waf_enabled, waf_mode, waf_paranoia_level, waf_anomaly_threshold,
 			advanced_config, proxy_connect_timeout, proxy_send_timeout, proxy_read_timeout,
-			proxy_buffering, proxy_request_buffering, client_max_body_size, proxy_max_temp_file_size, access_list_id, enabled
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29)
+			proxy_buffering, proxy_request_buffering, client_max_body_size, proxy_max_temp_file_size, proxy_protocol, access_list_id, enabled, tags
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30, $31)
 		RETURNING id, domain_names, forward_scheme, forward_host, forward_port,
 			ssl_enabled, ssl_force_https, ssl_http2, ssl_http3, certificate_id,
 			allow_websocket_upgrade, cache_enabled, cache_static_only, cache_ttl,
@@ -65,8 +81,8 @@ func (r *ProxyHostRepository) Create(ctx context.Context, req *model.CreateProxy
 			waf_paranoia_level, waf_anomaly_threshold,
 			proxy_connect_timeout, proxy_send_timeout, proxy_read_timeout,
 			proxy_buffering, COALESCE(proxy_request_buffering, '') as proxy_request_buffering,
-			client_max_body_size, COALESCE(proxy_max_temp_file_size, '') as proxy_max_temp_file_size,
-			access_list_id, enabled, meta, created_at, updated_at
+			client_max_body_size, COALESCE(proxy_max_temp_file_size, '') as proxy_max_temp_file_size, proxy_protocol,
+			access_list_id, enabled, meta, tags, created_at, updated_at
 	`
 
 	var host model.ProxyHost
@@ -141,8 +157,10 @@ func (r *ProxyHostRepository) Create(ctx context.Context, req *model.CreateProxy
 		req.ProxyRequestBuffering,
 		req.ClientMaxBodySize,
 		req.ProxyMaxTempFileSize,
+		req.ProxyProtocol,
 		accessListIDParam,
 		req.Enabled,
+		pq.Array(normalizeTags(req.Tags)),
 	).Scan(
 		&host.ID,
 		&host.DomainNames,
@@ -173,9 +191,11 @@ func (r *ProxyHostRepository) Create(ctx context.Context, req *model.CreateProxy
 		&host.ProxyRequestBuffering,
 		&host.ClientMaxBodySize,
 		&host.ProxyMaxTempFileSize,
+		&host.ProxyProtocol,
 		&accessListID,
 		&host.Enabled,
 		&meta,
+		&host.Tags,
 		&host.CreatedAt,
 		&host.UpdatedAt,
 	)
@@ -227,8 +247,8 @@ func (r *ProxyHostRepository) GetByID(ctx context.Context, id string) (*model.Pr
 			COALESCE(proxy_buffering, '') as proxy_buffering,
 			COALESCE(proxy_request_buffering, '') as proxy_request_buffering,
 			COALESCE(client_max_body_size, '') as client_max_body_size,
-			COALESCE(proxy_max_temp_file_size, '') as proxy_max_temp_file_size,
-			access_list_id, enabled, meta, created_at, updated_at
+			COALESCE(proxy_max_temp_file_size, '') as proxy_max_temp_file_size, proxy_protocol,
+			access_list_id, enabled, meta, tags, created_at, updated_at
 		FROM proxy_hosts WHERE id = $1
 	`
 
@@ -266,9 +286,11 @@ func (r *ProxyHostRepository) GetByID(ctx context.Context, id string) (*model.Pr
 		&host.ProxyRequestBuffering,
 		&host.ClientMaxBodySize,
 		&host.ProxyMaxTempFileSize,
+		&host.ProxyProtocol,
 		&accessListID,
 		&host.Enabled,
 		&meta,
+		&host.Tags,
 		&host.CreatedAt,
 		&host.UpdatedAt,
 	)
@@ -300,6 +322,11 @@ func (r *ProxyHostRepository) GetByID(ctx context.Context, id string) (*model.Pr
 }
 
 func (r *ProxyHostRepository) List(ctx context.Context, page, perPage int, search, sortBy, sortOrder string) ([]model.ProxyHost, int, error) {
+	return r.ListInScope(ctx, model.APITokenScope{}, page, perPage, search, sortBy, sortOrder)
+}
+
+// ListInScope lists the proxy hosts inside an API token scope; an empty scope lists all hosts
+func (r *ProxyHostRepository) ListInScope(ctx context.Context, scope model.APITokenScope, page, perPage int, search, sortBy, sortOrder string) ([]model.ProxyHost, int, error) {
 	if page < 1 {
 		page = 1
 	}
@@ -313,12 +340,22 @@ func (r *ProxyHostRepository) List(ctx context.Context, page, perPage int, searc
 	var args []interface{}
 	argIndex := 1
 
+	var conditions []string
 	if search != "" {
 		// Search in domain_names array and forward_host
-		whereClause = fmt.Sprintf(" WHERE (array_to_string(domain_names, ',') ILIKE $%d OR forward_host ILIKE $%d)", argIndex, argIndex)
+		conditions = append(conditions, fmt.Sprintf("(array_to_string(domain_names, ',') ILIKE $%d OR forward_host ILIKE $%d)", argIndex, argIndex))
 		args = append(args, "%"+search+"%")
 		argIndex++
 	}
+	if !scope.IsEmpty() {
+		cond, scopeArgs := hostScopeCondition(scope, "", argIndex)
+		conditions = append(conditions, cond)
+		args = append(args, scopeArgs...)
+		argIndex += len(scopeArgs)
+	}
+	if len(conditions) > 0 {
+		whereClause = " WHERE " + strings.Join(conditions, " AND ")
+	}
 
 	// Get total count
 	var total int
@@ -359,8 +396,8 @@ func (r *ProxyHostRepository) List(ctx context.Context, page, perPage int, searc
 			COALESCE(proxy_buffering, '') as proxy_buffering,
 			COALESCE(proxy_request_buffering, '') as proxy_request_buffering,
 			COALESCE(client_max_body_size, '') as client_max_body_size,
-			COALESCE(proxy_max_temp_file_size, '') as proxy_max_temp_file_size,
-			access_list_id, enabled, meta, created_at, updated_at
+			COALESCE(proxy_max_temp_file_size, '') as proxy_max_temp_file_size, proxy_protocol,
+			access_list_id, enabled, meta, tags, created_at, updated_at
 		FROM proxy_hosts
 		%s
 		ORDER BY %s
@@ -410,9 +447,11 @@ func (r *ProxyHostRepository) List(ctx context.Context, page, perPage int, searc
 			&host.ProxyRequestBuffering,
 			&host.ClientMaxBodySize,
 			&host.ProxyMaxTempFileSize,
+			&host.ProxyProtocol,
 			&accessListID,
 			&host.Enabled,
 			&meta,
+			&host.Tags,
 			&host.CreatedAt,
 			&host.UpdatedAt,
 		)
@@ -531,12 +570,18 @@ func (r *ProxyHostRepository) Update(ctx context.Context, id string, req *model.
 	if req.ProxyMaxTempFileSize != nil {
 		existing.ProxyMaxTempFileSize = *req.ProxyMaxTempFileSize
 	}
+	if req.ProxyProtocol != nil {
+		existing.ProxyProtocol = *req.ProxyProtocol
+	}
 	if req.Enabled != nil {
 		existing.Enabled = *req.Enabled
 	}
 	if req.AccessListID != nil {
 		existing.AccessListID = req.AccessListID
 	}
+	if req.Tags != nil {
+		existing.Tags = normalizeTags(*req.Tags)
+	}
 
 	query := `
 		UPDATE proxy_hosts SET
@@ -567,9 +612,11 @@ func (r *ProxyHostRepository) Update(ctx context.Context, id string, req *model.
 			proxy_request_buffering = $25,
 			client_max_body_size = $26,
 			proxy_max_temp_file_size = $27,
-			enabled = $28,
-			access_list_id = $29
-		WHERE id = $30
+			proxy_protocol = $28,
+			enabled = $29,
+			access_list_id = $30,
+			tags = $32
+		WHERE id = $31
 		RETURNING updated_at
 	`
 
@@ -613,9 +660,11 @@ func (r *ProxyHostRepository) Update(ctx context.Context, id string, req *model.
 		existing.ProxyRequestBuffering,
 		existing.ClientMaxBodySize,
 		existing.ProxyMaxTempFileSize,
+		existing.ProxyProtocol,
 		existing.Enabled,
 		accessListIDParam,
 		id,
+		pq.Array(normalizeTags(existing.Tags)),
 	).Scan(&existing.UpdatedAt)
 
 	if err != nil {
@@ -703,8 +752,8 @@ func (r *ProxyHostRepository) GetByDomain(ctx context.Context, domain string) (*
 			COALESCE(proxy_buffering, '') as proxy_buffering,
 			COALESCE(proxy_request_buffering, '') as proxy_request_buffering,
 			COALESCE(client_max_body_size, '') as client_max_body_size,
-			COALESCE(proxy_max_temp_file_size, '') as proxy_max_temp_file_size,
-			access_list_id, enabled, meta, created_at, updated_at
+			COALESCE(proxy_max_temp_file_size, '') as proxy_max_temp_file_size, proxy_protocol,
+			access_list_id, enabled, meta, tags, created_at, updated_at
 		FROM proxy_hosts WHERE $1 = ANY(domain_names)
 		LIMIT 1
 	`
@@ -743,9 +792,11 @@ func (r *ProxyHostRepository) GetByDomain(ctx context.Context, domain string) (*
 		&host.ProxyRequestBuffering,
 		&host.ClientMaxBodySize,
 		&host.ProxyMaxTempFileSize,
+		&host.ProxyProtocol,
 		&accessListID,
 		&host.Enabled,
 		&meta,
+		&host.Tags,
 		&host.CreatedAt,
 		&host.UpdatedAt,
 	)
@@ -786,8 +837,8 @@ func (r *ProxyHostRepository) GetAllEnabled(ctx context.Context) ([]model.ProxyH
 			COALESCE(proxy_buffering, '') as proxy_buffering,
 			COALESCE(proxy_request_buffering, '') as proxy_request_buffering,
 			COALESCE(client_max_body_size, '') as client_max_body_size,
-			COALESCE(proxy_max_temp_file_size, '') as proxy_max_temp_file_size,
-			access_list_id, enabled, meta, created_at, updated_at
+			COALESCE(proxy_max_temp_file_size, '') as proxy_max_temp_file_size, proxy_protocol,
+			access_list_id, enabled, meta, tags, created_at, updated_at
 		FROM proxy_hosts
 		WHERE enabled = true
 		ORDER BY created_at ASC
@@ -835,9 +886,11 @@ func (r *ProxyHostRepository) GetAllEnabled(ctx context.Context) ([]model.ProxyH
 			&host.ProxyRequestBuffering,
 			&host.ClientMaxBodySize,
 			&host.ProxyMaxTempFileSize,
+			&host.ProxyProtocol,
 			&accessListID,
 			&host.Enabled,
 			&meta,
+			&host.Tags,
 			&host.CreatedAt,
 			&host.UpdatedAt,
 		)
@@ -878,8 +931,8 @@ func (r *ProxyHostRepository) GetByCertificateID(ctx context.Context, certificat
 			COALESCE(proxy_buffering, '') as proxy_buffering,
 			COALESCE(proxy_request_buffering, '') as proxy_request_buffering,
 			COALESCE(client_max_body_size, '') as client_max_body_size,
-			COALESCE(proxy_max_temp_file_size, '') as proxy_max_temp_file_size,
-			access_list_id, enabled, meta, created_at, updated_at
+			COALESCE(proxy_max_temp_file_size, '') as proxy_max_temp_file_size, proxy_protocol,
+			access_list_id, enabled, meta, tags, created_at, updated_at
 		FROM proxy_hosts
 		WHERE certificate_id = $1
 		ORDER BY created_at ASC
@@ -927,9 +980,11 @@ func (r *ProxyHostRepository) GetByCertificateID(ctx context.Context, certificat
 			&host.ProxyRequestBuffering,
 			&host.ClientMaxBodySize,
 			&host.ProxyMaxTempFileSize,
+			&host.ProxyProtocol,
 			&accessListID,
 			&host.Enabled,
 			&meta,
+			&host.Tags,
 			&host.CreatedAt,
 			&host.UpdatedAt,
 		)
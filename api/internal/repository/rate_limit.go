@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"time"
 
 	"github.com/lib/pq"
@@ -159,7 +160,7 @@ func (r *RateLimitRepository) ListBannedIPs(ctx context.Context, proxyHostID *st
 	if proxyHostID != nil {
 		countQuery = "SELECT COUNT(*) FROM banned_ips WHERE proxy_host_id = $1 AND " + activeCondition
 		listQuery = `
-			SELECT id, proxy_host_id, ip_address, reason, fail_count, banned_at, expires_at, is_permanent, COALESCE(is_auto_banned, false), created_at
+			SELECT id, proxy_host_id, ip_address, reason, fail_count, banned_at, expires_at, is_permanent, COALESCE(is_auto_banned, false), origin, created_at
 			FROM banned_ips
 			WHERE proxy_host_id = $1 AND ` + activeCondition + `
 			ORDER BY banned_at DESC
@@ -169,7 +170,7 @@ func (r *RateLimitRepository) ListBannedIPs(ctx context.Context, proxyHostID *st
 	} else {
 		countQuery = "SELECT COUNT(*) FROM banned_ips WHERE " + activeCondition
 		listQuery = `
-			SELECT id, proxy_host_id, ip_address, reason, fail_count, banned_at, expires_at, is_permanent, COALESCE(is_auto_banned, false), created_at
+			SELECT id, proxy_host_id, ip_address, reason, fail_count, banned_at, expires_at, is_permanent, COALESCE(is_auto_banned, false), origin, created_at
 			FROM banned_ips
 			WHERE ` + activeCondition + `
 			ORDER BY banned_at DESC
@@ -205,7 +206,7 @@ func (r *RateLimitRepository) ListBannedIPs(ctx context.Context, proxyHostID *st
 		var expiresAt sql.NullTime
 
 		err := rows.Scan(&b.ID, &proxyHostID, &b.IPAddress, &reason, &b.FailCount,
-			&b.BannedAt, &expiresAt, &b.IsPermanent, &b.IsAutoBanned, &b.CreatedAt)
+			&b.BannedAt, &expiresAt, &b.IsPermanent, &b.IsAutoBanned, &b.Origin, &b.CreatedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -233,11 +234,21 @@ func (r *RateLimitRepository) ListBannedIPs(ctx context.Context, proxyHostID *st
 
 // ListGlobalBannedIPs returns only banned IPs where proxy_host_id IS NULL (global bans)
 func (r *RateLimitRepository) ListGlobalBannedIPs(ctx context.Context, page, perPage int) (*model.BannedIPListResponse, error) {
-	activeCondition := "(is_permanent = TRUE OR expires_at > NOW())"
+	return r.listGlobalBannedIPs(ctx, "", page, perPage)
+}
+
+// ListLocalGlobalBannedIPs returns global bans decided by this instance, excluding CrowdSec decisions
+// CrowdSec decisions are rendered once into the shared banned IPs include instead of every host config
+func (r *RateLimitRepository) ListLocalGlobalBannedIPs(ctx context.Context, page, perPage int) (*model.BannedIPListResponse, error) {
+	return r.listGlobalBannedIPs(ctx, " AND origin <> '"+model.BanOriginCrowdSec+"'", page, perPage)
+}
+
+func (r *RateLimitRepository) listGlobalBannedIPs(ctx context.Context, extraCondition string, page, perPage int) (*model.BannedIPListResponse, error) {
+	activeCondition := "(is_permanent = TRUE OR expires_at > NOW())" + extraCondition
 
 	countQuery := "SELECT COUNT(*) FROM banned_ips WHERE proxy_host_id IS NULL AND " + activeCondition
 	listQuery := `
-		SELECT id, proxy_host_id, ip_address, reason, fail_count, banned_at, expires_at, is_permanent, COALESCE(is_auto_banned, false), created_at
+		SELECT id, proxy_host_id, ip_address, reason, fail_count, banned_at, expires_at, is_permanent, COALESCE(is_auto_banned, false), origin, created_at
 		FROM banned_ips
 		WHERE proxy_host_id IS NULL AND ` + activeCondition + `
 		ORDER BY banned_at DESC
@@ -264,7 +275,7 @@ func (r *RateLimitRepository) ListGlobalBannedIPs(ctx context.Context, page, per
 		var expiresAt sql.NullTime
 
 		err := rows.Scan(&b.ID, &proxyHostID, &b.IPAddress, &reason, &b.FailCount,
-			&b.BannedAt, &expiresAt, &b.IsPermanent, &b.IsAutoBanned, &b.CreatedAt)
+			&b.BannedAt, &expiresAt, &b.IsPermanent, &b.IsAutoBanned, &b.Origin, &b.CreatedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -296,7 +307,7 @@ func (r *RateLimitRepository) ListHostBannedIPs(ctx context.Context, page, perPa
 
 	countQuery := "SELECT COUNT(*) FROM banned_ips WHERE proxy_host_id IS NOT NULL AND " + activeCondition
 	listQuery := `
-		SELECT id, proxy_host_id, ip_address, reason, fail_count, banned_at, expires_at, is_permanent, COALESCE(is_auto_banned, false), created_at
+		SELECT id, proxy_host_id, ip_address, reason, fail_count, banned_at, expires_at, is_permanent, COALESCE(is_auto_banned, false), origin, created_at
 		FROM banned_ips
 		WHERE proxy_host_id IS NOT NULL AND ` + activeCondition + `
 		ORDER BY banned_at DESC
@@ -323,7 +334,7 @@ func (r *RateLimitRepository) ListHostBannedIPs(ctx context.Context, page, perPa
 		var expiresAt sql.NullTime
 
 		err := rows.Scan(&b.ID, &proxyHostID, &b.IPAddress, &reason, &b.FailCount,
-			&b.BannedAt, &expiresAt, &b.IsPermanent, &b.IsAutoBanned, &b.CreatedAt)
+			&b.BannedAt, &expiresAt, &b.IsPermanent, &b.IsAutoBanned, &b.Origin, &b.CreatedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -368,7 +379,7 @@ func (r *RateLimitRepository) BanIP(ctx context.Context, proxyHostID *string, ip
 	query := `
 		INSERT INTO banned_ips (proxy_host_id, ip_address, reason, fail_count, banned_at, expires_at, is_permanent)
 		VALUES ($1, $2, $3, 1, NOW(), $4, $5)
-		RETURNING id, proxy_host_id, ip_address, reason, fail_count, banned_at, expires_at, is_permanent, created_at
+		RETURNING id, proxy_host_id, ip_address, reason, fail_count, banned_at, expires_at, is_permanent, origin, created_at
 	`
 
 	var b model.BannedIP
@@ -377,7 +388,7 @@ func (r *RateLimitRepository) BanIP(ctx context.Context, proxyHostID *string, ip
 	var expiresAtOut sql.NullTime
 
 	err := r.db.QueryRowContext(ctx, query, proxyHostID, ip, reason, expiresAt, isPermanent).Scan(
-		&b.ID, &phID, &b.IPAddress, &reasonOut, &b.FailCount, &b.BannedAt, &expiresAtOut, &b.IsPermanent, &b.CreatedAt,
+		&b.ID, &phID, &b.IPAddress, &reasonOut, &b.FailCount, &b.BannedAt, &expiresAtOut, &b.IsPermanent, &b.Origin, &b.CreatedAt,
 	)
 	if err != nil {
 		return nil, err
@@ -396,7 +407,7 @@ func (r *RateLimitRepository) BanIP(ctx context.Context, proxyHostID *string, ip
 
 func (r *RateLimitRepository) GetBannedIPByID(ctx context.Context, id string) (*model.BannedIP, error) {
 	query := `
-		SELECT id, proxy_host_id, ip_address, reason, fail_count, banned_at, expires_at, is_permanent, created_at
+		SELECT id, proxy_host_id, ip_address, reason, fail_count, banned_at, expires_at, is_permanent, origin, created_at
 		FROM banned_ips WHERE id = $1
 	`
 
@@ -406,7 +417,7 @@ func (r *RateLimitRepository) GetBannedIPByID(ctx context.Context, id string) (*
 	var expiresAt sql.NullTime
 
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&b.ID, &phID, &b.IPAddress, &reason, &b.FailCount, &b.BannedAt, &expiresAt, &b.IsPermanent, &b.CreatedAt,
+		&b.ID, &phID, &b.IPAddress, &reason, &b.FailCount, &b.BannedAt, &expiresAt, &b.IsPermanent, &b.Origin, &b.CreatedAt,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -502,3 +513,135 @@ func (r *RateLimitRepository) GetActiveBannedIPSet(ctx context.Context, ips []st
 
 	return result, rows.Err()
 }
+
+// ListRecentAutoBans returns active single-address local auto-bans banned since the given time.
+// The scope is exact: global bans when proxyHostID is nil, otherwise bans of that host only.
+func (r *RateLimitRepository) ListRecentAutoBans(ctx context.Context, proxyHostID *string, since time.Time) ([]model.BannedIP, error) {
+	scope := "proxy_host_id IS NULL"
+	args := []interface{}{since, model.BanOriginLocal}
+	if proxyHostID != nil {
+		scope = "proxy_host_id = $3"
+		args = append(args, *proxyHostID)
+	}
+
+	query := `
+		SELECT id, ip_address, fail_count, expires_at, is_permanent
+		FROM banned_ips
+		WHERE ` + scope + `
+		AND is_auto_banned = true AND origin = $2
+		AND position('/' in ip_address) = 0
+		AND banned_at >= $1
+		AND (is_permanent = true OR expires_at > NOW())
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list recent auto-bans: %w", err)
+	}
+	defer rows.Close()
+
+	var bans []model.BannedIP
+	for rows.Next() {
+		var b model.BannedIP
+		var expiresAt sql.NullTime
+		if err := rows.Scan(&b.ID, &b.IPAddress, &b.FailCount, &expiresAt, &b.IsPermanent); err != nil {
+			return nil, fmt.Errorf("failed to scan auto-ban: %w", err)
+		}
+		if expiresAt.Valid {
+			b.ExpiresAt = &expiresAt.Time
+		}
+		b.ProxyHostID = proxyHostID
+		bans = append(bans, b)
+	}
+	return bans, rows.Err()
+}
+
+// ListActiveSubnetBans returns the addresses of active CIDR bans that apply to a scope.
+// Global CIDR bans apply to every host.
+func (r *RateLimitRepository) ListActiveSubnetBans(ctx context.Context, proxyHostID *string) ([]string, error) {
+	scope := "proxy_host_id IS NULL"
+	var args []interface{}
+	if proxyHostID != nil {
+		scope = "(proxy_host_id IS NULL OR proxy_host_id = $1)"
+		args = append(args, *proxyHostID)
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT ip_address FROM banned_ips
+		WHERE `+scope+`
+		AND position('/' in ip_address) > 0
+		AND (is_permanent = true OR expires_at > NOW())
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subnet bans: %w", err)
+	}
+	defer rows.Close()
+
+	var cidrs []string
+	for rows.Next() {
+		var cidr string
+		if err := rows.Scan(&cidr); err != nil {
+			return nil, fmt.Errorf("failed to scan subnet ban: %w", err)
+		}
+		cidrs = append(cidrs, cidr)
+	}
+	return cidrs, rows.Err()
+}
+
+// ReplaceWithSubnetBan removes the member bans and creates (or extends) a single CIDR ban in the same scope.
+// The members are deleted rather than suspended, so unbanning the CIDR later does not bring them back.
+func (r *RateLimitRepository) ReplaceWithSubnetBan(ctx context.Context, proxyHostID *string, cidr, reason string, failCount int, expiresAt *time.Time, memberIDs []string) (*model.BannedIP, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM banned_ips WHERE id = ANY($1)`, pq.Array(memberIDs)); err != nil {
+		return nil, fmt.Errorf("failed to delete aggregated bans: %w", err)
+	}
+
+	// Same partial unique indexes as the auto-ban services (global vs host-specific)
+	conflict := "(ip_address) WHERE proxy_host_id IS NULL"
+	if proxyHostID != nil {
+		conflict = "(ip_address, proxy_host_id) WHERE proxy_host_id IS NOT NULL"
+	}
+	query := `
+		INSERT INTO banned_ips (proxy_host_id, ip_address, reason, fail_count, banned_at, expires_at, is_permanent, is_auto_banned, origin, created_at)
+		VALUES ($1, $2, $3, $4, NOW(), $5, $6, true, $7, NOW())
+		ON CONFLICT ` + conflict + ` DO UPDATE SET
+			reason = EXCLUDED.reason,
+			fail_count = banned_ips.fail_count + EXCLUDED.fail_count,
+			banned_at = EXCLUDED.banned_at,
+			expires_at = CASE WHEN banned_ips.is_permanent OR EXCLUDED.is_permanent THEN NULL
+				ELSE GREATEST(banned_ips.expires_at, EXCLUDED.expires_at) END,
+			is_permanent = banned_ips.is_permanent OR EXCLUDED.is_permanent,
+			is_auto_banned = true,
+			origin = EXCLUDED.origin
+		RETURNING id, proxy_host_id, ip_address, reason, fail_count, banned_at, expires_at, is_permanent, origin, created_at
+	`
+
+	var b model.BannedIP
+	var phID, reasonOut sql.NullString
+	var expiresAtOut sql.NullTime
+	err = tx.QueryRowContext(ctx, query, proxyHostID, cidr, reason, failCount, expiresAt, expiresAt == nil, model.BanOriginLocal).Scan(
+		&b.ID, &phID, &b.IPAddress, &reasonOut, &b.FailCount, &b.BannedAt, &expiresAtOut, &b.IsPermanent, &b.Origin, &b.CreatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert subnet ban: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit subnet ban: %w", err)
+	}
+
+	if phID.Valid {
+		b.ProxyHostID = &phID.String
+	}
+	b.Reason = reasonOut.String
+	if expiresAtOut.Valid {
+		b.ExpiresAt = &expiresAtOut.Time
+	}
+	b.IsAutoBanned = true
+
+	return &b, nil
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"nginx-proxy-guard/internal/model"
+)
+
+type RealIPRepository struct {
+	db *sql.DB
+}
+
+func NewRealIPRepository(db *sql.DB) *RealIPRepository {
+	return &RealIPRepository{db: db}
+}
+
+const realIPSettingsColumns = `id, enabled, header, recursive, include_private_ranges, cloud_providers, custom_cidrs,
+	       proxy_protocol, proxy_protocol_http_port, proxy_protocol_https_port, created_at, updated_at`
+
+func scanRealIPSettings(row interface{ Scan(...interface{}) error }, s *model.RealIPSettings) error {
+	var providers, cidrs pq.StringArray
+	err := row.Scan(
+		&s.ID, &s.Enabled, &s.Header, &s.Recursive, &s.IncludePrivateRanges, &providers, &cidrs,
+		&s.ProxyProtocol, &s.ProxyProtocolHTTPPort, &s.ProxyProtocolHTTPSPort, &s.CreatedAt, &s.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	s.CloudProviders = []string(providers)
+	s.CustomCIDRs = []string(cidrs)
+	return nil
+}
+
+// GetSettings returns the real IP settings, creating the default row if none exists
+func (r *RealIPRepository) GetSettings(ctx context.Context) (*model.RealIPSettings, error) {
+	query := `SELECT ` + realIPSettingsColumns + ` FROM real_ip_settings LIMIT 1`
+
+	var settings model.RealIPSettings
+	err := scanRealIPSettings(r.db.QueryRowContext(ctx, query), &settings)
+	if err == sql.ErrNoRows {
+		insert := `INSERT INTO real_ip_settings DEFAULT VALUES RETURNING ` + realIPSettingsColumns
+		if err := scanRealIPSettings(r.db.QueryRowContext(ctx, insert), &settings); err != nil {
+			return nil, fmt.Errorf("failed to create default real ip settings: %w", err)
+		}
+		return &settings, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get real ip settings: %w", err)
+	}
+
+	return &settings, nil
+}
+
+// SaveSettings stores the real IP settings; callers merge and validate the update beforehand
+func (r *RealIPRepository) SaveSettings(ctx context.Context, s *model.RealIPSettings) (*model.RealIPSettings, error) {
+	query := `
+		UPDATE real_ip_settings SET
+			enabled = $1,
+			header = $2,
+			recursive = $3,
+			include_private_ranges = $4,
+			cloud_providers = $5,
+			custom_cidrs = $6,
+			proxy_protocol = $7,
+			proxy_protocol_http_port = $8,
+			proxy_protocol_https_port = $9,
+			updated_at = NOW()
+		WHERE id = $10
+		RETURNING ` + realIPSettingsColumns
+
+	var updated model.RealIPSettings
+	err := scanRealIPSettings(r.db.QueryRowContext(ctx, query,
+		s.Enabled, s.Header, s.Recursive, s.IncludePrivateRanges,
+		pq.Array(s.CloudProviders), pq.Array(s.CustomCIDRs),
+		s.ProxyProtocol, s.ProxyProtocolHTTPPort, s.ProxyProtocolHTTPSPort, s.ID,
+	), &updated)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update real ip settings: %w", err)
+	}
+
+	return &updated, nil
+}
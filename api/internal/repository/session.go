@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"nginx-proxy-guard/internal/model"
+)
+
+type SessionRepository struct {
+	db *sql.DB
+}
+
+func NewSessionRepository(db *sql.DB) *SessionRepository {
+	return &SessionRepository{db: db}
+}
+
+const sessionSettingsColumns = `id, idle_timeout_minutes, max_lifetime_hours, created_at, updated_at`
+
+func scanSessionSettings(row interface{ Scan(...interface{}) error }, s *model.SessionSettings) error {
+	return row.Scan(&s.ID, &s.IdleTimeoutMinutes, &s.MaxLifetimeHours, &s.CreatedAt, &s.UpdatedAt)
+}
+
+// GetSettings returns the session policy, creating the default row if none exists
+func (r *SessionRepository) GetSettings(ctx context.Context) (*model.SessionSettings, error) {
+	query := `SELECT ` + sessionSettingsColumns + ` FROM session_settings LIMIT 1`
+
+	var settings model.SessionSettings
+	err := scanSessionSettings(r.db.QueryRowContext(ctx, query), &settings)
+	if err == sql.ErrNoRows {
+		insert := `INSERT INTO session_settings DEFAULT VALUES RETURNING ` + sessionSettingsColumns
+		if err := scanSessionSettings(r.db.QueryRowContext(ctx, insert), &settings); err != nil {
+			return nil, fmt.Errorf("failed to create default session settings: %w", err)
+		}
+		return &settings, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session settings: %w", err)
+	}
+
+	return &settings, nil
+}
+
+// SaveSettings stores the session policy; callers merge and validate the update beforehand
+func (r *SessionRepository) SaveSettings(ctx context.Context, s *model.SessionSettings) (*model.SessionSettings, error) {
+	query := `
+		UPDATE session_settings SET
+			idle_timeout_minutes = $1,
+			max_lifetime_hours = $2,
+			updated_at = NOW()
+		WHERE id = $3
+		RETURNING ` + sessionSettingsColumns
+
+	var updated model.SessionSettings
+	err := scanSessionSettings(r.db.QueryRowContext(ctx, query, s.IdleTimeoutMinutes, s.MaxLifetimeHours, s.ID), &updated)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update session settings: %w", err)
+	}
+
+	return &updated, nil
+}
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"nginx-proxy-guard/internal/model"
+)
+
+type SignedURLRepository struct {
+	db *sql.DB
+}
+
+func NewSignedURLRepository(db *sql.DB) *SignedURLRepository {
+	return &SignedURLRepository{db: db}
+}
+
+const signedURLLocationColumns = `id, proxy_host_id, path_prefix, enabled, secret, hash_expression, token_param,
+	       expires_param, default_ttl, max_ttl, created_at, updated_at`
+
+func scanSignedURLLocation(row interface{ Scan(...interface{}) error }, l *model.SignedURLLocation) error {
+	err := row.Scan(
+		&l.ID, &l.ProxyHostID, &l.PathPrefix, &l.Enabled, &l.Secret, &l.HashExpression, &l.TokenParam,
+		&l.ExpiresParam, &l.DefaultTTL, &l.MaxTTL, &l.CreatedAt, &l.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	l.HasSecret = l.Secret != ""
+	return nil
+}
+
+// ListByProxyHostID returns the signed URL locations of a proxy host
+func (r *SignedURLRepository) ListByProxyHostID(ctx context.Context, proxyHostID string) ([]model.SignedURLLocation, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+signedURLLocationColumns+`
+		FROM signed_url_locations WHERE proxy_host_id = $1
+		ORDER BY path_prefix
+	`, proxyHostID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list signed url locations: %w", err)
+	}
+	defer rows.Close()
+
+	var locations []model.SignedURLLocation
+	for rows.Next() {
+		var l model.SignedURLLocation
+		if err := scanSignedURLLocation(rows, &l); err != nil {
+			return nil, fmt.Errorf("failed to scan signed url location: %w", err)
+		}
+		locations = append(locations, l)
+	}
+
+	return locations, rows.Err()
+}
+
+// GetByID returns a signed URL location of a proxy host
+func (r *SignedURLRepository) GetByID(ctx context.Context, proxyHostID, id string) (*model.SignedURLLocation, error) {
+	var l model.SignedURLLocation
+	err := scanSignedURLLocation(r.db.QueryRowContext(ctx, `
+		SELECT `+signedURLLocationColumns+`
+		FROM signed_url_locations WHERE id = $1 AND proxy_host_id = $2
+	`, id, proxyHostID), &l)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get signed url location: %w", err)
+	}
+
+	return &l, nil
+}
+
+// GetByPath returns the signed URL location of a proxy host path prefix
+func (r *SignedURLRepository) GetByPath(ctx context.Context, proxyHostID, pathPrefix string) (*model.SignedURLLocation, error) {
+	var l model.SignedURLLocation
+	err := scanSignedURLLocation(r.db.QueryRowContext(ctx, `
+		SELECT `+signedURLLocationColumns+`
+		FROM signed_url_locations WHERE proxy_host_id = $1 AND path_prefix = $2
+	`, proxyHostID, pathPrefix), &l)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get signed url location: %w", err)
+	}
+
+	return &l, nil
+}
+
+// Upsert creates or replaces the signed URL location of a proxy host path prefix
+func (r *SignedURLRepository) Upsert(ctx context.Context, proxyHostID string, l *model.SignedURLLocation) (*model.SignedURLLocation, error) {
+	var saved model.SignedURLLocation
+	err := scanSignedURLLocation(r.db.QueryRowContext(ctx, `
+		INSERT INTO signed_url_locations (proxy_host_id, path_prefix, enabled, secret, hash_expression, token_param,
+		                                  expires_param, default_ttl, max_ttl)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (proxy_host_id, path_prefix) DO UPDATE SET
+			enabled = EXCLUDED.enabled,
+			secret = EXCLUDED.secret,
+			hash_expression = EXCLUDED.hash_expression,
+			token_param = EXCLUDED.token_param,
+			expires_param = EXCLUDED.expires_param,
+			default_ttl = EXCLUDED.default_ttl,
+			max_ttl = EXCLUDED.max_ttl,
+			updated_at = NOW()
+		RETURNING `+signedURLLocationColumns,
+		proxyHostID, l.PathPrefix, l.Enabled, l.Secret, l.HashExpression, l.TokenParam,
+		l.ExpiresParam, l.DefaultTTL, l.MaxTTL,
+	), &saved)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upsert signed url location: %w", err)
+	}
+
+	return &saved, nil
+}
+
+// Delete removes a signed URL location of a proxy host
+func (r *SignedURLRepository) Delete(ctx context.Context, proxyHostID, id string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM signed_url_locations WHERE id = $1 AND proxy_host_id = $2`, id, proxyHostID)
+	if err != nil {
+		return fmt.Errorf("failed to delete signed url location: %w", err)
+	}
+	return nil
+}
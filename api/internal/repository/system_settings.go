@@ -77,6 +77,14 @@ func (r *SystemSettingsRepository) getFromDB(ctx context.Context) (*model.System
 		       COALESCE(waf_auto_ban_threshold, 10) as waf_auto_ban_threshold,
 		       COALESCE(waf_auto_ban_window, 300) as waf_auto_ban_window,
 		       COALESCE(waf_auto_ban_duration, 3600) as waf_auto_ban_duration,
+		       COALESCE(ban_escalation_enabled, false) as ban_escalation_enabled,
+		       COALESCE(ban_escalation_steps, ARRAY[3600, 86400, 604800, 0]) as ban_escalation_steps,
+		       COALESCE(ban_escalation_window, 2592000) as ban_escalation_window,
+		       COALESCE(ban_subnet_aggregation_enabled, false) as ban_subnet_aggregation_enabled,
+		       COALESCE(ban_subnet_threshold, 5) as ban_subnet_threshold,
+		       COALESCE(ban_subnet_ipv4_prefix, 24) as ban_subnet_ipv4_prefix,
+		       COALESCE(ban_subnet_ipv6_prefix, 64) as ban_subnet_ipv6_prefix,
+		       COALESCE(ban_subnet_window, 3600) as ban_subnet_window,
 		       COALESCE(global_block_exploits_exceptions, '^/wp-json/
 ^/api/v1/challenge/
 ^/wp-admin/admin-ajax.php
@@ -149,6 +157,14 @@ func (r *SystemSettingsRepository) getFromDB(ctx context.Context) (*model.System
 		&settings.WAFAutoBanThreshold,
 		&settings.WAFAutoBanWindow,
 		&settings.WAFAutoBanDuration,
+		&settings.BanEscalationEnabled,
+		pq.Array(&settings.BanEscalationSteps),
+		&settings.BanEscalationWindow,
+		&settings.BanSubnetAggregationEnabled,
+		&settings.BanSubnetThreshold,
+		&settings.BanSubnetIPv4Prefix,
+		&settings.BanSubnetIPv6Prefix,
+		&settings.BanSubnetWindow,
 		&settings.GlobalBlockExploitsExceptions,
 		&settings.DirectIPAccessAction,
 		&settings.UIFontFamily,
@@ -514,6 +530,50 @@ func (r *SystemSettingsRepository) Update(ctx context.Context, req *model.Update
 		argIndex++
 	}
 
+	// Ban Escalation Settings
+	if req.BanEscalationEnabled != nil {
+		setClauses = append(setClauses, fmt.Sprintf("ban_escalation_enabled = $%d", argIndex))
+		args = append(args, *req.BanEscalationEnabled)
+		argIndex++
+	}
+	if req.BanEscalationSteps != nil {
+		setClauses = append(setClauses, fmt.Sprintf("ban_escalation_steps = $%d", argIndex))
+		args = append(args, pq.Array(*req.BanEscalationSteps))
+		argIndex++
+	}
+	if req.BanEscalationWindow != nil {
+		setClauses = append(setClauses, fmt.Sprintf("ban_escalation_window = $%d", argIndex))
+		args = append(args, *req.BanEscalationWindow)
+		argIndex++
+	}
+
+	// Subnet Aggregation Settings
+	if req.BanSubnetAggregationEnabled != nil {
+		setClauses = append(setClauses, fmt.Sprintf("ban_subnet_aggregation_enabled = $%d", argIndex))
+		args = append(args, *req.BanSubnetAggregationEnabled)
+		argIndex++
+	}
+	if req.BanSubnetThreshold != nil {
+		setClauses = append(setClauses, fmt.Sprintf("ban_subnet_threshold = $%d", argIndex))
+		args = append(args, *req.BanSubnetThreshold)
+		argIndex++
+	}
+	if req.BanSubnetIPv4Prefix != nil {
+		setClauses = append(setClauses, fmt.Sprintf("ban_subnet_ipv4_prefix = $%d", argIndex))
+		args = append(args, *req.BanSubnetIPv4Prefix)
+		argIndex++
+	}
+	if req.BanSubnetIPv6Prefix != nil {
+		setClauses = append(setClauses, fmt.Sprintf("ban_subnet_ipv6_prefix = $%d", argIndex))
+		args = append(args, *req.BanSubnetIPv6Prefix)
+		argIndex++
+	}
+	if req.BanSubnetWindow != nil {
+		setClauses = append(setClauses, fmt.Sprintf("ban_subnet_window = $%d", argIndex))
+		args = append(args, *req.BanSubnetWindow)
+		argIndex++
+	}
+
 	// Global Block Exploits Exceptions
 	if req.GlobalBlockExploitsExceptions != nil {
 		setClauses = append(setClauses, fmt.Sprintf("global_block_exploits_exceptions = $%d", argIndex))
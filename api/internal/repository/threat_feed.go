@@ -0,0 +1,354 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"nginx-proxy-guard/internal/model"
+)
+
+type ThreatFeedRepository struct {
+	db *sql.DB
+}
+
+func NewThreatFeedRepository(db *sql.DB) *ThreatFeedRepository {
+	return &ThreatFeedRepository{db: db}
+}
+
+const threatFeedColumns = `id, name, slug, COALESCE(description, ''), url, format, update_interval,
+	       COALESCE(array_length(ip_ranges, 1), 0), is_builtin, enabled, last_updated,
+	       COALESCE(last_error, ''), created_at, updated_at`
+
+func scanThreatFeed(row interface{ Scan(...interface{}) error }, f *model.ThreatFeed) error {
+	var lastUpdated sql.NullTime
+	err := row.Scan(
+		&f.ID, &f.Name, &f.Slug, &f.Description, &f.URL, &f.Format, &f.UpdateInterval,
+		&f.EntryCount, &f.IsBuiltin, &f.Enabled, &lastUpdated,
+		&f.LastError, &f.CreatedAt, &f.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	if lastUpdated.Valid {
+		f.LastUpdated = &lastUpdated.Time
+	}
+	return nil
+}
+
+// List returns all threat feeds (without IP ranges)
+func (r *ThreatFeedRepository) List(ctx context.Context) ([]model.ThreatFeed, error) {
+	query := `SELECT ` + threatFeedColumns + ` FROM threat_feeds ORDER BY is_builtin DESC, name`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list threat feeds: %w", err)
+	}
+	defer rows.Close()
+
+	var feeds []model.ThreatFeed
+	for rows.Next() {
+		var f model.ThreatFeed
+		if err := scanThreatFeed(rows, &f); err != nil {
+			return nil, fmt.Errorf("failed to scan threat feed: %w", err)
+		}
+		feeds = append(feeds, f)
+	}
+
+	return feeds, nil
+}
+
+// GetBySlug returns a threat feed by slug, including its IP ranges
+func (r *ThreatFeedRepository) GetBySlug(ctx context.Context, slug string) (*model.ThreatFeed, error) {
+	query := `SELECT ` + threatFeedColumns + `, ip_ranges FROM threat_feeds WHERE slug = $1`
+
+	var f model.ThreatFeed
+	var lastUpdated sql.NullTime
+	err := r.db.QueryRowContext(ctx, query, slug).Scan(
+		&f.ID, &f.Name, &f.Slug, &f.Description, &f.URL, &f.Format, &f.UpdateInterval,
+		&f.EntryCount, &f.IsBuiltin, &f.Enabled, &lastUpdated,
+		&f.LastError, &f.CreatedAt, &f.UpdatedAt, pq.Array(&f.IPRanges),
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get threat feed: %w", err)
+	}
+	if lastUpdated.Valid {
+		f.LastUpdated = &lastUpdated.Time
+	}
+
+	return &f, nil
+}
+
+// ExistsBySlug checks if a threat feed with the given slug exists
+func (r *ThreatFeedRepository) ExistsBySlug(ctx context.Context, slug string) (bool, error) {
+	var exists bool
+	err := r.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM threat_feeds WHERE slug = $1)`, slug).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check threat feed existence: %w", err)
+	}
+	return exists, nil
+}
+
+// Create creates a new threat feed
+func (r *ThreatFeedRepository) Create(ctx context.Context, feed *model.ThreatFeed) (*model.ThreatFeed, error) {
+	query := `
+		INSERT INTO threat_feeds (name, slug, description, url, format, update_interval, is_builtin, enabled)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING ` + threatFeedColumns
+
+	var f model.ThreatFeed
+	err := scanThreatFeed(r.db.QueryRowContext(ctx, query,
+		feed.Name, feed.Slug, feed.Description, feed.URL, feed.Format,
+		feed.UpdateInterval, feed.IsBuiltin, feed.Enabled,
+	), &f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create threat feed: %w", err)
+	}
+
+	return &f, nil
+}
+
+// Update updates an existing threat feed
+func (r *ThreatFeedRepository) Update(ctx context.Context, slug string, req *model.UpdateThreatFeedRequest) (*model.ThreatFeed, error) {
+	var sets []string
+	var args []interface{}
+	argNum := 1
+
+	if req.Name != nil {
+		sets = append(sets, fmt.Sprintf("name = $%d", argNum))
+		args = append(args, *req.Name)
+		argNum++
+	}
+	if req.Description != nil {
+		sets = append(sets, fmt.Sprintf("description = $%d", argNum))
+		args = append(args, *req.Description)
+		argNum++
+	}
+	if req.URL != nil {
+		sets = append(sets, fmt.Sprintf("url = $%d", argNum))
+		args = append(args, *req.URL)
+		argNum++
+	}
+	if req.Format != nil {
+		sets = append(sets, fmt.Sprintf("format = $%d", argNum))
+		args = append(args, *req.Format)
+		argNum++
+	}
+	if req.UpdateInterval != nil {
+		sets = append(sets, fmt.Sprintf("update_interval = $%d", argNum))
+		args = append(args, *req.UpdateInterval)
+		argNum++
+	}
+	if req.Enabled != nil {
+		sets = append(sets, fmt.Sprintf("enabled = $%d", argNum))
+		args = append(args, *req.Enabled)
+		argNum++
+	}
+
+	if len(sets) == 0 {
+		return r.GetBySlug(ctx, slug)
+	}
+
+	sets = append(sets, "updated_at = NOW()")
+	args = append(args, slug)
+
+	query := fmt.Sprintf(`UPDATE threat_feeds SET %s WHERE slug = $%d RETURNING `+threatFeedColumns,
+		strings.Join(sets, ", "), argNum)
+
+	var f model.ThreatFeed
+	err := scanThreatFeed(r.db.QueryRowContext(ctx, query, args...), &f)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update threat feed: %w", err)
+	}
+
+	return &f, nil
+}
+
+// Delete deletes a threat feed (host subscriptions are removed by cascade)
+func (r *ThreatFeedRepository) Delete(ctx context.Context, slug string) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM threat_feeds WHERE slug = $1", slug)
+	if err != nil {
+		return fmt.Errorf("failed to delete threat feed: %w", err)
+	}
+	return nil
+}
+
+// UpdateIPRanges stores freshly fetched IP ranges and clears the last error
+func (r *ThreatFeedRepository) UpdateIPRanges(ctx context.Context, slug string, ipRanges []string) error {
+	query := `
+		UPDATE threat_feeds
+		SET ip_ranges = $1, last_updated = NOW(), last_error = NULL, updated_at = NOW()
+		WHERE slug = $2`
+
+	_, err := r.db.ExecContext(ctx, query, pq.Array(ipRanges), slug)
+	if err != nil {
+		return fmt.Errorf("failed to update threat feed IP ranges: %w", err)
+	}
+	return nil
+}
+
+// SetLastError records a fetch failure while keeping the previous IP ranges
+func (r *ThreatFeedRepository) SetLastError(ctx context.Context, slug, message string) error {
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE threat_feeds SET last_error = $1, updated_at = NOW() WHERE slug = $2",
+		message, slug,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to set threat feed error: %w", err)
+	}
+	return nil
+}
+
+// GetHostFeeds returns the threat feed subscriptions of a proxy host
+func (r *ThreatFeedRepository) GetHostFeeds(ctx context.Context, proxyHostID string) ([]model.ProxyHostThreatFeed, error) {
+	query := `
+		SELECT f.slug, f.name, s.action
+		FROM proxy_host_threat_feeds s
+		JOIN threat_feeds f ON f.id = s.feed_id
+		WHERE s.proxy_host_id = $1
+		ORDER BY f.name`
+
+	rows, err := r.db.QueryContext(ctx, query, proxyHostID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get host threat feeds: %w", err)
+	}
+	defer rows.Close()
+
+	feeds := []model.ProxyHostThreatFeed{}
+	for rows.Next() {
+		var f model.ProxyHostThreatFeed
+		if err := rows.Scan(&f.Slug, &f.Name, &f.Action); err != nil {
+			return nil, fmt.Errorf("failed to scan host threat feed: %w", err)
+		}
+		feeds = append(feeds, f)
+	}
+
+	return feeds, nil
+}
+
+// SetHostFeeds replaces the threat feed subscriptions of a proxy host
+func (r *ThreatFeedRepository) SetHostFeeds(ctx context.Context, proxyHostID string, feeds []model.ProxyHostThreatFeed) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM proxy_host_threat_feeds WHERE proxy_host_id = $1", proxyHostID); err != nil {
+		return fmt.Errorf("failed to clear host threat feeds: %w", err)
+	}
+
+	for _, f := range feeds {
+		result, err := tx.ExecContext(ctx, `
+			INSERT INTO proxy_host_threat_feeds (proxy_host_id, feed_id, action)
+			SELECT $1, id, $3 FROM threat_feeds WHERE slug = $2
+			ON CONFLICT (proxy_host_id, feed_id) DO UPDATE SET action = EXCLUDED.action`,
+			proxyHostID, f.Slug, f.Action,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to set host threat feed %s: %w", f.Slug, err)
+		}
+		if n, _ := result.RowsAffected(); n == 0 {
+			return fmt.Errorf("threat feed not found: %s", f.Slug)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetHostFeedRanges returns IP ranges of enabled feeds subscribed by a proxy host, split by action
+func (r *ThreatFeedRepository) GetHostFeedRanges(ctx context.Context, proxyHostID string) (block []string, challenge []string, err error) {
+	query := `
+		SELECT s.action, f.ip_ranges
+		FROM proxy_host_threat_feeds s
+		JOIN threat_feeds f ON f.id = s.feed_id
+		WHERE s.proxy_host_id = $1 AND f.enabled = true`
+
+	rows, err := r.db.QueryContext(ctx, query, proxyHostID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get host threat feed ranges: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var action string
+		var ranges []string
+		if err := rows.Scan(&action, pq.Array(&ranges)); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan threat feed ranges: %w", err)
+		}
+		if action == model.ThreatFeedActionChallenge {
+			challenge = append(challenge, ranges...)
+		} else {
+			block = append(block, ranges...)
+		}
+	}
+
+	return block, challenge, nil
+}
+
+// GetProxyHostIDsForFeeds returns IDs of proxy hosts subscribed to any of the given feeds
+func (r *ThreatFeedRepository) GetProxyHostIDsForFeeds(ctx context.Context, slugs []string) ([]string, error) {
+	query := `
+		SELECT DISTINCT s.proxy_host_id
+		FROM proxy_host_threat_feeds s
+		JOIN threat_feeds f ON f.id = s.feed_id
+		WHERE f.slug = ANY($1)`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(slugs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get proxy hosts for threat feeds: %w", err)
+	}
+	defer rows.Close()
+
+	var hostIDs []string
+	for rows.Next() {
+		var hostID string
+		if err := rows.Scan(&hostID); err != nil {
+			return nil, fmt.Errorf("failed to scan proxy host ID: %w", err)
+		}
+		hostIDs = append(hostIDs, hostID)
+	}
+
+	return hostIDs, nil
+}
+
+// GetMatchStats returns per-host counts of requests blocked or challenged by threat feeds since the given time
+func (r *ThreatFeedRepository) GetMatchStats(ctx context.Context, since time.Time) ([]model.ThreatFeedMatchStats, error) {
+	query := `
+		SELECT COALESCE(proxy_host_id::text, ''), COALESCE(host, ''),
+		       COUNT(*) FILTER (WHERE block_reason = 'threat_feed_block'),
+		       COUNT(*) FILTER (WHERE block_reason = 'threat_feed_challenge')
+		FROM logs_partitioned
+		WHERE log_type = 'access'
+		  AND timestamp >= $1
+		  AND block_reason IN ('threat_feed_block', 'threat_feed_challenge')
+		GROUP BY 1, 2
+		ORDER BY COUNT(*) DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get threat feed match stats: %w", err)
+	}
+	defer rows.Close()
+
+	stats := []model.ThreatFeedMatchStats{}
+	for rows.Next() {
+		var s model.ThreatFeedMatchStats
+		if err := rows.Scan(&s.ProxyHostID, &s.Host, &s.Blocked, &s.Challenged); err != nil {
+			return nil, fmt.Errorf("failed to scan threat feed match stats: %w", err)
+		}
+		stats = append(stats, s)
+	}
+
+	return stats, nil
+}
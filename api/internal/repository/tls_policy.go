@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"nginx-proxy-guard/internal/model"
+)
+
+type TLSPolicyRepository struct {
+	db *sql.DB
+}
+
+func NewTLSPolicyRepository(db *sql.DB) *TLSPolicyRepository {
+	return &TLSPolicyRepository{db: db}
+}
+
+// GetByProxyHostID returns the TLS policy of a proxy host
+func (r *TLSPolicyRepository) GetByProxyHostID(ctx context.Context, proxyHostID string) (*model.TLSPolicy, error) {
+	var p model.TLSPolicy
+	var protocols pq.StringArray
+
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id, proxy_host_id, profile, protocols, ciphers, curves, prefer_server_ciphers, session_tickets,
+		       ocsp_stapling, hsts_enabled, hsts_max_age, hsts_include_subdomains, hsts_preload, created_at, updated_at
+		FROM tls_policies WHERE proxy_host_id = $1
+	`, proxyHostID).Scan(
+		&p.ID, &p.ProxyHostID, &p.Profile, &protocols, &p.Ciphers, &p.Curves, &p.PreferServerCiphers, &p.SessionTickets,
+		&p.OCSPStapling, &p.HSTSEnabled, &p.HSTSMaxAge, &p.HSTSIncludeSubdomains, &p.HSTSPreload, &p.CreatedAt, &p.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tls policy: %w", err)
+	}
+
+	p.Protocols = []string(protocols)
+	return &p, nil
+}
+
+// Upsert creates or replaces the TLS policy of a proxy host
+func (r *TLSPolicyRepository) Upsert(ctx context.Context, proxyHostID string, p *model.TLSPolicy) (*model.TLSPolicy, error) {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO tls_policies (proxy_host_id, profile, protocols, ciphers, curves, prefer_server_ciphers, session_tickets,
+		                          ocsp_stapling, hsts_enabled, hsts_max_age, hsts_include_subdomains, hsts_preload)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT (proxy_host_id) DO UPDATE SET
+			profile = EXCLUDED.profile,
+			protocols = EXCLUDED.protocols,
+			ciphers = EXCLUDED.ciphers,
+			curves = EXCLUDED.curves,
+			prefer_server_ciphers = EXCLUDED.prefer_server_ciphers,
+			session_tickets = EXCLUDED.session_tickets,
+			ocsp_stapling = EXCLUDED.ocsp_stapling,
+			hsts_enabled = EXCLUDED.hsts_enabled,
+			hsts_max_age = EXCLUDED.hsts_max_age,
+			hsts_include_subdomains = EXCLUDED.hsts_include_subdomains,
+			hsts_preload = EXCLUDED.hsts_preload,
+			updated_at = NOW()
+	`, proxyHostID, p.Profile, pq.Array(p.Protocols), p.Ciphers, p.Curves, p.PreferServerCiphers, p.SessionTickets,
+		p.OCSPStapling, p.HSTSEnabled, p.HSTSMaxAge, p.HSTSIncludeSubdomains, p.HSTSPreload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upsert tls policy: %w", err)
+	}
+
+	return r.GetByProxyHostID(ctx, proxyHostID)
+}
+
+// Delete removes the TLS policy of a proxy host, reverting it to the defaults
+func (r *TLSPolicyRepository) Delete(ctx context.Context, proxyHostID string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM tls_policies WHERE proxy_host_id = $1`, proxyHostID)
+	if err != nil {
+		return fmt.Errorf("failed to delete tls policy: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,236 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"nginx-proxy-guard/internal/model"
+)
+
+type TrustedIPRepository struct {
+	db *sql.DB
+}
+
+func NewTrustedIPRepository(db *sql.DB) *TrustedIPRepository {
+	return &TrustedIPRepository{db: db}
+}
+
+const trustedIPColumns = `id, cidr, label, expires_at, created_at, updated_at`
+
+func scanTrustedIP(row interface{ Scan(...interface{}) error }, t *model.TrustedIP) error {
+	var expiresAt sql.NullTime
+	if err := row.Scan(&t.ID, &t.CIDR, &t.Label, &expiresAt, &t.CreatedAt, &t.UpdatedAt); err != nil {
+		return err
+	}
+	if expiresAt.Valid {
+		t.ExpiresAt = &expiresAt.Time
+	}
+	return nil
+}
+
+// List returns all trusted networks, including expired ones
+func (r *TrustedIPRepository) List(ctx context.Context) ([]model.TrustedIP, error) {
+	return r.list(ctx, `SELECT `+trustedIPColumns+` FROM trusted_ips ORDER BY cidr`)
+}
+
+// ListActive returns trusted networks that have not expired
+func (r *TrustedIPRepository) ListActive(ctx context.Context) ([]model.TrustedIP, error) {
+	return r.list(ctx, `SELECT `+trustedIPColumns+` FROM trusted_ips
+		WHERE expires_at IS NULL OR expires_at > NOW() ORDER BY cidr`)
+}
+
+func (r *TrustedIPRepository) list(ctx context.Context, query string) ([]model.TrustedIP, error) {
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list trusted IPs: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []model.TrustedIP
+	for rows.Next() {
+		var t model.TrustedIP
+		if err := scanTrustedIP(rows, &t); err != nil {
+			return nil, fmt.Errorf("failed to scan trusted IP: %w", err)
+		}
+		entries = append(entries, t)
+	}
+
+	return entries, nil
+}
+
+// GetByID returns a trusted network by ID
+func (r *TrustedIPRepository) GetByID(ctx context.Context, id string) (*model.TrustedIP, error) {
+	var t model.TrustedIP
+	err := scanTrustedIP(r.db.QueryRowContext(ctx, `SELECT `+trustedIPColumns+` FROM trusted_ips WHERE id = $1`, id), &t)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trusted IP: %w", err)
+	}
+	return &t, nil
+}
+
+// ExistsByCIDR checks if a trusted network with the given CIDR exists
+func (r *TrustedIPRepository) ExistsByCIDR(ctx context.Context, cidr string) (bool, error) {
+	var exists bool
+	err := r.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM trusted_ips WHERE cidr = $1)`, cidr).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check trusted IP existence: %w", err)
+	}
+	return exists, nil
+}
+
+// Create adds a trusted network
+func (r *TrustedIPRepository) Create(ctx context.Context, cidr, label string, expiresAt *time.Time) (*model.TrustedIP, error) {
+	query := `
+		INSERT INTO trusted_ips (cidr, label, expires_at)
+		VALUES ($1, $2, $3)
+		RETURNING ` + trustedIPColumns
+
+	var t model.TrustedIP
+	if err := scanTrustedIP(r.db.QueryRowContext(ctx, query, cidr, label, expiresAt), &t); err != nil {
+		return nil, fmt.Errorf("failed to create trusted IP: %w", err)
+	}
+	return &t, nil
+}
+
+// Update updates the label and/or expiry of a trusted network
+func (r *TrustedIPRepository) Update(ctx context.Context, id string, req *model.UpdateTrustedIPRequest) (*model.TrustedIP, error) {
+	var sets []string
+	var args []interface{}
+	argNum := 1
+
+	if req.Label != nil {
+		sets = append(sets, fmt.Sprintf("label = $%d", argNum))
+		args = append(args, *req.Label)
+		argNum++
+	}
+	if req.ClearExpires {
+		sets = append(sets, "expires_at = NULL")
+	} else if req.ExpiresAt != nil {
+		sets = append(sets, fmt.Sprintf("expires_at = $%d", argNum))
+		args = append(args, *req.ExpiresAt)
+		argNum++
+	}
+
+	if len(sets) == 0 {
+		return r.GetByID(ctx, id)
+	}
+
+	sets = append(sets, "updated_at = NOW()")
+	args = append(args, id)
+
+	query := fmt.Sprintf(`UPDATE trusted_ips SET %s WHERE id = $%d RETURNING `+trustedIPColumns,
+		strings.Join(sets, ", "), argNum)
+
+	var t model.TrustedIP
+	err := scanTrustedIP(r.db.QueryRowContext(ctx, query, args...), &t)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update trusted IP: %w", err)
+	}
+	return &t, nil
+}
+
+// Delete removes a trusted network (its hits are removed by cascade)
+func (r *TrustedIPRepository) Delete(ctx context.Context, id string) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM trusted_ips WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete trusted IP: %w", err)
+	}
+	return nil
+}
+
+// RecordHit records a block that a trusted network prevented
+func (r *TrustedIPRepository) RecordHit(ctx context.Context, hit *model.TrustedIPHit) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO trusted_ip_hits (trusted_ip_id, ip_address, source, reason, proxy_host_id)
+		VALUES ($1, $2, $3, $4, $5)`,
+		hit.TrustedIPID, hit.IPAddress, hit.Source, hit.Reason, hit.ProxyHostID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record trusted IP hit: %w", err)
+	}
+	return nil
+}
+
+// GetReport returns every trusted network with the number of blocks it prevented since the
+// given time and up to recentLimit of the most recent prevented blocks
+func (r *TrustedIPRepository) GetReport(ctx context.Context, since time.Time, recentLimit int) ([]model.TrustedIPReportEntry, error) {
+	entries, err := r.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := make([]model.TrustedIPReportEntry, len(entries))
+	index := make(map[string]int, len(entries))
+	for i, t := range entries {
+		report[i] = model.TrustedIPReportEntry{TrustedIP: t, RecentHits: []model.TrustedIPHit{}}
+		index[t.ID] = i
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT trusted_ip_id, COUNT(*), MAX(created_at)
+		FROM trusted_ip_hits
+		WHERE created_at >= $1
+		GROUP BY trusted_ip_id`, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count trusted IP hits: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		var count int64
+		var last time.Time
+		if err := rows.Scan(&id, &count, &last); err != nil {
+			return nil, fmt.Errorf("failed to scan trusted IP hit count: %w", err)
+		}
+		if i, ok := index[id]; ok {
+			report[i].PreventedCount = count
+			report[i].LastPrevented = &last
+		}
+	}
+
+	hitRows, err := r.db.QueryContext(ctx, `
+		SELECT id, trusted_ip_id, ip_address, source, COALESCE(reason, ''), proxy_host_id, domain_names, created_at
+		FROM (
+			SELECT h.id, h.trusted_ip_id, h.ip_address, h.source, h.reason, h.proxy_host_id,
+			       ph.domain_names, h.created_at,
+			       ROW_NUMBER() OVER (PARTITION BY h.trusted_ip_id ORDER BY h.created_at DESC) AS rn
+			FROM trusted_ip_hits h
+			LEFT JOIN proxy_hosts ph ON ph.id = h.proxy_host_id
+			WHERE h.created_at >= $1
+		) recent
+		WHERE rn <= $2
+		ORDER BY created_at DESC`, since, recentLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list trusted IP hits: %w", err)
+	}
+	defer hitRows.Close()
+
+	for hitRows.Next() {
+		var h model.TrustedIPHit
+		var proxyHostID sql.NullString
+		if err := hitRows.Scan(&h.ID, &h.TrustedIPID, &h.IPAddress, &h.Source, &h.Reason,
+			&proxyHostID, pq.Array(&h.DomainNames), &h.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan trusted IP hit: %w", err)
+		}
+		if proxyHostID.Valid {
+			h.ProxyHostID = &proxyHostID.String
+		}
+		if i, ok := index[h.TrustedIPID]; ok {
+			report[i].RecentHits = append(report[i].RecentHits, h)
+		}
+	}
+
+	return report, nil
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"nginx-proxy-guard/internal/model"
+)
+
+type UpstreamTLSRepository struct {
+	db *sql.DB
+}
+
+func NewUpstreamTLSRepository(db *sql.DB) *UpstreamTLSRepository {
+	return &UpstreamTLSRepository{db: db}
+}
+
+// GetByProxyHostID returns the backend TLS settings of a proxy host
+func (r *UpstreamTLSRepository) GetByProxyHostID(ctx context.Context, proxyHostID string) (*model.UpstreamTLSConfig, error) {
+	var cfg model.UpstreamTLSConfig
+	var clientCertID sql.NullString
+
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id, proxy_host_id, enabled, verify, trusted_ca_pem, verify_depth, server_name, sni,
+		       client_certificate_id, created_at, updated_at
+		FROM upstream_tls_configs WHERE proxy_host_id = $1
+	`, proxyHostID).Scan(
+		&cfg.ID, &cfg.ProxyHostID, &cfg.Enabled, &cfg.Verify, &cfg.TrustedCAPEM, &cfg.VerifyDepth, &cfg.ServerName, &cfg.SNI,
+		&clientCertID, &cfg.CreatedAt, &cfg.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get upstream tls config: %w", err)
+	}
+
+	if clientCertID.Valid {
+		cfg.ClientCertificateID = &clientCertID.String
+	}
+	return &cfg, nil
+}
+
+// Upsert creates or replaces the backend TLS settings of a proxy host
+func (r *UpstreamTLSRepository) Upsert(ctx context.Context, proxyHostID string, req *model.UpsertUpstreamTLSRequest) (*model.UpstreamTLSConfig, error) {
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+	verify := true
+	if req.Verify != nil {
+		verify = *req.Verify
+	}
+	sni := true
+	if req.SNI != nil {
+		sni = *req.SNI
+	}
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO upstream_tls_configs (proxy_host_id, enabled, verify, trusted_ca_pem, verify_depth, server_name, sni, client_certificate_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (proxy_host_id) DO UPDATE SET
+			enabled = EXCLUDED.enabled,
+			verify = EXCLUDED.verify,
+			trusted_ca_pem = EXCLUDED.trusted_ca_pem,
+			verify_depth = EXCLUDED.verify_depth,
+			server_name = EXCLUDED.server_name,
+			sni = EXCLUDED.sni,
+			client_certificate_id = EXCLUDED.client_certificate_id,
+			updated_at = NOW()
+	`, proxyHostID, enabled, verify, req.TrustedCAPEM, req.VerifyDepth, req.ServerName, sni, req.ClientCertificateID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upsert upstream tls config: %w", err)
+	}
+
+	return r.GetByProxyHostID(ctx, proxyHostID)
+}
+
+// Delete removes the backend TLS settings of a proxy host
+func (r *UpstreamTLSRepository) Delete(ctx context.Context, proxyHostID string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM upstream_tls_configs WHERE proxy_host_id = $1`, proxyHostID)
+	if err != nil {
+		return fmt.Errorf("failed to delete upstream tls config: %w", err)
+	}
+	return nil
+}
+
+// ListProxyHostIDsByClientCertificate returns the proxy hosts presenting a certificate to their backend
+func (r *UpstreamTLSRepository) ListProxyHostIDsByClientCertificate(ctx context.Context, certificateID string) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT proxy_host_id FROM upstream_tls_configs WHERE client_certificate_id = $1`, certificateID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list upstream tls proxy hosts: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan proxy host id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
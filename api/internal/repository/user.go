@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"nginx-proxy-guard/internal/model"
+)
+
+// UserRepository manages the administrator accounts. Authentication lookups
+// (by username, by ID) live in AuthRepository.
+type UserRepository struct {
+	db *sql.DB
+}
+
+func NewUserRepository(db *sql.DB) *UserRepository {
+	return &UserRepository{db: db}
+}
+
+// List returns all users ordered by username
+func (r *UserRepository) List(ctx context.Context) ([]model.User, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, username, COALESCE(email, ''), COALESCE(role, ''), disabled, COALESCE(ldap_dn, ''),
+		       COALESCE(language, 'ko'), COALESCE(font_family, 'system'),
+		       is_initial_setup, totp_enabled, last_login_at, COALESCE(last_login_ip, ''), login_count,
+		       created_at, updated_at
+		FROM users
+		ORDER BY username
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []model.User
+	for rows.Next() {
+		var u model.User
+		var lastLoginAt sql.NullTime
+		if err := rows.Scan(
+			&u.ID, &u.Username, &u.Email, &u.Role, &u.Disabled, &u.LDAPDN,
+			&u.Language, &u.FontFamily,
+			&u.IsInitialSetup, &u.TOTPEnabled, &lastLoginAt, &u.LastLoginIP, &u.LoginCount,
+			&u.CreatedAt, &u.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		if lastLoginAt.Valid {
+			u.LastLoginAt = &lastLoginAt.Time
+		}
+		users = append(users, u)
+	}
+
+	return users, rows.Err()
+}
+
+// Create inserts a new user
+func (r *UserRepository) Create(ctx context.Context, u *model.User) error {
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO users (username, email, password_hash, role, is_initial_setup)
+		VALUES ($1, NULLIF($2, ''), $3, $4, $5)
+		RETURNING id, COALESCE(language, 'ko'), COALESCE(font_family, 'system'), created_at, updated_at
+	`, u.Username, u.Email, u.PasswordHash, u.Role, u.IsInitialSetup,
+	).Scan(&u.ID, &u.Language, &u.FontFamily, &u.CreatedAt, &u.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+	return nil
+}
+
+// EmailExists checks if another user already uses the email address
+func (r *UserRepository) EmailExists(ctx context.Context, email string) (bool, error) {
+	var exists bool
+	err := r.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM users WHERE LOWER(email) = LOWER($1))`, email).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check email: %w", err)
+	}
+	return exists, nil
+}
+
+// UpdateRole changes the role of a user
+func (r *UserRepository) UpdateRole(ctx context.Context, id, role string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE users SET role = $2, updated_at = NOW() WHERE id = $1`, id, role)
+	if err != nil {
+		return fmt.Errorf("failed to update user role: %w", err)
+	}
+	return nil
+}
+
+// SetDisabled disables or re-enables a user
+func (r *UserRepository) SetDisabled(ctx context.Context, id string, disabled bool) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE users SET disabled = $2, updated_at = NOW() WHERE id = $1`, id, disabled)
+	if err != nil {
+		return fmt.Errorf("failed to update user status: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a user (sessions, API tokens and invitations cascade)
+func (r *UserRepository) Delete(ctx context.Context, id string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM users WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+	return nil
+}
+
+// CountEnabledAdmins returns the number of enabled admin accounts
+func (r *UserRepository) CountEnabledAdmins(ctx context.Context) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM users WHERE role = $1 AND disabled = FALSE`, model.RoleAdmin).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count admins: %w", err)
+	}
+	return count, nil
+}
+
+// Invitations
+
+// CreateInvitation stores an invitation, replacing pending invitations of the same user
+func (r *UserRepository) CreateInvitation(ctx context.Context, inv *model.UserInvitation) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_invitations WHERE user_id = $1`, inv.UserID); err != nil {
+		return fmt.Errorf("failed to delete previous invitations: %w", err)
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO user_invitations (user_id, token_hash, expires_at, created_by)
+		VALUES ($1, $2, $3, NULLIF($4, '')::uuid)
+		RETURNING id, created_at
+	`, inv.UserID, inv.TokenHash, inv.ExpiresAt, inv.CreatedBy).Scan(&inv.ID, &inv.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create invitation: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// GetInvitationByTokenHash returns an unexpired invitation
+func (r *UserRepository) GetInvitationByTokenHash(ctx context.Context, tokenHash string) (*model.UserInvitation, error) {
+	var inv model.UserInvitation
+	var createdBy sql.NullString
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id, user_id, token_hash, expires_at, created_by, created_at
+		FROM user_invitations
+		WHERE token_hash = $1 AND expires_at > NOW()
+	`, tokenHash).Scan(&inv.ID, &inv.UserID, &inv.TokenHash, &inv.ExpiresAt, &createdBy, &inv.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invitation: %w", err)
+	}
+	inv.CreatedBy = createdBy.String
+
+	return &inv, nil
+}
+
+// AcceptInvitation sets the invited user's password and consumes the invitation
+func (r *UserRepository) AcceptInvitation(ctx context.Context, inv *model.UserInvitation, passwordHash string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE users
+		SET password_hash = $2,
+		    is_initial_setup = FALSE,
+		    updated_at = NOW()
+		WHERE id = $1
+	`, inv.UserID, passwordHash)
+	if err != nil {
+		return fmt.Errorf("failed to set password: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_invitations WHERE user_id = $1`, inv.UserID); err != nil {
+		return fmt.Errorf("failed to delete invitation: %w", err)
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,191 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"nginx-proxy-guard/internal/model"
+)
+
+type WebAuthnRepository struct {
+	db *sql.DB
+}
+
+func NewWebAuthnRepository(db *sql.DB) *WebAuthnRepository {
+	return &WebAuthnRepository{db: db}
+}
+
+const webAuthnSettingsColumns = `id, enabled, rp_id, rp_name, rp_origins, allow_passwordless,
+	       require_hardware_key_for_admins, created_at, updated_at`
+
+func scanWebAuthnSettings(row interface{ Scan(...interface{}) error }, s *model.WebAuthnSettings) error {
+	var origins pq.StringArray
+	err := row.Scan(
+		&s.ID, &s.Enabled, &s.RPID, &s.RPName, &origins, &s.AllowPasswordless,
+		&s.RequireHardwareKeyForAdmins, &s.CreatedAt, &s.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	s.RPOrigins = []string(origins)
+	if s.RPOrigins == nil {
+		s.RPOrigins = []string{}
+	}
+	return nil
+}
+
+// GetSettings returns the WebAuthn settings, creating the default row if none exists
+func (r *WebAuthnRepository) GetSettings(ctx context.Context) (*model.WebAuthnSettings, error) {
+	query := `SELECT ` + webAuthnSettingsColumns + ` FROM webauthn_settings LIMIT 1`
+
+	var settings model.WebAuthnSettings
+	err := scanWebAuthnSettings(r.db.QueryRowContext(ctx, query), &settings)
+	if err == sql.ErrNoRows {
+		insert := `INSERT INTO webauthn_settings DEFAULT VALUES RETURNING ` + webAuthnSettingsColumns
+		if err := scanWebAuthnSettings(r.db.QueryRowContext(ctx, insert), &settings); err != nil {
+			return nil, fmt.Errorf("failed to create default webauthn settings: %w", err)
+		}
+		return &settings, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webauthn settings: %w", err)
+	}
+
+	return &settings, nil
+}
+
+// SaveSettings stores the WebAuthn settings; callers merge and validate the update beforehand
+func (r *WebAuthnRepository) SaveSettings(ctx context.Context, s *model.WebAuthnSettings) (*model.WebAuthnSettings, error) {
+	query := `
+		UPDATE webauthn_settings SET
+			enabled = $1,
+			rp_id = $2,
+			rp_name = $3,
+			rp_origins = $4,
+			allow_passwordless = $5,
+			require_hardware_key_for_admins = $6,
+			updated_at = NOW()
+		WHERE id = $7
+		RETURNING ` + webAuthnSettingsColumns
+
+	var updated model.WebAuthnSettings
+	err := scanWebAuthnSettings(r.db.QueryRowContext(ctx, query,
+		s.Enabled, s.RPID, s.RPName, pq.Array(s.RPOrigins), s.AllowPasswordless,
+		s.RequireHardwareKeyForAdmins, s.ID,
+	), &updated)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update webauthn settings: %w", err)
+	}
+
+	return &updated, nil
+}
+
+const webAuthnCredentialColumns = `id, user_id, name, credential_id, public_key, attestation_type, transports,
+	       aaguid, sign_count, clone_warning, backup_eligible, backup_state, attachment, last_used_at, created_at`
+
+func scanWebAuthnCredential(row interface{ Scan(...interface{}) error }, c *model.WebAuthnCredential) error {
+	var transports pq.StringArray
+	var signCount int64
+	err := row.Scan(
+		&c.ID, &c.UserID, &c.Name, &c.CredentialID, &c.PublicKey, &c.AttestationType, &transports,
+		&c.AAGUID, &signCount, &c.CloneWarning, &c.BackupEligible, &c.BackupState, &c.Attachment, &c.LastUsedAt, &c.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	c.SignCount = uint32(signCount)
+	c.Transports = []string(transports)
+	if c.Transports == nil {
+		c.Transports = []string{}
+	}
+	c.HardwareKey = c.IsHardwareKey()
+	return nil
+}
+
+// ListCredentials returns the credentials registered by a user, oldest first
+func (r *WebAuthnRepository) ListCredentials(ctx context.Context, userID string) ([]model.WebAuthnCredential, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+webAuthnCredentialColumns+`
+		FROM webauthn_credentials
+		WHERE user_id = $1
+		ORDER BY created_at
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webauthn credentials: %w", err)
+	}
+	defer rows.Close()
+
+	credentials := []model.WebAuthnCredential{}
+	for rows.Next() {
+		var c model.WebAuthnCredential
+		if err := scanWebAuthnCredential(rows, &c); err != nil {
+			return nil, fmt.Errorf("failed to scan webauthn credential: %w", err)
+		}
+		credentials = append(credentials, c)
+	}
+	return credentials, rows.Err()
+}
+
+// CreateCredential stores a newly registered credential
+func (r *WebAuthnRepository) CreateCredential(ctx context.Context, c *model.WebAuthnCredential) error {
+	err := scanWebAuthnCredential(r.db.QueryRowContext(ctx, `
+		INSERT INTO webauthn_credentials (
+			user_id, name, credential_id, public_key, attestation_type, transports,
+			aaguid, sign_count, backup_eligible, backup_state, attachment
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING `+webAuthnCredentialColumns,
+		c.UserID, c.Name, c.CredentialID, c.PublicKey, c.AttestationType, pq.Array(c.Transports),
+		c.AAGUID, int64(c.SignCount), c.BackupEligible, c.BackupState, c.Attachment,
+	), c)
+	if err != nil {
+		return fmt.Errorf("failed to create webauthn credential: %w", err)
+	}
+	return nil
+}
+
+// RecordUse stores the authenticator state after a successful login
+func (r *WebAuthnRepository) RecordUse(ctx context.Context, c *model.WebAuthnCredential) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE webauthn_credentials
+		SET sign_count = $2, clone_warning = $3, backup_state = $4, last_used_at = NOW()
+		WHERE id = $1
+	`, c.ID, int64(c.SignCount), c.CloneWarning, c.BackupState)
+	if err != nil {
+		return fmt.Errorf("failed to update webauthn credential: %w", err)
+	}
+	return nil
+}
+
+// RenameCredential renames a credential of a user, reporting whether it exists
+func (r *WebAuthnRepository) RenameCredential(ctx context.Context, userID, id, name string) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE webauthn_credentials SET name = $3 WHERE id = $1 AND user_id = $2
+	`, id, userID, name)
+	if err != nil {
+		return false, fmt.Errorf("failed to rename webauthn credential: %w", err)
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// DeleteCredential removes a credential of a user, reporting whether it existed
+func (r *WebAuthnRepository) DeleteCredential(ctx context.Context, userID, id string) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM webauthn_credentials WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete webauthn credential: %w", err)
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// DeleteUserCredentials removes all credentials of a user
+func (r *WebAuthnRepository) DeleteUserCredentials(ctx context.Context, userID string) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM webauthn_credentials WHERE user_id = $1`, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete webauthn credentials: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -16,6 +16,7 @@ type RenewalScheduler struct {
 	certService *service.CertificateService
 	interval    time.Duration
 	daysBuffer  int // Renew certificates expiring within this many days
+	required    func(ctx context.Context) []string
 	stopChan    chan struct{}
 	running     bool
 }
@@ -43,6 +44,12 @@ func NewRenewalScheduler(
 	}
 }
 
+// SetRequiredCertificates sets a source of certificates that are renewed
+// before expiry even without auto-renew, e.g. the management listener's
+func (s *RenewalScheduler) SetRequiredCertificates(required func(ctx context.Context) []string) {
+	s.required = required
+}
+
 // Start begins the renewal scheduler
 func (s *RenewalScheduler) Start() {
 	if s.running {
@@ -92,6 +99,7 @@ func (s *RenewalScheduler) checkAndRenew() {
 		log.Printf("[Scheduler] Error getting expiring certificates: %v", err)
 		return
 	}
+	certs = append(certs, s.requiredExpiringSoon(ctx, certs)...)
 
 	if len(certs) == 0 {
 		log.Println("[Scheduler] No certificates need renewal")
@@ -125,6 +133,38 @@ func (s *RenewalScheduler) checkAndRenew() {
 	}
 }
 
+// requiredExpiringSoon returns the required certificates expiring within the
+// buffer that aren't in found already
+func (s *RenewalScheduler) requiredExpiringSoon(ctx context.Context, found []model.Certificate) []model.Certificate {
+	if s.required == nil {
+		return nil
+	}
+
+	var certs []model.Certificate
+	for _, id := range s.required(ctx) {
+		listed := false
+		for _, cert := range found {
+			if cert.ID == id {
+				listed = true
+				break
+			}
+		}
+		if listed {
+			continue
+		}
+
+		cert, err := s.certRepo.GetByID(ctx, id)
+		if err != nil {
+			log.Printf("[Scheduler] Error getting required certificate %s: %v", id, err)
+			continue
+		}
+		if cert != nil && cert.ExpiresAt != nil && cert.DaysUntilExpiry() <= s.daysBuffer {
+			certs = append(certs, *cert)
+		}
+	}
+	return certs
+}
+
 // CheckNow triggers an immediate renewal check
 func (s *RenewalScheduler) CheckNow() {
 	go s.checkAndRenew()
@@ -0,0 +1,525 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"nginx-proxy-guard/internal/model"
+	"nginx-proxy-guard/internal/repository"
+)
+
+var (
+	ErrAdminSSODisabled   = errors.New("single sign-on is not enabled")
+	ErrAdminSSONoAccount  = errors.New("no account is linked to this identity")
+	ErrInvalidSSOCode     = errors.New("invalid or expired sign-on code")
+	ErrLocalLoginDisabled = errors.New("password login is disabled, sign in with single sign-on")
+)
+
+const (
+	// Cookie holding the signed state of an admin SSO login in progress
+	AdminSSOStateCookie = "npg_sso_state"
+
+	// How long the UI has to redeem the code handed over by the SSO callback
+	ssoExchangeLifetime = time.Minute
+	// Password hash of provisioned users, who can only log in through SSO
+	ssoPasswordHash = "!"
+
+	oidcPurposeAdminState = "admin-state"
+)
+
+var ssoUsernameInvalidChars = regexp.MustCompile(`[^A-Za-z0-9._@-]+`)
+
+// LocalLoginPolicy decides whether a user may log in with a password
+type LocalLoginPolicy interface {
+	LocalLoginAllowed(ctx context.Context, username string) bool
+}
+
+// ssoExchange is a completed SSO login waiting for the UI to redeem its code
+type ssoExchange struct {
+	userID       string
+	providerName string
+	provisioned  bool
+	expiresAt    time.Time
+}
+
+// AdminSSOLogin is the session issued for a redeemed SSO login
+type AdminSSOLogin struct {
+	Response     *model.LoginResponse
+	ProviderName string
+	Provisioned  bool
+}
+
+// AdminSSOService logs admins in to the UI and API through an OIDC identity
+// provider. The code flow is shared with the login gate; the callback hands a
+// one-time code to the UI, which redeems it for a regular session.
+type AdminSSOService struct {
+	repo     *repository.AdminSSORepository
+	userRepo *repository.UserRepository
+	authRepo *repository.AuthRepository
+	oidc     *OIDCGateService
+	auth     *AuthService
+
+	exchangeMu sync.Mutex
+	exchanges  map[string]*ssoExchange
+}
+
+func NewAdminSSOService(
+	repo *repository.AdminSSORepository,
+	userRepo *repository.UserRepository,
+	authRepo *repository.AuthRepository,
+	oidc *OIDCGateService,
+	auth *AuthService,
+) *AdminSSOService {
+	return &AdminSSOService{
+		repo:      repo,
+		userRepo:  userRepo,
+		authRepo:  authRepo,
+		oidc:      oidc,
+		auth:      auth,
+		exchanges: make(map[string]*ssoExchange),
+	}
+}
+
+// GetSettings returns the admin SSO settings
+func (s *AdminSSOService) GetSettings(ctx context.Context) (*model.AdminSSOSettings, error) {
+	return s.repo.GetSettings(ctx)
+}
+
+// UpdateSettings merges and validates an update of the admin SSO settings
+func (s *AdminSSOService) UpdateSettings(ctx context.Context, req *model.UpdateAdminSSOSettingsRequest) (*model.AdminSSOSettings, error) {
+	settings, err := s.repo.GetSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Enabled != nil {
+		settings.Enabled = *req.Enabled
+	}
+	if req.ProviderID != nil {
+		settings.ProviderID = strings.TrimSpace(*req.ProviderID)
+	}
+	if req.RoleClaim != nil {
+		settings.RoleClaim = strings.TrimSpace(*req.RoleClaim)
+	}
+	if req.RoleMappings != nil {
+		settings.RoleMappings = *req.RoleMappings
+	}
+	if req.DefaultRole != nil {
+		settings.DefaultRole = strings.TrimSpace(*req.DefaultRole)
+	}
+	if req.AutoProvision != nil {
+		settings.AutoProvision = *req.AutoProvision
+	}
+	if req.AllowedEmailDomains != nil {
+		settings.AllowedEmailDomains = *req.AllowedEmailDomains
+	}
+	if req.DisableLocalLogin != nil {
+		settings.DisableLocalLogin = *req.DisableLocalLogin
+	}
+	if req.BreakGlassUsername != nil {
+		settings.BreakGlassUsername = strings.TrimSpace(*req.BreakGlassUsername)
+	}
+
+	if err := normalizeAdminSSOSettings(settings); err != nil {
+		return nil, err
+	}
+
+	if settings.ProviderID != "" {
+		provider, err := s.oidc.repo.GetProvider(ctx, settings.ProviderID)
+		if err != nil {
+			return nil, err
+		}
+		if provider == nil {
+			return nil, &UserError{Field: "provider_id", Message: "does not exist"}
+		}
+	}
+
+	// The break-glass account keeps the instance reachable when the identity
+	// provider is down, so it must be a working local account
+	if settings.DisableLocalLogin {
+		user, err := s.authRepo.GetUserByUsername(ctx, settings.BreakGlassUsername)
+		if err != nil {
+			return nil, err
+		}
+		if user == nil || user.Disabled || user.PasswordHash == ssoPasswordHash {
+			return nil, &UserError{Field: "break_glass_username", Message: "must be an enabled local account"}
+		}
+	}
+
+	return s.repo.SaveSettings(ctx, settings)
+}
+
+// normalizeAdminSSOSettings trims and validates settings before they are saved
+func normalizeAdminSSOSettings(settings *model.AdminSSOSettings) error {
+	if settings.Enabled && settings.ProviderID == "" {
+		return &UserError{Field: "provider_id", Message: "is required to enable single sign-on"}
+	}
+	if settings.DefaultRole != "" && !model.IsValidRole(settings.DefaultRole) {
+		return &UserError{Field: "default_role", Message: fmt.Sprintf("must be empty or one of %s", strings.Join(model.AllRoles, ", "))}
+	}
+
+	mappings := make([]model.AdminSSORoleMapping, 0, len(settings.RoleMappings))
+	for _, m := range settings.RoleMappings {
+		m.ClaimValue = strings.TrimSpace(m.ClaimValue)
+		if m.ClaimValue == "" {
+			return &UserError{Field: "role_mappings", Message: "claim_value is required"}
+		}
+		if !model.IsValidRole(m.Role) {
+			return &UserError{Field: "role_mappings", Message: fmt.Sprintf("role must be one of %s", strings.Join(model.AllRoles, ", "))}
+		}
+		mappings = append(mappings, m)
+	}
+	settings.RoleMappings = mappings
+
+	domains := make([]string, 0, len(settings.AllowedEmailDomains))
+	for _, d := range settings.AllowedEmailDomains {
+		d = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(d), "@"))
+		if d != "" {
+			domains = append(domains, d)
+		}
+	}
+	settings.AllowedEmailDomains = domains
+
+	if settings.DisableLocalLogin {
+		if !settings.Enabled {
+			return &UserError{Field: "disable_local_login", Message: "requires single sign-on to be enabled"}
+		}
+		if settings.BreakGlassUsername == "" {
+			return &UserError{Field: "break_glass_username", Message: "is required when local login is disabled"}
+		}
+	}
+	return nil
+}
+
+// Status reports what the login page should offer
+func (s *AdminSSOService) Status(ctx context.Context) (*model.AdminSSOStatus, error) {
+	settings, err := s.repo.GetSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &model.AdminSSOStatus{LocalLoginEnabled: true}
+	if !settings.Enabled || settings.ProviderID == "" {
+		return status, nil
+	}
+	provider, err := s.oidc.repo.GetProvider(ctx, settings.ProviderID)
+	if err != nil {
+		return nil, err
+	}
+	if provider != nil && provider.Enabled {
+		status.Enabled = true
+		status.ProviderName = provider.Name
+	}
+	status.LocalLoginEnabled = !settings.DisableLocalLogin
+	return status, nil
+}
+
+// LocalLoginAllowed refuses password logins, except for the break-glass
+// account, once local login is disabled
+func (s *AdminSSOService) LocalLoginAllowed(ctx context.Context, username string) bool {
+	settings, err := s.repo.GetSettings(ctx)
+	if err != nil {
+		log.Printf("[AdminSSO] Failed to load settings, allowing local login: %v", err)
+		return true
+	}
+	if !settings.Enabled || !settings.DisableLocalLogin {
+		return true
+	}
+	return username == settings.BreakGlassUsername
+}
+
+// BeginLogin starts the code flow at the configured identity provider
+func (s *AdminSSOService) BeginLogin(ctx context.Context, redirectURI string) (*OIDCLoginStart, error) {
+	_, provider, err := s.loadProvider(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.oidc.authorize(ctx, provider, oidcPurposeAdminState, "", redirectURI, "")
+}
+
+// CompleteLogin verifies the identity provider's response, provisions or
+// updates the user and returns a one-time code the UI exchanges for a session
+func (s *AdminSSOService) CompleteLogin(ctx context.Context, stateCookie, state, code, ip string) (string, error) {
+	ls, err := s.oidc.readLoginState(oidcPurposeAdminState, stateCookie, state)
+	if err != nil {
+		return "", err
+	}
+
+	settings, provider, err := s.loadProvider(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	claims, err := s.oidc.authenticate(ctx, provider, ls, code)
+	if err != nil {
+		return "", err
+	}
+
+	identity, role, err := resolveAdminSSOIdentity(settings, provider, claims)
+	if err != nil {
+		if identity != nil {
+			s.authRepo.RecordLoginAttempt(ctx, ip, ssoLoginName(identity), false)
+		}
+		return "", err
+	}
+
+	user, provisioned, err := s.findOrProvisionUser(ctx, settings, provider, identity, claims, role)
+	if err != nil {
+		if errors.Is(err, ErrAdminSSONoAccount) {
+			s.authRepo.RecordLoginAttempt(ctx, ip, ssoLoginName(identity), false)
+		}
+		return "", err
+	}
+	if user.Disabled {
+		s.authRepo.RecordLoginAttempt(ctx, ip, user.Username, false)
+		return "", ErrAccountDisabled
+	}
+
+	if user.Role != role {
+		if err := applyMappedRole(ctx, s.userRepo, user, role); err != nil {
+			return "", err
+		}
+	}
+
+	exchangeCode, err := generateSecureToken()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate sign-on code: %w", err)
+	}
+
+	now := time.Now()
+	s.exchangeMu.Lock()
+	for c, e := range s.exchanges {
+		if now.After(e.expiresAt) {
+			delete(s.exchanges, c)
+		}
+	}
+	s.exchanges[exchangeCode] = &ssoExchange{
+		userID:       user.ID,
+		providerName: provider.Name,
+		provisioned:  provisioned,
+		expiresAt:    now.Add(ssoExchangeLifetime),
+	}
+	s.exchangeMu.Unlock()
+
+	return exchangeCode, nil
+}
+
+// Exchange redeems the one-time code of a completed SSO login for a session
+func (s *AdminSSOService) Exchange(ctx context.Context, code, ip, userAgent string) (*AdminSSOLogin, error) {
+	s.exchangeMu.Lock()
+	exchange, ok := s.exchanges[code]
+	delete(s.exchanges, code)
+	s.exchangeMu.Unlock()
+
+	if !ok || time.Now().After(exchange.expiresAt) {
+		return nil, ErrInvalidSSOCode
+	}
+
+	user, err := s.authRepo.GetUserByID(ctx, exchange.userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrInvalidSSOCode
+	}
+	if user.Disabled {
+		return nil, ErrAccountDisabled
+	}
+
+	resp, err := s.auth.createSession(ctx, user, ip, userAgent)
+	if err != nil {
+		return nil, err
+	}
+	return &AdminSSOLogin{
+		Response:     resp,
+		ProviderName: exchange.providerName,
+		Provisioned:  exchange.provisioned,
+	}, nil
+}
+
+// loadProvider returns the settings and identity provider of an enabled admin SSO
+func (s *AdminSSOService) loadProvider(ctx context.Context) (*model.AdminSSOSettings, *model.OIDCProvider, error) {
+	settings, err := s.repo.GetSettings(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !settings.Enabled || settings.ProviderID == "" {
+		return nil, nil, ErrAdminSSODisabled
+	}
+
+	provider, err := s.oidc.repo.GetProvider(ctx, settings.ProviderID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if provider == nil || !provider.Enabled {
+		return nil, nil, ErrOIDCProviderDisabled
+	}
+	return settings, provider, nil
+}
+
+// findOrProvisionUser returns the user linked to the identity. Existing users
+// are linked on their first SSO login by verified email; unknown identities
+// get an account only when auto-provisioning is on.
+func (s *AdminSSOService) findOrProvisionUser(ctx context.Context, settings *model.AdminSSOSettings, provider *model.OIDCProvider, identity *model.OIDCIdentity, claims map[string]interface{}, role string) (*model.User, bool, error) {
+	userID, err := s.repo.FindLinkedUserID(ctx, provider.ID, identity.Subject)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if userID == "" && identity.Email != "" {
+		if verified, _ := claims["email_verified"].(bool); verified {
+			userID, err = s.repo.FindUserIDByEmail(ctx, identity.Email)
+			if err != nil {
+				return nil, false, err
+			}
+			if userID != "" {
+				if err := s.repo.LinkUser(ctx, userID, provider.ID, identity.Subject); err != nil {
+					return nil, false, err
+				}
+			}
+		}
+	}
+
+	provisioned := false
+	if userID == "" {
+		if !settings.AutoProvision {
+			return nil, false, ErrAdminSSONoAccount
+		}
+		username, err := s.availableUsername(ctx, identity)
+		if err != nil {
+			return nil, false, err
+		}
+		newUser := &model.User{Username: username, Email: identity.Email, Role: role}
+		if err := s.repo.ProvisionUser(ctx, newUser, provider.ID, identity.Subject); err != nil {
+			return nil, false, err
+		}
+		userID = newUser.ID
+		provisioned = true
+		log.Printf("[AdminSSO] Provisioned user %s (%s) from %s", username, role, provider.Name)
+	}
+
+	user, err := s.authRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, false, err
+	}
+	if user == nil {
+		return nil, false, ErrAdminSSONoAccount
+	}
+	return user, provisioned, nil
+}
+
+// availableUsername derives a free username from the identity
+func (s *AdminSSOService) availableUsername(ctx context.Context, identity *model.OIDCIdentity) (string, error) {
+	base := ssoUsername(identity)
+	for i := 1; i <= 100; i++ {
+		candidate := base
+		if i > 1 {
+			suffix := fmt.Sprintf("-%d", i)
+			if len(base)+len(suffix) > 64 {
+				candidate = base[:64-len(suffix)]
+			}
+			candidate += suffix
+		}
+		taken, err := s.repo.UsernameExists(ctx, candidate)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("no free username for %s", base)
+}
+
+// ssoUsername turns the preferred username, email or subject into a valid username
+func ssoUsername(identity *model.OIDCIdentity) string {
+	name := identity.Name
+	if name == "" || strings.Contains(name, " ") {
+		name = identity.Email
+	}
+	if name == "" {
+		name = "sso-" + identity.Subject
+	}
+	name = strings.Trim(ssoUsernameInvalidChars.ReplaceAllString(name, "-"), "-")
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	for len(name) < 3 {
+		name += "-"
+	}
+	return name
+}
+
+// ssoLoginName is recorded in login attempts for identities without an account
+func ssoLoginName(identity *model.OIDCIdentity) string {
+	if identity.Email != "" {
+		return identity.Email
+	}
+	return "sso:" + identity.Subject
+}
+
+// resolveAdminSSOIdentity checks verified ID token claims against the admin
+// SSO settings and returns the identity with the role it maps to
+func resolveAdminSSOIdentity(settings *model.AdminSSOSettings, provider *model.OIDCProvider, claims map[string]interface{}) (*model.OIDCIdentity, string, error) {
+	identity := oidcIdentityFromClaims(claims, provider.GroupsClaim)
+	if identity.Subject == "" {
+		return nil, "", ErrOIDCAccessDenied
+	}
+
+	policy := &model.OIDCHostGate{AllowedEmailDomains: settings.AllowedEmailDomains}
+	if err := EvaluateOIDCPolicy(policy, identity, claims); err != nil {
+		return identity, "", err
+	}
+
+	roleClaim := settings.RoleClaim
+	if roleClaim == "" {
+		roleClaim = provider.GroupsClaim
+	}
+	if roleClaim == "" {
+		roleClaim = "groups"
+	}
+
+	role := mapAdminSSORole(settings, oidcClaimValues(claims[roleClaim]))
+	if role == "" {
+		return identity, "", ErrOIDCAccessDenied
+	}
+	return identity, role, nil
+}
+
+// mapAdminSSORole returns the most privileged role mapped from the claim
+// values, the default role when none match, or "" to deny the login
+func mapAdminSSORole(settings *model.AdminSSOSettings, values []string) string {
+	best := -1
+	for _, m := range settings.RoleMappings {
+		rank := roleRank(m.Role)
+		if rank < 0 || (best >= 0 && rank >= best) {
+			continue
+		}
+		for _, v := range values {
+			if v == m.ClaimValue {
+				best = rank
+				break
+			}
+		}
+	}
+	if best >= 0 {
+		return model.AllRoles[best]
+	}
+	return settings.DefaultRole
+}
+
+// roleRank is the position of a role in model.AllRoles, lower is more privileged
+func roleRank(role string) int {
+	for i, r := range model.AllRoles {
+		if r == role {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+
+	"nginx-proxy-guard/internal/model"
+)
+
+func TestAdminSSOLoginFlow(t *testing.T) {
+	issuer := newMockOIDCIssuer(t)
+	issuer.claims = map[string]interface{}{
+		"email":              "alice@example.com",
+		"email_verified":     true,
+		"preferred_username": "alice",
+		"groups":             []string{"staff", "npg-admins"},
+	}
+
+	s := NewOIDCGateService(nil, "test-secret")
+	provider := &model.OIDCProvider{IssuerURL: issuer.server.URL, ClientID: "npg", ClientSecret: "secret", Enabled: true}
+	settings := &model.AdminSSOSettings{
+		Enabled:             true,
+		AllowedEmailDomains: []string{"example.com"},
+		RoleMappings: []model.AdminSSORoleMapping{
+			{ClaimValue: "staff", Role: model.RoleViewer},
+			{ClaimValue: "npg-admins", Role: model.RoleAdmin},
+		},
+	}
+	ctx := context.Background()
+
+	start, err := s.authorize(ctx, provider, oidcPurposeAdminState, "", "https://npg.example.com/api/v1/auth/sso/callback", "")
+	if err != nil {
+		t.Fatalf("authorize() error = %v", err)
+	}
+	authURL, err := url.Parse(start.AuthorizationURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := authURL.Query()
+	issuer.nonce = query.Get("nonce")
+
+	// A login started for the admin UI can't complete a login gate callback
+	if _, err := s.readLoginState(oidcPurposeState, start.StateCookie, query.Get("state")); !errors.Is(err, ErrOIDCInvalidState) {
+		t.Fatalf("admin state accepted by the login gate: %v", err)
+	}
+	ls, err := s.readLoginState(oidcPurposeAdminState, start.StateCookie, query.Get("state"))
+	if err != nil {
+		t.Fatalf("readLoginState() error = %v", err)
+	}
+
+	claims, err := s.authenticate(ctx, provider, ls, "good-code")
+	if err != nil {
+		t.Fatalf("authenticate() error = %v", err)
+	}
+	identity, role, err := resolveAdminSSOIdentity(settings, provider, claims)
+	if err != nil {
+		t.Fatalf("resolveAdminSSOIdentity() error = %v", err)
+	}
+	if identity.Subject != "user-1" || role != model.RoleAdmin {
+		t.Fatalf("identity = %+v, role = %q, want user-1 as admin", identity, role)
+	}
+	if got := ssoUsername(identity); got != "alice" {
+		t.Errorf("ssoUsername() = %q, want alice", got)
+	}
+
+	// Users without a mapped group are denied unless a default role is set
+	issuer.claims["groups"] = []string{"contractors"}
+	claims, err = s.authenticate(ctx, provider, ls, "good-code")
+	if err != nil {
+		t.Fatalf("authenticate() error = %v", err)
+	}
+	if _, _, err := resolveAdminSSOIdentity(settings, provider, claims); !errors.Is(err, ErrOIDCAccessDenied) {
+		t.Fatalf("unmapped user error = %v, want ErrOIDCAccessDenied", err)
+	}
+	settings.DefaultRole = model.RoleViewer
+	if _, role, err := resolveAdminSSOIdentity(settings, provider, claims); err != nil || role != model.RoleViewer {
+		t.Fatalf("default role = %q, %v, want viewer", role, err)
+	}
+
+	// Email domains outside the allow list are denied
+	issuer.claims["email"] = "mallory@other.example"
+	claims, err = s.authenticate(ctx, provider, ls, "good-code")
+	if err != nil {
+		t.Fatalf("authenticate() error = %v", err)
+	}
+	if _, _, err := resolveAdminSSOIdentity(settings, provider, claims); !errors.Is(err, ErrOIDCAccessDenied) {
+		t.Fatalf("other domain error = %v, want ErrOIDCAccessDenied", err)
+	}
+}
+
+func TestMapAdminSSORole(t *testing.T) {
+	settings := &model.AdminSSOSettings{
+		RoleMappings: []model.AdminSSORoleMapping{
+			{ClaimValue: "ops", Role: model.RoleOperator},
+			{ClaimValue: "soc", Role: model.RoleSecurityAnalyst},
+			{ClaimValue: "everyone", Role: model.RoleViewer},
+		},
+	}
+
+	tests := []struct {
+		values []string
+		want   string
+	}{
+		{[]string{"everyone"}, model.RoleViewer},
+		{[]string{"everyone", "soc"}, model.RoleSecurityAnalyst},
+		{[]string{"soc", "ops", "everyone"}, model.RoleOperator},
+		{[]string{"finance"}, ""},
+		{nil, ""},
+	}
+	for _, tt := range tests {
+		if got := mapAdminSSORole(settings, tt.values); got != tt.want {
+			t.Errorf("mapAdminSSORole(%v) = %q, want %q", tt.values, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeAdminSSOSettings(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings model.AdminSSOSettings
+		field    string
+	}{
+		{"disabled", model.AdminSSOSettings{}, ""},
+		{"enabled", model.AdminSSOSettings{Enabled: true, ProviderID: "p1"}, ""},
+		{"no provider", model.AdminSSOSettings{Enabled: true}, "provider_id"},
+		{"bad default role", model.AdminSSOSettings{DefaultRole: "root"}, "default_role"},
+		{"bad mapping role", model.AdminSSOSettings{RoleMappings: []model.AdminSSORoleMapping{{ClaimValue: "ops", Role: "root"}}}, "role_mappings"},
+		{"empty claim value", model.AdminSSOSettings{RoleMappings: []model.AdminSSORoleMapping{{ClaimValue: " ", Role: model.RoleViewer}}}, "role_mappings"},
+		{"local login off without sso", model.AdminSSOSettings{DisableLocalLogin: true, BreakGlassUsername: "admin"}, "disable_local_login"},
+		{"no break-glass account", model.AdminSSOSettings{Enabled: true, ProviderID: "p1", DisableLocalLogin: true}, "break_glass_username"},
+		{"break-glass account", model.AdminSSOSettings{Enabled: true, ProviderID: "p1", DisableLocalLogin: true, BreakGlassUsername: "admin"}, ""},
+	}
+	for _, tt := range tests {
+		err := normalizeAdminSSOSettings(&tt.settings)
+		var uerr *UserError
+		switch {
+		case tt.field == "" && err != nil:
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+		case tt.field != "" && (!errors.As(err, &uerr) || uerr.Field != tt.field):
+			t.Errorf("%s: error = %v, want invalid %s", tt.name, err, tt.field)
+		}
+	}
+
+	settings := model.AdminSSOSettings{AllowedEmailDomains: []string{" @Example.COM ", ""}}
+	if err := normalizeAdminSSOSettings(&settings); err != nil || len(settings.AllowedEmailDomains) != 1 || settings.AllowedEmailDomains[0] != "example.com" {
+		t.Errorf("email domains normalized to %v, %v", settings.AllowedEmailDomains, err)
+	}
+}
+
+func TestSSOUsername(t *testing.T) {
+	tests := []struct {
+		identity model.OIDCIdentity
+		want     string
+	}{
+		{model.OIDCIdentity{Subject: "1", Name: "bob", Email: "bob@example.com"}, "bob"},
+		{model.OIDCIdentity{Subject: "1", Name: "Bob Smith", Email: "bob@example.com"}, "bob@example.com"},
+		{model.OIDCIdentity{Subject: "abc|123"}, "sso-abc-123"},
+		{model.OIDCIdentity{Subject: "1", Name: "b"}, "b--"},
+	}
+	for _, tt := range tests {
+		if got := ssoUsername(&tt.identity); got != tt.want {
+			t.Errorf("ssoUsername(%+v) = %q, want %q", tt.identity, got, tt.want)
+		}
+	}
+}
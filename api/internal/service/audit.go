@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"net/http"
+	"time"
 
 	"nginx-proxy-guard/internal/model"
 	"nginx-proxy-guard/internal/repository"
@@ -339,6 +340,16 @@ func (s *AuditService) LogUserLogin(ctx context.Context, username, ipAddress, us
 	})
 }
 
+// LogUserSSOLogin logs a login through the admin single sign-on
+func (s *AuditService) LogUserSSOLogin(ctx context.Context, username, provider, ipAddress, userAgent string, provisioned bool) error {
+	return s.logEntry(ctx, "user_sso_login", "user", "", username, map[string]interface{}{
+		"provider":    provider,
+		"provisioned": provisioned,
+		"ip_address":  ipAddress,
+		"user_agent":  userAgent,
+	})
+}
+
 // LogUserLogout logs user logout
 func (s *AuditService) LogUserLogout(ctx context.Context, username string) error {
 	return s.logEntry(ctx, "user_logout", "user", "", username, nil)
@@ -367,6 +378,161 @@ func (s *AuditService) Log2FADisabled(ctx context.Context, username string) erro
 	return s.logEntry(ctx, "totp_disabled", "user", "", username, nil)
 }
 
+// LogWebAuthnRegistered logs a security key or passkey being registered
+func (s *AuditService) LogWebAuthnRegistered(ctx context.Context, username, keyName string, hardwareKey bool) error {
+	return s.logEntry(ctx, "webauthn_registered", "user", "", username, map[string]interface{}{
+		"name":         keyName,
+		"hardware_key": hardwareKey,
+	})
+}
+
+// LogWebAuthnRemoved logs a security key or passkey being revoked
+func (s *AuditService) LogWebAuthnRemoved(ctx context.Context, username, keyName string) error {
+	return s.logEntry(ctx, "webauthn_removed", "user", "", username, map[string]interface{}{
+		"name": keyName,
+	})
+}
+
+// LogSessionRevoked logs a single login session being ended
+func (s *AuditService) LogSessionRevoked(ctx context.Context, userID, username string, session *model.AuthSession) error {
+	return s.logEntry(ctx, "session_revoked", "user", userID, username, map[string]interface{}{
+		"session_id": session.ID,
+		"ip_address": session.IPAddress,
+	})
+}
+
+// LogSessionsRevoked logs all sessions of a user being ended at once
+func (s *AuditService) LogSessionsRevoked(ctx context.Context, userID, username string, count int) error {
+	return s.logEntry(ctx, "sessions_revoked", "user", userID, username, map[string]interface{}{
+		"count": count,
+	})
+}
+
+// LogPasswordBreachRejected logs a new password refused because it is in the breached password list
+func (s *AuditService) LogPasswordBreachRejected(ctx context.Context, username string) error {
+	return s.logEntry(ctx, "password_breach_rejected", "user", "", username, nil)
+}
+
+// LogPasswordBreachOverride logs an admin setting a breached password despite the breach check
+func (s *AuditService) LogPasswordBreachOverride(ctx context.Context, userID, username string) error {
+	return s.logEntry(ctx, "password_breach_override", "user", userID, username, nil)
+}
+
+// LogLoginLockout logs a username or source IP being locked out after failed logins
+func (s *AuditService) LogLoginLockout(ctx context.Context, scope, value string, until time.Time) error {
+	return s.logEntry(ctx, "login_locked_out", "user", "", value, map[string]interface{}{
+		"scope": scope,
+		"until": until,
+	})
+}
+
+// LogAccountUnlocked logs an admin ending the lockout of a user
+func (s *AuditService) LogAccountUnlocked(ctx context.Context, userID, username string, clearedAttempts int64) error {
+	return s.logEntry(ctx, "account_unlocked", "user", userID, username, map[string]interface{}{
+		"cleared_attempts": clearedAttempts,
+	})
+}
+
+// LogBreachListUpdated logs the breached password list being uploaded or reset to the bundled one
+func (s *AuditService) LogBreachListUpdated(ctx context.Context, status *model.BreachListStatus) error {
+	return s.logEntry(ctx, "breach_list_updated", "settings", "", status.Name, map[string]interface{}{
+		"source":     status.Source,
+		"hash_count": status.HashCount,
+	})
+}
+
+// LogChangeRequested logs a high-risk change captured for approval instead of being applied
+func (s *AuditService) LogChangeRequested(ctx context.Context, cr *model.ChangeRequest) error {
+	return s.logEntry(ctx, "change_requested", "change_request", cr.ID, cr.TargetName, map[string]interface{}{
+		"change_action": cr.Action,
+		"target_id":     cr.TargetID,
+		"expires_at":    cr.ExpiresAt,
+	})
+}
+
+// LogChangeApproved logs a change request being approved and the outcome of applying it
+func (s *AuditService) LogChangeApproved(ctx context.Context, cr *model.ChangeRequest) error {
+	if err := s.logEntry(ctx, "change_approved", "change_request", cr.ID, cr.TargetName, changeReviewDetails(cr)); err != nil {
+		return err
+	}
+	action := "change_applied"
+	if cr.Status == model.ChangeStatusFailed {
+		action = "change_failed"
+	}
+	details := map[string]interface{}{
+		"change_action": cr.Action,
+		"requested_by":  cr.RequestedByName,
+	}
+	if cr.ErrorMessage != "" {
+		details["error"] = cr.ErrorMessage
+	}
+	return s.logEntry(ctx, action, "change_request", cr.ID, cr.TargetName, details)
+}
+
+// LogChangeRejected logs a change request being rejected by a reviewer
+func (s *AuditService) LogChangeRejected(ctx context.Context, cr *model.ChangeRequest) error {
+	return s.logEntry(ctx, "change_rejected", "change_request", cr.ID, cr.TargetName, changeReviewDetails(cr))
+}
+
+// LogChangeCancelled logs a change request being withdrawn by its requester
+func (s *AuditService) LogChangeCancelled(ctx context.Context, cr *model.ChangeRequest) error {
+	return s.logEntry(ctx, "change_cancelled", "change_request", cr.ID, cr.TargetName, changeReviewDetails(cr))
+}
+
+// LogChangeExpired logs a change request that expired without review
+func (s *AuditService) LogChangeExpired(ctx context.Context, cr *model.ChangeRequest) error {
+	return s.logEntry(ctx, "change_expired", "change_request", cr.ID, cr.TargetName, map[string]interface{}{
+		"change_action": cr.Action,
+		"requested_by":  cr.RequestedByName,
+	})
+}
+
+func changeReviewDetails(cr *model.ChangeRequest) map[string]interface{} {
+	details := map[string]interface{}{
+		"change_action": cr.Action,
+		"requested_by":  cr.RequestedByName,
+	}
+	if cr.ReviewComment != "" {
+		details["comment"] = cr.ReviewComment
+	}
+	return details
+}
+
+// LogUserCreated logs user creation (invited or with a password)
+func (s *AuditService) LogUserCreated(ctx context.Context, userID, username, role string, invited bool) error {
+	return s.logEntry(ctx, "user_created", "user", userID, username, map[string]interface{}{
+		"role":    role,
+		"invited": invited,
+	})
+}
+
+// LogUserRoleChanged logs a role change
+func (s *AuditService) LogUserRoleChanged(ctx context.Context, userID, username, oldRole, newRole string) error {
+	return s.logEntry(ctx, "user_role_changed", "user", userID, username, map[string]interface{}{
+		"old_role": oldRole,
+		"new_role": newRole,
+	})
+}
+
+// LogUserDisabled logs a user being disabled or re-enabled
+func (s *AuditService) LogUserDisabled(ctx context.Context, userID, username string, disabled bool) error {
+	action := "user_enabled"
+	if disabled {
+		action = "user_disabled"
+	}
+	return s.logEntry(ctx, action, "user", userID, username, nil)
+}
+
+// LogUser2FAReset logs an administrator resetting another user's 2FA
+func (s *AuditService) LogUser2FAReset(ctx context.Context, userID, username string) error {
+	return s.logEntry(ctx, "user_totp_reset", "user", userID, username, nil)
+}
+
+// LogUserDeleted logs user deletion
+func (s *AuditService) LogUserDeleted(ctx context.Context, userID, username string) error {
+	return s.logEntry(ctx, "user_deleted", "user", userID, username, nil)
+}
+
 // LogAccessListCreated logs access list creation
 func (s *AuditService) LogAccessListCreated(ctx context.Context, name string) error {
 	return s.logEntry(ctx, "access_list_created", "access_list", "", name, nil)
@@ -6,6 +6,7 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"errors"
+	"log"
 	"sync"
 	"time"
 
@@ -29,6 +30,11 @@ var (
 	Err2FANotEnabled      = errors.New("2FA is not enabled")
 	Err2FAAlreadyEnabled  = errors.New("2FA is already enabled")
 	ErrInvalidTempToken   = errors.New("invalid or expired temporary token")
+	ErrAccountDisabled    = errors.New("account is disabled")
+
+	// ErrPasswordExpired comes with the user of a session issued while the
+	// password was expired: it may only be used to change the password
+	ErrPasswordExpired = errors.New("password expired, change it to continue")
 )
 
 const (
@@ -40,6 +46,8 @@ const (
 	tokenLength = 32
 	// Session duration
 	sessionDuration = 24 * time.Hour
+	// How often a session's last seen time is written back
+	sessionTouchInterval = time.Minute
 	// Temp token duration for 2FA
 	tempTokenDuration = 5 * time.Minute
 	// Number of backup codes
@@ -52,6 +60,8 @@ type tempTokenData struct {
 	ip        string
 	userAgent string
 	expiresAt time.Time
+	// Only a hardware security key may complete the login
+	securityKeyOnly bool
 }
 
 type AuthService struct {
@@ -61,6 +71,41 @@ type AuthService struct {
 	tokenMu     sync.RWMutex
 	redisCache  *cache.RedisClient
 	stopCleanup chan struct{}
+
+	localLoginPolicy LocalLoginPolicy
+	directory        DirectoryAuthenticator
+	secondFactor     SecondFactorPolicy
+	sessionPolicy    SessionPolicy
+	passwordRules    PasswordRules
+}
+
+// DirectoryAuthenticator verifies the passwords of users kept in an external
+// directory. It returns a nil user when no directory is configured.
+type DirectoryAuthenticator interface {
+	Authenticate(ctx context.Context, username, password string, existing *model.User) (*model.User, error)
+}
+
+// SecondFactorPolicy adds security keys to the second factors of a login. It
+// reports whether the user can verify with a security key and whether TOTP is
+// still accepted, or ErrSecurityKeyRequired if the user lacks a required key.
+type SecondFactorPolicy interface {
+	SecondFactors(ctx context.Context, user *model.User) (securityKey, totpAllowed bool, err error)
+}
+
+// SessionPolicy limits how long sessions stay valid. A zero idle timeout
+// disables it and a zero lifetime keeps the default session duration.
+type SessionPolicy interface {
+	SessionLimits(ctx context.Context) (idleTimeout, lifetime time.Duration)
+}
+
+// PasswordRules validates new passwords and locks out logins after failures.
+// CheckPassword reports whether the password is a known breached password and
+// refuses it with ErrBreachedPassword unless allowBreached is set.
+type PasswordRules interface {
+	CheckPassword(ctx context.Context, user *model.User, password string, allowBreached bool) (breached bool, err error)
+	PasswordChanged(ctx context.Context, userID, passwordHash string) error
+	PasswordExpired(ctx context.Context, user *model.User) bool
+	LoginLockout(ctx context.Context, ip, username string) error
 }
 
 func NewAuthService(repo *repository.AuthRepository, jwtSecret string) *AuthService {
@@ -121,15 +166,73 @@ func (s *AuthService) SetCache(redisCache *cache.RedisClient) {
 	s.redisCache = redisCache
 }
 
+// SetLocalLoginPolicy sets the policy that may refuse password logins
+func (s *AuthService) SetLocalLoginPolicy(policy LocalLoginPolicy) {
+	s.localLoginPolicy = policy
+}
+
+// SetDirectoryAuthenticator sets the directory that unknown users and directory users log in against
+func (s *AuthService) SetDirectoryAuthenticator(directory DirectoryAuthenticator) {
+	s.directory = directory
+}
+
+// SetSecondFactorPolicy sets the policy that offers security keys as second factor
+func (s *AuthService) SetSecondFactorPolicy(policy SecondFactorPolicy) {
+	s.secondFactor = policy
+}
+
+// SetSessionPolicy sets the policy that limits session idle time and lifetime
+func (s *AuthService) SetSessionPolicy(policy SessionPolicy) {
+	s.sessionPolicy = policy
+}
+
+// SetPasswordRules sets the password policy and login lockout
+func (s *AuthService) SetPasswordRules(rules PasswordRules) {
+	s.passwordRules = rules
+}
+
+// sessionLimits returns the idle timeout and absolute lifetime of sessions
+func (s *AuthService) sessionLimits(ctx context.Context) (idleTimeout, lifetime time.Duration) {
+	if s.sessionPolicy != nil {
+		idleTimeout, lifetime = s.sessionPolicy.SessionLimits(ctx)
+	}
+	if lifetime <= 0 {
+		lifetime = sessionDuration
+	}
+	return idleTimeout, lifetime
+}
+
+// checkLoginAttempts refuses logins from an IP or for a username with too
+// many recent failures. Username may be empty when it isn't known yet.
+func (s *AuthService) checkLoginAttempts(ctx context.Context, ip, username string) error {
+	if s.passwordRules != nil {
+		return s.passwordRules.LoginLockout(ctx, ip, username)
+	}
+
+	failedCount, err := s.repo.CountRecentFailedAttempts(ctx, ip, time.Now().Add(-lockoutWindow))
+	if err != nil {
+		return err
+	}
+	if failedCount >= maxFailedAttempts {
+		return ErrTooManyAttempts
+	}
+	return nil
+}
+
+// localLoginAllowed checks the local login policy, if any
+func (s *AuthService) localLoginAllowed(ctx context.Context, username string) bool {
+	return s.localLoginPolicy == nil || s.localLoginPolicy.LocalLoginAllowed(ctx, username)
+}
+
 // Login authenticates a user and returns a session token or requires 2FA
 func (s *AuthService) Login(ctx context.Context, req *model.LoginRequest, ip, userAgent string) (*model.LoginResponse, error) {
 	// Check for too many failed attempts
-	failedCount, err := s.repo.CountRecentFailedAttempts(ctx, ip, time.Now().Add(-lockoutWindow))
-	if err != nil {
+	if err := s.checkLoginAttempts(ctx, ip, req.Username); err != nil {
 		return nil, err
 	}
-	if failedCount >= maxFailedAttempts {
-		return nil, ErrTooManyAttempts
+
+	if !s.localLoginAllowed(ctx, req.Username) {
+		return nil, ErrLocalLoginDisabled
 	}
 
 	// Get user
@@ -137,62 +240,129 @@ func (s *AuthService) Login(ctx context.Context, req *model.LoginRequest, ip, us
 	if err != nil {
 		return nil, err
 	}
-	if user == nil {
+
+	if user == nil || user.LDAPDN != "" {
+		// Unknown users and directory users log in against the directory
+		user, err = s.authenticateDirectory(ctx, req.Username, req.Password, user)
+		if err != nil {
+			if errors.Is(err, ErrInvalidCredentials) || errors.Is(err, ErrLDAPAccessDenied) {
+				s.repo.RecordLoginAttempt(ctx, ip, req.Username, false)
+			}
+			return nil, err
+		}
+	} else if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+		// Verify password
 		s.repo.RecordLoginAttempt(ctx, ip, req.Username, false)
 		return nil, ErrInvalidCredentials
 	}
 
-	// Verify password
-	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+	if user.Disabled {
 		s.repo.RecordLoginAttempt(ctx, ip, req.Username, false)
-		return nil, ErrInvalidCredentials
+		return nil, ErrAccountDisabled
 	}
 
+	// Security keys may be offered next to TOTP, or required instead of it
+	securityKey, totpAllowed := false, true
+	if s.secondFactor != nil {
+		securityKey, totpAllowed, err = s.secondFactor.SecondFactors(ctx, user)
+		if err != nil {
+			return nil, err
+		}
+	}
+	totp := user.TOTPEnabled && totpAllowed
+
 	// Check if 2FA is enabled
-	if user.TOTPEnabled {
+	if totp && req.TOTPCode != "" {
 		// If TOTP code provided, verify it
-		if req.TOTPCode != "" {
-			if !s.verify2FACode(user, req.TOTPCode) {
-				s.repo.RecordLoginAttempt(ctx, ip, req.Username, false)
-				return nil, ErrInvalid2FACode
-			}
-		} else {
-			// Generate temporary token for 2FA verification
-			tempToken, err := generateToken(tokenLength)
-			if err != nil {
-				return nil, err
-			}
+		if !s.verify2FACode(user, req.TOTPCode) {
+			s.repo.RecordLoginAttempt(ctx, ip, req.Username, false)
+			return nil, ErrInvalid2FACode
+		}
+	} else if totp || securityKey {
+		// Generate temporary token for 2FA verification
+		tempToken, err := generateToken(tokenLength)
+		if err != nil {
+			return nil, err
+		}
 
-			s.tokenMu.Lock()
-			s.tempTokens[tempToken] = &tempTokenData{
-				userID:    user.ID,
-				ip:        ip,
-				userAgent: userAgent,
-				expiresAt: time.Now().Add(tempTokenDuration),
-			}
-			s.tokenMu.Unlock()
+		s.tokenMu.Lock()
+		s.tempTokens[tempToken] = &tempTokenData{
+			userID:          user.ID,
+			ip:              ip,
+			userAgent:       userAgent,
+			expiresAt:       time.Now().Add(tempTokenDuration),
+			securityKeyOnly: !totpAllowed,
+		}
+		s.tokenMu.Unlock()
 
-			return &model.LoginResponse{
-				Requires2FA: true,
-				TempToken:   tempToken,
-			}, nil
+		var methods []string
+		if totp {
+			methods = append(methods, model.TwoFactorTOTP)
 		}
+		if securityKey {
+			methods = append(methods, model.TwoFactorWebAuthn)
+		}
+		return &model.LoginResponse{
+			Requires2FA:      true,
+			TempToken:        tempToken,
+			TwoFactorMethods: methods,
+		}, nil
 	}
 
 	// Create full session
 	return s.createSession(ctx, user, ip, userAgent)
 }
 
+// authenticateDirectory checks a password with the directory authenticator
+func (s *AuthService) authenticateDirectory(ctx context.Context, username, password string, existing *model.User) (*model.User, error) {
+	if s.directory == nil {
+		return nil, ErrInvalidCredentials
+	}
+	user, err := s.directory.Authenticate(ctx, username, password, existing)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrInvalidCredentials
+	}
+	return user, nil
+}
+
 // Verify2FA completes login with 2FA code
 func (s *AuthService) Verify2FA(ctx context.Context, req *model.Verify2FARequest, ip string) (*model.LoginResponse, error) {
-	// Get temp token data
+	return s.completeTwoFactor(ctx, req.TempToken, func(user *model.User, data *tempTokenData) error {
+		if data.securityKeyOnly {
+			return ErrSecurityKeyRequired
+		}
+		// Verify 2FA code
+		if !user.TOTPEnabled || !s.verify2FACode(user, req.TOTPCode) {
+			s.repo.RecordLoginAttempt(ctx, ip, user.Username, false)
+			return ErrInvalid2FACode
+		}
+		return nil
+	})
+}
+
+// pendingLogin returns the login waiting on the second factor for a temp token
+func (s *AuthService) pendingLogin(tempToken string) (*tempTokenData, error) {
 	s.tokenMu.RLock()
-	data, exists := s.tempTokens[req.TempToken]
+	data, exists := s.tempTokens[tempToken]
 	s.tokenMu.RUnlock()
 
 	if !exists || time.Now().After(data.expiresAt) {
 		return nil, ErrInvalidTempToken
 	}
+	return data, nil
+}
+
+// completeTwoFactor creates the session of a login waiting on its second
+// factor once verify accepts it
+func (s *AuthService) completeTwoFactor(ctx context.Context, tempToken string, verify func(user *model.User, data *tempTokenData) error) (*model.LoginResponse, error) {
+	// Get temp token data
+	data, err := s.pendingLogin(tempToken)
+	if err != nil {
+		return nil, err
+	}
 
 	// Get user
 	user, err := s.repo.GetUserByID(ctx, data.userID)
@@ -202,16 +372,20 @@ func (s *AuthService) Verify2FA(ctx context.Context, req *model.Verify2FARequest
 	if user == nil {
 		return nil, ErrUnauthorized
 	}
+	if user.Disabled {
+		return nil, ErrAccountDisabled
+	}
+	if err := s.checkLoginAttempts(ctx, data.ip, user.Username); err != nil {
+		return nil, err
+	}
 
-	// Verify 2FA code
-	if !s.verify2FACode(user, req.TOTPCode) {
-		s.repo.RecordLoginAttempt(ctx, ip, user.Username, false)
-		return nil, ErrInvalid2FACode
+	if err := verify(user, data); err != nil {
+		return nil, err
 	}
 
 	// Remove temp token
 	s.tokenMu.Lock()
-	delete(s.tempTokens, req.TempToken)
+	delete(s.tempTokens, tempToken)
 	s.tokenMu.Unlock()
 
 	// Create full session
@@ -247,13 +421,15 @@ func (s *AuthService) createSession(ctx context.Context, user *model.User, ip, u
 	// Hash token for storage
 	tokenHash := hashToken(token)
 
-	// Create session
+	// Create session, restricted to changing the password while it is expired
+	_, lifetime := s.sessionLimits(ctx)
 	session := &model.AuthSession{
-		UserID:    user.ID,
-		TokenHash: tokenHash,
-		IPAddress: ip,
-		UserAgent: userAgent,
-		ExpiresAt: time.Now().Add(sessionDuration),
+		UserID:          user.ID,
+		TokenHash:       tokenHash,
+		IPAddress:       ip,
+		UserAgent:       userAgent,
+		ExpiresAt:       time.Now().Add(lifetime),
+		PasswordExpired: s.passwordExpired(ctx, user),
 	}
 
 	if err := s.repo.CreateSession(ctx, session); err != nil {
@@ -265,12 +441,40 @@ func (s *AuthService) createSession(ctx context.Context, user *model.User, ip, u
 	s.repo.UpdateUserLogin(ctx, user.ID, ip)
 
 	return &model.LoginResponse{
-		Token:          token,
-		User:           user,
-		IsInitialSetup: user.IsInitialSetup,
+		Token:           token,
+		User:            user,
+		IsInitialSetup:  user.IsInitialSetup,
+		PasswordExpired: session.PasswordExpired,
 	}, nil
 }
 
+// passwordExpired reports whether the password rules require a new password
+func (s *AuthService) passwordExpired(ctx context.Context, user *model.User) bool {
+	return s.passwordRules != nil && !user.IsInitialSetup && s.passwordRules.PasswordExpired(ctx, user)
+}
+
+// checkNewPassword validates a new password with the password rules, or only
+// its length when there are none
+func checkNewPassword(ctx context.Context, rules PasswordRules, user *model.User, password string, allowBreached bool) (bool, error) {
+	if rules == nil {
+		if len(password) < 8 {
+			return false, ErrWeakPassword
+		}
+		return false, nil
+	}
+	return rules.CheckPassword(ctx, user, password, allowBreached)
+}
+
+// passwordChanged records a new password with the password rules, if any
+func passwordChanged(ctx context.Context, rules PasswordRules, userID, passwordHash string) {
+	if rules == nil {
+		return
+	}
+	if err := rules.PasswordChanged(ctx, userID, passwordHash); err != nil {
+		log.Printf("[Auth] Failed to record password change: %v", err)
+	}
+}
+
 // Setup2FA initiates 2FA setup and returns secret + QR code URL
 func (s *AuthService) Setup2FA(ctx context.Context, userID string) (*model.Setup2FAResponse, error) {
 	user, err := s.repo.GetUserByID(ctx, userID)
@@ -373,15 +577,34 @@ func (s *AuthService) Logout(ctx context.Context, token string) error {
 
 	// Get session to find expiry time for blacklist
 	session, err := s.repo.GetSessionByTokenHash(ctx, tokenHash)
-	if err == nil && session != nil && s.redisCache != nil {
-		// Add to blacklist until original expiry
-		s.redisCache.BlacklistJWTToken(ctx, tokenHash, session.ExpiresAt)
+	if err == nil && session != nil {
+		return s.revokeSession(ctx, session)
 	}
 
 	return s.repo.DeleteSession(ctx, tokenHash)
 }
 
-// ValidateToken checks if a token is valid and returns the user
+// revokeSession ends a session at once: its token is blacklisted until the
+// original expiry so no cached validation outlives the deleted row
+func (s *AuthService) revokeSession(ctx context.Context, session *model.AuthSession) error {
+	if s.redisCache != nil {
+		s.redisCache.BlacklistJWTToken(ctx, session.TokenHash, session.ExpiresAt)
+	}
+	return s.repo.DeleteSession(ctx, session.TokenHash)
+}
+
+// sessionExpired reports whether a session ran past the idle timeout or the
+// absolute lifetime, which may have been shortened since it was created
+func sessionExpired(session *model.AuthSession, idleTimeout, lifetime time.Duration, now time.Time) bool {
+	if idleTimeout > 0 && now.Sub(session.LastSeenAt) > idleTimeout {
+		return true
+	}
+	return now.Sub(session.CreatedAt) > lifetime
+}
+
+// ValidateToken checks if a token is valid and returns the user. For a
+// session restricted to changing the password it returns the user together
+// with ErrPasswordExpired.
 func (s *AuthService) ValidateToken(ctx context.Context, token string) (*model.User, error) {
 	tokenHash := hashToken(token)
 
@@ -400,13 +623,30 @@ func (s *AuthService) ValidateToken(ctx context.Context, token string) (*model.U
 		return nil, ErrSessionExpired
 	}
 
+	now := time.Now()
+	idleTimeout, lifetime := s.sessionLimits(ctx)
+	if sessionExpired(session, idleTimeout, lifetime, now) {
+		if err := s.revokeSession(ctx, session); err != nil {
+			log.Printf("[Auth] Failed to revoke expired session %s: %v", session.ID, err)
+		}
+		return nil, ErrSessionExpired
+	}
+	if now.Sub(session.LastSeenAt) > sessionTouchInterval {
+		if err := s.repo.TouchSession(ctx, session.ID); err != nil {
+			log.Printf("[Auth] Failed to update session last seen: %v", err)
+		}
+	}
+
 	user, err := s.repo.GetUserByID(ctx, session.UserID)
 	if err != nil {
 		return nil, err
 	}
-	if user == nil {
+	if user == nil || user.Disabled {
 		return nil, ErrUnauthorized
 	}
+	if session.PasswordExpired {
+		return user, ErrPasswordExpired
+	}
 
 	return user, nil
 }
@@ -428,12 +668,12 @@ func (s *AuthService) ChangeCredentials(ctx context.Context, userID string, req
 	}
 
 	// Validate new password
-	if len(req.NewPassword) < 8 {
-		return ErrWeakPassword
-	}
 	if req.NewPassword != req.NewPasswordConfirm {
 		return ErrPasswordMismatch
 	}
+	if _, err := checkNewPassword(ctx, s.passwordRules, user, req.NewPassword, false); err != nil {
+		return err
+	}
 
 	// Check if new username is available
 	if req.NewUsername != "" && req.NewUsername != user.Username {
@@ -461,6 +701,7 @@ func (s *AuthService) ChangeCredentials(ctx context.Context, userID string, req
 	if err := s.repo.UpdateUserCredentials(ctx, userID, newUsername, string(hashedPassword)); err != nil {
 		return err
 	}
+	passwordChanged(ctx, s.passwordRules, userID, string(hashedPassword))
 
 	// Invalidate all existing sessions (force re-login with new credentials)
 	return s.repo.DeleteUserSessions(ctx, userID)
@@ -482,12 +723,12 @@ func (s *AuthService) ChangePassword(ctx context.Context, userID string, req *mo
 	}
 
 	// Validate new password
-	if len(req.NewPassword) < 8 {
-		return ErrWeakPassword
-	}
 	if req.NewPassword != req.NewPasswordConfirm {
 		return ErrPasswordMismatch
 	}
+	if _, err := checkNewPassword(ctx, s.passwordRules, user, req.NewPassword, false); err != nil {
+		return err
+	}
 
 	// Hash new password
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
@@ -495,7 +736,11 @@ func (s *AuthService) ChangePassword(ctx context.Context, userID string, req *mo
 		return err
 	}
 
-	return s.repo.UpdatePassword(ctx, userID, string(hashedPassword))
+	if err := s.repo.UpdatePassword(ctx, userID, string(hashedPassword)); err != nil {
+		return err
+	}
+	passwordChanged(ctx, s.passwordRules, userID, string(hashedPassword))
+	return s.repo.LiftPasswordExpired(ctx, userID)
 }
 
 // GetAuthStatus returns the current auth status
@@ -513,6 +758,14 @@ func (s *AuthService) GetAuthStatus(ctx context.Context, token string) (*model.A
 	}
 
 	user, err := s.ValidateToken(ctx, token)
+	if errors.Is(err, ErrPasswordExpired) {
+		return &model.AuthStatus{
+			Authenticated:   true,
+			IsInitialSetup:  user.IsInitialSetup,
+			PasswordExpired: true,
+			User:            user,
+		}, nil
+	}
 	if err != nil {
 		isInitialSetup, _ := s.repo.IsInitialSetupRequired(ctx)
 		return &model.AuthStatus{
@@ -522,9 +775,10 @@ func (s *AuthService) GetAuthStatus(ctx context.Context, token string) (*model.A
 	}
 
 	return &model.AuthStatus{
-		Authenticated:  true,
-		IsInitialSetup: user.IsInitialSetup,
-		User:           user,
+		Authenticated:   true,
+		IsInitialSetup:  user.IsInitialSetup,
+		PasswordExpired: s.passwordExpired(ctx, user),
+		User:            user,
 	}, nil
 }
 
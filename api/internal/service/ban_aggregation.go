@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"time"
+
+	"nginx-proxy-guard/internal/model"
+	"nginx-proxy-guard/internal/repository"
+	"nginx-proxy-guard/pkg/cache"
+)
+
+// BanAggregationService replaces many auto-bans from the same IPv4/IPv6 prefix with a single
+// CIDR ban (shared by WAF auto-ban and Fail2ban)
+type BanAggregationService struct {
+	rateLimitRepo      *repository.RateLimitRepository
+	systemSettingsRepo *repository.SystemSettingsRepository
+	historyRepo        *repository.IPBanHistoryRepository
+	redisCache         *cache.RedisClient
+}
+
+func NewBanAggregationService(
+	rateLimitRepo *repository.RateLimitRepository,
+	systemSettingsRepo *repository.SystemSettingsRepository,
+	historyRepo *repository.IPBanHistoryRepository,
+	redisCache *cache.RedisClient,
+) *BanAggregationService {
+	return &BanAggregationService{
+		rateLimitRepo:      rateLimitRepo,
+		systemSettingsRepo: systemSettingsRepo,
+		historyRepo:        historyRepo,
+		redisCache:         redisCache,
+	}
+}
+
+// CoveringSubnet returns the active CIDR ban that already covers ip in the given scope, or ""
+func (s *BanAggregationService) CoveringSubnet(ctx context.Context, ip string, proxyHostID *string) string {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return ""
+	}
+
+	cidrs, err := s.rateLimitRepo.ListActiveSubnetBans(ctx, proxyHostID)
+	if err != nil {
+		log.Printf("[BanAggregation] Failed to list subnet bans: %v", err)
+		return ""
+	}
+	for _, cidr := range cidrs {
+		if _, ipNet, err := net.ParseCIDR(cidr); err == nil && ipNet.Contains(addr) {
+			return cidr
+		}
+	}
+	return ""
+}
+
+// Aggregate checks the prefix of a freshly banned ip and, once the configured number of
+// addresses from that prefix were banned within the window, replaces them with one CIDR ban.
+// Returns the CIDR ban, or nil when nothing was aggregated.
+func (s *BanAggregationService) Aggregate(ctx context.Context, ip string, proxyHostID *string, source string) (*model.BannedIP, error) {
+	settings, err := s.systemSettingsRepo.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !settings.BanSubnetAggregationEnabled {
+		return nil, nil
+	}
+
+	subnet, err := SubnetForIP(ip, settings.BanSubnetIPv4Prefix, settings.BanSubnetIPv6Prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	since := time.Now().Add(-time.Duration(settings.BanSubnetWindow) * time.Second)
+	bans, err := s.rateLimitRepo.ListRecentAutoBans(ctx, proxyHostID, since)
+	if err != nil {
+		return nil, err
+	}
+
+	members := SubnetMembers(bans, subnet)
+	if len(members) < settings.BanSubnetThreshold {
+		return nil, nil
+	}
+
+	cidr := subnet.String()
+	memberIDs := make([]string, len(members))
+	memberIPs := make([]string, len(members))
+	failCount := 0
+	var expiresAt *time.Time
+	permanent := false
+	for i, m := range members {
+		memberIDs[i] = m.ID
+		memberIPs[i] = m.IPAddress
+		failCount += m.FailCount
+		if m.IsPermanent || m.ExpiresAt == nil {
+			permanent = true
+		} else if expiresAt == nil || m.ExpiresAt.After(*expiresAt) {
+			expiresAt = m.ExpiresAt
+		}
+	}
+	// The subnet ban lasts as long as the longest member ban
+	if permanent {
+		expiresAt = nil
+	}
+
+	reason := fmt.Sprintf("Subnet aggregation: %d addresses from %s banned within %s",
+		len(members), cidr, FormatBanDuration(settings.BanSubnetWindow))
+
+	banned, err := s.rateLimitRepo.ReplaceWithSubnetBan(ctx, proxyHostID, cidr, reason, failCount, expiresAt, memberIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	hostID := ""
+	if proxyHostID != nil {
+		hostID = *proxyHostID
+	}
+	if s.redisCache != nil && s.redisCache.IsReady() {
+		for _, memberIP := range memberIPs {
+			if err := s.redisCache.RemoveBannedIP(ctx, memberIP, hostID); err != nil {
+				log.Printf("[BanAggregation] Warning: Failed to remove %s from cache: %v", memberIP, err)
+			}
+		}
+	}
+
+	s.recordHistory(ctx, banned, source, memberIPs)
+
+	log.Printf("[BanAggregation] Replaced %d bans with %s", len(members), cidr)
+	return banned, nil
+}
+
+// recordHistory records the subnet ban and the removal of the individual bans it replaced
+func (s *BanAggregationService) recordHistory(ctx context.Context, banned *model.BannedIP, source string, memberIPs []string) {
+	if s.historyRepo == nil {
+		return
+	}
+
+	duration := int(banRemaining(banned).Seconds())
+	failCount := banned.FailCount
+	if err := s.historyRepo.RecordBanEvent(ctx, &model.IPBanHistory{
+		EventType:   model.BanEventTypeBan,
+		IPAddress:   banned.IPAddress,
+		ProxyHostID: banned.ProxyHostID,
+		Reason:      banned.Reason,
+		Source:      source,
+		BanDuration: &duration,
+		ExpiresAt:   banned.ExpiresAt,
+		IsPermanent: banned.IsPermanent,
+		IsAuto:      true,
+		FailCount:   &failCount,
+		Metadata:    map[string]interface{}{"aggregated_ips": memberIPs},
+	}); err != nil {
+		log.Printf("[BanAggregation] Warning: Failed to record ban history: %v", err)
+	}
+
+	for _, memberIP := range memberIPs {
+		if err := s.historyRepo.RecordBanEvent(ctx, &model.IPBanHistory{
+			EventType:   model.BanEventTypeUnban,
+			IPAddress:   memberIP,
+			ProxyHostID: banned.ProxyHostID,
+			Reason:      "Replaced by subnet ban " + banned.IPAddress,
+			Source:      model.BanSourceSubnetAggregation,
+			IsAuto:      true,
+		}); err != nil {
+			log.Printf("[BanAggregation] Warning: Failed to record unban history: %v", err)
+		}
+	}
+}
+
+// banRemaining returns the remaining duration of a ban (0 = permanent)
+func banRemaining(b *model.BannedIP) time.Duration {
+	if b.IsPermanent || b.ExpiresAt == nil {
+		return 0
+	}
+	return time.Until(*b.ExpiresAt)
+}
+
+// SubnetForIP returns the network of ip masked to the IPv4 or IPv6 prefix length
+func SubnetForIP(ip string, ipv4Prefix, ipv6Prefix int) (*net.IPNet, error) {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return nil, fmt.Errorf("invalid IP address: %s", ip)
+	}
+	if v4 := addr.To4(); v4 != nil {
+		mask := net.CIDRMask(ipv4Prefix, 32)
+		if mask == nil {
+			return nil, fmt.Errorf("invalid IPv4 prefix length: %d", ipv4Prefix)
+		}
+		return &net.IPNet{IP: v4.Mask(mask), Mask: mask}, nil
+	}
+	mask := net.CIDRMask(ipv6Prefix, 128)
+	if mask == nil {
+		return nil, fmt.Errorf("invalid IPv6 prefix length: %d", ipv6Prefix)
+	}
+	return &net.IPNet{IP: addr.Mask(mask), Mask: mask}, nil
+}
+
+// SubnetMembers returns the bans whose address lies within subnet
+func SubnetMembers(bans []model.BannedIP, subnet *net.IPNet) []model.BannedIP {
+	var members []model.BannedIP
+	for _, b := range bans {
+		if addr := net.ParseIP(b.IPAddress); addr != nil && subnet.Contains(addr) {
+			members = append(members, b)
+		}
+	}
+	return members
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"testing"
+
+	"nginx-proxy-guard/internal/model"
+)
+
+func TestSubnetForIP(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want string
+	}{
+		{"203.0.113.77", "203.0.113.0/24"},
+		{"::ffff:203.0.113.77", "203.0.113.0/24"},
+		{"2001:db8:1:2:aaaa::1", "2001:db8:1:2::/64"},
+	}
+	for _, tt := range tests {
+		subnet, err := SubnetForIP(tt.ip, 24, 64)
+		if err != nil {
+			t.Fatalf("SubnetForIP(%q) error = %v", tt.ip, err)
+		}
+		if subnet.String() != tt.want {
+			t.Errorf("SubnetForIP(%q) = %s, want %s", tt.ip, subnet, tt.want)
+		}
+	}
+
+	if _, err := SubnetForIP("not-an-ip", 24, 64); err == nil {
+		t.Error("SubnetForIP() expected error for invalid IP")
+	}
+	if _, err := SubnetForIP("203.0.113.77", 33, 64); err == nil {
+		t.Error("SubnetForIP() expected error for invalid prefix")
+	}
+}
+
+func TestSubnetMembers(t *testing.T) {
+	subnet, _ := SubnetForIP("198.51.100.9", 22, 64)
+	bans := []model.BannedIP{
+		{ID: "1", IPAddress: "198.51.100.1"},
+		{ID: "2", IPAddress: "198.51.103.254"},
+		{ID: "3", IPAddress: "198.51.104.1"},
+		{ID: "4", IPAddress: "2001:db8::1"},
+		{ID: "5", IPAddress: "bogus"},
+	}
+
+	members := SubnetMembers(bans, subnet)
+	if len(members) != 2 || members[0].ID != "1" || members[1].ID != "2" {
+		t.Errorf("SubnetMembers() = %+v, want bans 1 and 2", members)
+	}
+}
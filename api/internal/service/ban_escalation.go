@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"nginx-proxy-guard/internal/model"
+	"nginx-proxy-guard/internal/repository"
+)
+
+// DefaultBanEscalationSteps is used when no steps are configured (1h → 1d → 7d → permanent)
+var DefaultBanEscalationSteps = []int64{3600, 86400, 604800, 0}
+
+// BanEscalationService lengthens auto-bans for IPs that were already banned
+// within the configured lookback window (shared by WAF auto-ban and Fail2ban)
+type BanEscalationService struct {
+	systemSettingsRepo *repository.SystemSettingsRepository
+	historyRepo        *repository.IPBanHistoryRepository
+}
+
+func NewBanEscalationService(systemSettingsRepo *repository.SystemSettingsRepository, historyRepo *repository.IPBanHistoryRepository) *BanEscalationService {
+	return &BanEscalationService{
+		systemSettingsRepo: systemSettingsRepo,
+		historyRepo:        historyRepo,
+	}
+}
+
+// Resolve returns the ban duration to apply for ip.
+// When escalation is disabled (or cannot be evaluated) the configured duration is returned unchanged
+// together with a nil escalation.
+func (s *BanEscalationService) Resolve(ctx context.Context, ip string, configuredSeconds int) (int, *model.BanEscalation) {
+	settings, err := s.systemSettingsRepo.Get(ctx)
+	if err != nil {
+		log.Printf("[BanEscalation] Failed to load settings, using configured duration: %v", err)
+		return configuredSeconds, nil
+	}
+	if !settings.BanEscalationEnabled {
+		return configuredSeconds, nil
+	}
+
+	since := time.Now().Add(-time.Duration(settings.BanEscalationWindow) * time.Second)
+	priorBans, _, err := s.historyRepo.CountBansSince(ctx, ip, since)
+	if err != nil {
+		log.Printf("[BanEscalation] Failed to count prior bans for %s, using configured duration: %v", ip, err)
+		return configuredSeconds, nil
+	}
+
+	escalation := ComputeBanEscalation(settings.BanEscalationSteps, settings.BanEscalationWindow, priorBans)
+	return escalation.Duration, &escalation
+}
+
+// GetStatus returns the current escalation level of ip and what its next ban would be
+func (s *BanEscalationService) GetStatus(ctx context.Context, ip string) (*model.IPBanEscalationStatus, error) {
+	settings, err := s.systemSettingsRepo.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	steps := settings.BanEscalationSteps
+	if len(steps) == 0 {
+		steps = DefaultBanEscalationSteps
+	}
+	since := time.Now().Add(-time.Duration(settings.BanEscalationWindow) * time.Second)
+	priorBans, lastBanAt, err := s.historyRepo.CountBansSince(ctx, ip, since)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &model.IPBanEscalationStatus{
+		IPAddress:     ip,
+		Enabled:       settings.BanEscalationEnabled,
+		Steps:         steps,
+		WindowSeconds: settings.BanEscalationWindow,
+		CurrentLevel:  priorBans,
+		LastBanAt:     lastBanAt,
+		Next:          ComputeBanEscalation(steps, settings.BanEscalationWindow, priorBans),
+	}
+	if !settings.BanEscalationEnabled {
+		status.Next.Duration = settings.WAFAutoBanDuration
+		status.Next.Explanation = "Ban escalation is disabled; the duration configured by WAF auto-ban or the host's Fail2ban rule applies"
+	}
+
+	return status, nil
+}
+
+// ComputeBanEscalation picks the step for an IP with priorBans bans within the window.
+// The last step repeats once the policy is exhausted.
+func ComputeBanEscalation(steps []int64, windowSeconds int, priorBans int) model.BanEscalation {
+	if len(steps) == 0 {
+		steps = DefaultBanEscalationSteps
+	}
+	if priorBans < 0 {
+		priorBans = 0
+	}
+
+	level := priorBans + 1
+	step := level
+	if step > len(steps) {
+		step = len(steps)
+	}
+	duration := int(steps[step-1])
+
+	policy := make([]string, len(steps))
+	for i, v := range steps {
+		policy[i] = FormatBanDuration(int(v))
+	}
+
+	stepNote := fmt.Sprintf("step %d of %d", step, len(steps))
+	if level > len(steps) {
+		stepNote = fmt.Sprintf("capped at step %d of %d", step, len(steps))
+	}
+
+	return model.BanEscalation{
+		Level:     level,
+		PriorBans: priorBans,
+		Duration:  duration,
+		Explanation: fmt.Sprintf("%s ban within %s → %s (%s: %s)",
+			ordinal(level), FormatBanDuration(windowSeconds), FormatBanDuration(duration),
+			stepNote, strings.Join(policy, " → ")),
+	}
+}
+
+// FormatBanDuration renders a ban duration in seconds as 30d, 24h, 15m or "permanent"
+func FormatBanDuration(seconds int) string {
+	switch {
+	case seconds <= 0:
+		return "permanent"
+	case seconds%86400 == 0:
+		return fmt.Sprintf("%dd", seconds/86400)
+	case seconds%3600 == 0:
+		return fmt.Sprintf("%dh", seconds/3600)
+	case seconds%60 == 0:
+		return fmt.Sprintf("%dm", seconds/60)
+	default:
+		return fmt.Sprintf("%ds", seconds)
+	}
+}
+
+func ordinal(n int) string {
+	suffix := "th"
+	switch n % 10 {
+	case 1:
+		suffix = "st"
+	case 2:
+		suffix = "nd"
+	case 3:
+		suffix = "rd"
+	}
+	if n%100 >= 11 && n%100 <= 13 {
+		suffix = "th"
+	}
+	return fmt.Sprintf("%d%s", n, suffix)
+}
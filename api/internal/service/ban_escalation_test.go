@@ -0,0 +1,45 @@
+package service
+
+import "testing"
+
+func TestComputeBanEscalation(t *testing.T) {
+	steps := []int64{3600, 86400, 604800, 0}
+	window := 30 * 86400
+
+	tests := []struct {
+		priorBans   int
+		level       int
+		duration    int
+		explanation string
+	}{
+		{0, 1, 3600, "1st ban within 30d → 1h (step 1 of 4: 1h → 1d → 7d → permanent)"},
+		{1, 2, 86400, "2nd ban within 30d → 1d (step 2 of 4: 1h → 1d → 7d → permanent)"},
+		{2, 3, 604800, "3rd ban within 30d → 7d (step 3 of 4: 1h → 1d → 7d → permanent)"},
+		{3, 4, 0, "4th ban within 30d → permanent (step 4 of 4: 1h → 1d → 7d → permanent)"},
+		{10, 11, 0, "11th ban within 30d → permanent (capped at step 4 of 4: 1h → 1d → 7d → permanent)"},
+	}
+
+	for _, tt := range tests {
+		got := ComputeBanEscalation(steps, window, tt.priorBans)
+		if got.Level != tt.level || got.Duration != tt.duration || got.PriorBans != tt.priorBans {
+			t.Errorf("ComputeBanEscalation(prior=%d) = %+v, want level %d duration %d", tt.priorBans, got, tt.level, tt.duration)
+		}
+		if got.Explanation != tt.explanation {
+			t.Errorf("ComputeBanEscalation(prior=%d).Explanation = %q, want %q", tt.priorBans, got.Explanation, tt.explanation)
+		}
+	}
+
+	// An empty policy falls back to the default steps
+	if got := ComputeBanEscalation(nil, window, 1); got.Duration != 86400 {
+		t.Errorf("ComputeBanEscalation(nil steps) duration = %d, want 86400", got.Duration)
+	}
+}
+
+func TestFormatBanDuration(t *testing.T) {
+	tests := map[int]string{0: "permanent", 45: "45s", 900: "15m", 7200: "2h", 90000: "25h", 604800: "7d"}
+	for seconds, want := range tests {
+		if got := FormatBanDuration(seconds); got != want {
+			t.Errorf("FormatBanDuration(%d) = %q, want %q", seconds, got, want)
+		}
+	}
+}
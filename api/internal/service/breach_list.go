@@ -0,0 +1,117 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	_ "embed"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Bundled list of common passwords, used until a full list is uploaded
+//
+//go:embed common_passwords.sha1
+var commonPasswordHashes []byte
+
+const (
+	// Largest breached password list accepted, 100 MB of hashes
+	maxBreachListHashes = 5000000
+	// Bits of the hash prefix that select a bucket, the 5 hex digits of a range query
+	breachPrefixBits = 20
+)
+
+// breachList is a sorted set of SHA-1 password hashes. Lookups only look
+// at the bucket of the hash prefix, like a k-anonymity range query against
+// a local copy of the list.
+type breachList struct {
+	hashes []byte   // Sorted 20 byte hashes
+	index  []uint32 // Start of every prefix bucket in hashes, plus the end
+}
+
+// newBreachList indexes sorted, deduplicated 20 byte hashes
+func newBreachList(hashes []byte) *breachList {
+	buckets := 1 << breachPrefixBits
+	index := make([]uint32, buckets+1)
+	n := len(hashes) / sha1.Size
+	pos := 0
+	for bucket := 0; bucket < buckets; bucket++ {
+		index[bucket] = uint32(pos)
+		for pos < n && breachPrefix(hashes[pos*sha1.Size:]) == uint32(bucket) {
+			pos++
+		}
+	}
+	index[buckets] = uint32(n)
+	return &breachList{hashes: hashes, index: index}
+}
+
+// breachPrefix returns the first 20 bits of a hash
+func breachPrefix(hash []byte) uint32 {
+	return uint32(hash[0])<<12 | uint32(hash[1])<<4 | uint32(hash[2])>>4
+}
+
+// count returns the number of hashes in the list
+func (l *breachList) count() int {
+	return len(l.hashes) / sha1.Size
+}
+
+// contains reports whether the password is in the list
+func (l *breachList) contains(password string) bool {
+	sum := sha1.Sum([]byte(password))
+	prefix := breachPrefix(sum[:])
+	lo, hi := int(l.index[prefix]), int(l.index[prefix+1])
+	i := lo + sort.Search(hi-lo, func(i int) bool {
+		return bytes.Compare(l.hashes[(lo+i)*sha1.Size:(lo+i+1)*sha1.Size], sum[:]) >= 0
+	})
+	return i < hi && bytes.Equal(l.hashes[i*sha1.Size:(i+1)*sha1.Size], sum[:])
+}
+
+// parseBreachList reads SHA-1 hashes, one per line in hex, optionally
+// followed by :count as in the downloadable Pwned Passwords files. Empty
+// lines and lines starting with # are skipped. It returns the hashes sorted
+// and deduplicated.
+func parseBreachList(r io.Reader) ([]byte, error) {
+	var hashes [][sha1.Size]byte
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		if i := strings.IndexByte(text, ':'); i >= 0 {
+			text = text[:i]
+		}
+
+		var hash [sha1.Size]byte
+		if len(text) != hex.EncodedLen(sha1.Size) {
+			return nil, fmt.Errorf("line %d: expected a 40 character SHA-1 hash", line)
+		}
+		if _, err := hex.Decode(hash[:], []byte(text)); err != nil {
+			return nil, fmt.Errorf("line %d: expected a 40 character SHA-1 hash", line)
+		}
+		if len(hashes) >= maxBreachListHashes {
+			return nil, fmt.Errorf("list has more than %d hashes", maxBreachListHashes)
+		}
+		hashes = append(hashes, hash)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(hashes, func(i, j int) bool {
+		return bytes.Compare(hashes[i][:], hashes[j][:]) < 0
+	})
+	sorted := make([]byte, 0, len(hashes)*sha1.Size)
+	for i, hash := range hashes {
+		if i > 0 && hash == hashes[i-1] {
+			continue
+		}
+		sorted = append(sorted, hash[:]...)
+	}
+	return sorted, nil
+}
@@ -420,7 +420,12 @@ func (s *CertificateService) GetByID(ctx context.Context, id string) (*model.Cer
 
 // List retrieves certificates with pagination
 func (s *CertificateService) List(ctx context.Context, page, perPage int) (*model.CertificateListResponse, error) {
-	certs, total, err := s.repo.List(ctx, page, perPage)
+	return s.ListInScope(ctx, model.APITokenScope{}, page, perPage)
+}
+
+// ListInScope lists the certificates inside an API token scope
+func (s *CertificateService) ListInScope(ctx context.Context, scope model.APITokenScope, page, perPage int) (*model.CertificateListResponse, error) {
+	certs, total, err := s.repo.ListInScope(ctx, scope, page, perPage)
 	if err != nil {
 		return nil, err
 	}
@@ -436,6 +441,16 @@ func (s *CertificateService) List(ctx context.Context, page, perPage int) (*mode
 	}, nil
 }
 
+// InScope reports whether a certificate is inside an API token scope
+func (s *CertificateService) InScope(ctx context.Context, scope model.APITokenScope, id string) (bool, error) {
+	return s.repo.InScope(ctx, scope, id)
+}
+
+// DomainsInScope reports whether an API token scope covers a certificate for the domains
+func (s *CertificateService) DomainsInScope(ctx context.Context, scope model.APITokenScope, domains []string) (bool, error) {
+	return s.repo.DomainsInScope(ctx, scope, domains)
+}
+
 // Delete removes a certificate
 func (s *CertificateService) Delete(ctx context.Context, id string) error {
 	// Delete certificate files (use any ACME service instance, staging setting doesn't matter)
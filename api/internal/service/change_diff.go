@@ -0,0 +1,114 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// FieldDiff renders the fields that a change sets, one "- field: old" and
+// "+ field: new" pair per changed field. before and after are encoded as
+// JSON objects; fields absent from after are left as they are. With after
+// nil, every field of before is shown as removed.
+func FieldDiff(before, after interface{}) (string, error) {
+	old, err := diffFields(before)
+	if err != nil {
+		return "", err
+	}
+	if after == nil {
+		var b strings.Builder
+		for _, key := range sortedKeys(old) {
+			fmt.Fprintf(&b, "- %s: %s\n", key, old[key])
+		}
+		return b.String(), nil
+	}
+
+	updated, err := diffFields(after)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for _, key := range sortedKeys(updated) {
+		prev, ok := old[key]
+		if ok && bytes.Equal(prev, updated[key]) {
+			continue
+		}
+		if ok {
+			fmt.Fprintf(&b, "- %s: %s\n", key, prev)
+		}
+		fmt.Fprintf(&b, "+ %s: %s\n", key, updated[key])
+	}
+	return b.String(), nil
+}
+
+// ListDiff renders the entries removed from and added to a list
+func ListDiff(before, after []string) string {
+	old := make(map[string]bool, len(before))
+	for _, v := range before {
+		old[v] = true
+	}
+	updated := make(map[string]bool, len(after))
+	for _, v := range after {
+		updated[v] = true
+	}
+
+	var removed, added []string
+	for v := range old {
+		if !updated[v] {
+			removed = append(removed, v)
+		}
+	}
+	for v := range updated {
+		if !old[v] {
+			added = append(added, v)
+		}
+	}
+	sort.Strings(removed)
+	sort.Strings(added)
+
+	var b strings.Builder
+	for _, v := range removed {
+		fmt.Fprintf(&b, "- %s\n", v)
+	}
+	for _, v := range added {
+		fmt.Fprintf(&b, "+ %s\n", v)
+	}
+	return b.String()
+}
+
+// diffFields encodes v as a JSON object and returns its fields, each
+// compactly encoded. Null fields are dropped.
+func diffFields(v interface{}) (map[string][]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode diff: %w", err)
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to encode diff: %w", err)
+	}
+
+	fields := make(map[string][]byte, len(raw))
+	for key, value := range raw {
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, value); err != nil {
+			return nil, err
+		}
+		if compact.String() == "null" {
+			continue
+		}
+		fields[key] = compact.Bytes()
+	}
+	return fields, nil
+}
+
+func sortedKeys(m map[string][]byte) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
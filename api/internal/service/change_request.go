@@ -0,0 +1,499 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"nginx-proxy-guard/internal/model"
+	"nginx-proxy-guard/internal/repository"
+)
+
+var (
+	ErrChangeRequestNotFound = errors.New("change request not found")
+	ErrChangeRequestClosed   = errors.New("change request is no longer pending")
+	ErrSelfApproval          = errors.New("a change request must be reviewed by another user")
+	ErrReviewerRole          = errors.New("your role is not allowed to review this change request")
+	ErrNotRequester          = errors.New("only the requester can cancel a change request")
+)
+
+// ChangeApplier applies an approved change from the payload stored with its request
+type ChangeApplier func(ctx context.Context, payload json.RawMessage) error
+
+// changeAction is an action that can be captured as a change request
+type changeAction struct {
+	permission string // Reviewers need this permission on top of the approver role
+	apply      ChangeApplier
+}
+
+// ChangeRequestService holds high-risk changes until a second user approves
+// them. The handlers that own an action register how to apply it and ask
+// Required before acting; while approval is required they submit a change
+// request instead. Pending requests expire in a periodic check.
+type ChangeRequestService struct {
+	repo *repository.ChangeRequestRepository
+
+	actionsMu sync.RWMutex
+	actions   map[string]changeAction
+
+	onExpired func(ctx context.Context, cr *model.ChangeRequest)
+
+	stopCh        chan struct{}
+	wg            sync.WaitGroup
+	mu            sync.Mutex
+	running       bool
+	checkInterval time.Duration
+}
+
+// NewChangeRequestService creates a new change request service
+func NewChangeRequestService(repo *repository.ChangeRequestRepository) *ChangeRequestService {
+	s := &ChangeRequestService{
+		repo:          repo,
+		actions:       make(map[string]changeAction),
+		checkInterval: time.Minute,
+	}
+	s.Register(model.ChangeActionApprovalPolicy, model.PermissionAll, s.applySettingsChange)
+	return s
+}
+
+// Register makes action available for approval. Reviewers need permission
+// and apply is called with the stored payload once a request is approved.
+func (s *ChangeRequestService) Register(action, permission string, apply ChangeApplier) {
+	s.actionsMu.Lock()
+	defer s.actionsMu.Unlock()
+	s.actions[action] = changeAction{permission: permission, apply: apply}
+}
+
+func (s *ChangeRequestService) action(name string) (changeAction, bool) {
+	s.actionsMu.RLock()
+	defer s.actionsMu.RUnlock()
+	a, ok := s.actions[name]
+	return a, ok
+}
+
+// SetExpiredCallback sets the function called for every request that expired unreviewed
+func (s *ChangeRequestService) SetExpiredCallback(fn func(ctx context.Context, cr *model.ChangeRequest)) {
+	s.onExpired = fn
+}
+
+// Start starts the expiry check
+func (s *ChangeRequestService) Start() {
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = true
+	s.stopCh = make(chan struct{})
+	s.mu.Unlock()
+
+	// Nothing can still be applying a request approved before this start
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	if n, err := s.repo.FailStale(ctx, time.Now()); err != nil {
+		log.Printf("[ChangeRequest] Failed to close interrupted change requests: %v", err)
+	} else if n > 0 {
+		log.Printf("[ChangeRequest] Marked %d interrupted change requests as failed", n)
+	}
+	cancel()
+
+	s.wg.Add(1)
+	go s.run()
+	log.Println("[ChangeRequest] Started")
+}
+
+// Stop stops the expiry check
+func (s *ChangeRequestService) Stop() {
+	s.mu.Lock()
+	if !s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = false
+	close(s.stopCh)
+	s.mu.Unlock()
+
+	s.wg.Wait()
+	log.Println("[ChangeRequest] Stopped")
+}
+
+func (s *ChangeRequestService) run() {
+	defer s.wg.Done()
+
+	s.expire()
+
+	ticker := time.NewTicker(s.checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.expire()
+		case <-s.stopCh:
+			return
+		}
+	}
+}
+
+func (s *ChangeRequestService) expire() {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	expired, err := s.repo.ExpirePending(ctx, time.Now())
+	if err != nil {
+		log.Printf("[ChangeRequest] Failed to expire change requests: %v", err)
+		return
+	}
+	for i := range expired {
+		log.Printf("[ChangeRequest] Change request %s (%s) expired unreviewed", expired[i].ID, expired[i].Action)
+		if s.onExpired != nil {
+			s.onExpired(ctx, &expired[i])
+		}
+	}
+}
+
+// GetSettings returns the approval settings
+func (s *ChangeRequestService) GetSettings(ctx context.Context) (*model.ApprovalSettings, error) {
+	return s.repo.GetSettings(ctx)
+}
+
+// UpdateSettings validates and stores the approval settings
+func (s *ChangeRequestService) UpdateSettings(ctx context.Context, req *model.UpdateApprovalSettingsRequest) (*model.ApprovalSettings, error) {
+	_, updated, err := s.settingsUpdate(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.SaveSettings(ctx, updated)
+}
+
+// SettingsChangeRequired validates req and reports whether it has to go
+// through a change request: while approval is enabled, weakening the policy
+// needs a second user like the actions it guards. It also returns the
+// current settings.
+func (s *ChangeRequestService) SettingsChangeRequired(ctx context.Context, req *model.UpdateApprovalSettingsRequest) (*model.ApprovalSettings, bool, error) {
+	current, updated, err := s.settingsUpdate(ctx, req)
+	if err != nil {
+		return nil, false, err
+	}
+	return current, approvalSettingsWeakened(current, updated), nil
+}
+
+// applySettingsChange applies an approved change of the approval settings
+func (s *ChangeRequestService) applySettingsChange(ctx context.Context, payload json.RawMessage) error {
+	var req model.UpdateApprovalSettingsRequest
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return fmt.Errorf("invalid change request payload: %w", err)
+	}
+	_, err := s.UpdateSettings(ctx, &req)
+	return err
+}
+
+// settingsUpdate returns the current approval settings and the validated
+// settings req would store
+func (s *ChangeRequestService) settingsUpdate(ctx context.Context, req *model.UpdateApprovalSettingsRequest) (current, updated *model.ApprovalSettings, err error) {
+	current, err = s.repo.GetSettings(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	settings := *current
+	settings.Actions = append([]string(nil), current.Actions...)
+	if req.Enabled != nil {
+		settings.Enabled = *req.Enabled
+	}
+	if req.Actions != nil {
+		settings.Actions = *req.Actions
+	}
+	if req.ApproverRole != nil {
+		settings.ApproverRole = *req.ApproverRole
+	}
+	if req.ExpiryHours != nil {
+		settings.ExpiryHours = *req.ExpiryHours
+	}
+
+	actions, err := normalizeApprovalSettings(&settings, func(action string) bool {
+		_, ok := s.action(action)
+		return ok
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	settings.Actions = actions
+	return current, &settings, nil
+}
+
+// approvalSettingsWeakened reports whether updated guards less than the
+// enabled current settings: approval turned off, an action dropped, a less
+// privileged approver role, or requests left open longer
+func approvalSettingsWeakened(current, updated *model.ApprovalSettings) bool {
+	if !current.Enabled {
+		return false
+	}
+	if !updated.Enabled || updated.ExpiryHours > current.ExpiryHours {
+		return true
+	}
+	if !roleAtLeast(updated.ApproverRole, current.ApproverRole) {
+		return true
+	}
+	for _, action := range current.Actions {
+		kept := false
+		for _, a := range updated.Actions {
+			if a == action {
+				kept = true
+				break
+			}
+		}
+		if !kept {
+			return true
+		}
+	}
+	return false
+}
+
+// Required reports whether action must go through a change request
+func (s *ChangeRequestService) Required(ctx context.Context, action string) (bool, error) {
+	if _, ok := s.action(action); !ok {
+		return false, nil
+	}
+	settings, err := s.repo.GetSettings(ctx)
+	if err != nil {
+		return false, err
+	}
+	if !settings.Enabled {
+		return false, nil
+	}
+	for _, a := range settings.Actions {
+		if a == action {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Submit stores cr as a pending change request with payload, which is
+// handed to the applier of the action once the request is approved
+func (s *ChangeRequestService) Submit(ctx context.Context, cr *model.ChangeRequest, payload interface{}) (*model.ChangeRequest, error) {
+	if _, ok := s.action(cr.Action); !ok {
+		return nil, fmt.Errorf("unknown change request action %q", cr.Action)
+	}
+	settings, err := s.repo.GetSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode change request payload: %w", err)
+	}
+	cr.Payload = data
+	cr.ExpiresAt = time.Now().Add(time.Duration(settings.ExpiryHours) * time.Hour)
+
+	return s.repo.Create(ctx, cr)
+}
+
+// List returns the change requests userID may read, optionally filtered by
+// status: its own and those of the actions it could carry out itself, as
+// reported by has
+func (s *ChangeRequestService) List(ctx context.Context, status, userID string, has func(permission string) bool, page, perPage int) (*model.ChangeRequestListResponse, error) {
+	requests, total, err := s.repo.List(ctx, status, userID, s.readableActions(has), page, perPage)
+	if err != nil {
+		return nil, err
+	}
+	return &model.ChangeRequestListResponse{
+		Data:       requests,
+		Total:      total,
+		Page:       page,
+		PerPage:    perPage,
+		TotalPages: (total + perPage - 1) / perPage,
+	}, nil
+}
+
+// Get returns a change request userID may read, see List. Others are
+// reported as not found.
+func (s *ChangeRequestService) Get(ctx context.Context, id, userID string, has func(permission string) bool) (*model.ChangeRequest, error) {
+	cr, err := s.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !s.canRead(cr, userID, has) {
+		return nil, ErrChangeRequestNotFound
+	}
+	return cr, nil
+}
+
+func (s *ChangeRequestService) get(ctx context.Context, id string) (*model.ChangeRequest, error) {
+	cr, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if cr == nil {
+		return nil, ErrChangeRequestNotFound
+	}
+	return cr, nil
+}
+
+// readableActions returns the registered actions whose permission has reports
+func (s *ChangeRequestService) readableActions(has func(permission string) bool) []string {
+	s.actionsMu.RLock()
+	defer s.actionsMu.RUnlock()
+
+	actions := []string{}
+	for name, action := range s.actions {
+		if has(action.permission) {
+			actions = append(actions, name)
+		}
+	}
+	return actions
+}
+
+// canRead reports whether userID may read cr: change requests carry the full
+// payload of the change, so only the requester and users who could carry
+// out the action themselves see them
+func (s *ChangeRequestService) canRead(cr *model.ChangeRequest, userID string, has func(permission string) bool) bool {
+	if userID != "" && cr.RequestedBy == userID {
+		return true
+	}
+	action, ok := s.action(cr.Action)
+	return ok && has(action.permission)
+}
+
+// Approve approves a pending change request and applies it. Whether the
+// change could be applied is reported in the status of the returned request.
+func (s *ChangeRequestService) Approve(ctx context.Context, id string, reviewer *model.User, comment string) (*model.ChangeRequest, error) {
+	cr, action, err := s.reviewable(ctx, id, reviewer)
+	if err != nil {
+		return nil, err
+	}
+
+	claimed, err := s.repo.Review(ctx, cr.ID, model.ChangeStatusApproved, reviewer.ID, reviewer.Username, comment)
+	if err != nil {
+		return nil, err
+	}
+	if claimed == nil {
+		return nil, ErrChangeRequestClosed
+	}
+
+	// Finish the change even if the approving client goes away
+	applyCtx := context.WithoutCancel(ctx)
+	status, message := model.ChangeStatusApplied, ""
+	if err := action.apply(applyCtx, claimed.Payload); err != nil {
+		log.Printf("[ChangeRequest] Failed to apply change request %s (%s): %v", claimed.ID, claimed.Action, err)
+		status, message = model.ChangeStatusFailed, err.Error()
+	}
+
+	return s.repo.Finish(applyCtx, claimed.ID, status, message)
+}
+
+// Reject rejects a pending change request
+func (s *ChangeRequestService) Reject(ctx context.Context, id string, reviewer *model.User, comment string) (*model.ChangeRequest, error) {
+	cr, _, err := s.reviewable(ctx, id, reviewer)
+	if err != nil {
+		return nil, err
+	}
+	return s.close(ctx, cr.ID, model.ChangeStatusRejected, reviewer, comment)
+}
+
+// Cancel withdraws a pending change request; only its requester may
+func (s *ChangeRequestService) Cancel(ctx context.Context, id string, user *model.User, comment string) (*model.ChangeRequest, error) {
+	cr, err := s.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if cr.RequestedBy == "" || cr.RequestedBy != user.ID {
+		return nil, ErrNotRequester
+	}
+	return s.close(ctx, cr.ID, model.ChangeStatusCancelled, user, comment)
+}
+
+func (s *ChangeRequestService) close(ctx context.Context, id, status string, user *model.User, comment string) (*model.ChangeRequest, error) {
+	closed, err := s.repo.Review(ctx, id, status, user.ID, user.Username, comment)
+	if err != nil {
+		return nil, err
+	}
+	if closed == nil {
+		return nil, ErrChangeRequestClosed
+	}
+	return closed, nil
+}
+
+// reviewable loads a pending change request and checks that reviewer may review it
+func (s *ChangeRequestService) reviewable(ctx context.Context, id string, reviewer *model.User) (*model.ChangeRequest, changeAction, error) {
+	cr, err := s.get(ctx, id)
+	if err != nil {
+		return nil, changeAction{}, err
+	}
+	if cr.Status != model.ChangeStatusPending || !time.Now().Before(cr.ExpiresAt) {
+		return nil, changeAction{}, ErrChangeRequestClosed
+	}
+	action, ok := s.action(cr.Action)
+	if !ok {
+		return nil, changeAction{}, fmt.Errorf("unknown change request action %q", cr.Action)
+	}
+
+	settings, err := s.repo.GetSettings(ctx)
+	if err != nil {
+		return nil, changeAction{}, err
+	}
+	if err := checkReviewer(cr, reviewer, settings.ApproverRole, action.permission); err != nil {
+		return nil, changeAction{}, err
+	}
+	return cr, action, nil
+}
+
+// checkReviewer checks that reviewer isn't the requester, holds a role at
+// least as privileged as approverRole and has the permission of the action
+func checkReviewer(cr *model.ChangeRequest, reviewer *model.User, approverRole, permission string) error {
+	if reviewer.ID == cr.RequestedBy {
+		return ErrSelfApproval
+	}
+	if !roleAtLeast(reviewer.Role, approverRole) || !model.RoleHasPermission(reviewer.Role, permission) {
+		return ErrReviewerRole
+	}
+	return nil
+}
+
+// roleAtLeast reports whether role is as privileged as minimum or more
+func roleAtLeast(role, minimum string) bool {
+	rank := func(r string) int {
+		for i, name := range model.AllRoles {
+			if name == r {
+				return i
+			}
+		}
+		return -1
+	}
+	have, need := rank(role), rank(minimum)
+	return have >= 0 && need >= 0 && have <= need
+}
+
+// normalizeApprovalSettings validates the settings and returns the known
+// actions deduplicated, in a stable order
+func normalizeApprovalSettings(s *model.ApprovalSettings, known func(string) bool) ([]string, error) {
+	seen := make(map[string]bool)
+	for _, action := range s.Actions {
+		if !known(action) || !model.IsChangeAction(action) {
+			return nil, &UserError{Field: "actions", Message: fmt.Sprintf("unknown action %q", action)}
+		}
+		seen[action] = true
+	}
+	actions := []string{}
+	for _, action := range model.ChangeActions {
+		if seen[action] {
+			actions = append(actions, action)
+		}
+	}
+
+	if !model.IsValidRole(s.ApproverRole) || s.ApproverRole == model.RoleViewer {
+		return nil, &UserError{Field: "approver_role", Message: "must be admin, operator or security_analyst"}
+	}
+	if s.ExpiryHours < 1 || s.ExpiryHours > 720 {
+		return nil, &UserError{Field: "expiry_hours", Message: "must be between 1 and 720"}
+	}
+	if s.Enabled && len(actions) == 0 {
+		return nil, &UserError{Field: "actions", Message: "choose at least one action that needs approval"}
+	}
+	return actions, nil
+}
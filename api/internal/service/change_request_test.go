@@ -0,0 +1,232 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"nginx-proxy-guard/internal/model"
+)
+
+func TestCheckReviewer(t *testing.T) {
+	cr := &model.ChangeRequest{RequestedBy: "u1"}
+
+	tests := []struct {
+		name         string
+		reviewer     *model.User
+		approverRole string
+		permission   string
+		want         error
+	}{
+		{"admin approves", &model.User{ID: "u2", Role: model.RoleAdmin}, model.RoleAdmin, model.PermissionBackupRestore, nil},
+		{"requester can't approve", &model.User{ID: "u1", Role: model.RoleAdmin}, model.RoleAdmin, model.PermissionBackupRestore, ErrSelfApproval},
+		{"operator below admin", &model.User{ID: "u2", Role: model.RoleOperator}, model.RoleAdmin, model.PermissionCertDelete, ErrReviewerRole},
+		{"analyst lacks permission", &model.User{ID: "u2", Role: model.RoleSecurityAnalyst}, model.RoleSecurityAnalyst, model.PermissionSettingsWrite, ErrReviewerRole},
+		{"analyst with permission", &model.User{ID: "u2", Role: model.RoleSecurityAnalyst}, model.RoleSecurityAnalyst, model.PermissionWAFWrite, nil},
+		{"viewer never", &model.User{ID: "u2", Role: model.RoleViewer}, model.RoleViewer, model.PermissionWAFWrite, ErrReviewerRole},
+	}
+	for _, tt := range tests {
+		if err := checkReviewer(cr, tt.reviewer, tt.approverRole, tt.permission); !errors.Is(err, tt.want) {
+			t.Errorf("%s: checkReviewer() = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestRoleAtLeast(t *testing.T) {
+	tests := []struct {
+		role, minimum string
+		want          bool
+	}{
+		{model.RoleAdmin, model.RoleOperator, true},
+		{model.RoleOperator, model.RoleOperator, true},
+		{model.RoleSecurityAnalyst, model.RoleOperator, false},
+		{"user", model.RoleViewer, false},
+		{model.RoleAdmin, "unknown", false},
+	}
+	for _, tt := range tests {
+		if got := roleAtLeast(tt.role, tt.minimum); got != tt.want {
+			t.Errorf("roleAtLeast(%q, %q) = %v, want %v", tt.role, tt.minimum, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeApprovalSettings(t *testing.T) {
+	known := func(string) bool { return true }
+
+	s := &model.ApprovalSettings{
+		Enabled:      true,
+		Actions:      []string{model.ChangeActionGlobalSettings, model.ChangeActionBackupRestore, model.ChangeActionGlobalSettings},
+		ApproverRole: model.RoleAdmin,
+		ExpiryHours:  24,
+	}
+	actions, err := normalizeApprovalSettings(s, known)
+	if err != nil {
+		t.Fatalf("normalizeApprovalSettings() error = %v", err)
+	}
+	if want := []string{model.ChangeActionBackupRestore, model.ChangeActionGlobalSettings}; !reflect.DeepEqual(actions, want) {
+		t.Errorf("actions = %v, want %v", actions, want)
+	}
+
+	tests := []struct {
+		field  string
+		modify func(s *model.ApprovalSettings)
+	}{
+		{"actions", func(s *model.ApprovalSettings) { s.Actions = []string{"delete_everything"} }},
+		{"actions", func(s *model.ApprovalSettings) { s.Actions = nil }},
+		{"approver_role", func(s *model.ApprovalSettings) { s.ApproverRole = model.RoleViewer }},
+		{"approver_role", func(s *model.ApprovalSettings) { s.ApproverRole = "root" }},
+		{"expiry_hours", func(s *model.ApprovalSettings) { s.ExpiryHours = 0 }},
+		{"expiry_hours", func(s *model.ApprovalSettings) { s.ExpiryHours = 721 }},
+	}
+	for _, tt := range tests {
+		invalid := *s
+		tt.modify(&invalid)
+		var uerr *UserError
+		if _, err := normalizeApprovalSettings(&invalid, known); !errors.As(err, &uerr) || uerr.Field != tt.field {
+			t.Errorf("%s: error = %v", tt.field, err)
+		}
+	}
+
+	disabled := &model.ApprovalSettings{ApproverRole: model.RoleAdmin, ExpiryHours: 24}
+	if _, err := normalizeApprovalSettings(disabled, known); err != nil {
+		t.Errorf("disabled without actions: error = %v", err)
+	}
+	unregistered := func(string) bool { return false }
+	if _, err := normalizeApprovalSettings(s, unregistered); err == nil {
+		t.Error("unregistered actions should be rejected")
+	}
+}
+
+func TestApprovalSettingsWeakened(t *testing.T) {
+	current := model.ApprovalSettings{
+		Enabled:      true,
+		Actions:      []string{model.ChangeActionBackupRestore, model.ChangeActionWAFDisable},
+		ApproverRole: model.RoleOperator,
+		ExpiryHours:  24,
+	}
+
+	tests := []struct {
+		name   string
+		modify func(s *model.ApprovalSettings)
+		want   bool
+	}{
+		{"unchanged", func(s *model.ApprovalSettings) {}, false},
+		{"disabled", func(s *model.ApprovalSettings) { s.Enabled = false }, true},
+		{"action dropped", func(s *model.ApprovalSettings) { s.Actions = []string{model.ChangeActionWAFDisable} }, true},
+		{"action added", func(s *model.ApprovalSettings) { s.Actions = append(s.Actions, model.ChangeActionCertificateDelete) }, false},
+		{"lower approver role", func(s *model.ApprovalSettings) { s.ApproverRole = model.RoleSecurityAnalyst }, true},
+		{"higher approver role", func(s *model.ApprovalSettings) { s.ApproverRole = model.RoleAdmin }, false},
+		{"longer expiry", func(s *model.ApprovalSettings) { s.ExpiryHours = 48 }, true},
+		{"shorter expiry", func(s *model.ApprovalSettings) { s.ExpiryHours = 12 }, false},
+	}
+	for _, tt := range tests {
+		updated := current
+		updated.Actions = append([]string(nil), current.Actions...)
+		tt.modify(&updated)
+		if got := approvalSettingsWeakened(&current, &updated); got != tt.want {
+			t.Errorf("%s: approvalSettingsWeakened() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	disabled := current
+	disabled.Enabled = false
+	updated := disabled
+	updated.Actions = nil
+	if approvalSettingsWeakened(&disabled, &updated) {
+		t.Error("changes while approval is disabled need no approval")
+	}
+}
+
+func TestChangeRequestVisibility(t *testing.T) {
+	s := NewChangeRequestService(nil)
+	s.Register(model.ChangeActionBackupRestore, model.PermissionBackupRestore, nil)
+	s.Register(model.ChangeActionWAFDisable, model.PermissionWAFWrite, nil)
+
+	analyst := func(permission string) bool {
+		return model.RoleHasPermission(model.RoleSecurityAnalyst, permission)
+	}
+	viewer := func(permission string) bool {
+		return model.RoleHasPermission(model.RoleViewer, permission)
+	}
+	admin := func(permission string) bool {
+		return model.RoleHasPermission(model.RoleAdmin, permission)
+	}
+
+	if got := s.readableActions(analyst); !reflect.DeepEqual(got, []string{model.ChangeActionWAFDisable}) {
+		t.Errorf("readableActions(analyst) = %v", got)
+	}
+	if got := s.readableActions(viewer); len(got) != 0 {
+		t.Errorf("readableActions(viewer) = %v, want none", got)
+	}
+	if got := s.readableActions(admin); len(got) != 3 {
+		t.Errorf("readableActions(admin) = %v, want all actions", got)
+	}
+
+	restore := &model.ChangeRequest{Action: model.ChangeActionBackupRestore, RequestedBy: "u1"}
+	tests := []struct {
+		name   string
+		userID string
+		has    func(string) bool
+		want   bool
+	}{
+		{"requester", "u1", viewer, true},
+		{"other viewer", "u2", viewer, false},
+		{"analyst without the permission", "u2", analyst, false},
+		{"admin", "u2", admin, true},
+		{"no user", "", viewer, false},
+	}
+	for _, tt := range tests {
+		if got := s.canRead(restore, tt.userID, tt.has); got != tt.want {
+			t.Errorf("%s: canRead() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	if s.canRead(&model.ChangeRequest{Action: model.ChangeActionBackupRestore}, "", viewer) {
+		t.Error("a request without a requester must not match an empty user ID")
+	}
+}
+
+func TestFieldDiff(t *testing.T) {
+	type settings struct {
+		Workers  int     `json:"workers"`
+		Gzip     bool    `json:"gzip"`
+		Protocol string  `json:"protocol"`
+		Comment  *string `json:"comment,omitempty"`
+	}
+	type update struct {
+		Workers  *int    `json:"workers,omitempty"`
+		Gzip     *bool   `json:"gzip,omitempty"`
+		Protocol *string `json:"protocol,omitempty"`
+		Comment  *string `json:"comment,omitempty"`
+	}
+	current := settings{Workers: 2, Gzip: true, Protocol: "TLSv1.2"}
+	workers, gzip, comment := 4, true, "tuned"
+
+	diff, err := FieldDiff(current, update{Workers: &workers, Gzip: &gzip, Comment: &comment})
+	if err != nil {
+		t.Fatalf("FieldDiff() error = %v", err)
+	}
+	want := "+ comment: \"tuned\"\n- workers: 2\n+ workers: 4\n"
+	if diff != want {
+		t.Errorf("FieldDiff() = %q, want %q", diff, want)
+	}
+
+	diff, err = FieldDiff(current, nil)
+	if err != nil {
+		t.Fatalf("FieldDiff() error = %v", err)
+	}
+	want = "- gzip: true\n- protocol: \"TLSv1.2\"\n- workers: 2\n"
+	if diff != want {
+		t.Errorf("FieldDiff(removal) = %q, want %q", diff, want)
+	}
+}
+
+func TestListDiff(t *testing.T) {
+	got := ListDiff([]string{"a.example", "b.example", "c.example"}, []string{"c.example", "d.example", "a.example"})
+	want := "- b.example\n+ d.example\n"
+	if got != want {
+		t.Errorf("ListDiff() = %q, want %q", got, want)
+	}
+	if got := ListDiff([]string{"a"}, []string{"a"}); got != "" {
+		t.Errorf("ListDiff() of equal lists = %q", got)
+	}
+}
@@ -61,6 +61,13 @@ var defaultCloudProviders = []DefaultCloudProvider{
 	// Vultr: Official JSON geofeed
 	{Name: "Vultr", Slug: "vultr", Region: "us", Description: "Vultr cloud hosting", IPRangesURL: "https://geofeed.constant.com/?json", ASNs: []uint{20473}},
 
+	// === CDNs (trusted proxy sources for real client IPs) ===
+	// No ASN fallback: the ASNs also cover non-proxy services (e.g. WARP egress) that could forge headers
+	// Cloudflare: Official JSON API
+	{Name: "Cloudflare", Slug: "cloudflare", Region: "us", Description: "Cloudflare CDN and proxy edge network", IPRangesURL: "https://api.cloudflare.com/client/v4/ips", ASNs: nil},
+	// Fastly: Official JSON API
+	{Name: "Fastly", Slug: "fastly", Region: "us", Description: "Fastly CDN edge network", IPRangesURL: "https://api.fastly.com/public-ip-list", ASNs: nil},
+
 	// === European Providers (ASN-based) ===
 	{Name: "Contabo", Slug: "contabo", Region: "eu", Description: "German cloud hosting provider", IPRangesURL: "", ASNs: []uint{51167, 40021}},
 	{Name: "Hetzner", Slug: "hetzner", Region: "eu", Description: "German hosting and cloud provider", IPRangesURL: "", ASNs: []uint{24940, 213230}},
@@ -328,6 +335,10 @@ func (s *CloudProviderService) fetchIPRanges(ctx context.Context, slug, url stri
 		return s.parseCSVGeoFeed(body)
 	case "digitalocean":
 		return s.parseCSVGeoFeed(body)
+	case "cloudflare":
+		return s.parseCloudflareIPRanges(body)
+	case "fastly":
+		return s.parseFastlyIPRanges(body)
 	default:
 		return nil, fmt.Errorf("unknown provider format: %s", slug)
 	}
@@ -404,6 +415,48 @@ func (s *CloudProviderService) parseGCPIPRanges(data []byte) ([]string, error) {
 	return ranges, nil
 }
 
+// Cloudflare IP Ranges JSON structure
+type cloudflareIPRanges struct {
+	Result struct {
+		IPv4CIDRs []string `json:"ipv4_cidrs"`
+		IPv6CIDRs []string `json:"ipv6_cidrs"`
+	} `json:"result"`
+	Success bool `json:"success"`
+}
+
+func (s *CloudProviderService) parseCloudflareIPRanges(data []byte) ([]string, error) {
+	var cf cloudflareIPRanges
+	if err := json.Unmarshal(data, &cf); err != nil {
+		return nil, fmt.Errorf("failed to parse Cloudflare IP ranges: %w", err)
+	}
+	if !cf.Success {
+		return nil, fmt.Errorf("cloudflare API returned an unsuccessful response")
+	}
+
+	ranges := make([]string, 0, len(cf.Result.IPv4CIDRs)+len(cf.Result.IPv6CIDRs))
+	ranges = append(ranges, cf.Result.IPv4CIDRs...)
+	ranges = append(ranges, cf.Result.IPv6CIDRs...)
+	return ranges, nil
+}
+
+// Fastly IP Ranges JSON structure
+type fastlyIPRanges struct {
+	Addresses     []string `json:"addresses"`
+	IPv6Addresses []string `json:"ipv6_addresses"`
+}
+
+func (s *CloudProviderService) parseFastlyIPRanges(data []byte) ([]string, error) {
+	var fastly fastlyIPRanges
+	if err := json.Unmarshal(data, &fastly); err != nil {
+		return nil, fmt.Errorf("failed to parse Fastly IP ranges: %w", err)
+	}
+
+	ranges := make([]string, 0, len(fastly.Addresses)+len(fastly.IPv6Addresses))
+	ranges = append(ranges, fastly.Addresses...)
+	ranges = append(ranges, fastly.IPv6Addresses...)
+	return ranges, nil
+}
+
 // Oracle IP Ranges JSON structure
 type oracleIPRanges struct {
 	Regions []struct {
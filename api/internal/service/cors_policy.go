@@ -0,0 +1,202 @@
+package service
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"nginx-proxy-guard/internal/model"
+)
+
+const maxCORSMaxAge = 86400 // Chromium caps preflight results at 2 hours, Firefox at 24 hours
+
+var (
+	corsPathPrefixPattern = regexp.MustCompile(`^/[A-Za-z0-9/._~%-]*$`)
+	corsHeaderPattern     = regexp.MustCompile(`^[A-Za-z0-9-]+$`)
+	corsMethods           = map[string]bool{"GET": true, "HEAD": true, "POST": true, "PUT": true, "PATCH": true, "DELETE": true, "OPTIONS": true}
+	defaultCORSMethods    = []string{"GET", "HEAD", "POST"}
+)
+
+// CORSPolicyError reports the field of a CORS policy that is invalid or unsafe
+type CORSPolicyError struct {
+	Field   string
+	Message string
+}
+
+func (e *CORSPolicyError) Error() string {
+	return fmt.Sprintf("%s %s", e.Field, e.Message)
+}
+
+// ResolveCORSPolicy builds a policy from a request, applying its preset, and validates it
+func ResolveCORSPolicy(req *model.UpsertCORSPolicyRequest) (*model.CORSPolicy, error) {
+	policy := model.CORSPolicy{Enabled: true}
+	if req.Preset != "" {
+		preset, ok := model.CORSPolicyPresets[req.Preset]
+		if !ok {
+			return nil, &CORSPolicyError{Field: "preset", Message: "is not a known preset"}
+		}
+		policy = preset
+	}
+	policy.PathPrefix = strings.TrimSpace(req.PathPrefix)
+
+	if req.Enabled != nil {
+		policy.Enabled = *req.Enabled
+	}
+	if req.AllowedOrigins != nil {
+		policy.AllowedOrigins = req.AllowedOrigins
+	}
+	if req.AllowedOriginPatterns != nil {
+		policy.AllowedOriginPatterns = req.AllowedOriginPatterns
+	}
+	if req.AllowedMethods != nil {
+		policy.AllowedMethods = req.AllowedMethods
+	}
+	if req.AllowedHeaders != nil {
+		policy.AllowedHeaders = req.AllowedHeaders
+	}
+	if req.ExposedHeaders != nil {
+		policy.ExposedHeaders = req.ExposedHeaders
+	}
+	if req.AllowCredentials != nil {
+		policy.AllowCredentials = *req.AllowCredentials
+	}
+	if req.MaxAge != nil {
+		policy.MaxAge = *req.MaxAge
+	}
+
+	if err := ValidateCORSPolicy(&policy); err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// ValidateCORSPolicy rejects policies nginx can't render or browsers would refuse, and
+// normalizes origins, methods and headers
+func ValidateCORSPolicy(policy *model.CORSPolicy) error {
+	if policy.PathPrefix != "" && !corsPathPrefixPattern.MatchString(policy.PathPrefix) {
+		return &CORSPolicyError{Field: "path_prefix", Message: "must start with / and contain only letters, digits and /._~%-"}
+	}
+
+	origins := make([]string, 0, len(policy.AllowedOrigins))
+	seen := make(map[string]bool)
+	for _, origin := range policy.AllowedOrigins {
+		normalized, err := normalizeCORSOrigin(origin)
+		if err != nil {
+			return err
+		}
+		if normalized == "*" && policy.AllowCredentials {
+			return &CORSPolicyError{Field: "allowed_origins", Message: "can't contain * when credentials are allowed, list the trusted origins instead"}
+		}
+		if !seen[normalized] {
+			seen[normalized] = true
+			origins = append(origins, normalized)
+		}
+	}
+	policy.AllowedOrigins = origins
+
+	patterns := make([]string, 0, len(policy.AllowedOriginPatterns))
+	for _, pattern := range policy.AllowedOriginPatterns {
+		pattern = strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(pattern), "^"), "$")
+		if pattern == "" {
+			continue
+		}
+		if strings.ContainsAny(pattern, "\"'{};") || strings.IndexFunc(pattern, isCORSControlChar) >= 0 {
+			return &CORSPolicyError{Field: "allowed_origin_patterns", Message: fmt.Sprintf("%q contains quotes, braces, semicolons or control characters", pattern)}
+		}
+		re, err := regexp.Compile("(?i)^(?:" + pattern + ")$")
+		if err != nil {
+			return &CORSPolicyError{Field: "allowed_origin_patterns", Message: fmt.Sprintf("%q is not a valid regular expression", pattern)}
+		}
+		if policy.AllowCredentials && re.MatchString("https://attacker.invalid") {
+			return &CORSPolicyError{Field: "allowed_origin_patterns", Message: fmt.Sprintf("%q matches any origin, which must not be trusted with credentials", pattern)}
+		}
+		patterns = append(patterns, pattern)
+	}
+	policy.AllowedOriginPatterns = patterns
+
+	if policy.Enabled && len(origins) == 0 && len(patterns) == 0 {
+		return &CORSPolicyError{Field: "allowed_origins", Message: "must contain at least one origin or origin pattern"}
+	}
+
+	methods := make([]string, 0, len(policy.AllowedMethods))
+	for _, method := range policy.AllowedMethods {
+		method = strings.ToUpper(strings.TrimSpace(method))
+		if !corsMethods[method] {
+			return &CORSPolicyError{Field: "allowed_methods", Message: fmt.Sprintf("contains an unsupported method %q", method)}
+		}
+		methods = appendUnique(methods, method)
+	}
+	if len(methods) == 0 {
+		methods = append(methods, defaultCORSMethods...)
+	}
+	policy.AllowedMethods = methods
+
+	var err error
+	if policy.AllowedHeaders, err = normalizeCORSHeaders("allowed_headers", policy.AllowedHeaders, policy.AllowCredentials); err != nil {
+		return err
+	}
+	if policy.ExposedHeaders, err = normalizeCORSHeaders("exposed_headers", policy.ExposedHeaders, policy.AllowCredentials); err != nil {
+		return err
+	}
+
+	if policy.MaxAge < 0 || policy.MaxAge > maxCORSMaxAge {
+		return &CORSPolicyError{Field: "max_age", Message: fmt.Sprintf("must be between 0 and %d seconds", maxCORSMaxAge)}
+	}
+	return nil
+}
+
+// normalizeCORSOrigin returns the serialized form browsers send in the Origin header
+func normalizeCORSOrigin(origin string) (string, error) {
+	origin = strings.TrimSpace(origin)
+	if origin == "*" {
+		return origin, nil
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", &CORSPolicyError{Field: "allowed_origins", Message: fmt.Sprintf("%q must be an http or https origin such as https://app.example.com", origin)}
+	}
+	if u.User != nil || (u.Path != "" && u.Path != "/") || u.RawQuery != "" || u.Fragment != "" {
+		return "", &CORSPolicyError{Field: "allowed_origins", Message: fmt.Sprintf("%q must not contain credentials, a path or a query", origin)}
+	}
+	if strings.ContainsAny(u.Host, "\"'{};* ") {
+		return "", &CORSPolicyError{Field: "allowed_origins", Message: fmt.Sprintf("%q contains an invalid host, use an origin pattern for wildcards", origin)}
+	}
+
+	host := strings.ToLower(u.Host)
+	if (u.Scheme == "http" && strings.HasSuffix(host, ":80")) || (u.Scheme == "https" && strings.HasSuffix(host, ":443")) {
+		host = host[:strings.LastIndex(host, ":")]
+	}
+	return u.Scheme + "://" + host, nil
+}
+
+// normalizeCORSHeaders validates header names; * is only a wildcard for requests without credentials
+func normalizeCORSHeaders(field string, headers []string, credentials bool) ([]string, error) {
+	normalized := make([]string, 0, len(headers))
+	for _, header := range headers {
+		header = strings.TrimSpace(header)
+		if header == "*" {
+			if credentials {
+				return nil, &CORSPolicyError{Field: field, Message: "can't contain * when credentials are allowed, browsers treat it as a literal header name"}
+			}
+		} else if !corsHeaderPattern.MatchString(header) {
+			return nil, &CORSPolicyError{Field: field, Message: fmt.Sprintf("contains an invalid header name %q", header)}
+		}
+		normalized = appendUnique(normalized, header)
+	}
+	return normalized, nil
+}
+
+func isCORSControlChar(r rune) bool {
+	return r < 0x20 || r == 0x7f
+}
+
+func appendUnique(values []string, value string) []string {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return values
+		}
+	}
+	return append(values, value)
+}
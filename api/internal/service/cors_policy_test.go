@@ -0,0 +1,62 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"nginx-proxy-guard/internal/model"
+)
+
+func TestResolveCORSPolicy(t *testing.T) {
+	policy, err := ResolveCORSPolicy(&model.UpsertCORSPolicyRequest{
+		Preset:         "credentialed_spa",
+		PathPrefix:     "/api/",
+		AllowedOrigins: []string{"HTTPS://App.Example.com:443/", "https://app.example.com"},
+	})
+	if err != nil {
+		t.Fatalf("ResolveCORSPolicy() error = %v", err)
+	}
+	if !reflect.DeepEqual(policy.AllowedOrigins, []string{"https://app.example.com"}) {
+		t.Errorf("origins = %v, want normalized and deduplicated", policy.AllowedOrigins)
+	}
+	if !policy.AllowCredentials || policy.MaxAge != 600 {
+		t.Errorf("preset values not applied: %+v", policy)
+	}
+
+	policy, err = ResolveCORSPolicy(&model.UpsertCORSPolicyRequest{AllowedOriginPatterns: []string{`^https://[a-z0-9-]+\.example\.com$`}})
+	if err != nil {
+		t.Fatalf("ResolveCORSPolicy(pattern) error = %v", err)
+	}
+	if !reflect.DeepEqual(policy.AllowedMethods, defaultCORSMethods) {
+		t.Errorf("methods = %v, want defaults", policy.AllowedMethods)
+	}
+	if policy.AllowedOriginPatterns[0] != `https://[a-z0-9-]+\.example\.com` {
+		t.Errorf("pattern anchors not stripped: %q", policy.AllowedOriginPatterns[0])
+	}
+}
+
+func TestResolveCORSPolicyRejectsUnsafe(t *testing.T) {
+	yes := true
+	tests := map[string]struct {
+		req   model.UpsertCORSPolicyRequest
+		field string
+	}{
+		"no origins":            {model.UpsertCORSPolicyRequest{}, "allowed_origins"},
+		"unknown preset":        {model.UpsertCORSPolicyRequest{Preset: "nope"}, "preset"},
+		"wildcard credentials":  {model.UpsertCORSPolicyRequest{AllowedOrigins: []string{"*"}, AllowCredentials: &yes}, "allowed_origins"},
+		"pattern credentials":   {model.UpsertCORSPolicyRequest{AllowedOriginPatterns: []string{".*"}, AllowCredentials: &yes}, "allowed_origin_patterns"},
+		"origin with path":      {model.UpsertCORSPolicyRequest{AllowedOrigins: []string{"https://a.example.com/app"}}, "allowed_origins"},
+		"pattern injection":     {model.UpsertCORSPolicyRequest{AllowedOriginPatterns: []string{`a"; return 200;`}}, "allowed_origin_patterns"},
+		"relative path prefix":  {model.UpsertCORSPolicyRequest{PathPrefix: "api", AllowedOrigins: []string{"*"}}, "path_prefix"},
+		"header name":           {model.UpsertCORSPolicyRequest{AllowedOrigins: []string{"*"}, AllowedHeaders: []string{"X Bad"}}, "allowed_headers"},
+		"wildcard header creds": {model.UpsertCORSPolicyRequest{AllowedOrigins: []string{"https://a.example.com"}, AllowedHeaders: []string{"*"}, AllowCredentials: &yes}, "allowed_headers"},
+	}
+	for name, tt := range tests {
+		_, err := ResolveCORSPolicy(&tt.req)
+		var perr *CORSPolicyError
+		if !errors.As(err, &perr) || perr.Field != tt.field {
+			t.Errorf("%s: error = %v, want a %s error", name, err, tt.field)
+		}
+	}
+}
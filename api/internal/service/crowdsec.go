@@ -0,0 +1,660 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"nginx-proxy-guard/internal/model"
+	"nginx-proxy-guard/internal/repository"
+)
+
+// BannedIPsRenderer renders the shared banned IP set into nginx
+type BannedIPsRenderer interface {
+	UpdateBannedIPs(ctx context.Context, bannedIPs []string) error
+}
+
+// BanNotifier is notified after a local ban decision has been stored
+// source is the IPBanHistory source (fail2ban, waf_auto_ban); a zero duration means permanent
+type BanNotifier interface {
+	NotifyBan(ip, source, reason string, duration time.Duration)
+}
+
+// ErrCrowdSecDisabled is returned when a sync is requested while the integration is disabled
+var ErrCrowdSecDisabled = errors.New("crowdsec integration is disabled or not configured")
+
+const (
+	crowdSecUserAgent       = "nginx-proxy-guard-bouncer/v1"
+	crowdSecDefaultInterval = 60
+	crowdSecMinInterval     = 10
+	crowdSecMaxReasonLen    = 255
+	// Permanent local bans are shared with a long fixed duration since CrowdSec decisions always expire
+	crowdSecPermanentBan = 365 * 24 * time.Hour
+	// Upper bound for a decision stream response (startup streams with community blocklists are large)
+	maxCrowdSecStreamSize = 64 << 20
+)
+
+// CrowdSecService pulls ban decisions from a CrowdSec LAPI (bouncer) and
+// optionally pushes local ban events back as alerts (watcher)
+type CrowdSecService struct {
+	repo       *repository.CrowdSecRepository
+	renderer   BannedIPsRenderer
+	httpClient *http.Client
+	stopCh     chan struct{}
+	wg         sync.WaitGroup
+	mu         sync.Mutex
+	running    bool
+
+	// Sync state, guarded by syncMu
+	syncMu       sync.Mutex
+	needStartup  bool   // next poll requests the full decision set
+	lastRendered string // joined values last written to nginx
+	hasRendered  bool
+
+	// Watcher JWT cache, guarded by tokenMu
+	tokenMu     sync.Mutex
+	token       string
+	tokenExpiry time.Time
+	tokenKey    string // lapi_url + machine_id the token was issued for
+}
+
+// NewCrowdSecService creates a new CrowdSec service
+func NewCrowdSecService(repo *repository.CrowdSecRepository, renderer BannedIPsRenderer) *CrowdSecService {
+	return &CrowdSecService{
+		repo:     repo,
+		renderer: renderer,
+		httpClient: &http.Client{
+			Timeout: 60 * time.Second,
+		},
+		needStartup: true,
+	}
+}
+
+// Start starts the decision stream poller
+func (s *CrowdSecService) Start() {
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = true
+	s.stopCh = make(chan struct{})
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go s.run()
+	log.Println("[CrowdSec] Bouncer started")
+}
+
+// Stop stops the poller
+func (s *CrowdSecService) Stop() {
+	s.mu.Lock()
+	if !s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = false
+	close(s.stopCh)
+	s.mu.Unlock()
+
+	s.wg.Wait()
+	log.Println("[CrowdSec] Bouncer stopped")
+}
+
+func (s *CrowdSecService) run() {
+	defer s.wg.Done()
+
+	// Initial sync after a short delay
+	wait := 10 * time.Second
+	for {
+		select {
+		case <-time.After(wait):
+		case <-s.stopCh:
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		if _, err := s.Sync(ctx); err != nil && !errors.Is(err, ErrCrowdSecDisabled) {
+			log.Printf("[CrowdSec] Sync failed: %v", err)
+		}
+		wait = s.pollInterval(ctx)
+		cancel()
+	}
+}
+
+func (s *CrowdSecService) pollInterval(ctx context.Context) time.Duration {
+	settings, err := s.repo.GetSettings(ctx)
+	if err != nil || settings.PollInterval <= 0 {
+		return crowdSecDefaultInterval * time.Second
+	}
+	if settings.PollInterval < crowdSecMinInterval {
+		return crowdSecMinInterval * time.Second
+	}
+	return time.Duration(settings.PollInterval) * time.Second
+}
+
+// Reset makes the next sync request the full decision set from LAPI
+// Called when the LAPI URL or key changes
+func (s *CrowdSecService) Reset() {
+	s.syncMu.Lock()
+	s.needStartup = true
+	s.syncMu.Unlock()
+
+	s.tokenMu.Lock()
+	s.token = ""
+	s.tokenMu.Unlock()
+}
+
+// Sync polls the LAPI decision stream, stores the decisions in banned_ips and renders them into nginx
+func (s *CrowdSecService) Sync(ctx context.Context) (*model.CrowdSecSyncResult, error) {
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+
+	settings, err := s.repo.GetSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if !settings.Enabled || settings.LAPIURL == "" || settings.APIKey == "" {
+		// Drop decisions pulled while the integration was enabled
+		if err := s.repo.Clear(ctx); err != nil {
+			return nil, err
+		}
+		s.needStartup = true
+		if err := s.renderLocked(ctx); err != nil {
+			return nil, err
+		}
+		return nil, ErrCrowdSecDisabled
+	}
+
+	startup := s.needStartup
+	stream, err := s.FetchDecisionStream(ctx, settings, startup)
+	if err != nil {
+		s.repo.SetSyncStatus(ctx, err.Error())
+		return nil, err
+	}
+
+	bans, deleted, skipped := ParseCrowdSecDecisions(stream)
+	result := &model.CrowdSecSyncResult{Added: len(bans), Deleted: len(deleted), Skipped: skipped}
+
+	if startup {
+		err = s.repo.ReplaceDecisions(ctx, bans)
+	} else {
+		err = s.repo.ApplyDecisions(ctx, bans, deleted)
+	}
+	if err != nil {
+		s.repo.SetSyncStatus(ctx, err.Error())
+		return nil, err
+	}
+	s.needStartup = false
+
+	if n, err := s.repo.DeleteExpired(ctx); err != nil {
+		log.Printf("[CrowdSec] Failed to delete expired decisions: %v", err)
+	} else if n > 0 {
+		log.Printf("[CrowdSec] Removed %d expired decisions", n)
+	}
+
+	if err := s.renderLocked(ctx); err != nil {
+		s.repo.SetSyncStatus(ctx, err.Error())
+		return nil, err
+	}
+
+	active, err := s.repo.ListActiveValues(ctx)
+	if err == nil {
+		result.ActiveBans = len(active)
+	}
+
+	if result.Added > 0 || result.Deleted > 0 {
+		log.Printf("[CrowdSec] Synced decisions: %d new, %d deleted, %d skipped, %d active",
+			result.Added, result.Deleted, result.Skipped, result.ActiveBans)
+	}
+	s.repo.SetSyncStatus(ctx, "")
+	return result, nil
+}
+
+// renderLocked writes the active CrowdSec decisions to nginx when they changed since the last render
+// Manual unbans and expired decisions are picked up here as well
+func (s *CrowdSecService) renderLocked(ctx context.Context) error {
+	values, err := s.repo.ListActiveValues(ctx)
+	if err != nil {
+		return err
+	}
+
+	key := strings.Join(values, ",")
+	if s.hasRendered && key == s.lastRendered {
+		return nil
+	}
+	if s.renderer == nil {
+		return nil
+	}
+	if err := s.renderer.UpdateBannedIPs(ctx, values); err != nil {
+		return fmt.Errorf("failed to render crowdsec decisions: %w", err)
+	}
+
+	s.lastRendered = key
+	s.hasRendered = true
+	return nil
+}
+
+// FetchDecisionStream requests GET /v1/decisions/stream from LAPI
+func (s *CrowdSecService) FetchDecisionStream(ctx context.Context, settings *model.CrowdSecSettings, startup bool) (*model.CrowdSecDecisionStream, error) {
+	endpoint, err := crowdSecEndpoint(settings.LAPIURL, "/v1/decisions/stream")
+	if err != nil {
+		return nil, err
+	}
+	endpoint += fmt.Sprintf("?startup=%t", startup)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Api-Key", settings.APIKey)
+	req.Header.Set("User-Agent", crowdSecUserAgent)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach LAPI: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("LAPI decision stream returned HTTP %d", resp.StatusCode)
+	}
+
+	var stream model.CrowdSecDecisionStream
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxCrowdSecStreamSize)).Decode(&stream); err != nil {
+		return nil, fmt.Errorf("failed to parse decision stream: %w", err)
+	}
+	return &stream, nil
+}
+
+// ParseCrowdSecDecisions validates stream decisions and converts them to bans
+// Only ban decisions with Ip or Range scope are kept; a value both deleted and
+// re-added in the same response stays banned
+func ParseCrowdSecDecisions(stream *model.CrowdSecDecisionStream) (bans []model.CrowdSecBan, deleted []string, skipped int) {
+	byValue := make(map[string]int)
+	for _, d := range stream.New {
+		value, ok := normalizeCrowdSecDecision(d)
+		if !ok {
+			skipped++
+			continue
+		}
+		duration, err := time.ParseDuration(d.Duration)
+		if err != nil || duration <= 0 {
+			skipped++
+			continue
+		}
+
+		// Several decisions may target the same address; keep the longest
+		if i, exists := byValue[value]; exists {
+			if duration > bans[i].Duration {
+				bans[i].Duration = duration
+				bans[i].Reason = crowdSecReason(d)
+			}
+			continue
+		}
+		byValue[value] = len(bans)
+		bans = append(bans, model.CrowdSecBan{Value: value, Reason: crowdSecReason(d), Duration: duration})
+	}
+
+	seen := make(map[string]bool)
+	for _, d := range stream.Deleted {
+		value, ok := normalizeCrowdSecDecision(d)
+		if !ok {
+			continue
+		}
+		if _, readded := byValue[value]; readded || seen[value] {
+			continue
+		}
+		seen[value] = true
+		deleted = append(deleted, value)
+	}
+
+	return bans, deleted, skipped
+}
+
+// normalizeCrowdSecDecision returns the canonical IP or CIDR of a ban decision
+func normalizeCrowdSecDecision(d model.CrowdSecDecision) (string, bool) {
+	if !strings.EqualFold(d.Type, "ban") {
+		return "", false
+	}
+
+	value := strings.TrimSpace(d.Value)
+	switch strings.ToLower(d.Scope) {
+	case "ip":
+		ip := net.ParseIP(value)
+		if ip == nil {
+			return "", false
+		}
+		return ip.String(), true
+	case "range":
+		_, ipNet, err := net.ParseCIDR(value)
+		if err != nil {
+			return "", false
+		}
+		return ipNet.String(), true
+	default:
+		return "", false
+	}
+}
+
+func crowdSecReason(d model.CrowdSecDecision) string {
+	reason := "CrowdSec"
+	if d.Scenario != "" {
+		reason += ": " + d.Scenario
+	}
+	if d.Origin != "" {
+		reason += " (" + d.Origin + ")"
+	}
+	return truncateString(reason, crowdSecMaxReasonLen)
+}
+
+// TestConnection checks the bouncer API key and, when configured, the watcher credentials
+func (s *CrowdSecService) TestConnection(ctx context.Context, settings *model.CrowdSecSettings) *model.CrowdSecTestResult {
+	result := &model.CrowdSecTestResult{}
+
+	if err := s.checkBouncer(ctx, settings); err != nil {
+		result.BouncerError = err.Error()
+	} else {
+		result.BouncerOK = true
+	}
+
+	if settings.MachineID != "" || settings.MachinePassword != "" {
+		_, _, err := s.login(ctx, settings)
+		ok := err == nil
+		result.WatcherOK = &ok
+		if err != nil {
+			result.WatcherError = err.Error()
+		}
+	}
+
+	return result
+}
+
+func (s *CrowdSecService) checkBouncer(ctx context.Context, settings *model.CrowdSecSettings) error {
+	if settings.LAPIURL == "" || settings.APIKey == "" {
+		return errors.New("LAPI URL and API key are required")
+	}
+
+	endpoint, err := crowdSecEndpoint(settings.LAPIURL, "/v1/decisions")
+	if err != nil {
+		return err
+	}
+	// Querying a loopback address returns no decisions but validates the key
+	endpoint += "?ip=127.0.0.1"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Api-Key", settings.APIKey)
+	req.Header.Set("User-Agent", crowdSecUserAgent)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach LAPI: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+
+	if resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusUnauthorized {
+		return errors.New("LAPI rejected the bouncer API key")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("LAPI returned HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// NotifyBan pushes a local ban to LAPI as an alert when signal sharing is enabled
+// It never blocks the caller
+func (s *CrowdSecService) NotifyBan(ip, source, reason string, duration time.Duration) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		settings, err := s.repo.GetSettings(ctx)
+		if err != nil {
+			log.Printf("[CrowdSec] Failed to load settings for signal push: %v", err)
+			return
+		}
+		if !settings.Enabled || !settings.PushSignals || settings.MachineID == "" || settings.MachinePassword == "" {
+			return
+		}
+
+		if err := s.PushAlert(ctx, settings, ip, source, reason, duration); err != nil {
+			log.Printf("[CrowdSec] Failed to push signal for %s: %v", ip, err)
+		}
+	}()
+}
+
+// crowdSecAlert is the subset of the LAPI alert model sent for a local ban
+type crowdSecAlert struct {
+	Capacity        int32                   `json:"capacity"`
+	Decisions       []crowdSecAlertDecision `json:"decisions"`
+	Events          []crowdSecAlertEvent    `json:"events"`
+	EventsCount     int32                   `json:"events_count"`
+	Leakspeed       string                  `json:"leakspeed"`
+	Message         string                  `json:"message"`
+	Scenario        string                  `json:"scenario"`
+	ScenarioHash    string                  `json:"scenario_hash"`
+	ScenarioVersion string                  `json:"scenario_version"`
+	Simulated       bool                    `json:"simulated"`
+	Source          crowdSecAlertSource     `json:"source"`
+	StartAt         string                  `json:"start_at"`
+	StopAt          string                  `json:"stop_at"`
+}
+
+type crowdSecAlertDecision struct {
+	Duration string `json:"duration"`
+	Origin   string `json:"origin"`
+	Scenario string `json:"scenario"`
+	Scope    string `json:"scope"`
+	Type     string `json:"type"`
+	Value    string `json:"value"`
+}
+
+type crowdSecAlertEvent struct {
+	Timestamp string              `json:"timestamp"`
+	Meta      []crowdSecAlertMeta `json:"meta"`
+}
+
+type crowdSecAlertMeta struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+type crowdSecAlertSource struct {
+	Scope string `json:"scope"`
+	Value string `json:"value"`
+	IP    string `json:"ip,omitempty"`
+	Range string `json:"range,omitempty"`
+}
+
+// PushAlert sends a single local ban as an alert with a ban decision to POST /v1/alerts
+func (s *CrowdSecService) PushAlert(ctx context.Context, settings *model.CrowdSecSettings, ip, source, reason string, duration time.Duration) error {
+	scope := "Ip"
+	alertSource := crowdSecAlertSource{Scope: scope, Value: ip, IP: ip}
+	if _, ipNet, err := net.ParseCIDR(ip); err == nil {
+		scope = "Range"
+		alertSource = crowdSecAlertSource{Scope: scope, Value: ipNet.String(), Range: ipNet.String()}
+	} else if net.ParseIP(ip) == nil {
+		return fmt.Errorf("invalid IP address: %s", ip)
+	}
+
+	if duration <= 0 {
+		duration = crowdSecPermanentBan
+	}
+
+	scenario := "nginx-proxy-guard/" + source
+	now := time.Now().UTC().Format(time.RFC3339)
+	alert := crowdSecAlert{
+		Decisions: []crowdSecAlertDecision{{
+			Duration: duration.String(),
+			Origin:   "crowdsec",
+			Scenario: scenario,
+			Scope:    scope,
+			Type:     "ban",
+			Value:    alertSource.Value,
+		}},
+		Events: []crowdSecAlertEvent{{
+			Timestamp: now,
+			Meta: []crowdSecAlertMeta{
+				{Key: "source_ip", Value: ip},
+				{Key: "reason", Value: reason},
+			},
+		}},
+		EventsCount: 1,
+		Leakspeed:   "0",
+		Message:     fmt.Sprintf("%s banned by Nginx Proxy Guard (%s): %s", ip, source, reason),
+		Scenario:    scenario,
+		Source:      alertSource,
+		StartAt:     now,
+		StopAt:      now,
+	}
+
+	body, err := json.Marshal([]crowdSecAlert{alert})
+	if err != nil {
+		return err
+	}
+
+	token, err := s.cachedToken(ctx, settings)
+	if err != nil {
+		return err
+	}
+
+	status, err := s.postAlerts(ctx, settings, token, body)
+	if err == nil && status == http.StatusUnauthorized {
+		// Token revoked or expired early, log in again once
+		s.tokenMu.Lock()
+		s.token = ""
+		s.tokenMu.Unlock()
+		if token, err = s.cachedToken(ctx, settings); err != nil {
+			return err
+		}
+		status, err = s.postAlerts(ctx, settings, token, body)
+	}
+	if err != nil {
+		return err
+	}
+	if status != http.StatusCreated && status != http.StatusOK {
+		return fmt.Errorf("LAPI alerts endpoint returned HTTP %d", status)
+	}
+	return nil
+}
+
+func (s *CrowdSecService) postAlerts(ctx context.Context, settings *model.CrowdSecSettings, token string, body []byte) (int, error) {
+	endpoint, err := crowdSecEndpoint(settings.LAPIURL, "/v1/alerts")
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", crowdSecUserAgent)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to reach LAPI: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+
+	return resp.StatusCode, nil
+}
+
+// cachedToken returns a valid watcher JWT, logging in when needed
+func (s *CrowdSecService) cachedToken(ctx context.Context, settings *model.CrowdSecSettings) (string, error) {
+	key := settings.LAPIURL + "|" + settings.MachineID
+
+	s.tokenMu.Lock()
+	defer s.tokenMu.Unlock()
+
+	if s.token != "" && s.tokenKey == key && time.Until(s.tokenExpiry) > time.Minute {
+		return s.token, nil
+	}
+
+	token, expiry, err := s.login(ctx, settings)
+	if err != nil {
+		return "", err
+	}
+	s.token, s.tokenExpiry, s.tokenKey = token, expiry, key
+	return token, nil
+}
+
+// login authenticates the watcher with POST /v1/watchers/login
+func (s *CrowdSecService) login(ctx context.Context, settings *model.CrowdSecSettings) (string, time.Time, error) {
+	if settings.MachineID == "" || settings.MachinePassword == "" {
+		return "", time.Time{}, errors.New("machine ID and password are required for signal sharing")
+	}
+
+	endpoint, err := crowdSecEndpoint(settings.LAPIURL, "/v1/watchers/login")
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"machine_id": settings.MachineID,
+		"password":   settings.MachinePassword,
+		"scenarios":  []string{"nginx-proxy-guard/" + model.BanSourceWAFAutoBan, "nginx-proxy-guard/" + model.BanSourceFail2ban},
+	})
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", crowdSecUserAgent)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to reach LAPI: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+		return "", time.Time{}, fmt.Errorf("LAPI watcher login returned HTTP %d", resp.StatusCode)
+	}
+
+	var loginResp struct {
+		Token  string `json:"token"`
+		Expire string `json:"expire"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&loginResp); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to parse login response: %w", err)
+	}
+	if loginResp.Token == "" {
+		return "", time.Time{}, errors.New("LAPI login response did not include a token")
+	}
+
+	expiry, err := time.Parse(time.RFC3339, loginResp.Expire)
+	if err != nil {
+		// LAPI tokens are valid for an hour by default
+		expiry = time.Now().Add(time.Hour)
+	}
+	return loginResp.Token, expiry, nil
+}
+
+// crowdSecEndpoint joins the LAPI base URL with an API path
+func crowdSecEndpoint(baseURL, path string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(baseURL))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("invalid LAPI URL: %s", baseURL)
+	}
+	return strings.TrimRight(u.String(), "/") + path, nil
+}
@@ -0,0 +1,260 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"nginx-proxy-guard/internal/model"
+)
+
+// fakeLAPI is a minimal stand-in for the CrowdSec Local API
+type fakeLAPI struct {
+	mu          sync.Mutex
+	startupSeen []string
+	logins      int
+	alerts      []crowdSecAlert
+	rejectToken string // token answered with 401 once
+}
+
+const (
+	fakeLAPIBouncerKey = "bouncer-key"
+	fakeLAPIMachineID  = "npg-test"
+	fakeLAPIPassword   = "secret"
+)
+
+func (f *fakeLAPI) handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/v1/decisions/stream", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Api-Key") != fakeLAPIBouncerKey {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		f.mu.Lock()
+		f.startupSeen = append(f.startupSeen, r.URL.Query().Get("startup"))
+		f.mu.Unlock()
+
+		w.Write([]byte(`{
+			"new": [
+				{"id": 1, "origin": "CAPI", "type": "ban", "scope": "Ip", "value": "203.0.113.7", "duration": "3h59m58s", "scenario": "crowdsecurity/ssh-bf"},
+				{"id": 2, "origin": "crowdsec", "type": "ban", "scope": "Ip", "value": "203.0.113.7", "duration": "167h", "scenario": "crowdsecurity/http-probing"},
+				{"id": 3, "origin": "lists", "type": "ban", "scope": "Range", "value": "198.51.100.17/24", "duration": "24h", "scenario": "firehol"},
+				{"id": 4, "origin": "crowdsec", "type": "captcha", "scope": "Ip", "value": "192.0.2.1", "duration": "4h", "scenario": "crowdsecurity/http-crawl"},
+				{"id": 5, "origin": "cscli", "type": "ban", "scope": "Country", "value": "XX", "duration": "4h", "scenario": "manual"},
+				{"id": 6, "origin": "cscli", "type": "ban", "scope": "Ip", "value": "not-an-ip", "duration": "4h", "scenario": "manual"},
+				{"id": 7, "origin": "cscli", "type": "ban", "scope": "Ip", "value": "2001:db8::1", "duration": "-5s", "scenario": "manual"}
+			],
+			"deleted": [
+				{"id": 8, "origin": "crowdsec", "type": "ban", "scope": "Ip", "value": "192.0.2.50", "duration": "-1s"},
+				{"id": 9, "origin": "crowdsec", "type": "ban", "scope": "Ip", "value": "203.0.113.7", "duration": "-1s"}
+			]
+		}`))
+	})
+
+	mux.HandleFunc("/v1/decisions", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Api-Key") != fakeLAPIBouncerKey {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write([]byte("null"))
+	})
+
+	mux.HandleFunc("/v1/watchers/login", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			MachineID string `json:"machine_id"`
+			Password  string `json:"password"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.MachineID != fakeLAPIMachineID || req.Password != fakeLAPIPassword {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		f.mu.Lock()
+		f.logins++
+		token := fmt.Sprintf("token-%d", f.logins)
+		f.mu.Unlock()
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"code":   200,
+			"expire": time.Now().Add(time.Hour).UTC().Format(time.RFC3339),
+			"token":  token,
+		})
+	})
+
+	mux.HandleFunc("/v1/alerts", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		auth := r.Header.Get("Authorization")
+		if auth == "" || auth == "Bearer "+f.rejectToken {
+			f.rejectToken = ""
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var alerts []crowdSecAlert
+		if err := json.NewDecoder(r.Body).Decode(&alerts); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.alerts = append(f.alerts, alerts...)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`["1"]`))
+	})
+
+	return mux
+}
+
+func TestCrowdSecDecisionStream(t *testing.T) {
+	lapi := &fakeLAPI{}
+	srv := httptest.NewServer(lapi.handler())
+	defer srv.Close()
+
+	svc := NewCrowdSecService(nil, nil)
+	settings := &model.CrowdSecSettings{LAPIURL: srv.URL + "/", APIKey: fakeLAPIBouncerKey}
+
+	stream, err := svc.FetchDecisionStream(context.Background(), settings, true)
+	if err != nil {
+		t.Fatalf("FetchDecisionStream() error = %v", err)
+	}
+	if !reflect.DeepEqual(lapi.startupSeen, []string{"true"}) {
+		t.Errorf("startup params = %v, want [true]", lapi.startupSeen)
+	}
+
+	bans, deleted, skipped := ParseCrowdSecDecisions(stream)
+
+	wantBans := []model.CrowdSecBan{
+		{Value: "203.0.113.7", Reason: "CrowdSec: crowdsecurity/http-probing (crowdsec)", Duration: 167 * time.Hour},
+		{Value: "198.51.100.0/24", Reason: "CrowdSec: firehol (lists)", Duration: 24 * time.Hour},
+	}
+	if !reflect.DeepEqual(bans, wantBans) {
+		t.Errorf("bans = %+v, want %+v", bans, wantBans)
+	}
+	// 203.0.113.7 is deleted and re-added in the same response, so it must stay banned
+	if !reflect.DeepEqual(deleted, []string{"192.0.2.50"}) {
+		t.Errorf("deleted = %v, want [192.0.2.50]", deleted)
+	}
+	// captcha, country scope, invalid value and expired duration
+	if skipped != 4 {
+		t.Errorf("skipped = %d, want 4", skipped)
+	}
+
+	t.Run("rejected key", func(t *testing.T) {
+		bad := &model.CrowdSecSettings{LAPIURL: srv.URL, APIKey: "wrong"}
+		if _, err := svc.FetchDecisionStream(context.Background(), bad, false); err == nil {
+			t.Error("FetchDecisionStream() expected error for rejected key")
+		}
+	})
+
+	t.Run("invalid url", func(t *testing.T) {
+		bad := &model.CrowdSecSettings{LAPIURL: "ftp://lapi", APIKey: fakeLAPIBouncerKey}
+		if _, err := svc.FetchDecisionStream(context.Background(), bad, false); err == nil {
+			t.Error("FetchDecisionStream() expected error for non-http URL")
+		}
+	})
+}
+
+func TestCrowdSecTestConnection(t *testing.T) {
+	lapi := &fakeLAPI{}
+	srv := httptest.NewServer(lapi.handler())
+	defer srv.Close()
+
+	svc := NewCrowdSecService(nil, nil)
+
+	result := svc.TestConnection(context.Background(), &model.CrowdSecSettings{
+		LAPIURL: srv.URL, APIKey: fakeLAPIBouncerKey,
+		MachineID: fakeLAPIMachineID, MachinePassword: fakeLAPIPassword,
+	})
+	if !result.BouncerOK || result.WatcherOK == nil || !*result.WatcherOK {
+		t.Errorf("TestConnection() = %+v, want bouncer and watcher OK", result)
+	}
+
+	result = svc.TestConnection(context.Background(), &model.CrowdSecSettings{
+		LAPIURL: srv.URL, APIKey: "wrong",
+		MachineID: fakeLAPIMachineID, MachinePassword: "wrong",
+	})
+	if result.BouncerOK || result.BouncerError == "" || result.WatcherOK == nil || *result.WatcherOK {
+		t.Errorf("TestConnection() = %+v, want bouncer and watcher failures", result)
+	}
+
+	// Watcher is not checked when no credentials are configured
+	result = svc.TestConnection(context.Background(), &model.CrowdSecSettings{LAPIURL: srv.URL, APIKey: fakeLAPIBouncerKey})
+	if !result.BouncerOK || result.WatcherOK != nil {
+		t.Errorf("TestConnection() = %+v, want bouncer OK and no watcher result", result)
+	}
+}
+
+func TestCrowdSecPushAlert(t *testing.T) {
+	lapi := &fakeLAPI{}
+	srv := httptest.NewServer(lapi.handler())
+	defer srv.Close()
+
+	svc := NewCrowdSecService(nil, nil)
+	settings := &model.CrowdSecSettings{LAPIURL: srv.URL, MachineID: fakeLAPIMachineID, MachinePassword: fakeLAPIPassword}
+	ctx := context.Background()
+
+	if err := svc.PushAlert(ctx, settings, "203.0.113.7", model.BanSourceWAFAutoBan, "WAF threshold exceeded", time.Hour); err != nil {
+		t.Fatalf("PushAlert() error = %v", err)
+	}
+	// The token is cached between pushes
+	if err := svc.PushAlert(ctx, settings, "203.0.113.8", model.BanSourceFail2ban, "Too many 401", 0); err != nil {
+		t.Fatalf("PushAlert() error = %v", err)
+	}
+	if lapi.logins != 1 {
+		t.Errorf("logins = %d, want 1", lapi.logins)
+	}
+
+	// A revoked token triggers a single re-login
+	lapi.mu.Lock()
+	lapi.rejectToken = "token-1"
+	lapi.mu.Unlock()
+	if err := svc.PushAlert(ctx, settings, "198.51.100.0/24", model.BanSourceWAFAutoBan, "subnet", time.Hour); err != nil {
+		t.Fatalf("PushAlert() after revoked token error = %v", err)
+	}
+	if lapi.logins != 2 {
+		t.Errorf("logins = %d, want 2", lapi.logins)
+	}
+
+	if len(lapi.alerts) != 3 {
+		t.Fatalf("alerts = %d, want 3", len(lapi.alerts))
+	}
+
+	first := lapi.alerts[0]
+	if first.Scenario != "nginx-proxy-guard/waf_auto_ban" || first.Source.Scope != "Ip" || first.Source.IP != "203.0.113.7" {
+		t.Errorf("first alert = %+v", first)
+	}
+	if len(first.Decisions) != 1 || first.Decisions[0].Type != "ban" || first.Decisions[0].Duration != "1h0m0s" {
+		t.Errorf("first alert decisions = %+v", first.Decisions)
+	}
+	if d := lapi.alerts[1].Decisions[0].Duration; d != crowdSecPermanentBan.String() {
+		t.Errorf("permanent ban duration = %s, want %s", d, crowdSecPermanentBan)
+	}
+	if src := lapi.alerts[2].Source; src.Scope != "Range" || src.Range != "198.51.100.0/24" {
+		t.Errorf("range alert source = %+v", src)
+	}
+
+	t.Run("invalid ip", func(t *testing.T) {
+		if err := svc.PushAlert(ctx, settings, "bogus", model.BanSourceFail2ban, "", time.Hour); err == nil {
+			t.Error("PushAlert() expected error for invalid IP")
+		}
+	})
+}
+
+func TestCrowdSecReasonTruncatesOnRunes(t *testing.T) {
+	reason := crowdSecReason(model.CrowdSecDecision{Scenario: strings.Repeat("공격", 200), Origin: "CAPI"})
+	if !utf8.ValidString(reason) {
+		t.Fatalf("crowdSecReason() returned invalid UTF-8")
+	}
+	if n := utf8.RuneCountInString(reason); n != crowdSecMaxReasonLen {
+		t.Errorf("crowdSecReason() has %d runes, want %d", n, crowdSecMaxReasonLen)
+	}
+
+	if got := crowdSecReason(model.CrowdSecDecision{Scenario: "crowdsecurity/ssh-bf", Origin: "crowdsec"}); got != "CrowdSec: crowdsecurity/ssh-bf (crowdsec)" {
+		t.Errorf("crowdSecReason() = %q", got)
+	}
+}
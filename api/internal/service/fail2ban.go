@@ -25,6 +25,10 @@ type Fail2banService struct {
 	proxyHostService *ProxyHostService
 	redisCache      *cache.RedisClient
 	historyRepo     *repository.IPBanHistoryRepository
+	banNotifier     BanNotifier
+	banEscalation   *BanEscalationService
+	banAggregation  *BanAggregationService
+	trustedIPs      *TrustedIPService
 
 	// In-memory tracking of failed requests per IP per host (fallback when Redis unavailable)
 	mu       sync.RWMutex
@@ -57,6 +61,26 @@ func NewFail2banService(
 	}
 }
 
+// SetBanNotifier sets the notifier called after each ban (e.g. CrowdSec signal sharing)
+func (s *Fail2banService) SetBanNotifier(notifier BanNotifier) {
+	s.banNotifier = notifier
+}
+
+// SetBanEscalation enables escalating ban durations for repeat offenders
+func (s *Fail2banService) SetBanEscalation(escalation *BanEscalationService) {
+	s.banEscalation = escalation
+}
+
+// SetBanAggregation enables replacing sibling bans with a single subnet ban
+func (s *Fail2banService) SetBanAggregation(aggregation *BanAggregationService) {
+	s.banAggregation = aggregation
+}
+
+// SetTrustedIPs makes bans skip addresses in the global trusted IP allowlist
+func (s *Fail2banService) SetTrustedIPs(trustedIPs *TrustedIPService) {
+	s.trustedIPs = trustedIPs
+}
+
 // Start begins the Fail2ban service background tasks
 func (s *Fail2banService) Start(ctx context.Context) {
 	// Load initial configs
@@ -272,6 +296,25 @@ func (s *Fail2banService) banIP(ctx context.Context, ip string, hostID string, r
 		return nil
 	}
 
+	// Never ban addresses in the global trusted IP allowlist
+	if s.trustedIPs != nil && s.trustedIPs.PreventBan(ctx, ip, model.BanSourceFail2ban, reason, &hostID) {
+		return nil
+	}
+
+	// Already blocked by an aggregated subnet ban
+	if s.banAggregation != nil {
+		if cidr := s.banAggregation.CoveringSubnet(ctx, ip, &hostID); cidr != "" {
+			log.Printf("[Fail2ban] %s is already covered by subnet ban %s", ip, cidr)
+			return nil
+		}
+	}
+
+	// Repeat offenders get a longer ban (must run before this ban is recorded in history)
+	var escalation *model.BanEscalation
+	if s.banEscalation != nil {
+		banTime, escalation = s.banEscalation.Resolve(ctx, ip, banTime)
+	}
+
 	id := uuid.New().String()
 	now := time.Now()
 
@@ -336,11 +379,29 @@ func (s *Fail2banService) banIP(ctx context.Context, ip string, hostID string, r
 			IsAuto:      true,
 			FailCount:   &failCount,
 		}
+		if escalation != nil {
+			historyEvent.EscalationLevel = &escalation.Level
+			historyEvent.EscalationReason = escalation.Explanation
+		}
 		if err := s.historyRepo.RecordBanEvent(ctx, historyEvent); err != nil {
 			log.Printf("[Fail2ban] Warning: Failed to record ban history: %v", err)
 		}
 	}
 
+	if s.banNotifier != nil {
+		s.banNotifier.NotifyBan(ip, model.BanSourceFail2ban, reason, time.Duration(banTime)*time.Second)
+	}
+
+	// Collapse sibling bans from the same prefix into one subnet ban
+	if s.banAggregation != nil {
+		subnetBan, err := s.banAggregation.Aggregate(ctx, ip, &hostID, model.BanSourceFail2ban)
+		if err != nil {
+			log.Printf("[Fail2ban] Warning: Subnet aggregation failed: %v", err)
+		} else if subnetBan != nil && s.banNotifier != nil {
+			s.banNotifier.NotifyBan(subnetBan.IPAddress, model.BanSourceFail2ban, subnetBan.Reason, banRemaining(subnetBan))
+		}
+	}
+
 	// Regenerate nginx config to apply the ban (async for speed)
 	if s.proxyHostService != nil {
 		go func() {
@@ -403,7 +464,7 @@ func (s *Fail2banService) cleanupExpiredBans(ctx context.Context) {
 	selectQuery := `
 		SELECT id, proxy_host_id, ip_address, reason
 		FROM banned_ips
-		WHERE expires_at IS NOT NULL AND expires_at < NOW() AND is_auto_banned = true
+		WHERE expires_at IS NOT NULL AND expires_at < NOW() AND is_auto_banned = true AND origin <> 'crowdsec'
 	`
 	rows, err := s.db.QueryContext(ctx, selectQuery)
 	if err != nil {
@@ -469,7 +530,7 @@ func (s *Fail2banService) cleanupExpiredBans(ctx context.Context) {
 	}
 
 	// Delete expired bans
-	deleteQuery := `DELETE FROM banned_ips WHERE expires_at IS NOT NULL AND expires_at < NOW() AND is_auto_banned = true`
+	deleteQuery := `DELETE FROM banned_ips WHERE expires_at IS NOT NULL AND expires_at < NOW() AND is_auto_banned = true AND origin <> 'crowdsec'`
 	result, err := s.db.ExecContext(ctx, deleteQuery)
 	if err != nil {
 		log.Printf("[Fail2ban] Failed to cleanup expired bans: %v", err)
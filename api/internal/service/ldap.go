@@ -0,0 +1,517 @@
+package service
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+
+	"nginx-proxy-guard/internal/model"
+	"nginx-proxy-guard/internal/repository"
+)
+
+var (
+	ErrLDAPUnavailable  = errors.New("directory server is unavailable")
+	ErrLDAPAccessDenied = errors.New("directory user is not allowed to log in")
+)
+
+const (
+	DefaultLDAPTimeout = 10
+	MaxLDAPTimeout     = 60
+)
+
+// ldapUser is a directory user whose password was verified
+type ldapUser struct {
+	DN     string
+	Email  string
+	Groups []string
+}
+
+// LDAPService authenticates admins against an LDAP directory or Active
+// Directory. Users are provisioned on their first login and their role is
+// synced from group membership on every login.
+type LDAPService struct {
+	repo     *repository.LDAPRepository
+	userRepo *repository.UserRepository
+	authRepo *repository.AuthRepository
+}
+
+func NewLDAPService(repo *repository.LDAPRepository, userRepo *repository.UserRepository, authRepo *repository.AuthRepository) *LDAPService {
+	return &LDAPService{repo: repo, userRepo: userRepo, authRepo: authRepo}
+}
+
+// GetSettings returns the LDAP settings
+func (s *LDAPService) GetSettings(ctx context.Context) (*model.LDAPSettings, error) {
+	return s.repo.GetSettings(ctx)
+}
+
+// UpdateSettings merges and validates an update of the LDAP settings
+func (s *LDAPService) UpdateSettings(ctx context.Context, req *model.UpdateLDAPSettingsRequest) (*model.LDAPSettings, error) {
+	settings, err := s.repo.GetSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	mergeLDAPSettings(settings, req)
+	if err := normalizeLDAPSettings(settings); err != nil {
+		return nil, err
+	}
+	return s.repo.SaveSettings(ctx, settings)
+}
+
+// TestConnection runs a bind and search with the stored settings and the
+// request's changes applied, without saving them
+func (s *LDAPService) TestConnection(ctx context.Context, req *model.LDAPTestRequest) (*model.LDAPTestResult, error) {
+	settings, err := s.repo.GetSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	mergeLDAPSettings(settings, &req.UpdateLDAPSettingsRequest)
+	settings.Enabled = true
+	if err := normalizeLDAPSettings(settings); err != nil {
+		return nil, err
+	}
+	return testLDAP(settings, req.Username, req.Password), nil
+}
+
+// Authenticate verifies a password against the directory and returns the
+// provisioned or updated user. It returns a nil user when LDAP is disabled.
+func (s *LDAPService) Authenticate(ctx context.Context, username, password string, existing *model.User) (*model.User, error) {
+	settings, err := s.repo.GetSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !settings.Enabled {
+		return nil, nil
+	}
+
+	entry, err := lookupLDAPUser(settings, username, password)
+	if err != nil {
+		if !errors.Is(err, ErrInvalidCredentials) {
+			log.Printf("[LDAP] Login of %s failed: %v", username, err)
+		}
+		return nil, err
+	}
+
+	role := mapLDAPRole(settings, entry.Groups)
+	if role == "" {
+		return nil, ErrLDAPAccessDenied
+	}
+
+	if existing == nil {
+		if !settings.AutoProvision || !usernamePattern.MatchString(username) {
+			return nil, ErrLDAPAccessDenied
+		}
+		user := &model.User{Username: username, Role: role, LDAPDN: entry.DN}
+		if entry.Email != "" {
+			taken, err := s.userRepo.EmailExists(ctx, entry.Email)
+			if err != nil {
+				return nil, err
+			}
+			if !taken {
+				user.Email = entry.Email
+			}
+		}
+		if err := s.repo.ProvisionUser(ctx, user); err != nil {
+			return nil, err
+		}
+		log.Printf("[LDAP] Provisioned user %s (%s)", username, role)
+		return s.authRepo.GetUserByID(ctx, user.ID)
+	}
+
+	if existing.LDAPDN != entry.DN {
+		if err := s.repo.SetDN(ctx, existing.ID, entry.DN); err != nil {
+			return nil, err
+		}
+		existing.LDAPDN = entry.DN
+	}
+	if existing.Role != role {
+		if err := applyMappedRole(ctx, s.userRepo, existing, role); err != nil {
+			return nil, err
+		}
+	}
+	return existing, nil
+}
+
+// mergeLDAPSettings applies the set fields of an update request
+func mergeLDAPSettings(settings *model.LDAPSettings, req *model.UpdateLDAPSettingsRequest) {
+	if req.Enabled != nil {
+		settings.Enabled = *req.Enabled
+	}
+	if req.URL != nil {
+		settings.URL = strings.TrimSpace(*req.URL)
+	}
+	if req.StartTLS != nil {
+		settings.StartTLS = *req.StartTLS
+	}
+	if req.SkipTLSVerify != nil {
+		settings.SkipTLSVerify = *req.SkipTLSVerify
+	}
+	if req.BindDN != nil {
+		settings.BindDN = strings.TrimSpace(*req.BindDN)
+		if settings.BindDN == "" {
+			settings.BindPassword = ""
+		}
+	}
+	if req.BindPassword != nil && *req.BindPassword != "" {
+		settings.BindPassword = *req.BindPassword
+	}
+	if req.SearchBase != nil {
+		settings.SearchBase = strings.TrimSpace(*req.SearchBase)
+	}
+	if req.UserFilter != nil {
+		settings.UserFilter = strings.TrimSpace(*req.UserFilter)
+	}
+	if req.EmailAttribute != nil {
+		settings.EmailAttribute = strings.TrimSpace(*req.EmailAttribute)
+	}
+	if req.GroupAttribute != nil {
+		settings.GroupAttribute = strings.TrimSpace(*req.GroupAttribute)
+	}
+	if req.GroupSearchBase != nil {
+		settings.GroupSearchBase = strings.TrimSpace(*req.GroupSearchBase)
+	}
+	if req.GroupFilter != nil {
+		settings.GroupFilter = strings.TrimSpace(*req.GroupFilter)
+	}
+	if req.GroupMappings != nil {
+		settings.GroupMappings = *req.GroupMappings
+	}
+	if req.DefaultRole != nil {
+		settings.DefaultRole = strings.TrimSpace(*req.DefaultRole)
+	}
+	if req.AutoProvision != nil {
+		settings.AutoProvision = *req.AutoProvision
+	}
+	if req.Timeout != nil {
+		settings.Timeout = *req.Timeout
+	}
+}
+
+// normalizeLDAPSettings validates settings before they are saved or tested
+func normalizeLDAPSettings(settings *model.LDAPSettings) error {
+	if settings.Timeout == 0 {
+		settings.Timeout = DefaultLDAPTimeout
+	}
+	if settings.Timeout < 1 || settings.Timeout > MaxLDAPTimeout {
+		return &UserError{Field: "timeout", Message: fmt.Sprintf("must be between 1 and %d seconds", MaxLDAPTimeout)}
+	}
+
+	if settings.DefaultRole != "" && !model.IsValidRole(settings.DefaultRole) {
+		return &UserError{Field: "default_role", Message: fmt.Sprintf("must be empty or one of %s", strings.Join(model.AllRoles, ", "))}
+	}
+	mappings := make([]model.LDAPGroupMapping, 0, len(settings.GroupMappings))
+	for _, m := range settings.GroupMappings {
+		m.Group = strings.TrimSpace(m.Group)
+		if m.Group == "" {
+			return &UserError{Field: "group_mappings", Message: "group is required"}
+		}
+		if !model.IsValidRole(m.Role) {
+			return &UserError{Field: "group_mappings", Message: fmt.Sprintf("role must be one of %s", strings.Join(model.AllRoles, ", "))}
+		}
+		mappings = append(mappings, m)
+	}
+	settings.GroupMappings = mappings
+
+	if !settings.Enabled {
+		return nil
+	}
+
+	u, err := url.Parse(settings.URL)
+	if err != nil || (u.Scheme != "ldap" && u.Scheme != "ldaps") || u.Host == "" {
+		return &UserError{Field: "url", Message: "must be an ldap:// or ldaps:// URL"}
+	}
+	if settings.StartTLS && u.Scheme == "ldaps" {
+		return &UserError{Field: "start_tls", Message: "cannot be used with ldaps://"}
+	}
+	if settings.BindDN != "" && settings.BindPassword == "" {
+		return &UserError{Field: "bind_password", Message: "is required with a bind DN"}
+	}
+	if settings.SearchBase == "" {
+		return &UserError{Field: "search_base", Message: "is required"}
+	}
+	if !validLDAPFilter(settings.UserFilter, "{username}") {
+		return &UserError{Field: "user_filter", Message: "must be a filter in parentheses containing {username}"}
+	}
+	if settings.GroupSearchBase != "" && !validLDAPFilter(settings.GroupFilter, "{dn}") {
+		return &UserError{Field: "group_filter", Message: "must be a filter in parentheses containing {dn}"}
+	}
+	return nil
+}
+
+func validLDAPFilter(filter, placeholder string) bool {
+	if !strings.HasPrefix(filter, "(") || !strings.HasSuffix(filter, ")") || !strings.Contains(filter, placeholder) {
+		return false
+	}
+	_, err := ldap.CompileFilter(strings.ReplaceAll(filter, placeholder, "x"))
+	return err == nil
+}
+
+// ldapFilter fills a filter template with an escaped value
+func ldapFilter(template, placeholder, value string) string {
+	return strings.ReplaceAll(template, placeholder, ldap.EscapeFilter(value))
+}
+
+// dialLDAP connects to the directory, upgrading with StartTLS when configured
+func dialLDAP(settings *model.LDAPSettings) (*ldap.Conn, error) {
+	u, err := url.Parse(settings.URL)
+	if err != nil {
+		return nil, err
+	}
+	timeout := time.Duration(settings.Timeout) * time.Second
+	tlsConfig := &tls.Config{
+		ServerName:         u.Hostname(),
+		InsecureSkipVerify: settings.SkipTLSVerify,
+		MinVersion:         tls.VersionTLS12,
+	}
+
+	conn, err := ldap.DialURL(settings.URL,
+		ldap.DialWithDialer(&net.Dialer{Timeout: timeout}),
+		ldap.DialWithTLSConfig(tlsConfig))
+	if err != nil {
+		return nil, err
+	}
+	conn.SetTimeout(timeout)
+
+	if settings.StartTLS {
+		if err := conn.StartTLS(tlsConfig); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("StartTLS failed: %w", err)
+		}
+	}
+	return conn, nil
+}
+
+// bindService binds as the service account, or anonymously without one
+func bindService(conn *ldap.Conn, settings *model.LDAPSettings) error {
+	if settings.BindDN == "" {
+		return conn.UnauthenticatedBind("")
+	}
+	return conn.Bind(settings.BindDN, settings.BindPassword)
+}
+
+// searchLDAPUser finds the single entry matching the user filter, or nil
+func searchLDAPUser(conn *ldap.Conn, settings *model.LDAPSettings, username string) (*ldap.Entry, error) {
+	attributes := []string{"dn"}
+	if settings.EmailAttribute != "" {
+		attributes = append(attributes, settings.EmailAttribute)
+	}
+	if settings.GroupAttribute != "" {
+		attributes = append(attributes, settings.GroupAttribute)
+	}
+
+	result, err := conn.Search(ldap.NewSearchRequest(
+		settings.SearchBase, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 2, settings.Timeout, false,
+		ldapFilter(settings.UserFilter, "{username}", username), attributes, nil,
+	))
+	if err != nil && !ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) {
+		return nil, err
+	}
+	switch {
+	case result == nil || len(result.Entries) == 0:
+		return nil, nil
+	case len(result.Entries) > 1:
+		return nil, fmt.Errorf("user filter matches more than one entry")
+	}
+	return result.Entries[0], nil
+}
+
+// ldapUserGroups returns the DNs of the user's groups from the group
+// attribute and, when a group search base is set, from a group search
+func ldapUserGroups(conn *ldap.Conn, settings *model.LDAPSettings, entry *ldap.Entry) ([]string, error) {
+	var groups []string
+	if settings.GroupAttribute != "" {
+		groups = append(groups, entry.GetAttributeValues(settings.GroupAttribute)...)
+	}
+	if settings.GroupSearchBase == "" {
+		return groups, nil
+	}
+
+	result, err := conn.Search(ldap.NewSearchRequest(
+		settings.GroupSearchBase, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, settings.Timeout, false,
+		ldapFilter(settings.GroupFilter, "{dn}", entry.DN), []string{"dn"}, nil,
+	))
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range result.Entries {
+		groups = append(groups, g.DN)
+	}
+	return groups, nil
+}
+
+// lookupLDAPUser verifies a user's password by binding as the user's entry
+func lookupLDAPUser(settings *model.LDAPSettings, username, password string) (*ldapUser, error) {
+	// An empty password would be an unauthenticated bind, which succeeds
+	if username == "" || password == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	conn, err := dialLDAP(settings)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrLDAPUnavailable, err)
+	}
+	defer conn.Close()
+
+	if err := bindService(conn, settings); err != nil {
+		return nil, fmt.Errorf("%w: service bind failed: %v", ErrLDAPUnavailable, err)
+	}
+	entry, err := searchLDAPUser(conn, settings, username)
+	if err != nil {
+		return nil, fmt.Errorf("%w: user search failed: %v", ErrLDAPUnavailable, err)
+	}
+	if entry == nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	if err := conn.Bind(entry.DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, fmt.Errorf("%w: user bind failed: %v", ErrLDAPUnavailable, err)
+	}
+
+	// Group searches run with the service account's rights
+	if settings.GroupSearchBase != "" {
+		if err := bindService(conn, settings); err != nil {
+			return nil, fmt.Errorf("%w: service bind failed: %v", ErrLDAPUnavailable, err)
+		}
+	}
+	groups, err := ldapUserGroups(conn, settings, entry)
+	if err != nil {
+		return nil, fmt.Errorf("%w: group search failed: %v", ErrLDAPUnavailable, err)
+	}
+
+	user := &ldapUser{DN: entry.DN, Groups: groups}
+	if settings.EmailAttribute != "" {
+		user.Email = entry.GetAttributeValue(settings.EmailAttribute)
+	}
+	return user, nil
+}
+
+// testLDAP runs the login steps one by one and reports each of them
+func testLDAP(settings *model.LDAPSettings, username, password string) *model.LDAPTestResult {
+	started := time.Now()
+	result := &model.LDAPTestResult{Steps: []model.LDAPTestStep{}}
+	step := func(name string, err error, message string) bool {
+		s := model.LDAPTestStep{Name: name, Success: err == nil, Message: message}
+		if err != nil {
+			s.Message = err.Error()
+		}
+		result.Steps = append(result.Steps, s)
+		return err == nil
+	}
+	defer func() { result.DurationMs = time.Since(started).Milliseconds() }()
+
+	conn, err := dialLDAP(settings)
+	if !step("connect", err, "Connected to "+settings.URL) {
+		return result
+	}
+	defer conn.Close()
+
+	bindAs := settings.BindDN
+	if bindAs == "" {
+		bindAs = "anonymous"
+	}
+	if !step("bind", bindService(conn, settings), "Bound as "+bindAs) {
+		return result
+	}
+
+	if username == "" {
+		// Without a user, check that the search base can be read
+		res, err := conn.Search(ldap.NewSearchRequest(
+			settings.SearchBase, ldap.ScopeBaseObject, ldap.NeverDerefAliases, 1, settings.Timeout, false,
+			"(objectClass=*)", []string{"dn"}, nil,
+		))
+		if err == nil && len(res.Entries) == 0 {
+			err = fmt.Errorf("search base %s not found", settings.SearchBase)
+		}
+		result.Success = step("search", err, "Search base "+settings.SearchBase+" is readable")
+		return result
+	}
+
+	entry, err := searchLDAPUser(conn, settings, username)
+	if err == nil && entry == nil {
+		err = fmt.Errorf("no entry matches %s", ldapFilter(settings.UserFilter, "{username}", username))
+	}
+	if !step("search", err, "Found user") {
+		return result
+	}
+	result.UserDN = entry.DN
+	if settings.EmailAttribute != "" {
+		result.Email = entry.GetAttributeValue(settings.EmailAttribute)
+	}
+
+	if password != "" {
+		if !step("user_bind", conn.Bind(entry.DN, password), "Password accepted") {
+			return result
+		}
+		if settings.GroupSearchBase != "" {
+			if !step("bind", bindService(conn, settings), "Bound as "+bindAs+" for the group search") {
+				return result
+			}
+		}
+	}
+
+	groups, err := ldapUserGroups(conn, settings, entry)
+	if !step("groups", err, fmt.Sprintf("Member of %d groups", len(groups))) {
+		return result
+	}
+	result.Groups = groups
+	result.Role = mapLDAPRole(settings, groups)
+	result.Success = true
+	return result
+}
+
+// mapLDAPRole returns the most privileged role mapped from the user's
+// groups, the default role when none match, or "" to deny the login
+func mapLDAPRole(settings *model.LDAPSettings, groups []string) string {
+	best := -1
+	for _, m := range settings.GroupMappings {
+		rank := roleRank(m.Role)
+		if rank < 0 || (best >= 0 && rank >= best) {
+			continue
+		}
+		for _, g := range groups {
+			if ldapGroupMatches(g, m.Group) {
+				best = rank
+				break
+			}
+		}
+	}
+	if best >= 0 {
+		return model.AllRoles[best]
+	}
+	return settings.DefaultRole
+}
+
+// ldapGroupMatches compares a group DN with a mapping given as DN or common name
+func ldapGroupMatches(groupDN, want string) bool {
+	if strings.EqualFold(groupDN, want) {
+		return true
+	}
+	if strings.Contains(want, "=") {
+		wantDN, err := ldap.ParseDN(want)
+		if err != nil {
+			return false
+		}
+		dn, err := ldap.ParseDN(groupDN)
+		return err == nil && dn.EqualFold(wantDN)
+	}
+	dn, err := ldap.ParseDN(groupDN)
+	if err != nil || len(dn.RDNs) == 0 {
+		return false
+	}
+	for _, attr := range dn.RDNs[0].Attributes {
+		if strings.EqualFold(attr.Type, "cn") && strings.EqualFold(attr.Value, want) {
+			return true
+		}
+	}
+	return false
+}
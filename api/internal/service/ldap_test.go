@@ -0,0 +1,176 @@
+package service
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"nginx-proxy-guard/internal/model"
+)
+
+func TestNormalizeLDAPSettings(t *testing.T) {
+	valid := func() model.LDAPSettings {
+		return model.LDAPSettings{
+			Enabled:        true,
+			URL:            "ldap://ldap.example.com:389",
+			BindDN:         "cn=admin,dc=example,dc=com",
+			BindPassword:   "secret",
+			SearchBase:     "dc=example,dc=com",
+			UserFilter:     "(uid={username})",
+			GroupFilter:    "(member={dn})",
+			EmailAttribute: "mail",
+		}
+	}
+
+	tests := []struct {
+		name   string
+		modify func(s *model.LDAPSettings)
+		field  string
+	}{
+		{"valid", func(s *model.LDAPSettings) {}, ""},
+		{"disabled skips connection checks", func(s *model.LDAPSettings) { s.Enabled = false; s.URL = "" }, ""},
+		{"bad scheme", func(s *model.LDAPSettings) { s.URL = "http://ldap.example.com" }, "url"},
+		{"starttls over ldaps", func(s *model.LDAPSettings) { s.URL = "ldaps://ldap.example.com"; s.StartTLS = true }, "start_tls"},
+		{"bind dn without password", func(s *model.LDAPSettings) { s.BindPassword = "" }, "bind_password"},
+		{"anonymous bind", func(s *model.LDAPSettings) { s.BindDN = ""; s.BindPassword = "" }, ""},
+		{"no search base", func(s *model.LDAPSettings) { s.SearchBase = "" }, "search_base"},
+		{"filter without placeholder", func(s *model.LDAPSettings) { s.UserFilter = "(uid=admin)" }, "user_filter"},
+		{"malformed filter", func(s *model.LDAPSettings) { s.UserFilter = "(&(uid={username})" }, "user_filter"},
+		{"group filter without dn", func(s *model.LDAPSettings) {
+			s.GroupSearchBase = "ou=groups,dc=example,dc=com"
+			s.GroupFilter = "(member=x)"
+		}, "group_filter"},
+		{"timeout", func(s *model.LDAPSettings) { s.Timeout = 600 }, "timeout"},
+		{"bad mapping role", func(s *model.LDAPSettings) {
+			s.GroupMappings = []model.LDAPGroupMapping{{Group: "admins", Role: "root"}}
+		}, "group_mappings"},
+	}
+	for _, tt := range tests {
+		settings := valid()
+		tt.modify(&settings)
+		err := normalizeLDAPSettings(&settings)
+		var uerr *UserError
+		switch {
+		case tt.field == "" && err != nil:
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+		case tt.field != "" && (!errors.As(err, &uerr) || uerr.Field != tt.field):
+			t.Errorf("%s: error = %v, want invalid %s", tt.name, err, tt.field)
+		}
+	}
+
+	settings := valid()
+	if err := normalizeLDAPSettings(&settings); err != nil || settings.Timeout != DefaultLDAPTimeout {
+		t.Errorf("timeout defaulted to %d, %v", settings.Timeout, err)
+	}
+}
+
+func TestLDAPFilterEscapesUsername(t *testing.T) {
+	got := ldapFilter("(&(objectClass=person)(sAMAccountName={username}))", "{username}", "*)(uid=*")
+	want := `(&(objectClass=person)(sAMAccountName=\2a\29\28uid=\2a))`
+	if got != want {
+		t.Errorf("ldapFilter() = %s, want %s", got, want)
+	}
+}
+
+func TestMapLDAPRole(t *testing.T) {
+	settings := &model.LDAPSettings{
+		GroupMappings: []model.LDAPGroupMapping{
+			{Group: "CN=NPG Admins,OU=Groups,DC=corp,DC=example", Role: model.RoleAdmin},
+			{Group: "soc", Role: model.RoleSecurityAnalyst},
+			{Group: "staff", Role: model.RoleViewer},
+		},
+	}
+
+	tests := []struct {
+		groups []string
+		want   string
+	}{
+		{[]string{"cn=staff,ou=groups,dc=corp,dc=example"}, model.RoleViewer},
+		{[]string{"cn=staff,ou=groups,dc=corp,dc=example", "cn=SOC,ou=groups,dc=corp,dc=example"}, model.RoleSecurityAnalyst},
+		{[]string{"cn=npg admins, ou=groups, dc=corp, dc=example"}, model.RoleAdmin},
+		{[]string{"cn=npg admins,ou=other,dc=corp,dc=example"}, ""},
+		{[]string{"cn=soc-leads,ou=groups,dc=corp,dc=example"}, ""},
+		{nil, ""},
+	}
+	for _, tt := range tests {
+		if got := mapLDAPRole(settings, tt.groups); got != tt.want {
+			t.Errorf("mapLDAPRole(%v) = %q, want %q", tt.groups, got, tt.want)
+		}
+	}
+
+	settings.DefaultRole = model.RoleViewer
+	if got := mapLDAPRole(settings, nil); got != model.RoleViewer {
+		t.Errorf("mapLDAPRole() without groups = %q, want default role", got)
+	}
+}
+
+func TestLookupLDAPUserRejectsEmptyPassword(t *testing.T) {
+	// Never reaches the server: an empty password would be an unauthenticated bind
+	settings := &model.LDAPSettings{URL: "ldap://127.0.0.1:1", Timeout: 1}
+	if _, err := lookupLDAPUser(settings, "alice", ""); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("lookupLDAPUser() error = %v, want ErrInvalidCredentials", err)
+	}
+}
+
+// TestLDAPDirectory runs against a real directory, for example a local OpenLDAP container:
+//
+//	docker run -d -p 1389:1389 -e LDAP_ADMIN_PASSWORD=adminpassword \
+//	  -e LDAP_USERS=alice -e LDAP_PASSWORDS=alicepassword bitnami/openldap
+//	NPG_TEST_LDAP_URL=ldap://localhost:1389 go test ./internal/service -run TestLDAPDirectory
+//
+// The defaults match that image; override them with the NPG_TEST_LDAP_* variables.
+func TestLDAPDirectory(t *testing.T) {
+	ldapURL := os.Getenv("NPG_TEST_LDAP_URL")
+	if ldapURL == "" {
+		t.Skip("NPG_TEST_LDAP_URL is not set")
+	}
+	env := func(name, fallback string) string {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+		return fallback
+	}
+
+	settings := &model.LDAPSettings{
+		Enabled:         true,
+		URL:             ldapURL,
+		StartTLS:        os.Getenv("NPG_TEST_LDAP_STARTTLS") == "true",
+		SkipTLSVerify:   true,
+		BindDN:          env("NPG_TEST_LDAP_BIND_DN", "cn=admin,dc=example,dc=org"),
+		BindPassword:    env("NPG_TEST_LDAP_BIND_PASSWORD", "adminpassword"),
+		SearchBase:      env("NPG_TEST_LDAP_BASE", "dc=example,dc=org"),
+		UserFilter:      env("NPG_TEST_LDAP_FILTER", "(cn={username})"),
+		EmailAttribute:  "mail",
+		GroupSearchBase: env("NPG_TEST_LDAP_GROUP_BASE", "dc=example,dc=org"),
+		GroupFilter:     "(|(member={dn})(uniqueMember={dn}))",
+		DefaultRole:     model.RoleViewer,
+	}
+	if err := normalizeLDAPSettings(settings); err != nil {
+		t.Fatalf("invalid test settings: %v", err)
+	}
+	username := env("NPG_TEST_LDAP_USER", "alice")
+	password := env("NPG_TEST_LDAP_PASSWORD", "alicepassword")
+
+	result := testLDAP(settings, username, password)
+	if !result.Success {
+		t.Fatalf("testLDAP() failed: %+v", result.Steps)
+	}
+	if result.UserDN == "" || result.Role != model.RoleViewer {
+		t.Errorf("testLDAP() = %+v", result)
+	}
+
+	user, err := lookupLDAPUser(settings, username, password)
+	if err != nil {
+		t.Fatalf("lookupLDAPUser() error = %v", err)
+	}
+	if user.DN != result.UserDN {
+		t.Errorf("lookupLDAPUser() DN = %s, want %s", user.DN, result.UserDN)
+	}
+
+	if _, err := lookupLDAPUser(settings, username, password+"-wrong"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("wrong password error = %v, want ErrInvalidCredentials", err)
+	}
+	if _, err := lookupLDAPUser(settings, "no-such-user", password); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("unknown user error = %v, want ErrInvalidCredentials", err)
+	}
+}
@@ -421,7 +421,8 @@ func (c *LogCollector) addLog(logReq model.CreateLogRequest) {
 	logReq.AttackType = truncateString(logReq.AttackType, 500)
 	logReq.ActionTaken = truncateString(logReq.ActionTaken, 100)
 	logReq.BotCategory = truncateString(logReq.BotCategory, 100)
-	logReq.ExploitRule = sanitizeString(logReq.ExploitRule)
+	// Also carries the client certificate verify result (FAILED:<reason>) of client_cert blocks
+	logReq.ExploitRule = truncateString(logReq.ExploitRule, 50)
 	logReq.ErrorMessage = sanitizeString(logReq.ErrorMessage)
 	logReq.RawLog = sanitizeString(logReq.RawLog)
 
@@ -0,0 +1,636 @@
+package service
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"nginx-proxy-guard/internal/model"
+	"nginx-proxy-guard/internal/repository"
+	"nginx-proxy-guard/pkg/acme"
+)
+
+// managementListenerKey marks requests that came in on the dedicated listener
+type managementListenerKey struct{}
+
+// FromManagementListener reports whether the request of ctx came in on the
+// dedicated management listener
+func FromManagementListener(ctx context.Context) bool {
+	via, _ := ctx.Value(managementListenerKey{}).(bool)
+	return via
+}
+
+// managementProxyResolveInterval is how long resolved trusted proxy host
+// names are reused; containers get a new address when they are recreated
+const managementProxyResolveInterval = 30 * time.Second
+
+// trustedProxies are the peers whose X-Real-IP the management allowlist
+// believes: addresses, networks, and host names resolved periodically
+type trustedProxies struct {
+	mu         sync.Mutex
+	networks   []*net.IPNet
+	hosts      []string
+	resolved   map[string][]net.IP
+	resolvedAt time.Time
+	lookup     func(ctx context.Context, host string) ([]net.IP, error)
+}
+
+var managementProxies = &trustedProxies{lookup: lookupProxyHost}
+
+func lookupProxyHost(ctx context.Context, host string) ([]net.IP, error) {
+	return net.DefaultResolver.LookupIP(ctx, "ip", host)
+}
+
+// SetManagementTrustedProxies sets the peers allowed to pass the client
+// address in X-Real-IP on the main port. Entries are IP addresses, CIDR
+// networks or host names, such as the UI container's.
+func SetManagementTrustedProxies(entries []string) error {
+	var networks []*net.IPNet
+	var hosts []string
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if ipNet, err := ParseTrustedCIDR(entry); err == nil {
+			networks = append(networks, ipNet)
+			continue
+		}
+		if strings.ContainsAny(entry, "/: ") {
+			return fmt.Errorf("invalid management trusted proxy: %s", entry)
+		}
+		hosts = append(hosts, entry)
+	}
+
+	p := managementProxies
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.networks = networks
+	p.hosts = hosts
+	p.resolved = nil
+	p.resolvedAt = time.Time{}
+	return nil
+}
+
+// trusts reports whether ip is one of the trusted proxies
+func (p *trustedProxies) trusts(ip net.IP) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, ipNet := range p.networks {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	if len(p.hosts) == 0 {
+		return false
+	}
+	if time.Since(p.resolvedAt) > managementProxyResolveInterval {
+		p.resolve()
+	}
+	for _, addrs := range p.resolved {
+		for _, addr := range addrs {
+			if addr.Equal(ip) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// resolve looks the trusted host names up again, keeping the previous
+// addresses of a name that fails to resolve. Called with mu held.
+func (p *trustedProxies) resolve() {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	resolved := make(map[string][]net.IP, len(p.hosts))
+	for _, host := range p.hosts {
+		ips, err := p.lookup(ctx, host)
+		if err != nil {
+			log.Printf("[Management] Failed to resolve trusted proxy %s: %v", host, err)
+			resolved[host] = p.resolved[host]
+			continue
+		}
+		resolved[host] = ips
+	}
+	p.resolved = resolved
+	p.resolvedAt = time.Now()
+}
+
+// ManagementClientIP returns the address the management allowlist checks.
+// The dedicated listener is reached by clients directly. On the main port
+// the API sits behind the UI container's nginx, which replaces X-Real-IP
+// with the connecting address; the header is only trusted from the
+// configured trusted proxies so that other peers, even on the same private
+// network, can't choose their own address.
+func ManagementClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if FromManagementListener(r.Context()) {
+		return host
+	}
+	if peer := net.ParseIP(host); peer != nil && managementProxies.trusts(peer) {
+		if realIP := strings.TrimSpace(r.Header.Get("X-Real-IP")); net.ParseIP(realIP) != nil {
+			return realIP
+		}
+	}
+	return host
+}
+
+// ManagementService enforces the management allowlist and runs the optional
+// dedicated management listener. The listener serves the API and proxies
+// everything else to the UI container, over TLS with a certificate from the
+// certificate store that is reloaded whenever it is renewed.
+type ManagementService struct {
+	repo       *repository.ManagementRepository
+	certRepo   *repository.CertificateRepository
+	override   bool // Allowlist disabled from the environment
+	uiUpstream *url.URL
+
+	// Dedicated listener, guarded by mu
+	mu         sync.Mutex
+	started    bool
+	handler    http.Handler
+	server     *http.Server
+	serverAddr string
+
+	// Settings and certificate in use, guarded by cacheMu
+	cacheMu       sync.RWMutex
+	settings      *model.ManagementSettings
+	networks      []*net.IPNet
+	cert          *tls.Certificate
+	certID        string
+	certExpiresAt *time.Time
+	listening     bool
+	listenerErr   string
+}
+
+// NewManagementService creates a new management service
+func NewManagementService(repo *repository.ManagementRepository, certRepo *repository.CertificateRepository) *ManagementService {
+	return &ManagementService{
+		repo:     repo,
+		certRepo: certRepo,
+	}
+}
+
+// SetAllowlistOverride turns the allowlist off regardless of the settings,
+// to recover from an allowlist that locks everyone out
+func (s *ManagementService) SetAllowlistOverride(override bool) {
+	s.override = override
+}
+
+// SetUIUpstream sets where the dedicated listener proxies UI requests to;
+// without it the listener serves the API only
+func (s *ManagementService) SetUIUpstream(rawURL string) error {
+	if rawURL == "" {
+		s.uiUpstream = nil
+		return nil
+	}
+	upstream, err := url.Parse(rawURL)
+	if err != nil || upstream.Host == "" || (upstream.Scheme != "http" && upstream.Scheme != "https") {
+		return fmt.Errorf("invalid management UI upstream: %s", rawURL)
+	}
+	s.uiUpstream = upstream
+	return nil
+}
+
+// Start loads the settings and starts the dedicated listener if enabled.
+// handler serves the API on the listener.
+func (s *ManagementService) Start(handler http.Handler) {
+	s.mu.Lock()
+	s.started = true
+	s.handler = handler
+	s.mu.Unlock()
+
+	if s.override {
+		log.Println("[Management] Warning: management allowlist disabled by MANAGEMENT_ALLOWLIST_DISABLED")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := s.Apply(ctx); err != nil {
+		log.Printf("[Management] Failed to apply management settings: %v", err)
+	}
+}
+
+// Stop shuts the dedicated listener down
+func (s *ManagementService) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.started = false
+	s.stopListener()
+}
+
+// GetSettings returns the management settings
+func (s *ManagementService) GetSettings(ctx context.Context) (*model.ManagementSettings, error) {
+	return s.repo.GetSettings(ctx)
+}
+
+// UpdateSettings validates and stores the management settings, then applies
+// them. clientIP and viaListener describe the caller, who must not lock
+// themselves out. Listener failures don't fail the update; they show in
+// the status.
+func (s *ManagementService) UpdateSettings(ctx context.Context, req *model.UpdateManagementSettingsRequest, clientIP string, viaListener bool) (*model.ManagementSettings, error) {
+	settings, err := s.repo.GetSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.AllowlistEnabled != nil {
+		settings.AllowlistEnabled = *req.AllowlistEnabled
+	}
+	if req.AllowedNetworks != nil {
+		settings.AllowedNetworks = *req.AllowedNetworks
+	}
+	if req.ListenerEnabled != nil {
+		settings.ListenerEnabled = *req.ListenerEnabled
+	}
+	if req.ListenerAddress != nil {
+		settings.ListenerAddress = strings.TrimSpace(*req.ListenerAddress)
+	}
+	if req.CertificateID != nil {
+		if *req.CertificateID == "" {
+			settings.CertificateID = nil
+		} else {
+			id := *req.CertificateID
+			settings.CertificateID = &id
+		}
+	}
+	// Management can only be restricted to the listener from the listener,
+	// which shows that it works
+	if req.DedicatedOnly != nil {
+		if *req.DedicatedOnly && !settings.DedicatedOnly && !viaListener {
+			return nil, &UserError{Field: "dedicated_only", Message: "can only be turned on from the dedicated listener"}
+		}
+		settings.DedicatedOnly = *req.DedicatedOnly
+	}
+
+	networks, err := validateManagementSettings(settings, clientIP)
+	if err != nil {
+		return nil, err
+	}
+	settings.AllowedNetworks = networks
+
+	if settings.CertificateID != nil {
+		cert, err := s.certRepo.GetByID(ctx, *settings.CertificateID)
+		if err != nil {
+			return nil, err
+		}
+		if cert == nil {
+			return nil, &UserError{Field: "certificate_id", Message: "does not exist"}
+		}
+		if _, err := managementKeyPair(cert); err != nil {
+			return nil, &UserError{Field: "certificate_id", Message: err.Error()}
+		}
+	}
+
+	updated, err := s.repo.SaveSettings(ctx, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Apply(ctx); err != nil {
+		log.Printf("[Management] Failed to apply management settings: %v", err)
+	}
+	return updated, nil
+}
+
+// Apply loads the settings into the allowlist and starts, restarts or
+// stops the dedicated listener to match them
+func (s *ManagementService) Apply(ctx context.Context) error {
+	settings, err := s.repo.GetSettings(ctx)
+	if err != nil {
+		return err
+	}
+
+	networks := make([]*net.IPNet, 0, len(settings.AllowedNetworks))
+	for _, value := range settings.AllowedNetworks {
+		ipNet, err := ParseTrustedCIDR(value)
+		if err != nil {
+			log.Printf("[Management] Skipping invalid allowed network %s: %v", value, err)
+			continue
+		}
+		networks = append(networks, ipNet)
+	}
+
+	s.cacheMu.Lock()
+	s.settings = settings
+	s.networks = networks
+	s.cacheMu.Unlock()
+
+	return s.applyListener(ctx, settings)
+}
+
+// Allowed reports whether ip may use the management API
+func (s *ManagementService) Allowed(ip string) bool {
+	if s.override {
+		return true
+	}
+	s.cacheMu.RLock()
+	defer s.cacheMu.RUnlock()
+	if s.settings == nil || !s.settings.AllowlistEnabled {
+		return true
+	}
+	return networksContain(s.networks, ip)
+}
+
+// DedicatedOnly reports whether management requests must use the dedicated
+// listener. It only holds while the listener is actually serving, so a
+// listener that fails to start never locks the main port out.
+func (s *ManagementService) DedicatedOnly() bool {
+	s.cacheMu.RLock()
+	defer s.cacheMu.RUnlock()
+	return s.settings != nil && s.settings.DedicatedOnly && s.listening
+}
+
+// Status describes the dedicated listener and the caller of r
+func (s *ManagementService) Status(r *http.Request) *model.ManagementStatus {
+	clientIP := ManagementClientIP(r)
+	status := &model.ManagementStatus{
+		ClientIP:            clientIP,
+		ClientAllowed:       s.Allowed(clientIP),
+		ViaListener:         FromManagementListener(r.Context()),
+		AllowlistOverridden: s.override,
+	}
+
+	s.cacheMu.RLock()
+	defer s.cacheMu.RUnlock()
+	status.ListenerRunning = s.listening
+	status.ListenerError = s.listenerErr
+	if s.listening && s.settings != nil {
+		status.ListenerAddress = s.settings.ListenerAddress
+	}
+	if s.cert != nil {
+		status.CertificateExpiresAt = s.certExpiresAt
+	}
+	return status
+}
+
+// RenewalCertificates returns the certificate of the dedicated listener, which
+// the renewal scheduler renews whether or not auto-renew is set on it
+func (s *ManagementService) RenewalCertificates(ctx context.Context) []string {
+	s.cacheMu.RLock()
+	defer s.cacheMu.RUnlock()
+	if s.settings == nil || !s.settings.ListenerEnabled || s.settings.CertificateID == nil {
+		return nil
+	}
+	return []string{*s.settings.CertificateID}
+}
+
+// OnCertificateReady reloads the listener certificate after it was renewed
+func (s *ManagementService) OnCertificateReady(ctx context.Context, certificateID string) error {
+	s.cacheMu.RLock()
+	inUse := s.certID == certificateID
+	s.cacheMu.RUnlock()
+	if !inUse {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadCertificate(ctx, certificateID); err != nil {
+		return err
+	}
+	log.Printf("[Management] Reloaded listener certificate %s", certificateID)
+	return nil
+}
+
+// applyListener brings the listener in line with settings
+func (s *ManagementService) applyListener(ctx context.Context, settings *model.ManagementSettings) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.started {
+		return nil
+	}
+
+	if !settings.ListenerEnabled || settings.CertificateID == nil {
+		s.stopListener()
+		s.setListenerError("")
+		return nil
+	}
+
+	// A certificate that fails to load keeps a running listener on the
+	// previous one
+	if err := s.loadCertificate(ctx, *settings.CertificateID); err != nil {
+		s.setListenerError(err.Error())
+		return err
+	}
+	s.cacheMu.RLock()
+	listening := s.listening
+	s.cacheMu.RUnlock()
+	if listening && s.serverAddr == settings.ListenerAddress {
+		return nil
+	}
+
+	s.stopListener()
+	ln, err := net.Listen("tcp", settings.ListenerAddress)
+	if err != nil {
+		s.setListenerError(err.Error())
+		return fmt.Errorf("failed to listen on %s: %w", settings.ListenerAddress, err)
+	}
+
+	server := &http.Server{
+		Handler: s.listenerHandler(),
+		TLSConfig: &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: s.getCertificate,
+		},
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		if err := server.ServeTLS(ln, "", ""); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("[Management] Listener on %s failed: %v", settings.ListenerAddress, err)
+			s.cacheMu.Lock()
+			s.listening = false
+			s.listenerErr = err.Error()
+			s.cacheMu.Unlock()
+		}
+	}()
+
+	s.server = server
+	s.serverAddr = settings.ListenerAddress
+	s.cacheMu.Lock()
+	s.listening = true
+	s.listenerErr = ""
+	s.cacheMu.Unlock()
+	log.Printf("[Management] Listening on %s", settings.ListenerAddress)
+	return nil
+}
+
+// stopListener shuts the listener down; callers hold mu
+func (s *ManagementService) stopListener() {
+	if s.server == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.server.Shutdown(ctx); err != nil {
+		log.Printf("[Management] Failed to shut down listener: %v", err)
+	}
+	log.Printf("[Management] Stopped listening on %s", s.serverAddr)
+	s.server = nil
+	s.serverAddr = ""
+
+	s.cacheMu.Lock()
+	s.listening = false
+	s.cacheMu.Unlock()
+}
+
+func (s *ManagementService) setListenerError(message string) {
+	s.cacheMu.Lock()
+	s.listenerErr = message
+	s.cacheMu.Unlock()
+}
+
+// loadCertificate loads the listener certificate from the store; callers hold mu
+func (s *ManagementService) loadCertificate(ctx context.Context, id string) error {
+	cert, err := s.certRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if cert == nil {
+		return fmt.Errorf("certificate %s does not exist", id)
+	}
+	pair, err := managementKeyPair(cert)
+	if err != nil {
+		return err
+	}
+
+	s.cacheMu.Lock()
+	s.cert = pair
+	s.certID = id
+	s.certExpiresAt = cert.ExpiresAt
+	s.cacheMu.Unlock()
+	return nil
+}
+
+func (s *ManagementService) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	s.cacheMu.RLock()
+	defer s.cacheMu.RUnlock()
+	if s.cert == nil {
+		return nil, errors.New("no management certificate loaded")
+	}
+	return s.cert, nil
+}
+
+// listenerHandler marks requests as coming from the dedicated listener and
+// sends API requests to the API, everything else to the UI
+func (s *ManagementService) listenerHandler() http.Handler {
+	var ui http.Handler
+	if s.uiUpstream != nil {
+		proxy := httputil.NewSingleHostReverseProxy(s.uiUpstream)
+		// The UI container serves a self-signed certificate on the internal network
+		proxy.Transport = &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		}
+		ui = proxy
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r = r.WithContext(context.WithValue(r.Context(), managementListenerKey{}, true))
+		if r.URL.Path == "/health" || strings.HasPrefix(r.URL.Path, "/api/") || ui == nil {
+			s.handler.ServeHTTP(w, r)
+			return
+		}
+		// The API checks the allowlist itself; the UI is checked here
+		if !s.Allowed(ManagementClientIP(r)) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		ui.ServeHTTP(w, r)
+	})
+}
+
+// managementKeyPair builds the TLS certificate of the listener from a
+// stored certificate
+func managementKeyPair(cert *model.Certificate) (*tls.Certificate, error) {
+	if cert.CertificatePEM == "" || cert.PrivateKeyPEM == "" {
+		return nil, errors.New("has not been issued yet")
+	}
+	pair, err := tls.X509KeyPair([]byte(acme.BuildFullchain(cert.CertificatePEM, cert.IssuerCertificatePEM)), []byte(cert.PrivateKeyPEM))
+	if err != nil {
+		return nil, fmt.Errorf("can't be loaded: %v", err)
+	}
+	return &pair, nil
+}
+
+// validateManagementSettings checks merged settings and returns the allowed
+// networks trimmed and deduplicated. The caller at clientIP must stay
+// allowed.
+func validateManagementSettings(s *model.ManagementSettings, clientIP string) ([]string, error) {
+	networks := make([]string, 0, len(s.AllowedNetworks))
+	parsed := make([]*net.IPNet, 0, len(s.AllowedNetworks))
+	seen := make(map[string]bool)
+	for _, value := range s.AllowedNetworks {
+		value = strings.TrimSpace(value)
+		if value == "" || seen[value] {
+			continue
+		}
+		ipNet, err := ParseTrustedCIDR(value)
+		if err != nil {
+			return nil, &UserError{Field: "allowed_networks", Message: err.Error()}
+		}
+		seen[value] = true
+		networks = append(networks, value)
+		parsed = append(parsed, ipNet)
+	}
+
+	if s.AllowlistEnabled {
+		if len(networks) == 0 {
+			return nil, &UserError{Field: "allowed_networks", Message: "must not be empty while the allowlist is enabled"}
+		}
+		if !networksContain(parsed, clientIP) {
+			return nil, &UserError{Field: "allowed_networks", Message: fmt.Sprintf("must include your current address %s", clientIP)}
+		}
+	}
+
+	if s.ListenerEnabled {
+		host, port, err := net.SplitHostPort(s.ListenerAddress)
+		if err != nil {
+			return nil, &UserError{Field: "listener_address", Message: "must be host:port, e.g. 10.8.0.1:8443"}
+		}
+		if host != "" && net.ParseIP(host) == nil {
+			return nil, &UserError{Field: "listener_address", Message: "host must be an IP address"}
+		}
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			return nil, &UserError{Field: "listener_address", Message: "port must be between 1 and 65535"}
+		}
+		if s.CertificateID == nil {
+			return nil, &UserError{Field: "certificate_id", Message: "is required for the listener"}
+		}
+	}
+
+	if s.DedicatedOnly && !s.ListenerEnabled {
+		return nil, &UserError{Field: "dedicated_only", Message: "requires the dedicated listener"}
+	}
+
+	return networks, nil
+}
+
+// networksContain reports whether ip is in one of networks
+func networksContain(networks []*net.IPNet, ip string) bool {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+	for _, ipNet := range networks {
+		if ipNet.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
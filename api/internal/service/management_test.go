@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"nginx-proxy-guard/internal/model"
+)
+
+func TestManagementClientIP(t *testing.T) {
+	managementProxies.lookup = func(ctx context.Context, host string) ([]net.IP, error) {
+		if host == "ui" {
+			return []net.IP{net.ParseIP("172.18.0.5")}, nil
+		}
+		return nil, errors.New("no such host")
+	}
+	defer func() { managementProxies.lookup = lookupProxyHost }()
+	if err := SetManagementTrustedProxies([]string{"ui", " 10.9.0.0/24"}); err != nil {
+		t.Fatal(err)
+	}
+	defer SetManagementTrustedProxies(nil)
+
+	tests := []struct {
+		name        string
+		remoteAddr  string
+		realIP      string
+		viaListener bool
+		want        string
+	}{
+		{"UI proxy on the docker network", "172.18.0.5:41234", "203.0.113.7", false, "203.0.113.7"},
+		{"trusted proxy network", "10.9.0.3:41234", "203.0.113.7", false, "203.0.113.7"},
+		{"private peer outside the trusted proxies", "172.18.0.9:41234", "10.8.0.2", false, "172.18.0.9"},
+		{"loopback isn't trusted by default", "127.0.0.1:41234", "10.8.0.2", false, "127.0.0.1"},
+		{"public peer can't pick its address", "198.51.100.9:41234", "10.8.0.2", false, "198.51.100.9"},
+		{"invalid header", "172.18.0.5:41234", "not-an-ip", false, "172.18.0.5"},
+		{"dedicated listener ignores the header", "172.18.0.5:41234", "192.168.1.1", true, "172.18.0.5"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/api/v1/settings", nil)
+		r.RemoteAddr = tt.remoteAddr
+		if tt.realIP != "" {
+			r.Header.Set("X-Real-IP", tt.realIP)
+		}
+		if tt.viaListener {
+			r = r.WithContext(context.WithValue(r.Context(), managementListenerKey{}, true))
+		}
+		if got := ManagementClientIP(r); got != tt.want {
+			t.Errorf("%s: ManagementClientIP() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSetManagementTrustedProxies(t *testing.T) {
+	defer SetManagementTrustedProxies(nil)
+	for _, entry := range []string{"10.0.0.0/33", "ui:443", "ui container"} {
+		if err := SetManagementTrustedProxies([]string{entry}); err == nil {
+			t.Errorf("%q: expected an error", entry)
+		}
+	}
+	if err := SetManagementTrustedProxies([]string{"ui", "", "2001:db8::1", "10.0.0.0/8"}); err != nil {
+		t.Errorf("valid entries: error = %v", err)
+	}
+}
+
+func TestManagementAllowed(t *testing.T) {
+	_, vpn, _ := net.ParseCIDR("10.8.0.0/24")
+	s := &ManagementService{
+		settings: &model.ManagementSettings{AllowlistEnabled: true},
+		networks: []*net.IPNet{vpn},
+	}
+	if !s.Allowed("10.8.0.2") {
+		t.Error("address in an allowed network should be allowed")
+	}
+	if s.Allowed("192.168.1.10") || s.Allowed("garbage") {
+		t.Error("address outside the allowed networks should be refused")
+	}
+
+	s.override = true
+	if !s.Allowed("192.168.1.10") {
+		t.Error("override should allow every address")
+	}
+
+	s.override = false
+	s.settings.AllowlistEnabled = false
+	if !s.Allowed("192.168.1.10") {
+		t.Error("disabled allowlist should allow every address")
+	}
+}
+
+func TestManagementDedicatedOnly(t *testing.T) {
+	s := &ManagementService{settings: &model.ManagementSettings{DedicatedOnly: true}}
+	if s.DedicatedOnly() {
+		t.Error("dedicated_only must not apply while the listener isn't serving")
+	}
+	s.listening = true
+	if !s.DedicatedOnly() {
+		t.Error("dedicated_only should apply while the listener is serving")
+	}
+}
+
+func TestValidateManagementSettings(t *testing.T) {
+	certID := "cert-1"
+	s := &model.ManagementSettings{
+		AllowlistEnabled: true,
+		AllowedNetworks:  []string{" 10.8.0.0/24", "10.8.0.0/24", "", "2001:db8::1"},
+		ListenerEnabled:  true,
+		ListenerAddress:  "10.8.0.1:8443",
+		CertificateID:    &certID,
+	}
+	networks, err := validateManagementSettings(s, "10.8.0.2")
+	if err != nil {
+		t.Fatalf("validateManagementSettings() error = %v", err)
+	}
+	if want := []string{"10.8.0.0/24", "2001:db8::1"}; !reflect.DeepEqual(networks, want) {
+		t.Errorf("networks = %v, want %v", networks, want)
+	}
+	if _, err := validateManagementSettings(s, "2001:db8::1"); err != nil {
+		t.Errorf("single IPv6 address: error = %v", err)
+	}
+
+	tests := []struct {
+		field    string
+		clientIP string
+		modify   func(s *model.ManagementSettings)
+	}{
+		{"allowed_networks", "10.8.0.2", func(s *model.ManagementSettings) { s.AllowedNetworks = []string{"10.8.0.0/33"} }},
+		{"allowed_networks", "10.8.0.2", func(s *model.ManagementSettings) { s.AllowedNetworks = nil }},
+		{"allowed_networks", "192.168.1.10", func(s *model.ManagementSettings) {}},
+		{"listener_address", "10.8.0.2", func(s *model.ManagementSettings) { s.ListenerAddress = "10.8.0.1" }},
+		{"listener_address", "10.8.0.2", func(s *model.ManagementSettings) { s.ListenerAddress = "vpn.example:8443" }},
+		{"listener_address", "10.8.0.2", func(s *model.ManagementSettings) { s.ListenerAddress = ":70000" }},
+		{"certificate_id", "10.8.0.2", func(s *model.ManagementSettings) { s.CertificateID = nil }},
+		{"dedicated_only", "10.8.0.2", func(s *model.ManagementSettings) { s.ListenerEnabled = false; s.DedicatedOnly = true }},
+	}
+	for _, tt := range tests {
+		invalid := *s
+		tt.modify(&invalid)
+		var uerr *UserError
+		if _, err := validateManagementSettings(&invalid, tt.clientIP); !errors.As(err, &uerr) || uerr.Field != tt.field {
+			t.Errorf("%s: error = %v", tt.field, err)
+		}
+	}
+
+	disabled := &model.ManagementSettings{ListenerAddress: ":8443"}
+	if _, err := validateManagementSettings(disabled, "192.168.1.10"); err != nil {
+		t.Errorf("defaults: error = %v", err)
+	}
+}
+
+func TestManagementKeyPair(t *testing.T) {
+	if _, err := managementKeyPair(&model.Certificate{Status: model.CertStatusPending}); err == nil {
+		t.Error("certificate without PEM should be refused")
+	}
+	if _, err := managementKeyPair(&model.Certificate{CertificatePEM: "not a certificate", PrivateKeyPEM: "not a key"}); err == nil {
+		t.Error("invalid PEM should be refused")
+	}
+}
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+
+	"nginx-proxy-guard/internal/model"
+)
+
+// mockOIDCIssuer is a minimal OpenID provider issuing ID tokens with the claims set by the test
+type mockOIDCIssuer struct {
+	server *httptest.Server
+	signer jose.Signer
+	keys   jose.JSONWebKeySet
+	nonce  string
+	claims map[string]interface{}
+}
+
+func newMockOIDCIssuer(t *testing.T) *mockOIDCIssuer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: key},
+		(&jose.SignerOptions{}).WithType("JWT").WithHeader("kid", "test-key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := &mockOIDCIssuer{
+		signer: signer,
+		keys:   jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{Key: &key.PublicKey, KeyID: "test-key", Algorithm: "RS256", Use: "sig"}}},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(oidcDiscovery{
+			Issuer:                m.server.URL,
+			AuthorizationEndpoint: m.server.URL + "/authorize",
+			TokenEndpoint:         m.server.URL + "/token",
+			JWKSURI:               m.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(m.keys)
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("code") != "good-code" || r.FormValue("code_verifier") == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if user, pass, _ := r.BasicAuth(); user != "npg" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		claims := map[string]interface{}{
+			"iss":   m.server.URL,
+			"aud":   "npg",
+			"sub":   "user-1",
+			"nonce": m.nonce,
+			"iat":   time.Now().Unix(),
+			"exp":   time.Now().Add(time.Hour).Unix(),
+		}
+		for k, v := range m.claims {
+			claims[k] = v
+		}
+		idToken, err := jwt.Signed(m.signer).Claims(claims).Serialize()
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"access_token": "x", "token_type": "Bearer", "id_token": idToken})
+	})
+	m.server = httptest.NewServer(mux)
+	t.Cleanup(m.server.Close)
+	return m
+}
+
+func TestOIDCGateLoginFlow(t *testing.T) {
+	issuer := newMockOIDCIssuer(t)
+	issuer.claims = map[string]interface{}{
+		"email":          "alice@example.com",
+		"email_verified": true,
+		"groups":         []string{"staff", "ops"},
+	}
+
+	s := NewOIDCGateService(nil, "test-secret")
+	provider := &model.OIDCProvider{IssuerURL: issuer.server.URL, ClientID: "npg", ClientSecret: "secret", Enabled: true}
+	gate := &model.OIDCHostGate{
+		ProxyHostID:         "host-1",
+		Enabled:             true,
+		AllowedEmailDomains: []string{"example.com"},
+		AllowedGroups:       []string{"ops"},
+		SessionLifetime:     3600,
+	}
+	ctx := context.Background()
+
+	start, err := s.beginLogin(ctx, provider, "host-1", "https://app.example.com/api/v1/oidc/callback", "https://app.example.com/dashboard")
+	if err != nil {
+		t.Fatalf("beginLogin() error = %v", err)
+	}
+	authURL, err := url.Parse(start.AuthorizationURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := authURL.Query()
+	if query.Get("code_challenge_method") != "S256" || query.Get("client_id") != "npg" {
+		t.Fatalf("unexpected authorization URL %s", start.AuthorizationURL)
+	}
+	issuer.nonce = query.Get("nonce")
+
+	var ls oidcLoginState
+	if err := s.verify(oidcPurposeState, start.StateCookie, &ls); err != nil {
+		t.Fatalf("state cookie does not verify: %v", err)
+	}
+	if ls.State != query.Get("state") {
+		t.Fatalf("state cookie and authorization URL disagree")
+	}
+
+	result, err := s.completeLogin(ctx, gate, provider, &ls, "good-code")
+	if err != nil {
+		t.Fatalf("completeLogin() error = %v", err)
+	}
+	if result.Identity.Subject != "user-1" || result.Identity.Email != "alice@example.com" || result.ReturnURL != "https://app.example.com/dashboard" {
+		t.Fatalf("unexpected login result %+v", result)
+	}
+
+	var sess oidcSession
+	if err := s.verify(oidcPurposeSession, result.SessionCookie, &sess); err != nil || sess.HostID != "host-1" {
+		t.Fatalf("session cookie does not verify: %v", err)
+	}
+	// A state cookie must never be accepted as a session
+	if err := s.verify(oidcPurposeSession, start.StateCookie, &sess); err == nil {
+		t.Fatalf("state cookie verified as a session")
+	}
+
+	// Tokens for another nonce (replayed from an earlier login) are rejected
+	issuer.nonce = "other"
+	if _, err := s.completeLogin(ctx, gate, provider, &ls, "good-code"); err == nil {
+		t.Fatalf("completeLogin() accepted a token with the wrong nonce")
+	}
+
+	// Users outside the allowed groups are denied
+	issuer.nonce = ls.Nonce
+	issuer.claims["groups"] = []string{"staff"}
+	if _, err := s.completeLogin(ctx, gate, provider, &ls, "good-code"); !errors.Is(err, ErrOIDCAccessDenied) {
+		t.Fatalf("completeLogin() error = %v, want ErrOIDCAccessDenied", err)
+	}
+}
+
+func TestEvaluateOIDCPolicy(t *testing.T) {
+	claims := map[string]interface{}{
+		"email":          "bob@corp.example",
+		"email_verified": true,
+		"department":     "eng",
+		"roles":          []interface{}{"admin", "dev"},
+	}
+	identity := oidcIdentityFromClaims(claims, "roles")
+
+	tests := []struct {
+		name    string
+		gate    model.OIDCHostGate
+		allowed bool
+	}{
+		{"no restrictions", model.OIDCHostGate{}, true},
+		{"email domain", model.OIDCHostGate{AllowedEmailDomains: []string{"@Corp.Example"}}, true},
+		{"other email domain", model.OIDCHostGate{AllowedEmailDomains: []string{"example.com"}}, false},
+		{"group", model.OIDCHostGate{AllowedGroups: []string{"dev"}}, true},
+		{"missing group", model.OIDCHostGate{AllowedGroups: []string{"finance"}}, false},
+		{"claim", model.OIDCHostGate{RequiredClaims: map[string]string{"department": "eng", "roles": "admin"}}, true},
+		{"claim mismatch", model.OIDCHostGate{RequiredClaims: map[string]string{"department": "sales"}}, false},
+	}
+	for _, tt := range tests {
+		err := EvaluateOIDCPolicy(&tt.gate, identity, claims)
+		if (err == nil) != tt.allowed {
+			t.Errorf("%s: EvaluateOIDCPolicy() error = %v, allowed = %v", tt.name, err, tt.allowed)
+		}
+	}
+
+	claims["email_verified"] = false
+	if err := EvaluateOIDCPolicy(&model.OIDCHostGate{AllowedEmailDomains: []string{"corp.example"}}, identity, claims); err == nil {
+		t.Errorf("unverified email allowed by domain restriction")
+	}
+}
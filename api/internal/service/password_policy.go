@@ -0,0 +1,475 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"nginx-proxy-guard/internal/model"
+	"nginx-proxy-guard/internal/repository"
+)
+
+var ErrBreachedPassword = errors.New("password appears in a list of breached passwords")
+
+const (
+	// Failed logins older than this never count towards a lockout, login
+	// attempts are kept for as long
+	lockoutLookback = 24 * time.Hour
+	// bcrypt ignores everything after 72 bytes
+	maxPasswordBytes = 72
+	// Lockout scopes
+	LockoutScopeUsername = "username"
+	LockoutScopeIP       = "ip"
+)
+
+// LockoutError refuses a login while a username or source IP is locked out.
+// It matches ErrTooManyAttempts.
+type LockoutError struct {
+	Scope string // username or ip
+	Value string
+	Until time.Time
+	First bool // The first login refused by this lockout
+}
+
+func (e *LockoutError) Error() string {
+	return fmt.Sprintf("too many failed login attempts, locked until %s", e.Until.Format(time.RFC3339))
+}
+
+func (e *LockoutError) Is(target error) bool {
+	return target == ErrTooManyAttempts
+}
+
+type PasswordPolicyService struct {
+	repo     *repository.PasswordPolicyRepository
+	authRepo *repository.AuthRepository
+
+	breachMu     sync.RWMutex
+	breach       *breachList
+	breachStatus model.BreachListStatus
+
+	lockMu   sync.Mutex
+	reported map[string]time.Time // Lockouts already reported, by scope and value
+}
+
+func NewPasswordPolicyService(repo *repository.PasswordPolicyRepository, authRepo *repository.AuthRepository) *PasswordPolicyService {
+	return &PasswordPolicyService{
+		repo:     repo,
+		authRepo: authRepo,
+		reported: make(map[string]time.Time),
+	}
+}
+
+// GetSettings returns the password policy
+func (s *PasswordPolicyService) GetSettings(ctx context.Context) (*model.PasswordPolicy, error) {
+	return s.repo.GetSettings(ctx)
+}
+
+// UpdateSettings validates and stores the password policy. Existing
+// passwords are checked against the new rules when they are next changed.
+func (s *PasswordPolicyService) UpdateSettings(ctx context.Context, req *model.UpdatePasswordPolicyRequest) (*model.PasswordPolicy, error) {
+	policy, err := s.repo.GetSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	mergePasswordPolicy(policy, req)
+	if err := validatePasswordPolicy(policy); err != nil {
+		return nil, err
+	}
+
+	return s.repo.SaveSettings(ctx, policy)
+}
+
+// CheckPassword validates a new password of a user, or of a user about to
+// be created when user has no ID. A password in the breached password list
+// is refused with ErrBreachedPassword unless allowBreached is set; breached
+// reports whether it was listed either way.
+func (s *PasswordPolicyService) CheckPassword(ctx context.Context, user *model.User, password string, allowBreached bool) (bool, error) {
+	policy, err := s.repo.GetSettings(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	if err := checkPasswordRules(policy, user.Username, password); err != nil {
+		return false, err
+	}
+
+	if policy.HistoryCount > 0 && user.ID != "" {
+		hashes, err := s.repo.ListPasswordHistory(ctx, user.ID, policy.HistoryCount+1)
+		if err != nil {
+			return false, err
+		}
+		for _, hash := range hashes {
+			if bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil {
+				return false, &UserError{Field: "password", Message: fmt.Sprintf("must not be one of your last %d passwords", policy.HistoryCount)}
+			}
+		}
+	}
+
+	if !policy.BreachCheckEnabled {
+		return false, nil
+	}
+	list, err := s.breachList(ctx)
+	if err != nil {
+		return false, err
+	}
+	if !list.contains(password) {
+		return false, nil
+	}
+	if !allowBreached {
+		return true, ErrBreachedPassword
+	}
+	return true, nil
+}
+
+// PasswordChanged records the new password hash of a user for the history
+// and maximum age
+func (s *PasswordPolicyService) PasswordChanged(ctx context.Context, userID, passwordHash string) error {
+	policy, err := s.repo.GetSettings(ctx)
+	if err != nil {
+		return err
+	}
+	// The current password is always kept, it dates the last change
+	return s.repo.AddPasswordHistory(ctx, userID, passwordHash, policy.HistoryCount+1)
+}
+
+// PasswordExpired reports whether the password of a local account is older
+// than the maximum age
+func (s *PasswordPolicyService) PasswordExpired(ctx context.Context, user *model.User) bool {
+	if user.LDAPDN != "" || user.PasswordHash == "" || user.PasswordHash == ssoPasswordHash {
+		return false
+	}
+	policy, err := s.repo.GetSettings(ctx)
+	if err != nil {
+		log.Printf("[PasswordPolicy] Failed to load password policy: %v", err)
+		return false
+	}
+	if policy.MaxAgeDays <= 0 {
+		return false
+	}
+
+	changed, err := s.repo.LastPasswordChange(ctx, user.ID)
+	if err != nil {
+		log.Printf("[PasswordPolicy] Failed to get password age of %s: %v", user.Username, err)
+		return false
+	}
+	return changed != nil && time.Since(*changed) > time.Duration(policy.MaxAgeDays)*24*time.Hour
+}
+
+// LoginLockout returns a LockoutError while the username or the source IP
+// is locked out after too many failed logins. Username may be empty.
+func (s *PasswordPolicyService) LoginLockout(ctx context.Context, ip, username string) error {
+	policy, err := s.repo.GetSettings(ctx)
+	if err != nil {
+		return err
+	}
+	base := time.Duration(policy.LockoutMinutes) * time.Minute
+	limit := time.Duration(policy.MaxLockoutMinutes) * time.Minute
+	now := time.Now()
+	since := now.Add(-lockoutLookback)
+
+	if username != "" && policy.LockoutThreshold > 0 {
+		failures, last, err := s.authRepo.CountFailuresByUsername(ctx, username, since)
+		if err != nil {
+			return err
+		}
+		if until := lockoutUntil(failures, last, policy.LockoutThreshold, base, limit); until.After(now) {
+			return s.lockout(LockoutScopeUsername, username, until, now)
+		}
+	}
+
+	if ip != "" && policy.IPLockoutThreshold > 0 {
+		failures, last, err := s.authRepo.CountFailuresByIP(ctx, ip, since)
+		if err != nil {
+			return err
+		}
+		if until := lockoutUntil(failures, last, policy.IPLockoutThreshold, base, limit); until.After(now) {
+			return s.lockout(LockoutScopeIP, ip, until, now)
+		}
+	}
+
+	return nil
+}
+
+// lockout builds the error for an active lockout and remembers it, so only
+// the first refused login is reported
+func (s *PasswordPolicyService) lockout(scope, value string, until, now time.Time) error {
+	key := scope + ":" + value
+
+	s.lockMu.Lock()
+	defer s.lockMu.Unlock()
+
+	first := !s.reported[key].Equal(until)
+	if first {
+		for k, u := range s.reported {
+			if u.Before(now) {
+				delete(s.reported, k)
+			}
+		}
+		s.reported[key] = until
+	}
+	return &LockoutError{Scope: scope, Value: value, Until: until, First: first}
+}
+
+// Unlock ends the lockout of a user by forgetting its failed logins
+func (s *PasswordPolicyService) Unlock(ctx context.Context, userID string) (*model.User, int64, error) {
+	user, err := s.authRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if user == nil {
+		return nil, 0, ErrUserNotFound
+	}
+
+	cleared, err := s.authRepo.ClearFailedAttempts(ctx, user.Username)
+	if err != nil {
+		return nil, 0, err
+	}
+	return user, cleared, nil
+}
+
+// === Breached password list ===
+
+// BreachListStatus describes the breached password list in use
+func (s *PasswordPolicyService) BreachListStatus(ctx context.Context) (*model.BreachListStatus, error) {
+	if _, err := s.breachList(ctx); err != nil {
+		return nil, err
+	}
+	s.breachMu.RLock()
+	defer s.breachMu.RUnlock()
+	status := s.breachStatus
+	return &status, nil
+}
+
+// UploadBreachList replaces the bundled list with an uploaded list of SHA-1 hashes
+func (s *PasswordPolicyService) UploadBreachList(ctx context.Context, name string, r io.Reader, uploadedBy string) (*model.BreachListStatus, error) {
+	hashes, err := parseBreachList(r)
+	if err != nil {
+		return nil, &UserError{Field: "file", Message: err.Error()}
+	}
+	if len(hashes) == 0 {
+		return nil, &UserError{Field: "file", Message: "contains no hashes"}
+	}
+
+	list := &model.BreachedPasswordList{
+		Name:       name,
+		HashCount:  len(hashes) / sha1.Size,
+		Hashes:     hashes,
+		UploadedBy: uploadedBy,
+	}
+	if err := s.repo.SaveBreachList(ctx, list); err != nil {
+		return nil, err
+	}
+
+	s.setBreachList(newBreachList(hashes), uploadedBreachStatus(list))
+	return s.BreachListStatus(ctx)
+}
+
+// DeleteBreachList removes the uploaded list and goes back to the bundled one
+func (s *PasswordPolicyService) DeleteBreachList(ctx context.Context) (*model.BreachListStatus, error) {
+	if _, err := s.repo.DeleteBreachList(ctx); err != nil {
+		return nil, err
+	}
+
+	list, status, err := bundledBreachList()
+	if err != nil {
+		return nil, err
+	}
+	s.setBreachList(list, status)
+	return s.BreachListStatus(ctx)
+}
+
+// breachList returns the list in use, loading it on first use
+func (s *PasswordPolicyService) breachList(ctx context.Context) (*breachList, error) {
+	s.breachMu.RLock()
+	list := s.breach
+	s.breachMu.RUnlock()
+	if list != nil {
+		return list, nil
+	}
+
+	uploaded, err := s.repo.GetBreachList(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if uploaded != nil && len(uploaded.Hashes)%sha1.Size == 0 {
+		list = newBreachList(uploaded.Hashes)
+		s.setBreachList(list, uploadedBreachStatus(uploaded))
+		return list, nil
+	}
+
+	list, status, err := bundledBreachList()
+	if err != nil {
+		return nil, err
+	}
+	s.setBreachList(list, status)
+	return list, nil
+}
+
+func (s *PasswordPolicyService) setBreachList(list *breachList, status model.BreachListStatus) {
+	s.breachMu.Lock()
+	s.breach = list
+	s.breachStatus = status
+	s.breachMu.Unlock()
+}
+
+func uploadedBreachStatus(list *model.BreachedPasswordList) model.BreachListStatus {
+	uploadedAt := list.CreatedAt
+	return model.BreachListStatus{
+		Source:     model.BreachListUploaded,
+		Name:       list.Name,
+		HashCount:  list.HashCount,
+		UploadedAt: &uploadedAt,
+	}
+}
+
+func bundledBreachList() (*breachList, model.BreachListStatus, error) {
+	hashes, err := parseBreachList(bytes.NewReader(commonPasswordHashes))
+	if err != nil {
+		return nil, model.BreachListStatus{}, fmt.Errorf("invalid bundled password list: %w", err)
+	}
+	list := newBreachList(hashes)
+	return list, model.BreachListStatus{
+		Source:    model.BreachListBundled,
+		Name:      "Common passwords",
+		HashCount: list.count(),
+	}, nil
+}
+
+// checkPasswordRules checks the length and character classes of a password
+func checkPasswordRules(policy *model.PasswordPolicy, username, password string) error {
+	if len([]rune(password)) < policy.MinLength {
+		return &UserError{Field: "password", Message: fmt.Sprintf("must be at least %d characters", policy.MinLength)}
+	}
+	if len(password) > maxPasswordBytes {
+		return &UserError{Field: "password", Message: fmt.Sprintf("must be at most %d bytes", maxPasswordBytes)}
+	}
+	if username != "" && strings.EqualFold(password, username) {
+		return &UserError{Field: "password", Message: "must not be the username"}
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case !unicode.IsLetter(r):
+			symbol = true
+		}
+	}
+
+	var missing []string
+	if policy.RequireUppercase && !upper {
+		missing = append(missing, "an uppercase letter")
+	}
+	if policy.RequireLowercase && !lower {
+		missing = append(missing, "a lowercase letter")
+	}
+	if policy.RequireDigit && !digit {
+		missing = append(missing, "a digit")
+	}
+	if policy.RequireSymbol && !symbol {
+		missing = append(missing, "a symbol")
+	}
+	if len(missing) > 0 {
+		return &UserError{Field: "password", Message: "must contain " + strings.Join(missing, ", ")}
+	}
+	return nil
+}
+
+// lockoutUntil returns when a lockout after failures failed logins, the
+// newest at last, ends. Every threshold failures double the lockout up to
+// limit. It returns the zero time below the threshold.
+func lockoutUntil(failures int, last time.Time, threshold int, base, limit time.Duration) time.Time {
+	if threshold <= 0 || failures < threshold {
+		return time.Time{}
+	}
+	duration := base
+	for i := 1; i < failures/threshold && duration < limit; i++ {
+		duration *= 2
+	}
+	if duration > limit {
+		duration = limit
+	}
+	return last.Add(duration)
+}
+
+func mergePasswordPolicy(p *model.PasswordPolicy, req *model.UpdatePasswordPolicyRequest) {
+	if req.MinLength != nil {
+		p.MinLength = *req.MinLength
+	}
+	if req.RequireUppercase != nil {
+		p.RequireUppercase = *req.RequireUppercase
+	}
+	if req.RequireLowercase != nil {
+		p.RequireLowercase = *req.RequireLowercase
+	}
+	if req.RequireDigit != nil {
+		p.RequireDigit = *req.RequireDigit
+	}
+	if req.RequireSymbol != nil {
+		p.RequireSymbol = *req.RequireSymbol
+	}
+	if req.HistoryCount != nil {
+		p.HistoryCount = *req.HistoryCount
+	}
+	if req.MaxAgeDays != nil {
+		p.MaxAgeDays = *req.MaxAgeDays
+	}
+	if req.BreachCheckEnabled != nil {
+		p.BreachCheckEnabled = *req.BreachCheckEnabled
+	}
+	if req.LockoutThreshold != nil {
+		p.LockoutThreshold = *req.LockoutThreshold
+	}
+	if req.IPLockoutThreshold != nil {
+		p.IPLockoutThreshold = *req.IPLockoutThreshold
+	}
+	if req.LockoutMinutes != nil {
+		p.LockoutMinutes = *req.LockoutMinutes
+	}
+	if req.MaxLockoutMinutes != nil {
+		p.MaxLockoutMinutes = *req.MaxLockoutMinutes
+	}
+}
+
+// validatePasswordPolicy checks the bounds of the password policy
+func validatePasswordPolicy(p *model.PasswordPolicy) error {
+	if p.MinLength < 8 || p.MinLength > maxPasswordBytes {
+		return &UserError{Field: "min_length", Message: fmt.Sprintf("must be between 8 and %d", maxPasswordBytes)}
+	}
+	if p.HistoryCount < 0 || p.HistoryCount > 24 {
+		return &UserError{Field: "history_count", Message: "must be between 0 and 24"}
+	}
+	if p.MaxAgeDays < 0 || p.MaxAgeDays > 3650 {
+		return &UserError{Field: "max_age_days", Message: "must be between 0 and 3650"}
+	}
+	if p.LockoutThreshold != 0 && (p.LockoutThreshold < 3 || p.LockoutThreshold > 100) {
+		return &UserError{Field: "lockout_threshold", Message: "must be 0 (disabled) or between 3 and 100"}
+	}
+	if p.IPLockoutThreshold != 0 && (p.IPLockoutThreshold < 3 || p.IPLockoutThreshold > 1000) {
+		return &UserError{Field: "ip_lockout_threshold", Message: "must be 0 (disabled) or between 3 and 1000"}
+	}
+	maxMinutes := int(lockoutLookback / time.Minute)
+	if p.LockoutMinutes < 1 || p.LockoutMinutes > maxMinutes {
+		return &UserError{Field: "lockout_minutes", Message: fmt.Sprintf("must be between 1 and %d", maxMinutes)}
+	}
+	if p.MaxLockoutMinutes < p.LockoutMinutes || p.MaxLockoutMinutes > maxMinutes {
+		return &UserError{Field: "max_lockout_minutes", Message: fmt.Sprintf("must be between lockout_minutes and %d", maxMinutes)}
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"nginx-proxy-guard/internal/model"
+)
+
+func TestLockoutUntil(t *testing.T) {
+	last := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	base, limit := 15*time.Minute, 2*time.Hour
+
+	tests := []struct {
+		name      string
+		failures  int
+		threshold int
+		want      time.Duration
+	}{
+		{"below threshold", 4, 5, 0},
+		{"disabled", 50, 0, 0},
+		{"at threshold", 5, 5, 15 * time.Minute},
+		{"between multiples", 9, 5, 15 * time.Minute},
+		{"second multiple doubles", 10, 5, 30 * time.Minute},
+		{"third multiple doubles again", 15, 5, time.Hour},
+		{"capped at limit", 100, 5, 2 * time.Hour},
+	}
+	for _, tt := range tests {
+		got := lockoutUntil(tt.failures, last, tt.threshold, base, limit)
+		if tt.want == 0 {
+			if !got.IsZero() {
+				t.Errorf("%s: lockoutUntil() = %v, want no lockout", tt.name, got)
+			}
+			continue
+		}
+		if want := last.Add(tt.want); !got.Equal(want) {
+			t.Errorf("%s: lockoutUntil() = %v, want %v", tt.name, got, want)
+		}
+	}
+}
+
+func TestLockoutErrorIs(t *testing.T) {
+	err := error(&LockoutError{Scope: LockoutScopeUsername, Value: "admin", Until: time.Now()})
+	if !errors.Is(err, ErrTooManyAttempts) {
+		t.Error("LockoutError should match ErrTooManyAttempts")
+	}
+}
+
+func TestCheckPasswordRules(t *testing.T) {
+	strict := &model.PasswordPolicy{
+		MinLength:        10,
+		RequireUppercase: true,
+		RequireLowercase: true,
+		RequireDigit:     true,
+		RequireSymbol:    true,
+	}
+	lenient := &model.PasswordPolicy{MinLength: 8}
+
+	tests := []struct {
+		name     string
+		policy   *model.PasswordPolicy
+		username string
+		password string
+		wantErr  string
+	}{
+		{"lenient ok", lenient, "admin", "abcdefgh", ""},
+		{"too short", lenient, "admin", "abcdefg", "at least 8"},
+		{"multibyte counts runes", lenient, "admin", "비밀번호비밀번호", ""},
+		{"too long for bcrypt", lenient, "admin", strings.Repeat("a", 73), "at most 72"},
+		{"same as username", lenient, "administrator", "Administrator", "username"},
+		{"strict ok", strict, "admin", "Correct-Horse9", ""},
+		{"missing classes", strict, "admin", "correcthorse", "an uppercase letter, a digit, a symbol"},
+		{"missing symbol", strict, "admin", "CorrectHorse9", "a symbol"},
+	}
+	for _, tt := range tests {
+		err := checkPasswordRules(tt.policy, tt.username, tt.password)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error %v", tt.name, err)
+			}
+			continue
+		}
+		var uerr *UserError
+		if !errors.As(err, &uerr) || !strings.Contains(uerr.Message, tt.wantErr) {
+			t.Errorf("%s: error = %v, want it to contain %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidatePasswordPolicy(t *testing.T) {
+	valid := func() *model.PasswordPolicy {
+		return &model.PasswordPolicy{
+			MinLength:         8,
+			LockoutThreshold:  5,
+			LockoutMinutes:    15,
+			MaxLockoutMinutes: 1440,
+		}
+	}
+	if err := validatePasswordPolicy(valid()); err != nil {
+		t.Fatalf("default policy rejected: %v", err)
+	}
+
+	tests := []struct {
+		field  string
+		modify func(p *model.PasswordPolicy)
+	}{
+		{"min_length", func(p *model.PasswordPolicy) { p.MinLength = 6 }},
+		{"min_length", func(p *model.PasswordPolicy) { p.MinLength = 80 }},
+		{"history_count", func(p *model.PasswordPolicy) { p.HistoryCount = 25 }},
+		{"max_age_days", func(p *model.PasswordPolicy) { p.MaxAgeDays = -1 }},
+		{"lockout_threshold", func(p *model.PasswordPolicy) { p.LockoutThreshold = 2 }},
+		{"ip_lockout_threshold", func(p *model.PasswordPolicy) { p.IPLockoutThreshold = 1 }},
+		{"lockout_minutes", func(p *model.PasswordPolicy) { p.LockoutMinutes = 0 }},
+		{"max_lockout_minutes", func(p *model.PasswordPolicy) { p.MaxLockoutMinutes = 10 }},
+	}
+	for _, tt := range tests {
+		p := valid()
+		tt.modify(p)
+		var uerr *UserError
+		if err := validatePasswordPolicy(p); !errors.As(err, &uerr) || uerr.Field != tt.field {
+			t.Errorf("%s: error = %v", tt.field, err)
+		}
+	}
+}
+
+func TestBreachList(t *testing.T) {
+	// SHA-1 of "password" and "letmein"
+	input := strings.Join([]string{
+		"# comment",
+		"",
+		"5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8:3861493",
+		"b7a875fc1ea228b9061041b7cec4bd3c52ab3ce3",
+		"5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8",
+	}, "\n")
+	hashes, err := parseBreachList(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("parseBreachList() error = %v", err)
+	}
+	list := newBreachList(hashes)
+	if list.count() != 2 {
+		t.Errorf("count() = %d, want 2 after deduplication", list.count())
+	}
+	for _, password := range []string{"password", "letmein"} {
+		if !list.contains(password) {
+			t.Errorf("contains(%q) = false", password)
+		}
+	}
+	if list.contains("Correct-Horse-Battery-Staple") {
+		t.Error("contains() matched a password that is not in the list")
+	}
+
+	if _, err := parseBreachList(strings.NewReader("5BAA61E4\n")); err == nil || !strings.Contains(err.Error(), "line 1") {
+		t.Errorf("parseBreachList() error = %v, want a line 1 error", err)
+	}
+
+	bundled, status, err := bundledBreachList()
+	if err != nil {
+		t.Fatalf("bundledBreachList() error = %v", err)
+	}
+	if status.Source != model.BreachListBundled || status.HashCount != bundled.count() {
+		t.Errorf("bundled status = %+v", status)
+	}
+	if !bundled.contains("123456") {
+		t.Error("bundled list should contain 123456")
+	}
+}
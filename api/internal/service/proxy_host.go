@@ -56,7 +56,15 @@ type ProxyHostService struct {
 	exploitBlockRuleRepo   *repository.ExploitBlockRuleRepository
 	certRepo               *repository.CertificateRepository
 	nginx                  NginxManager
-	certService            CertificateCreator // Optional: for creating certificates during clone
+	certService            CertificateCreator                 // Optional: for creating certificates during clone
+	threatFeedRepo         *repository.ThreatFeedRepository // Optional: threat intelligence feed subscriptions
+	forwardAuthRepo        *repository.ForwardAuthRepository  // Optional: external authentication per host
+	oidcGateRepo           *repository.OIDCGateRepository     // Optional: built-in OIDC login gate per host
+	clientCARepo           *repository.ClientCARepository     // Optional: client certificate authentication per host
+	upstreamTLSRepo        *repository.UpstreamTLSRepository  // Optional: backend TLS verification per host
+	tlsPolicyRepo          *repository.TLSPolicyRepository    // Optional: TLS server policy per host
+	corsPolicyRepo         *repository.CORSPolicyRepository   // Optional: CORS policies per host and path
+	signedURLRepo          *repository.SignedURLRepository    // Optional: signed URL protected paths per host
 }
 
 func NewProxyHostService(
@@ -100,6 +108,46 @@ func (s *ProxyHostService) SetCertificateService(certService CertificateCreator)
 	s.certService = certService
 }
 
+// SetThreatFeedRepository sets the repository used to load per-host threat feed subscriptions
+func (s *ProxyHostService) SetThreatFeedRepository(repo *repository.ThreatFeedRepository) {
+	s.threatFeedRepo = repo
+}
+
+// SetForwardAuthRepository sets the repository used to load per-host forward auth settings
+func (s *ProxyHostService) SetForwardAuthRepository(repo *repository.ForwardAuthRepository) {
+	s.forwardAuthRepo = repo
+}
+
+// SetOIDCGateRepository sets the repository used to load per-host OIDC login gates
+func (s *ProxyHostService) SetOIDCGateRepository(repo *repository.OIDCGateRepository) {
+	s.oidcGateRepo = repo
+}
+
+// SetClientCARepository sets the repository used to load per-host client certificate authentication
+func (s *ProxyHostService) SetClientCARepository(repo *repository.ClientCARepository) {
+	s.clientCARepo = repo
+}
+
+// SetUpstreamTLSRepository sets the repository used to load per-host backend TLS settings
+func (s *ProxyHostService) SetUpstreamTLSRepository(repo *repository.UpstreamTLSRepository) {
+	s.upstreamTLSRepo = repo
+}
+
+// SetTLSPolicyRepository sets the repository used to load per-host TLS policies
+func (s *ProxyHostService) SetTLSPolicyRepository(repo *repository.TLSPolicyRepository) {
+	s.tlsPolicyRepo = repo
+}
+
+// SetCORSPolicyRepository sets the repository used to load per-host CORS policies
+func (s *ProxyHostService) SetCORSPolicyRepository(repo *repository.CORSPolicyRepository) {
+	s.corsPolicyRepo = repo
+}
+
+// SetSignedURLRepository sets the repository used to load per-host signed URL locations
+func (s *ProxyHostService) SetSignedURLRepository(repo *repository.SignedURLRepository) {
+	s.signedURLRepo = repo
+}
+
 // getMergedWAFExclusions gets host-specific exclusions and merges with global exclusions
 func (s *ProxyHostService) getMergedWAFExclusions(ctx context.Context, hostID string) ([]model.WAFRuleExclusion, error) {
 	// Get host-specific exclusions
@@ -287,8 +335,8 @@ func (s *ProxyHostService) getHostConfigData(ctx context.Context, host *model.Pr
 			if err == nil && bannedResp != nil {
 				bannedIPs = bannedResp.Data
 			}
-			// Also get global bans (proxy_host_id IS NULL)
-			globalBannedResp, err := s.rateLimitRepo.ListGlobalBannedIPs(ctx, 1, 1000)
+			// Also get global bans (proxy_host_id IS NULL); CrowdSec bans live in the shared include
+			globalBannedResp, err := s.rateLimitRepo.ListLocalGlobalBannedIPs(ctx, 1, 1000)
 			if err == nil && globalBannedResp != nil {
 				bannedIPs = append(bannedIPs, globalBannedResp.Data...)
 			}
@@ -317,6 +365,124 @@ func (s *ProxyHostService) getHostConfigData(ctx context.Context, host *model.Pr
 		}()
 	}
 
+	// Fetch threat feed IP ranges subscribed by this host
+	if s.threatFeedRepo != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			block, challenge, err := s.threatFeedRepo.GetHostFeedRanges(ctx, host.ID)
+			if err == nil {
+				mu.Lock()
+				data.ThreatFeedBlockRanges = block
+				data.ThreatFeedChallengeRanges = challenge
+				mu.Unlock()
+			}
+		}()
+	}
+
+	// Fetch forward auth settings
+	if s.forwardAuthRepo != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fa, err := s.forwardAuthRepo.GetByProxyHostID(ctx, host.ID)
+			if err == nil && fa != nil && fa.Enabled {
+				mu.Lock()
+				data.ForwardAuth = fa
+				mu.Unlock()
+			}
+		}()
+	}
+
+	// Fetch OIDC login gate
+	if s.oidcGateRepo != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			gate, err := s.oidcGateRepo.GetGateByProxyHostID(ctx, host.ID)
+			if err == nil && gate != nil && gate.Enabled {
+				mu.Lock()
+				data.OIDCGate = gate
+				mu.Unlock()
+			}
+		}()
+	}
+
+	// Fetch client certificate authentication with its CA
+	if s.clientCARepo != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cfg, err := s.clientCARepo.GetAuthByProxyHostID(ctx, host.ID)
+			if err != nil || cfg == nil || !cfg.Enabled {
+				return
+			}
+			ca, err := s.clientCARepo.GetByID(ctx, cfg.ClientCAID)
+			if err == nil && ca != nil {
+				mu.Lock()
+				data.ClientCertAuth = cfg
+				data.ClientCA = ca
+				mu.Unlock()
+			}
+		}()
+	}
+
+	// Fetch TLS policy
+	if s.tlsPolicyRepo != nil && host.SSLEnabled {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			policy, err := s.tlsPolicyRepo.GetByProxyHostID(ctx, host.ID)
+			if err == nil && policy != nil {
+				mu.Lock()
+				data.TLSPolicy = policy
+				mu.Unlock()
+			}
+		}()
+	}
+
+	// Fetch CORS policies
+	if s.corsPolicyRepo != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			policies, err := s.corsPolicyRepo.ListByProxyHostID(ctx, host.ID)
+			if err == nil {
+				mu.Lock()
+				data.CORSPolicies = policies
+				mu.Unlock()
+			}
+		}()
+	}
+
+	// Fetch signed URL locations
+	if s.signedURLRepo != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			locations, err := s.signedURLRepo.ListByProxyHostID(ctx, host.ID)
+			if err == nil {
+				mu.Lock()
+				data.SignedURLLocations = locations
+				mu.Unlock()
+			}
+		}()
+	}
+
+	// Fetch backend TLS settings
+	if s.upstreamTLSRepo != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cfg, err := s.upstreamTLSRepo.GetByProxyHostID(ctx, host.ID)
+			if err == nil && cfg != nil && cfg.Enabled {
+				mu.Lock()
+				data.UpstreamTLS = cfg
+				mu.Unlock()
+			}
+		}()
+	}
+
 	// Fetch URI block settings (both global and per-host)
 	if s.uriBlockRepo != nil {
 		wg.Add(1)
@@ -523,7 +689,12 @@ func (s *ProxyHostService) GetByDomain(ctx context.Context, domain string) (*mod
 }
 
 func (s *ProxyHostService) List(ctx context.Context, page, perPage int, search, sortBy, sortOrder string) (*model.ProxyHostListResponse, error) {
-	hosts, total, err := s.repo.List(ctx, page, perPage, search, sortBy, sortOrder)
+	return s.ListInScope(ctx, model.APITokenScope{}, page, perPage, search, sortBy, sortOrder)
+}
+
+// ListInScope lists the proxy hosts inside an API token scope
+func (s *ProxyHostService) ListInScope(ctx context.Context, scope model.APITokenScope, page, perPage int, search, sortBy, sortOrder string) (*model.ProxyHostListResponse, error) {
+	hosts, total, err := s.repo.ListInScope(ctx, scope, page, perPage, search, sortBy, sortOrder)
 	if err != nil {
 		return nil, err
 	}
@@ -889,6 +1060,29 @@ func (s *ProxyHostService) RegenerateConfigsForCertificate(ctx context.Context,
 		return fmt.Errorf("failed to get proxy hosts for certificate %s: %w", certificateID, err)
 	}
 
+	// Hosts presenting the certificate to their backend pick up renewed files on reload as well
+	if s.upstreamTLSRepo != nil {
+		hostIDs, err := s.upstreamTLSRepo.ListProxyHostIDsByClientCertificate(ctx, certificateID)
+		if err != nil {
+			return fmt.Errorf("failed to get upstream TLS proxy hosts for certificate %s: %w", certificateID, err)
+		}
+		for _, hostID := range hostIDs {
+			found := false
+			for _, host := range hosts {
+				if host.ID == hostID {
+					found = true
+					break
+				}
+			}
+			if found {
+				continue
+			}
+			if host, err := s.repo.GetByID(ctx, hostID); err == nil && host != nil {
+				hosts = append(hosts, *host)
+			}
+		}
+	}
+
 	if len(hosts) == 0 {
 		return nil // No proxy hosts use this certificate
 	}
@@ -1140,6 +1334,141 @@ func (s *ProxyHostService) RegenerateConfigsForCloudProviders(ctx context.Contex
 	return nil
 }
 
+// RegenerateConfigsForThreatFeeds regenerates nginx configs for proxy hosts
+// subscribed to any of the specified threat feeds
+func (s *ProxyHostService) RegenerateConfigsForThreatFeeds(ctx context.Context, updatedFeeds []string) error {
+	if s.threatFeedRepo == nil {
+		return nil
+	}
+
+	hostIDs, err := s.threatFeedRepo.GetProxyHostIDsForFeeds(ctx, updatedFeeds)
+	if err != nil {
+		return fmt.Errorf("failed to get proxy hosts with threat feeds: %w", err)
+	}
+
+	if len(hostIDs) == 0 {
+		return nil
+	}
+
+	log.Printf("[ThreatFeed] Regenerating configs for %d proxy hosts", len(hostIDs))
+
+	// Only the host config and its include file change; WAF configs are untouched
+	for _, hostID := range hostIDs {
+		host, err := s.repo.GetByID(ctx, hostID)
+		if err != nil {
+			log.Printf("[ThreatFeed] Error getting proxy host %s: %v", hostID, err)
+			continue
+		}
+		if host == nil || !host.Enabled {
+			continue
+		}
+
+		configData := s.getHostConfigData(ctx, host)
+		if err := s.nginx.GenerateConfigFull(ctx, configData); err != nil {
+			return fmt.Errorf("failed to generate config for host %s: %w", hostID, err)
+		}
+	}
+
+	if err := s.nginx.TestConfig(ctx); err != nil {
+		return fmt.Errorf("nginx config test failed: %w", err)
+	}
+
+	if err := s.nginx.ReloadNginx(ctx); err != nil {
+		return fmt.Errorf("failed to reload nginx: %w", err)
+	}
+
+	log.Printf("[ThreatFeed] Nginx configs regenerated and reloaded for %d hosts", len(hostIDs))
+	return nil
+}
+
+// RegenerateConfigsForAccessList regenerates nginx configs for the given proxy hosts after
+// their access list changed (IP rules or Basic Auth users), then tests and reloads once
+func (s *ProxyHostService) RegenerateConfigsForAccessList(ctx context.Context, hostIDs []string) error {
+	if len(hostIDs) == 0 {
+		return nil
+	}
+
+	for _, hostID := range hostIDs {
+		host, err := s.repo.GetByID(ctx, hostID)
+		if err != nil {
+			log.Printf("[AccessList] Error getting proxy host %s: %v", hostID, err)
+			continue
+		}
+		if host == nil || !host.Enabled {
+			continue
+		}
+
+		configData := s.getHostConfigData(ctx, host)
+		if err := s.nginx.GenerateConfigFull(ctx, configData); err != nil {
+			return fmt.Errorf("failed to generate config for host %s: %w", hostID, err)
+		}
+	}
+
+	if err := s.nginx.TestConfig(ctx); err != nil {
+		return fmt.Errorf("nginx config test failed: %w", err)
+	}
+
+	if err := s.nginx.ReloadNginx(ctx); err != nil {
+		return fmt.Errorf("failed to reload nginx: %w", err)
+	}
+
+	log.Printf("[AccessList] Nginx configs regenerated and reloaded for %d hosts", len(hostIDs))
+	return nil
+}
+
+// RegenerateConfigsForClientCA regenerates the configs of the proxy hosts using an updated client CA
+func (s *ProxyHostService) RegenerateConfigsForClientCA(ctx context.Context, hostIDs []string) error {
+	if len(hostIDs) == 0 {
+		return nil
+	}
+
+	for _, hostID := range hostIDs {
+		host, err := s.repo.GetByID(ctx, hostID)
+		if err != nil {
+			log.Printf("[ClientCA] Error getting proxy host %s: %v", hostID, err)
+			continue
+		}
+		if host == nil || !host.Enabled {
+			continue
+		}
+
+		configData := s.getHostConfigData(ctx, host)
+		if err := s.nginx.GenerateConfigFull(ctx, configData); err != nil {
+			return fmt.Errorf("failed to generate config for host %s: %w", hostID, err)
+		}
+	}
+
+	if err := s.nginx.TestConfig(ctx); err != nil {
+		return fmt.Errorf("nginx config test failed: %w", err)
+	}
+
+	if err := s.nginx.ReloadNginx(ctx); err != nil {
+		return fmt.Errorf("failed to reload nginx: %w", err)
+	}
+
+	log.Printf("[ClientCA] Nginx configs regenerated and reloaded for %d hosts", len(hostIDs))
+	return nil
+}
+
+// GetUpstreamTLS returns the backend TLS settings of a proxy host with the client certificate they present
+func (s *ProxyHostService) GetUpstreamTLS(ctx context.Context, hostID string) (*model.UpstreamTLSConfig, *model.Certificate, error) {
+	if s.upstreamTLSRepo == nil {
+		return nil, nil, nil
+	}
+	cfg, err := s.upstreamTLSRepo.GetByProxyHostID(ctx, hostID)
+	if err != nil || cfg == nil || !cfg.Enabled {
+		return nil, nil, err
+	}
+	if cfg.ClientCertificateID == nil || s.certRepo == nil {
+		return cfg, nil, nil
+	}
+	cert, err := s.certRepo.GetByID(ctx, *cfg.ClientCertificateID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get upstream client certificate: %w", err)
+	}
+	return cfg, cert, nil
+}
+
 // RegenerateConfigsForExploitRules regenerates nginx configs for all proxy hosts
 // that have block_exploits enabled. Called when exploit rules are modified.
 func (s *ProxyHostService) RegenerateConfigsForExploitRules(ctx context.Context) error {
@@ -1400,7 +1729,9 @@ func (s *ProxyHostService) Clone(ctx context.Context, sourceID string, req *mode
 		ProxyRequestBuffering:   source.ProxyRequestBuffering,
 		ClientMaxBodySize:       source.ClientMaxBodySize,
 		ProxyMaxTempFileSize:    source.ProxyMaxTempFileSize,
+		ProxyProtocol:           source.ProxyProtocol,
 		Enabled:                 source.Enabled,
+		Tags:                    source.Tags,
 	}
 
 	// Create the new host
@@ -1610,6 +1941,131 @@ func (s *ProxyHostService) cloneRelatedConfigs(ctx context.Context, sourceID, ta
 		}
 	}
 
+	// Clone ForwardAuth
+	if s.forwardAuthRepo != nil {
+		fa, err := s.forwardAuthRepo.GetByProxyHostID(ctx, sourceID)
+		if err != nil {
+			log.Printf("[Clone] Failed to get forward auth: %v", err)
+		} else if fa != nil {
+			faReq := &model.UpsertForwardAuthRequest{
+				Enabled:         &fa.Enabled,
+				AuthURL:         fa.AuthURL,
+				SigninURL:       fa.SigninURL,
+				ResponseHeaders: fa.ResponseHeaders,
+				BypassPaths:     fa.BypassPaths,
+				ProtectHost:     &fa.ProtectHost,
+			}
+			if _, err := s.forwardAuthRepo.Upsert(ctx, targetID, faReq); err != nil {
+				log.Printf("[Clone] Failed to clone forward auth: %v", err)
+			}
+		}
+	}
+
+	// Clone OIDC login gate
+	if s.oidcGateRepo != nil {
+		gate, err := s.oidcGateRepo.GetGateByProxyHostID(ctx, sourceID)
+		if err != nil {
+			log.Printf("[Clone] Failed to get OIDC login gate: %v", err)
+		} else if gate != nil {
+			gateReq := &model.UpsertOIDCHostGateRequest{
+				ProviderID:          gate.ProviderID,
+				Enabled:             &gate.Enabled,
+				AllowedEmailDomains: gate.AllowedEmailDomains,
+				AllowedGroups:       gate.AllowedGroups,
+				RequiredClaims:      gate.RequiredClaims,
+				SessionLifetime:     gate.SessionLifetime,
+			}
+			if _, err := s.oidcGateRepo.UpsertGate(ctx, targetID, gateReq); err != nil {
+				log.Printf("[Clone] Failed to clone OIDC login gate: %v", err)
+			}
+		}
+	}
+
+	// Clone client certificate authentication
+	if s.clientCARepo != nil {
+		cfg, err := s.clientCARepo.GetAuthByProxyHostID(ctx, sourceID)
+		if err != nil {
+			log.Printf("[Clone] Failed to get client certificate authentication: %v", err)
+		} else if cfg != nil {
+			cfgReq := &model.UpsertClientCertAuthRequest{
+				ClientCAID:     cfg.ClientCAID,
+				Enabled:        &cfg.Enabled,
+				VerifyMode:     cfg.VerifyMode,
+				VerifyDepth:    cfg.VerifyDepth,
+				ForwardHeaders: cfg.ForwardHeaders,
+				EnforcePaths:   cfg.EnforcePaths,
+			}
+			if _, err := s.clientCARepo.UpsertAuth(ctx, targetID, cfgReq); err != nil {
+				log.Printf("[Clone] Failed to clone client certificate authentication: %v", err)
+			}
+		}
+	}
+
+	// Clone TLS policy
+	if s.tlsPolicyRepo != nil {
+		policy, err := s.tlsPolicyRepo.GetByProxyHostID(ctx, sourceID)
+		if err != nil {
+			log.Printf("[Clone] Failed to get TLS policy: %v", err)
+		} else if policy != nil {
+			if _, err := s.tlsPolicyRepo.Upsert(ctx, targetID, policy); err != nil {
+				log.Printf("[Clone] Failed to clone TLS policy: %v", err)
+			}
+		}
+	}
+
+	// Clone CORS policies
+	if s.corsPolicyRepo != nil {
+		policies, err := s.corsPolicyRepo.ListByProxyHostID(ctx, sourceID)
+		if err != nil {
+			log.Printf("[Clone] Failed to get CORS policies: %v", err)
+		}
+		for i := range policies {
+			if _, err := s.corsPolicyRepo.Upsert(ctx, targetID, &policies[i]); err != nil {
+				log.Printf("[Clone] Failed to clone CORS policy %s: %v", policies[i].PathPrefix, err)
+			}
+		}
+	}
+
+	// Clone signed URL locations with new secrets, so links of the source host don't open the clone
+	if s.signedURLRepo != nil {
+		locations, err := s.signedURLRepo.ListByProxyHostID(ctx, sourceID)
+		if err != nil {
+			log.Printf("[Clone] Failed to get signed URL locations: %v", err)
+		}
+		for i := range locations {
+			secret, err := generateSignedURLSecret()
+			if err != nil {
+				log.Printf("[Clone] Failed to clone signed URL location %s: %v", locations[i].PathPrefix, err)
+				continue
+			}
+			locations[i].Secret = secret
+			if _, err := s.signedURLRepo.Upsert(ctx, targetID, &locations[i]); err != nil {
+				log.Printf("[Clone] Failed to clone signed URL location %s: %v", locations[i].PathPrefix, err)
+			}
+		}
+	}
+
+	// Clone backend TLS settings
+	if s.upstreamTLSRepo != nil {
+		cfg, err := s.upstreamTLSRepo.GetByProxyHostID(ctx, sourceID)
+		if err != nil {
+			log.Printf("[Clone] Failed to get upstream TLS: %v", err)
+		} else if cfg != nil {
+			cfgReq := &model.UpsertUpstreamTLSRequest{
+				Enabled:             &cfg.Enabled,
+				Verify:              &cfg.Verify,
+				TrustedCAPEM:        cfg.TrustedCAPEM,
+				VerifyDepth:         cfg.VerifyDepth,
+				ServerName:          cfg.ServerName,
+				SNI:                 &cfg.SNI,
+				ClientCertificateID: cfg.ClientCertificateID,
+			}
+			if _, err := s.upstreamTLSRepo.Upsert(ctx, targetID, cfgReq); err != nil {
+				log.Printf("[Clone] Failed to clone upstream TLS: %v", err)
+			}
+		}
+	}
+
 	// Clone WAF Rule Exclusions
 	if s.wafRepo != nil {
 		exclusions, err := s.wafRepo.GetExclusionsByProxyHost(ctx, sourceID)
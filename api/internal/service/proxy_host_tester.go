@@ -2,7 +2,10 @@ package service
 
 import (
 	"context"
+	"crypto/sha256"
 	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
 	"fmt"
 	"net"
 	"net/http"
@@ -142,15 +145,32 @@ func (t *ProxyHostTester) TestHost(ctx context.Context, host *model.ProxyHost, t
 }
 
 // TestUpstream tests connectivity to the upstream server directly
-func (t *ProxyHostTester) TestUpstream(ctx context.Context, host *model.ProxyHost) (*model.ProxyHostTestResult, error) {
+// With backend TLS settings the handshake is verified the way nginx would (trusted CA, depth, name)
+// and the client certificate is presented, so verification failures show up before traffic does
+func (t *ProxyHostTester) TestUpstream(ctx context.Context, host *model.ProxyHost, tlsCfg *model.UpstreamTLSConfig, clientCert *model.Certificate) (*model.ProxyHostTestResult, error) {
 	result := &model.ProxyHostTestResult{
 		Domain:   fmt.Sprintf("%s:%d", host.ForwardHost, host.ForwardPort),
 		TestedAt: time.Now(),
 		Headers:  make(map[string]string),
 	}
 
+	// Backend TLS settings switch the upstream to https
+	scheme := host.ForwardScheme
+	if tlsCfg != nil {
+		scheme = "https"
+	}
+
 	// Build upstream URL
-	upstreamURL := fmt.Sprintf("%s://%s:%d/", host.ForwardScheme, host.ForwardHost, host.ForwardPort)
+	upstreamURL := fmt.Sprintf("%s://%s:%d/", scheme, host.ForwardHost, host.ForwardPort)
+
+	tlsConfig, tlsResult, err := upstreamTLSClientConfig(host, tlsCfg, clientCert)
+	if err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+	if scheme == "https" {
+		result.UpstreamTLS = tlsResult
+	}
 
 	transport := &http.Transport{
 		DialContext: (&net.Dialer{
@@ -158,9 +178,7 @@ func (t *ProxyHostTester) TestUpstream(ctx context.Context, host *model.ProxyHos
 			KeepAlive: 30 * time.Second,
 		}).DialContext,
 		TLSHandshakeTimeout: 5 * time.Second,
-		TLSClientConfig: &tls.Config{
-			InsecureSkipVerify: true,
-		},
+		TLSClientConfig:     tlsConfig,
 	}
 
 	client := &http.Client{
@@ -191,9 +209,111 @@ func (t *ProxyHostTester) TestUpstream(ctx context.Context, host *model.ProxyHos
 	result.StatusCode = resp.StatusCode
 	result.Success = resp.StatusCode >= 200 && resp.StatusCode < 500
 
+	if result.UpstreamTLS != nil && resp.TLS != nil {
+		result.UpstreamTLS.Protocol = tlsVersionToString(resp.TLS.Version)
+		result.UpstreamTLS.Cipher = tls.CipherSuiteName(resp.TLS.CipherSuite)
+	}
+	if tlsCfg != nil && tlsCfg.Verify && !result.UpstreamTLS.Verified {
+		// nginx refuses to proxy to a backend failing proxy_ssl_verify
+		result.Success = false
+		result.Error = fmt.Sprintf("Backend certificate verification failed: %s", result.UpstreamTLS.VerifyError)
+	}
+
 	return result, nil
 }
 
+// upstreamTLSClientConfig builds the TLS client settings of an upstream test
+// The handshake itself never fails on verification: the chain is recorded and verified
+// separately so the result can report both the chain and why nginx would reject it
+func upstreamTLSClientConfig(host *model.ProxyHost, tlsCfg *model.UpstreamTLSConfig, clientCert *model.Certificate) (*tls.Config, *model.UpstreamTLSTestResult, error) {
+	tlsResult := &model.UpstreamTLSTestResult{Chain: []model.UpstreamCertificateInfo{}}
+	config := &tls.Config{
+		InsecureSkipVerify: true,
+	}
+	if tlsCfg == nil {
+		return config, tlsResult, nil
+	}
+
+	serverName := tlsCfg.ServerName
+	if serverName == "" {
+		serverName = host.ForwardHost
+	}
+	tlsResult.ServerName = serverName
+	if tlsCfg.SNI {
+		config.ServerName = serverName
+	}
+
+	var roots *x509.CertPool
+	if tlsCfg.TrustedCAPEM != "" {
+		roots = x509.NewCertPool()
+		if !roots.AppendCertsFromPEM([]byte(tlsCfg.TrustedCAPEM)) {
+			return nil, nil, fmt.Errorf("trusted CA bundle of the backend TLS settings contains no certificate")
+		}
+	}
+
+	if clientCert != nil {
+		if clientCert.CertificatePEM == "" || clientCert.PrivateKeyPEM == "" {
+			return nil, nil, fmt.Errorf("client certificate for the backend has not been issued")
+		}
+		chainPEM := clientCert.CertificatePEM
+		if clientCert.IssuerCertificatePEM != "" {
+			chainPEM += "\n" + clientCert.IssuerCertificatePEM
+		}
+		pair, err := tls.X509KeyPair([]byte(chainPEM), []byte(clientCert.PrivateKeyPEM))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load client certificate for the backend: %w", err)
+		}
+		config.Certificates = []tls.Certificate{pair}
+		tlsResult.ClientCertificate = true
+	}
+
+	config.VerifyConnection = func(state tls.ConnectionState) error {
+		for _, cert := range state.PeerCertificates {
+			fingerprint := sha256.Sum256(cert.Raw)
+			tlsResult.Chain = append(tlsResult.Chain, model.UpstreamCertificateInfo{
+				Subject:           cert.Subject.String(),
+				Issuer:            cert.Issuer.String(),
+				DNSNames:          cert.DNSNames,
+				NotBefore:         cert.NotBefore.Format(time.RFC3339),
+				NotAfter:          cert.NotAfter.Format(time.RFC3339),
+				FingerprintSHA256: strings.ToUpper(hex.EncodeToString(fingerprint[:])),
+			})
+		}
+		if !tlsCfg.Verify {
+			return nil
+		}
+		if len(state.PeerCertificates) == 0 {
+			tlsResult.VerifyError = "backend sent no certificate"
+			return nil
+		}
+
+		intermediates := x509.NewCertPool()
+		for _, cert := range state.PeerCertificates[1:] {
+			intermediates.AddCert(cert)
+		}
+		chains, err := state.PeerCertificates[0].Verify(x509.VerifyOptions{
+			DNSName:       serverName,
+			Roots:         roots, // nil uses the system store
+			Intermediates: intermediates,
+		})
+		if err != nil {
+			tlsResult.VerifyError = err.Error()
+			return nil
+		}
+		// proxy_ssl_verify_depth counts the certificates above the leaf
+		for _, chain := range chains {
+			if len(chain)-1 <= tlsCfg.VerifyDepth {
+				tlsResult.Verified = true
+				return nil
+			}
+		}
+		tlsResult.VerifyError = fmt.Sprintf("certificate chain is longer than the verify depth %d", tlsCfg.VerifyDepth)
+		return nil
+	}
+
+	return config, tlsResult, nil
+}
+
 func (t *ProxyHostTester) testSSL(host *model.ProxyHost, resp *http.Response, testURL string) *model.SSLTestResult {
 	sslResult := &model.SSLTestResult{
 		Enabled: host.SSLEnabled,
@@ -0,0 +1,349 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+
+	"nginx-proxy-guard/internal/model"
+	"nginx-proxy-guard/internal/nginx"
+	"nginx-proxy-guard/internal/repository"
+)
+
+// realIPPrivateRanges mirrors the set_real_ip_from defaults of nginx.conf (Docker networks, LAN load balancers)
+var realIPPrivateRanges = []string{
+	"10.0.0.0/8",
+	"172.16.0.0/12",
+	"192.168.0.0/16",
+	"127.0.0.0/8",
+	"::1/128",
+	"fc00::/7",
+}
+
+var realIPHeaders = []string{
+	model.RealIPHeaderXForwardedFor,
+	model.RealIPHeaderXRealIP,
+	model.RealIPHeaderCFConnectingIP,
+	model.RealIPHeaderTrueClientIP,
+	model.RealIPHeaderProxyProtocol,
+}
+
+// RealIPRenderer renders the trusted proxy configuration and PROXY protocol listeners into nginx
+type RealIPRenderer interface {
+	UpdateRealIP(ctx context.Context, settings *model.RealIPSettings, sources, proxyProtocolSources []string) error
+	SetProxyProtocolListeners(listeners nginx.ProxyProtocolListeners)
+	GetHTTPPort() string
+	GetHTTPSPort() string
+}
+
+// RealIPSettingsError reports the field of the real IP settings that was rejected
+type RealIPSettingsError struct {
+	Field   string
+	Message string
+}
+
+func (e *RealIPSettingsError) Error() string {
+	return fmt.Sprintf("%s %s", e.Field, e.Message)
+}
+
+// RealIPService keeps the trusted proxy include in sync with the settings and with the
+// IP ranges of the selected cloud providers. PROXY protocol listener changes affect every
+// server block, so they are handed to the listeners changed callback to regenerate all configs.
+type RealIPService struct {
+	repo      *repository.RealIPRepository
+	cloudRepo *repository.CloudProviderRepository
+	renderer  RealIPRenderer
+
+	listenersChangedCallback func(ctx context.Context) error
+
+	mu           sync.Mutex
+	lastRendered string
+	hasRendered  bool
+}
+
+// NewRealIPService creates a new real IP service
+func NewRealIPService(repo *repository.RealIPRepository, cloudRepo *repository.CloudProviderRepository, renderer RealIPRenderer) *RealIPService {
+	return &RealIPService{
+		repo:      repo,
+		cloudRepo: cloudRepo,
+		renderer:  renderer,
+	}
+}
+
+// SetListenersChangedCallback sets the callback regenerating all server configs after the PROXY protocol listeners changed
+func (s *RealIPService) SetListenersChangedCallback(callback func(ctx context.Context) error) {
+	s.listenersChangedCallback = callback
+}
+
+// LoadListeners hands the stored PROXY protocol listeners to nginx; call it before configs are generated at startup
+func (s *RealIPService) LoadListeners(ctx context.Context) error {
+	settings, err := s.repo.GetSettings(ctx)
+	if err != nil {
+		return err
+	}
+	if s.renderer != nil {
+		s.renderer.SetProxyProtocolListeners(proxyProtocolListeners(settings))
+	}
+	return nil
+}
+
+// GetSettings returns the settings with the number of trusted networks they resolve to
+func (s *RealIPService) GetSettings(ctx context.Context) (*model.RealIPSettings, error) {
+	settings, err := s.repo.GetSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sources, err := s.resolveSources(ctx, settings)
+	if err != nil {
+		return nil, err
+	}
+	settings.SourceCount = len(sources)
+	return settings, nil
+}
+
+// Update validates and stores the settings, then applies them to nginx
+func (s *RealIPService) Update(ctx context.Context, req *model.UpdateRealIPSettingsRequest) (*model.RealIPSettings, error) {
+	settings, err := s.repo.GetSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	previousListeners := proxyProtocolListeners(settings)
+	applyRealIPUpdate(settings, req)
+
+	httpPort, httpsPort := "80", "443"
+	if s.renderer != nil {
+		httpPort, httpsPort = s.renderer.GetHTTPPort(), s.renderer.GetHTTPSPort()
+	}
+	if err := ValidateRealIPSettings(settings, httpPort, httpsPort); err != nil {
+		return nil, err
+	}
+	for _, slug := range settings.CloudProviders {
+		provider, err := s.cloudRepo.GetBySlug(ctx, slug)
+		if err != nil {
+			return nil, err
+		}
+		if provider == nil {
+			return nil, &RealIPSettingsError{Field: "cloud_providers", Message: fmt.Sprintf("unknown cloud provider %s", slug)}
+		}
+	}
+
+	if _, err := s.repo.SaveSettings(ctx, settings); err != nil {
+		return nil, err
+	}
+	if err := s.Apply(ctx); err != nil {
+		return nil, err
+	}
+
+	if listeners := proxyProtocolListeners(settings); listeners != previousListeners && s.renderer != nil {
+		s.renderer.SetProxyProtocolListeners(listeners)
+		if s.listenersChangedCallback != nil {
+			if err := s.listenersChangedCallback(ctx); err != nil {
+				return nil, fmt.Errorf("failed to regenerate configs for PROXY protocol listeners: %w", err)
+			}
+		}
+	}
+	return s.GetSettings(ctx)
+}
+
+// Apply resolves the trusted sources and rewrites the nginx include when they changed
+func (s *RealIPService) Apply(ctx context.Context) error {
+	settings, err := s.repo.GetSettings(ctx)
+	if err != nil {
+		return err
+	}
+	sources, err := s.resolveSources(ctx, settings)
+	if err != nil {
+		return err
+	}
+
+	// Load balancers sending the PROXY protocol are trusted like the other proxies,
+	// or through the private ranges of nginx.conf while the settings are disabled
+	var proxyProtocolSources []string
+	rendered := ""
+	if settings.Enabled && len(sources) > 0 {
+		proxyProtocolSources = sources
+		rendered = fmt.Sprintf("%s|%t|%s", settings.Header, settings.Recursive, strings.Join(sources, ","))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.hasRendered && rendered == s.lastRendered {
+		return nil
+	}
+	if s.renderer == nil {
+		return nil
+	}
+
+	if err := s.renderer.UpdateRealIP(ctx, settings, sources, proxyProtocolSources); err != nil {
+		return err
+	}
+	s.lastRendered = rendered
+	s.hasRendered = true
+
+	if rendered == "" {
+		log.Println("[RealIP] Using nginx.conf defaults")
+	} else {
+		log.Printf("[RealIP] Rendered %d trusted proxy networks (header %s)", len(sources), settings.Header)
+	}
+	return nil
+}
+
+// OnCloudProvidersUpdated re-applies the settings when the ranges of a trusted provider changed
+func (s *RealIPService) OnCloudProvidersUpdated(ctx context.Context, updatedProviders []string) error {
+	settings, err := s.repo.GetSettings(ctx)
+	if err != nil {
+		return err
+	}
+	if !settings.Enabled {
+		return nil
+	}
+
+	for _, updated := range updatedProviders {
+		for _, slug := range settings.CloudProviders {
+			if slug == updated {
+				return s.Apply(ctx)
+			}
+		}
+	}
+	return nil
+}
+
+// resolveSources returns the deduplicated networks trusted to report the client IP
+func (s *RealIPService) resolveSources(ctx context.Context, settings *model.RealIPSettings) ([]string, error) {
+	var candidates []string
+	if settings.IncludePrivateRanges {
+		candidates = append(candidates, realIPPrivateRanges...)
+	}
+	if len(settings.CloudProviders) > 0 && s.cloudRepo != nil {
+		ranges, err := s.cloudRepo.GetIPRangesForProviders(ctx, settings.CloudProviders)
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, ranges...)
+	}
+	candidates = append(candidates, settings.CustomCIDRs...)
+
+	sources := make([]string, 0, len(candidates))
+	seen := make(map[string]bool, len(candidates))
+	for _, value := range candidates {
+		ipNet, err := ParseTrustedCIDR(value)
+		if err != nil {
+			log.Printf("[RealIP] Skipping invalid source %s: %v", value, err)
+			continue
+		}
+		cidr := ipNet.String()
+		if seen[cidr] {
+			continue
+		}
+		seen[cidr] = true
+		sources = append(sources, cidr)
+	}
+	return sources, nil
+}
+
+// proxyProtocolListeners converts the stored PROXY protocol settings to nginx listeners
+func proxyProtocolListeners(settings *model.RealIPSettings) nginx.ProxyProtocolListeners {
+	listeners := nginx.ProxyProtocolListeners{Enabled: settings.ProxyProtocol}
+	if settings.ProxyProtocolHTTPPort > 0 {
+		listeners.HTTPPort = strconv.Itoa(settings.ProxyProtocolHTTPPort)
+	}
+	if settings.ProxyProtocolHTTPSPort > 0 {
+		listeners.HTTPSPort = strconv.Itoa(settings.ProxyProtocolHTTPSPort)
+	}
+	return listeners
+}
+
+// applyRealIPUpdate applies the non-nil fields of req
+func applyRealIPUpdate(settings *model.RealIPSettings, req *model.UpdateRealIPSettingsRequest) {
+	if req.Enabled != nil {
+		settings.Enabled = *req.Enabled
+	}
+	if req.Header != nil {
+		settings.Header = strings.TrimSpace(*req.Header)
+	}
+	if req.Recursive != nil {
+		settings.Recursive = *req.Recursive
+	}
+	if req.IncludePrivateRanges != nil {
+		settings.IncludePrivateRanges = *req.IncludePrivateRanges
+	}
+	if req.CloudProviders != nil {
+		providers := make([]string, 0, len(*req.CloudProviders))
+		for _, slug := range *req.CloudProviders {
+			if slug = strings.TrimSpace(slug); slug != "" {
+				providers = append(providers, slug)
+			}
+		}
+		settings.CloudProviders = providers
+	}
+	if req.CustomCIDRs != nil {
+		cidrs := make([]string, 0, len(*req.CustomCIDRs))
+		for _, cidr := range *req.CustomCIDRs {
+			if cidr = strings.TrimSpace(cidr); cidr != "" {
+				cidrs = append(cidrs, cidr)
+			}
+		}
+		settings.CustomCIDRs = cidrs
+	}
+	if req.ProxyProtocol != nil {
+		settings.ProxyProtocol = *req.ProxyProtocol
+	}
+	if req.ProxyProtocolHTTPPort != nil {
+		settings.ProxyProtocolHTTPPort = *req.ProxyProtocolHTTPPort
+	}
+	if req.ProxyProtocolHTTPSPort != nil {
+		settings.ProxyProtocolHTTPSPort = *req.ProxyProtocolHTTPSPort
+	}
+}
+
+// ValidateRealIPSettings rejects settings that nginx would refuse or that would let any client spoof its address
+// httpPort and httpsPort are the standard listen ports the dedicated PROXY protocol ports must not reuse
+func ValidateRealIPSettings(settings *model.RealIPSettings, httpPort, httpsPort string) error {
+	validHeader := false
+	for _, header := range realIPHeaders {
+		if settings.Header == header {
+			validHeader = true
+		}
+	}
+	if !validHeader {
+		return &RealIPSettingsError{Field: "header", Message: "must be one of " + strings.Join(realIPHeaders, ", ")}
+	}
+
+	for i, value := range settings.CustomCIDRs {
+		ipNet, err := ParseTrustedCIDR(value)
+		if err != nil {
+			return &RealIPSettingsError{Field: "custom_cidrs", Message: err.Error()}
+		}
+		if ones, _ := ipNet.Mask.Size(); ones == 0 {
+			return &RealIPSettingsError{Field: "custom_cidrs", Message: fmt.Sprintf("%s trusts every address, which lets any client spoof its IP", value)}
+		}
+		settings.CustomCIDRs[i] = ipNet.String()
+	}
+
+	if settings.Enabled && !settings.IncludePrivateRanges && len(settings.CloudProviders) == 0 && len(settings.CustomCIDRs) == 0 {
+		return &RealIPSettingsError{Field: "custom_cidrs", Message: "at least one trusted source is required"}
+	}
+
+	ports := map[string]int{
+		"proxy_protocol_http_port":  settings.ProxyProtocolHTTPPort,
+		"proxy_protocol_https_port": settings.ProxyProtocolHTTPSPort,
+	}
+	for field, port := range ports {
+		if port == 0 {
+			continue
+		}
+		if port < 1 || port > 65535 {
+			return &RealIPSettingsError{Field: field, Message: "must be between 1 and 65535, or 0 to disable"}
+		}
+		if p := strconv.Itoa(port); p == httpPort || p == httpsPort {
+			return &RealIPSettingsError{Field: field, Message: "must differ from the standard HTTP and HTTPS ports"}
+		}
+	}
+	if settings.ProxyProtocolHTTPPort != 0 && settings.ProxyProtocolHTTPPort == settings.ProxyProtocolHTTPSPort {
+		return &RealIPSettingsError{Field: "proxy_protocol_https_port", Message: "must differ from the PROXY protocol HTTP port"}
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package service
+
+import (
+	"testing"
+
+	"nginx-proxy-guard/internal/model"
+)
+
+func TestValidateRealIPSettings(t *testing.T) {
+	valid := &model.RealIPSettings{
+		Enabled:        true,
+		Header:         model.RealIPHeaderCFConnectingIP,
+		CloudProviders: []string{"cloudflare"},
+		CustomCIDRs:    []string{"203.0.113.7", "198.51.100.9/24"},
+	}
+	if err := ValidateRealIPSettings(valid, "80", "443"); err != nil {
+		t.Fatalf("ValidateRealIPSettings() error = %v", err)
+	}
+	if valid.CustomCIDRs[0] != "203.0.113.7/32" || valid.CustomCIDRs[1] != "198.51.100.0/24" {
+		t.Errorf("custom CIDRs not normalized: %v", valid.CustomCIDRs)
+	}
+
+	tests := map[string]*model.RealIPSettings{
+		"unknown header":    {Header: "X-Client-IP", IncludePrivateRanges: true},
+		"invalid cidr":      {Header: model.RealIPHeaderXForwardedFor, CustomCIDRs: []string{"bogus"}},
+		"trust everything":  {Header: model.RealIPHeaderXForwardedFor, CustomCIDRs: []string{"0.0.0.0/0"}},
+		"no sources":        {Enabled: true, Header: model.RealIPHeaderXForwardedFor},
+		"port out of range": {Header: model.RealIPHeaderXForwardedFor, ProxyProtocolHTTPPort: 70000},
+		"standard port":     {Header: model.RealIPHeaderXForwardedFor, ProxyProtocolHTTPSPort: 443},
+		"same ports":        {Header: model.RealIPHeaderXForwardedFor, ProxyProtocolHTTPPort: 8081, ProxyProtocolHTTPSPort: 8081},
+	}
+	for name, settings := range tests {
+		if err := ValidateRealIPSettings(settings, "80", "443"); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
@@ -0,0 +1,287 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"nginx-proxy-guard/internal/model"
+	"nginx-proxy-guard/internal/repository"
+)
+
+var ErrSessionNotFound = errors.New("session not found")
+
+const (
+	// How long the session policy is cached, it is read on every request
+	sessionSettingsCacheTTL = 30 * time.Second
+	// Bounds of the session policy
+	maxIdleTimeoutMinutes = 7 * 24 * 60
+	minIdleTimeoutMinutes = 5
+	maxSessionHours       = 30 * 24
+	defaultSessionHours   = 24
+)
+
+type SessionService struct {
+	repo     *repository.SessionRepository
+	authRepo *repository.AuthRepository
+	auth     *AuthService
+	geoIP    *GeoIPService
+
+	mu       sync.RWMutex
+	cached   *model.SessionSettings
+	cachedAt time.Time
+}
+
+func NewSessionService(repo *repository.SessionRepository, authRepo *repository.AuthRepository, auth *AuthService, geoIP *GeoIPService) *SessionService {
+	return &SessionService{
+		repo:     repo,
+		authRepo: authRepo,
+		auth:     auth,
+		geoIP:    geoIP,
+	}
+}
+
+// GetSettings returns the session policy
+func (s *SessionService) GetSettings(ctx context.Context) (*model.SessionSettings, error) {
+	return s.repo.GetSettings(ctx)
+}
+
+// UpdateSettings validates and stores the session policy. It applies to
+// existing sessions from their next request on.
+func (s *SessionService) UpdateSettings(ctx context.Context, req *model.UpdateSessionSettingsRequest) (*model.SessionSettings, error) {
+	settings, err := s.repo.GetSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.IdleTimeoutMinutes != nil {
+		settings.IdleTimeoutMinutes = *req.IdleTimeoutMinutes
+	}
+	if req.MaxLifetimeHours != nil {
+		settings.MaxLifetimeHours = *req.MaxLifetimeHours
+	}
+	if err := normalizeSessionSettings(settings); err != nil {
+		return nil, err
+	}
+
+	saved, err := s.repo.SaveSettings(ctx, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.cached = saved
+	s.cachedAt = time.Now()
+	s.mu.Unlock()
+
+	return saved, nil
+}
+
+// SessionLimits implements SessionPolicy from a briefly cached copy of the settings
+func (s *SessionService) SessionLimits(ctx context.Context) (idleTimeout, lifetime time.Duration) {
+	s.mu.RLock()
+	settings := s.cached
+	fresh := time.Since(s.cachedAt) < sessionSettingsCacheTTL
+	s.mu.RUnlock()
+
+	if settings == nil || !fresh {
+		loaded, err := s.repo.GetSettings(ctx)
+		if err != nil {
+			log.Printf("[Session] Failed to load session settings: %v", err)
+		} else {
+			settings = loaded
+			s.mu.Lock()
+			s.cached = loaded
+			s.cachedAt = time.Now()
+			s.mu.Unlock()
+		}
+	}
+	if settings == nil {
+		return 0, 0
+	}
+
+	return time.Duration(settings.IdleTimeoutMinutes) * time.Minute, time.Duration(settings.MaxLifetimeHours) * time.Hour
+}
+
+// List returns the active sessions of a user. currentToken marks the session
+// making the request and may be empty.
+func (s *SessionService) List(ctx context.Context, userID, currentToken string) ([]model.SessionInfo, error) {
+	if _, err := s.loadUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	sessions, err := s.authRepo.ListUserSessions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	idleTimeout, lifetime := s.auth.sessionLimits(ctx)
+	now := time.Now()
+	currentHash := ""
+	if currentToken != "" {
+		currentHash = hashToken(currentToken)
+	}
+
+	infos := make([]model.SessionInfo, 0, len(sessions))
+	for _, session := range sessions {
+		// Expired by policy but not requested since, ValidateToken revokes it on use
+		if sessionExpired(&session, idleTimeout, lifetime, now) {
+			continue
+		}
+		info := model.SessionInfo{
+			AuthSession: session,
+			Device:      describeDevice(session.UserAgent),
+			Current:     currentHash != "" && session.TokenHash == currentHash,
+		}
+		if s.geoIP != nil && session.IPAddress != "" {
+			if geo := s.geoIP.LookupWithContext(ctx, session.IPAddress); geo != nil {
+				info.Country = geo.Country
+				info.CountryCode = geo.CountryCode
+			}
+		}
+		infos = append(infos, info)
+	}
+
+	return infos, nil
+}
+
+// Revoke ends one session of a user and returns the user and the session
+func (s *SessionService) Revoke(ctx context.Context, userID, sessionID string) (*model.User, *model.AuthSession, error) {
+	user, err := s.loadUser(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	session, err := s.authRepo.GetUserSession(ctx, userID, sessionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if session == nil {
+		return nil, nil, ErrSessionNotFound
+	}
+
+	if err := s.auth.revokeSession(ctx, session); err != nil {
+		return nil, nil, err
+	}
+	return user, session, nil
+}
+
+// RevokeAll ends every session of a user except the one using keepToken, if
+// given, and returns the user and how many sessions were ended
+func (s *SessionService) RevokeAll(ctx context.Context, userID, keepToken string) (*model.User, int, error) {
+	user, err := s.loadUser(ctx, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	sessions, err := s.authRepo.ListUserSessions(ctx, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	keepHash := ""
+	if keepToken != "" {
+		keepHash = hashToken(keepToken)
+	}
+
+	revoked := 0
+	for i := range sessions {
+		if keepHash != "" && sessions[i].TokenHash == keepHash {
+			continue
+		}
+		if err := s.auth.revokeSession(ctx, &sessions[i]); err != nil {
+			return user, revoked, err
+		}
+		revoked++
+	}
+
+	return user, revoked, nil
+}
+
+func (s *SessionService) loadUser(ctx context.Context, userID string) (*model.User, error) {
+	user, err := s.authRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
+// normalizeSessionSettings validates the session policy and fills in defaults
+func normalizeSessionSettings(s *model.SessionSettings) error {
+	if s.IdleTimeoutMinutes != 0 && (s.IdleTimeoutMinutes < minIdleTimeoutMinutes || s.IdleTimeoutMinutes > maxIdleTimeoutMinutes) {
+		return &UserError{Field: "idle_timeout_minutes", Message: "must be 0 (disabled) or between 5 and 10080"}
+	}
+	if s.MaxLifetimeHours == 0 {
+		s.MaxLifetimeHours = defaultSessionHours
+	}
+	if s.MaxLifetimeHours < 1 || s.MaxLifetimeHours > maxSessionHours {
+		return &UserError{Field: "max_lifetime_hours", Message: "must be between 1 and 720"}
+	}
+	if s.IdleTimeoutMinutes >= s.MaxLifetimeHours*60 {
+		return &UserError{Field: "idle_timeout_minutes", Message: "must be shorter than the session lifetime"}
+	}
+	return nil
+}
+
+// describeDevice turns a user agent into a short label such as "Firefox on Windows"
+func describeDevice(userAgent string) string {
+	if userAgent == "" {
+		return "Unknown device"
+	}
+
+	browser := ""
+	switch {
+	case strings.Contains(userAgent, "Edg/"):
+		browser = "Edge"
+	case strings.Contains(userAgent, "OPR/"):
+		browser = "Opera"
+	case strings.Contains(userAgent, "Firefox/"), strings.Contains(userAgent, "FxiOS/"):
+		browser = "Firefox"
+	case strings.Contains(userAgent, "Chrome/"), strings.Contains(userAgent, "CriOS/"):
+		browser = "Chrome"
+	case strings.Contains(userAgent, "Safari/"):
+		browser = "Safari"
+	case strings.HasPrefix(userAgent, "curl/"):
+		browser = "curl"
+	}
+
+	platform := ""
+	switch {
+	case strings.Contains(userAgent, "iPhone"), strings.Contains(userAgent, "iPad"):
+		platform = "iOS"
+	case strings.Contains(userAgent, "Android"):
+		platform = "Android"
+	case strings.Contains(userAgent, "Windows"):
+		platform = "Windows"
+	case strings.Contains(userAgent, "Mac OS X"), strings.Contains(userAgent, "Macintosh"):
+		platform = "macOS"
+	case strings.Contains(userAgent, "CrOS"):
+		platform = "ChromeOS"
+	case strings.Contains(userAgent, "Linux"):
+		platform = "Linux"
+	}
+
+	switch {
+	case browser != "" && platform != "":
+		return browser + " on " + platform
+	case browser != "":
+		return browser
+	case platform != "":
+		return platform
+	}
+
+	// Unknown clients such as scripts: keep the product token
+	if i := strings.IndexAny(userAgent, " /"); i > 0 {
+		return userAgent[:i]
+	}
+	if len(userAgent) > 50 {
+		return userAgent[:50]
+	}
+	return userAgent
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"nginx-proxy-guard/internal/model"
+)
+
+func TestSessionExpired(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	session := func(age, idle time.Duration) *model.AuthSession {
+		return &model.AuthSession{CreatedAt: now.Add(-age), LastSeenAt: now.Add(-idle)}
+	}
+
+	tests := []struct {
+		name        string
+		session     *model.AuthSession
+		idleTimeout time.Duration
+		lifetime    time.Duration
+		want        bool
+	}{
+		{"active", session(time.Hour, time.Minute), 30 * time.Minute, 24 * time.Hour, false},
+		{"idle", session(time.Hour, 31*time.Minute), 30 * time.Minute, 24 * time.Hour, true},
+		{"idle timeout disabled", session(time.Hour, 10*time.Hour), 0, 24 * time.Hour, false},
+		{"past lifetime", session(25*time.Hour, time.Minute), 0, 24 * time.Hour, true},
+		{"lifetime shortened since login", session(3*time.Hour, time.Minute), 0, 2 * time.Hour, true},
+	}
+	for _, tt := range tests {
+		if got := sessionExpired(tt.session, tt.idleTimeout, tt.lifetime, now); got != tt.want {
+			t.Errorf("%s: sessionExpired() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeSessionSettings(t *testing.T) {
+	tests := []struct {
+		name     string
+		idle     int
+		lifetime int
+		field    string
+	}{
+		{"defaults", 0, 24, ""},
+		{"idle timeout", 30, 8, ""},
+		{"idle timeout too short", 1, 24, "idle_timeout_minutes"},
+		{"idle timeout too long", 20000, 720, "idle_timeout_minutes"},
+		{"idle timeout longer than lifetime", 120, 1, "idle_timeout_minutes"},
+		{"lifetime too long", 0, 1000, "max_lifetime_hours"},
+		{"negative lifetime", 0, -1, "max_lifetime_hours"},
+	}
+	for _, tt := range tests {
+		settings := &model.SessionSettings{IdleTimeoutMinutes: tt.idle, MaxLifetimeHours: tt.lifetime}
+		err := normalizeSessionSettings(settings)
+		var uerr *UserError
+		switch {
+		case tt.field == "" && err != nil:
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+		case tt.field != "" && (!errors.As(err, &uerr) || uerr.Field != tt.field):
+			t.Errorf("%s: error = %v, want invalid %s", tt.name, err, tt.field)
+		}
+	}
+
+	settings := &model.SessionSettings{}
+	if err := normalizeSessionSettings(settings); err != nil || settings.MaxLifetimeHours != defaultSessionHours {
+		t.Errorf("lifetime defaulted to %d, %v", settings.MaxLifetimeHours, err)
+	}
+}
+
+func TestDescribeDevice(t *testing.T) {
+	tests := []struct {
+		userAgent string
+		want      string
+	}{
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36", "Chrome on Windows"},
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Edg/120.0.0.0", "Edge on Windows"},
+		{"Mozilla/5.0 (Macintosh; Intel Mac OS X 14_2) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Safari/605.1.15", "Safari on macOS"},
+		{"Mozilla/5.0 (X11; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0", "Firefox on Linux"},
+		{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) CriOS/120.0 Mobile/15E148 Safari/604.1", "Chrome on iOS"},
+		{"Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Mobile Safari/537.36", "Chrome on Android"},
+		{"curl/8.4.0", "curl"},
+		{"Go-http-client/1.1", "Go-http-client"},
+		{"", "Unknown device"},
+	}
+	for _, tt := range tests {
+		if got := describeDevice(tt.userAgent); got != tt.want {
+			t.Errorf("describeDevice(%q) = %q, want %q", tt.userAgent, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package service
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"nginx-proxy-guard/internal/model"
+)
+
+const (
+	defaultSignedURLExpression = "$secure_link_expires$uri $secret"
+	defaultSignedURLTTL        = 3600   // 1 hour
+	defaultSignedURLMaxTTL     = 604800 // 7 days
+	maxSignedURLTTL            = 31536000
+)
+
+// signedURLVariables are the variables a hash expression may use; $secret is replaced
+// by the location's secret when rendering, the others are nginx variables
+var signedURLVariables = map[string]bool{"secure_link_expires": true, "uri": true, "remote_addr": true, "host": true, "secret": true}
+
+var (
+	signedURLVariablePattern = regexp.MustCompile(`\$[A-Za-z0-9_]+`)
+	signedURLLiteralPattern  = regexp.MustCompile(`^[A-Za-z0-9 _.:/|-]*$`)
+	signedURLSecretPattern   = regexp.MustCompile(`^[A-Za-z0-9_-]{16,128}$`)
+	signedURLParamPattern    = regexp.MustCompile(`^[a-z][a-z0-9_]{0,31}$`)
+	signedURLPathPattern     = regexp.MustCompile(`^/[A-Za-z0-9/._~-]*$`)
+)
+
+// SignedURLError reports the field of a signed URL location or mint request that is invalid
+type SignedURLError struct {
+	Field   string
+	Message string
+}
+
+func (e *SignedURLError) Error() string {
+	return fmt.Sprintf("%s %s", e.Field, e.Message)
+}
+
+// ResolveSignedURLLocation builds a location from a request on top of the existing one (nil
+// for new locations), keeping or generating the secret, and validates it
+func ResolveSignedURLLocation(existing *model.SignedURLLocation, req *model.UpsertSignedURLLocationRequest) (*model.SignedURLLocation, error) {
+	loc := model.SignedURLLocation{
+		Enabled:        true,
+		HashExpression: defaultSignedURLExpression,
+		TokenParam:     "md5",
+		ExpiresParam:   "expires",
+		DefaultTTL:     defaultSignedURLTTL,
+		MaxTTL:         defaultSignedURLMaxTTL,
+	}
+	if existing != nil {
+		loc = *existing
+	}
+	loc.PathPrefix = strings.TrimSpace(req.PathPrefix)
+
+	if req.Enabled != nil {
+		loc.Enabled = *req.Enabled
+	}
+	if req.HashExpression != "" {
+		loc.HashExpression = strings.TrimSpace(req.HashExpression)
+	}
+	if req.TokenParam != "" {
+		loc.TokenParam = req.TokenParam
+	}
+	if req.ExpiresParam != "" {
+		loc.ExpiresParam = req.ExpiresParam
+	}
+	if req.DefaultTTL != 0 {
+		loc.DefaultTTL = req.DefaultTTL
+	}
+	if req.MaxTTL != 0 {
+		loc.MaxTTL = req.MaxTTL
+	}
+
+	switch {
+	case req.Secret != "":
+		loc.Secret = req.Secret
+	case loc.Secret == "" || req.RotateSecret:
+		secret, err := generateSignedURLSecret()
+		if err != nil {
+			return nil, err
+		}
+		loc.Secret = secret
+	}
+	loc.HasSecret = true
+
+	if err := ValidateSignedURLLocation(&loc); err != nil {
+		return nil, err
+	}
+	return &loc, nil
+}
+
+// ValidateSignedURLLocation rejects locations nginx can't render or that would clash with
+// the generated location blocks
+func ValidateSignedURLLocation(loc *model.SignedURLLocation) error {
+	if !signedURLPathPattern.MatchString(loc.PathPrefix) || strings.Contains(loc.PathPrefix, "..") {
+		return &SignedURLError{Field: "path_prefix", Message: "must start with / and contain only letters, digits and /._~-"}
+	}
+	if loc.PathPrefix == "/" || strings.HasPrefix(loc.PathPrefix, "/.well-known/") || strings.HasPrefix(loc.PathPrefix, "/api/v1/") {
+		return &SignedURLError{Field: "path_prefix", Message: "can't be /, /.well-known/ or /api/v1/, which are served by other locations"}
+	}
+
+	if !signedURLSecretPattern.MatchString(loc.Secret) {
+		return &SignedURLError{Field: "secret", Message: "must be 16 to 128 letters, digits, _ or -"}
+	}
+
+	vars := make(map[string]int)
+	for _, v := range signedURLVariablePattern.FindAllString(loc.HashExpression, -1) {
+		name := strings.TrimPrefix(v, "$")
+		if !signedURLVariables[name] {
+			return &SignedURLError{Field: "hash_expression", Message: fmt.Sprintf("contains an unsupported variable %s (allowed: $secure_link_expires, $uri, $remote_addr, $host, $secret)", v)}
+		}
+		vars[name]++
+	}
+	if vars["secret"] == 0 || vars["secure_link_expires"] == 0 {
+		return &SignedURLError{Field: "hash_expression", Message: "must include $secret and $secure_link_expires"}
+	}
+	if !signedURLLiteralPattern.MatchString(signedURLVariablePattern.ReplaceAllString(loc.HashExpression, "")) {
+		return &SignedURLError{Field: "hash_expression", Message: "may only contain letters, digits, spaces and _.:/|- besides variables"}
+	}
+
+	if !signedURLParamPattern.MatchString(loc.TokenParam) {
+		return &SignedURLError{Field: "token_param", Message: "must be a lowercase query parameter name"}
+	}
+	if !signedURLParamPattern.MatchString(loc.ExpiresParam) {
+		return &SignedURLError{Field: "expires_param", Message: "must be a lowercase query parameter name"}
+	}
+	if loc.TokenParam == loc.ExpiresParam {
+		return &SignedURLError{Field: "expires_param", Message: "must differ from token_param"}
+	}
+
+	if loc.MaxTTL < 60 || loc.MaxTTL > maxSignedURLTTL {
+		return &SignedURLError{Field: "max_ttl", Message: fmt.Sprintf("must be between 60 and %d seconds", maxSignedURLTTL)}
+	}
+	if loc.DefaultTTL < 1 || loc.DefaultTTL > loc.MaxTTL {
+		return &SignedURLError{Field: "default_ttl", Message: "must be positive and not exceed max_ttl"}
+	}
+	return nil
+}
+
+// MatchSignedURLLocation returns the enabled location with the longest prefix of path
+func MatchSignedURLLocation(locations []model.SignedURLLocation, path string) *model.SignedURLLocation {
+	var match *model.SignedURLLocation
+	for i := range locations {
+		loc := &locations[i]
+		if loc.Enabled && strings.HasPrefix(path, loc.PathPrefix) && (match == nil || len(loc.PathPrefix) > len(match.PathPrefix)) {
+			match = loc
+		}
+	}
+	return match
+}
+
+// MintSignedURL signs a link to a path below the location, valid for the requested TTL
+func MintSignedURL(loc *model.SignedURLLocation, host *model.ProxyHost, req *model.MintSignedURLRequest, now time.Time) (*model.SignedURL, error) {
+	if !signedURLPathPattern.MatchString(req.Path) || strings.Contains(req.Path, "..") {
+		return nil, &SignedURLError{Field: "path", Message: "must start with / and contain only letters, digits and /._~-"}
+	}
+	if !strings.HasPrefix(req.Path, loc.PathPrefix) {
+		return nil, &SignedURLError{Field: "path", Message: fmt.Sprintf("is not below %s", loc.PathPrefix)}
+	}
+
+	ttl := req.TTL
+	if ttl == 0 {
+		ttl = loc.DefaultTTL
+	}
+	if ttl < 1 || ttl > loc.MaxTTL {
+		return nil, &SignedURLError{Field: "ttl", Message: fmt.Sprintf("must be between 1 and %d seconds", loc.MaxTTL)}
+	}
+
+	domain := req.Domain
+	if domain == "" && len(host.DomainNames) > 0 {
+		domain = host.DomainNames[0]
+	}
+	known := false
+	for _, d := range host.DomainNames {
+		if strings.EqualFold(d, domain) {
+			known = true
+		}
+	}
+	if !known || strings.Contains(domain, "*") {
+		return nil, &SignedURLError{Field: "domain", Message: "must be one of the host's domains, without wildcards"}
+	}
+	domain = strings.ToLower(domain)
+
+	clientIP := ""
+	if strings.Contains(loc.HashExpression, "$remote_addr") {
+		ip := net.ParseIP(strings.TrimSpace(req.ClientIP))
+		if ip == nil {
+			return nil, &SignedURLError{Field: "client_ip", Message: "is required, the links of this location are bound to the client address"}
+		}
+		clientIP = ip.String()
+	}
+
+	expires := now.Add(time.Duration(ttl) * time.Second).Unix()
+	token := signedURLToken(loc, req.Path, domain, clientIP, expires)
+
+	scheme := "http"
+	if host.SSLEnabled {
+		scheme = "https"
+	}
+	query := url.Values{}
+	query.Set(loc.TokenParam, token)
+	query.Set(loc.ExpiresParam, strconv.FormatInt(expires, 10))
+
+	return &model.SignedURL{
+		URL:        fmt.Sprintf("%s://%s%s?%s", scheme, domain, req.Path, query.Encode()),
+		Path:       req.Path,
+		LocationID: loc.ID,
+		Token:      token,
+		ExpiresAt:  time.Unix(expires, 0).UTC(),
+	}, nil
+}
+
+// signedURLToken evaluates the hash expression the way secure_link_md5 does: the
+// base64url encoded MD5 of the expression with its variables substituted
+func signedURLToken(loc *model.SignedURLLocation, path, host, clientIP string, expires int64) string {
+	values := map[string]string{
+		"$secure_link_expires": strconv.FormatInt(expires, 10),
+		"$uri":                 path,
+		"$remote_addr":         clientIP,
+		"$host":                host,
+		"$secret":              loc.Secret,
+	}
+	expr := signedURLVariablePattern.ReplaceAllStringFunc(loc.HashExpression, func(v string) string {
+		return values[v]
+	})
+	sum := md5.Sum([]byte(expr))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func generateSignedURLSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate signed url secret: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"nginx-proxy-guard/internal/model"
+)
+
+func TestSignedURLTokenMatchesNginx(t *testing.T) {
+	// Example of the ngx_http_secure_link_module documentation
+	loc := &model.SignedURLLocation{HashExpression: "$secure_link_expires$uri$remote_addr $secret", Secret: "secret"}
+	if got := signedURLToken(loc, "/s/link", "example.com", "127.0.0.1", 2147483647); got != "_e4Nc3iduzkWRm01TBBNYw" {
+		t.Errorf("signedURLToken() = %s, want _e4Nc3iduzkWRm01TBBNYw", got)
+	}
+}
+
+func TestMintSignedURL(t *testing.T) {
+	loc, err := ResolveSignedURLLocation(nil, &model.UpsertSignedURLLocationRequest{PathPrefix: "/downloads/"})
+	if err != nil {
+		t.Fatalf("ResolveSignedURLLocation() error = %v", err)
+	}
+	if len(loc.Secret) < 16 {
+		t.Fatalf("secret not generated: %q", loc.Secret)
+	}
+
+	host := &model.ProxyHost{DomainNames: []string{"files.example.com"}, SSLEnabled: true}
+	now := time.Unix(1700000000, 0)
+	signed, err := MintSignedURL(loc, host, &model.MintSignedURLRequest{Path: "/downloads/report.pdf", TTL: 600}, now)
+	if err != nil {
+		t.Fatalf("MintSignedURL() error = %v", err)
+	}
+	if !strings.HasPrefix(signed.URL, "https://files.example.com/downloads/report.pdf?expires=1700000600&md5=") {
+		t.Errorf("URL = %s", signed.URL)
+	}
+
+	rejected := map[string]model.MintSignedURLRequest{
+		"path": {Path: "/private/report.pdf"},
+		"ttl":  {Path: "/downloads/a", TTL: defaultSignedURLMaxTTL + 1},
+	}
+	for field, req := range rejected {
+		_, err := MintSignedURL(loc, host, &req, now)
+		var serr *SignedURLError
+		if !errors.As(err, &serr) || serr.Field != field {
+			t.Errorf("MintSignedURL(%+v) error = %v, want a %s error", req, err, field)
+		}
+	}
+}
+
+func TestValidateSignedURLLocationExpression(t *testing.T) {
+	for expr, ok := range map[string]bool{
+		"$secure_link_expires$uri $secret":             true,
+		"$secure_link_expires$uri$remote_addr $secret": true,
+		"$uri $secret": false,
+		"$secure_link_expires$http_cookie $secret":      false,
+		`$secure_link_expires$uri $secret"; return 200`: false,
+	} {
+		_, err := ResolveSignedURLLocation(nil, &model.UpsertSignedURLLocationRequest{PathPrefix: "/files/", HashExpression: expr})
+		if (err == nil) != ok {
+			t.Errorf("hash expression %q: error = %v, want ok %v", expr, err, ok)
+		}
+	}
+}
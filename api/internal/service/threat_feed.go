@@ -0,0 +1,436 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"nginx-proxy-guard/internal/model"
+	"nginx-proxy-guard/internal/repository"
+)
+
+// ThreatFeedsUpdatedCallback is called after threat feed IP ranges are updated
+type ThreatFeedsUpdatedCallback func(ctx context.Context, updatedFeeds []string) error
+
+// ThreatFeedService downloads threat intelligence IP blocklists on a schedule
+type ThreatFeedService struct {
+	repo           *repository.ThreatFeedRepository
+	httpClient     *http.Client
+	stopCh         chan struct{}
+	wg             sync.WaitGroup
+	mu             sync.Mutex
+	running        bool
+	checkInterval  time.Duration
+	onFeedsUpdated ThreatFeedsUpdatedCallback
+}
+
+// Default refresh interval for feeds that don't specify one
+const defaultThreatFeedInterval = 24 * 60 * 60
+
+// Upper bound for the response body of a single feed
+const maxThreatFeedSize = 32 << 20
+
+// Shortest prefixes accepted from a feed; anything wider blocks whole regions
+const (
+	minThreatFeedPrefixV4 = 8
+	minThreatFeedPrefixV6 = 16
+)
+
+// Ranges that feed entries must not overlap, so that feeds containing bogons
+// (e.g. FireHOL level1) or overly wide entries never lock out internal clients
+var threatFeedProtectedRanges = mustParseCIDRs(
+	"0.0.0.0/32", "10.0.0.0/8", "127.0.0.0/8", "169.254.0.0/16", "172.16.0.0/12", "192.168.0.0/16",
+	"::/128", "::1/128", "fc00::/7", "fe80::/10",
+)
+
+func mustParseCIDRs(values ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(values))
+	for _, value := range values {
+		_, ipNet, err := net.ParseCIDR(value)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, ipNet)
+	}
+	return networks
+}
+
+// Built-in threat feeds to seed
+var defaultThreatFeeds = []model.ThreatFeed{
+	{Name: "Spamhaus DROP", Slug: "spamhaus-drop", Description: "Spamhaus Don't Route Or Peer list (hijacked and criminal netblocks)", URL: "https://www.spamhaus.org/drop/drop.txt", Format: model.ThreatFeedFormatPlain, UpdateInterval: 12 * 60 * 60},
+	{Name: "Spamhaus EDROP", Slug: "spamhaus-edrop", Description: "Spamhaus Extended DROP list", URL: "https://www.spamhaus.org/drop/edrop.txt", Format: model.ThreatFeedFormatPlain, UpdateInterval: 12 * 60 * 60},
+	{Name: "FireHOL Level 1", Slug: "firehol-level1", Description: "FireHOL level 1 aggregated blocklist (bogons excluded)", URL: "https://raw.githubusercontent.com/firehol/blocklist-ipsets/master/firehol_level1.netset", Format: model.ThreatFeedFormatPlain, UpdateInterval: 24 * 60 * 60},
+	{Name: "Emerging Threats Compromised", Slug: "et-compromised", Description: "Proofpoint Emerging Threats compromised host IPs", URL: "https://rules.emergingthreats.net/blockrules/compromised-ips.txt", Format: model.ThreatFeedFormatPlain, UpdateInterval: 24 * 60 * 60},
+	{Name: "Tor Exit Nodes", Slug: "tor-exit", Description: "Tor Project bulk exit node list", URL: "https://check.torproject.org/torbulkexitlist", Format: model.ThreatFeedFormatPlain, UpdateInterval: 60 * 60},
+}
+
+// NewThreatFeedService creates a new threat feed service
+func NewThreatFeedService(repo *repository.ThreatFeedRepository) *ThreatFeedService {
+	return &ThreatFeedService{
+		repo: repo,
+		httpClient: &http.Client{
+			Timeout: 60 * time.Second,
+		},
+		checkInterval: 5 * time.Minute, // Feeds are refreshed based on their own interval
+	}
+}
+
+// SetFeedsUpdatedCallback sets the callback to call after feed IP ranges are updated
+// This is used to trigger nginx config regeneration for subscribed hosts
+func (s *ThreatFeedService) SetFeedsUpdatedCallback(cb ThreatFeedsUpdatedCallback) {
+	s.onFeedsUpdated = cb
+}
+
+// SeedDefaultFeeds creates the built-in feeds if they don't exist yet
+func (s *ThreatFeedService) SeedDefaultFeeds(ctx context.Context) error {
+	for _, df := range defaultThreatFeeds {
+		exists, err := s.repo.ExistsBySlug(ctx, df.Slug)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+
+		feed := df
+		feed.IsBuiltin = true
+		feed.Enabled = true
+		if _, err := s.repo.Create(ctx, &feed); err != nil {
+			log.Printf("[ThreatFeed] Error creating feed %s: %v", df.Slug, err)
+			continue
+		}
+		log.Printf("[ThreatFeed] Created feed: %s", df.Name)
+	}
+	return nil
+}
+
+// Start starts the periodic feed update scheduler
+func (s *ThreatFeedService) Start() {
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = true
+	s.stopCh = make(chan struct{})
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go s.run()
+	log.Println("[ThreatFeed Scheduler] Started")
+}
+
+// Stop stops the scheduler
+func (s *ThreatFeedService) Stop() {
+	s.mu.Lock()
+	if !s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = false
+	close(s.stopCh)
+	s.mu.Unlock()
+
+	s.wg.Wait()
+	log.Println("[ThreatFeed Scheduler] Stopped")
+}
+
+func (s *ThreatFeedService) run() {
+	defer s.wg.Done()
+
+	// Initial update after a short delay
+	select {
+	case <-time.After(1 * time.Minute):
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+		s.UpdateDueFeeds(ctx)
+		cancel()
+	case <-s.stopCh:
+		return
+	}
+
+	ticker := time.NewTicker(s.checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+			s.UpdateDueFeeds(ctx)
+			cancel()
+		case <-s.stopCh:
+			return
+		}
+	}
+}
+
+// UpdateDueFeeds refreshes every enabled feed whose update interval has elapsed
+func (s *ThreatFeedService) UpdateDueFeeds(ctx context.Context) {
+	feeds, err := s.repo.List(ctx)
+	if err != nil {
+		log.Printf("[ThreatFeed] Error listing feeds: %v", err)
+		return
+	}
+
+	now := time.Now()
+	var due []string
+	for _, f := range feeds {
+		if !f.Enabled {
+			continue
+		}
+		interval := f.UpdateInterval
+		if interval <= 0 {
+			interval = defaultThreatFeedInterval
+		}
+		if f.LastUpdated == nil || now.Sub(*f.LastUpdated) >= time.Duration(interval)*time.Second {
+			due = append(due, f.Slug)
+		}
+	}
+
+	if len(due) > 0 {
+		s.RefreshFeeds(ctx, due)
+	}
+}
+
+// RefreshFeeds downloads the given feeds immediately and triggers config regeneration
+// Returns the slugs of feeds that were updated successfully
+func (s *ThreatFeedService) RefreshFeeds(ctx context.Context, slugs []string) []string {
+	var updated []string
+	var failed int
+
+	for _, slug := range slugs {
+		if err := s.RefreshFeed(ctx, slug); err != nil {
+			log.Printf("[ThreatFeed] Error updating %s: %v", slug, err)
+			failed++
+			continue
+		}
+		updated = append(updated, slug)
+	}
+
+	log.Printf("[ThreatFeed] Feed update completed: %d updated, %d failed", len(updated), failed)
+
+	if len(updated) > 0 && s.onFeedsUpdated != nil {
+		if err := s.onFeedsUpdated(ctx, updated); err != nil {
+			log.Printf("[ThreatFeed] Error regenerating nginx configs: %v", err)
+		}
+	}
+
+	return updated
+}
+
+// RefreshFeed downloads a single feed and stores its IP ranges
+// On failure the previous ranges are kept and the error is recorded on the feed
+func (s *ThreatFeedService) RefreshFeed(ctx context.Context, slug string) error {
+	feed, err := s.repo.GetBySlug(ctx, slug)
+	if err != nil {
+		return err
+	}
+	if feed == nil {
+		return fmt.Errorf("threat feed not found: %s", slug)
+	}
+
+	ranges, err := s.FetchFeed(ctx, feed.URL, feed.Format)
+	if err == nil && len(ranges) == 0 {
+		err = fmt.Errorf("feed returned no valid IP addresses")
+	}
+	if err != nil {
+		_ = s.repo.SetLastError(ctx, slug, err.Error())
+		return err
+	}
+
+	if err := s.repo.UpdateIPRanges(ctx, slug, ranges); err != nil {
+		return err
+	}
+
+	log.Printf("[ThreatFeed] Updated %s with %d entries", slug, len(ranges))
+	return nil
+}
+
+// FetchFeed downloads a feed and parses it according to its format
+func (s *ThreatFeedService) FetchFeed(ctx context.Context, url, format string) ([]string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("User-Agent", "NginxProxyGuard/1.0")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	// Read one byte past the limit so that an oversized feed fails instead of
+	// being applied cut off
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxThreatFeedSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if len(body) > maxThreatFeedSize {
+		return nil, fmt.Errorf("feed is larger than %d MB", maxThreatFeedSize>>20)
+	}
+
+	return ParseThreatFeed(body, format)
+}
+
+// ParseThreatFeed extracts IP addresses and CIDR ranges from feed content
+// Entries overlapping private, loopback or unspecified ranges and overly wide
+// prefixes are dropped so that feeds containing bogons (e.g. FireHOL level1)
+// never lock out internal clients
+func ParseThreatFeed(data []byte, format string) ([]string, error) {
+	c := newThreatFeedCollector()
+
+	switch format {
+	case model.ThreatFeedFormatPlain, "":
+		for _, line := range strings.Split(string(data), "\n") {
+			// Strip comments ('#' or ';', e.g. "1.2.3.0/24 ; SBL123")
+			if i := strings.IndexAny(line, "#;"); i >= 0 {
+				line = line[:i]
+			}
+			fields := strings.Fields(line)
+			if len(fields) > 0 {
+				c.add(fields[0])
+			}
+		}
+	case model.ThreatFeedFormatCSV:
+		r := csv.NewReader(strings.NewReader(string(data)))
+		r.FieldsPerRecord = -1
+		r.Comment = '#'
+		r.LazyQuotes = true
+		for {
+			record, err := r.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse CSV feed: %w", err)
+			}
+			// Use the first column that holds an IP or CIDR (skips header rows)
+			for _, field := range record {
+				if c.add(field) {
+					break
+				}
+			}
+		}
+	case model.ThreatFeedFormatJSON:
+		var doc interface{}
+		if err := json.Unmarshal(data, &doc); err == nil {
+			c.walkJSON(doc)
+			break
+		}
+		// Fall back to newline-delimited JSON (e.g. Spamhaus drop_v4.json)
+		for _, line := range strings.Split(string(data), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" {
+				continue
+			}
+			var v interface{}
+			if err := json.Unmarshal([]byte(line), &v); err != nil {
+				return nil, fmt.Errorf("failed to parse JSON feed: %w", err)
+			}
+			c.walkJSON(v)
+		}
+	default:
+		return nil, fmt.Errorf("unknown feed format: %s", format)
+	}
+
+	// Stable order keeps generated nginx includes diff-friendly
+	sort.Strings(c.ranges)
+	return c.ranges, nil
+}
+
+type threatFeedCollector struct {
+	seen   map[string]bool
+	ranges []string
+}
+
+func newThreatFeedCollector() *threatFeedCollector {
+	return &threatFeedCollector{seen: make(map[string]bool)}
+}
+
+// add normalizes an IP or CIDR and records it; returns true if the value was an address
+func (c *threatFeedCollector) add(value string) bool {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return false
+	}
+
+	var entry string
+	var ipNet *net.IPNet
+	if strings.Contains(value, "/") {
+		_, parsed, err := net.ParseCIDR(value)
+		if err != nil {
+			return false
+		}
+		ipNet = parsed
+		entry = parsed.String()
+	} else {
+		ip := net.ParseIP(value)
+		if ip == nil {
+			return false
+		}
+		ipNet = singleIPNet(ip)
+		entry = ip.String()
+	}
+
+	if !threatFeedEntryAllowed(ipNet) {
+		return true
+	}
+
+	if !c.seen[entry] {
+		c.seen[entry] = true
+		c.ranges = append(c.ranges, entry)
+	}
+	return true
+}
+
+// singleIPNet returns the /32 or /128 network of ip
+func singleIPNet(ip net.IP) *net.IPNet {
+	if v4 := ip.To4(); v4 != nil {
+		return &net.IPNet{IP: v4, Mask: net.CIDRMask(32, 32)}
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}
+}
+
+// threatFeedEntryAllowed reports whether a feed entry is narrow enough and
+// stays clear of every protected range
+func threatFeedEntryAllowed(ipNet *net.IPNet) bool {
+	ones, bits := ipNet.Mask.Size()
+	if (bits == 32 && ones < minThreatFeedPrefixV4) || (bits == 128 && ones < minThreatFeedPrefixV6) {
+		return false
+	}
+	for _, protected := range threatFeedProtectedRanges {
+		if protected.Contains(ipNet.IP) || ipNet.Contains(protected.IP) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *threatFeedCollector) walkJSON(v interface{}) {
+	switch t := v.(type) {
+	case string:
+		c.add(t)
+	case []interface{}:
+		for _, item := range t {
+			c.walkJSON(item)
+		}
+	case map[string]interface{}:
+		for _, item := range t {
+			c.walkJSON(item)
+		}
+	}
+}
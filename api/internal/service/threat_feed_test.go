@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"nginx-proxy-guard/internal/model"
+)
+
+func TestThreatFeedFetch(t *testing.T) {
+	files := map[string]string{
+		"/drop.txt": "; Spamhaus DROP List\n" +
+			"1.10.16.0/20 ; SBL256894\n" +
+			"2.56.192.0/22 ; SBL459831\n",
+		"/netset": "# FireHOL level1\n" +
+			"0.0.0.0/8\n" +
+			"10.0.0.0/8\n" +
+			"127.0.0.0/8\n" +
+			"5.188.10.0/23\n" +
+			"185.220.101.7\n" +
+			"185.220.101.7\n",
+		"/feed.csv": "ip,first_seen,category\n" +
+			"203.0.113.7,2024-01-01,scanner\n" +
+			"198.51.100.0/24,2024-01-02,botnet\n" +
+			"not-an-ip,2024-01-03,junk\n",
+		"/feed.json": `{"data":[{"ip":"203.0.113.9","score":90},{"cidr":"2001:db8:dead::/48"}],"count":2}`,
+		"/feed.ndjson": `{"cidr":"1.10.16.0/20","sblid":"SBL256894"}` + "\n" +
+			`{"cidr":"2.56.192.0/22","sblid":"SBL459831"}` + "\n" +
+			`{"type":"metadata","records":2}` + "\n",
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	svc := NewThreatFeedService(nil)
+
+	tests := []struct {
+		name   string
+		path   string
+		format string
+		want   []string
+	}{
+		{"plain with semicolon comments", "/drop.txt", model.ThreatFeedFormatPlain, []string{"1.10.16.0/20", "2.56.192.0/22"}},
+		{"plain drops bogons and duplicates", "/netset", model.ThreatFeedFormatPlain, []string{"185.220.101.7", "5.188.10.0/23"}},
+		{"csv skips header and invalid rows", "/feed.csv", model.ThreatFeedFormatCSV, []string{"198.51.100.0/24", "203.0.113.7"}},
+		{"json walks nested values", "/feed.json", model.ThreatFeedFormatJSON, []string{"2001:db8:dead::/48", "203.0.113.9"}},
+		{"ndjson", "/feed.ndjson", model.ThreatFeedFormatJSON, []string{"1.10.16.0/20", "2.56.192.0/22"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := svc.FetchFeed(context.Background(), srv.URL+tt.path, tt.format)
+			if err != nil {
+				t.Fatalf("FetchFeed() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FetchFeed() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("http error", func(t *testing.T) {
+		if _, err := svc.FetchFeed(context.Background(), srv.URL+"/missing", model.ThreatFeedFormatPlain); err == nil {
+			t.Error("FetchFeed() expected error for 404 response")
+		}
+	})
+
+	t.Run("oversized feed fails", func(t *testing.T) {
+		big := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			line := []byte("203.0.113.7\n")
+			for written := 0; written <= maxThreatFeedSize; written += len(line) {
+				w.Write(line)
+			}
+		}))
+		defer big.Close()
+		if _, err := svc.FetchFeed(context.Background(), big.URL, model.ThreatFeedFormatPlain); err == nil {
+			t.Error("FetchFeed() expected error for a feed over the size limit")
+		}
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		if _, err := svc.FetchFeed(context.Background(), srv.URL+"/drop.txt", "xml"); err == nil {
+			t.Error("FetchFeed() expected error for unknown format")
+		}
+	})
+}
+
+func TestParseThreatFeedDropsWideAndPrivateRanges(t *testing.T) {
+	feed := "0.0.0.0/0\n" +
+		"8.0.0.0/4\n" + // Covers 10.0.0.0/8
+		"4.0.0.0/6\n" + // Public, but wider than /8
+		"172.0.0.0/8\n" + // Contains 172.16.0.0/12
+		"192.168.10.0/24\n" +
+		"169.254.1.1\n" +
+		"::/0\n" +
+		"fc00::/6\n" + // Contains fc00::/7
+		"2000::/3\n" +
+		"45.0.0.0/8\n" +
+		"2a06:e480::/29\n"
+
+	got, err := ParseThreatFeed([]byte(feed), model.ThreatFeedFormatPlain)
+	if err != nil {
+		t.Fatalf("ParseThreatFeed() error = %v", err)
+	}
+	if want := []string{"2a06:e480::/29", "45.0.0.0/8"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseThreatFeed() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,350 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"nginx-proxy-guard/internal/model"
+)
+
+const (
+	defaultHSTSMaxAge = 63072000 // 2 years, the Mozilla recommendation
+	minHSTSPreloadAge = 31536000 // hstspreload.org requirement
+	minHSTSGradeAge   = 15552000 // 180 days, required for A+
+)
+
+// tlsProtocolOrder lists the protocols nginx accepts in ssl_protocols, oldest first
+var tlsProtocolOrder = []string{"TLSv1", "TLSv1.1", "TLSv1.2", "TLSv1.3"}
+
+// tlsProfilePresets follows the Mozilla server side TLS guidelines (v5.7)
+// DHE suites are left out: without ssl_dhparam nginx never negotiates them
+var tlsProfilePresets = map[string]model.TLSPolicy{
+	model.TLSProfileModern: {
+		Profile:             model.TLSProfileModern,
+		Protocols:           []string{"TLSv1.3"},
+		Curves:              "X25519:prime256v1:secp384r1",
+		PreferServerCiphers: false,
+		OCSPStapling:        true,
+		HSTSMaxAge:          defaultHSTSMaxAge,
+	},
+	model.TLSProfileIntermediate: {
+		Profile:             model.TLSProfileIntermediate,
+		Protocols:           []string{"TLSv1.2", "TLSv1.3"},
+		Ciphers:             "ECDHE-ECDSA-AES128-GCM-SHA256:ECDHE-RSA-AES128-GCM-SHA256:ECDHE-ECDSA-AES256-GCM-SHA384:ECDHE-RSA-AES256-GCM-SHA384:ECDHE-ECDSA-CHACHA20-POLY1305:ECDHE-RSA-CHACHA20-POLY1305",
+		Curves:              "X25519:prime256v1:secp384r1",
+		PreferServerCiphers: false,
+		OCSPStapling:        true,
+		HSTSMaxAge:          defaultHSTSMaxAge,
+	},
+	model.TLSProfileOld: {
+		Profile:             model.TLSProfileOld,
+		Protocols:           []string{"TLSv1", "TLSv1.1", "TLSv1.2", "TLSv1.3"},
+		Ciphers:             "ECDHE-ECDSA-AES128-GCM-SHA256:ECDHE-RSA-AES128-GCM-SHA256:ECDHE-ECDSA-AES256-GCM-SHA384:ECDHE-RSA-AES256-GCM-SHA384:ECDHE-ECDSA-CHACHA20-POLY1305:ECDHE-RSA-CHACHA20-POLY1305:ECDHE-ECDSA-AES128-SHA256:ECDHE-RSA-AES128-SHA256:ECDHE-ECDSA-AES128-SHA:ECDHE-RSA-AES128-SHA:ECDHE-ECDSA-AES256-SHA384:ECDHE-RSA-AES256-SHA384:ECDHE-ECDSA-AES256-SHA:ECDHE-RSA-AES256-SHA:AES128-GCM-SHA256:AES256-GCM-SHA384:AES128-SHA256:AES256-SHA256:AES128-SHA:AES256-SHA:DES-CBC3-SHA:@SECLEVEL=0",
+		Curves:              "X25519:prime256v1:secp384r1",
+		PreferServerCiphers: true,
+		OCSPStapling:        true,
+		HSTSMaxAge:          defaultHSTSMaxAge,
+	},
+}
+
+// knownTLSCurves are the groups the OpenSSL of the nginx image accepts in ssl_ecdh_curve
+var knownTLSCurves = map[string]bool{
+	"X25519": true, "X448": true, "prime256v1": true, "secp256r1": true, "secp384r1": true, "secp521r1": true,
+	"X25519MLKEM768": true, "SecP256r1MLKEM768": true, "SecP384r1MLKEM1024": true,
+	"ffdhe2048": true, "ffdhe3072": true, "ffdhe4096": true,
+}
+
+// tlsCipherKeywords are the OpenSSL cipher string keywords accepted besides cipher names
+var tlsCipherKeywords = map[string]bool{
+	"ALL": true, "DEFAULT": true, "COMPLEMENTOFDEFAULT": true, "COMPLEMENTOFALL": true,
+	"HIGH": true, "MEDIUM": true, "LOW": true,
+	"ECDHE": true, "EECDH": true, "kECDHE": true, "DHE": true, "EDH": true, "kEDH": true, "kDHE": true, "kRSA": true, "RSA": true,
+	"aRSA": true, "aECDSA": true, "ECDSA": true, "aDSS": true, "DSS": true, "aNULL": true, "eNULL": true, "NULL": true,
+	"AES": true, "AES128": true, "AES256": true, "AESGCM": true, "AESCCM": true, "CHACHA20": true, "CAMELLIA": true, "ARIA": true,
+	"3DES": true, "DES": true, "RC4": true, "SEED": true, "IDEA": true, "MD5": true, "SHA1": true, "SHA": true, "SHA256": true, "SHA384": true,
+	"EXPORT": true, "PSK": true, "SRP": true, "TLSv1.2": true, "TLSv1.0": true, "SSLv3": true,
+}
+
+var tlsCipherNamePattern = regexp.MustCompile(`^[A-Z0-9]+(-[A-Z0-9]+)+$`)
+
+// TLSPolicyError reports the field of a TLS policy that nginx or OpenSSL would refuse
+type TLSPolicyError struct {
+	Field   string
+	Message string
+}
+
+func (e *TLSPolicyError) Error() string {
+	return fmt.Sprintf("%s %s", e.Field, e.Message)
+}
+
+// TLSProfilePresets returns the preset profiles
+func TLSProfilePresets() []model.TLSPolicy {
+	presets := make([]model.TLSPolicy, 0, len(tlsProfilePresets))
+	for _, profile := range []string{model.TLSProfileModern, model.TLSProfileIntermediate, model.TLSProfileOld} {
+		preset := tlsProfilePresets[profile]
+		preset.Protocols = append([]string(nil), preset.Protocols...)
+		GradeTLSPolicy(&preset)
+		presets = append(presets, preset)
+	}
+	return presets
+}
+
+// DefaultTLSPolicy returns the policy rendered for hosts without one
+func DefaultTLSPolicy(proxyHostID string) *model.TLSPolicy {
+	policy := tlsProfilePresets[model.TLSProfileIntermediate]
+	policy.ProxyHostID = proxyHostID
+	policy.Protocols = append([]string(nil), policy.Protocols...)
+	policy.PreferServerCiphers = true // the built-in server block prefers server ciphers
+	GradeTLSPolicy(&policy)
+	return &policy
+}
+
+// ResolveTLSPolicy builds a policy from a request, applying the preset of its profile, and validates it
+func ResolveTLSPolicy(req *model.UpsertTLSPolicyRequest) (*model.TLSPolicy, error) {
+	profile := strings.TrimSpace(req.Profile)
+	if profile == "" {
+		profile = model.TLSProfileIntermediate
+	}
+
+	var policy model.TLSPolicy
+	if preset, ok := tlsProfilePresets[profile]; ok {
+		policy = preset
+		policy.Protocols = append([]string(nil), preset.Protocols...)
+	} else if profile == model.TLSProfileCustom {
+		policy = model.TLSPolicy{
+			Profile:      model.TLSProfileCustom,
+			Protocols:    req.Protocols,
+			Ciphers:      strings.TrimSpace(req.Ciphers),
+			Curves:       strings.TrimSpace(req.Curves),
+			OCSPStapling: true,
+			HSTSMaxAge:   defaultHSTSMaxAge,
+		}
+		if req.PreferServerCiphers != nil {
+			policy.PreferServerCiphers = *req.PreferServerCiphers
+		}
+	} else {
+		return nil, &TLSPolicyError{Field: "profile", Message: "must be one of modern, intermediate, old, custom"}
+	}
+
+	if req.SessionTickets != nil {
+		policy.SessionTickets = *req.SessionTickets
+	}
+	if req.OCSPStapling != nil {
+		policy.OCSPStapling = *req.OCSPStapling
+	}
+	if req.HSTSEnabled != nil {
+		policy.HSTSEnabled = *req.HSTSEnabled
+	}
+	if req.HSTSMaxAge != 0 {
+		policy.HSTSMaxAge = req.HSTSMaxAge
+	}
+	if req.HSTSIncludeSubdomains != nil {
+		policy.HSTSIncludeSubdomains = *req.HSTSIncludeSubdomains
+	}
+	if req.HSTSPreload != nil {
+		policy.HSTSPreload = *req.HSTSPreload
+	}
+
+	if err := ValidateTLSPolicy(&policy); err != nil {
+		return nil, err
+	}
+	GradeTLSPolicy(&policy)
+	return &policy, nil
+}
+
+// ValidateTLSPolicy rejects settings nginx refuses to load or OpenSSL refuses to negotiate,
+// and normalizes the protocol list to nginx order
+func ValidateTLSPolicy(policy *model.TLSPolicy) error {
+	enabled := make(map[string]bool)
+	for _, protocol := range policy.Protocols {
+		protocol = strings.TrimSpace(protocol)
+		known := false
+		for _, p := range tlsProtocolOrder {
+			if p == protocol {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return &TLSPolicyError{Field: "protocols", Message: fmt.Sprintf("contains an unsupported protocol %q (allowed: %s)", protocol, strings.Join(tlsProtocolOrder, ", "))}
+		}
+		enabled[protocol] = true
+	}
+	if len(enabled) == 0 {
+		return &TLSPolicyError{Field: "protocols", Message: "must enable at least one protocol"}
+	}
+	protocols := make([]string, 0, len(enabled))
+	for _, p := range tlsProtocolOrder {
+		if enabled[p] {
+			protocols = append(protocols, p)
+		}
+	}
+	policy.Protocols = protocols
+	legacy := enabled["TLSv1"] || enabled["TLSv1.1"]
+	onlyTLS13 := len(policy.Protocols) == 1 && enabled["TLSv1.3"]
+
+	if onlyTLS13 {
+		if policy.Ciphers != "" {
+			return &TLSPolicyError{Field: "ciphers", Message: "have no effect with TLSv1.3 only, TLS 1.3 cipher suites are not configured by ssl_ciphers"}
+		}
+	} else {
+		if policy.Ciphers == "" {
+			return &TLSPolicyError{Field: "ciphers", Message: "are required when TLSv1.2 or older is enabled"}
+		}
+		if err := validateTLSCiphers(policy.Ciphers, legacy); err != nil {
+			return err
+		}
+	}
+
+	if policy.Curves != "" {
+		hasEC := false
+		for _, curve := range strings.Split(policy.Curves, ":") {
+			if !knownTLSCurves[curve] {
+				return &TLSPolicyError{Field: "curves", Message: fmt.Sprintf("contains an unknown curve %q", curve)}
+			}
+			if !strings.HasPrefix(curve, "ffdhe") {
+				hasEC = true
+			}
+		}
+		if !hasEC && strings.Contains(policy.Ciphers, "ECDHE") {
+			return &TLSPolicyError{Field: "curves", Message: "must include an elliptic curve when ECDHE ciphers are used"}
+		}
+	}
+
+	if policy.HSTSEnabled {
+		if policy.HSTSMaxAge <= 0 {
+			return &TLSPolicyError{Field: "hsts_max_age", Message: "must be positive"}
+		}
+		if policy.HSTSPreload && (!policy.HSTSIncludeSubdomains || policy.HSTSMaxAge < minHSTSPreloadAge) {
+			return &TLSPolicyError{Field: "hsts_preload", Message: fmt.Sprintf("requires includeSubDomains and a max-age of at least %d", minHSTSPreloadAge)}
+		}
+	}
+	return nil
+}
+
+// validateTLSCiphers checks an OpenSSL cipher string
+// OpenSSL only fails when nothing matches, but a typo silently dropping ciphers is just as broken
+func validateTLSCiphers(ciphers string, legacy bool) error {
+	positive := 0
+	secLevel0 := false
+	for _, token := range strings.Split(ciphers, ":") {
+		if token == "" {
+			continue
+		}
+		if strings.HasPrefix(token, "@") {
+			switch token {
+			case "@STRENGTH":
+			case "@SECLEVEL=0":
+				secLevel0 = true
+			case "@SECLEVEL=1", "@SECLEVEL=2", "@SECLEVEL=3", "@SECLEVEL=4", "@SECLEVEL=5":
+			default:
+				return &TLSPolicyError{Field: "ciphers", Message: fmt.Sprintf("contains an unknown directive %q", token)}
+			}
+			continue
+		}
+		if strings.HasPrefix(token, "TLS_") {
+			return &TLSPolicyError{Field: "ciphers", Message: fmt.Sprintf("contains the TLS 1.3 suite %q, which ssl_ciphers doesn't configure", token)}
+		}
+		exclusion := strings.HasPrefix(token, "!") || strings.HasPrefix(token, "-") || strings.HasPrefix(token, "+")
+		name := strings.TrimLeft(token, "!-+")
+		for _, part := range strings.Split(name, "+") {
+			if !tlsCipherKeywords[part] && !tlsCipherNamePattern.MatchString(part) {
+				return &TLSPolicyError{Field: "ciphers", Message: fmt.Sprintf("contains an invalid cipher %q", token)}
+			}
+		}
+		if !exclusion {
+			positive++
+		}
+	}
+	if positive == 0 {
+		return &TLSPolicyError{Field: "ciphers", Message: "must enable at least one cipher"}
+	}
+	// OpenSSL 3 disables TLS 1.0/1.1 (SHA-1 signatures) above security level 0
+	if legacy && !secLevel0 {
+		return &TLSPolicyError{Field: "ciphers", Message: "must end with @SECLEVEL=0 for OpenSSL 3 to negotiate TLSv1 or TLSv1.1"}
+	}
+	return nil
+}
+
+// GradeTLSPolicy computes an SSL Labs style grade with the reasons it is capped
+func GradeTLSPolicy(policy *model.TLSPolicy) {
+	grade := "A"
+	notes := []string{}
+	capGrade := func(g, note string) {
+		if gradeRank(g) > gradeRank(grade) {
+			grade = g
+		}
+		notes = append(notes, note)
+	}
+
+	protocols := make(map[string]bool)
+	for _, p := range policy.Protocols {
+		protocols[p] = true
+	}
+	if protocols["TLSv1"] || protocols["TLSv1.1"] {
+		capGrade("B", "TLS 1.0/1.1 enabled")
+	}
+	if !protocols["TLSv1.3"] {
+		capGrade("A-", "TLS 1.3 not enabled")
+	}
+
+	if policy.Ciphers != "" {
+		weak, noForwardSecrecy, cbc := false, false, false
+		for _, token := range strings.Split(policy.Ciphers, ":") {
+			if token == "" || strings.HasPrefix(token, "!") || strings.HasPrefix(token, "-") || strings.HasPrefix(token, "@") {
+				continue
+			}
+			name := strings.TrimPrefix(token, "+")
+			switch {
+			case strings.Contains(name, "RC4") || strings.Contains(name, "NULL") || strings.Contains(name, "EXP") || strings.Contains(name, "MD5"):
+				weak = true
+			case strings.Contains(name, "3DES") || strings.Contains(name, "DES-CBC"):
+				capGrade("C", "3DES cipher enabled")
+			}
+			if !strings.Contains(name, "ECDHE") && !strings.Contains(name, "DHE") && !strings.Contains(name, "EECDH") && !strings.Contains(name, "EDH") {
+				noForwardSecrecy = true
+			}
+			if !strings.Contains(name, "GCM") && !strings.Contains(name, "CHACHA20") && !strings.Contains(name, "CCM") {
+				cbc = true
+			}
+		}
+		if weak {
+			capGrade("F", "insecure cipher (RC4, NULL, EXPORT or MD5) enabled")
+		}
+		if noForwardSecrecy {
+			capGrade("B", "ciphers without forward secrecy enabled")
+		}
+		if cbc {
+			capGrade("A-", "CBC ciphers enabled")
+		}
+	}
+
+	if grade == "A" {
+		if policy.HSTSEnabled && policy.HSTSMaxAge >= minHSTSGradeAge {
+			grade = "A+"
+		} else {
+			notes = append(notes, "HSTS with a max-age of at least 180 days is required for A+")
+		}
+	}
+
+	policy.Grade = grade
+	policy.GradeNotes = notes
+}
+
+func gradeRank(grade string) int {
+	switch grade {
+	case "A+":
+		return 0
+	case "A":
+		return 1
+	case "A-":
+		return 2
+	case "B":
+		return 3
+	case "C":
+		return 4
+	default:
+		return 5
+	}
+}
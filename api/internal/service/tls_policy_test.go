@@ -0,0 +1,69 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"nginx-proxy-guard/internal/model"
+)
+
+func TestResolveTLSPolicyPresets(t *testing.T) {
+	yes := true
+	grades := map[string]string{
+		model.TLSProfileModern:       "A+",
+		model.TLSProfileIntermediate: "A+",
+		model.TLSProfileOld:          "C",
+	}
+	for profile, want := range grades {
+		policy, err := ResolveTLSPolicy(&model.UpsertTLSPolicyRequest{Profile: profile, HSTSEnabled: &yes})
+		if err != nil {
+			t.Fatalf("ResolveTLSPolicy(%s) error = %v", profile, err)
+		}
+		if policy.Grade != want {
+			t.Errorf("ResolveTLSPolicy(%s) grade = %s, want %s (%v)", profile, policy.Grade, want, policy.GradeNotes)
+		}
+	}
+
+	policy, err := ResolveTLSPolicy(&model.UpsertTLSPolicyRequest{Profile: model.TLSProfileIntermediate})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if policy.Grade != "A" {
+		t.Errorf("intermediate without HSTS grade = %s, want A", policy.Grade)
+	}
+}
+
+func TestResolveTLSPolicyCustom(t *testing.T) {
+	policy, err := ResolveTLSPolicy(&model.UpsertTLSPolicyRequest{
+		Profile:   model.TLSProfileCustom,
+		Protocols: []string{"TLSv1.3", "TLSv1.2"},
+		Ciphers:   "ECDHE+AESGCM:!aNULL",
+		Curves:    "X25519MLKEM768:X25519",
+	})
+	if err != nil {
+		t.Fatalf("ResolveTLSPolicy error = %v", err)
+	}
+	if len(policy.Protocols) != 2 || policy.Protocols[0] != "TLSv1.2" {
+		t.Errorf("protocols = %v, want nginx order", policy.Protocols)
+	}
+
+	rejected := map[string]model.UpsertTLSPolicyRequest{
+		"protocols": {Profile: model.TLSProfileCustom, Protocols: []string{"SSLv3"}},
+		"ciphers":   {Profile: model.TLSProfileCustom, Protocols: []string{"TLSv1.1", "TLSv1.2"}, Ciphers: "ECDHE-RSA-AES128-SHA"},
+		"curves":    {Profile: model.TLSProfileCustom, Protocols: []string{"TLSv1.3"}, Curves: "brainpool"},
+	}
+	for field, req := range rejected {
+		_, err := ResolveTLSPolicy(&req)
+		var perr *TLSPolicyError
+		if !errors.As(err, &perr) || perr.Field != field {
+			t.Errorf("expected %s to be rejected, got %v", field, err)
+		}
+	}
+
+	yes := true
+	_, err = ResolveTLSPolicy(&model.UpsertTLSPolicyRequest{Profile: model.TLSProfileModern, HSTSEnabled: &yes, HSTSPreload: &yes})
+	var perr *TLSPolicyError
+	if !errors.As(err, &perr) || perr.Field != "hsts_preload" {
+		t.Errorf("expected preload without includeSubDomains to be rejected, got %v", err)
+	}
+}
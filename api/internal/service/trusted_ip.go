@@ -0,0 +1,212 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"nginx-proxy-guard/internal/model"
+	"nginx-proxy-guard/internal/repository"
+)
+
+// TrustedIPsRenderer renders the active trusted networks into nginx
+type TrustedIPsRenderer interface {
+	UpdateTrustedIPs(ctx context.Context, entries []model.TrustedIP) error
+}
+
+// TrustedIPService keeps the global never-ban list in nginx up to date and lets the ban
+// services check it before banning. Expired entries are dropped from nginx by a periodic check.
+type TrustedIPService struct {
+	repo          *repository.TrustedIPRepository
+	renderer      TrustedIPsRenderer
+	stopCh        chan struct{}
+	wg            sync.WaitGroup
+	mu            sync.Mutex
+	running       bool
+	checkInterval time.Duration
+
+	// Active entries last rendered to nginx, guarded by cacheMu
+	cacheMu      sync.RWMutex
+	active       []model.TrustedIP
+	networks     []*net.IPNet
+	lastRendered string
+	hasRendered  bool
+}
+
+// NewTrustedIPService creates a new trusted IP service
+func NewTrustedIPService(repo *repository.TrustedIPRepository, renderer TrustedIPsRenderer) *TrustedIPService {
+	return &TrustedIPService{
+		repo:          repo,
+		renderer:      renderer,
+		checkInterval: time.Minute,
+	}
+}
+
+// Start renders the current list and starts the expiry check
+func (s *TrustedIPService) Start() {
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = true
+	s.stopCh = make(chan struct{})
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go s.run()
+	log.Println("[TrustedIP] Started")
+}
+
+// Stop stops the expiry check
+func (s *TrustedIPService) Stop() {
+	s.mu.Lock()
+	if !s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = false
+	close(s.stopCh)
+	s.mu.Unlock()
+
+	s.wg.Wait()
+	log.Println("[TrustedIP] Stopped")
+}
+
+func (s *TrustedIPService) run() {
+	defer s.wg.Done()
+
+	s.refresh()
+
+	ticker := time.NewTicker(s.checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.refresh()
+		case <-s.stopCh:
+			return
+		}
+	}
+}
+
+func (s *TrustedIPService) refresh() {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	if err := s.Apply(ctx); err != nil {
+		log.Printf("[TrustedIP] Failed to apply trusted IPs: %v", err)
+	}
+}
+
+// Apply loads the active trusted networks, refreshes the match cache and rewrites the nginx
+// include when the active set changed (e.g. after an edit or when an entry expired)
+func (s *TrustedIPService) Apply(ctx context.Context) error {
+	entries, err := s.repo.ListActive(ctx)
+	if err != nil {
+		return err
+	}
+
+	active := make([]model.TrustedIP, 0, len(entries))
+	networks := make([]*net.IPNet, 0, len(entries))
+	keys := make([]string, 0, len(entries))
+	for _, e := range entries {
+		ipNet, err := ParseTrustedCIDR(e.CIDR)
+		if err != nil {
+			log.Printf("[TrustedIP] Skipping invalid entry %s: %v", e.CIDR, err)
+			continue
+		}
+		active = append(active, e)
+		networks = append(networks, ipNet)
+		keys = append(keys, e.CIDR+"|"+e.Label)
+	}
+	rendered := strings.Join(keys, "\n")
+
+	s.cacheMu.Lock()
+	s.active = active
+	s.networks = networks
+	changed := !s.hasRendered || rendered != s.lastRendered
+	s.cacheMu.Unlock()
+
+	if !changed || s.renderer == nil {
+		return nil
+	}
+
+	if err := s.renderer.UpdateTrustedIPs(ctx, active); err != nil {
+		return err
+	}
+
+	s.cacheMu.Lock()
+	s.lastRendered = rendered
+	s.hasRendered = true
+	s.cacheMu.Unlock()
+
+	log.Printf("[TrustedIP] Rendered %d trusted networks", len(active))
+	return nil
+}
+
+// Match returns the active trusted entry that contains ip, or nil
+func (s *TrustedIPService) Match(ip string) *model.TrustedIP {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return nil
+	}
+
+	now := time.Now()
+	s.cacheMu.RLock()
+	defer s.cacheMu.RUnlock()
+	for i, ipNet := range s.networks {
+		if ipNet.Contains(addr) && s.active[i].IsActive(now) {
+			entry := s.active[i]
+			return &entry
+		}
+	}
+	return nil
+}
+
+// PreventBan reports whether ip is trusted and, if so, records which entry prevented the ban
+// source is the IPBanHistory source of the ban that would have been applied
+func (s *TrustedIPService) PreventBan(ctx context.Context, ip, source, reason string, proxyHostID *string) bool {
+	entry := s.Match(ip)
+	if entry == nil {
+		return false
+	}
+
+	if err := s.repo.RecordHit(ctx, &model.TrustedIPHit{
+		TrustedIPID: entry.ID,
+		IPAddress:   ip,
+		Source:      source,
+		Reason:      reason,
+		ProxyHostID: proxyHostID,
+	}); err != nil {
+		log.Printf("[TrustedIP] Warning: Failed to record prevented ban: %v", err)
+	}
+
+	log.Printf("[TrustedIP] Not banning %s (%s): trusted by %s", ip, source, entry.CIDR)
+	return true
+}
+
+// ParseTrustedCIDR parses an IP or CIDR into a network; single IPs become /32 or /128
+func ParseTrustedCIDR(value string) (*net.IPNet, error) {
+	value = strings.TrimSpace(value)
+	if !strings.Contains(value, "/") {
+		ip := net.ParseIP(value)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid IP address or CIDR: %s", value)
+		}
+		if v4 := ip.To4(); v4 != nil {
+			return &net.IPNet{IP: v4, Mask: net.CIDRMask(32, 32)}, nil
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
+	}
+
+	_, ipNet, err := net.ParseCIDR(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid IP address or CIDR: %s", value)
+	}
+	return ipNet, nil
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"nginx-proxy-guard/internal/model"
+)
+
+func TestParseTrustedCIDR(t *testing.T) {
+	tests := map[string]string{
+		"203.0.113.7":    "203.0.113.7/32",
+		"203.0.113.7/24": "203.0.113.0/24",
+		"2001:db8::1":    "2001:db8::1/128",
+		" 10.0.0.0/8 ":   "10.0.0.0/8",
+	}
+	for in, want := range tests {
+		got, err := ParseTrustedCIDR(in)
+		if err != nil {
+			t.Fatalf("ParseTrustedCIDR(%q) error = %v", in, err)
+		}
+		if got.String() != want {
+			t.Errorf("ParseTrustedCIDR(%q) = %s, want %s", in, got, want)
+		}
+	}
+
+	for _, in := range []string{"", "bogus", "10.0.0.0/33"} {
+		if _, err := ParseTrustedCIDR(in); err == nil {
+			t.Errorf("ParseTrustedCIDR(%q) expected error", in)
+		}
+	}
+}
+
+func TestTrustedIPServiceMatch(t *testing.T) {
+	past := time.Now().Add(-time.Minute)
+	s := NewTrustedIPService(nil, nil)
+	for _, e := range []model.TrustedIP{
+		{ID: "office", CIDR: "198.51.100.0/24"},
+		{ID: "expired", CIDR: "192.0.2.0/24", ExpiresAt: &past},
+		{ID: "v6", CIDR: "2001:db8::/32"},
+	} {
+		ipNet, _ := ParseTrustedCIDR(e.CIDR)
+		s.active = append(s.active, e)
+		s.networks = append(s.networks, ipNet)
+	}
+
+	tests := map[string]string{
+		"198.51.100.42":  "office",
+		"192.0.2.1":      "",
+		"2001:db8:5::9":  "v6",
+		"203.0.113.1":    "",
+		"not-an-address": "",
+	}
+	for ip, want := range tests {
+		got := ""
+		if entry := s.Match(ip); entry != nil {
+			got = entry.ID
+		}
+		if got != want {
+			t.Errorf("Match(%q) = %q, want %q", ip, got, want)
+		}
+	}
+}
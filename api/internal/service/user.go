@@ -0,0 +1,418 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"nginx-proxy-guard/internal/model"
+	"nginx-proxy-guard/internal/repository"
+)
+
+const (
+	defaultInvitationTTL = 72 * time.Hour
+	maxInvitationTTL     = 30 * 24 * time.Hour
+)
+
+var (
+	ErrUserNotFound      = errors.New("user not found")
+	ErrSelfModification  = errors.New("you cannot disable, delete or demote your own account")
+	ErrLastAdmin         = errors.New("at least one enabled admin account is required")
+	ErrInvalidInvitation = errors.New("invalid or expired invitation")
+)
+
+var (
+	usernamePattern = regexp.MustCompile(`^[A-Za-z0-9._@-]{3,64}$`)
+	emailPattern    = regexp.MustCompile(`^[^@\s]+@[^@\s]+$`)
+)
+
+// UserError reports the field of a user request that is invalid
+type UserError struct {
+	Field   string
+	Message string
+}
+
+func (e *UserError) Error() string {
+	return fmt.Sprintf("%s %s", e.Field, e.Message)
+}
+
+type UserService struct {
+	repo         *repository.UserRepository
+	authRepo     *repository.AuthRepository
+	webauthnRepo *repository.WebAuthnRepository
+
+	passwordRules PasswordRules
+}
+
+func NewUserService(repo *repository.UserRepository, authRepo *repository.AuthRepository, webauthnRepo *repository.WebAuthnRepository) *UserService {
+	return &UserService{repo: repo, authRepo: authRepo, webauthnRepo: webauthnRepo}
+}
+
+// SetPasswordRules sets the password policy new passwords are checked against
+func (s *UserService) SetPasswordRules(rules PasswordRules) {
+	s.passwordRules = rules
+}
+
+// ValidateNewUser checks the username, email and role of a user to create
+func ValidateNewUser(username, email, role string) error {
+	if !usernamePattern.MatchString(username) {
+		return &UserError{Field: "username", Message: "must be 3-64 characters of letters, digits, '.', '_', '@' or '-'"}
+	}
+	if email != "" && (len(email) > 255 || !emailPattern.MatchString(email)) {
+		return &UserError{Field: "email", Message: "is not a valid email address"}
+	}
+	if !model.IsValidRole(role) {
+		return &UserError{Field: "role", Message: fmt.Sprintf("must be one of %s", strings.Join(model.AllRoles, ", "))}
+	}
+	return nil
+}
+
+// parseInvitationTTL parses "72h" or "7d" style durations, defaulting to 72 hours
+func parseInvitationTTL(value string) (time.Duration, error) {
+	if value == "" {
+		return defaultInvitationTTL, nil
+	}
+
+	var ttl time.Duration
+	if strings.HasSuffix(value, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(value, "d"))
+		if err != nil {
+			return 0, &UserError{Field: "expires_in", Message: "must be a duration like 24h or 7d"}
+		}
+		ttl = time.Duration(days) * 24 * time.Hour
+	} else {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return 0, &UserError{Field: "expires_in", Message: "must be a duration like 24h or 7d"}
+		}
+		ttl = d
+	}
+
+	if ttl < time.Hour || ttl > maxInvitationTTL {
+		return 0, &UserError{Field: "expires_in", Message: "must be between 1h and 30d"}
+	}
+	return ttl, nil
+}
+
+// checkAdminRemoval refuses to take away the last enabled admin account.
+// A user stops counting as an admin when it is disabled, deleted or demoted.
+func checkAdminRemoval(target *model.User, enabledAdmins int) error {
+	if target.Role == model.RoleAdmin && !target.Disabled && enabledAdmins <= 1 {
+		return ErrLastAdmin
+	}
+	return nil
+}
+
+// applyMappedRole applies a role mapped from an identity provider or
+// directory. The last enabled admin keeps its role so a misconfigured mapping
+// can't lock everyone out.
+func applyMappedRole(ctx context.Context, repo *repository.UserRepository, user *model.User, role string) error {
+	if user.Role == model.RoleAdmin && !user.Disabled {
+		count, err := repo.CountEnabledAdmins(ctx)
+		if err != nil {
+			return err
+		}
+		if checkAdminRemoval(user, count) != nil {
+			log.Printf("[Users] Keeping admin role of %s, it is the last enabled admin", user.Username)
+			return nil
+		}
+	}
+	if err := repo.UpdateRole(ctx, user.ID, role); err != nil {
+		return err
+	}
+	user.Role = role
+	return nil
+}
+
+// List returns all users
+func (s *UserService) List(ctx context.Context) ([]model.User, error) {
+	users, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if users == nil {
+		users = []model.User{}
+	}
+	return users, nil
+}
+
+// Get returns a user by ID
+func (s *UserService) Get(ctx context.Context, id string) (*model.User, error) {
+	user, err := s.authRepo.GetUserByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
+func (s *UserService) checkAvailable(ctx context.Context, username, email string) error {
+	exists, err := s.authRepo.CheckUsernameExists(ctx, username, "")
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrUsernameTaken
+	}
+	if email != "" {
+		exists, err := s.repo.EmailExists(ctx, email)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return &UserError{Field: "email", Message: "is already used by another user"}
+		}
+	}
+	return nil
+}
+
+// Create adds a user with an initial password. The user is asked to change
+// the credentials on first login, like the bootstrap admin. It also reports
+// whether a breached password was accepted because the request allowed it.
+func (s *UserService) Create(ctx context.Context, req *model.CreateUserRequest) (*model.User, bool, error) {
+	req.Username = strings.TrimSpace(req.Username)
+	req.Email = strings.TrimSpace(req.Email)
+	if err := ValidateNewUser(req.Username, req.Email, req.Role); err != nil {
+		return nil, false, err
+	}
+	breached, err := checkNewPassword(ctx, s.passwordRules, &model.User{Username: req.Username}, req.Password, req.AllowBreachedPassword)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := s.checkAvailable(ctx, req.Username, req.Email); err != nil {
+		return nil, false, err
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, false, err
+	}
+
+	user := &model.User{
+		Username:       req.Username,
+		Email:          req.Email,
+		PasswordHash:   string(hashedPassword),
+		Role:           req.Role,
+		IsInitialSetup: true,
+	}
+	if err := s.repo.Create(ctx, user); err != nil {
+		return nil, false, err
+	}
+	passwordChanged(ctx, s.passwordRules, user.ID, user.PasswordHash)
+	return user, breached, nil
+}
+
+// Invite adds a user without a password and returns a one-time token with
+// which the user sets it through the public accept-invite endpoint
+func (s *UserService) Invite(ctx context.Context, req *model.InviteUserRequest, invitedBy string) (*model.UserInvitationResponse, error) {
+	req.Username = strings.TrimSpace(req.Username)
+	req.Email = strings.TrimSpace(req.Email)
+	if err := ValidateNewUser(req.Username, req.Email, req.Role); err != nil {
+		return nil, err
+	}
+	ttl, err := parseInvitationTTL(req.ExpiresIn)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkAvailable(ctx, req.Username, req.Email); err != nil {
+		return nil, err
+	}
+
+	token, err := generateToken(tokenLength)
+	if err != nil {
+		return nil, err
+	}
+
+	// An empty password hash never matches, so the account can't log in
+	// before the invitation is accepted
+	user := &model.User{
+		Username:       req.Username,
+		Email:          req.Email,
+		Role:           req.Role,
+		IsInitialSetup: true,
+	}
+	if err := s.repo.Create(ctx, user); err != nil {
+		return nil, err
+	}
+
+	invitation := &model.UserInvitation{
+		UserID:    user.ID,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(ttl),
+		CreatedBy: invitedBy,
+	}
+	if err := s.repo.CreateInvitation(ctx, invitation); err != nil {
+		return nil, err
+	}
+
+	return &model.UserInvitationResponse{
+		User:      user,
+		Token:     token,
+		ExpiresAt: invitation.ExpiresAt,
+	}, nil
+}
+
+// AcceptInvite sets the password of an invited user
+func (s *UserService) AcceptInvite(ctx context.Context, req *model.AcceptInviteRequest) (*model.User, error) {
+	if req.Password != req.PasswordConfirm {
+		return nil, ErrPasswordMismatch
+	}
+
+	invitation, err := s.repo.GetInvitationByTokenHash(ctx, hashToken(req.Token))
+	if err != nil {
+		return nil, err
+	}
+	if invitation == nil {
+		return nil, ErrInvalidInvitation
+	}
+	invited, err := s.Get(ctx, invitation.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := checkNewPassword(ctx, s.passwordRules, invited, req.Password, false); err != nil {
+		return nil, err
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.AcceptInvitation(ctx, invitation, string(hashedPassword)); err != nil {
+		return nil, err
+	}
+	passwordChanged(ctx, s.passwordRules, invitation.UserID, string(hashedPassword))
+
+	return s.Get(ctx, invitation.UserID)
+}
+
+// ChangeRole assigns a built-in role to a user
+func (s *UserService) ChangeRole(ctx context.Context, actorID, id, role string) (*model.User, string, error) {
+	if !model.IsValidRole(role) {
+		return nil, "", &UserError{Field: "role", Message: fmt.Sprintf("must be one of %s", strings.Join(model.AllRoles, ", "))}
+	}
+
+	user, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, "", err
+	}
+	oldRole := user.Role
+	if oldRole == role {
+		return user, oldRole, nil
+	}
+	if id == actorID {
+		return nil, "", ErrSelfModification
+	}
+	if err := s.guardLastAdmin(ctx, user); err != nil {
+		return nil, "", err
+	}
+
+	if err := s.repo.UpdateRole(ctx, id, role); err != nil {
+		return nil, "", err
+	}
+	user.Role = role
+	return user, oldRole, nil
+}
+
+// SetDisabled disables or re-enables a user. Disabling ends all of its sessions.
+func (s *UserService) SetDisabled(ctx context.Context, actorID, id string, disabled bool) (*model.User, error) {
+	user, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if user.Disabled == disabled {
+		return user, nil
+	}
+	if disabled {
+		if id == actorID {
+			return nil, ErrSelfModification
+		}
+		if err := s.guardLastAdmin(ctx, user); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.repo.SetDisabled(ctx, id, disabled); err != nil {
+		return nil, err
+	}
+	if disabled {
+		if err := s.authRepo.DeleteUserSessions(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+	user.Disabled = disabled
+	return user, nil
+}
+
+// Reset2FA removes the TOTP secret, backup codes and security keys of a user
+// who lost the device
+func (s *UserService) Reset2FA(ctx context.Context, id string) (*model.User, error) {
+	user, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	removedKeys, err := s.webauthnRepo.DeleteUserCredentials(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TOTPEnabled {
+		if removedKeys == 0 {
+			return nil, Err2FANotEnabled
+		}
+		return user, nil
+	}
+
+	if err := s.authRepo.DisableTOTP(ctx, id); err != nil {
+		return nil, err
+	}
+	user.TOTPEnabled = false
+	return user, nil
+}
+
+// Delete removes a user
+func (s *UserService) Delete(ctx context.Context, actorID, id string) (*model.User, error) {
+	user, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if id == actorID {
+		return nil, ErrSelfModification
+	}
+	if err := s.guardLastAdmin(ctx, user); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *UserService) guardLastAdmin(ctx context.Context, user *model.User) error {
+	if user.Role != model.RoleAdmin || user.Disabled {
+		return nil
+	}
+	count, err := s.repo.CountEnabledAdmins(ctx)
+	if err != nil {
+		return err
+	}
+	return checkAdminRemoval(user, count)
+}
+
+// Roles returns the built-in roles with their permissions
+func Roles() []model.RoleInfo {
+	roles := make([]model.RoleInfo, 0, len(model.AllRoles))
+	for _, role := range model.AllRoles {
+		roles = append(roles, model.RoleInfo{Name: role, Permissions: model.RolePermissions[role]})
+	}
+	return roles
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"nginx-proxy-guard/internal/model"
+)
+
+func TestRolePermissions(t *testing.T) {
+	tests := []struct {
+		role, permission string
+		want             bool
+	}{
+		{model.RoleAdmin, model.PermissionBackupRestore, true},
+		{model.RoleAdmin, model.PermissionUserWrite, true},
+		{model.RoleOperator, model.PermissionProxyWrite, true},
+		{model.RoleOperator, model.PermissionBackupCreate, true},
+		{model.RoleOperator, model.PermissionBackupRestore, false},
+		{model.RoleOperator, model.PermissionUserRead, false},
+		{model.RoleSecurityAnalyst, model.PermissionWAFWrite, true},
+		{model.RoleSecurityAnalyst, model.PermissionProxyWrite, false},
+		{model.RoleViewer, model.PermissionLogsRead, true},
+		{model.RoleViewer, model.PermissionWAFWrite, false},
+		{"user", model.PermissionProxyRead, false},
+		{"", model.PermissionProxyRead, false},
+	}
+	for _, tt := range tests {
+		if got := model.RoleHasPermission(tt.role, tt.permission); got != tt.want {
+			t.Errorf("RoleHasPermission(%q, %q) = %v, want %v", tt.role, tt.permission, got, tt.want)
+		}
+	}
+}
+
+func TestValidateNewUser(t *testing.T) {
+	if err := ValidateNewUser("alice", "alice@example.com", model.RoleOperator); err != nil {
+		t.Errorf("valid user rejected: %v", err)
+	}
+
+	tests := []struct {
+		username, email, role, field string
+	}{
+		{"al", "", model.RoleViewer, "username"},
+		{"alice smith", "", model.RoleViewer, "username"},
+		{"alice", "not-an-email", model.RoleViewer, "email"},
+		{"alice", "", "superuser", "role"},
+		{"alice", "", "security-analyst", "role"},
+	}
+	for _, tt := range tests {
+		err := ValidateNewUser(tt.username, tt.email, tt.role)
+		var uerr *UserError
+		if !errors.As(err, &uerr) || uerr.Field != tt.field {
+			t.Errorf("ValidateNewUser(%q, %q, %q) = %v, want error on %s", tt.username, tt.email, tt.role, err, tt.field)
+		}
+	}
+}
+
+func TestParseInvitationTTL(t *testing.T) {
+	tests := map[string]time.Duration{
+		"":    defaultInvitationTTL,
+		"24h": 24 * time.Hour,
+		"7d":  7 * 24 * time.Hour,
+	}
+	for value, want := range tests {
+		if got, err := parseInvitationTTL(value); err != nil || got != want {
+			t.Errorf("parseInvitationTTL(%q) = %v, %v, want %v", value, got, err, want)
+		}
+	}
+
+	for _, value := range []string{"10m", "31d", "soon"} {
+		if _, err := parseInvitationTTL(value); err == nil {
+			t.Errorf("parseInvitationTTL(%q) accepted", value)
+		}
+	}
+}
+
+func TestCheckAdminRemoval(t *testing.T) {
+	admin := &model.User{Role: model.RoleAdmin}
+	if err := checkAdminRemoval(admin, 1); !errors.Is(err, ErrLastAdmin) {
+		t.Errorf("removing the last admin = %v, want ErrLastAdmin", err)
+	}
+	if err := checkAdminRemoval(admin, 2); err != nil {
+		t.Errorf("removing one of two admins = %v", err)
+	}
+	if err := checkAdminRemoval(&model.User{Role: model.RoleAdmin, Disabled: true}, 1); err != nil {
+		t.Errorf("removing a disabled admin = %v", err)
+	}
+	if err := checkAdminRemoval(&model.User{Role: model.RoleViewer}, 1); err != nil {
+		t.Errorf("removing a viewer = %v", err)
+	}
+}
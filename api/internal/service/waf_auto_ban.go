@@ -22,6 +22,10 @@ type WAFAutoBanService struct {
 	proxyHostRepo    *repository.ProxyHostRepository
 	proxyHostService *ProxyHostService
 	historyRepo      *repository.IPBanHistoryRepository
+	banNotifier      BanNotifier
+	banEscalation    *BanEscalationService
+	banAggregation   *BanAggregationService
+	trustedIPs       *TrustedIPService
 
 	// In-memory tracking of WAF events per IP
 	mu          sync.RWMutex
@@ -59,6 +63,26 @@ func NewWAFAutoBanService(
 	}
 }
 
+// SetBanNotifier sets the notifier called after each ban (e.g. CrowdSec signal sharing)
+func (s *WAFAutoBanService) SetBanNotifier(notifier BanNotifier) {
+	s.banNotifier = notifier
+}
+
+// SetBanEscalation enables escalating ban durations for repeat offenders
+func (s *WAFAutoBanService) SetBanEscalation(escalation *BanEscalationService) {
+	s.banEscalation = escalation
+}
+
+// SetBanAggregation enables replacing sibling bans with a single subnet ban
+func (s *WAFAutoBanService) SetBanAggregation(aggregation *BanAggregationService) {
+	s.banAggregation = aggregation
+}
+
+// SetTrustedIPs makes bans skip addresses in the global trusted IP allowlist
+func (s *WAFAutoBanService) SetTrustedIPs(trustedIPs *TrustedIPService) {
+	s.trustedIPs = trustedIPs
+}
+
 // Start begins the auto-ban service background tasks
 func (s *WAFAutoBanService) Start(ctx context.Context) {
 	// Load initial settings
@@ -187,6 +211,25 @@ func (s *WAFAutoBanService) isIPBanned(ctx context.Context, ip string) bool {
 
 // banIP adds an IP to the banned list
 func (s *WAFAutoBanService) banIP(ctx context.Context, ip string, host string, reason string, failCount int, durationSeconds int) error {
+	// Never ban addresses in the global trusted IP allowlist
+	if s.trustedIPs != nil && s.trustedIPs.PreventBan(ctx, ip, model.BanSourceWAFAutoBan, reason, nil) {
+		return nil
+	}
+
+	// Already blocked by an aggregated subnet ban
+	if s.banAggregation != nil {
+		if cidr := s.banAggregation.CoveringSubnet(ctx, ip, nil); cidr != "" {
+			log.Printf("[WAF Auto-Ban] %s is already covered by subnet ban %s", ip, cidr)
+			return nil
+		}
+	}
+
+	// Repeat offenders get a longer ban (must run before this ban is recorded in history)
+	var escalation *model.BanEscalation
+	if s.banEscalation != nil {
+		durationSeconds, escalation = s.banEscalation.Resolve(ctx, ip, durationSeconds)
+	}
+
 	id := uuid.New().String()
 	now := time.Now()
 
@@ -211,7 +254,8 @@ func (s *WAFAutoBanService) banIP(ctx context.Context, ip string, host string, r
 			banned_at = EXCLUDED.banned_at,
 			expires_at = EXCLUDED.expires_at,
 			is_permanent = EXCLUDED.is_permanent,
-			is_auto_banned = true
+			is_auto_banned = true,
+			origin = 'local'
 	`
 
 	_, err := s.db.ExecContext(ctx, query, id, ip, reason, failCount, now, expiresAt, isPermanent)
@@ -233,11 +277,29 @@ func (s *WAFAutoBanService) banIP(ctx context.Context, ip string, host string, r
 			IsAuto:      true,
 			FailCount:   &failCount,
 		}
+		if escalation != nil {
+			historyEvent.EscalationLevel = &escalation.Level
+			historyEvent.EscalationReason = escalation.Explanation
+		}
 		if err := s.historyRepo.RecordBanEvent(ctx, historyEvent); err != nil {
 			log.Printf("[WAF Auto-Ban] Warning: Failed to record ban history: %v", err)
 		}
 	}
 
+	if s.banNotifier != nil {
+		s.banNotifier.NotifyBan(ip, model.BanSourceWAFAutoBan, reason, time.Duration(durationSeconds)*time.Second)
+	}
+
+	// Collapse sibling bans from the same prefix into one subnet ban
+	if s.banAggregation != nil {
+		subnetBan, err := s.banAggregation.Aggregate(ctx, ip, nil, model.BanSourceWAFAutoBan)
+		if err != nil {
+			log.Printf("[WAF Auto-Ban] Warning: Subnet aggregation failed: %v", err)
+		} else if subnetBan != nil && s.banNotifier != nil {
+			s.banNotifier.NotifyBan(subnetBan.IPAddress, model.BanSourceWAFAutoBan, subnetBan.Reason, banRemaining(subnetBan))
+		}
+	}
+
 	// Regenerate nginx configs for all enabled hosts (global ban)
 	if s.proxyHostService != nil && s.proxyHostRepo != nil {
 		hosts, _, err := s.proxyHostRepo.List(ctx, 1, 1000, "", "", "")
@@ -323,13 +385,13 @@ func (s *WAFAutoBanService) cleanupOldEvents() {
 
 // cleanupExpiredBans removes expired bans from the database and regenerates nginx configs
 // Note: This only handles bans with proxy_host_id IS NULL (global bans from WAF)
-// Fail2ban service handles bans with specific proxy_host_id
+// Fail2ban service handles bans with specific proxy_host_id, CrowdSec service handles its own decisions
 func (s *WAFAutoBanService) cleanupExpiredBans(ctx context.Context) {
 	// Get expired global bans before deleting (for history recording)
 	selectQuery := `
 		SELECT id, ip_address, reason FROM banned_ips
 		WHERE expires_at IS NOT NULL AND expires_at < NOW()
-		AND proxy_host_id IS NULL
+		AND proxy_host_id IS NULL AND origin <> 'crowdsec'
 	`
 	rows, err := s.db.QueryContext(ctx, selectQuery)
 	if err != nil {
@@ -379,7 +441,7 @@ func (s *WAFAutoBanService) cleanupExpiredBans(ctx context.Context) {
 	deleteQuery := `
 		DELETE FROM banned_ips
 		WHERE expires_at IS NOT NULL AND expires_at < NOW()
-		AND proxy_host_id IS NULL
+		AND proxy_host_id IS NULL AND origin <> 'crowdsec'
 	`
 	result, err := s.db.ExecContext(ctx, deleteQuery)
 	if err != nil {
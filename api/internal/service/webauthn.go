@@ -0,0 +1,736 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+
+	"nginx-proxy-guard/internal/model"
+	"nginx-proxy-guard/internal/repository"
+)
+
+var (
+	ErrWebAuthnDisabled           = errors.New("security keys are not enabled")
+	ErrPasswordlessDisabled       = errors.New("passwordless login is not enabled")
+	ErrInvalidWebAuthnCeremony    = errors.New("invalid or expired security key request")
+	ErrWebAuthnVerification       = errors.New("security key verification failed")
+	ErrWebAuthnOrigin             = errors.New("origin is not allowed for security keys")
+	ErrSecurityKeyRequired        = errors.New("a hardware security key is required for this account")
+	ErrLastSecurityKey            = errors.New("the last hardware security key of an admin can't be removed while hardware keys are required")
+	ErrWebAuthnCredentialNotFound = errors.New("security key not found")
+)
+
+const (
+	// How long the browser has to answer a registration or login ceremony
+	webauthnCeremonyLifetime = 5 * time.Minute
+	// Security keys a user may register
+	maxWebAuthnCredentials = 20
+	// Relying party name shown by browsers when none is configured
+	defaultWebAuthnRPName = "Nginx Proxy Guard"
+
+	webauthnCeremonyRegister = "register"
+	webauthnCeremonyLogin    = "login"
+	webauthnCeremonyPasskey  = "passkey"
+)
+
+// webauthnCeremony is a registration or login waiting for the browser's answer
+type webauthnCeremony struct {
+	kind      string
+	userID    string
+	name      string // Name of the credential being registered
+	tempToken string // Password login completed by this ceremony
+	rp        *webauthn.WebAuthn
+	session   webauthn.SessionData
+	expiresAt time.Time
+}
+
+// webauthnUser adapts a user and their credentials to the WebAuthn library.
+// The user ID is the user handle, so passkeys resolve to the account directly.
+type webauthnUser struct {
+	user        *model.User
+	credentials []model.WebAuthnCredential
+}
+
+func (u *webauthnUser) WebAuthnID() []byte          { return []byte(u.user.ID) }
+func (u *webauthnUser) WebAuthnName() string        { return u.user.Username }
+func (u *webauthnUser) WebAuthnDisplayName() string { return u.user.Username }
+func (u *webauthnUser) WebAuthnIcon() string        { return "" }
+
+func (u *webauthnUser) WebAuthnCredentials() []webauthn.Credential {
+	credentials := make([]webauthn.Credential, 0, len(u.credentials))
+	for i := range u.credentials {
+		credentials = append(credentials, toWebAuthnCredential(&u.credentials[i]))
+	}
+	return credentials
+}
+
+// find returns the stored credential with the given credential ID
+func (u *webauthnUser) find(credentialID []byte) *model.WebAuthnCredential {
+	for i := range u.credentials {
+		if bytes.Equal(u.credentials[i].CredentialID, credentialID) {
+			return &u.credentials[i]
+		}
+	}
+	return nil
+}
+
+// WebAuthnService registers security keys and passkeys and verifies them as
+// second factor of a password login or, when allowed, as passwordless login
+type WebAuthnService struct {
+	repo     *repository.WebAuthnRepository
+	authRepo *repository.AuthRepository
+	auth     *AuthService
+
+	ceremonyMu sync.Mutex
+	ceremonies map[string]*webauthnCeremony
+}
+
+func NewWebAuthnService(repo *repository.WebAuthnRepository, authRepo *repository.AuthRepository, auth *AuthService) *WebAuthnService {
+	return &WebAuthnService{
+		repo:       repo,
+		authRepo:   authRepo,
+		auth:       auth,
+		ceremonies: make(map[string]*webauthnCeremony),
+	}
+}
+
+// GetSettings returns the WebAuthn settings
+func (s *WebAuthnService) GetSettings(ctx context.Context) (*model.WebAuthnSettings, error) {
+	return s.repo.GetSettings(ctx)
+}
+
+// UpdateSettings validates and stores the WebAuthn settings. Requiring hardware
+// keys for admins needs the acting admin to have one, so they can't lock themselves out.
+func (s *WebAuthnService) UpdateSettings(ctx context.Context, actor *model.User, req *model.UpdateWebAuthnSettingsRequest) (*model.WebAuthnSettings, error) {
+	settings, err := s.repo.GetSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	wasRequired := settings.Enabled && settings.RequireHardwareKeyForAdmins
+
+	mergeWebAuthnSettings(settings, req)
+	if err := normalizeWebAuthnSettings(settings); err != nil {
+		return nil, err
+	}
+
+	if settings.RequireHardwareKeyForAdmins && !wasRequired && actor.Role == model.RoleAdmin {
+		credentials, err := s.repo.ListCredentials(ctx, actor.ID)
+		if err != nil {
+			return nil, err
+		}
+		if len(hardwareKeys(credentials)) == 0 {
+			return nil, &UserError{Field: "require_hardware_key_for_admins", Message: "register a hardware security key for your own account first"}
+		}
+	}
+
+	return s.repo.SaveSettings(ctx, settings)
+}
+
+// Status tells the login page which WebAuthn logins to offer
+func (s *WebAuthnService) Status(ctx context.Context) (*model.WebAuthnStatus, error) {
+	settings, err := s.repo.GetSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &model.WebAuthnStatus{
+		Enabled:           settings.Enabled,
+		AllowPasswordless: settings.Enabled && settings.AllowPasswordless,
+	}, nil
+}
+
+// SecondFactors implements SecondFactorPolicy
+func (s *WebAuthnService) SecondFactors(ctx context.Context, user *model.User) (bool, bool, error) {
+	settings, err := s.repo.GetSettings(ctx)
+	if err != nil {
+		return false, false, err
+	}
+	if !settings.Enabled {
+		return false, true, nil
+	}
+
+	credentials, err := s.repo.ListCredentials(ctx, user.ID)
+	if err != nil {
+		return false, false, err
+	}
+	if requiresHardwareKey(settings, user) {
+		if len(hardwareKeys(credentials)) == 0 {
+			return false, false, ErrSecurityKeyRequired
+		}
+		return true, false, nil
+	}
+	return len(credentials) > 0, true, nil
+}
+
+// === Credential management ===
+
+// ListCredentials returns the security keys registered by a user
+func (s *WebAuthnService) ListCredentials(ctx context.Context, userID string) ([]model.WebAuthnCredential, error) {
+	return s.repo.ListCredentials(ctx, userID)
+}
+
+// BeginRegistration starts registering a new security key or passkey
+func (s *WebAuthnService) BeginRegistration(ctx context.Context, user *model.User, name, origin string) (*model.WebAuthnCeremony, error) {
+	settings, err := s.repo.GetSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !settings.Enabled {
+		return nil, ErrWebAuthnDisabled
+	}
+
+	credentials, err := s.repo.ListCredentials(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	name, err = validateWebAuthnName(name, "", credentials)
+	if err != nil {
+		return nil, err
+	}
+	if len(credentials) >= maxWebAuthnCredentials {
+		return nil, &UserError{Field: "name", Message: fmt.Sprintf("at most %d security keys can be registered", maxWebAuthnCredentials)}
+	}
+
+	rp, err := newRelyingParty(settings, origin)
+	if err != nil {
+		return nil, err
+	}
+	wuser := &webauthnUser{user: user, credentials: credentials}
+	exclusions := make([]protocol.CredentialDescriptor, 0, len(credentials))
+	for _, c := range wuser.WebAuthnCredentials() {
+		exclusions = append(exclusions, c.Descriptor())
+	}
+
+	// Direct attestation tells hardware keys apart from software authenticators
+	creation, session, err := rp.BeginRegistration(wuser,
+		webauthn.WithExclusions(exclusions),
+		webauthn.WithResidentKeyRequirement(protocol.ResidentKeyRequirementPreferred),
+		webauthn.WithConveyancePreference(protocol.PreferDirectAttestation),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start security key registration: %w", err)
+	}
+
+	id, err := s.startCeremony(&webauthnCeremony{kind: webauthnCeremonyRegister, userID: user.ID, name: name, rp: rp, session: *session})
+	if err != nil {
+		return nil, err
+	}
+	return &model.WebAuthnCeremony{CeremonyID: id, Options: creation}, nil
+}
+
+// FinishRegistration verifies the browser's answer and stores the new credential
+func (s *WebAuthnService) FinishRegistration(ctx context.Context, user *model.User, req *model.WebAuthnFinishRequest) (*model.WebAuthnCredential, error) {
+	ceremony, err := s.takeCeremony(req.CeremonyID, webauthnCeremonyRegister)
+	if err != nil {
+		return nil, err
+	}
+	if ceremony.userID != user.ID {
+		return nil, ErrInvalidWebAuthnCeremony
+	}
+
+	parsed, err := protocol.ParseCredentialCreationResponseBody(bytes.NewReader(req.Credential))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrWebAuthnVerification, protocolErrorDetail(err))
+	}
+	credentials, err := s.repo.ListCredentials(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	created, err := ceremony.rp.CreateCredential(&webauthnUser{user: user, credentials: credentials}, ceremony.session, parsed)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrWebAuthnVerification, protocolErrorDetail(err))
+	}
+
+	credential := newWebAuthnCredential(user.ID, ceremony.name, created)
+	if err := s.repo.CreateCredential(ctx, credential); err != nil {
+		return nil, err
+	}
+	return credential, nil
+}
+
+// RenameCredential renames a security key of a user
+func (s *WebAuthnService) RenameCredential(ctx context.Context, userID, id, name string) (*model.WebAuthnCredential, error) {
+	credentials, err := s.repo.ListCredentials(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	name, err = validateWebAuthnName(name, id, credentials)
+	if err != nil {
+		return nil, err
+	}
+
+	found, err := s.repo.RenameCredential(ctx, userID, id, name)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, ErrWebAuthnCredentialNotFound
+	}
+	for i := range credentials {
+		if credentials[i].ID == id {
+			credentials[i].Name = name
+			return &credentials[i], nil
+		}
+	}
+	return nil, ErrWebAuthnCredentialNotFound
+}
+
+// DeleteCredential revokes a security key of a user. Admins keep their last
+// hardware key while hardware keys are required.
+func (s *WebAuthnService) DeleteCredential(ctx context.Context, user *model.User, id string) (*model.WebAuthnCredential, error) {
+	credentials, err := s.repo.ListCredentials(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	var target *model.WebAuthnCredential
+	for i := range credentials {
+		if credentials[i].ID == id {
+			target = &credentials[i]
+		}
+	}
+	if target == nil {
+		return nil, ErrWebAuthnCredentialNotFound
+	}
+
+	settings, err := s.repo.GetSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if requiresHardwareKey(settings, user) && target.HardwareKey && len(hardwareKeys(credentials)) == 1 {
+		return nil, ErrLastSecurityKey
+	}
+
+	found, err := s.repo.DeleteCredential(ctx, user.ID, id)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, ErrWebAuthnCredentialNotFound
+	}
+	return target, nil
+}
+
+// === Login ===
+
+// BeginLogin starts the security key step of a password login that answered Requires2FA
+func (s *WebAuthnService) BeginLogin(ctx context.Context, tempToken, origin string) (*model.WebAuthnCeremony, error) {
+	pending, err := s.auth.pendingLogin(tempToken)
+	if err != nil {
+		return nil, err
+	}
+	settings, err := s.repo.GetSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !settings.Enabled {
+		return nil, ErrWebAuthnDisabled
+	}
+
+	wuser, err := s.loadUser(ctx, settings, pending.userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(wuser.credentials) == 0 {
+		return nil, ErrSecurityKeyRequired
+	}
+
+	rp, err := newRelyingParty(settings, origin)
+	if err != nil {
+		return nil, err
+	}
+	assertion, session, err := rp.BeginLogin(wuser)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start security key login: %w", err)
+	}
+
+	id, err := s.startCeremony(&webauthnCeremony{kind: webauthnCeremonyLogin, userID: wuser.user.ID, tempToken: tempToken, rp: rp, session: *session})
+	if err != nil {
+		return nil, err
+	}
+	return &model.WebAuthnCeremony{CeremonyID: id, Options: assertion}, nil
+}
+
+// FinishLogin verifies the security key and creates the session of the password login
+func (s *WebAuthnService) FinishLogin(ctx context.Context, req *model.WebAuthnFinishRequest, ip string) (*model.LoginResponse, error) {
+	ceremony, err := s.takeCeremony(req.CeremonyID, webauthnCeremonyLogin)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.auth.completeTwoFactor(ctx, ceremony.tempToken, func(user *model.User, _ *tempTokenData) error {
+		if user.ID != ceremony.userID {
+			return ErrInvalidWebAuthnCeremony
+		}
+		settings, err := s.repo.GetSettings(ctx)
+		if err != nil {
+			return err
+		}
+		wuser, err := s.loadUser(ctx, settings, user.ID)
+		if err != nil {
+			return err
+		}
+
+		parsed, err := protocol.ParseCredentialRequestResponseBody(bytes.NewReader(req.Credential))
+		if err == nil {
+			var used *webauthn.Credential
+			if used, err = ceremony.rp.ValidateLogin(wuser, ceremony.session, parsed); err == nil {
+				return s.recordUse(ctx, wuser, used)
+			}
+		}
+		s.authRepo.RecordLoginAttempt(ctx, ip, user.Username, false)
+		return fmt.Errorf("%w: %s", ErrWebAuthnVerification, protocolErrorDetail(err))
+	})
+}
+
+// BeginPasskeyLogin starts a passwordless login with a discoverable credential
+func (s *WebAuthnService) BeginPasskeyLogin(ctx context.Context, origin string) (*model.WebAuthnCeremony, error) {
+	settings, err := s.repo.GetSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !settings.Enabled || !settings.AllowPasswordless {
+		return nil, ErrPasswordlessDisabled
+	}
+
+	rp, err := newRelyingParty(settings, origin)
+	if err != nil {
+		return nil, err
+	}
+	// Without a password the authenticator must verify the user itself
+	assertion, session, err := rp.BeginDiscoverableLogin(webauthn.WithUserVerification(protocol.VerificationRequired))
+	if err != nil {
+		return nil, fmt.Errorf("failed to start passkey login: %w", err)
+	}
+
+	id, err := s.startCeremony(&webauthnCeremony{kind: webauthnCeremonyPasskey, rp: rp, session: *session})
+	if err != nil {
+		return nil, err
+	}
+	return &model.WebAuthnCeremony{CeremonyID: id, Options: assertion}, nil
+}
+
+// FinishPasskeyLogin verifies a passkey and creates a session for its owner
+func (s *WebAuthnService) FinishPasskeyLogin(ctx context.Context, req *model.WebAuthnFinishRequest, ip, userAgent string) (*model.LoginResponse, error) {
+	if err := s.auth.checkLoginAttempts(ctx, ip, ""); err != nil {
+		return nil, err
+	}
+	ceremony, err := s.takeCeremony(req.CeremonyID, webauthnCeremonyPasskey)
+	if err != nil {
+		return nil, err
+	}
+	settings, err := s.repo.GetSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !settings.Enabled || !settings.AllowPasswordless {
+		return nil, ErrPasswordlessDisabled
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBody(bytes.NewReader(req.Credential))
+	if err != nil {
+		s.authRepo.RecordLoginAttempt(ctx, ip, "", false)
+		return nil, fmt.Errorf("%w: %s", ErrWebAuthnVerification, protocolErrorDetail(err))
+	}
+
+	var wuser *webauthnUser
+	used, err := ceremony.rp.ValidateDiscoverableLogin(func(_, userHandle []byte) (webauthn.User, error) {
+		u, err := s.loadUser(ctx, settings, string(userHandle))
+		if err != nil {
+			return nil, err
+		}
+		wuser = u
+		return u, nil
+	}, ceremony.session, parsed)
+	if err != nil {
+		username := ""
+		if wuser != nil {
+			username = wuser.user.Username
+		}
+		s.authRepo.RecordLoginAttempt(ctx, ip, username, false)
+		return nil, fmt.Errorf("%w: %s", ErrWebAuthnVerification, protocolErrorDetail(err))
+	}
+
+	user := wuser.user
+	if user.Disabled {
+		s.authRepo.RecordLoginAttempt(ctx, ip, user.Username, false)
+		return nil, ErrAccountDisabled
+	}
+	if !s.auth.localLoginAllowed(ctx, user.Username) {
+		return nil, ErrLocalLoginDisabled
+	}
+	if err := s.auth.checkLoginAttempts(ctx, ip, user.Username); err != nil {
+		return nil, err
+	}
+	if err := s.recordUse(ctx, wuser, used); err != nil {
+		return nil, err
+	}
+
+	return s.auth.createSession(ctx, user, ip, userAgent)
+}
+
+// loadUser returns a user with the credentials they may log in with: only
+// hardware keys when the policy requires them for the user
+func (s *WebAuthnService) loadUser(ctx context.Context, settings *model.WebAuthnSettings, userID string) (*webauthnUser, error) {
+	user, err := s.authRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUnauthorized
+	}
+	credentials, err := s.repo.ListCredentials(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if requiresHardwareKey(settings, user) {
+		credentials = hardwareKeys(credentials)
+	}
+	return &webauthnUser{user: user, credentials: credentials}, nil
+}
+
+// recordUse stores the signature counter and backup state of a used credential
+func (s *WebAuthnService) recordUse(ctx context.Context, wuser *webauthnUser, used *webauthn.Credential) error {
+	credential := wuser.find(used.ID)
+	if credential == nil {
+		return ErrWebAuthnCredentialNotFound
+	}
+	credential.SignCount = used.Authenticator.SignCount
+	credential.BackupState = used.Flags.BackupState
+	if used.Authenticator.CloneWarning && !credential.CloneWarning {
+		log.Printf("[WebAuthn] Signature counter of security key %q of %s went backwards, the key may be cloned", credential.Name, wuser.user.Username)
+		credential.CloneWarning = true
+	}
+	return s.repo.RecordUse(ctx, credential)
+}
+
+// startCeremony stores a ceremony until the browser answers and returns its ID
+func (s *WebAuthnService) startCeremony(ceremony *webauthnCeremony) (string, error) {
+	id, err := generateToken(tokenLength)
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	ceremony.expiresAt = now.Add(webauthnCeremonyLifetime)
+
+	s.ceremonyMu.Lock()
+	defer s.ceremonyMu.Unlock()
+	for key, c := range s.ceremonies {
+		if now.After(c.expiresAt) {
+			delete(s.ceremonies, key)
+		}
+	}
+	s.ceremonies[id] = ceremony
+	return id, nil
+}
+
+// takeCeremony removes and returns a pending ceremony; each can be answered once
+func (s *WebAuthnService) takeCeremony(id, kind string) (*webauthnCeremony, error) {
+	s.ceremonyMu.Lock()
+	defer s.ceremonyMu.Unlock()
+
+	ceremony, ok := s.ceremonies[id]
+	if !ok || ceremony.kind != kind {
+		return nil, ErrInvalidWebAuthnCeremony
+	}
+	delete(s.ceremonies, id)
+	if time.Now().After(ceremony.expiresAt) {
+		return nil, ErrInvalidWebAuthnCeremony
+	}
+	return ceremony, nil
+}
+
+// newRelyingParty configures the library for a ceremony started from origin.
+// Without a configured RP ID the credentials are bound to the origin's host.
+func newRelyingParty(settings *model.WebAuthnSettings, origin string) (*webauthn.WebAuthn, error) {
+	originURL, err := url.Parse(origin)
+	if err != nil || originURL.Hostname() == "" {
+		return nil, ErrWebAuthnOrigin
+	}
+	host := strings.ToLower(originURL.Hostname())
+
+	rpID := settings.RPID
+	if rpID == "" {
+		rpID = host
+	}
+	origins := settings.RPOrigins
+	if len(origins) == 0 {
+		if host != rpID && !strings.HasSuffix(host, "."+rpID) {
+			return nil, ErrWebAuthnOrigin
+		}
+		origins = []string{originURL.Scheme + "://" + originURL.Host}
+	}
+
+	rpName := settings.RPName
+	if rpName == "" {
+		rpName = defaultWebAuthnRPName
+	}
+	return webauthn.New(&webauthn.Config{
+		RPID:          rpID,
+		RPDisplayName: rpName,
+		RPOrigins:     origins,
+		Timeouts: webauthn.TimeoutsConfig{
+			Login:        webauthn.TimeoutConfig{Enforce: true, Timeout: webauthnCeremonyLifetime, TimeoutUVD: webauthnCeremonyLifetime},
+			Registration: webauthn.TimeoutConfig{Enforce: true, Timeout: webauthnCeremonyLifetime, TimeoutUVD: webauthnCeremonyLifetime},
+		},
+	})
+}
+
+// requiresHardwareKey reports whether the policy requires a hardware key for the user
+func requiresHardwareKey(settings *model.WebAuthnSettings, user *model.User) bool {
+	return settings.Enabled && settings.RequireHardwareKeyForAdmins && user.Role == model.RoleAdmin
+}
+
+// hardwareKeys returns the credentials that are hardware security keys
+func hardwareKeys(credentials []model.WebAuthnCredential) []model.WebAuthnCredential {
+	var keys []model.WebAuthnCredential
+	for _, c := range credentials {
+		if c.HardwareKey {
+			keys = append(keys, c)
+		}
+	}
+	return keys
+}
+
+func toWebAuthnCredential(c *model.WebAuthnCredential) webauthn.Credential {
+	transports := make([]protocol.AuthenticatorTransport, 0, len(c.Transports))
+	for _, t := range c.Transports {
+		transports = append(transports, protocol.AuthenticatorTransport(t))
+	}
+	return webauthn.Credential{
+		ID:              c.CredentialID,
+		PublicKey:       c.PublicKey,
+		AttestationType: c.AttestationType,
+		Transport:       transports,
+		Flags: webauthn.CredentialFlags{
+			BackupEligible: c.BackupEligible,
+			BackupState:    c.BackupState,
+		},
+		Authenticator: webauthn.Authenticator{
+			AAGUID:       c.AAGUID,
+			SignCount:    c.SignCount,
+			CloneWarning: c.CloneWarning,
+			Attachment:   protocol.AuthenticatorAttachment(c.Attachment),
+		},
+	}
+}
+
+func newWebAuthnCredential(userID, name string, c *webauthn.Credential) *model.WebAuthnCredential {
+	transports := make([]string, 0, len(c.Transport))
+	for _, t := range c.Transport {
+		transports = append(transports, string(t))
+	}
+	credential := &model.WebAuthnCredential{
+		UserID:          userID,
+		Name:            name,
+		CredentialID:    c.ID,
+		PublicKey:       c.PublicKey,
+		AttestationType: c.AttestationType,
+		Transports:      transports,
+		AAGUID:          c.Authenticator.AAGUID,
+		SignCount:       c.Authenticator.SignCount,
+		BackupEligible:  c.Flags.BackupEligible,
+		BackupState:     c.Flags.BackupState,
+		Attachment:      string(c.Authenticator.Attachment),
+	}
+	credential.HardwareKey = credential.IsHardwareKey()
+	return credential
+}
+
+// validateWebAuthnName checks a credential name is set and unique for the user
+func validateWebAuthnName(name, id string, credentials []model.WebAuthnCredential) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > 100 {
+		return "", &UserError{Field: "name", Message: "must be 1-100 characters"}
+	}
+	for _, c := range credentials {
+		if c.ID != id && strings.EqualFold(c.Name, name) {
+			return "", &UserError{Field: "name", Message: "is already used by another security key"}
+		}
+	}
+	return name, nil
+}
+
+// protocolErrorDetail returns the detail of a WebAuthn protocol error, which
+// says which check failed rather than just "bad request"
+func protocolErrorDetail(err error) string {
+	var perr *protocol.Error
+	if errors.As(err, &perr) && perr.Details != "" {
+		return perr.Details
+	}
+	if err == nil {
+		return "no response"
+	}
+	return err.Error()
+}
+
+func mergeWebAuthnSettings(s *model.WebAuthnSettings, req *model.UpdateWebAuthnSettingsRequest) {
+	if req.Enabled != nil {
+		s.Enabled = *req.Enabled
+	}
+	if req.RPID != nil {
+		s.RPID = *req.RPID
+	}
+	if req.RPName != nil {
+		s.RPName = *req.RPName
+	}
+	if req.RPOrigins != nil {
+		s.RPOrigins = *req.RPOrigins
+	}
+	if req.AllowPasswordless != nil {
+		s.AllowPasswordless = *req.AllowPasswordless
+	}
+	if req.RequireHardwareKeyForAdmins != nil {
+		s.RequireHardwareKeyForAdmins = *req.RequireHardwareKeyForAdmins
+	}
+}
+
+// normalizeWebAuthnSettings validates the settings and fills in defaults
+func normalizeWebAuthnSettings(s *model.WebAuthnSettings) error {
+	s.RPID = strings.ToLower(strings.TrimSpace(s.RPID))
+	if s.RPID != "" && (strings.ContainsAny(s.RPID, ":/ ") || net.ParseIP(s.RPID) != nil) {
+		return &UserError{Field: "rp_id", Message: "must be a domain name without scheme or port"}
+	}
+
+	s.RPName = strings.TrimSpace(s.RPName)
+	if s.RPName == "" {
+		s.RPName = defaultWebAuthnRPName
+	}
+	if len(s.RPName) > 255 {
+		return &UserError{Field: "rp_name", Message: "must be at most 255 characters"}
+	}
+
+	origins := []string{}
+	for _, origin := range s.RPOrigins {
+		origin = strings.TrimRight(strings.TrimSpace(origin), "/")
+		if origin == "" {
+			continue
+		}
+		u, err := url.Parse(origin)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" || u.Path != "" || u.RawQuery != "" {
+			return &UserError{Field: "rp_origins", Message: fmt.Sprintf("%q must be an origin like https://npg.example.com", origin)}
+		}
+		if s.RPID == "" {
+			return &UserError{Field: "rp_origins", Message: "requires an RP ID"}
+		}
+		host := strings.ToLower(u.Hostname())
+		if host != s.RPID && !strings.HasSuffix(host, "."+s.RPID) {
+			return &UserError{Field: "rp_origins", Message: fmt.Sprintf("%q is not within the RP ID %s", origin, s.RPID)}
+		}
+		origins = append(origins, strings.ToLower(u.Scheme)+"://"+strings.ToLower(u.Host))
+	}
+	s.RPOrigins = origins
+
+	if s.RequireHardwareKeyForAdmins && !s.Enabled {
+		return &UserError{Field: "require_hardware_key_for_admins", Message: "requires security keys to be enabled"}
+	}
+	return nil
+}
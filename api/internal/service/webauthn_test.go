@@ -0,0 +1,311 @@
+package service
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/protocol/webauthncbor"
+	"github.com/go-webauthn/webauthn/webauthn"
+
+	"nginx-proxy-guard/internal/model"
+)
+
+const (
+	authDataUserPresent  = 0x01
+	authDataUserVerified = 0x04
+	authDataAttested     = 0x40
+)
+
+// softAuthenticator is an ES256 authenticator that answers ceremonies the
+// way a browser would pass them to the API
+type softAuthenticator struct {
+	t       *testing.T
+	key     *ecdsa.PrivateKey
+	id      []byte
+	counter uint32
+}
+
+func newSoftAuthenticator(t *testing.T) *softAuthenticator {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := make([]byte, 16)
+	rand.Read(id)
+	return &softAuthenticator{t: t, key: key, id: id}
+}
+
+func (a *softAuthenticator) clientData(kind, challenge, origin string) []byte {
+	data, _ := json.Marshal(map[string]string{"type": kind, "challenge": challenge, "origin": origin})
+	return data
+}
+
+func (a *softAuthenticator) authData(rpID string, flags byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(rpID))
+	data := append(rpIDHash[:], flags)
+	return binary.BigEndian.AppendUint32(data, a.counter)
+}
+
+func (a *softAuthenticator) register(session *webauthn.SessionData, rpID, origin string) []byte {
+	x := a.key.PublicKey.X.FillBytes(make([]byte, 32))
+	y := a.key.PublicKey.Y.FillBytes(make([]byte, 32))
+	coseKey, err := webauthncbor.Marshal(map[int]interface{}{1: 2, 3: -7, -1: 1, -2: x, -3: y})
+	if err != nil {
+		a.t.Fatal(err)
+	}
+
+	authData := a.authData(rpID, authDataUserPresent|authDataUserVerified|authDataAttested)
+	authData = append(authData, make([]byte, 16)...) // AAGUID
+	authData = binary.BigEndian.AppendUint16(authData, uint16(len(a.id)))
+	authData = append(authData, a.id...)
+	authData = append(authData, coseKey...)
+
+	attestation, err := webauthncbor.Marshal(map[string]interface{}{"fmt": "none", "attStmt": map[string]interface{}{}, "authData": authData})
+	if err != nil {
+		a.t.Fatal(err)
+	}
+	return a.response(map[string]interface{}{
+		"clientDataJSON":    a.clientData("webauthn.create", session.Challenge, origin),
+		"attestationObject": attestation,
+		"transports":        []string{"usb"},
+	})
+}
+
+func (a *softAuthenticator) assert(session *webauthn.SessionData, rpID, origin string, flags byte, userHandle []byte) []byte {
+	a.counter++
+	clientData := a.clientData("webauthn.get", session.Challenge, origin)
+	authData := a.authData(rpID, flags)
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	if err != nil {
+		a.t.Fatal(err)
+	}
+	return a.response(map[string]interface{}{
+		"clientDataJSON":    clientData,
+		"authenticatorData": authData,
+		"signature":         signature,
+		"userHandle":        userHandle,
+	})
+}
+
+func (a *softAuthenticator) response(response map[string]interface{}) []byte {
+	encoded := map[string]interface{}{}
+	for k, v := range response {
+		if b, ok := v.([]byte); ok {
+			v = base64.RawURLEncoding.EncodeToString(b)
+		}
+		encoded[k] = v
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"id":                      base64.RawURLEncoding.EncodeToString(a.id),
+		"rawId":                   base64.RawURLEncoding.EncodeToString(a.id),
+		"type":                    "public-key",
+		"authenticatorAttachment": "cross-platform",
+		"response":                encoded,
+	})
+	if err != nil {
+		a.t.Fatal(err)
+	}
+	return body
+}
+
+func TestWebAuthnCeremonies(t *testing.T) {
+	const origin = "https://npg.example.com"
+	rp, err := newRelyingParty(&model.WebAuthnSettings{Enabled: true}, origin)
+	if err != nil {
+		t.Fatalf("newRelyingParty() error = %v", err)
+	}
+	user := &webauthnUser{user: &model.User{ID: "7d1c4a52-0d7e-4d43-9c35-1f0f2b0c9a11", Username: "alice", Role: model.RoleAdmin}}
+	key := newSoftAuthenticator(t)
+
+	// Registration
+	_, session, err := rp.BeginRegistration(user)
+	if err != nil {
+		t.Fatalf("BeginRegistration() error = %v", err)
+	}
+	parsed, err := protocol.ParseCredentialCreationResponseBody(bytes.NewReader(key.register(session, "npg.example.com", origin)))
+	if err != nil {
+		t.Fatalf("ParseCredentialCreationResponseBody() error = %v", protocolErrorDetail(err))
+	}
+	created, err := rp.CreateCredential(user, *session, parsed)
+	if err != nil {
+		t.Fatalf("CreateCredential() error = %v", protocolErrorDetail(err))
+	}
+	credential := newWebAuthnCredential(user.user.ID, "YubiKey", created)
+	if !bytes.Equal(credential.CredentialID, key.id) || credential.Attachment != "cross-platform" || len(credential.Transports) != 1 {
+		t.Fatalf("credential = %+v", credential)
+	}
+	// Without attestation the key can't prove it is hardware
+	if credential.HardwareKey {
+		t.Error("unattested credential counted as hardware key")
+	}
+	user.credentials = append(user.credentials, *credential)
+
+	// Second factor login
+	_, session, err = rp.BeginLogin(user)
+	if err != nil {
+		t.Fatalf("BeginLogin() error = %v", err)
+	}
+	parsedAssertion, err := protocol.ParseCredentialRequestResponseBody(bytes.NewReader(
+		key.assert(session, "npg.example.com", origin, authDataUserPresent, nil)))
+	if err != nil {
+		t.Fatalf("ParseCredentialRequestResponseBody() error = %v", protocolErrorDetail(err))
+	}
+	used, err := rp.ValidateLogin(user, *session, parsedAssertion)
+	if err != nil {
+		t.Fatalf("ValidateLogin() error = %v", protocolErrorDetail(err))
+	}
+	if used.Authenticator.SignCount != 1 || user.find(used.ID) == nil {
+		t.Errorf("used credential = %+v", used)
+	}
+
+	// Answers from another origin are rejected
+	_, session, _ = rp.BeginLogin(user)
+	parsedAssertion, err = protocol.ParseCredentialRequestResponseBody(bytes.NewReader(
+		key.assert(session, "npg.example.com", "https://evil.example.net", authDataUserPresent, nil)))
+	if err == nil {
+		_, err = rp.ValidateLogin(user, *session, parsedAssertion)
+	}
+	if err == nil {
+		t.Error("assertion from another origin accepted")
+	}
+
+	// Passwordless login needs user verification and resolves the user handle
+	resolve := func(_, userHandle []byte) (webauthn.User, error) {
+		if string(userHandle) != user.user.ID {
+			return nil, errors.New("unknown user")
+		}
+		return user, nil
+	}
+	_, session, err = rp.BeginDiscoverableLogin(webauthn.WithUserVerification(protocol.VerificationRequired))
+	if err != nil {
+		t.Fatalf("BeginDiscoverableLogin() error = %v", err)
+	}
+	parsedAssertion, err = protocol.ParseCredentialRequestResponseBody(bytes.NewReader(
+		key.assert(session, "npg.example.com", origin, authDataUserPresent, []byte(user.user.ID))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rp.ValidateDiscoverableLogin(resolve, *session, parsedAssertion); err == nil {
+		t.Error("passkey login without user verification accepted")
+	}
+
+	_, session, _ = rp.BeginDiscoverableLogin(webauthn.WithUserVerification(protocol.VerificationRequired))
+	parsedAssertion, err = protocol.ParseCredentialRequestResponseBody(bytes.NewReader(
+		key.assert(session, "npg.example.com", origin, authDataUserPresent|authDataUserVerified, []byte(user.user.ID))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rp.ValidateDiscoverableLogin(resolve, *session, parsedAssertion); err != nil {
+		t.Errorf("ValidateDiscoverableLogin() error = %v", protocolErrorDetail(err))
+	}
+}
+
+func TestWebAuthnCeremonyIsSingleUse(t *testing.T) {
+	s := NewWebAuthnService(nil, nil, nil)
+	id, err := s.startCeremony(&webauthnCeremony{kind: webauthnCeremonyLogin, userID: "u1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.takeCeremony(id, webauthnCeremonyRegister); !errors.Is(err, ErrInvalidWebAuthnCeremony) {
+		t.Errorf("ceremony of another kind error = %v", err)
+	}
+	if _, err := s.takeCeremony(id, webauthnCeremonyLogin); err != nil {
+		t.Errorf("takeCeremony() error = %v", err)
+	}
+	if _, err := s.takeCeremony(id, webauthnCeremonyLogin); !errors.Is(err, ErrInvalidWebAuthnCeremony) {
+		t.Errorf("replayed ceremony error = %v", err)
+	}
+}
+
+func TestNewRelyingParty(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings model.WebAuthnSettings
+		origin   string
+		rpID     string
+		ok       bool
+	}{
+		{"host of the origin", model.WebAuthnSettings{}, "https://npg.example.com:8443", "npg.example.com", true},
+		{"subdomain of the rp id", model.WebAuthnSettings{RPID: "example.com"}, "https://npg.example.com", "example.com", true},
+		{"outside the rp id", model.WebAuthnSettings{RPID: "example.com"}, "https://npg.example.net", "", false},
+		{"suffix without dot", model.WebAuthnSettings{RPID: "example.com"}, "https://badexample.com", "", false},
+		{"configured origins", model.WebAuthnSettings{RPID: "example.com", RPOrigins: []string{"https://npg.example.com"}}, "https://other.test", "example.com", true},
+		{"no origin", model.WebAuthnSettings{}, "", "", false},
+	}
+	for _, tt := range tests {
+		rp, err := newRelyingParty(&tt.settings, tt.origin)
+		switch {
+		case tt.ok && err != nil:
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+		case !tt.ok && !errors.Is(err, ErrWebAuthnOrigin):
+			t.Errorf("%s: error = %v, want ErrWebAuthnOrigin", tt.name, err)
+		case tt.ok && rp.Config.RPID != tt.rpID:
+			t.Errorf("%s: RP ID = %s, want %s", tt.name, rp.Config.RPID, tt.rpID)
+		}
+	}
+}
+
+func TestNormalizeWebAuthnSettings(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings model.WebAuthnSettings
+		field    string
+	}{
+		{"defaults", model.WebAuthnSettings{Enabled: true}, ""},
+		{"rp id with scheme", model.WebAuthnSettings{RPID: "https://example.com"}, "rp_id"},
+		{"rp id with port", model.WebAuthnSettings{RPID: "example.com:443"}, "rp_id"},
+		{"rp id ip address", model.WebAuthnSettings{RPID: "192.0.2.1"}, "rp_id"},
+		{"origins without rp id", model.WebAuthnSettings{RPOrigins: []string{"https://example.com"}}, "rp_origins"},
+		{"origin with path", model.WebAuthnSettings{RPID: "example.com", RPOrigins: []string{"https://example.com/admin"}}, "rp_origins"},
+		{"origin outside rp id", model.WebAuthnSettings{RPID: "example.com", RPOrigins: []string{"https://example.net"}}, "rp_origins"},
+		{"hardware keys while disabled", model.WebAuthnSettings{RequireHardwareKeyForAdmins: true}, "require_hardware_key_for_admins"},
+	}
+	for _, tt := range tests {
+		err := normalizeWebAuthnSettings(&tt.settings)
+		var uerr *UserError
+		switch {
+		case tt.field == "" && err != nil:
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+		case tt.field != "" && (!errors.As(err, &uerr) || uerr.Field != tt.field):
+			t.Errorf("%s: error = %v, want invalid %s", tt.name, err, tt.field)
+		}
+	}
+
+	settings := model.WebAuthnSettings{RPID: " Example.COM ", RPOrigins: []string{"https://NPG.example.com/", " "}}
+	if err := normalizeWebAuthnSettings(&settings); err != nil {
+		t.Fatal(err)
+	}
+	if settings.RPID != "example.com" || len(settings.RPOrigins) != 1 || settings.RPOrigins[0] != "https://npg.example.com" || settings.RPName != defaultWebAuthnRPName {
+		t.Errorf("normalized to %+v", settings)
+	}
+}
+
+func TestWebAuthnCredentialHardwareKey(t *testing.T) {
+	tests := []struct {
+		credential model.WebAuthnCredential
+		want       bool
+	}{
+		{model.WebAuthnCredential{AttestationType: "packed", Attachment: "cross-platform"}, true},
+		{model.WebAuthnCredential{AttestationType: "fido-u2f"}, true},
+		{model.WebAuthnCredential{AttestationType: "none", Attachment: "cross-platform"}, false},
+		{model.WebAuthnCredential{AttestationType: "packed", Attachment: "cross-platform", BackupEligible: true}, false},
+		{model.WebAuthnCredential{AttestationType: "tpm", Attachment: "platform"}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.credential.IsHardwareKey(); got != tt.want {
+			t.Errorf("IsHardwareKey(%+v) = %v, want %v", tt.credential, got, tt.want)
+		}
+	}
+}